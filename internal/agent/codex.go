@@ -56,7 +56,7 @@ A separate cloister guardian process acts as your http proxy and gatekeeper for
 
 ## Network Access
 
-All external web traffic routes through an allowlist proxy configured via HTTP_PROXY and HTTPS_PROXY. Common package registries (npm, PyPI, crates.io, proxy.golang.org, etc.) and documentation sites are pre-allowed. Requests to unlisted domains are either rejected with a 403 error or held for human approval, depending on the user's configuration. There is no direct internet access - if a fetch fails with 403, the domain is not on the allowlist.
+All external web traffic routes through an allowlist proxy configured via HTTP_PROXY and HTTPS_PROXY. Common package registries (npm, PyPI, crates.io, proxy.golang.org, etc.) and documentation sites are pre-allowed. Requests to unlisted domains are either rejected with a 403 error or held for human approval, depending on the user's configuration. There is no direct internet access - if a fetch fails with 403, the domain is not on the allowlist. If a human denied the domain, the 403 response body of a plain HTTP request may include "Message from approver: <message>"; follow that guidance. HTTPS clients usually show only the failed CONNECT, not the body.
 
 Non-HTTP protocols (e.g., git://, ssh://) are not supported.
 
@@ -89,8 +89,11 @@ Check the result by examining the exit code and output:
 - Exit code 0 with output: command was approved and executed successfully; hostexec stdout/stderr/exit-code are relayed from the host command
 - Exit code non-zero with output: command was approved but failed (check stderr)
 - "Command denied: <reason>": the human denied the request or the command pattern was not allowed
+- "Command denied. Message from approver: <message>": the human denied the request and left you guidance; follow it
 - "Command timed out waiting for approval": no response within the timeout period
 
+If the CLOISTER_MESSAGES_FILE environment variable is set, hostexec appends the approver message from each denied hostexec command to that file. Read it before retrying work that was denied earlier. Domain denial messages are not written there.
+
 **IMPORTANT**: If the command is denied or times out, do not retry it. Instead, find a workaround if you can or else inform the user and ask for further instructions.
 
 For example, if 'hostexec git push' is denied, then the user doesn't want you to push changes to the remote. You should respect that decision and not attempt to push again.
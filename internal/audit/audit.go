@@ -1,14 +1,18 @@
 // Package audit provides structured logging for hostexec events.
-// Log entries follow a key=value format suitable for parsing and analysis.
+// Log entries follow a key=value format suitable for parsing and analysis,
+// or optionally a versioned JSON Lines schema for log pipelines.
 package audit
 
 import (
+	"crypto/ed25519"
 	"fmt"
 	"io"
 	"strconv"
 	"strings"
 	"sync"
 	"time"
+
+	"github.com/xdg/cloister/internal/clog"
 )
 
 // EventType represents the type of hostexec or domain event.
@@ -22,6 +26,7 @@ const (
 	EventDeny        EventType = "DENY"
 	EventComplete    EventType = "COMPLETE"
 	EventTimeout     EventType = "TIMEOUT"
+	EventRedact      EventType = "REDACT"
 )
 
 // Event types for domain approval operations.
@@ -34,6 +39,14 @@ const (
 
 // Event represents a hostexec or domain approval audit log entry.
 type Event struct {
+	// ID uniquely identifies the event. Logger.Log generates one if unset.
+	ID string
+
+	// RequestID is shared by the events for one hostexec or domain request
+	// (request, decision, completion). It matches the ID shown in the
+	// approval UI for requests that were queued there.
+	RequestID string
+
 	// Timestamp is when the event occurred.
 	Timestamp time.Time
 
@@ -58,7 +71,7 @@ type Event struct {
 	// Pattern is the matched pattern (for AUTO_APPROVE events).
 	Pattern string
 
-	// User is the user who approved/denied (for APPROVE events).
+	// User is the user who approved/denied (for APPROVE and manual DENY events).
 	User string
 
 	// Reason is the denial reason (for DENY events).
@@ -69,6 +82,12 @@ type Event struct {
 
 	// Duration is the execution time (for COMPLETE events).
 	Duration time.Duration
+
+	// Redactions is the number of secrets masked in output (for REDACT events).
+	Redactions int
+
+	// Detectors lists the detectors that matched, comma-separated (for REDACT events).
+	Detectors string
 }
 
 // Format returns the log entry as a formatted string.
@@ -120,18 +139,24 @@ func (e *Event) formatTypeSpecificFields(b *strings.Builder) {
 	case EventApprove:
 		writeOptionalField(b, "user", e.User)
 	case EventDeny:
+		writeOptionalField(b, "user", e.User)
 		writeOptionalField(b, "reason", e.Reason)
 	case EventComplete:
 		b.WriteString(" exit=")
 		b.WriteString(strconv.Itoa(e.ExitCode))
 		b.WriteString(" duration=")
 		b.WriteString(formatDuration(e.Duration))
+	case EventRedact:
+		b.WriteString(" count=")
+		b.WriteString(strconv.Itoa(e.Redactions))
+		writeOptionalField(b, "detectors", e.Detectors)
 	case EventDomainApprove:
 		writeOptionalField(b, "scope", e.Scope)
 		writeOptionalField(b, "user", e.User)
 	case EventDomainDeny:
 		writeOptionalField(b, "scope", e.Scope)
 		writeOptionalField(b, "pattern", e.Pattern)
+		writeOptionalField(b, "user", e.User)
 		writeOptionalField(b, "reason", e.Reason)
 	}
 }
@@ -164,37 +189,218 @@ func formatDuration(d time.Duration) string {
 	return d.Round(time.Second).String()
 }
 
+// Observer receives every event passed to a Logger, in order, whether or not
+// the logger has a writer. Observe is called with the logger's lock held and
+// must not block.
+type Observer interface {
+	Observe(e *Event)
+}
+
+// rotator is implemented by writers that move the log aside and start a new
+// file, such as clog.RotatingFile. When records are sealed, Logger rotates
+// such writers itself so that each new file starts with an EventRotate
+// record.
+type rotator interface {
+	RotateDue(n int) bool
+	Rotate() error
+	SetManualRotation(manual bool)
+}
+
+// maxSealLen bounds the length Chain.Seal adds to a record.
+const maxSealLen = 260
+
 // Logger writes audit events to an io.Writer.
 type Logger struct {
-	mu sync.Mutex
-	w  io.Writer
+	mu        sync.Mutex
+	w         io.Writer
+	format    Format
+	chain     *Chain
+	observers []Observer
+
+	failures  int   // Events that could not be written
+	lastError error // Error writing the most recent of those
 }
 
-// NewLogger creates a new audit logger that writes to the given writer.
+// NewLogger creates a new audit logger that writes to the given writer in
+// FormatText. A nil writer disables writing; observers still receive events.
 func NewLogger(w io.Writer) *Logger {
-	return &Logger{w: w}
+	return &Logger{w: w, format: FormatText}
+}
+
+// SetFormat sets the format of subsequently written events.
+func (l *Logger) SetFormat(f Format) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.format = f
+}
+
+// SetChain seals subsequently written records with c. See Chain.
+func (l *Logger) SetChain(c *Chain) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.chain = c
+	if r, ok := l.w.(rotator); ok {
+		r.SetManualRotation(c != nil)
+	}
 }
 
-// Log writes an event to the audit log.
+// AddObserver registers an observer for subsequent events.
+func (l *Logger) AddObserver(o Observer) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.observers = append(l.observers, o)
+}
+
+// Log writes an event to the audit log and passes it to any observers,
+// assigning it an ID first if it has none.
 func (l *Logger) Log(e *Event) error {
-	if l == nil || l.w == nil {
+	if l == nil {
 		return nil
 	}
+	if e.ID == "" {
+		e.ID = NewID()
+	}
 
 	l.mu.Lock()
 	defer l.mu.Unlock()
 
-	line := e.Format() + "\n"
-	_, err := l.w.Write([]byte(line))
-	if err != nil {
+	for _, o := range l.observers {
+		o.Observe(e)
+	}
+	if err := l.write(e); err != nil {
+		l.failures++
+		l.lastError = err
+		return err
+	}
+	return nil
+}
+
+// WriteFailures returns how many events could not be written and the error
+// writing the most recent of them.
+func (l *Logger) WriteFailures() (int, error) {
+	if l == nil {
+		return 0, nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.failures, l.lastError
+}
+
+// Writing returns whether events are written anywhere, as opposed to only
+// being passed to observers.
+func (l *Logger) Writing() bool {
+	if l == nil {
+		return false
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.w != nil
+}
+
+// write writes one event. The caller must hold l.mu.
+func (l *Logger) write(e *Event) error {
+	if l.w == nil {
+		return nil
+	}
+
+	line := e.Line(l.format)
+	if l.chain != nil {
+		if r, ok := l.w.(rotator); ok && r.RotateDue(len(line)+maxSealLen) {
+			if err := l.rotate(r); err != nil {
+				clog.Warn("%v", err)
+			}
+		}
+		line = l.chain.Seal(line)
+	}
+	if _, err := l.w.Write([]byte(line + "\n")); err != nil {
 		return fmt.Errorf("write audit event: %w", err)
 	}
+	if l.chain != nil && l.chain.checkpointDue() {
+		return l.writeCheckpoint()
+	}
 	return nil
 }
 
+// PublicKey returns the key that verifies records sealed by the logger's
+// chain, or nil if records are not sealed.
+func (l *Logger) PublicKey() ed25519.PublicKey {
+	if l == nil {
+		return nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.chain == nil {
+		return nil
+	}
+	return l.chain.key.Public().(ed25519.PublicKey)
+}
+
+// Checkpoint writes a checkpoint record if records are sealed with a Chain
+// and any were written since the last checkpoint. The guardian calls it on
+// shutdown so that the checkpoint file covers the whole log.
+func (l *Logger) Checkpoint() error {
+	if l == nil {
+		return nil
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.w == nil || l.chain == nil || l.chain.since == 0 {
+		return nil
+	}
+	return l.writeCheckpoint()
+}
+
+// Close closes the logger's writer if it is an io.Closer, which for a
+// rotating file also waits for rotated files to be compressed. Later events
+// only go to observers. Close on a nil Logger does nothing.
+func (l *Logger) Close() error {
+	if l == nil {
+		return nil
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	closer, ok := l.w.(io.Closer)
+	l.w = nil
+	if !ok {
+		return nil
+	}
+	if err := closer.Close(); err != nil {
+		return fmt.Errorf("close audit log: %w", err)
+	}
+	return nil
+}
+
+// rotate starts a new log file and writes the record that links it to the
+// previous one. The caller must hold l.mu. If the file cannot be rotated,
+// records continue in the current file.
+func (l *Logger) rotate(r rotator) error {
+	if err := r.Rotate(); err != nil {
+		return fmt.Errorf("rotate audit log: %w", err)
+	}
+	line := l.chain.Rotated(l.format)
+	if _, err := l.w.Write([]byte(line + "\n")); err != nil {
+		return fmt.Errorf("write audit rotation: %w", err)
+	}
+	return l.chain.SaveCheckpoint(line)
+}
+
+// writeCheckpoint writes a checkpoint record. The caller must hold l.mu.
+func (l *Logger) writeCheckpoint() error {
+	line := l.chain.Checkpoint(l.format)
+	if _, err := l.w.Write([]byte(line + "\n")); err != nil {
+		return fmt.Errorf("write audit checkpoint: %w", err)
+	}
+	return l.chain.SaveCheckpoint(line)
+}
+
 // LogRequest logs a HOSTEXEC REQUEST event.
-func (l *Logger) LogRequest(project, cloister, cmd string) error {
+func (l *Logger) LogRequest(requestID, project, cloister, cmd string) error {
 	return l.Log(&Event{
+		RequestID: requestID,
 		Timestamp: time.Now(),
 		Type:      EventRequest,
 		Project:   project,
@@ -204,8 +410,9 @@ func (l *Logger) LogRequest(project, cloister, cmd string) error {
 }
 
 // LogAutoApprove logs a HOSTEXEC AUTO_APPROVE event.
-func (l *Logger) LogAutoApprove(project, cloister, cmd, pattern string) error {
+func (l *Logger) LogAutoApprove(requestID, project, cloister, cmd, pattern string) error {
 	return l.Log(&Event{
+		RequestID: requestID,
 		Timestamp: time.Now(),
 		Type:      EventAutoApprove,
 		Project:   project,
@@ -216,8 +423,9 @@ func (l *Logger) LogAutoApprove(project, cloister, cmd, pattern string) error {
 }
 
 // LogApprove logs a HOSTEXEC APPROVE event.
-func (l *Logger) LogApprove(project, cloister, cmd, user string) error {
+func (l *Logger) LogApprove(requestID, project, cloister, cmd, user string) error {
 	return l.Log(&Event{
+		RequestID: requestID,
 		Timestamp: time.Now(),
 		Type:      EventApprove,
 		Project:   project,
@@ -228,20 +436,36 @@ func (l *Logger) LogApprove(project, cloister, cmd, user string) error {
 }
 
 // LogDeny logs a HOSTEXEC DENY event.
-func (l *Logger) LogDeny(project, cloister, cmd, reason string) error {
+func (l *Logger) LogDeny(requestID, project, cloister, cmd, reason string) error {
+	return l.Log(&Event{
+		RequestID: requestID,
+		Timestamp: time.Now(),
+		Type:      EventDeny,
+		Project:   project,
+		Cloister:  cloister,
+		Cmd:       cmd,
+		Reason:    reason,
+	})
+}
+
+// LogDenyBy logs a HOSTEXEC DENY event for a request denied by a person.
+func (l *Logger) LogDenyBy(requestID, project, cloister, cmd, user, reason string) error {
 	return l.Log(&Event{
+		RequestID: requestID,
 		Timestamp: time.Now(),
 		Type:      EventDeny,
 		Project:   project,
 		Cloister:  cloister,
 		Cmd:       cmd,
+		User:      user,
 		Reason:    reason,
 	})
 }
 
 // LogComplete logs a HOSTEXEC COMPLETE event.
-func (l *Logger) LogComplete(project, cloister, cmd string, exitCode int, duration time.Duration) error {
+func (l *Logger) LogComplete(requestID, project, cloister, cmd string, exitCode int, duration time.Duration) error {
 	return l.Log(&Event{
+		RequestID: requestID,
 		Timestamp: time.Now(),
 		Type:      EventComplete,
 		Project:   project,
@@ -252,9 +476,25 @@ func (l *Logger) LogComplete(project, cloister, cmd string, exitCode int, durati
 	})
 }
 
+// LogRedact logs a HOSTEXEC REDACT event recording that secrets were masked
+// in a command's output before it was returned to the cloister.
+func (l *Logger) LogRedact(requestID, project, cloister, cmd string, count int, detectors []string) error {
+	return l.Log(&Event{
+		RequestID:  requestID,
+		Timestamp:  time.Now(),
+		Type:       EventRedact,
+		Project:    project,
+		Cloister:   cloister,
+		Cmd:        cmd,
+		Redactions: count,
+		Detectors:  strings.Join(detectors, ","),
+	})
+}
+
 // LogTimeout logs a HOSTEXEC TIMEOUT event.
-func (l *Logger) LogTimeout(project, cloister, cmd string) error {
+func (l *Logger) LogTimeout(requestID, project, cloister, cmd string) error {
 	return l.Log(&Event{
+		RequestID: requestID,
 		Timestamp: time.Now(),
 		Type:      EventTimeout,
 		Project:   project,
@@ -264,8 +504,9 @@ func (l *Logger) LogTimeout(project, cloister, cmd string) error {
 }
 
 // LogDomainRequest logs a DOMAIN DOMAIN_REQUEST event.
-func (l *Logger) LogDomainRequest(project, cloister, domain string) error {
+func (l *Logger) LogDomainRequest(requestID, project, cloister, domain string) error {
 	return l.Log(&Event{
+		RequestID: requestID,
 		Timestamp: time.Now(),
 		Type:      EventDomainRequest,
 		Project:   project,
@@ -275,8 +516,9 @@ func (l *Logger) LogDomainRequest(project, cloister, domain string) error {
 }
 
 // LogDomainApprove logs a DOMAIN DOMAIN_APPROVE event.
-func (l *Logger) LogDomainApprove(project, cloister, domain, scope, actor string) error {
+func (l *Logger) LogDomainApprove(requestID, project, cloister, domain, scope, actor string) error {
 	return l.Log(&Event{
+		RequestID: requestID,
 		Timestamp: time.Now(),
 		Type:      EventDomainApprove,
 		Project:   project,
@@ -288,8 +530,9 @@ func (l *Logger) LogDomainApprove(project, cloister, domain, scope, actor string
 }
 
 // LogDomainDeny logs a DOMAIN DOMAIN_DENY event.
-func (l *Logger) LogDomainDeny(project, cloister, domain, reason string) error {
+func (l *Logger) LogDomainDeny(requestID, project, cloister, domain, reason string) error {
 	return l.Log(&Event{
+		RequestID: requestID,
 		Timestamp: time.Now(),
 		Type:      EventDomainDeny,
 		Project:   project,
@@ -299,10 +542,26 @@ func (l *Logger) LogDomainDeny(project, cloister, domain, reason string) error {
 	})
 }
 
+// LogDomainDenyBy logs a DOMAIN DOMAIN_DENY event for a request denied by a
+// person.
+func (l *Logger) LogDomainDenyBy(requestID, project, cloister, domain, actor, reason string) error {
+	return l.Log(&Event{
+		RequestID: requestID,
+		Timestamp: time.Now(),
+		Type:      EventDomainDeny,
+		Project:   project,
+		Cloister:  cloister,
+		Domain:    domain,
+		User:      actor,
+		Reason:    reason,
+	})
+}
+
 // LogDomainDenyWithScope logs a DOMAIN DOMAIN_DENY event with scope and pattern fields.
 // This is used by the domain approver to log processed denials with full context.
-func (l *Logger) LogDomainDenyWithScope(project, cloister, domain, scope, pattern string) error {
+func (l *Logger) LogDomainDenyWithScope(requestID, project, cloister, domain, scope, pattern string) error {
 	return l.Log(&Event{
+		RequestID: requestID,
 		Timestamp: time.Now(),
 		Type:      EventDomainDeny,
 		Project:   project,
@@ -314,8 +573,9 @@ func (l *Logger) LogDomainDenyWithScope(project, cloister, domain, scope, patter
 }
 
 // LogDomainTimeout logs a DOMAIN DOMAIN_TIMEOUT event.
-func (l *Logger) LogDomainTimeout(project, cloister, domain string) error {
+func (l *Logger) LogDomainTimeout(requestID, project, cloister, domain string) error {
 	return l.Log(&Event{
+		RequestID: requestID,
 		Timestamp: time.Now(),
 		Type:      EventDomainTimeout,
 		Project:   project,
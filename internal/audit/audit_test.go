@@ -2,6 +2,7 @@ package audit
 
 import (
 	"bytes"
+	"errors"
 	"regexp"
 	"strings"
 	"testing"
@@ -286,7 +287,7 @@ func TestLogger_LogRequest(t *testing.T) {
 	var buf bytes.Buffer
 	logger := NewLogger(&buf)
 
-	if err := logger.LogRequest("my-api", "my-api", "docker ps"); err != nil {
+	if err := logger.LogRequest("", "my-api", "my-api", "docker ps"); err != nil {
 		t.Fatalf("LogRequest() error = %v", err)
 	}
 
@@ -303,7 +304,7 @@ func TestLogger_LogAutoApprove(t *testing.T) {
 	var buf bytes.Buffer
 	logger := NewLogger(&buf)
 
-	if err := logger.LogAutoApprove("my-api", "my-api", "make test", "^make test$"); err != nil {
+	if err := logger.LogAutoApprove("", "my-api", "my-api", "make test", "^make test$"); err != nil {
 		t.Fatalf("LogAutoApprove() error = %v", err)
 	}
 
@@ -320,7 +321,7 @@ func TestLogger_LogApprove(t *testing.T) {
 	var buf bytes.Buffer
 	logger := NewLogger(&buf)
 
-	if err := logger.LogApprove("my-api", "my-api", "docker build .", "david"); err != nil {
+	if err := logger.LogApprove("", "my-api", "my-api", "docker build .", "david"); err != nil {
 		t.Fatalf("LogApprove() error = %v", err)
 	}
 
@@ -337,7 +338,7 @@ func TestLogger_LogDeny(t *testing.T) {
 	var buf bytes.Buffer
 	logger := NewLogger(&buf)
 
-	if err := logger.LogDeny("my-api", "my-api", "rm -rf /", "command not allowed"); err != nil {
+	if err := logger.LogDeny("", "my-api", "my-api", "rm -rf /", "command not allowed"); err != nil {
 		t.Fatalf("LogDeny() error = %v", err)
 	}
 
@@ -354,7 +355,7 @@ func TestLogger_LogComplete(t *testing.T) {
 	var buf bytes.Buffer
 	logger := NewLogger(&buf)
 
-	if err := logger.LogComplete("my-api", "my-api", "make build", 0, 5*time.Second); err != nil {
+	if err := logger.LogComplete("", "my-api", "my-api", "make build", 0, 5*time.Second); err != nil {
 		t.Fatalf("LogComplete() error = %v", err)
 	}
 
@@ -370,11 +371,47 @@ func TestLogger_LogComplete(t *testing.T) {
 	}
 }
 
+func TestEventFormat_Redact(t *testing.T) {
+	e := &Event{
+		Timestamp:  testTime,
+		Type:       EventRedact,
+		Project:    "my-api",
+		Cloister:   "my-api",
+		Cmd:        "gh auth status -t",
+		Redactions: 2,
+		Detectors:  "github_token,jwt",
+	}
+
+	got := e.Format()
+	want := `2024-01-15T14:32:05Z HOSTEXEC REDACT project=my-api cloister=my-api cmd="gh auth status -t" count=2 detectors="github_token,jwt"`
+
+	if got != want {
+		t.Errorf("Format() =\n  got:  %q\n  want: %q", got, want)
+	}
+}
+
+func TestLogger_LogRedact(t *testing.T) {
+	var buf bytes.Buffer
+	logger := NewLogger(&buf)
+
+	if err := logger.LogRedact("", "my-api", "my-api", "env", 3, []string{"aws_access_key", "openai_key"}); err != nil {
+		t.Fatalf("LogRedact() error = %v", err)
+	}
+
+	got := buf.String()
+	if !strings.Contains(got, "HOSTEXEC REDACT") {
+		t.Errorf("LogRedact() should contain 'HOSTEXEC REDACT': %s", got)
+	}
+	if !strings.Contains(got, `count=3 detectors="aws_access_key,openai_key"`) {
+		t.Errorf("LogRedact() should contain count and detectors: %s", got)
+	}
+}
+
 func TestLogger_LogTimeout(t *testing.T) {
 	var buf bytes.Buffer
 	logger := NewLogger(&buf)
 
-	if err := logger.LogTimeout("my-api", "my-api", "long-running-cmd"); err != nil {
+	if err := logger.LogTimeout("", "my-api", "my-api", "long-running-cmd"); err != nil {
 		t.Fatalf("LogTimeout() error = %v", err)
 	}
 
@@ -524,6 +561,7 @@ func TestEventTypeConstants(t *testing.T) {
 		{EventDeny, "DENY"},
 		{EventComplete, "COMPLETE"},
 		{EventTimeout, "TIMEOUT"},
+		{EventRedact, "REDACT"},
 		{EventDomainRequest, "DOMAIN_REQUEST"},
 		{EventDomainApprove, "DOMAIN_APPROVE"},
 		{EventDomainDeny, "DOMAIN_DENY"},
@@ -728,7 +766,7 @@ func TestLogger_LogDomainRequest(t *testing.T) {
 	var buf bytes.Buffer
 	logger := NewLogger(&buf)
 
-	if err := logger.LogDomainRequest("my-api", "my-api-main", "api.example.com"); err != nil {
+	if err := logger.LogDomainRequest("", "my-api", "my-api-main", "api.example.com"); err != nil {
 		t.Fatalf("LogDomainRequest() error = %v", err)
 	}
 
@@ -755,7 +793,7 @@ func TestLogger_LogDomainApprove(t *testing.T) {
 	var buf bytes.Buffer
 	logger := NewLogger(&buf)
 
-	if err := logger.LogDomainApprove("my-api", "my-api-main", "api.example.com", "project", "user"); err != nil {
+	if err := logger.LogDomainApprove("", "my-api", "my-api-main", "api.example.com", "project", "user"); err != nil {
 		t.Fatalf("LogDomainApprove() error = %v", err)
 	}
 
@@ -778,7 +816,7 @@ func TestLogger_LogDomainApprove_SessionScope(t *testing.T) {
 	var buf bytes.Buffer
 	logger := NewLogger(&buf)
 
-	if err := logger.LogDomainApprove("my-api", "my-api-main", "cdn.example.com", "session", "user"); err != nil {
+	if err := logger.LogDomainApprove("", "my-api", "my-api-main", "cdn.example.com", "session", "user"); err != nil {
 		t.Fatalf("LogDomainApprove() error = %v", err)
 	}
 
@@ -792,7 +830,7 @@ func TestLogger_LogDomainApprove_GlobalScope(t *testing.T) {
 	var buf bytes.Buffer
 	logger := NewLogger(&buf)
 
-	if err := logger.LogDomainApprove("my-api", "my-api-main", "docs.example.com", "global", "user"); err != nil {
+	if err := logger.LogDomainApprove("", "my-api", "my-api-main", "docs.example.com", "global", "user"); err != nil {
 		t.Fatalf("LogDomainApprove() error = %v", err)
 	}
 
@@ -806,7 +844,7 @@ func TestLogger_LogDomainDeny(t *testing.T) {
 	var buf bytes.Buffer
 	logger := NewLogger(&buf)
 
-	if err := logger.LogDomainDeny("my-api", "my-api-main", "malicious.example.com", "Denied by user"); err != nil {
+	if err := logger.LogDomainDeny("", "my-api", "my-api-main", "malicious.example.com", "Denied by user"); err != nil {
 		t.Fatalf("LogDomainDeny() error = %v", err)
 	}
 
@@ -826,7 +864,7 @@ func TestLogger_LogDomainDenyWithScope(t *testing.T) {
 	var buf bytes.Buffer
 	logger := NewLogger(&buf)
 
-	if err := logger.LogDomainDenyWithScope("my-api", "my-api-main", "api.evil.example.com", "project", "*.evil.example.com"); err != nil {
+	if err := logger.LogDomainDenyWithScope("", "my-api", "my-api-main", "api.evil.example.com", "project", "*.evil.example.com"); err != nil {
 		t.Fatalf("LogDomainDenyWithScope() error = %v", err)
 	}
 
@@ -853,7 +891,7 @@ func TestLogger_LogDomainDenyWithScope_NoPattern(t *testing.T) {
 	var buf bytes.Buffer
 	logger := NewLogger(&buf)
 
-	if err := logger.LogDomainDenyWithScope("my-api", "my-api-main", "evil.example.com", "session", ""); err != nil {
+	if err := logger.LogDomainDenyWithScope("", "my-api", "my-api-main", "evil.example.com", "session", ""); err != nil {
 		t.Fatalf("LogDomainDenyWithScope() error = %v", err)
 	}
 
@@ -870,7 +908,7 @@ func TestLogger_LogDomainTimeout(t *testing.T) {
 	var buf bytes.Buffer
 	logger := NewLogger(&buf)
 
-	if err := logger.LogDomainTimeout("my-api", "my-api-main", "slow.example.com"); err != nil {
+	if err := logger.LogDomainTimeout("", "my-api", "my-api-main", "slow.example.com"); err != nil {
 		t.Fatalf("LogDomainTimeout() error = %v", err)
 	}
 
@@ -888,8 +926,8 @@ func TestLogger_LogMultipleDomainEvents(t *testing.T) {
 	logger := NewLogger(&buf)
 
 	// Simulate a typical domain approval workflow
-	_ = logger.LogDomainRequest("my-api", "my-api-main", "api.example.com")
-	_ = logger.LogDomainApprove("my-api", "my-api-main", "api.example.com", "project", "user")
+	_ = logger.LogDomainRequest("", "my-api", "my-api-main", "api.example.com")
+	_ = logger.LogDomainApprove("", "my-api", "my-api-main", "api.example.com", "project", "user")
 
 	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
 	if len(lines) != 2 {
@@ -904,3 +942,79 @@ func TestLogger_LogMultipleDomainEvents(t *testing.T) {
 		}
 	}
 }
+
+func TestLogger_LogDenyBy(t *testing.T) {
+	var buf bytes.Buffer
+	logger := NewLogger(&buf)
+
+	if err := logger.LogDenyBy("", "my-api", "my-api", "rm -rf /", "david", "too risky"); err != nil {
+		t.Fatalf("LogDenyBy() error = %v", err)
+	}
+
+	got := buf.String()
+	if !strings.Contains(got, `HOSTEXEC DENY project=my-api cloister=my-api cmd="rm -rf /" user="david" reason="too risky"`) {
+		t.Errorf("LogDenyBy() = %s", got)
+	}
+}
+
+func TestLogger_LogDomainDenyBy(t *testing.T) {
+	var buf bytes.Buffer
+	logger := NewLogger(&buf)
+
+	if err := logger.LogDomainDenyBy("", "my-api", "my-api", "evil.com", "david", "Denied by david"); err != nil {
+		t.Fatalf("LogDomainDenyBy() error = %v", err)
+	}
+
+	got := buf.String()
+	if !strings.Contains(got, `DOMAIN DOMAIN_DENY project=my-api cloister=my-api domain="evil.com" user="david" reason="Denied by david"`) {
+		t.Errorf("LogDomainDenyBy() = %s", got)
+	}
+}
+
+// recordingObserver collects observed event types.
+type recordingObserver struct {
+	types []EventType
+}
+
+func (r *recordingObserver) Observe(e *Event) {
+	r.types = append(r.types, e.Type)
+}
+
+func TestLogger_Observers(t *testing.T) {
+	obs := &recordingObserver{}
+	logger := NewLogger(nil)
+	logger.AddObserver(obs)
+
+	_ = logger.LogRequest("", "p", "c", "ls")
+	_ = logger.LogComplete("", "p", "c", "ls", 0, time.Second)
+
+	if len(obs.types) != 2 || obs.types[0] != EventRequest || obs.types[1] != EventComplete {
+		t.Errorf("observed %v, want [REQUEST COMPLETE]", obs.types)
+	}
+}
+
+// failingWriter fails every write.
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) { return 0, errors.New("disk full") }
+
+func TestLogger_WriteFailures(t *testing.T) {
+	logger := NewLogger(failingWriter{})
+	if !logger.Writing() {
+		t.Error("Writing() = false with a writer")
+	}
+	if n, err := logger.WriteFailures(); n != 0 || err != nil {
+		t.Errorf("WriteFailures() = %d, %v before logging", n, err)
+	}
+
+	_ = logger.LogRequest("", "p", "c", "ls")
+	if err := logger.LogRequest("", "p", "c", "pwd"); err == nil {
+		t.Error("Log() did not return the write error")
+	}
+	if n, err := logger.WriteFailures(); n != 2 || err == nil || !strings.Contains(err.Error(), "disk full") {
+		t.Errorf("WriteFailures() = %d, %v; want 2, disk full", n, err)
+	}
+	if NewLogger(nil).Writing() {
+		t.Error("Writing() = true without a writer")
+	}
+}
@@ -0,0 +1,320 @@
+package audit
+
+import (
+	"bufio"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/xdg/cloister/internal/clog"
+)
+
+// DefaultCheckpointInterval is the number of records between checkpoint
+// records by default.
+const DefaultCheckpointInterval = 100
+
+// CategoryAudit is the category of records about the audit log itself, such
+// as checkpoints. They are not events and ParseEvent rejects them.
+const CategoryAudit = "audit"
+
+// Types of records about the audit log itself.
+const (
+	// EventCheckpoint records the chain's position so that truncation can
+	// be detected.
+	EventCheckpoint EventType = "CHECKPOINT"
+
+	// EventRotate starts each file after the log is rotated. It links to
+	// the last record of the previous file and also serves as a checkpoint.
+	EventRotate EventType = "ROTATE"
+)
+
+// genesis is the previous-record hash of the first record in a log.
+var genesis = strings.Repeat("0", 2*sha256.Size)
+
+// Seals appended to each record. The signature covers the record up to and
+// including prev; seq and prev link it to the record before.
+var (
+	textSeal = regexp.MustCompile(` seq=(\d+) prev="([0-9a-f]{64})" sig="([0-9a-f]{128})"$`)
+	jsonSeal = regexp.MustCompile(`,"seq":(\d+),"prev":"([0-9a-f]{64})","sig":"([0-9a-f]{128})"}$`)
+)
+
+// legacySeal matches records sealed by earlier versions with an HMAC, whose
+// key the host could read. They cannot be verified and count as unsealed.
+var legacySeal = regexp.MustCompile(`(?: mac="|,"mac":")[0-9a-f]{64}"}?$`)
+
+// GenerateChainKey returns a new random chain signing key.
+func GenerateChainKey() ed25519.PrivateKey {
+	_, key, _ := ed25519.GenerateKey(nil) // Never returns an error
+	return key
+}
+
+// CheckpointPath returns the path of the file holding the latest checkpoint
+// record for the audit log at logPath.
+func CheckpointPath(logPath string) string {
+	return logPath + ".checkpoint"
+}
+
+// Chain seals audit records into a tamper-evident chain. Each record gets a
+// sequence number, the SHA-256 hash of the previous line, and an Ed25519
+// signature over the record. Only the guardian holds the signing key;
+// verifying needs just the public key. Every interval records, a checkpoint
+// record is added to the log and copied to a side file so that truncation of
+// the log can be detected. A Chain is not safe for concurrent use; Logger
+// serializes access to it.
+type Chain struct {
+	key            ed25519.PrivateKey
+	seq            uint64
+	prev           string
+	interval       int
+	since          int
+	checkpointPath string
+}
+
+// NewChain creates a chain that starts a new log. Call Resume to continue
+// an existing one. checkpointPath is where the latest checkpoint record is
+// kept; "" disables the side file.
+func NewChain(key ed25519.PrivateKey, checkpointPath string) *Chain {
+	return &Chain{key: key, prev: genesis, interval: DefaultCheckpointInterval, checkpointPath: checkpointPath}
+}
+
+// SetCheckpointInterval sets the number of records between checkpoints. A
+// value of zero or less uses DefaultCheckpointInterval.
+func (c *Chain) SetCheckpointInterval(n int) {
+	if n <= 0 {
+		n = DefaultCheckpointInterval
+	}
+	c.interval = n
+}
+
+// Resume continues the chain from the last line of the log at path, or of
+// its latest rotated file if the log itself is empty. A missing or empty
+// log starts a new chain. If the last line is not sealed (it predates the
+// chain), the chain starts after it, and a checkpoint file left by an
+// HMAC-sealed chain from an earlier version is removed.
+func (c *Chain) Resume(path string) error {
+	last, err := lastLine(path)
+	if err != nil {
+		return err
+	}
+	if last == "" {
+		rotated, err := clog.RotatedFiles(path)
+		if err != nil {
+			return err
+		}
+		if len(rotated) > 0 {
+			if last, err = lastLine(rotated[len(rotated)-1]); err != nil {
+				return err
+			}
+		}
+	}
+	if last == "" {
+		return nil
+	}
+	if seq, _, ok := parseSeal(last); ok {
+		c.seq = seq
+	} else if err := c.removeLegacyCheckpoint(); err != nil {
+		return err
+	}
+	c.prev = hashLine(last)
+	return nil
+}
+
+// removeLegacyCheckpoint removes the checkpoint file if it holds an
+// HMAC-sealed record, which the new chain would never match.
+func (c *Chain) removeLegacyCheckpoint() error {
+	if c.checkpointPath == "" {
+		return nil
+	}
+	data, err := os.ReadFile(c.checkpointPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read checkpoint: %w", err)
+	}
+	if !legacySeal.MatchString(strings.TrimSpace(string(data))) {
+		return nil
+	}
+	if err := os.Remove(c.checkpointPath); err != nil {
+		return fmt.Errorf("failed to remove legacy checkpoint: %w", err)
+	}
+	return nil
+}
+
+// Seal returns line with its seal appended and advances the chain.
+func (c *Chain) Seal(line string) string {
+	c.seq++
+	var body string
+	if strings.HasPrefix(line, "{") {
+		body = fmt.Sprintf(`%s,"seq":%d,"prev":%q}`, strings.TrimSuffix(line, "}"), c.seq, c.prev)
+		body = fmt.Sprintf(`%s,"sig":%q}`, strings.TrimSuffix(body, "}"), c.sign(body))
+	} else {
+		body = fmt.Sprintf(`%s seq=%d prev=%q`, line, c.seq, c.prev)
+		body = fmt.Sprintf(`%s sig=%q`, body, c.sign(body))
+	}
+	c.prev = hashLine(body)
+	c.since++
+	return body
+}
+
+// checkpointDue reports whether a checkpoint record should be written.
+func (c *Chain) checkpointDue() bool {
+	return c.since >= c.interval
+}
+
+// Checkpoint returns a sealed checkpoint record in the given format. Once
+// it is written to the log, pass it to SaveCheckpoint.
+func (c *Chain) Checkpoint(f Format) string {
+	return c.checkpointRecord(EventCheckpoint, f)
+}
+
+// Rotated returns the sealed record that starts a new file after the log
+// is rotated. Like a checkpoint, pass it to SaveCheckpoint once written.
+func (c *Chain) Rotated(f Format) string {
+	return c.checkpointRecord(EventRotate, f)
+}
+
+// checkpointRecord returns a sealed record of type t about the log itself.
+func (c *Chain) checkpointRecord(t EventType, f Format) string {
+	now := time.Now().UTC()
+	var line string
+	if f == FormatJSONL {
+		b, _ := json.Marshal(struct {
+			V        int       `json:"v"`
+			ID       string    `json:"id"`
+			Time     string    `json:"time"`
+			Category string    `json:"category"`
+			Type     EventType `json:"type"`
+		}{SchemaVersion, NewID(), now.Format(time.RFC3339Nano), CategoryAudit, t})
+		line = string(b)
+	} else {
+		line = now.Format(time.RFC3339) + " AUDIT " + string(t)
+	}
+	line = c.Seal(line)
+	c.since = 0
+	return line
+}
+
+// SaveCheckpoint replaces the checkpoint file, if any, with a checkpoint
+// record returned by Checkpoint.
+func (c *Chain) SaveCheckpoint(line string) error {
+	if c.checkpointPath == "" {
+		return nil
+	}
+	return writeFileAtomic(c.checkpointPath, []byte(line+"\n"))
+}
+
+// sign returns the hex Ed25519 signature of body.
+func (c *Chain) sign(body string) string {
+	return hex.EncodeToString(ed25519.Sign(c.key, []byte(body)))
+}
+
+// validSignature reports whether sig is the hex Ed25519 signature of body
+// under pub.
+func validSignature(pub ed25519.PublicKey, body, sig string) bool {
+	raw, err := hex.DecodeString(sig)
+	return err == nil && len(pub) == ed25519.PublicKeySize && ed25519.Verify(pub, []byte(body), raw)
+}
+
+// hashLine returns the hex SHA-256 of a log line without its newline.
+func hashLine(line string) string {
+	sum := sha256.Sum256([]byte(line))
+	return hex.EncodeToString(sum[:])
+}
+
+// seal is the parsed seal of a record.
+type seal struct {
+	prev string
+	sig  string
+	body string // The record as covered by the signature
+}
+
+// parseSeal extracts the sequence number and seal from a sealed line.
+// ok is false if the line is not sealed.
+func parseSeal(line string) (seq uint64, s seal, ok bool) {
+	re, sigKey, closer := textSeal, ` sig="`, ""
+	if strings.HasPrefix(line, "{") {
+		re, sigKey, closer = jsonSeal, `,"sig":"`, "}"
+	}
+	m := re.FindStringSubmatchIndex(line)
+	if m == nil {
+		return 0, seal{}, false
+	}
+	seq, err := strconv.ParseUint(line[m[2]:m[3]], 10, 64)
+	if err != nil {
+		return 0, seal{}, false
+	}
+	return seq, seal{
+		prev: line[m[4]:m[5]],
+		sig:  line[m[6]:m[7]],
+		body: line[:m[6]-len(sigKey)] + closer,
+	}, true
+}
+
+// lastLine returns the last non-empty line of the file at path, or "" if the
+// file is missing or empty. Compressed rotated files are read in full.
+func lastLine(path string) (string, error) {
+	rc, err := clog.OpenRotated(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to open audit log: %w", err)
+	}
+	defer func() { _ = rc.Close() }()
+
+	if f, ok := rc.(*os.File); ok {
+		info, err := f.Stat()
+		if err != nil {
+			return "", fmt.Errorf("failed to stat audit log: %w", err)
+		}
+		if info.Size() > loadTailBytes {
+			if _, err := f.Seek(info.Size()-loadTailBytes, io.SeekStart); err != nil {
+				return "", fmt.Errorf("failed to seek audit log: %w", err)
+			}
+		}
+	}
+
+	var last string
+	scanner := bufio.NewScanner(rc)
+	scanner.Buffer(make([]byte, 64*1024), loadTailBytes)
+	for scanner.Scan() {
+		if line := scanner.Text(); line != "" {
+			last = line
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", fmt.Errorf("failed to read audit log: %w", err)
+	}
+	return last, nil
+}
+
+// writeFileAtomic replaces the file at path with data.
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp*")
+	if err != nil {
+		return fmt.Errorf("failed to write checkpoint: %w", err)
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("failed to write checkpoint: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write checkpoint: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to write checkpoint: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,166 @@
+package audit
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// sealedLog writes n REQUEST events through a chained logger and returns
+// the log's lines.
+func sealedLog(t *testing.T, key ed25519.PrivateKey, format Format, n int) []string {
+	t.Helper()
+	var buf bytes.Buffer
+	logger := NewLogger(&buf)
+	logger.SetFormat(format)
+	logger.SetChain(NewChain(key, ""))
+	for i := range n {
+		if err := logger.LogRequest("r", "p", "c", "cmd "+string(rune('a'+i))); err != nil {
+			t.Fatalf("LogRequest: %v", err)
+		}
+	}
+	return strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+}
+
+func TestChain_Seal(t *testing.T) {
+	key := GenerateChainKey()
+	for _, format := range []Format{FormatText, FormatJSONL} {
+		lines := sealedLog(t, key, format, 3)
+		if len(lines) != 3 {
+			t.Fatalf("%s: got %d lines, want 3", format, len(lines))
+		}
+
+		prev := genesis
+		for i, line := range lines {
+			seq, s, ok := parseSeal(line)
+			if !ok {
+				t.Fatalf("%s: line %d is not sealed: %s", format, i, line)
+			}
+			if seq != uint64(i+1) || s.prev != prev {
+				t.Errorf("%s: line %d seq = %d, prev = %s; want %d, %s", format, i, seq, s.prev, i+1, prev)
+			}
+			if !validSignature(publicKey(key), s.body, s.sig) {
+				t.Errorf("%s: line %d has a bad signature", format, i)
+			}
+			prev = hashLine(line)
+
+			// Sealed lines still parse as events.
+			e, err := ParseEvent(line)
+			if err != nil || e.Cmd != "cmd "+string(rune('a'+i)) {
+				t.Errorf("%s: ParseEvent(line %d) = %+v, %v", format, i, e, err)
+			}
+		}
+	}
+}
+
+func TestChain_Checkpoints(t *testing.T) {
+	dir := t.TempDir()
+	cpPath := filepath.Join(dir, "audit.log.checkpoint")
+	var buf bytes.Buffer
+	logger := NewLogger(&buf)
+	chain := NewChain(GenerateChainKey(), cpPath)
+	chain.SetCheckpointInterval(2)
+	logger.SetChain(chain)
+
+	for range 3 {
+		_ = logger.LogRequest("r", "p", "c", "ls")
+	}
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != 4 || !isCheckpoint(lines[2]) {
+		t.Fatalf("lines = %q, want a checkpoint after the second record", lines)
+	}
+	if _, err := ParseEvent(lines[2]); err == nil {
+		t.Error("ParseEvent() accepted a checkpoint record")
+	}
+	saved, err := os.ReadFile(cpPath)
+	if err != nil || strings.TrimSpace(string(saved)) != lines[2] {
+		t.Errorf("checkpoint file = %q, %v; want %q", saved, err, lines[2])
+	}
+
+	if err := logger.Checkpoint(); err != nil {
+		t.Fatalf("Checkpoint: %v", err)
+	}
+	if err := logger.Checkpoint(); err != nil {
+		t.Fatalf("Checkpoint: %v", err)
+	}
+	lines = strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != 5 || !isCheckpoint(lines[4]) {
+		t.Errorf("lines = %q, want one more checkpoint and none when nothing is new", lines)
+	}
+}
+
+func TestChain_Resume(t *testing.T) {
+	key := GenerateChainKey()
+	path := filepath.Join(t.TempDir(), "audit.log")
+	legacy := (&Event{Timestamp: time.Now(), Type: EventRequest, Project: "p", Cloister: "c", Cmd: "old"}).Format()
+	if err := os.WriteFile(path, []byte(legacy+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	for range 2 {
+		f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0o600)
+		if err != nil {
+			t.Fatal(err)
+		}
+		chain := NewChain(key, "")
+		if err := chain.Resume(path); err != nil {
+			t.Fatalf("Resume: %v", err)
+		}
+		logger := NewLogger(f)
+		logger.SetChain(chain)
+		_ = logger.LogRequest("r", "p", "c", "new")
+		_ = f.Close()
+	}
+
+	report, err := VerifyFile(path, publicKey(key))
+	if err != nil {
+		t.Fatalf("VerifyFile: %v", err)
+	}
+	if report.Break != nil || report.Unsealed != 1 || report.Records != 2 || report.LastSeq != 2 {
+		t.Errorf("report = %+v (break %+v), want 1 unsealed line then records 1 and 2", report, report.Break)
+	}
+}
+
+// publicKey returns the public half of a chain signing key.
+func publicKey(key ed25519.PrivateKey) ed25519.PublicKey {
+	return key.Public().(ed25519.PublicKey)
+}
+
+func TestChain_ResumeLegacyHMAC(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "audit.log")
+	old := `2024-01-15T14:32:05Z HOSTEXEC REQUEST project=p cloister=c cmd="old" seq=1 prev="` +
+		genesis + `" mac="` + strings.Repeat("a", 64) + `"`
+	if err := os.WriteFile(path, []byte(old+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(CheckpointPath(path), []byte(old+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	key := GenerateChainKey()
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	chain := NewChain(key, CheckpointPath(path))
+	if err := chain.Resume(path); err != nil {
+		t.Fatalf("Resume: %v", err)
+	}
+	logger := NewLogger(f)
+	logger.SetChain(chain)
+	_ = logger.LogRequest("r", "p", "c", "new")
+	_ = f.Close()
+
+	if _, err := os.Stat(CheckpointPath(path)); !os.IsNotExist(err) {
+		t.Errorf("legacy checkpoint file still present: %v", err)
+	}
+	report, err := VerifyFile(path, publicKey(key))
+	if err != nil || report.Break != nil || report.Unsealed != 1 || report.Records != 1 {
+		t.Errorf("VerifyFile = %+v, %v; want the HMAC-sealed line unsealed then one record", report, err)
+	}
+}
@@ -0,0 +1,96 @@
+package audit
+
+import (
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/xdg/cloister/internal/clog"
+)
+
+// CloisterLogs writes each audit event to a file named after its cloister,
+// <dir>/<cloister>.log, in the given format. Files are
+// opened on a cloister's first event and stay open until Close, and are
+// rotated as configured. It implements Observer.
+type CloisterLogs struct {
+	mu     sync.Mutex
+	dir    string
+	format Format
+	rotate clog.RotateConfig
+	files  map[string]*clog.RotatingFile
+}
+
+// NewCloisterLogs creates per-cloister logs under dir, written in format
+// and rotated according to rotate. The directory is created when the first
+// file is opened.
+func NewCloisterLogs(dir string, format Format, rotate clog.RotateConfig) *CloisterLogs {
+	return &CloisterLogs{dir: dir, format: format, rotate: rotate, files: make(map[string]*clog.RotatingFile)}
+}
+
+// Path returns the log file path for a cloister, or "" if the name cannot be
+// used as a file name.
+func (c *CloisterLogs) Path(cloister string) string {
+	if cloister == "" || cloister == "." || cloister == ".." || strings.ContainsAny(cloister, `/\`) {
+		return ""
+	}
+	return filepath.Join(c.dir, cloister+".log")
+}
+
+// Observe appends the event to its cloister's log file. Events without a
+// usable cloister name are skipped; they remain in the unified log.
+func (c *CloisterLogs) Observe(e *Event) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	f, ok := c.files[e.Cloister]
+	if !ok {
+		path := c.Path(e.Cloister)
+		if path == "" {
+			return
+		}
+		var err error
+		f, err = clog.OpenRotatingFile(path, c.rotate)
+		if err != nil {
+			clog.Warn("failed to open audit log for cloister %s: %v", e.Cloister, err)
+			return
+		}
+		c.files[e.Cloister] = f
+	}
+
+	if _, err := f.Write([]byte(e.Line(c.format) + "\n")); err != nil {
+		clog.Warn("failed to write audit log for cloister %s: %v", e.Cloister, err)
+	}
+}
+
+// Close closes a cloister's log file, if open. A later event for the same
+// cloister reopens it for appending. Close on a nil CloisterLogs does
+// nothing.
+func (c *CloisterLogs) Close(cloister string) {
+	if c == nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if f, ok := c.files[cloister]; ok {
+		_ = f.Close()
+		delete(c.files, cloister)
+	}
+}
+
+// CloseAll closes every open log file. CloseAll on a nil CloisterLogs does
+// nothing.
+func (c *CloisterLogs) CloseAll() {
+	if c == nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for name, f := range c.files {
+		_ = f.Close()
+		delete(c.files, name)
+	}
+}
@@ -0,0 +1,73 @@
+package audit
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/xdg/cloister/internal/clog"
+)
+
+func TestCloisterLogs_Observe(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "logs")
+	c := NewCloisterLogs(dir, FormatText, clog.RotateConfig{})
+	defer c.CloseAll()
+
+	c.Observe(&Event{Type: EventRequest, Project: "api", Cloister: "api-main", Cmd: "make test"})
+	c.Observe(&Event{Type: EventDomainRequest, Project: "web", Cloister: "web-dev", Domain: "example.com"})
+	c.Observe(&Event{Type: EventDeny, Project: "api", Cloister: "api-main", Cmd: "make test", Reason: "no"})
+	c.Observe(&Event{Type: EventRequest, Cloister: "../escape", Cmd: "ls"})
+	c.Observe(&Event{Type: EventRequest, Cmd: "ls"})
+
+	data, err := os.ReadFile(filepath.Join(dir, "api-main.log"))
+	if err != nil {
+		t.Fatalf("read api-main.log: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 2 || !strings.Contains(lines[0], "REQUEST") || !strings.Contains(lines[1], `reason="no"`) {
+		t.Errorf("api-main.log = %q, want its REQUEST and DENY events", lines)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "web-dev.log")); err != nil {
+		t.Errorf("web-dev.log: %v", err)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("read dir: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Errorf("log dir has %d files, want 2", len(entries))
+	}
+	if _, err := os.Stat(filepath.Join(filepath.Dir(dir), "escape.log")); err == nil {
+		t.Error("event with a path in its cloister name was written outside the log dir")
+	}
+}
+
+func TestCloisterLogs_CloseReopens(t *testing.T) {
+	dir := t.TempDir()
+	c := NewCloisterLogs(dir, FormatText, clog.RotateConfig{})
+	defer c.CloseAll()
+
+	c.Observe(&Event{Type: EventRequest, Cloister: "a", Cmd: "1"})
+	c.Observe(&Event{Type: EventRequest, Cloister: "b", Cmd: "1"})
+	c.Close("a")
+	c.Close("unknown")
+	if len(c.files) != 1 {
+		t.Fatalf("open files = %d after Close, want 1", len(c.files))
+	}
+
+	c.Observe(&Event{Type: EventRequest, Cloister: "a", Cmd: "2"})
+	data, err := os.ReadFile(filepath.Join(dir, "a.log"))
+	if err != nil {
+		t.Fatalf("read a.log: %v", err)
+	}
+	if n := strings.Count(string(data), "\n"); n != 2 {
+		t.Errorf("a.log has %d lines, want 2 (reopened for append)", n)
+	}
+
+	c.CloseAll()
+	if len(c.files) != 0 {
+		t.Errorf("open files = %d after CloseAll, want 0", len(c.files))
+	}
+}
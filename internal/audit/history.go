@@ -0,0 +1,204 @@
+package audit
+
+import (
+	"sync"
+	"time"
+)
+
+// DefaultHistorySize is the number of decisions a History keeps by default.
+const DefaultHistorySize = 500
+
+// denyMergeWindow is how close together the two DOMAIN_DENY events written
+// for a single denial (one by the approval UI, one by the domain approver)
+// must be to be merged into one decision.
+const denyMergeWindow = 5 * time.Second
+
+// Decision types.
+const (
+	DecisionHostexec = "hostexec"
+	DecisionDomain   = "domain"
+)
+
+// Decision outcomes.
+const (
+	OutcomeApproved     = "approved"
+	OutcomeAutoApproved = "auto_approved"
+	OutcomeDenied       = "denied"
+	OutcomeTimeout      = "timeout"
+)
+
+// Decision is a resolved hostexec or domain request, assembled from the
+// audit events that describe it.
+type Decision struct {
+	Time       time.Time `json:"time"`
+	Type       string    `json:"type"` // DecisionHostexec or DecisionDomain
+	Project    string    `json:"project"`
+	Cloister   string    `json:"cloister"`
+	Cmd        string    `json:"cmd,omitempty"`
+	Domain     string    `json:"domain,omitempty"`
+	Outcome    string    `json:"outcome"`
+	Actor      string    `json:"actor,omitempty"` // Who decided; empty for automatic decisions
+	Scope      string    `json:"scope,omitempty"`
+	Pattern    string    `json:"pattern,omitempty"`
+	Reason     string    `json:"reason,omitempty"`
+	ExitCode   *int      `json:"exit_code,omitempty"` // Set once an approved command completes
+	DurationMs int64     `json:"duration_ms,omitempty"`
+}
+
+// HistoryFilter selects decisions from a History. Empty fields match all.
+type HistoryFilter struct {
+	Project  string
+	Cloister string
+	Type     string
+	Limit    int // Maximum number of decisions; zero means no limit
+}
+
+// matches reports whether d satisfies the filter.
+func (f HistoryFilter) matches(d *Decision) bool {
+	return (f.Project == "" || d.Project == f.Project) &&
+		(f.Cloister == "" || d.Cloister == f.Cloister) &&
+		(f.Type == "" || d.Type == f.Type)
+}
+
+// History is a bounded in-memory index of recent decisions. It implements
+// Observer so it can be attached to the guardian's audit Logger.
+type History struct {
+	mu        sync.Mutex
+	size      int
+	decisions []Decision // Oldest first
+}
+
+// NewHistory creates a history holding at most size decisions. A size of
+// zero or less uses DefaultHistorySize.
+func NewHistory(size int) *History {
+	if size <= 0 {
+		size = DefaultHistorySize
+	}
+	return &History{size: size}
+}
+
+// Observe records the decision, if any, described by an audit event.
+// REQUEST, DOMAIN_REQUEST and REDACT events carry no decision and are ignored.
+func (h *History) Observe(e *Event) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	switch e.Type {
+	case EventAutoApprove:
+		h.add(newHostexecDecision(e, OutcomeAutoApproved))
+	case EventApprove:
+		h.add(newHostexecDecision(e, OutcomeApproved))
+	case EventDeny:
+		h.add(newHostexecDecision(e, OutcomeDenied))
+	case EventTimeout:
+		h.add(newHostexecDecision(e, OutcomeTimeout))
+	case EventComplete:
+		h.complete(e)
+	case EventDomainApprove:
+		h.add(newDomainDecision(e, OutcomeApproved))
+	case EventDomainDeny:
+		if !h.mergeDomainDeny(e) {
+			h.add(newDomainDecision(e, OutcomeDenied))
+		}
+	case EventDomainTimeout:
+		h.add(newDomainDecision(e, OutcomeTimeout))
+	default:
+	}
+}
+
+// List returns the decisions matching f, newest first.
+func (h *History) List(f HistoryFilter) []Decision {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	result := []Decision{}
+	for i := len(h.decisions) - 1; i >= 0; i-- {
+		if f.Limit > 0 && len(result) >= f.Limit {
+			break
+		}
+		if f.matches(&h.decisions[i]) {
+			result = append(result, h.decisions[i])
+		}
+	}
+	return result
+}
+
+// add appends a decision, dropping the oldest when the history is full.
+func (h *History) add(d Decision) {
+	if len(h.decisions) >= h.size {
+		n := copy(h.decisions, h.decisions[len(h.decisions)-h.size+1:])
+		h.decisions = h.decisions[:n]
+	}
+	h.decisions = append(h.decisions, d)
+}
+
+// complete attaches a COMPLETE event's exit code and duration to the newest
+// approved, not yet completed decision for the same command.
+func (h *History) complete(e *Event) {
+	for i := len(h.decisions) - 1; i >= 0; i-- {
+		d := &h.decisions[i]
+		if d.Type != DecisionHostexec || d.ExitCode != nil || d.Cloister != e.Cloister || d.Cmd != e.Cmd {
+			continue
+		}
+		if d.Outcome != OutcomeApproved && d.Outcome != OutcomeAutoApproved {
+			continue
+		}
+		exitCode := e.ExitCode
+		d.ExitCode = &exitCode
+		d.DurationMs = e.Duration.Milliseconds()
+		return
+	}
+}
+
+// mergeDomainDeny folds the domain approver's DOMAIN_DENY (scope and
+// pattern) into the approval UI's DOMAIN_DENY (user and reason) for the same
+// denial. Reports whether the event was merged.
+func (h *History) mergeDomainDeny(e *Event) bool {
+	if e.Scope == "" || e.Reason != "" {
+		return false
+	}
+	for i := len(h.decisions) - 1; i >= 0; i-- {
+		d := &h.decisions[i]
+		if e.Timestamp.Sub(d.Time) > denyMergeWindow {
+			return false
+		}
+		if d.Type == DecisionDomain && d.Outcome == OutcomeDenied && d.Scope == "" &&
+			d.Cloister == e.Cloister && d.Domain == e.Domain {
+			d.Scope = e.Scope
+			d.Pattern = e.Pattern
+			return true
+		}
+	}
+	return false
+}
+
+// newHostexecDecision creates a hostexec decision from an audit event.
+func newHostexecDecision(e *Event, outcome string) Decision {
+	return Decision{
+		Time:     e.Timestamp,
+		Type:     DecisionHostexec,
+		Project:  e.Project,
+		Cloister: e.Cloister,
+		Cmd:      e.Cmd,
+		Outcome:  outcome,
+		Actor:    e.User,
+		Pattern:  e.Pattern,
+		Reason:   e.Reason,
+	}
+}
+
+// newDomainDecision creates a domain decision from an audit event.
+func newDomainDecision(e *Event, outcome string) Decision {
+	return Decision{
+		Time:     e.Timestamp,
+		Type:     DecisionDomain,
+		Project:  e.Project,
+		Cloister: e.Cloister,
+		Domain:   e.Domain,
+		Outcome:  outcome,
+		Actor:    e.User,
+		Scope:    e.Scope,
+		Pattern:  e.Pattern,
+		Reason:   e.Reason,
+	}
+}
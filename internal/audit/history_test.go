@@ -0,0 +1,111 @@
+package audit
+
+import (
+	"testing"
+	"time"
+)
+
+func TestHistory_RecordsDecisions(t *testing.T) {
+	h := NewHistory(0)
+	logger := NewLogger(nil)
+	logger.AddObserver(h)
+
+	_ = logger.LogRequest("", "api", "api-main", "make test")
+	_ = logger.LogAutoApprove("", "api", "api-main", "make test", "^make .+$")
+	_ = logger.LogComplete("", "api", "api-main", "make test", 1, 1500*time.Millisecond)
+	_ = logger.LogDenyBy("", "web", "web-main", "rm -rf /", "alice", "dangerous")
+	_ = logger.LogDomainApprove("", "web", "web-main", "example.com", "session", "alice")
+
+	got := h.List(HistoryFilter{})
+	if len(got) != 3 {
+		t.Fatalf("List() returned %d decisions, want 3: %+v", len(got), got)
+	}
+
+	if got[0].Type != DecisionDomain || got[0].Outcome != OutcomeApproved || got[0].Scope != "session" || got[0].Actor != "alice" {
+		t.Errorf("newest decision = %+v, want domain approval", got[0])
+	}
+	if got[1].Outcome != OutcomeDenied || got[1].Actor != "alice" || got[1].Reason != "dangerous" {
+		t.Errorf("second decision = %+v, want manual denial", got[1])
+	}
+	auto := got[2]
+	if auto.Outcome != OutcomeAutoApproved || auto.Pattern != "^make .+$" {
+		t.Errorf("oldest decision = %+v, want auto-approval", auto)
+	}
+	if auto.ExitCode == nil || *auto.ExitCode != 1 || auto.DurationMs != 1500 {
+		t.Errorf("auto-approval completion = %v/%d, want exit 1 in 1500ms", auto.ExitCode, auto.DurationMs)
+	}
+}
+
+func TestHistory_Filter(t *testing.T) {
+	h := NewHistory(0)
+	h.Observe(&Event{Timestamp: testTime, Type: EventTimeout, Project: "api", Cloister: "api-main", Cmd: "a"})
+	h.Observe(&Event{Timestamp: testTime, Type: EventDomainTimeout, Project: "api", Cloister: "api-dev", Domain: "x.io"})
+	h.Observe(&Event{Timestamp: testTime, Type: EventDeny, Project: "web", Cloister: "web-main", Cmd: "b"})
+
+	tests := []struct {
+		name   string
+		filter HistoryFilter
+		want   int
+	}{
+		{"all", HistoryFilter{}, 3},
+		{"project", HistoryFilter{Project: "api"}, 2},
+		{"cloister", HistoryFilter{Cloister: "api-dev"}, 1},
+		{"type", HistoryFilter{Type: DecisionHostexec}, 2},
+		{"combined", HistoryFilter{Project: "api", Type: DecisionDomain}, 1},
+		{"limit", HistoryFilter{Limit: 2}, 2},
+		{"no match", HistoryFilter{Project: "none"}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := h.List(tt.filter); len(got) != tt.want {
+				t.Errorf("List() returned %d decisions, want %d", len(got), tt.want)
+			}
+		})
+	}
+}
+
+func TestHistory_Bounded(t *testing.T) {
+	h := NewHistory(3)
+	for _, cmd := range []string{"1", "2", "3", "4", "5"} {
+		h.Observe(&Event{Timestamp: testTime, Type: EventTimeout, Cmd: cmd})
+	}
+
+	got := h.List(HistoryFilter{})
+	if len(got) != 3 {
+		t.Fatalf("List() returned %d decisions, want 3", len(got))
+	}
+	if got[0].Cmd != "5" || got[2].Cmd != "3" {
+		t.Errorf("List() = %s..%s, want 5..3", got[0].Cmd, got[2].Cmd)
+	}
+}
+
+func TestHistory_MergesDomainDeny(t *testing.T) {
+	h := NewHistory(0)
+	h.Observe(&Event{Timestamp: testTime, Type: EventDomainDeny, Project: "p", Cloister: "c", Domain: "evil.com", User: "alice", Reason: "Denied by alice"})
+	h.Observe(&Event{Timestamp: testTime.Add(time.Second), Type: EventDomainDeny, Project: "p", Cloister: "c", Domain: "evil.com", Scope: "project", Pattern: "*.evil.com"})
+
+	got := h.List(HistoryFilter{})
+	if len(got) != 1 {
+		t.Fatalf("List() returned %d decisions, want 1 merged: %+v", len(got), got)
+	}
+	d := got[0]
+	if d.Actor != "alice" || d.Reason != "Denied by alice" || d.Scope != "project" || d.Pattern != "*.evil.com" {
+		t.Errorf("merged decision = %+v", d)
+	}
+
+	// A later denial with scope but no matching UI event stands alone.
+	h.Observe(&Event{Timestamp: testTime.Add(time.Minute), Type: EventDomainDeny, Cloister: "c", Domain: "evil.com", Scope: "once"})
+	if n := len(h.List(HistoryFilter{})); n != 2 {
+		t.Errorf("List() returned %d decisions, want 2", n)
+	}
+}
+
+func TestHistory_CompleteIgnoresDenied(t *testing.T) {
+	h := NewHistory(0)
+	h.Observe(&Event{Timestamp: testTime, Type: EventDeny, Cloister: "c", Cmd: "ls"})
+	h.Observe(&Event{Timestamp: testTime, Type: EventComplete, Cloister: "c", Cmd: "ls", ExitCode: 0})
+
+	if d := h.List(HistoryFilter{})[0]; d.ExitCode != nil {
+		t.Errorf("denied decision got exit code %d", *d.ExitCode)
+	}
+}
@@ -0,0 +1,147 @@
+package audit
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Format selects how audit events are written to log files.
+type Format string
+
+// Audit log formats.
+const (
+	// FormatText writes key=value lines (see Event.Format).
+	FormatText Format = "text"
+
+	// FormatJSONL writes one JSON object per line (see Event.FormatJSON).
+	FormatJSONL Format = "jsonl"
+)
+
+// SchemaVersion is the version of the JSON Lines record schema, written as
+// "v" in every record. It changes only when existing fields change meaning
+// or are removed; new optional fields do not bump it.
+const SchemaVersion = 1
+
+// record is the JSON Lines form of an Event. Fields that do not apply to an
+// event's type are omitted.
+type record struct {
+	V          int       `json:"v"`
+	ID         string    `json:"id"`
+	RequestID  string    `json:"request_id,omitempty"`
+	Time       string    `json:"time"` // RFC 3339 with nanoseconds, UTC
+	Category   string    `json:"category"`
+	Type       EventType `json:"type"`
+	Project    string    `json:"project"`
+	Cloister   string    `json:"cloister"`
+	Cmd        string    `json:"cmd,omitempty"`
+	Domain     string    `json:"domain,omitempty"`
+	Scope      string    `json:"scope,omitempty"`
+	Pattern    string    `json:"pattern,omitempty"`
+	User       string    `json:"user,omitempty"`
+	Reason     string    `json:"reason,omitempty"`
+	ExitCode   *int      `json:"exit_code,omitempty"`   // COMPLETE only
+	DurationMs *float64  `json:"duration_ms,omitempty"` // COMPLETE only
+	Redactions *int      `json:"redactions,omitempty"`  // REDACT only
+	Detectors  []string  `json:"detectors,omitempty"`   // REDACT only
+}
+
+// NewID returns a random event or request ID as 16 hex characters.
+func NewID() string {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b) // Never returns an error
+	return hex.EncodeToString(b)
+}
+
+// Line returns the event as a log line in the given format, without a
+// trailing newline. Unknown formats use FormatText.
+func (e *Event) Line(f Format) string {
+	if f == FormatJSONL {
+		return e.FormatJSON()
+	}
+	return e.Format()
+}
+
+// FormatJSON returns the event as a single-line JSON object:
+// {"v":1,"id":"…","request_id":"…","time":"2024-01-15T14:32:05.123456789Z","category":"hostexec","type":"REQUEST",…}
+func (e *Event) FormatJSON() string {
+	r := record{
+		V:         SchemaVersion,
+		ID:        e.ID,
+		RequestID: e.RequestID,
+		Time:      e.Timestamp.UTC().Format(time.RFC3339Nano),
+		Category:  e.Category(),
+		Type:      e.Type,
+		Project:   e.Project,
+		Cloister:  e.Cloister,
+		Cmd:       e.Cmd,
+		Domain:    e.Domain,
+		Scope:     e.Scope,
+		Pattern:   e.Pattern,
+		User:      e.User,
+		Reason:    e.Reason,
+	}
+	switch e.Type {
+	case EventComplete:
+		exitCode := e.ExitCode
+		ms := float64(e.Duration) / float64(time.Millisecond)
+		r.ExitCode, r.DurationMs = &exitCode, &ms
+	case EventRedact:
+		n := e.Redactions
+		r.Redactions = &n
+		if e.Detectors != "" {
+			r.Detectors = strings.Split(e.Detectors, ",")
+		}
+	}
+
+	b, _ := json.Marshal(r) // Strings and finite numbers only; cannot fail
+	return string(b)
+}
+
+// parseJSONEvent parses a line written by Event.FormatJSON back into an
+// Event. Unknown fields are ignored.
+func parseJSONEvent(line string) (*Event, error) {
+	var r record
+	if err := json.Unmarshal([]byte(line), &r); err != nil {
+		return nil, fmt.Errorf("malformed audit record: %w", err)
+	}
+	if r.V < 1 {
+		return nil, fmt.Errorf("audit record has no schema version: %q", line)
+	}
+	if r.Category == CategoryAudit {
+		return nil, fmt.Errorf("not an audit event: %s record", r.Type)
+	}
+	ts, err := time.Parse(time.RFC3339Nano, r.Time)
+	if err != nil {
+		return nil, fmt.Errorf("invalid audit timestamp %q: %w", r.Time, err)
+	}
+
+	e := &Event{
+		ID:        r.ID,
+		RequestID: r.RequestID,
+		Timestamp: ts,
+		Type:      r.Type,
+		Project:   r.Project,
+		Cloister:  r.Cloister,
+		Cmd:       r.Cmd,
+		Domain:    r.Domain,
+		Scope:     r.Scope,
+		Pattern:   r.Pattern,
+		User:      r.User,
+		Reason:    r.Reason,
+		Detectors: strings.Join(r.Detectors, ","),
+	}
+	if r.ExitCode != nil {
+		e.ExitCode = *r.ExitCode
+	}
+	if r.DurationMs != nil {
+		e.Duration = time.Duration(*r.DurationMs * float64(time.Millisecond))
+	}
+	if r.Redactions != nil {
+		e.Redactions = *r.Redactions
+	}
+	return e, nil
+}
@@ -0,0 +1,134 @@
+package audit
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEventFormatJSON_Complete(t *testing.T) {
+	e := &Event{
+		ID:        "e1",
+		RequestID: "r1",
+		Timestamp: time.Date(2024, 1, 15, 14, 32, 5, 123456789, time.UTC),
+		Type:      EventComplete,
+		Project:   "my-api",
+		Cloister:  "my-api-main",
+		Cmd:       "make test",
+		Duration:  2300 * time.Millisecond,
+	}
+
+	var got map[string]any
+	if err := json.Unmarshal([]byte(e.FormatJSON()), &got); err != nil {
+		t.Fatalf("FormatJSON() is not JSON: %v", err)
+	}
+	want := map[string]any{
+		"v": 1.0, "id": "e1", "request_id": "r1",
+		"time":     "2024-01-15T14:32:05.123456789Z",
+		"category": "hostexec", "type": "COMPLETE",
+		"project": "my-api", "cloister": "my-api-main", "cmd": "make test",
+		"exit_code": 0.0, "duration_ms": 2300.0,
+	}
+	if len(got) != len(want) {
+		t.Errorf("FormatJSON() fields = %v, want %v", got, want)
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("FormatJSON()[%q] = %v, want %v", k, got[k], v)
+		}
+	}
+}
+
+func TestEventFormatJSON_OmitsUnrelatedFields(t *testing.T) {
+	e := &Event{ID: "e2", Timestamp: time.Now(), Type: EventDomainRequest, Project: "p", Cloister: "c", Domain: "example.com"}
+	line := e.FormatJSON()
+	for _, key := range []string{`"cmd"`, `"exit_code"`, `"duration_ms"`, `"redactions"`, `"request_id"`} {
+		if strings.Contains(line, key) {
+			t.Errorf("FormatJSON() = %s, should not contain %s", line, key)
+		}
+	}
+	if !strings.Contains(line, `"category":"domain"`) {
+		t.Errorf("FormatJSON() = %s, want domain category", line)
+	}
+}
+
+func TestParseEvent_JSONRoundTrip(t *testing.T) {
+	events := []*Event{
+		{ID: "a", RequestID: "r", Timestamp: time.Date(2024, 1, 15, 14, 32, 5, 500, time.UTC), Type: EventComplete, Project: "p", Cloister: "c", Cmd: "make", ExitCode: 2, Duration: 1500 * time.Microsecond},
+		{ID: "b", RequestID: "r", Timestamp: time.Date(2024, 1, 15, 14, 32, 6, 0, time.UTC), Type: EventRedact, Project: "p", Cloister: "c", Cmd: "make", Redactions: 3, Detectors: "github_token,aws_access_key"},
+		{ID: "c", Timestamp: time.Date(2024, 1, 15, 14, 32, 7, 0, time.UTC), Type: EventDomainDeny, Project: "p", Cloister: "c", Domain: "x.io", Scope: "once", User: "alice", Reason: "no"},
+	}
+	for _, want := range events {
+		got, err := ParseEvent(want.FormatJSON())
+		if err != nil {
+			t.Fatalf("ParseEvent(%s): %v", want.Type, err)
+		}
+		if !got.Timestamp.Equal(want.Timestamp) {
+			t.Errorf("%s: Timestamp = %v, want %v", want.Type, got.Timestamp, want.Timestamp)
+		}
+		got.Timestamp = want.Timestamp
+		if *got != *want {
+			t.Errorf("ParseEvent(FormatJSON()) = %+v, want %+v", got, want)
+		}
+	}
+
+	if _, err := ParseEvent(`{"id":"x","type":"REQUEST"}`); err == nil {
+		t.Error("ParseEvent() accepted a record without a schema version")
+	}
+}
+
+func TestLogger_JSONL(t *testing.T) {
+	var buf bytes.Buffer
+	logger := NewLogger(&buf)
+	logger.SetFormat(FormatJSONL)
+
+	if err := logger.LogRequest("req1", "p", "c", "make"); err != nil {
+		t.Fatalf("LogRequest: %v", err)
+	}
+	if err := logger.LogComplete("req1", "p", "c", "make", 0, time.Second); err != nil {
+		t.Fatalf("LogComplete: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("got %d lines, want 2: %q", len(lines), buf.String())
+	}
+	var ids []string
+	for _, line := range lines {
+		e, err := ParseEvent(line)
+		if err != nil {
+			t.Fatalf("ParseEvent(%q): %v", line, err)
+		}
+		if e.RequestID != "req1" || len(e.ID) != 16 {
+			t.Errorf("event %s: id = %q, request_id = %q", e.Type, e.ID, e.RequestID)
+		}
+		ids = append(ids, e.ID)
+	}
+	if ids[0] == ids[1] {
+		t.Errorf("events share ID %q", ids[0])
+	}
+}
+
+func TestLoadFile_MixedFormats(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	text := &Event{Timestamp: time.Now(), Type: EventRequest, Project: "p", Cloister: "c", Cmd: "old"}
+	jsonl := &Event{ID: "x", Timestamp: time.Now(), Type: EventRequest, Project: "p", Cloister: "c", Cmd: "new"}
+	content := text.Format() + "\n" + jsonl.FormatJSON() + "\n"
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	s := NewStream(10)
+	if err := LoadFile(path, s); err != nil {
+		t.Fatalf("LoadFile: %v", err)
+	}
+	recent, ch := s.Subscribe(Filter{}, 10)
+	defer s.Unsubscribe(ch)
+	if len(recent) != 2 || recent[0].Cmd != "old" || recent[1].Cmd != "new" {
+		t.Errorf("replayed %v, want old and new", cmds(recent))
+	}
+}
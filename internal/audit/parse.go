@@ -0,0 +1,163 @@
+package audit
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// loadTailBytes bounds how much of an existing audit log LoadFile reads;
+// only recent events are needed to fill in-memory indexes.
+const loadTailBytes = 4 << 20
+
+// ParseEvent parses a line written by Event.Format or Event.FormatJSON back
+// into an Event. Unknown keys are ignored so that older readers tolerate
+// newer fields.
+func ParseEvent(line string) (*Event, error) {
+	line = strings.TrimSpace(line)
+	if strings.HasPrefix(line, "{") {
+		return parseJSONEvent(line)
+	}
+
+	parts := strings.SplitN(line, " ", 4)
+	if len(parts) < 3 {
+		return nil, fmt.Errorf("malformed audit line: %q", line)
+	}
+
+	ts, err := time.Parse(time.RFC3339, parts[0])
+	if err != nil {
+		return nil, fmt.Errorf("invalid audit timestamp %q: %w", parts[0], err)
+	}
+	if parts[1] != "HOSTEXEC" && parts[1] != "DOMAIN" {
+		return nil, fmt.Errorf("unknown audit category %q", parts[1])
+	}
+
+	e := &Event{Timestamp: ts, Type: EventType(parts[2])}
+	rest := ""
+	if len(parts) == 4 {
+		rest = parts[3]
+	}
+	for rest != "" {
+		var key, value string
+		key, value, rest, err = nextField(rest)
+		if err != nil {
+			return nil, err
+		}
+		if err := e.setField(key, value); err != nil {
+			return nil, err
+		}
+	}
+	return e, nil
+}
+
+// nextField splits the leading key=value pair off s. Quoted values are
+// unquoted; unquoted values run to the next space.
+func nextField(s string) (key, value, rest string, err error) {
+	s = strings.TrimLeft(s, " ")
+	key, s, ok := strings.Cut(s, "=")
+	if !ok || key == "" || strings.Contains(key, " ") {
+		return "", "", "", fmt.Errorf("malformed audit field near %q", key)
+	}
+
+	if strings.HasPrefix(s, `"`) {
+		quoted, err := strconv.QuotedPrefix(s)
+		if err != nil {
+			return "", "", "", fmt.Errorf("malformed quoted value for %s: %w", key, err)
+		}
+		value, err = strconv.Unquote(quoted)
+		if err != nil {
+			return "", "", "", fmt.Errorf("malformed quoted value for %s: %w", key, err)
+		}
+		return key, value, strings.TrimLeft(s[len(quoted):], " "), nil
+	}
+
+	value, rest, _ = strings.Cut(s, " ")
+	return key, value, rest, nil
+}
+
+// setField assigns a parsed key=value pair to the matching event field.
+func (e *Event) setField(key, value string) error {
+	var err error
+	switch key {
+	case "project":
+		e.Project = value
+	case "cloister":
+		e.Cloister = value
+	case "cmd":
+		e.Cmd = value
+	case "domain":
+		e.Domain = value
+	case "scope":
+		e.Scope = value
+	case "pattern":
+		e.Pattern = value
+	case "user":
+		e.User = value
+	case "reason":
+		e.Reason = value
+	case "detectors":
+		e.Detectors = value
+	case "exit":
+		e.ExitCode, err = strconv.Atoi(value)
+	case "count":
+		e.Redactions, err = strconv.Atoi(value)
+	case "duration":
+		e.Duration, err = time.ParseDuration(value)
+	}
+	if err != nil {
+		return fmt.Errorf("invalid %s value %q: %w", key, value, err)
+	}
+	return nil
+}
+
+// LoadFile replays the tail of an existing audit log to observers, so that
+// in-memory indexes survive a guardian restart. At most loadTailBytes are
+// read. A missing file is not an error; lines that do not parse are skipped.
+func LoadFile(path string, observers ...Observer) error {
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to open audit log: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+
+	info, err := f.Stat()
+	if err != nil {
+		return fmt.Errorf("failed to stat audit log: %w", err)
+	}
+	partial := false
+	if info.Size() > loadTailBytes {
+		if _, err := f.Seek(info.Size()-loadTailBytes, io.SeekStart); err != nil {
+			return fmt.Errorf("failed to seek audit log: %w", err)
+		}
+		partial = true
+	}
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), loadTailBytes)
+	for scanner.Scan() {
+		if partial {
+			// The first line after seeking is likely cut short.
+			partial = false
+			continue
+		}
+		e, err := ParseEvent(scanner.Text())
+		if err != nil {
+			continue
+		}
+		for _, o := range observers {
+			o.Observe(e)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read audit log: %w", err)
+	}
+	return nil
+}
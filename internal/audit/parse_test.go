@@ -0,0 +1,89 @@
+package audit
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseEvent_RoundTrip(t *testing.T) {
+	events := []*Event{
+		{Timestamp: testTime, Type: EventRequest, Project: "my-api", Cloister: "my-api", Cmd: `echo "hi there"`},
+		{Timestamp: testTime, Type: EventAutoApprove, Project: "p", Cloister: "c", Cmd: "make test", Pattern: "^make .+$"},
+		{Timestamp: testTime, Type: EventDeny, Project: "p", Cloister: "c", Cmd: "rm -rf /", User: "alice", Reason: "nope"},
+		{Timestamp: testTime, Type: EventComplete, Project: "p", Cloister: "c", Cmd: "make", ExitCode: 2, Duration: 2300 * time.Millisecond},
+		{Timestamp: testTime, Type: EventRedact, Project: "p", Cloister: "c", Cmd: "env", Redactions: 3, Detectors: "aws,github"},
+		{Timestamp: testTime, Type: EventDomainApprove, Project: "p", Cloister: "c", Domain: "example.com", Scope: "session", User: "bob"},
+		{Timestamp: testTime, Type: EventDomainDeny, Project: "p", Cloister: "c", Domain: "evil.com", Scope: "global", Pattern: "*.evil.com"},
+	}
+
+	for _, want := range events {
+		t.Run(string(want.Type), func(t *testing.T) {
+			got, err := ParseEvent(want.Format())
+			if err != nil {
+				t.Fatalf("ParseEvent() error = %v", err)
+			}
+			if !got.Timestamp.Equal(want.Timestamp) {
+				t.Errorf("Timestamp = %v, want %v", got.Timestamp, want.Timestamp)
+			}
+			got.Timestamp = want.Timestamp
+			if *got != *want {
+				t.Errorf("ParseEvent() =\n  got:  %+v\n  want: %+v", got, want)
+			}
+		})
+	}
+}
+
+func TestParseEvent_Invalid(t *testing.T) {
+	lines := []string{
+		"",
+		"2024-01-15T14:32:05Z HOSTEXEC",
+		"yesterday HOSTEXEC REQUEST project=p",
+		"2024-01-15T14:32:05Z PROXY REQUEST project=p",
+		`2024-01-15T14:32:05Z HOSTEXEC REQUEST cmd="unterminated`,
+		"2024-01-15T14:32:05Z HOSTEXEC COMPLETE exit=abc",
+		"2024-01-15T14:32:05Z HOSTEXEC REQUEST garbage",
+	}
+	for _, line := range lines {
+		if _, err := ParseEvent(line); err == nil {
+			t.Errorf("ParseEvent(%q) expected error", line)
+		}
+	}
+}
+
+func TestParseEvent_IgnoresUnknownFields(t *testing.T) {
+	e, err := ParseEvent(`2024-01-15T14:32:05Z HOSTEXEC APPROVE project=p cloister=c cmd="ls" future="x" user="u"`)
+	if err != nil {
+		t.Fatalf("ParseEvent() error = %v", err)
+	}
+	if e.User != "u" || e.Cmd != "ls" {
+		t.Errorf("ParseEvent() = %+v", e)
+	}
+}
+
+func TestLoadFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	lines := []string{
+		(&Event{Timestamp: testTime, Type: EventApprove, Project: "p", Cloister: "c", Cmd: "make", User: "alice"}).Format(),
+		"not an audit line",
+		(&Event{Timestamp: testTime, Type: EventComplete, Project: "p", Cloister: "c", Cmd: "make", Duration: time.Second}).Format(),
+	}
+	if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	h := NewHistory(0)
+	if err := LoadFile(path, h); err != nil {
+		t.Fatalf("LoadFile() error = %v", err)
+	}
+	got := h.List(HistoryFilter{})
+	if len(got) != 1 || got[0].Actor != "alice" || got[0].ExitCode == nil || got[0].DurationMs != 1000 {
+		t.Errorf("List() = %+v, want one completed approval", got)
+	}
+
+	if err := LoadFile(filepath.Join(t.TempDir(), "missing.log"), h); err != nil {
+		t.Errorf("LoadFile() on missing file error = %v, want nil", err)
+	}
+}
@@ -0,0 +1,148 @@
+package audit
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/xdg/cloister/internal/clog"
+)
+
+// Outcomes of COMPLETE events, in addition to the decision outcomes.
+const (
+	OutcomeCompleted = "completed" // The command exited with status 0
+	OutcomeFailed    = "failed"    // The command exited with a non-zero status
+)
+
+// outcomes lists every outcome, for validating queries.
+var outcomes = []string{
+	OutcomeApproved, OutcomeAutoApproved, OutcomeDenied, OutcomeTimeout, OutcomeCompleted, OutcomeFailed,
+}
+
+// IsKnownOutcome reports whether s names an outcome, ignoring case.
+func IsKnownOutcome(s string) bool {
+	return slices.ContainsFunc(outcomes, func(o string) bool { return strings.EqualFold(s, o) })
+}
+
+// Outcome returns the outcome an event records, or "" for events that
+// record none (REQUEST, DOMAIN_REQUEST, REDACT).
+func (e *Event) Outcome() string {
+	switch e.Type {
+	case EventApprove, EventDomainApprove:
+		return OutcomeApproved
+	case EventAutoApprove:
+		return OutcomeAutoApproved
+	case EventDeny, EventDomainDeny:
+		return OutcomeDenied
+	case EventTimeout, EventDomainTimeout:
+		return OutcomeTimeout
+	case EventComplete:
+		if e.ExitCode == 0 {
+			return OutcomeCompleted
+		}
+		return OutcomeFailed
+	default:
+		return ""
+	}
+}
+
+// Query selects events read from an audit log. Zero fields match all events.
+type Query struct {
+	Filter
+
+	Since time.Time // Events at or after this time
+	Until time.Time // Events before this time
+
+	// Match is a substring of the command or domain, matched without
+	// regard to case.
+	Match string
+
+	// Outcomes lists outcomes (see Event.Outcome), matched without regard to
+	// case. An event matches if it records any of them.
+	Outcomes []string
+}
+
+// Matches reports whether e satisfies the query.
+func (q *Query) Matches(e *Event) bool {
+	if !q.Filter.Matches(e) {
+		return false
+	}
+	if !q.Since.IsZero() && e.Timestamp.Before(q.Since) {
+		return false
+	}
+	if !q.Until.IsZero() && !e.Timestamp.Before(q.Until) {
+		return false
+	}
+	if q.Match != "" {
+		target := e.Cmd
+		if e.isDomainEvent() {
+			target = e.Domain
+		}
+		if !strings.Contains(strings.ToLower(target), strings.ToLower(q.Match)) {
+			return false
+		}
+	}
+	if len(q.Outcomes) > 0 {
+		outcome := e.Outcome()
+		return slices.ContainsFunc(q.Outcomes, func(o string) bool { return strings.EqualFold(o, outcome) })
+	}
+	return true
+}
+
+// ReadLog passes every event in the audit log at path to fn, oldest first,
+// starting with the log's rotated files. Both the text and JSON Lines
+// formats are read, sealed or not; lines that are not events, such as
+// checkpoint records, are skipped.
+func ReadLog(path string, fn func(*Event)) error {
+	rotated, err := clog.RotatedFiles(path)
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(path); err != nil && (len(rotated) == 0 || !errors.Is(err, os.ErrNotExist)) {
+		return fmt.Errorf("failed to open audit log: %w", err)
+	}
+
+	for _, name := range append(rotated, path) {
+		if err := readLogFile(name, fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readLogFile passes the events in one file of an audit log to fn.
+func readLogFile(name string, fn func(*Event)) error {
+	rc, err := clog.OpenRotated(name)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to open audit log: %w", err)
+	}
+	defer func() { _ = rc.Close() }()
+	return readEvents(rc, fn)
+}
+
+// readEvents passes the events read from r to fn.
+func readEvents(r io.Reader, fn func(*Event)) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), loadTailBytes)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		if e, err := ParseEvent(line); err == nil {
+			fn(e)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read audit log: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+package audit
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/xdg/cloister/internal/clog"
+)
+
+func TestQuery_Matches(t *testing.T) {
+	t0 := time.Date(2024, 1, 15, 14, 0, 0, 0, time.UTC)
+	request := &Event{Timestamp: t0, Type: EventRequest, Project: "api", Cloister: "api-main", Cmd: "docker compose up"}
+	failed := &Event{Timestamp: t0.Add(time.Hour), Type: EventComplete, Project: "api", Cloister: "api-main", Cmd: "make test", ExitCode: 2}
+	denied := &Event{Timestamp: t0.Add(2 * time.Hour), Type: EventDomainDeny, Project: "web", Cloister: "web-main", Domain: "Evil.example.com"}
+
+	tests := []struct {
+		name  string
+		query Query
+		want  []*Event
+	}{
+		{"empty", Query{}, []*Event{request, failed, denied}},
+		{"project", Query{Filter: Filter{Project: "api"}}, []*Event{request, failed}},
+		{"category", Query{Filter: Filter{Types: []string{"domain"}}}, []*Event{denied}},
+		{"since", Query{Since: t0.Add(time.Hour)}, []*Event{failed, denied}},
+		{"until is exclusive", Query{Until: t0.Add(time.Hour)}, []*Event{request}},
+		{"match command", Query{Match: "COMPOSE"}, []*Event{request}},
+		{"match domain", Query{Match: "evil."}, []*Event{denied}},
+		{"outcome", Query{Outcomes: []string{"failed", "denied"}}, []*Event{failed, denied}},
+		{"outcome excludes requests", Query{Outcomes: []string{"approved"}}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []*Event
+			for _, e := range []*Event{request, failed, denied} {
+				if tt.query.Matches(e) {
+					got = append(got, e)
+				}
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("matched %d events, want %d", len(got), len(tt.want))
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("event %d = %+v, want %+v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestReadLog(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "audit.log")
+
+	// An older, compressed text segment and a current JSON Lines log with a
+	// sealed record and a checkpoint.
+	f, err := clog.OpenRotatingFile(path, clog.RotateConfig{MaxSize: 1, Compress: true})
+	if err != nil {
+		t.Fatalf("OpenRotatingFile: %v", err)
+	}
+	logger := NewLogger(f)
+	_ = logger.LogRequest("r1", "p", "c", "first")
+	if err := f.Rotate(); err != nil {
+		t.Fatalf("Rotate: %v", err)
+	}
+	logger.SetFormat(FormatJSONL)
+	logger.SetChain(NewChain(GenerateChainKey(), ""))
+	_ = logger.LogRequest("r2", "p", "c", "second")
+	_ = logger.Checkpoint()
+	if err := logger.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if rotated, _ := clog.RotatedFiles(path); len(rotated) != 1 || filepath.Ext(rotated[0]) != ".gz" {
+		t.Fatalf("RotatedFiles = %v, want one compressed file", rotated)
+	}
+
+	var cmds []string
+	if err := ReadLog(path, func(e *Event) { cmds = append(cmds, e.Cmd) }); err != nil {
+		t.Fatalf("ReadLog: %v", err)
+	}
+	if len(cmds) != 2 || cmds[0] != "first" || cmds[1] != "second" {
+		t.Errorf("ReadLog events = %q, want [first second]", cmds)
+	}
+
+	if err := ReadLog(filepath.Join(dir, "missing.log"), func(*Event) {}); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("ReadLog(missing) error = %v, want not exist", err)
+	}
+}
@@ -0,0 +1,176 @@
+package audit
+
+import (
+	"slices"
+	"strings"
+	"sync"
+)
+
+// DefaultStreamBacklog is the number of recent events a Stream keeps for
+// new subscribers by default.
+const DefaultStreamBacklog = 1000
+
+// streamBufSize is the per-subscriber channel buffer. Events for a
+// subscriber whose buffer is full are dropped rather than blocking logging.
+const streamBufSize = 64
+
+// Event categories, as written after the timestamp of each log line.
+const (
+	CategoryHostexec = "hostexec"
+	CategoryDomain   = "domain"
+)
+
+// eventTypes lists every event type, for validating filters.
+var eventTypes = []EventType{
+	EventRequest, EventAutoApprove, EventApprove, EventDeny, EventComplete, EventTimeout, EventRedact,
+	EventDomainRequest, EventDomainApprove, EventDomainDeny, EventDomainTimeout,
+}
+
+// Category returns the event's category: CategoryHostexec or CategoryDomain.
+func (e *Event) Category() string {
+	if e.isDomainEvent() {
+		return CategoryDomain
+	}
+	return CategoryHostexec
+}
+
+// IsKnownType reports whether s names an event type (such as "DENY") or a
+// category ("hostexec" or "domain"), ignoring case.
+func IsKnownType(s string) bool {
+	if strings.EqualFold(s, CategoryHostexec) || strings.EqualFold(s, CategoryDomain) {
+		return true
+	}
+	return slices.ContainsFunc(eventTypes, func(t EventType) bool {
+		return strings.EqualFold(s, string(t))
+	})
+}
+
+// Filter selects audit events. Empty fields match all events.
+type Filter struct {
+	Project  string
+	Cloister string
+
+	// Types lists event types (such as "DENY") or categories ("hostexec",
+	// "domain"), matched without regard to case. An event matches if it
+	// matches any entry.
+	Types []string
+}
+
+// Matches reports whether e satisfies the filter.
+func (f Filter) Matches(e *Event) bool {
+	if f.Project != "" && e.Project != f.Project {
+		return false
+	}
+	if f.Cloister != "" && e.Cloister != f.Cloister {
+		return false
+	}
+	if len(f.Types) == 0 {
+		return true
+	}
+	return slices.ContainsFunc(f.Types, func(t string) bool {
+		return strings.EqualFold(t, string(e.Type)) || strings.EqualFold(t, e.Category())
+	})
+}
+
+// Stream fans audit events out to live subscribers and keeps a bounded
+// backlog of recent events for new ones. It implements Observer.
+type Stream struct {
+	mu      sync.Mutex
+	size    int
+	backlog []*Event // Oldest first
+	subs    map[chan *Event]Filter
+	closed  bool
+}
+
+// NewStream creates a stream keeping up to size recent events. A size of
+// zero or less uses DefaultStreamBacklog.
+func NewStream(size int) *Stream {
+	if size <= 0 {
+		size = DefaultStreamBacklog
+	}
+	return &Stream{size: size, subs: make(map[chan *Event]Filter)}
+}
+
+// Observe records an event and delivers it to matching subscribers.
+func (s *Stream) Observe(e *Event) {
+	ev := *e
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.backlog) >= s.size {
+		n := copy(s.backlog, s.backlog[len(s.backlog)-s.size+1:])
+		s.backlog = s.backlog[:n]
+	}
+	s.backlog = append(s.backlog, &ev)
+
+	for ch, f := range s.subs {
+		if !f.Matches(&ev) {
+			continue
+		}
+		select {
+		case ch <- &ev:
+		default:
+			// Subscriber is not keeping up; drop the event
+		}
+	}
+}
+
+// Subscribe returns up to tail of the most recent events matching f, oldest
+// first, and a channel that receives later matching events. No event is
+// missed or repeated between the two. The channel is nil if the stream is
+// closed; otherwise the caller must call Unsubscribe when done.
+func (s *Stream) Subscribe(f Filter, tail int) ([]*Event, chan *Event) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var recent []*Event
+	for i := len(s.backlog) - 1; i >= 0 && len(recent) < tail; i-- {
+		if f.Matches(s.backlog[i]) {
+			recent = append(recent, s.backlog[i])
+		}
+	}
+	slices.Reverse(recent)
+
+	if s.closed {
+		return recent, nil
+	}
+	ch := make(chan *Event, streamBufSize)
+	s.subs[ch] = f
+	return recent, ch
+}
+
+// Unsubscribe stops delivery to ch and closes it.
+func (s *Stream) Unsubscribe(ch chan *Event) {
+	if ch == nil {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.subs[ch]; ok {
+		delete(s.subs, ch)
+		close(ch)
+	}
+}
+
+// SubscriberCount returns the number of live subscribers.
+func (s *Stream) SubscriberCount() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.subs)
+}
+
+// Close disconnects all subscribers. Later subscriptions receive only the
+// backlog.
+func (s *Stream) Close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.closed = true
+	for ch := range s.subs {
+		close(ch)
+		delete(s.subs, ch)
+	}
+}
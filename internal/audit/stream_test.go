@@ -0,0 +1,123 @@
+package audit
+
+import (
+	"testing"
+)
+
+func TestFilter_Matches(t *testing.T) {
+	e := &Event{Type: EventDomainDeny, Project: "api", Cloister: "api-main", Domain: "x.io"}
+	tests := []struct {
+		name   string
+		filter Filter
+		want   bool
+	}{
+		{"empty", Filter{}, true},
+		{"project", Filter{Project: "api"}, true},
+		{"wrong project", Filter{Project: "web"}, false},
+		{"cloister", Filter{Cloister: "api-main"}, true},
+		{"wrong cloister", Filter{Cloister: "api-dev"}, false},
+		{"event type", Filter{Types: []string{"domain_deny"}}, true},
+		{"category", Filter{Types: []string{"hostexec", "DOMAIN"}}, true},
+		{"other type", Filter{Types: []string{"DENY"}}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.filter.Matches(e); got != tt.want {
+				t.Errorf("Matches() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsKnownType(t *testing.T) {
+	for _, s := range []string{"hostexec", "Domain", "REQUEST", "domain_timeout"} {
+		if !IsKnownType(s) {
+			t.Errorf("IsKnownType(%q) = false, want true", s)
+		}
+	}
+	for _, s := range []string{"", "proxy", "ALLOW"} {
+		if IsKnownType(s) {
+			t.Errorf("IsKnownType(%q) = true, want false", s)
+		}
+	}
+}
+
+func TestStream_BacklogThenLive(t *testing.T) {
+	s := NewStream(3)
+	for _, cmd := range []string{"1", "2", "3", "4"} {
+		s.Observe(&Event{Type: EventRequest, Cloister: "c", Cmd: cmd})
+	}
+	s.Observe(&Event{Type: EventRequest, Cloister: "other", Cmd: "x"})
+
+	recent, ch := s.Subscribe(Filter{Cloister: "c"}, 10)
+	defer s.Unsubscribe(ch)
+
+	// Backlog holds the last three events; one is filtered out.
+	if len(recent) != 2 || recent[0].Cmd != "3" || recent[1].Cmd != "4" {
+		t.Fatalf("backlog = %v, want commands 3 and 4", cmds(recent))
+	}
+
+	s.Observe(&Event{Type: EventRequest, Cloister: "other", Cmd: "y"})
+	s.Observe(&Event{Type: EventRequest, Cloister: "c", Cmd: "5"})
+	if e := <-ch; e.Cmd != "5" {
+		t.Errorf("live event = %q, want 5", e.Cmd)
+	}
+}
+
+func TestStream_TailLimit(t *testing.T) {
+	s := NewStream(0)
+	for _, cmd := range []string{"1", "2", "3"} {
+		s.Observe(&Event{Type: EventRequest, Cmd: cmd})
+	}
+
+	recent, ch := s.Subscribe(Filter{}, 2)
+	s.Unsubscribe(ch)
+	if len(recent) != 2 || recent[0].Cmd != "2" {
+		t.Errorf("backlog = %v, want [2 3]", cmds(recent))
+	}
+
+	if recent, _ := s.Subscribe(Filter{}, 0); len(recent) != 0 {
+		t.Errorf("tail 0 returned %d events", len(recent))
+	}
+}
+
+func TestStream_Close(t *testing.T) {
+	s := NewStream(0)
+	s.Observe(&Event{Type: EventRequest, Cmd: "1"})
+	_, ch := s.Subscribe(Filter{}, 0)
+
+	s.Close()
+	if _, ok := <-ch; ok {
+		t.Error("expected subscriber channel to be closed")
+	}
+
+	recent, ch := s.Subscribe(Filter{}, 5)
+	if ch != nil {
+		t.Error("Subscribe() after Close returned a channel")
+	}
+	if len(recent) != 1 {
+		t.Errorf("Subscribe() after Close returned %d backlog events, want 1", len(recent))
+	}
+}
+
+func TestStream_SubscriberCount(t *testing.T) {
+	s := NewStream(0)
+	_, a := s.Subscribe(Filter{}, 0)
+	_, _ = s.Subscribe(Filter{Project: "api"}, 0)
+	if n := s.SubscriberCount(); n != 2 {
+		t.Errorf("SubscriberCount() = %d, want 2", n)
+	}
+	s.Unsubscribe(a)
+	if n := s.SubscriberCount(); n != 1 {
+		t.Errorf("SubscriberCount() after Unsubscribe = %d, want 1", n)
+	}
+}
+
+// cmds returns the commands of events, for test messages.
+func cmds(events []*Event) []string {
+	out := make([]string, len(events))
+	for i, e := range events {
+		out[i] = e.Cmd
+	}
+	return out
+}
@@ -0,0 +1,184 @@
+package audit
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"time"
+)
+
+// Summary aggregates a set of audit events.
+type Summary struct {
+	Events      int            `json:"events"`
+	From        time.Time      `json:"from,omitzero"` // Time of the first event
+	To          time.Time      `json:"to,omitzero"`   // Time of the last event
+	ByType      map[string]int `json:"by_type"`
+	TopDomains  []Count        `json:"top_domains"`  // Domains by DOMAIN_REQUEST events
+	TopCommands []Count        `json:"top_commands"` // Commands by COMPLETE events
+	Denials     Denials        `json:"denials"`
+	Latency     Latency        `json:"approval_latency"`
+}
+
+// Count is a value and the number of times it occurred.
+type Count struct {
+	Value string `json:"value"`
+	Count int    `json:"count"`
+}
+
+// Denials counts denied requests by category, and by what was denied.
+type Denials struct {
+	Hostexec int     `json:"hostexec"`
+	Domain   int     `json:"domain"`
+	Top      []Count `json:"top"` // Denied commands and domains
+}
+
+// Latency describes how long manually decided requests waited for a person.
+type Latency struct {
+	Decisions int // Decisions matched to their request
+	Median    time.Duration
+	P90       time.Duration
+	Max       time.Duration
+}
+
+// MarshalJSON writes durations as fractional milliseconds, like the
+// duration_ms field of JSON Lines records.
+func (l Latency) MarshalJSON() ([]byte, error) {
+	ms := func(d time.Duration) float64 { return float64(d) / float64(time.Millisecond) }
+	b, err := json.Marshal(struct {
+		Decisions int     `json:"decisions"`
+		MedianMs  float64 `json:"median_ms"`
+		P90Ms     float64 `json:"p90_ms"`
+		MaxMs     float64 `json:"max_ms"`
+	}{l.Decisions, ms(l.Median), ms(l.P90), ms(l.Max)})
+	if err != nil {
+		return nil, fmt.Errorf("marshal latency: %w", err)
+	}
+	return b, nil
+}
+
+// pendingKey identifies a request awaiting a decision when events carry no
+// request ID: requests for the same target from the same cloister are
+// decided oldest first.
+type pendingKey struct {
+	category, cloister, target string
+}
+
+// Summarize aggregates events, which must be oldest first. The top lists
+// hold at most top entries; zero or less means no limit.
+//
+// Approval latency is measured from a request to the approval or denial
+// of it by a person. Events are matched by request ID where the log
+// records one (JSON Lines) and otherwise by cloister and command or
+// domain. The second DOMAIN_DENY event written for a single domain
+// denial is not counted twice.
+func Summarize(events []*Event, top int) Summary {
+	s := Summary{ByType: make(map[string]int)}
+	domains := make(map[string]int)
+	commands := make(map[string]int)
+	denied := make(map[string]int)
+	requested := make(map[string]time.Time)
+	pending := make(map[pendingKey][]time.Time)
+	lastDeny := make(map[string]time.Time)
+	var latencies []time.Duration
+
+	for _, e := range events {
+		s.Events++
+		if s.From.IsZero() {
+			s.From = e.Timestamp
+		}
+		s.To = e.Timestamp
+		s.ByType[string(e.Type)]++
+
+		target := e.Cmd
+		if e.isDomainEvent() {
+			target = e.Domain
+		}
+		key := pendingKey{e.Category(), e.Cloister, target}
+
+		switch e.Type {
+		case EventRequest, EventDomainRequest:
+			if e.RequestID != "" {
+				requested[e.RequestID] = e.Timestamp
+			} else {
+				pending[key] = append(pending[key], e.Timestamp)
+			}
+			if e.Type == EventDomainRequest {
+				domains[target]++
+			}
+			continue
+		case EventComplete:
+			commands[target]++
+			continue
+		case EventDomainDeny:
+			// A denial from the approval UI is logged by both the UI and the
+			// domain approver; only the first counts.
+			denyKey := e.RequestID
+			if denyKey == "" {
+				denyKey = e.Cloister + " " + target
+			}
+			if last, ok := lastDeny[denyKey]; ok && e.Timestamp.Sub(last) <= denyMergeWindow {
+				continue
+			}
+			lastDeny[denyKey] = e.Timestamp
+			s.Denials.Domain++
+			denied[target]++
+		case EventDeny:
+			s.Denials.Hostexec++
+			denied[target]++
+		case EventApprove, EventDomainApprove, EventAutoApprove, EventTimeout, EventDomainTimeout:
+		default:
+			continue
+		}
+
+		// A decision or timeout resolves the oldest matching request. Only
+		// decisions made by a person count toward approval latency.
+		var requestedAt time.Time
+		if t, ok := requested[e.RequestID]; ok && e.RequestID != "" {
+			requestedAt = t
+			delete(requested, e.RequestID)
+		} else if queue := pending[key]; len(queue) > 0 {
+			requestedAt = queue[0]
+			pending[key] = queue[1:]
+		}
+		if e.User != "" && !requestedAt.IsZero() {
+			latencies = append(latencies, e.Timestamp.Sub(requestedAt))
+		}
+	}
+
+	s.TopDomains = topCounts(domains, top)
+	s.TopCommands = topCounts(commands, top)
+	s.Denials.Top = topCounts(denied, top)
+	s.Latency = latencyOf(latencies)
+	return s
+}
+
+// topCounts returns counts sorted by descending count, then value, limited
+// to top entries if top is positive.
+func topCounts(counts map[string]int, top int) []Count {
+	result := make([]Count, 0, len(counts))
+	for v, n := range counts {
+		result = append(result, Count{Value: v, Count: n})
+	}
+	slices.SortFunc(result, func(a, b Count) int {
+		return cmp.Or(cmp.Compare(b.Count, a.Count), cmp.Compare(a.Value, b.Value))
+	})
+	if top > 0 && len(result) > top {
+		result = result[:top]
+	}
+	return result
+}
+
+// latencyOf summarizes a set of latencies.
+func latencyOf(d []time.Duration) Latency {
+	if len(d) == 0 {
+		return Latency{}
+	}
+	slices.Sort(d)
+	return Latency{
+		Decisions: len(d),
+		Median:    d[len(d)/2],
+		P90:       d[(len(d)*9)/10],
+		Max:       d[len(d)-1],
+	}
+}
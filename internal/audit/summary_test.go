@@ -0,0 +1,61 @@
+package audit
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSummarize(t *testing.T) {
+	t0 := time.Date(2024, 1, 15, 14, 0, 0, 0, time.UTC)
+	at := func(s int) time.Time { return t0.Add(time.Duration(s) * time.Second) }
+	events := []*Event{
+		// Text-format events, without request IDs.
+		{Timestamp: at(0), Type: EventRequest, Cloister: "c", Cmd: "make"},
+		{Timestamp: at(10), Type: EventApprove, Cloister: "c", Cmd: "make", User: "alice"},
+		{Timestamp: at(12), Type: EventComplete, Cloister: "c", Cmd: "make"},
+		{Timestamp: at(20), Type: EventRequest, Cloister: "c", Cmd: "ls"},
+		{Timestamp: at(20), Type: EventAutoApprove, Cloister: "c", Cmd: "ls"},
+		{Timestamp: at(21), Type: EventComplete, Cloister: "c", Cmd: "ls"},
+		{Timestamp: at(30), Type: EventRequest, Cloister: "c", Cmd: "ls"},
+		{Timestamp: at(30), Type: EventAutoApprove, Cloister: "c", Cmd: "ls"},
+		{Timestamp: at(31), Type: EventComplete, Cloister: "c", Cmd: "ls"},
+		// JSON Lines events, matched by request ID; the domain denial is
+		// logged twice.
+		{Timestamp: at(40), RequestID: "d1", Type: EventDomainRequest, Cloister: "c", Domain: "evil.com"},
+		{Timestamp: at(41), RequestID: "d2", Type: EventDomainRequest, Cloister: "c", Domain: "evil.com"},
+		{Timestamp: at(70), RequestID: "d1", Type: EventDomainDeny, Cloister: "c", Domain: "evil.com", User: "bob"},
+		{Timestamp: at(70), RequestID: "d1", Type: EventDomainDeny, Cloister: "c", Domain: "evil.com", Scope: "once"},
+		{Timestamp: at(101), RequestID: "d2", Type: EventDomainTimeout, Cloister: "c", Domain: "evil.com"},
+		{Timestamp: at(110), Type: EventDeny, Cloister: "c", Cmd: "rm -rf /", Reason: "pattern denied"},
+	}
+
+	s := Summarize(events, 1)
+
+	if s.Events != len(events) || !s.From.Equal(at(0)) || !s.To.Equal(at(110)) {
+		t.Errorf("Events, From, To = %d, %v, %v", s.Events, s.From, s.To)
+	}
+	if s.ByType["COMPLETE"] != 3 || s.ByType["DOMAIN_DENY"] != 2 {
+		t.Errorf("ByType = %v", s.ByType)
+	}
+	if len(s.TopCommands) != 1 || s.TopCommands[0] != (Count{"ls", 2}) {
+		t.Errorf("TopCommands = %v, want [{ls 2}]", s.TopCommands)
+	}
+	if len(s.TopDomains) != 1 || s.TopDomains[0] != (Count{"evil.com", 2}) {
+		t.Errorf("TopDomains = %v, want [{evil.com 2}]", s.TopDomains)
+	}
+	if s.Denials.Hostexec != 1 || s.Denials.Domain != 1 {
+		t.Errorf("Denials = %+v, want 1 hostexec and 1 domain", s.Denials)
+	}
+	// make waited 10s for alice and evil.com 30s for bob; the timeout and
+	// automatic approvals are not counted.
+	if want := (Latency{Decisions: 2, Median: 30 * time.Second, P90: 30 * time.Second, Max: 30 * time.Second}); s.Latency != want {
+		t.Errorf("Latency = %+v, want %+v", s.Latency, want)
+	}
+
+	b, err := json.Marshal(s.Latency)
+	if err != nil || !strings.Contains(string(b), `"max_ms":30000`) {
+		t.Errorf("Latency JSON = %s, %v", b, err)
+	}
+}
@@ -0,0 +1,231 @@
+package audit
+
+import (
+	"bufio"
+	"crypto/ed25519"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/xdg/cloister/internal/clog"
+)
+
+// VerifyReport summarizes the verification of a sealed audit log.
+type VerifyReport struct {
+	Files       int    // Files read, including rotated files
+	Unsealed    int    // Leading lines written before the chain started
+	Records     int    // Sealed records, including checkpoints
+	Checkpoints int    // Checkpoint and rotation records
+	LastSeq     uint64 // Sequence number of the last sealed record
+	Break       *Break // First broken link; nil if the chain is intact
+}
+
+// Break describes the first point at which an audit log's chain is broken.
+type Break struct {
+	File   string // Log file containing Line; "" for a single reader or the checkpoint file
+	Line   int    // 1-based line number in File; 0 for the checkpoint file
+	Reason string // What is wrong, e.g. "signature mismatch"
+}
+
+// VerifyFile verifies the audit log at path against its checkpoint file, if
+// any. Rotated files of the log are verified first, oldest first, as one
+// chain with the log. See Verify.
+func VerifyFile(path string, key ed25519.PublicKey) (*VerifyReport, error) {
+	checkpoint, err := os.ReadFile(CheckpointPath(path))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("failed to read checkpoint: %w", err)
+	}
+	rotated, err := clog.RotatedFiles(path)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := os.Stat(path); err != nil && (len(rotated) == 0 || !errors.Is(err, os.ErrNotExist)) {
+		return nil, fmt.Errorf("failed to open audit log: %w", err)
+	}
+
+	v := newVerifier(key, strings.TrimSpace(string(checkpoint)))
+	for _, name := range append(rotated, path) {
+		if v.report.Break != nil {
+			break
+		}
+		if err := v.readFile(name); err != nil {
+			return nil, err
+		}
+	}
+	v.finish()
+	return v.report, nil
+}
+
+// Verify checks the chain of a sealed audit log read from r. Every record
+// must carry a valid signature under key, follow the previous record's
+// sequence number, and name the previous line's hash. The log must start at the
+// first record, or with the rotation record that starts a file whose
+// predecessors were deleted. checkpoint is the latest checkpoint record
+// saved outside the log, or "" if there is none; the log must still
+// contain it, which detects truncation. Lines written before the chain
+// started are counted but not checked. Verification stops at the first
+// broken link, which is reported in VerifyReport.Break; the error is only
+// for failures to read.
+func Verify(r io.Reader, key ed25519.PublicKey, checkpoint string) (*VerifyReport, error) {
+	v := newVerifier(key, checkpoint)
+	if v.report.Break == nil {
+		if err := v.read("", r); err != nil {
+			return nil, err
+		}
+	}
+	v.finish()
+	return v.report, nil
+}
+
+// verifier checks a chain that may span several files.
+type verifier struct {
+	key        ed25519.PublicKey
+	checkpoint string
+	cpSeq      uint64
+	cpMatched  bool
+	prevLine   string
+	havePrev   bool
+	file       string // File being read
+	lineNo     int    // Last line read in file
+	report     *VerifyReport
+}
+
+// newVerifier returns a verifier for a chain whose latest checkpoint is
+// checkpoint, or "" for none. An invalid checkpoint is reported as a break.
+func newVerifier(key ed25519.PublicKey, checkpoint string) *verifier {
+	v := &verifier{key: key, checkpoint: checkpoint, report: &VerifyReport{}}
+	if checkpoint != "" {
+		seq, s, ok := parseSeal(checkpoint)
+		if !ok || !isCheckpoint(checkpoint) || !validSignature(key, s.body, s.sig) {
+			v.report.Break = &Break{Reason: "checkpoint file does not hold a valid checkpoint record"}
+		}
+		v.cpSeq = seq
+	}
+	return v
+}
+
+// readFile verifies the log file name, which may be a compressed rotated
+// file, as the next part of the chain.
+func (v *verifier) readFile(name string) error {
+	rc, err := clog.OpenRotated(name)
+	if errors.Is(err, os.ErrNotExist) {
+		// The log itself is not created until the first record after a
+		// rotation is written.
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to open audit log: %w", err)
+	}
+	defer func() { _ = rc.Close() }()
+	return v.read(name, rc)
+}
+
+// read verifies the lines of file, read from r, as the next part of the
+// chain. It stops at the first broken link.
+func (v *verifier) read(file string, r io.Reader) error {
+	v.file, v.lineNo = file, 0
+	v.report.Files++
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), loadTailBytes)
+	for scanner.Scan() {
+		v.lineNo++
+		line := scanner.Text()
+		if line == "" {
+			continue
+		}
+		if reason := checkLink(line, v.key, v.report, v.prevLine, v.havePrev); reason != "" {
+			v.report.Break = &Break{File: file, Line: v.lineNo, Reason: reason}
+			return nil
+		}
+		v.prevLine, v.havePrev = line, true
+
+		if v.report.Records > 0 && v.checkpoint != "" && v.report.LastSeq == v.cpSeq {
+			if line != v.checkpoint {
+				v.report.Break = &Break{File: file, Line: v.lineNo, Reason: fmt.Sprintf("record %d does not match the checkpoint file", v.cpSeq)}
+				return nil
+			}
+			v.cpMatched = true
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read audit log: %w", err)
+	}
+	return nil
+}
+
+// finish checks that the chain reached the checkpoint. A checkpoint older
+// than the first record read was in a rotated file since deleted.
+func (v *verifier) finish() {
+	if v.report.Break != nil || v.checkpoint == "" || v.cpMatched || v.report.LastSeq > v.cpSeq {
+		return
+	}
+	v.report.Break = &Break{
+		File:   v.file,
+		Line:   v.lineNo + 1,
+		Reason: fmt.Sprintf("log ends at record %d but the checkpoint file records %d; the log was truncated", v.report.LastSeq, v.cpSeq),
+	}
+}
+
+// checkLink checks one line against the chain so far, updating the report.
+// It returns the reason the link is broken, or "".
+func checkLink(line string, key ed25519.PublicKey, report *VerifyReport, prevLine string, havePrev bool) string {
+	seq, s, ok := parseSeal(line)
+	if !ok {
+		if report.Records == 0 {
+			report.Unsealed++
+			return ""
+		}
+		return "record is not sealed"
+	}
+	if !validSignature(key, s.body, s.sig) {
+		return "signature mismatch: the record was modified or signed with a different key"
+	}
+
+	// The oldest remaining file of a rotated log starts with the rotation
+	// record, linked to a file that retention has deleted.
+	rotatedHead := report.Records == 0 && seq != 1 && isAuditRecord(line, EventRotate)
+
+	switch want := report.LastSeq + 1; {
+	case rotatedHead:
+	case report.Records == 0 && seq != 1:
+		return fmt.Sprintf("log starts at record %d; earlier records were removed", seq)
+	case seq > want:
+		return fmt.Sprintf("records %d to %d are missing", want, seq-1)
+	case seq < want:
+		return fmt.Sprintf("record %d is out of order; expected %d", seq, want)
+	}
+
+	wantPrev := genesis
+	if havePrev {
+		wantPrev = hashLine(prevLine)
+	}
+	if !rotatedHead && s.prev != wantPrev {
+		return "previous line was modified, removed, or inserted"
+	}
+
+	report.Records++
+	report.LastSeq = seq
+	if isCheckpoint(line) {
+		report.Checkpoints++
+	}
+	return ""
+}
+
+// isCheckpoint reports whether a sealed line is a checkpoint or rotation
+// record.
+func isCheckpoint(line string) bool {
+	return isAuditRecord(line, EventCheckpoint) || isAuditRecord(line, EventRotate)
+}
+
+// isAuditRecord reports whether a sealed line is a record of type t about
+// the log itself.
+func isAuditRecord(line string, t EventType) bool {
+	if strings.HasPrefix(line, "{") {
+		return strings.Contains(line, `"category":"`+CategoryAudit+`","type":"`+string(t)+`"`)
+	}
+	_, rest, _ := strings.Cut(line, " ")
+	return strings.HasPrefix(rest, "AUDIT "+string(t)+" ")
+}
@@ -0,0 +1,136 @@
+package audit
+
+import (
+	"crypto/ed25519"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/xdg/cloister/internal/clog"
+)
+
+func TestVerify(t *testing.T) {
+	priv := GenerateChainKey()
+	key := publicKey(priv)
+	lines := sealedLog(t, priv, FormatText, 5)
+	checkpoint := sealedCheckpoint(t, priv, lines)
+	withCheckpoint := append(slices.Clone(lines), checkpoint)
+
+	tests := []struct {
+		name       string
+		lines      []string
+		key        ed25519.PublicKey
+		checkpoint string
+		wantLine   int    // 0 for an intact log
+		wantReason string // Substring of the break reason
+	}{
+		{"intact", lines, key, "", 0, ""},
+		{"intact with checkpoint", withCheckpoint, key, checkpoint, 0, ""},
+		{"wrong key", lines, publicKey(GenerateChainKey()), "", 1, "signature mismatch"},
+		{"modified", replaceAt(lines, 2, strings.Replace(lines[2], "cmd c", "cmd x", 1)), key, "", 3, "signature mismatch"},
+		{"deleted", slices.Delete(slices.Clone(lines), 2, 3), key, "", 3, "records 3 to 3 are missing"},
+		{"head truncated", lines[2:], key, "", 1, "log starts at record 3"},
+		{"reordered", []string{lines[0], lines[2], lines[1]}, key, "", 2, "records 2 to 2 are missing"},
+		{"unsealed insert", slices.Insert(slices.Clone(lines), 2, "2024-01-15T14:32:05Z HOSTEXEC REQUEST project=p cloister=c cmd=\"x\""), key, "", 3, "not sealed"},
+		{"tail truncated", lines[:4], key, checkpoint, 5, "the log was truncated"},
+		{"forged checkpoint", withCheckpoint, key, lines[4], 0, "checkpoint file does not hold"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report, err := Verify(strings.NewReader(strings.Join(tt.lines, "\n")+"\n"), tt.key, tt.checkpoint)
+			if err != nil {
+				t.Fatalf("Verify: %v", err)
+			}
+			if tt.wantReason == "" {
+				if report.Break != nil {
+					t.Errorf("Break = %+v, want intact", report.Break)
+				}
+				return
+			}
+			if report.Break == nil {
+				t.Fatalf("Break = nil, want %q at line %d", tt.wantReason, tt.wantLine)
+			}
+			if report.Break.Line != tt.wantLine || !strings.Contains(report.Break.Reason, tt.wantReason) {
+				t.Errorf("Break = %+v, want %q at line %d", report.Break, tt.wantReason, tt.wantLine)
+			}
+		})
+	}
+}
+
+// sealedCheckpoint returns the checkpoint record that would follow lines.
+func sealedCheckpoint(t *testing.T, key ed25519.PrivateKey, lines []string) string {
+	t.Helper()
+	chain := NewChain(key, "")
+	seq, _, _ := parseSeal(lines[len(lines)-1])
+	chain.seq = seq
+	chain.prev = hashLine(lines[len(lines)-1])
+	return chain.Checkpoint(FormatText)
+}
+
+func replaceAt(lines []string, i int, line string) []string {
+	out := slices.Clone(lines)
+	out[i] = line
+	return out
+}
+
+func TestVerifyFile_Rotated(t *testing.T) {
+	key := GenerateChainKey()
+	path := filepath.Join(t.TempDir(), "audit.log")
+	f, err := clog.OpenRotatingFile(path, clog.RotateConfig{MaxSize: 600})
+	if err != nil {
+		t.Fatalf("OpenRotatingFile: %v", err)
+	}
+	logger := NewLogger(f)
+	logger.SetChain(NewChain(key, CheckpointPath(path)))
+	for range 8 {
+		if err := logger.LogRequest("r", "p", "c", "make"); err != nil {
+			t.Fatalf("LogRequest: %v", err)
+		}
+		time.Sleep(time.Millisecond) // Distinct rotation timestamps
+	}
+	if err := logger.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	rotated, _ := clog.RotatedFiles(path)
+	if len(rotated) < 2 {
+		t.Fatalf("got %d rotated files, want at least 2", len(rotated))
+	}
+	first, err := os.ReadFile(path)
+	if err != nil || !strings.Contains(string(first), " AUDIT ROTATE ") {
+		t.Fatalf("log does not start with a rotation record: %q, %v", first, err)
+	}
+
+	report, err := VerifyFile(path, publicKey(key))
+	if err != nil || report.Break != nil {
+		t.Fatalf("VerifyFile = %+v, %v; want intact", report, err)
+	}
+	if report.Files != len(rotated)+1 || report.Records != 8+len(rotated) {
+		t.Errorf("report = %+v, want %d files and %d records", report, len(rotated)+1, 8+len(rotated))
+	}
+
+	// Retention deleting the oldest file leaves an intact chain.
+	if err := os.Remove(rotated[0]); err != nil {
+		t.Fatal(err)
+	}
+	if report, err := VerifyFile(path, publicKey(key)); err != nil || report.Break != nil {
+		t.Fatalf("after deleting oldest file, VerifyFile = %+v, %v; want intact", report, err)
+	}
+
+	// Truncating a rotated file breaks the link to the next one.
+	data, err := os.ReadFile(rotated[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.SplitAfter(strings.TrimSuffix(string(data), "\n"), "\n")
+	if err := os.WriteFile(rotated[1], []byte(strings.Join(lines[:len(lines)-1], "")), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	report, err = VerifyFile(path, publicKey(key))
+	if err != nil || report.Break == nil || report.Break.File == rotated[1] {
+		t.Fatalf("after truncating %s, VerifyFile = %+v, %v; want a break in the next file", rotated[1], report, err)
+	}
+}
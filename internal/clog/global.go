@@ -32,6 +32,26 @@ func Configure(logPath string, debug, daemonMode bool) error {
 	return nil
 }
 
+// EnableRotation reopens the global logger's file output at logPath as a
+// RotatingFile, closing the previous file output. The guardian calls it once
+// its configuration is loaded.
+func EnableRotation(logPath string, cfg RotateConfig) error {
+	f, err := OpenRotatingFile(logPath, cfg)
+	if err != nil {
+		return err
+	}
+
+	std.mu.Lock()
+	old := std.fileWriter
+	std.fileWriter = f
+	std.mu.Unlock()
+
+	if closer, ok := old.(io.Closer); ok {
+		_ = closer.Close()
+	}
+	return nil
+}
+
 // ConfigureWithDefaults sets up the global logger with default log path.
 // This is a convenience function for common initialization.
 func ConfigureWithDefaults(debug, daemonMode bool) error {
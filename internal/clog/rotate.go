@@ -0,0 +1,311 @@
+package clog
+
+import (
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// rotatedTimeFormat is the timestamp suffix of rotated files. It is fixed
+// width so that rotated files sort oldest first by name.
+const rotatedTimeFormat = "20060102T150405.000000000Z"
+
+// gzipSuffix is appended to rotated files once they are compressed.
+const gzipSuffix = ".gz"
+
+// RotateConfig controls when a RotatingFile starts a new file and how long
+// rotated files are kept. Zero values disable each limit.
+type RotateConfig struct {
+	MaxSize  int64         // Rotate before a write would grow the file past this many bytes
+	MaxAge   time.Duration // Rotate once the file has been written to for this long
+	Compress bool          // gzip rotated files
+	Keep     int           // Number of rotated files to keep
+	KeepFor  time.Duration // Delete rotated files older than this
+}
+
+// Enabled reports whether any rotation limit is set.
+func (c RotateConfig) Enabled() bool {
+	return c.MaxSize > 0 || c.MaxAge > 0
+}
+
+// RotatingFile is a log file opened for appending that moves itself aside
+// to <path>.<timestamp> when it grows past RotateConfig.MaxSize or gets
+// older than RotateConfig.MaxAge, and starts a new file at path. Rotated
+// files are optionally compressed in the background and pruned to the
+// retention limits. Writes are never split across files, so a caller that
+// writes whole lines gets whole lines in every file. It is safe for
+// concurrent use.
+type RotatingFile struct {
+	mu      sync.Mutex
+	path    string
+	cfg     RotateConfig
+	f       *os.File
+	size    int64
+	started time.Time
+	manual  bool // Write does not rotate; the caller calls Rotate
+
+	bg sync.Mutex     // Serializes compression and pruning
+	wg sync.WaitGroup // Tracks background compression
+}
+
+// OpenRotatingFile opens the log file at path for appending, creating it and
+// its parent directories if needed, and applies the retention limits to
+// files already rotated. The age of an existing file is counted from the
+// latest rotation, or from now if it has never been rotated.
+func OpenRotatingFile(path string, cfg RotateConfig) (*RotatingFile, error) {
+	f, err := OpenLogFile(path)
+	if err != nil {
+		return nil, err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		return nil, fmt.Errorf("stat log file: %w", err)
+	}
+
+	r := &RotatingFile{path: path, cfg: cfg, f: f, size: info.Size(), started: time.Now()}
+	if rotated, err := RotatedFiles(path); err == nil && len(rotated) > 0 {
+		if t, ok := rotatedTime(path, rotated[len(rotated)-1]); ok {
+			r.started = t
+		}
+	}
+	r.prune()
+	return r, nil
+}
+
+// Write appends p to the file, rotating first if RotateDue(len(p)) unless
+// rotation is manual. If rotation fails, p is written to the current file.
+func (r *RotatingFile) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if !r.manual && r.dueLocked(len(p)) {
+		_ = r.rotateLocked() //nolint:errcheck // Keep logging to the current file; there is nowhere to report this.
+	}
+	n, err := r.f.Write(p)
+	r.size += int64(n)
+	if err != nil {
+		return n, fmt.Errorf("write log file: %w", err)
+	}
+	return n, nil
+}
+
+// RotateDue reports whether writing n more bytes would rotate the file. An
+// empty file is never rotated.
+func (r *RotatingFile) RotateDue(n int) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.dueLocked(n)
+}
+
+// SetManualRotation stops Write from rotating the file, for callers that
+// check RotateDue and call Rotate themselves because they write a header
+// at the start of each file.
+func (r *RotatingFile) SetManualRotation(manual bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.manual = manual
+}
+
+// Rotate moves the current file aside and starts a new one, whether or not
+// rotation is due.
+func (r *RotatingFile) Rotate() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.rotateLocked()
+}
+
+// Close closes the current file and waits for background compression.
+func (r *RotatingFile) Close() error {
+	r.mu.Lock()
+	err := r.f.Close()
+	r.mu.Unlock()
+
+	r.wg.Wait()
+	if err != nil {
+		return fmt.Errorf("close log file: %w", err)
+	}
+	return nil
+}
+
+// dueLocked implements RotateDue. The caller must hold r.mu.
+func (r *RotatingFile) dueLocked(n int) bool {
+	if r.size == 0 {
+		return false
+	}
+	if r.cfg.MaxSize > 0 && r.size+int64(n) > r.cfg.MaxSize {
+		return true
+	}
+	return r.cfg.MaxAge > 0 && time.Since(r.started) >= r.cfg.MaxAge
+}
+
+// rotateLocked implements Rotate. The caller must hold r.mu.
+func (r *RotatingFile) rotateLocked() error {
+	now := time.Now()
+	rotated := r.path + "." + now.UTC().Format(rotatedTimeFormat)
+	if err := os.Rename(r.path, rotated); err != nil {
+		return fmt.Errorf("rotate log file: %w", err)
+	}
+	f, err := OpenLogFile(r.path)
+	if err != nil {
+		// Keep appending to the renamed file rather than losing writes.
+		return err
+	}
+	_ = r.f.Close()
+	r.f, r.size, r.started = f, 0, now
+
+	if !r.cfg.Compress {
+		r.prune()
+		return nil
+	}
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+		if err := compressFile(rotated); err != nil {
+			Warn("failed to compress rotated log %s: %v", rotated, err)
+		}
+		r.prune()
+	}()
+	return nil
+}
+
+// prune deletes rotated files beyond the retention limits.
+func (r *RotatingFile) prune() {
+	if r.cfg.Keep <= 0 && r.cfg.KeepFor <= 0 {
+		return
+	}
+
+	r.bg.Lock()
+	defer r.bg.Unlock()
+
+	rotated, err := RotatedFiles(r.path)
+	if err != nil {
+		return
+	}
+	for i, name := range rotated {
+		expired := false
+		if t, ok := rotatedTime(r.path, name); ok && r.cfg.KeepFor > 0 {
+			expired = time.Since(t) > r.cfg.KeepFor
+		}
+		if expired || (r.cfg.Keep > 0 && len(rotated)-i > r.cfg.Keep) {
+			_ = os.Remove(strings.TrimSuffix(name, gzipSuffix))
+			_ = os.Remove(strings.TrimSuffix(name, gzipSuffix) + gzipSuffix)
+		}
+	}
+}
+
+// compressFile replaces the file at path with a gzip copy at path.gz.
+func compressFile(path string) error {
+	in, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("open: %w", err)
+	}
+	defer func() { _ = in.Close() }()
+
+	tmp := path + gzipSuffix + ".tmp"
+	out, err := os.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("create: %w", err)
+	}
+	defer func() { _ = os.Remove(tmp) }()
+
+	zw := gzip.NewWriter(out)
+	if _, err := io.Copy(zw, in); err != nil {
+		_ = out.Close()
+		return fmt.Errorf("compress: %w", err)
+	}
+	if err := zw.Close(); err != nil {
+		_ = out.Close()
+		return fmt.Errorf("compress: %w", err)
+	}
+	if err := out.Close(); err != nil {
+		return fmt.Errorf("close: %w", err)
+	}
+	if err := os.Rename(tmp, path+gzipSuffix); err != nil {
+		return fmt.Errorf("rename: %w", err)
+	}
+	if err := os.Remove(path); err != nil {
+		return fmt.Errorf("remove: %w", err)
+	}
+	return nil
+}
+
+// RotatedFiles returns the rotated files of the log at path, oldest first.
+// Compressed files end in .gz. While a file is being compressed, only the
+// uncompressed original is listed.
+func RotatedFiles(path string) ([]string, error) {
+	matches, err := filepath.Glob(globEscape(path) + ".*")
+	if err != nil {
+		return nil, fmt.Errorf("list rotated logs: %w", err)
+	}
+	var rotated []string
+	for _, name := range matches {
+		if _, ok := rotatedTime(path, name); !ok {
+			continue
+		}
+		if strings.HasSuffix(name, gzipSuffix) && slices.Contains(matches, strings.TrimSuffix(name, gzipSuffix)) {
+			continue
+		}
+		rotated = append(rotated, name)
+	}
+	slices.Sort(rotated)
+	return rotated, nil
+}
+
+// OpenRotated opens a file returned by RotatedFiles, decompressing it if
+// needed.
+func OpenRotated(name string) (io.ReadCloser, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, fmt.Errorf("open rotated log: %w", err)
+	}
+	if !strings.HasSuffix(name, gzipSuffix) {
+		return f, nil
+	}
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		_ = f.Close()
+		return nil, fmt.Errorf("open rotated log %s: %w", name, err)
+	}
+	return &gzipFile{Reader: zr, f: f}, nil
+}
+
+// gzipFile closes both a gzip reader and its file.
+type gzipFile struct {
+	*gzip.Reader
+	f *os.File
+}
+
+func (g *gzipFile) Close() error {
+	return errors.Join(g.Reader.Close(), g.f.Close())
+}
+
+// rotatedTime returns the rotation time encoded in the name of a rotated
+// file of the log at path.
+func rotatedTime(path, name string) (time.Time, bool) {
+	suffix, ok := strings.CutPrefix(name, path+".")
+	if !ok {
+		return time.Time{}, false
+	}
+	t, err := time.Parse(rotatedTimeFormat, strings.TrimSuffix(suffix, gzipSuffix))
+	return t, err == nil
+}
+
+// globEscape escapes glob metacharacters in a literal path.
+func globEscape(path string) string {
+	var b strings.Builder
+	for _, c := range path {
+		if strings.ContainsRune(`*?[\`, c) {
+			b.WriteRune('\\')
+		}
+		b.WriteRune(c)
+	}
+	return b.String()
+}
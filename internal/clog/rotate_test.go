@@ -0,0 +1,123 @@
+package clog
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRotatingFile_RotatesBySize(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	r, err := OpenRotatingFile(path, RotateConfig{MaxSize: 10})
+	if err != nil {
+		t.Fatalf("OpenRotatingFile: %v", err)
+	}
+
+	for _, line := range []string{"one\n", "two\n", "three\n"} {
+		if _, err := r.Write([]byte(line)); err != nil {
+			t.Fatalf("Write: %v", err)
+		}
+	}
+	if err := r.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	rotated, err := RotatedFiles(path)
+	if err != nil || len(rotated) != 1 {
+		t.Fatalf("RotatedFiles = %v, %v; want one file", rotated, err)
+	}
+	assertContent(t, rotated[0], "one\ntwo\n")
+	assertContent(t, path, "three\n")
+}
+
+func TestRotatingFile_RotatesByAge(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	r, err := OpenRotatingFile(path, RotateConfig{MaxAge: time.Hour})
+	if err != nil {
+		t.Fatalf("OpenRotatingFile: %v", err)
+	}
+	defer func() { _ = r.Close() }()
+
+	_, _ = r.Write([]byte("old\n"))
+	if r.RotateDue(4) {
+		t.Fatal("RotateDue = true for a new file")
+	}
+	r.started = time.Now().Add(-2 * time.Hour)
+	if !r.RotateDue(4) {
+		t.Fatal("RotateDue = false for a file past MaxAge")
+	}
+	_, _ = r.Write([]byte("new\n"))
+
+	assertContent(t, path, "new\n")
+}
+
+func TestRotatingFile_CompressAndKeep(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	r, err := OpenRotatingFile(path, RotateConfig{MaxSize: 1, Compress: true, Keep: 2})
+	if err != nil {
+		t.Fatalf("OpenRotatingFile: %v", err)
+	}
+	for _, line := range []string{"a\n", "b\n", "c\n", "d\n"} {
+		_, _ = r.Write([]byte(line))
+		time.Sleep(time.Millisecond) // Distinct rotation timestamps
+	}
+	if err := r.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	rotated, err := RotatedFiles(path)
+	if err != nil || len(rotated) != 2 {
+		t.Fatalf("RotatedFiles = %v, %v; want two files", rotated, err)
+	}
+	for i, want := range []string{"b\n", "c\n"} {
+		if !strings.HasSuffix(rotated[i], ".gz") {
+			t.Errorf("rotated[%d] = %s, want .gz", i, rotated[i])
+		}
+		assertContent(t, rotated[i], want)
+	}
+	assertContent(t, path, "d\n")
+}
+
+func TestRotatingFile_KeepForPrunesOnOpen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	old := path + "." + time.Now().Add(-48*time.Hour).UTC().Format(rotatedTimeFormat)
+	recent := path + "." + time.Now().Add(-time.Hour).UTC().Format(rotatedTimeFormat)
+	for _, name := range []string{old, recent, path + ".checkpoint"} {
+		if err := os.WriteFile(name, []byte("x\n"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	r, err := OpenRotatingFile(path, RotateConfig{MaxSize: 100, KeepFor: 24 * time.Hour})
+	if err != nil {
+		t.Fatalf("OpenRotatingFile: %v", err)
+	}
+	defer func() { _ = r.Close() }()
+
+	rotated, _ := RotatedFiles(path)
+	if len(rotated) != 1 || rotated[0] != recent {
+		t.Errorf("RotatedFiles = %v, want [%s]", rotated, recent)
+	}
+	if _, err := os.Stat(path + ".checkpoint"); err != nil {
+		t.Errorf("unrelated file was removed: %v", err)
+	}
+}
+
+func assertContent(t *testing.T, name, want string) {
+	t.Helper()
+	rc, err := OpenRotated(name)
+	if err != nil {
+		t.Fatalf("open %s: %v", name, err)
+	}
+	defer func() { _ = rc.Close() }()
+	got, err := io.ReadAll(rc)
+	if err != nil {
+		t.Fatalf("read %s: %v", name, err)
+	}
+	if string(got) != want {
+		t.Errorf("%s = %q, want %q", filepath.Base(name), got, want)
+	}
+}
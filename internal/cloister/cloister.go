@@ -3,6 +3,7 @@
 package cloister
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"os"
@@ -13,6 +14,9 @@ import (
 	"github.com/xdg/cloister/internal/container"
 	"github.com/xdg/cloister/internal/docker"
 	"github.com/xdg/cloister/internal/guardian"
+	"github.com/xdg/cloister/internal/project"
+	"github.com/xdg/cloister/internal/pty"
+	"github.com/xdg/cloister/internal/sidechannel"
 	"github.com/xdg/cloister/internal/term"
 	"github.com/xdg/cloister/internal/token"
 	"github.com/xdg/cloister/internal/worktree"
@@ -47,6 +51,7 @@ type ContainerManager interface {
 	StartContainer(containerName string) error
 	Stop(containerName string) error
 	Attach(containerName string) (int, error)
+	AttachFiltered(containerName string, filter pty.InputFilter, out io.Writer) (int, error)
 	IsRunning(name string) (bool, error)
 }
 
@@ -79,8 +84,9 @@ type GuardianManager interface {
 	// Includes the worktree path for hostexec workdir validation.
 	RegisterTokenFull(token, cloisterName, projectName, worktreePath string) error
 
-	// RevokeToken revokes a token from the guardian.
-	RevokeToken(token string) error
+	// RevokeToken revokes a token from the guardian and returns the report
+	// of the cloister's session, if the guardian has one.
+	RevokeToken(token string) (*guardian.SessionReport, error)
 }
 
 // defaultGuardianManager implements GuardianManager using the real guardian package.
@@ -102,7 +108,7 @@ func (defaultGuardianManager) RegisterTokenFull(tok, cloisterName, projectName,
 }
 
 // RevokeToken delegates to the real guardian package.
-func (defaultGuardianManager) RevokeToken(tok string) error {
+func (defaultGuardianManager) RevokeToken(tok string) (*guardian.SessionReport, error) {
 	return guardian.RevokeToken(tok)
 }
 
@@ -124,8 +130,10 @@ type options struct {
 	agent        agent.Agent
 	registry     RegistryStore
 	worktreeOps  WorktreeOperations
+	stdout       io.Writer
 	stderr       io.Writer
 	globalConfig *config.GlobalConfig // Pre-loaded config (avoids double-load)
+	approvals    sidechannel.API      // Approval side-channel for Attach (nil = disabled)
 }
 
 // WithManager sets a custom container manager for dependency injection.
@@ -168,6 +176,14 @@ func WithRegistryStore(r RegistryStore) Option {
 	}
 }
 
+// WithStdout sets a custom writer for the attached shell's output and the
+// approval side-channel's terminal title. If not set, os.Stdout is used.
+func WithStdout(w io.Writer) Option {
+	return func(o *options) {
+		o.stdout = w
+	}
+}
+
 // WithStderr sets a custom writer for stderr output (warnings, deprecation notices).
 // If not set, os.Stderr is used.
 func WithStderr(w io.Writer) Option {
@@ -192,6 +208,16 @@ func WithGlobalConfig(cfg *config.GlobalConfig) Option {
 	}
 }
 
+// WithApprovalPrompts enables the approval side-channel for Attach and
+// AttachExisting: pending requests from the attached cloister are announced
+// in the host terminal title and can be decided with hotkeys (see package
+// sidechannel). If not set, approvals are only available in the web UI.
+func WithApprovalPrompts(api sidechannel.API) Option {
+	return func(o *options) {
+		o.approvals = api
+	}
+}
+
 // defaultWorktreeOps implements WorktreeOperations using the real worktree package.
 type defaultWorktreeOps struct{}
 
@@ -232,6 +258,9 @@ func applyOptions(opts ...Option) *options {
 	if o.worktreeOps == nil {
 		o.worktreeOps = defaultWorktreeOps{}
 	}
+	if o.stdout == nil {
+		o.stdout = os.Stdout
+	}
 	if o.stderr == nil {
 		o.stderr = os.Stderr
 	}
@@ -322,7 +351,7 @@ func Start(opts StartOptions, options ...Option) (containerID, tok string, err e
 	// If container creation fails after token registration, revoke the token
 	defer func() {
 		if err != nil {
-			if revokeErr := deps.guardian.RevokeToken(tok); revokeErr != nil {
+			if _, revokeErr := deps.guardian.RevokeToken(tok); revokeErr != nil {
 				clog.Warn("failed to revoke token on cleanup: %v", revokeErr)
 			}
 			if removeErr := store.Remove(cloisterName); removeErr != nil {
@@ -377,12 +406,20 @@ func registerInRegistryStore(deps *options, cloisterName string, opts StartOptio
 		return err
 	}
 
+	// The starting commit is recorded for the session report; a project
+	// path that is not a git checkout just has none.
+	var startCommit string
+	if opts.ProjectPath != "" {
+		startCommit, _ = project.HeadCommit(opts.ProjectPath) //nolint:errcheck // best-effort
+	}
+
 	if err := reg.Register(RegistryEntry{
 		CloisterName: cloisterName,
 		ProjectName:  opts.ProjectName,
 		Branch:       opts.BranchName,
 		HostPath:     opts.ProjectPath,
 		IsWorktree:   opts.IsWorktree,
+		StartCommit:  startCommit,
 	}); err != nil {
 		return err
 	}
@@ -474,6 +511,10 @@ func resolveAgentAndEnv(deps *options, opts StartOptions, tok string) (agent.Age
 		}
 	}
 
+	if globalCfg != nil && globalCfg.Request.MessagesFile != "" {
+		envVars = append(envVars, "CLOISTER_MESSAGES_FILE="+globalCfg.Request.MessagesFile)
+	}
+
 	agentImpl, agentName, agentCfg := resolveAgent(deps, globalCfg, opts.Agent)
 
 	if agentImpl != nil {
@@ -532,21 +573,35 @@ func resolveAgent(deps *options, globalCfg *config.GlobalConfig, cliAgent string
 //
 //	Stop(containerName, tok, WithManager(mockManager), WithGuardian(mockGuardian))
 func Stop(containerName, tok string, options ...Option) error {
+	_, err := StopWithReport(containerName, tok, options...)
+	return err
+}
+
+// StopWithReport stops a cloister like Stop and returns a report of its
+// session: the guardian's record of it, returned when the token is revoked,
+// and the git changes in its worktree since it started. The report is nil
+// if neither is available. It is returned even if stopping the container
+// fails.
+func StopWithReport(containerName, tok string, options ...Option) (*SessionReport, error) {
 	deps := applyOptions(options...)
+	cloisterName := container.NameToCloisterName(containerName)
+	report := &SessionReport{Cloister: cloisterName}
+
 	// Step 1: Revoke the token from guardian (if provided)
 	// We ignore revocation errors and continue with container stop.
 	// The token will become orphaned but won't cause security issues
 	// since the container will no longer exist.
 	if tok != "" {
-		if revokeErr := deps.guardian.RevokeToken(tok); revokeErr != nil {
+		session, revokeErr := deps.guardian.RevokeToken(tok)
+		if revokeErr != nil {
 			clog.Warn("failed to revoke token: %v", revokeErr)
 		}
+		report.Guardian = session
 	}
 
 	// Step 2: Remove the token from disk (best effort)
 	// Store files are keyed by cloister name, not container name.
 	if store, err := getTokenStore(); err == nil {
-		cloisterName := container.NameToCloisterName(containerName)
 		if removeErr := store.Remove(cloisterName); removeErr != nil {
 			clog.Warn("failed to remove token from disk: %v", removeErr)
 		}
@@ -555,11 +610,33 @@ func Stop(containerName, tok string, options ...Option) error {
 	// Step 3: Stop and remove the container
 	stopErr := deps.manager.Stop(containerName)
 
-	// Step 4: Remove from cloister registry (best-effort)
-	cloisterName := container.NameToCloisterName(containerName)
+	// Step 4: Collect git changes, then remove from cloister registry (best-effort)
+	report.Git = gitChangesSinceStart(deps, cloisterName)
 	removeFromRegistryStore(deps, cloisterName)
 
-	return stopErr
+	if report.Guardian == nil && report.Git == nil {
+		return nil, stopErr
+	}
+	return report, stopErr
+}
+
+// gitChangesSinceStart returns the commits made in a cloister's worktree
+// since the commit recorded when it started, or nil if none was recorded.
+func gitChangesSinceStart(deps *options, cloisterName string) *project.Changes {
+	reg, err := deps.registry.LoadRegistry()
+	if err != nil {
+		return nil
+	}
+	entry := reg.FindByName(cloisterName)
+	if entry == nil || entry.StartCommit == "" {
+		return nil
+	}
+	changes, err := project.ChangesSince(entry.HostPath, entry.StartCommit)
+	if err != nil {
+		clog.Warn("failed to collect git changes for session report: %v", err)
+		return nil
+	}
+	return changes
 }
 
 // Attach attaches an interactive shell to a running cloister container.
@@ -574,12 +651,15 @@ func Stop(containerName, tok string, options ...Option) error {
 // Ctrl+C inside the container is handled by the shell; it does not terminate
 // the attachment or kill the container.
 //
+// With WithApprovalPrompts, the cloister's approval requests are surfaced in
+// the host terminal while attached.
+//
 // Options can be used to inject dependencies for testing:
 //
 //	Attach(containerID, WithManager(mockManager))
 func Attach(containerID string, options ...Option) (exitCode int, err error) {
 	deps := applyOptions(options...)
-	return deps.manager.Attach(containerID)
+	return attach(deps, containerID)
 }
 
 // AttachExisting attaches to an existing cloister container, starting it first
@@ -606,6 +686,34 @@ func AttachExisting(containerName string, options ...Option) (started bool, exit
 		started = true
 	}
 
-	exitCode, err = deps.manager.Attach(containerName)
+	exitCode, err = attach(deps, containerName)
 	return started, exitCode, err
 }
+
+// attach attaches to the container, running the approval side-channel
+// alongside the shell if one is configured.
+func attach(deps *options, containerName string) (int, error) {
+	if deps.approvals == nil {
+		return deps.manager.Attach(containerName)
+	}
+
+	// The shell's output and title updates share one writer so that title
+	// sequences never land inside a chunk of shell output.
+	out := pty.NewSyncWriter(deps.stdout)
+	w := sidechannel.New(deps.approvals, container.NameToCloisterName(containerName), out)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		if err := w.Run(ctx); err != nil && ctx.Err() == nil {
+			clog.Debug("approval side-channel stopped: %v", err)
+		}
+	}()
+	defer func() {
+		cancel()
+		<-done
+		w.Close()
+	}()
+
+	return deps.manager.AttachFiltered(containerName, w.Filter, out)
+}
@@ -2,7 +2,9 @@ package cloister
 
 import (
 	"bytes"
+	"context"
 	"errors"
+	"io"
 	"os"
 	"slices"
 	"strings"
@@ -11,6 +13,9 @@ import (
 	"github.com/xdg/cloister/internal/agent"
 	"github.com/xdg/cloister/internal/config"
 	"github.com/xdg/cloister/internal/container"
+	"github.com/xdg/cloister/internal/guardian"
+	"github.com/xdg/cloister/internal/guardian/approval"
+	"github.com/xdg/cloister/internal/pty"
 	"github.com/xdg/cloister/internal/term"
 	"github.com/xdg/cloister/internal/testutil"
 )
@@ -55,6 +60,8 @@ type mockManager struct {
 	stopError             error
 	attachCalled          bool
 	attachContainerName   string
+	attachFilter          pty.InputFilter
+	attachOut             io.Writer
 	attachExitCode        int
 	attachError           error
 	isRunningResult       bool
@@ -94,6 +101,12 @@ func (m *mockManager) Attach(containerName string) (int, error) {
 	return m.attachExitCode, m.attachError
 }
 
+func (m *mockManager) AttachFiltered(containerName string, filter pty.InputFilter, out io.Writer) (int, error) {
+	m.attachFilter = filter
+	m.attachOut = out
+	return m.Attach(containerName)
+}
+
 func (m *mockManager) IsRunning(_ string) (bool, error) {
 	return m.isRunningResult, m.isRunningError
 }
@@ -119,6 +132,50 @@ func TestWithManager_InjectionWorks(t *testing.T) {
 	}
 }
 
+// idleApprovalAPI is a sidechannel.API with nothing pending whose event
+// stream stays open until cancelled.
+type idleApprovalAPI struct{}
+
+func (idleApprovalAPI) Pending(context.Context) ([]approval.PendingCommand, error) { return nil, nil }
+func (idleApprovalAPI) PendingDomains(context.Context) ([]approval.PendingDomain, error) {
+	return nil, nil
+}
+func (idleApprovalAPI) Approve(context.Context, string) error                          { return nil }
+func (idleApprovalAPI) Deny(context.Context, string, string) error                     { return nil }
+func (idleApprovalAPI) ApproveDomain(context.Context, string, string, string) error    { return nil }
+func (idleApprovalAPI) DenyDomain(context.Context, string, string, bool, string) error { return nil }
+func (idleApprovalAPI) Subscribe(ctx context.Context, _ func(approval.Event)) error {
+	<-ctx.Done()
+	return nil
+}
+
+func TestAttach_WithApprovalPrompts(t *testing.T) {
+	mock := &mockManager{attachExitCode: 3}
+	var stdout bytes.Buffer
+
+	exitCode, err := Attach("cloister-myproject", WithManager(mock), WithStdout(&stdout), WithApprovalPrompts(idleApprovalAPI{}))
+	if err != nil {
+		t.Fatalf("Attach() returned error: %v", err)
+	}
+	if exitCode != 3 {
+		t.Errorf("Attach() exitCode = %d, want 3", exitCode)
+	}
+	if mock.attachFilter == nil {
+		t.Error("Attach() did not pass an input filter to the manager")
+	}
+	// The terminal title is reset when the side-channel shuts down.
+	if got := stdout.String(); got != "\x1b]2;cloister: myproject\a" {
+		t.Errorf("stdout = %q, want title reset", got)
+	}
+	// Shell output goes to the same terminal, through the same writer.
+	if _, err := mock.attachOut.Write([]byte("shell output")); err != nil {
+		t.Fatalf("write shell output: %v", err)
+	}
+	if got := stdout.String(); !strings.HasSuffix(got, "shell output") {
+		t.Errorf("stdout = %q, want shell output", got)
+	}
+}
+
 func TestAttach_WithMockManager_ReturnsError(t *testing.T) {
 	// Test that errors from the manager are propagated
 	expectedErr := errors.New("attach failed")
@@ -209,6 +266,7 @@ type mockGuardian struct {
 	registerTokenErr     error
 	registerTokenFullErr error
 	revokeTokenErr       error
+	sessionReport        *guardian.SessionReport
 
 	// Captured args from RegisterTokenFull
 	registeredCloisterName string
@@ -238,10 +296,10 @@ func (m *mockGuardian) RegisterTokenFull(_, cloisterName, projectName, worktreeP
 	return m.registerTokenErr
 }
 
-func (m *mockGuardian) RevokeToken(tok string) error {
+func (m *mockGuardian) RevokeToken(tok string) (*guardian.SessionReport, error) {
 	m.revokeTokenCalled = true
 	m.revokedToken = tok
-	return m.revokeTokenErr
+	return m.sessionReport, m.revokeTokenErr
 }
 
 // mockConfigLoader is a test double for ConfigLoader.
@@ -421,6 +479,36 @@ func TestStart_WithAPIKeyAuth(t *testing.T) {
 	}
 }
 
+// TestStart_MessagesFileEnv verifies that request.messages_file is passed to
+// the container so hostexec can record denial messages.
+func TestStart_MessagesFileEnv(t *testing.T) {
+	mockMgr := &mockManager{createResult: "container-123"}
+	mockCfgLoader := &mockConfigLoader{
+		config: &config.GlobalConfig{
+			Request: config.RequestConfig{MessagesFile: "/home/cloister/.cloister/messages.md"},
+			Agents:  map[string]config.AgentConfig{"claude": {AuthMethod: "api_key", APIKey: "sk-ant-api01-test-key"}},
+		},
+	}
+
+	t.Setenv("HOME", t.TempDir())
+	testutil.IsolateXDGDirs(t)
+
+	_, _, err := Start(StartOptions{ProjectPath: "/path/to/project", ProjectName: "testproject", BranchName: "main"},
+		WithManager(mockMgr),
+		WithGuardian(&mockGuardian{}),
+		WithConfigLoader(mockCfgLoader),
+		WithAgent(&mockAgent{name: "claude"}),
+	)
+	if err != nil {
+		t.Fatalf("Start() returned error: %v", err)
+	}
+
+	envVars := mockMgr.createConfig.EnvVars
+	if !slices.Contains(envVars, "CLOISTER_MESSAGES_FILE=/home/cloister/.cloister/messages.md") {
+		t.Errorf("CLOISTER_MESSAGES_FILE not found in container env vars: %v", envVars)
+	}
+}
+
 // TestStart_WithTokenAuthCallsSetup verifies that "token" auth triggers agent setup.
 func TestStart_WithTokenAuthCallsSetup(t *testing.T) {
 	mockMgr := &mockManager{createResult: "container-123"}
@@ -1133,6 +1221,41 @@ func TestStop_RemovesFromRegistryStore(t *testing.T) {
 	}
 }
 
+// TestStopWithReport_ReturnsGuardianSession verifies that StopWithReport returns
+// the session report from token revocation.
+func TestStopWithReport_ReturnsGuardianSession(t *testing.T) {
+	testutil.IsolateXDGDirs(t)
+
+	session := &guardian.SessionReport{Cloister: "testproject"}
+	mockGuard := &mockGuardian{sessionReport: session}
+	mockReg := &mockRegistryStore{
+		registry: &Registry{
+			Cloisters: []RegistryEntry{{CloisterName: "testproject", ProjectName: "testproject"}},
+		},
+	}
+
+	report, err := StopWithReport("cloister-testproject", "tok",
+		WithManager(&mockManager{}),
+		WithGuardian(mockGuard),
+		WithRegistryStore(mockReg),
+	)
+	if err != nil {
+		t.Fatalf("StopWithReport() returned error: %v", err)
+	}
+	if report == nil || report.Cloister != "testproject" || report.Guardian != session {
+		t.Errorf("report = %+v, want the guardian's session", report)
+	}
+	if report != nil && report.Git != nil {
+		t.Errorf("report.Git = %+v, want nil without a start commit", report.Git)
+	}
+
+	// Without a token there is no guardian session and no report.
+	report, err = StopWithReport("cloister-testproject", "", WithManager(&mockManager{}), WithRegistryStore(mockReg))
+	if err != nil || report != nil {
+		t.Errorf("StopWithReport() without token = %+v, %v; want nil, nil", report, err)
+	}
+}
+
 // TestStop_RegistryErrorDoesNotFailStop verifies that registry errors don't fail Stop().
 func TestStop_RegistryErrorDoesNotFailStop(t *testing.T) {
 	testutil.IsolateXDGDirs(t)
@@ -42,6 +42,7 @@ type RegistryEntry struct {
 	HostPath     string    `yaml:"host_path"`        // absolute path on host
 	IsWorktree   bool      `yaml:"is_worktree"`
 	CreatedAt    time.Time `yaml:"created_at"`
+	StartCommit  string    `yaml:"start_commit,omitempty"` // HEAD when last started, for the session report
 }
 
 // Registry contains the list of known cloisters.
@@ -0,0 +1,189 @@
+package cloister
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/xdg/cloister/internal/audit"
+	"github.com/xdg/cloister/internal/guardian"
+	"github.com/xdg/cloister/internal/project"
+)
+
+// reportListLimit is the number of entries a session report lists in each
+// section before summarizing the rest.
+const reportListLimit = 10
+
+// SessionReport summarizes a cloister's session when it is stopped.
+type SessionReport struct {
+	Cloister string
+
+	// Guardian is the guardian's record of the session's traffic and
+	// decisions. It is nil if the guardian was not running or had no
+	// session for the cloister.
+	Guardian *guardian.SessionReport
+
+	// Git describes the changes in the cloister's worktree since it
+	// started. It is nil if the starting commit is unknown.
+	Git *project.Changes
+}
+
+// Format writes the report as text.
+func (r *SessionReport) Format(w io.Writer) {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Session report for %s\n", r.Cloister)
+
+	if g := r.Guardian; g != nil {
+		fmt.Fprintf(&b, "  Duration: %s (%s to %s)\n", g.Duration().Round(time.Second),
+			g.Started.Local().Format(time.DateTime), g.Ended.Local().Format(time.DateTime))
+		r.formatDomains(&b)
+		r.formatDecisions(&b)
+	}
+
+	if c := r.Git; c != nil {
+		fmt.Fprintf(&b, "  Git: %d commit(s), %d file(s) changed (+%d -%d) since %s\n",
+			c.Commits, c.FilesChanged, c.Insertions, c.Deletions, shortCommit(c.Since))
+	}
+	_, _ = io.WriteString(w, b.String())
+}
+
+// Save writes the report as text to <dir>/<cloister>-session-<time>.txt and
+// returns the file's path.
+func (r *SessionReport) Save(dir string) (string, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return "", fmt.Errorf("create session report directory: %w", err)
+	}
+	var buf bytes.Buffer
+	r.Format(&buf)
+	name := fmt.Sprintf("%s-session-%s.txt", r.Cloister, time.Now().Format("20060102T150405"))
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, buf.Bytes(), 0o600); err != nil {
+		return "", fmt.Errorf("write session report: %w", err)
+	}
+	return path, nil
+}
+
+// formatDomains writes the domains section.
+func (r *SessionReport) formatDomains(b *strings.Builder) {
+	domains := r.Guardian.Domains
+	var conns int
+	var sent, received int64
+	for _, d := range domains {
+		conns += d.Connections
+		sent += d.BytesSent
+		received += d.BytesReceived
+	}
+	fmt.Fprintf(b, "  Domains: %d contacted, %d connection(s), %s sent, %s received\n",
+		len(domains), conns, formatBytes(sent), formatBytes(received))
+	for i, d := range domains {
+		if i == reportListLimit {
+			fmt.Fprintf(b, "    ... and %d more\n", len(domains)-i)
+			break
+		}
+		fmt.Fprintf(b, "    %-30s %4d conn  %9s sent  %9s received\n",
+			d.Domain, d.Connections, formatBytes(d.BytesSent), formatBytes(d.BytesReceived))
+	}
+}
+
+// formatDecisions writes the approvals, commands and denials sections.
+func (r *SessionReport) formatDecisions(b *strings.Builder) {
+	var approvals, commands, refusals []string
+	for _, d := range r.Guardian.Decisions {
+		switch {
+		case d.Outcome == audit.OutcomeDenied || d.Outcome == audit.OutcomeTimeout:
+			refusals = append(refusals, describeRefusal(&d))
+		case d.Type == audit.DecisionDomain:
+			approvals = append(approvals, describeApproval(&d, d.Domain))
+		default:
+			if d.Outcome == audit.OutcomeApproved {
+				approvals = append(approvals, describeApproval(&d, fmt.Sprintf("%q", d.Cmd)))
+			}
+			commands = append(commands, describeCommand(&d))
+		}
+	}
+	formatList(b, "New approvals", approvals)
+	formatList(b, "Hostexec commands", commands)
+	formatList(b, "Denials and timeouts", refusals)
+}
+
+// formatList writes a titled section of a report, or "none" if it is empty.
+func formatList(b *strings.Builder, title string, items []string) {
+	if len(items) == 0 {
+		fmt.Fprintf(b, "  %s: none\n", title)
+		return
+	}
+	fmt.Fprintf(b, "  %s: %d\n", title, len(items))
+	for i, item := range items {
+		if i == reportListLimit {
+			fmt.Fprintf(b, "    ... and %d more\n", len(items)-i)
+			return
+		}
+		fmt.Fprintf(b, "    %s\n", item)
+	}
+}
+
+// describeApproval describes a manual approval of target.
+func describeApproval(d *audit.Decision, target string) string {
+	s := d.Type + " " + target
+	if d.Scope != "" {
+		s += " (" + d.Scope + ")"
+	}
+	if d.Actor != "" {
+		s += " by " + d.Actor
+	}
+	return s
+}
+
+// describeCommand describes an approved hostexec command and how it exited.
+func describeCommand(d *audit.Decision) string {
+	s := d.Cmd
+	if d.Outcome == audit.OutcomeAutoApproved {
+		s += " (auto-approved)"
+	}
+	if d.ExitCode == nil {
+		return s + ": no exit recorded"
+	}
+	return fmt.Sprintf("%s: exit %d", s, *d.ExitCode)
+}
+
+// describeRefusal describes a denied or timed-out request.
+func describeRefusal(d *audit.Decision) string {
+	target := d.Domain
+	if d.Type == audit.DecisionHostexec {
+		target = fmt.Sprintf("%q", d.Cmd)
+	}
+	s := fmt.Sprintf("%s %s: %s", d.Type, target, d.Outcome)
+	if d.Actor != "" {
+		s += " by " + d.Actor
+	}
+	if d.Reason != "" {
+		s += fmt.Sprintf(" (%s)", d.Reason)
+	}
+	return s
+}
+
+// formatBytes formats a byte count with a binary unit.
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+// shortCommit abbreviates a commit hash for display.
+func shortCommit(commit string) string {
+	if len(commit) > 12 {
+		return commit[:12]
+	}
+	return commit
+}
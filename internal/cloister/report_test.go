@@ -0,0 +1,88 @@
+package cloister
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/xdg/cloister/internal/audit"
+	"github.com/xdg/cloister/internal/guardian"
+	"github.com/xdg/cloister/internal/project"
+)
+
+func testSessionReport() *SessionReport {
+	start := time.Date(2024, 1, 15, 14, 0, 0, 0, time.UTC)
+	exit0, exit2 := 0, 2
+	return &SessionReport{
+		Cloister: "api-main",
+		Guardian: &guardian.SessionReport{
+			Cloister: "api-main",
+			Started:  start,
+			Ended:    start.Add(95 * time.Minute),
+			Domains: []guardian.DomainTraffic{
+				{Domain: "github.com", Connections: 3, BytesSent: 2048, BytesReceived: 3 << 20},
+			},
+			Decisions: []audit.Decision{
+				{Type: audit.DecisionDomain, Domain: "pypi.org", Outcome: audit.OutcomeApproved, Scope: "project", Actor: "alice"},
+				{Type: audit.DecisionHostexec, Cmd: "make test", Outcome: audit.OutcomeAutoApproved, ExitCode: &exit2},
+				{Type: audit.DecisionHostexec, Cmd: "docker ps", Outcome: audit.OutcomeApproved, Actor: "alice", ExitCode: &exit0},
+				{Type: audit.DecisionDomain, Domain: "evil.example.com", Outcome: audit.OutcomeDenied, Actor: "bob", Reason: "no"},
+				{Type: audit.DecisionHostexec, Cmd: "rm -rf /", Outcome: audit.OutcomeTimeout},
+			},
+		},
+		Git: &project.Changes{Since: "0123456789abcdef", Commits: 2, FilesChanged: 5, Insertions: 120, Deletions: 30},
+	}
+}
+
+func TestSessionReport_Format(t *testing.T) {
+	var buf bytes.Buffer
+	testSessionReport().Format(&buf)
+	out := buf.String()
+
+	for _, want := range []string{
+		"Session report for api-main",
+		"Duration: 1h35m0s",
+		"Domains: 1 contacted, 3 connection(s), 2.0 KiB sent, 3.0 MiB received",
+		"New approvals: 2",
+		"domain pypi.org (project) by alice",
+		`hostexec "docker ps" by alice`,
+		"Hostexec commands: 2",
+		"make test (auto-approved): exit 2",
+		"docker ps: exit 0",
+		"Denials and timeouts: 2",
+		"domain evil.example.com: denied by bob (no)",
+		`hostexec "rm -rf /": timeout`,
+		"Git: 2 commit(s), 5 file(s) changed (+120 -30) since 0123456789ab",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("report missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestSessionReport_FormatGitOnly(t *testing.T) {
+	r := &SessionReport{Cloister: "api-main", Git: &project.Changes{Since: "abc"}}
+	var buf bytes.Buffer
+	r.Format(&buf)
+	if strings.Contains(buf.String(), "Domains") || !strings.Contains(buf.String(), "Git: 0 commit(s)") {
+		t.Errorf("report = %q, want only the git line", buf.String())
+	}
+}
+
+func TestSessionReport_Save(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "logs")
+	path, err := testSessionReport().Save(dir)
+	if err != nil {
+		t.Fatalf("Save() returned error: %v", err)
+	}
+	if filepath.Dir(path) != dir || !strings.HasPrefix(filepath.Base(path), "api-main-session-") {
+		t.Errorf("Save() path = %s", path)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil || !strings.HasPrefix(string(data), "Session report for api-main") {
+		t.Errorf("saved report = %q, %v", data, err)
+	}
+}
@@ -0,0 +1,404 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/xdg/cloister/internal/guardian"
+	"github.com/xdg/cloister/internal/guardian/approval"
+	"github.com/xdg/cloister/internal/prompt"
+	"github.com/xdg/cloister/internal/term"
+)
+
+var approveCmd = &cobra.Command{
+	Use:   "approve [request-id]",
+	Short: "Review and approve pending requests from the terminal",
+	Long: `Review pending hostexec and domain requests without a browser.
+
+With no arguments, lists pending requests and lets you approve or deny them
+interactively. New requests are announced as they arrive; choose Refresh
+to update the list. Pressing Enter at any menu takes the safe default (Quit
+or Back), never an approval.
+
+With a request ID, approves that request directly. For domain requests,
+--scope selects how long the approval lasts (once, session, project, global;
+default once) and --wildcard approves the domain's wildcard pattern instead.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runApprove,
+}
+
+var denyCmd = &cobra.Command{
+	Use:   "deny <request-id>",
+	Short: "Deny a pending request",
+	Long: `Deny a pending hostexec or domain request.
+
+--reason is passed back to the agent verbatim. For domain requests, --scope
+selects how long the denial lasts (once, session, project, global; default
+once) and --wildcard denies the domain's wildcard pattern instead.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runDeny,
+}
+
+var (
+	approveScope    string
+	approveWildcard bool
+	denyScope       string
+	denyWildcard    bool
+	denyReason      string
+)
+
+func init() {
+	approveCmd.Flags().StringVar(&approveScope, "scope", "", "approval scope for domain requests: once, session, project, global")
+	approveCmd.Flags().BoolVar(&approveWildcard, "wildcard", false, "approve the domain's wildcard pattern (e.g. *.example.com)")
+	denyCmd.Flags().StringVar(&denyScope, "scope", "", "denial scope for domain requests: once, session, project, global")
+	denyCmd.Flags().BoolVar(&denyWildcard, "wildcard", false, "deny the domain's wildcard pattern (e.g. *.example.com)")
+	denyCmd.Flags().StringVar(&denyReason, "reason", "", "reason reported back to the requester")
+	rootCmd.AddCommand(approveCmd)
+	rootCmd.AddCommand(denyCmd)
+}
+
+// approvalAPI is the subset of approval.Client used by approve and deny.
+type approvalAPI interface {
+	Pending(ctx context.Context) ([]approval.PendingCommand, error)
+	PendingDomains(ctx context.Context) ([]approval.PendingDomain, error)
+	Approve(ctx context.Context, id string) error
+	Deny(ctx context.Context, id, reason string) error
+	ApproveDomain(ctx context.Context, id, scope, pattern string) error
+	DenyDomain(ctx context.Context, id, scope string, wildcard bool, reason string) error
+	Subscribe(ctx context.Context, onEvent func(approval.Event)) error
+}
+
+// approvalClientFactory creates the approval client.
+// It can be overridden for testing.
+var approvalClientFactory = func() (approvalAPI, error) {
+	return guardian.NewApprovalClient()
+}
+
+// approvePrompter is the prompter used by interactive approve.
+// If nil, a StdinPrompter is used; it can be overridden for testing.
+var approvePrompter prompt.Prompter
+
+// getApprovePrompter returns the prompter to use for interactive approve.
+func getApprovePrompter(cmd *cobra.Command) prompt.Prompter {
+	if approvePrompter != nil {
+		return approvePrompter
+	}
+	return prompt.NewStdinPrompter(os.Stdin, cmd.OutOrStdout())
+}
+
+// newApprovalClient creates the approval client with a friendly error when
+// the guardian has not been started.
+func newApprovalClient() (approvalAPI, error) {
+	c, err := approvalClientFactory()
+	if err != nil {
+		return nil, fmt.Errorf("cannot reach the approval server (is the guardian running?): %w", err)
+	}
+	return c, nil
+}
+
+func runApprove(cmd *cobra.Command, args []string) error {
+	c, err := newApprovalClient()
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+
+	if len(args) == 0 {
+		return runApproveInteractive(ctx, c, getApprovePrompter(cmd))
+	}
+
+	item, err := findPending(ctx, c, args[0])
+	if err != nil {
+		return err
+	}
+	if item.kind == pendingKindCommand && (approveScope != "" || approveWildcard) {
+		return fmt.Errorf("--scope and --wildcard apply only to domain requests")
+	}
+	return decide(ctx, c, item, decision{approve: true, scope: approveScope, wildcard: approveWildcard})
+}
+
+func runDeny(_ *cobra.Command, args []string) error {
+	c, err := newApprovalClient()
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+
+	item, err := findPending(ctx, c, args[0])
+	if err != nil {
+		return err
+	}
+	if item.kind == pendingKindCommand && (denyScope != "" || denyWildcard) {
+		return fmt.Errorf("--scope and --wildcard apply only to domain requests")
+	}
+	return decide(ctx, c, item, decision{scope: denyScope, wildcard: denyWildcard, reason: denyReason})
+}
+
+// Pending request kinds.
+const (
+	pendingKindCommand = "command"
+	pendingKindDomain  = "domain"
+)
+
+// pendingItem is a pending command or domain request, flattened for display.
+type pendingItem struct {
+	id       string
+	kind     string
+	cloister string
+	detail   string // Command line or domain
+}
+
+// label returns the one-line description shown in lists.
+func (p pendingItem) label() string {
+	return fmt.Sprintf("[%s] %s: %s", p.kind, p.cloister, p.detail)
+}
+
+// listPending returns all pending requests, commands first, oldest first.
+func listPending(ctx context.Context, c approvalAPI) ([]pendingItem, error) {
+	cmds, err := c.Pending(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pending requests: %w", err)
+	}
+	domains, err := c.PendingDomains(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pending domains: %w", err)
+	}
+
+	items := make([]pendingItem, 0, len(cmds)+len(domains))
+	for _, r := range cmds {
+		// For actions, Cmd names the action and its parameter values
+		items = append(items, pendingItem{id: r.ID, kind: pendingKindCommand, cloister: r.Cloister, detail: r.Cmd})
+	}
+	for _, r := range domains {
+		items = append(items, pendingItem{id: r.ID, kind: pendingKindDomain, cloister: r.Cloister, detail: r.Domain})
+	}
+	return items, nil
+}
+
+// findPending looks up a pending request by ID.
+func findPending(ctx context.Context, c approvalAPI, id string) (pendingItem, error) {
+	items, err := listPending(ctx, c)
+	if err != nil {
+		return pendingItem{}, err
+	}
+	for _, item := range items {
+		if item.id == id {
+			return item, nil
+		}
+	}
+	return pendingItem{}, fmt.Errorf("request %s: %w (already decided or timed out)", id, approval.ErrNotPending)
+}
+
+// decision is the outcome chosen for a pending request.
+type decision struct {
+	approve  bool
+	scope    string // Domain requests only; empty means once
+	wildcard bool   // Domain requests only
+	reason   string // Denials only
+}
+
+// decide sends a decision to the approval server and reports the outcome.
+func decide(ctx context.Context, c approvalAPI, item pendingItem, d decision) error {
+	err := sendDecision(ctx, c, item, d)
+	if errors.Is(err, approval.ErrNotPending) {
+		return fmt.Errorf("request %s: %w (already decided or timed out)", item.id, err)
+	}
+	if err != nil {
+		return err
+	}
+
+	verb := "Denied"
+	if d.approve {
+		verb = "Approved"
+	}
+	term.Printf("%s %s\n", verb, item.label())
+	return nil
+}
+
+// sendDecision calls the endpoint matching the request kind and decision.
+func sendDecision(ctx context.Context, c approvalAPI, item pendingItem, d decision) error {
+	if item.kind == pendingKindCommand {
+		if d.approve {
+			return c.Approve(ctx, item.id)
+		}
+		return c.Deny(ctx, item.id, d.reason)
+	}
+
+	scope := d.scope
+	if scope == "" {
+		scope = "once"
+	}
+	if !d.approve {
+		return c.DenyDomain(ctx, item.id, scope, d.wildcard, d.reason)
+	}
+	var pattern string
+	if d.wildcard {
+		pattern = approval.DomainToWildcard(item.detail)
+		if pattern == "" {
+			return fmt.Errorf("domain %s has no wildcard form", item.detail)
+		}
+	}
+	return c.ApproveDomain(ctx, item.id, scope, pattern)
+}
+
+// runApproveInteractive lists pending requests and prompts for decisions
+// until the user quits. While the list is empty it waits for new requests
+// on the approval event stream.
+func runApproveInteractive(ctx context.Context, c approvalAPI, p prompt.Prompter) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	changed, streamErr := watchApprovals(ctx, c)
+
+	for {
+		items, err := listPending(ctx, c)
+		if err != nil {
+			return err
+		}
+		if len(items) == 0 {
+			term.Println("No pending requests; waiting for new ones (Ctrl-C to quit)...")
+			select {
+			case <-ctx.Done():
+				return nil
+			case err := <-streamErr:
+				return err
+			case <-changed:
+				continue
+			}
+		}
+		quit, err := reviewPending(ctx, c, p, items)
+		if err != nil || quit {
+			return err
+		}
+	}
+}
+
+// watchApprovals subscribes to the approval event stream. The returned
+// channel is signalled (coalesced) whenever a request is added or removed;
+// the error channel receives an error if the stream ends unexpectedly.
+func watchApprovals(ctx context.Context, c approvalAPI) (<-chan struct{}, <-chan error) {
+	changed := make(chan struct{}, 1)
+	streamErr := make(chan error, 1)
+	go func() {
+		err := c.Subscribe(ctx, func(ev approval.Event) {
+			switch ev.Type {
+			case approval.EventRequestAdded, approval.EventDomainRequestAdded:
+				term.Println("\n* New request pending (select Refresh to update the list)")
+			case approval.EventRequestRemoved, approval.EventDomainRequestRemoved:
+			default:
+				return
+			}
+			select {
+			case changed <- struct{}{}:
+			default:
+			}
+		})
+		if ctx.Err() != nil {
+			return
+		}
+		if err == nil {
+			err = errors.New("event stream closed")
+		}
+		streamErr <- fmt.Errorf("lost connection to approval server: %w", err)
+	}()
+	return changed, streamErr
+}
+
+// reviewPending prompts for one request and a decision for it. It returns
+// quit=true when the user chooses to exit. Quit is the default so that a
+// closed stdin ends the session instead of looping.
+func reviewPending(ctx context.Context, c approvalAPI, p prompt.Prompter, items []pendingItem) (quit bool, err error) {
+	options := make([]string, 0, len(items)+2)
+	for _, item := range items {
+		options = append(options, item.label())
+	}
+	refreshIdx, quitIdx := len(items), len(items)+1
+	options = append(options, "Refresh", "Quit")
+
+	term.Println()
+	sel, err := p.Prompt(fmt.Sprintf("%d pending request(s):", len(items)), options, quitIdx)
+	if err != nil {
+		return false, fmt.Errorf("failed to read selection: %w", err)
+	}
+	switch sel {
+	case refreshIdx:
+		return false, nil
+	case quitIdx:
+		return true, nil
+	}
+
+	item := items[sel]
+	d, ok, err := promptDecision(p, item)
+	if err != nil || !ok {
+		return false, err
+	}
+	if err := decide(ctx, c, item, d); err != nil {
+		if !errors.Is(err, approval.ErrNotPending) {
+			return false, err
+		}
+		term.Warn("%v", err)
+	}
+	return false, nil
+}
+
+// commandDecisions are the choices offered for a hostexec request.
+var commandDecisions = []struct {
+	label string
+	d     decision
+}{
+	{"Approve", decision{approve: true}},
+	{"Deny", decision{}},
+}
+
+// domainDecisions are the choices offered for a domain request.
+var domainDecisions = []struct {
+	label string
+	d     decision
+}{
+	{"Allow once", decision{approve: true, scope: "once"}},
+	{"Allow for this session", decision{approve: true, scope: "session"}},
+	{"Allow for this project (saved)", decision{approve: true, scope: "project"}},
+	{"Allow globally (saved)", decision{approve: true, scope: "global"}},
+	{"Deny once", decision{scope: "once"}},
+	{"Deny for this session", decision{scope: "session"}},
+	{"Deny for this project (saved)", decision{scope: "project"}},
+	{"Deny globally (saved)", decision{scope: "global"}},
+}
+
+// promptDecision asks what to do with item. ok is false if the user backs
+// out, which is the default.
+func promptDecision(p prompt.Prompter, item pendingItem) (d decision, ok bool, err error) {
+	choices := commandDecisions
+	if item.kind == pendingKindDomain {
+		choices = domainDecisions
+	}
+	options := make([]string, 0, len(choices)+1)
+	for _, c := range choices {
+		options = append(options, c.label)
+	}
+	backIdx := len(choices)
+	options = append(options, "Back")
+
+	sel, err := p.Prompt(item.label(), options, backIdx)
+	if err != nil {
+		return decision{}, false, fmt.Errorf("failed to read decision: %w", err)
+	}
+	if sel == backIdx {
+		return decision{}, false, nil
+	}
+	d = choices[sel].d
+
+	// Saved decisions may cover the whole wildcard instead of one domain.
+	pattern := approval.DomainToWildcard(item.detail)
+	if item.kind == pendingKindDomain && pattern != "" && (d.scope == "project" || d.scope == "global") {
+		sel, err := p.Prompt("Apply to:", []string{item.detail, pattern}, 0)
+		if err != nil {
+			return decision{}, false, fmt.Errorf("failed to read pattern choice: %w", err)
+		}
+		d.wildcard = sel == 1
+	}
+	return d, true, nil
+}
@@ -0,0 +1,223 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/xdg/cloister/internal/guardian/approval"
+	"github.com/xdg/cloister/internal/prompt"
+)
+
+// fakeApprovalAPI is an in-memory approvalAPI that records decisions.
+type fakeApprovalAPI struct {
+	commands  []approval.PendingCommand
+	domains   []approval.PendingDomain
+	decisions []string
+}
+
+func (f *fakeApprovalAPI) Pending(context.Context) ([]approval.PendingCommand, error) {
+	return f.commands, nil
+}
+
+func (f *fakeApprovalAPI) PendingDomains(context.Context) ([]approval.PendingDomain, error) {
+	return f.domains, nil
+}
+
+func (f *fakeApprovalAPI) Approve(_ context.Context, id string) error {
+	return f.record(id, "approve "+id)
+}
+
+func (f *fakeApprovalAPI) Deny(_ context.Context, id, reason string) error {
+	return f.record(id, "deny "+id+" reason="+reason)
+}
+
+func (f *fakeApprovalAPI) ApproveDomain(_ context.Context, id, scope, pattern string) error {
+	return f.record(id, "approve-domain "+id+" scope="+scope+" pattern="+pattern)
+}
+
+func (f *fakeApprovalAPI) DenyDomain(_ context.Context, id, scope string, wildcard bool, reason string) error {
+	w := ""
+	if wildcard {
+		w = " wildcard"
+	}
+	return f.record(id, "deny-domain "+id+" scope="+scope+w+" reason="+reason)
+}
+
+func (f *fakeApprovalAPI) Subscribe(ctx context.Context, _ func(approval.Event)) error {
+	<-ctx.Done()
+	return nil
+}
+
+// record notes a decision and removes the request from the pending lists.
+func (f *fakeApprovalAPI) record(id, decision string) error {
+	f.decisions = append(f.decisions, decision)
+	f.commands = slices.DeleteFunc(f.commands, func(r approval.PendingCommand) bool { return r.ID == id })
+	f.domains = slices.DeleteFunc(f.domains, func(r approval.PendingDomain) bool { return r.ID == id })
+	return nil
+}
+
+// useFakeApprovalAPI installs api as the approval client for one test.
+func useFakeApprovalAPI(t *testing.T, api *fakeApprovalAPI) {
+	t.Helper()
+	old := approvalClientFactory
+	approvalClientFactory = func() (approvalAPI, error) { return api, nil }
+	t.Cleanup(func() { approvalClientFactory = old })
+}
+
+// resetApproveFlags restores flag variables after a test sets them.
+func resetApproveFlags(t *testing.T) {
+	t.Helper()
+	t.Cleanup(func() {
+		approveScope, approveWildcard = "", false
+		denyScope, denyWildcard, denyReason = "", false, ""
+	})
+}
+
+func TestApproveCmd_ByID(t *testing.T) {
+	api := &fakeApprovalAPI{commands: []approval.PendingCommand{{ID: "abc", Cloister: "c1", Cmd: "make"}}}
+	useFakeApprovalAPI(t, api)
+
+	if err := approveCmd.RunE(approveCmd, []string{"abc"}); err != nil {
+		t.Fatalf("approve returned error: %v", err)
+	}
+	if len(api.decisions) != 1 || api.decisions[0] != "approve abc" {
+		t.Errorf("decisions = %v, want [approve abc]", api.decisions)
+	}
+}
+
+func TestApproveCmd_DomainScopeAndWildcard(t *testing.T) {
+	api := &fakeApprovalAPI{domains: []approval.PendingDomain{{ID: "d1", Cloister: "c1", Domain: "api.example.com:443"}}}
+	useFakeApprovalAPI(t, api)
+	resetApproveFlags(t)
+	approveScope, approveWildcard = "project", true
+
+	if err := approveCmd.RunE(approveCmd, []string{"d1"}); err != nil {
+		t.Fatalf("approve returned error: %v", err)
+	}
+	want := "approve-domain d1 scope=project pattern=*.example.com"
+	if len(api.decisions) != 1 || api.decisions[0] != want {
+		t.Errorf("decisions = %v, want [%s]", api.decisions, want)
+	}
+}
+
+func TestApproveCmd_ScopeRejectedForCommand(t *testing.T) {
+	api := &fakeApprovalAPI{commands: []approval.PendingCommand{{ID: "abc", Cmd: "make"}}}
+	useFakeApprovalAPI(t, api)
+	resetApproveFlags(t)
+	approveScope = "project"
+
+	err := approveCmd.RunE(approveCmd, []string{"abc"})
+	if err == nil || !strings.Contains(err.Error(), "only to domain requests") {
+		t.Errorf("error = %v, want scope rejection", err)
+	}
+	if len(api.decisions) != 0 {
+		t.Errorf("decisions = %v, want none", api.decisions)
+	}
+}
+
+func TestApproveCmd_UnknownID(t *testing.T) {
+	useFakeApprovalAPI(t, &fakeApprovalAPI{})
+
+	err := approveCmd.RunE(approveCmd, []string{"gone"})
+	if !errors.Is(err, approval.ErrNotPending) {
+		t.Errorf("error = %v, want ErrNotPending", err)
+	}
+}
+
+func TestDenyCmd_WithReason(t *testing.T) {
+	api := &fakeApprovalAPI{commands: []approval.PendingCommand{{ID: "abc", Cmd: "rm -rf /"}}}
+	useFakeApprovalAPI(t, api)
+	resetApproveFlags(t)
+	denyReason = "too risky"
+
+	if err := denyCmd.RunE(denyCmd, []string{"abc"}); err != nil {
+		t.Fatalf("deny returned error: %v", err)
+	}
+	if len(api.decisions) != 1 || api.decisions[0] != "deny abc reason=too risky" {
+		t.Errorf("decisions = %v", api.decisions)
+	}
+}
+
+func TestDenyCmd_DomainDefaultsToOnce(t *testing.T) {
+	api := &fakeApprovalAPI{domains: []approval.PendingDomain{{ID: "d1", Domain: "evil.example"}}}
+	useFakeApprovalAPI(t, api)
+
+	if err := denyCmd.RunE(denyCmd, []string{"d1"}); err != nil {
+		t.Fatalf("deny returned error: %v", err)
+	}
+	if len(api.decisions) != 1 || api.decisions[0] != "deny-domain d1 scope=once reason=" {
+		t.Errorf("decisions = %v", api.decisions)
+	}
+}
+
+func TestApproveInteractive_ApprovesSelection(t *testing.T) {
+	api := &fakeApprovalAPI{commands: []approval.PendingCommand{
+		{ID: "a", Cloister: "c1", Cmd: "make"},
+		{ID: "b", Cloister: "c2", Cmd: "make test"},
+	}}
+	// Select the second request, approve it, then quit via the default.
+	p := prompt.NewMockPrompter(1, 0)
+
+	if err := runApproveInteractive(context.Background(), api, p); err != nil {
+		t.Fatalf("runApproveInteractive() error = %v", err)
+	}
+	if len(api.decisions) != 1 || api.decisions[0] != "approve b" {
+		t.Errorf("decisions = %v, want [approve b]", api.decisions)
+	}
+}
+
+func TestListPending_ShowsActionParams(t *testing.T) {
+	api := &fakeApprovalAPI{commands: []approval.PendingCommand{{
+		ID:       "a",
+		Cloister: "c1",
+		Cmd:      `action:reset-db name="prod"`,
+		Action:   "reset-db",
+		Params:   []approval.ActionParam{{Name: "name", Value: "prod"}},
+	}}}
+
+	items, err := listPending(context.Background(), api)
+	if err != nil {
+		t.Fatalf("listPending() error = %v", err)
+	}
+	if len(items) != 1 {
+		t.Fatalf("got %d items, want 1", len(items))
+	}
+	if got, want := items[0].label(), `[command] c1: action:reset-db name="prod"`; got != want {
+		t.Errorf("label() = %q, want %q", got, want)
+	}
+}
+
+func TestApproveInteractive_DefaultsNeverApprove(t *testing.T) {
+	api := &fakeApprovalAPI{commands: []approval.PendingCommand{{ID: "a", Cmd: "make"}}}
+	p := &prompt.MockPrompter{} // Every prompt takes its default
+
+	if err := runApproveInteractive(context.Background(), api, p); err != nil {
+		t.Fatalf("runApproveInteractive() error = %v", err)
+	}
+	if len(api.decisions) != 0 {
+		t.Errorf("decisions = %v, want none", api.decisions)
+	}
+	if len(p.Calls) != 1 || p.Calls[0].Options[p.Calls[0].DefaultIdx] != "Quit" {
+		t.Errorf("prompt calls = %+v, want one list prompt defaulting to Quit", p.Calls)
+	}
+}
+
+func TestApproveInteractive_DomainWildcardChoice(t *testing.T) {
+	api := &fakeApprovalAPI{
+		commands: []approval.PendingCommand{{ID: "a", Cmd: "make"}},
+		domains:  []approval.PendingDomain{{ID: "d1", Cloister: "c1", Domain: "api.example.com"}},
+	}
+	// Pick the domain, "Deny globally (saved)", the wildcard pattern, then quit.
+	p := prompt.NewMockPrompter(1, 7, 1)
+
+	if err := runApproveInteractive(context.Background(), api, p); err != nil {
+		t.Fatalf("runApproveInteractive() error = %v", err)
+	}
+
+	if len(api.decisions) != 1 || api.decisions[0] != "deny-domain d1 scope=global wildcard reason=" {
+		t.Errorf("decisions = %v", api.decisions)
+	}
+}
@@ -0,0 +1,346 @@
+package cmd
+
+import (
+	"crypto/ed25519"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/xdg/cloister/internal/audit"
+	"github.com/xdg/cloister/internal/config"
+	"github.com/xdg/cloister/internal/guardian"
+	"github.com/xdg/cloister/internal/term"
+)
+
+var auditCmd = &cobra.Command{
+	Use:   "audit",
+	Short: "Search the guardian's audit log",
+	Long: `Search the audit log for host command and domain events, including its
+rotated files, in either the text or JSON Lines format.
+
+Filters combine: an event is shown only if it matches all of them.
+--since and --until take a time (2024-01-15T14:00:00Z), a date
+(2024-01-15, local time), or a duration before now (2h, 30m). --type takes
+event types (e.g. DENY, DOMAIN_APPROVE) or the categories "hostexec" and
+"domain". --match is a substring of the command or domain, ignoring case.
+--outcome takes approved, auto_approved, denied, timeout, completed (exit
+status 0), or failed. --type and --outcome may be repeated or
+comma-separated.
+
+Output is a table, or with --output json one JSON object per event in the
+JSON Lines audit schema. --summary prints totals instead: events by type,
+most-requested domains, most-run commands, denials, and how long manually
+decided requests waited for a decision.
+
+Without --file, reads the file configured as log.file.`,
+	Args: cobra.NoArgs,
+	RunE: runAuditQuery,
+}
+
+var auditVerifyCmd = &cobra.Command{
+	Use:   "verify [log-file]",
+	Short: "Check the audit log for tampering",
+	Long: `Check that the audit log has not been modified, truncated, or had records
+removed since the guardian wrote it.
+
+Each record the guardian writes is sealed with a sequence number, the hash
+of the previous line, and an Ed25519 signature. The signing key never leaves
+the guardian: it lives on a Docker volume that only the guardian container
+mounts. Verifying needs only the public key, which this command fetches from
+the running guardian, or takes from --public-key. Every 100 records, and
+when the guardian stops, a checkpoint record is written and copied to
+<log-file>.checkpoint so that truncation of the log can be detected too.
+
+The seal stops cloister containers, and host processes that cannot use
+Docker, from rewriting the log unnoticed. It does not stop anyone who can
+run Docker commands as you, including an approved hostexec command such as
+"docker exec" or "docker run -v": they can read the key from the guardian.
+
+Rotated files of the log (<log-file>.<timestamp>, optionally gzipped) are
+checked first, oldest first, as one chain with the log. Deleting the oldest
+rotated files, as log.rotate retention does, leaves the chain intact.
+
+Reports the first broken link and exits non-zero if the chain is broken.
+Lines written before sealing was enabled are counted but not checked.
+
+Without a log file, checks the file configured as log.file.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runAuditVerify,
+}
+
+var (
+	auditFile     string
+	auditSince    string
+	auditUntil    string
+	auditProject  string
+	auditCloister string
+	auditTypes    []string
+	auditMatch    string
+	auditOutcomes []string
+	auditOutput   string
+	auditSummary  bool
+	auditTop      int
+
+	auditPublicKey string
+)
+
+func init() {
+	auditCmd.Flags().StringVar(&auditFile, "file", "", "audit log to read (default: log.file)")
+	auditCmd.Flags().StringVar(&auditSince, "since", "", "show events at or after this time, date, or duration ago")
+	auditCmd.Flags().StringVar(&auditUntil, "until", "", "show events before this time, date, or duration ago")
+	auditCmd.Flags().StringVar(&auditProject, "project", "", "show only events for this project")
+	auditCmd.Flags().StringVar(&auditCloister, "cloister", "", "show only events for this cloister")
+	auditCmd.Flags().StringSliceVar(&auditTypes, "type", nil, "show only these event types or categories (hostexec, domain)")
+	auditCmd.Flags().StringVar(&auditMatch, "match", "", "show only events whose command or domain contains this text")
+	auditCmd.Flags().StringSliceVar(&auditOutcomes, "outcome", nil, "show only events with these outcomes")
+	auditCmd.Flags().StringVarP(&auditOutput, "output", "o", "table", "output format: table or json")
+	auditCmd.Flags().BoolVar(&auditSummary, "summary", false, "print totals instead of events")
+	auditCmd.Flags().IntVar(&auditTop, "top", 10, "entries in each top list of --summary")
+	auditVerifyCmd.Flags().StringVar(&auditPublicKey, "public-key", "", "verify with this hex public key instead of the running guardian's")
+	auditCmd.AddCommand(auditVerifyCmd)
+	rootCmd.AddCommand(auditCmd)
+}
+
+func runAuditQuery(_ *cobra.Command, _ []string) error {
+	if auditOutput != "table" && auditOutput != "json" {
+		return fmt.Errorf("--output must be table or json, got %q", auditOutput)
+	}
+	q, err := auditQuery(time.Now())
+	if err != nil {
+		return err
+	}
+	path := auditFile
+	if path == "" {
+		if path, err = configuredAuditLog(); err != nil {
+			return err
+		}
+	}
+
+	var events []*audit.Event
+	err = audit.ReadLog(path, func(e *audit.Event) {
+		if q.Matches(e) {
+			events = append(events, e)
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	if auditSummary {
+		return printAuditSummary(audit.Summarize(events, auditTop))
+	}
+	if auditOutput == "json" {
+		for _, e := range events {
+			term.Println(e.FormatJSON())
+		}
+		return nil
+	}
+	printAuditTable(events)
+	return nil
+}
+
+// auditQuery builds the query described by the audit command's flags.
+func auditQuery(now time.Time) (*audit.Query, error) {
+	for _, t := range auditTypes {
+		if !audit.IsKnownType(t) {
+			return nil, fmt.Errorf("unknown event type %q", t)
+		}
+	}
+	for _, o := range auditOutcomes {
+		if !audit.IsKnownOutcome(o) {
+			return nil, fmt.Errorf("unknown outcome %q", o)
+		}
+	}
+	q := &audit.Query{
+		Filter:   audit.Filter{Project: auditProject, Cloister: auditCloister, Types: auditTypes},
+		Match:    auditMatch,
+		Outcomes: auditOutcomes,
+	}
+	var err error
+	if q.Since, err = parseAuditTime(auditSince, now); err != nil {
+		return nil, fmt.Errorf("--since: %w", err)
+	}
+	if q.Until, err = parseAuditTime(auditUntil, now); err != nil {
+		return nil, fmt.Errorf("--until: %w", err)
+	}
+	return q, nil
+}
+
+// parseAuditTime parses a --since or --until value: an RFC 3339 time, a
+// date in local time, or a duration before now. An empty value returns the
+// zero time.
+func parseAuditTime(s string, now time.Time) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	if t, err := time.ParseInLocation(time.DateOnly, s, time.Local); err == nil {
+		return t, nil
+	}
+	if d, err := time.ParseDuration(s); err == nil && d >= 0 {
+		return now.Add(-d), nil
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q: use 2024-01-15T14:00:00Z, 2024-01-15, or a duration such as 2h", s)
+}
+
+// configuredAuditLog returns the audit log file from the global config.
+func configuredAuditLog() (string, error) {
+	cfg, err := config.LoadGlobalConfig()
+	if err != nil {
+		return "", fmt.Errorf("failed to load config: %w", err)
+	}
+	if cfg.Log.File == "" {
+		return "", fmt.Errorf("no audit log configured (log.file)")
+	}
+	return cfg.Log.File, nil
+}
+
+// printAuditTable prints events as a table.
+func printAuditTable(events []*audit.Event) {
+	if len(events) == 0 {
+		term.Println("No matching events.")
+		return
+	}
+	w := tabwriter.NewWriter(term.Stdout(), 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "TIME\tTYPE\tPROJECT\tCLOISTER\tCOMMAND/DOMAIN\tDETAILS")
+	for _, e := range events {
+		target := e.Cmd
+		if e.Category() == audit.CategoryDomain {
+			target = e.Domain
+		}
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n",
+			e.Timestamp.Local().Format(time.DateTime), e.Type, e.Project, e.Cloister, target, auditDetails(e))
+	}
+	_ = w.Flush()
+}
+
+// auditDetails returns the type-specific fields of an event for the table.
+func auditDetails(e *audit.Event) string {
+	var parts []string
+	add := func(key, value string) {
+		if value != "" {
+			parts = append(parts, key+"="+value)
+		}
+	}
+	switch e.Type {
+	case audit.EventComplete:
+		add("exit", strconv.Itoa(e.ExitCode))
+		add("duration", e.Duration.String())
+	case audit.EventRedact:
+		add("count", strconv.Itoa(e.Redactions))
+		add("detectors", e.Detectors)
+	default:
+		add("user", e.User)
+		add("scope", e.Scope)
+		add("pattern", e.Pattern)
+		if e.Reason != "" {
+			add("reason", strconv.Quote(e.Reason))
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// printAuditSummary prints a summary as text, or as JSON with --output json.
+func printAuditSummary(s audit.Summary) error {
+	if auditOutput == "json" {
+		b, err := json.MarshalIndent(s, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to encode summary: %w", err)
+		}
+		term.Println(string(b))
+		return nil
+	}
+
+	if s.Events == 0 {
+		term.Println("No matching events.")
+		return nil
+	}
+	term.Printf("Events:   %d, %s to %s\n", s.Events,
+		s.From.Local().Format(time.DateTime), s.To.Local().Format(time.DateTime))
+	types := make([]string, 0, len(s.ByType))
+	for t, n := range s.ByType {
+		types = append(types, fmt.Sprintf("%s %d", t, n))
+	}
+	slices.Sort(types)
+	term.Printf("By type:  %s\n", strings.Join(types, ", "))
+	term.Printf("Denials:  %d hostexec, %d domain\n", s.Denials.Hostexec, s.Denials.Domain)
+	if l := s.Latency; l.Decisions > 0 {
+		term.Printf("Approval latency: %d decision(s), median %s, p90 %s, max %s\n",
+			l.Decisions, l.Median, l.P90, l.Max)
+	}
+	printAuditCounts("Top domains", s.TopDomains)
+	printAuditCounts("Most-run commands", s.TopCommands)
+	printAuditCounts("Most denied", s.Denials.Top)
+	return nil
+}
+
+// printAuditCounts prints a titled top list, if it is not empty.
+func printAuditCounts(title string, counts []audit.Count) {
+	if len(counts) == 0 {
+		return
+	}
+	term.Printf("\n%s:\n", title)
+	for _, c := range counts {
+		term.Printf("  %6d  %s\n", c.Count, c.Value)
+	}
+}
+
+// auditVerifyKey returns the public key to verify the audit log with: the
+// --public-key flag, or else the running guardian's.
+func auditVerifyKey() (ed25519.PublicKey, error) {
+	if auditPublicKey != "" {
+		return guardian.ParseAuditPublicKey(auditPublicKey) //nolint:wrapcheck // names the problem
+	}
+	key, err := guardian.NewClient(guardian.APIAddr()).AuditPublicKey()
+	if err != nil {
+		return nil, fmt.Errorf("cannot verify without the guardian's audit public key; start the guardian or pass --public-key: %w", err)
+	}
+	return key, nil
+}
+
+func runAuditVerify(_ *cobra.Command, args []string) error {
+	var path string
+	if len(args) > 0 {
+		path = args[0]
+	} else {
+		var err error
+		if path, err = configuredAuditLog(); err != nil {
+			return err
+		}
+	}
+
+	key, err := auditVerifyKey()
+	if err != nil {
+		return err
+	}
+	term.Printf("Audit public key: %s\n", hex.EncodeToString(key))
+
+	report, err := audit.VerifyFile(path, key)
+	if err != nil {
+		return err
+	}
+	if report.Unsealed > 0 {
+		term.Printf("%d unsealed line(s) at the start of the log were not checked\n", report.Unsealed)
+	}
+	if b := report.Break; b != nil {
+		if b.Line == 0 {
+			return fmt.Errorf("%s: %s", audit.CheckpointPath(path), b.Reason)
+		}
+		return fmt.Errorf("%s:%d: chain broken after %d intact record(s): %s", b.File, b.Line, report.Records, b.Reason)
+	}
+	term.Printf("%s: intact, %d record(s) including %d checkpoint(s)", path, report.Records, report.Checkpoints)
+	if report.Files > 1 {
+		term.Printf(" across %d files", report.Files)
+	}
+	term.Println()
+	return nil
+}
@@ -0,0 +1,194 @@
+package cmd
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/xdg/cloister/internal/audit"
+	"github.com/xdg/cloister/internal/term"
+)
+
+// writeSealedLog writes a sealed audit log of n records, points
+// --public-key at its signing key, and returns its path.
+func writeSealedLog(t *testing.T, n int) string {
+	t.Helper()
+	key := audit.GenerateChainKey()
+	auditPublicKey = hex.EncodeToString(key.Public().(ed25519.PublicKey))
+	t.Cleanup(func() { auditPublicKey = "" })
+
+	path := filepath.Join(t.TempDir(), "audit.log")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = f.Close() }()
+	logger := audit.NewLogger(f)
+	logger.SetChain(audit.NewChain(key, audit.CheckpointPath(path)))
+	for range n {
+		_ = logger.LogRequest("r", "p", "c", "make")
+	}
+	if err := logger.Checkpoint(); err != nil {
+		t.Fatalf("Checkpoint: %v", err)
+	}
+	return path
+}
+
+func TestAuditVerifyCmd_Intact(t *testing.T) {
+	path := writeSealedLog(t, 3)
+	var out bytes.Buffer
+	term.SetOutput(&out)
+	t.Cleanup(term.Reset)
+
+	if err := auditVerifyCmd.RunE(auditVerifyCmd, []string{path}); err != nil {
+		t.Fatalf("audit verify returned error: %v", err)
+	}
+	if !strings.Contains(out.String(), "intact, 4 record(s) including 1 checkpoint(s)") {
+		t.Errorf("output = %q", out.String())
+	}
+}
+
+func TestAuditVerifyCmd_Truncated(t *testing.T) {
+	path := writeSealedLog(t, 3)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.SplitAfter(string(data), "\n")
+	if err := os.WriteFile(path, []byte(strings.Join(lines[:2], "")), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	err = auditVerifyCmd.RunE(auditVerifyCmd, []string{path})
+	if err == nil || !strings.Contains(err.Error(), path+":3:") || !strings.Contains(err.Error(), "truncated") {
+		t.Errorf("audit verify error = %v, want truncation at line 3", err)
+	}
+}
+
+func TestAuditQueryCmd(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	log := `2024-01-15T14:32:05Z HOSTEXEC REQUEST project=api cloister=api-main cmd="make test"
+2024-01-15T14:32:15Z HOSTEXEC APPROVE project=api cloister=api-main cmd="make test" user="alice"
+2024-01-15T14:32:20Z HOSTEXEC COMPLETE project=api cloister=api-main cmd="make test" exit=2 duration=5.0s
+2024-01-15T14:33:00Z DOMAIN DOMAIN_REQUEST project=web cloister=web-main domain="evil.example.com"
+2024-01-15T14:33:30Z DOMAIN DOMAIN_DENY project=web cloister=web-main domain="evil.example.com" user="bob" reason="no"
+`
+	if err := os.WriteFile(path, []byte(log), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		set      func()
+		want     []string
+		dontWant []string
+	}{
+		{
+			name:     "table filtered by outcome",
+			set:      func() { auditOutcomes = []string{"failed"} },
+			want:     []string{"COMMAND/DOMAIN", "COMPLETE", "exit=2"},
+			dontWant: []string{"APPROVE", "evil.example.com"},
+		},
+		{
+			name:     "json filtered by project and time",
+			set:      func() { auditProject, auditSince, auditOutput = "web", "2024-01-15T14:33:10Z", "json" },
+			want:     []string{`"type":"DOMAIN_DENY"`, `"reason":"no"`},
+			dontWant: []string{"DOMAIN_REQUEST", "make test"},
+		},
+		{
+			name:     "match",
+			set:      func() { auditMatch = "EVIL" },
+			want:     []string{"DOMAIN_REQUEST", "DOMAIN_DENY"},
+			dontWant: []string{"make test"},
+		},
+		{
+			name: "summary",
+			set:  func() { auditSummary = true },
+			want: []string{
+				"Events:   5", "Denials:  0 hostexec, 1 domain",
+				"Approval latency: 2 decision(s), median 30s, p90 30s, max 30s",
+				"Top domains:", "evil.example.com", "Most-run commands:", "make test",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetAuditFlags(t)
+			auditFile = path
+			tt.set()
+			var out bytes.Buffer
+			term.SetOutput(&out)
+			t.Cleanup(term.Reset)
+
+			if err := auditCmd.RunE(auditCmd, nil); err != nil {
+				t.Fatalf("audit returned error: %v", err)
+			}
+			for _, s := range tt.want {
+				if !strings.Contains(out.String(), s) {
+					t.Errorf("output missing %q:\n%s", s, out.String())
+				}
+			}
+			for _, s := range tt.dontWant {
+				if strings.Contains(out.String(), s) {
+					t.Errorf("output contains %q:\n%s", s, out.String())
+				}
+			}
+		})
+	}
+}
+
+func TestAuditQueryCmd_InvalidFlags(t *testing.T) {
+	for _, set := range []func(){
+		func() { auditTypes = []string{"BOGUS"} },
+		func() { auditOutcomes = []string{"maybe"} },
+		func() { auditSince = "yesterday" },
+		func() { auditOutput = "yaml" },
+	} {
+		resetAuditFlags(t)
+		auditFile = "unused.log"
+		set()
+		if err := auditCmd.RunE(auditCmd, nil); err == nil {
+			t.Error("audit accepted an invalid flag")
+		}
+	}
+}
+
+func TestParseAuditTime(t *testing.T) {
+	now := time.Date(2024, 1, 15, 14, 0, 0, 0, time.UTC)
+	tests := []struct {
+		in   string
+		want time.Time
+	}{
+		{"", time.Time{}},
+		{"2024-01-10T08:00:00Z", time.Date(2024, 1, 10, 8, 0, 0, 0, time.UTC)},
+		{"2024-01-10", time.Date(2024, 1, 10, 0, 0, 0, 0, time.Local)},
+		{"90m", now.Add(-90 * time.Minute)},
+	}
+	for _, tt := range tests {
+		got, err := parseAuditTime(tt.in, now)
+		if err != nil || !got.Equal(tt.want) {
+			t.Errorf("parseAuditTime(%q) = %v, %v; want %v", tt.in, got, err, tt.want)
+		}
+	}
+	if _, err := parseAuditTime("-1h", now); err == nil {
+		t.Error("parseAuditTime accepted a negative duration")
+	}
+}
+
+// resetAuditFlags restores the audit command's flags to their defaults now
+// and after the test.
+func resetAuditFlags(t *testing.T) {
+	t.Helper()
+	reset := func() {
+		auditFile, auditSince, auditUntil, auditProject, auditCloister, auditMatch = "", "", "", "", "", ""
+		auditTypes, auditOutcomes = nil, nil
+		auditOutput, auditSummary, auditTop = "table", false, 10
+	}
+	reset()
+	t.Cleanup(reset)
+}
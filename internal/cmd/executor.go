@@ -1,18 +1,22 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
 	"net"
 	"os"
 	"os/signal"
 	"strconv"
 	"syscall"
+	"time"
 
 	"github.com/spf13/cobra"
 
 	"github.com/xdg/cloister/internal/clog"
+	"github.com/xdg/cloister/internal/config"
 	"github.com/xdg/cloister/internal/executor"
 	"github.com/xdg/cloister/internal/guardian"
+	"github.com/xdg/cloister/internal/tracing"
 )
 
 var executorCmd = &cobra.Command{
@@ -30,7 +34,7 @@ This command is intended to be run as a background process by 'cloister guardian
 and should not normally be invoked directly by users.
 
 The executor listens on a Unix socket and executes approved commands on the host.
-It validates requests using a shared secret (guardian-executor authentication).
+It verifies per-request HMAC signatures keyed by a shared secret (guardian-executor authentication).
 Token validation is handled by the guardian before forwarding requests.`,
 	Hidden: true,
 	RunE:   runExecutor,
@@ -41,6 +45,30 @@ func init() {
 	rootCmd.AddCommand(executorCmd)
 }
 
+// newExecutorLimiter builds the concurrency limiter from the global config,
+// falling back to the built-in defaults if the config could not be loaded.
+func newExecutorLimiter(cfg *config.GlobalConfig) *executor.Limiter {
+	if cfg == nil {
+		return executor.NewLimiter(0, 0)
+	}
+	return executor.NewLimiter(cfg.Hostexec.MaxConcurrent, cfg.Hostexec.MaxConcurrentPerCloister)
+}
+
+// newExecutorTracer installs a tracer exporting to the configured OTLP
+// collector, or returns nil if tracing is not configured.
+func newExecutorTracer(cfg *config.GlobalConfig) *tracing.Tracer {
+	if cfg == nil || cfg.Tracing.Endpoint == "" {
+		return nil
+	}
+	t := tracing.NewTracer(tracing.Options{
+		Endpoint: cfg.Tracing.Endpoint,
+		Service:  "cloister-executor",
+		Headers:  cfg.Tracing.Headers,
+	})
+	tracing.SetTracer(t)
+	return t
+}
+
 // runExecutor starts the executor socket server and blocks until interrupted.
 func runExecutor(_ *cobra.Command, _ []string) error {
 	// Switch to daemon mode: logs go to file only, not stderr
@@ -52,6 +80,13 @@ func runExecutor(_ *cobra.Command, _ []string) error {
 		return fmt.Errorf("shared secret not provided (set %s)", guardian.SharedSecretEnvVar)
 	}
 
+	cfg, err := config.LoadGlobalConfig()
+	if err != nil {
+		clog.Warn("failed to load config, using default concurrency limits: %v", err)
+		cfg = nil
+	}
+	tracer := newExecutorTracer(cfg)
+
 	// Create real executor
 	realExecutor := executor.NewRealExecutor()
 
@@ -62,6 +97,7 @@ func runExecutor(_ *cobra.Command, _ []string) error {
 		secret,
 		realExecutor,
 		executor.WithTCPAddr("127.0.0.1:0"),
+		executor.WithLimiter(newExecutorLimiter(cfg)),
 	)
 
 	// Start the server
@@ -109,6 +145,14 @@ func runExecutor(_ *cobra.Command, _ []string) error {
 		clog.Warn("failed to remove daemon state: %v", err)
 	}
 
+	if tracer != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := tracer.Shutdown(ctx); err != nil {
+			clog.Warn("failed to export remaining trace spans: %v", err)
+		}
+	}
+
 	clog.Debug("executor server stopped")
 	return nil
 }
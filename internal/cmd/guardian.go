@@ -4,16 +4,19 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"os"
 	"strings"
 	"time"
 
 	"github.com/spf13/cobra"
+	xterm "golang.org/x/term"
 
 	"github.com/xdg/cloister/internal/clog"
 	"github.com/xdg/cloister/internal/container"
 	"github.com/xdg/cloister/internal/docker"
 	"github.com/xdg/cloister/internal/executor"
 	"github.com/xdg/cloister/internal/guardian"
+	"github.com/xdg/cloister/internal/prompt"
 	"github.com/xdg/cloister/internal/term"
 	"github.com/xdg/cloister/internal/token"
 )
@@ -52,6 +55,9 @@ var guardianStartCmd = &cobra.Command{
 			return fmt.Errorf("failed to check guardian status: %w", err)
 		}
 		if running {
+			if err := ensureCompatibleGuardian(); err != nil {
+				return err
+			}
 			term.Println("Guardian is already running")
 			return nil
 		}
@@ -64,10 +70,15 @@ var guardianStartCmd = &cobra.Command{
 				term.Println("Guardian is already running")
 				return nil
 			}
+			var incompatible *guardian.IncompatibleError
+			if errors.As(err, &incompatible) {
+				return incompatibleGuardianError(incompatible)
+			}
 			return fmt.Errorf("failed to start guardian: %w", err)
 		}
 
 		term.Println("Guardian started successfully")
+		printApprovalURL()
 		return nil
 	},
 }
@@ -135,7 +146,13 @@ Warns if there are running cloister containers that depend on the guardian.`,
 var guardianStatusCmd = &cobra.Command{
 	Use:   "status",
 	Short: "Show guardian service status",
-	Long:  `Show guardian status including uptime and active token count.`,
+	Long: `Show guardian status including uptime, active token count, and the health
+of each guardian component: its servers, the executor connection, audit log
+writes, the latest configuration reload, and connected approval UI clients.
+Exits non-zero if any component is degraded or down.
+
+Also prints the approval UI URL. It includes a login token; opening it once
+signs the browser in to the approval UI for this guardian instance.`,
 	RunE: func(_ *cobra.Command, _ []string) error {
 		// Check if Docker is running
 		if err := docker.CheckDaemon(); err != nil {
@@ -157,6 +174,8 @@ var guardianStatusCmd = &cobra.Command{
 		}
 
 		term.Println("Status: running")
+		info, versionErr := guardian.CheckVersion()
+		printGuardianVersion(info, versionErr)
 
 		// Get container details for uptime
 		uptime, err := getGuardianUptime()
@@ -184,10 +203,37 @@ var guardianStatusCmd = &cobra.Command{
 			term.Println("Executor: not running (stale state)")
 		}
 
+		report, healthErr := guardian.CheckHealth()
+		if healthErr != nil {
+			term.Printf("Health: (unable to retrieve: %v)\n", healthErr)
+		} else {
+			printHealthReport(report)
+		}
+
+		printApprovalURL()
+
+		var incompatible *guardian.IncompatibleError
+		if errors.As(versionErr, &incompatible) {
+			return incompatibleGuardianError(incompatible)
+		}
+		if healthErr != nil {
+			return fmt.Errorf("failed to check guardian health: %w", healthErr)
+		}
+		if report.Status != guardian.HealthOK {
+			return fmt.Errorf("guardian is %s: %s", report.Status, strings.Join(report.Unhealthy(), ", "))
+		}
 		return nil
 	},
 }
 
+// printHealthReport prints the overall health and a line per component.
+func printHealthReport(report *guardian.HealthReport) {
+	term.Printf("Health: %s\n", report.Status)
+	for _, c := range report.Components {
+		term.Printf("  %-10s %-9s %s\n", c.Name, c.Status, c.Detail)
+	}
+}
+
 var guardianReloadCmd = &cobra.Command{
 	Use:   "reload",
 	Short: "Reload guardian configuration",
@@ -213,6 +259,27 @@ per-project decisions without restarting the guardian.`,
 	},
 }
 
+var guardianApproverURLCmd = &cobra.Command{
+	Use:   "approver-url <name>",
+	Short: "Print one approver's approval UI login URL",
+	Long: `Print the approval UI login URL of a named approver from the global
+config's approvers list. Approvals made through it are recorded under that
+name.
+
+Each approver's URL carries a random login token of their own, generated when
+the guardian starts. Give each approver only their own URL; anyone holding it
+can approve as them until the guardian restarts.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(_ *cobra.Command, args []string) error {
+		u, err := guardian.ApproverURL(args[0])
+		if err != nil {
+			return err //nolint:wrapcheck // already describes the fix
+		}
+		term.Println(u)
+		return nil
+	},
+}
+
 var guardianRunCmd = &cobra.Command{
 	Use:    "run",
 	Short:  "Run the guardian proxy server (internal)",
@@ -240,6 +307,7 @@ func init() {
 	guardianCmd.AddCommand(guardianStopCmd)
 	guardianCmd.AddCommand(guardianStatusCmd)
 	guardianCmd.AddCommand(guardianReloadCmd)
+	guardianCmd.AddCommand(guardianApproverURLCmd)
 	guardianCmd.AddCommand(guardianRunCmd)
 	rootCmd.AddCommand(guardianCmd)
 }
@@ -254,6 +322,12 @@ func runGuardianProxy(_ *cobra.Command, _ []string) error {
 	cfg := guardian.LoadGuardianConfig()
 	decisions := guardian.LoadGuardianDecisions()
 
+	if rotate := guardian.LogRotation(cfg); rotate.Enabled() {
+		if err := clog.EnableRotation(clog.DefaultLogPath(), rotate); err != nil {
+			clog.Warn("failed to enable log rotation: %v", err)
+		}
+	}
+
 	srv, err := guardian.NewServer(registry, cfg, decisions)
 	if err != nil {
 		return err
@@ -337,3 +411,91 @@ func formatDuration(d time.Duration) string {
 	}
 	return fmt.Sprintf("%d days", days)
 }
+
+// printApprovalURL prints the owner's approval UI login URL. Approvers' URLs
+// are never listed alongside it; each is printed on request by
+// "cloister guardian approver-url".
+func printApprovalURL() {
+	approvalURL, err := guardian.ApprovalURL()
+	if err != nil {
+		return
+	}
+	term.Printf("Approval UI: %s\n", approvalURL)
+}
+
+// guardianRestartPrompter asks whether to restart an incompatible guardian.
+// If nil, stdin is used when it is a terminal; otherwise the CLI refuses
+// without asking.
+var guardianRestartPrompter prompt.YesNoPrompter
+
+// checkGuardianVersion and restartGuardian are replaced in tests.
+var (
+	checkGuardianVersion = guardian.CheckVersion
+	restartGuardian      = func() error {
+		if err := guardian.Stop(); err != nil {
+			return err //nolint:wrapcheck // wrapped by the caller
+		}
+		return guardian.EnsureRunning() //nolint:wrapcheck // wrapped by the caller
+	}
+)
+
+// ensureCompatibleGuardian checks that a running guardian serves this CLI's
+// API version. If the guardian is older, it offers to restart it with the
+// current image; otherwise, or if the user declines, it refuses with an
+// explanation. A guardian that is not running, or cannot be reached, is left
+// to the caller.
+func ensureCompatibleGuardian() error {
+	_, err := checkGuardianVersion()
+	var incompatible *guardian.IncompatibleError
+	if !errors.As(err, &incompatible) {
+		return nil
+	}
+	if !incompatible.GuardianOlder() {
+		return incompatibleGuardianError(incompatible)
+	}
+
+	prompter := guardianRestartPrompter
+	if prompter == nil {
+		if !xterm.IsTerminal(int(os.Stdin.Fd())) {
+			return incompatibleGuardianError(incompatible)
+		}
+		prompter = prompt.NewStdinYesNoPrompter(os.Stdin, term.Stdout())
+	}
+	term.Warn("%v", incompatible)
+	restart, err := prompter.PromptYesNo("Restart the guardian now? Running cloisters lose network access until it is back. [y/N]: ", false)
+	if err != nil || !restart {
+		return incompatibleGuardianError(incompatible)
+	}
+
+	term.Println("Restarting guardian...")
+	if err := restartGuardian(); err != nil {
+		return fmt.Errorf("failed to restart guardian: %w", err)
+	}
+	term.Println("Guardian restarted")
+	return nil
+}
+
+// incompatibleGuardianError explains how to resolve an incompatible
+// guardian.
+func incompatibleGuardianError(err *guardian.IncompatibleError) error {
+	if err.GuardianOlder() {
+		return fmt.Errorf("%w\n\nhint: restart the guardian with 'cloister guardian stop' and 'cloister guardian start'; if it is still incompatible, rebuild the image with 'docker build -t cloister:latest .'", err)
+	}
+	return fmt.Errorf("%w\n\nhint: upgrade cloister to a release that speaks guardian API version %d or later", err, err.Guardian.MinAPIVersion)
+}
+
+// printGuardianVersion prints the guardian's version and whether it serves
+// this CLI's API version.
+func printGuardianVersion(info *guardian.VersionInfo, err error) {
+	switch {
+	case info == nil:
+		term.Printf("Version: (unable to retrieve: %v)\n", err)
+	case info.APIVersion == 0:
+		term.Println("Version: unknown (predates API versioning), incompatible")
+	case err != nil:
+		term.Printf("Version: %s (API %d-%d), incompatible with this cloister (API %d)\n",
+			info.Version, info.MinAPIVersion, info.APIVersion, guardian.APIVersion)
+	default:
+		term.Printf("Version: %s (API %d-%d)\n", info.Version, info.MinAPIVersion, info.APIVersion)
+	}
+}
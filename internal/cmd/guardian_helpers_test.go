@@ -1,9 +1,12 @@
 package cmd
 
 import (
+	"bytes"
 	"testing"
 
 	"github.com/xdg/cloister/internal/config"
+	"github.com/xdg/cloister/internal/guardian"
+	"github.com/xdg/cloister/internal/term"
 )
 
 func TestMergeStaticAndDecisions_GlobalOnly(t *testing.T) {
@@ -366,3 +369,25 @@ func TestMergeStaticAndDecisions_AllowAndDeny(t *testing.T) {
 		t.Errorf("deny[1].Pattern = %q, want %q", deny[1].Pattern, "*.evil.com")
 	}
 }
+
+func TestPrintHealthReport(t *testing.T) {
+	var out bytes.Buffer
+	term.SetOutput(&out)
+	t.Cleanup(term.Reset)
+
+	printHealthReport(&guardian.HealthReport{
+		Status: guardian.HealthDegraded,
+		Ready:  true,
+		Components: []guardian.ComponentHealth{
+			{Name: "proxy", Status: guardian.HealthOK, Detail: "listening on [::]:3128", Critical: true},
+			{Name: "executor", Status: guardian.HealthDegraded, Detail: "unreachable: connection refused"},
+		},
+	})
+
+	want := "Health: degraded\n" +
+		"  proxy      ok        listening on [::]:3128\n" +
+		"  executor   degraded  unreachable: connection refused\n"
+	if out.String() != want {
+		t.Errorf("output =\n%s\nwant\n%s", out.String(), want)
+	}
+}
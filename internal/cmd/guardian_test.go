@@ -0,0 +1,111 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/xdg/cloister/internal/guardian"
+	"github.com/xdg/cloister/internal/prompt"
+	"github.com/xdg/cloister/internal/term"
+)
+
+// stubGuardianVersion replaces the guardian version check and restart for
+// the duration of a test, returning a counter of restarts.
+func stubGuardianVersion(t *testing.T, info guardian.VersionInfo, checkErr error, answers ...bool) *int {
+	t.Helper()
+	oldCheck, oldRestart, oldPrompter := checkGuardianVersion, restartGuardian, guardianRestartPrompter
+	t.Cleanup(func() {
+		checkGuardianVersion, restartGuardian, guardianRestartPrompter = oldCheck, oldRestart, oldPrompter
+	})
+
+	restarts := 0
+	checkGuardianVersion = func() (*guardian.VersionInfo, error) { return &info, checkErr }
+	restartGuardian = func() error {
+		restarts++
+		return nil
+	}
+	guardianRestartPrompter = prompt.NewMockYesNoPrompter(answers...)
+	return &restarts
+}
+
+func TestEnsureCompatibleGuardian(t *testing.T) {
+	older := guardian.VersionInfo{}
+	newer := guardian.VersionInfo{Version: "v9", APIVersion: guardian.APIVersion + 2, MinAPIVersion: guardian.APIVersion + 1}
+
+	tests := []struct {
+		name         string
+		info         guardian.VersionInfo
+		incompatible bool
+		checkErr     error
+		answers      []bool
+		wantErr      string
+		wantRestarts int
+	}{
+		{name: "compatible", info: guardian.VersionInfo{APIVersion: guardian.APIVersion, MinAPIVersion: 1}},
+		{name: "not running", checkErr: guardian.ErrGuardianNotRunning},
+		{name: "older, restart", info: older, incompatible: true, answers: []bool{true}, wantRestarts: 1},
+		{name: "older, declined", info: older, incompatible: true, answers: []bool{false}, wantErr: "cloister guardian stop"},
+		{name: "newer", info: newer, incompatible: true, wantErr: "upgrade cloister"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			term.SetOutput(&buf)
+			t.Cleanup(term.Reset)
+
+			checkErr := tt.checkErr
+			if tt.incompatible {
+				checkErr = &guardian.IncompatibleError{Guardian: tt.info}
+			}
+			restarts := stubGuardianVersion(t, tt.info, checkErr, tt.answers...)
+
+			err := ensureCompatibleGuardian()
+			if tt.wantErr == "" && err != nil {
+				t.Fatalf("ensureCompatibleGuardian() error = %v", err)
+			}
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("ensureCompatibleGuardian() error = %v, want it to contain %q", err, tt.wantErr)
+				}
+				if !errors.Is(err, guardian.ErrIncompatibleGuardian) {
+					t.Errorf("error = %v, want ErrIncompatibleGuardian", err)
+				}
+			}
+			if *restarts != tt.wantRestarts {
+				t.Errorf("restarts = %d, want %d", *restarts, tt.wantRestarts)
+			}
+			if tt.wantRestarts > 0 && !strings.Contains(buf.String(), "Guardian restarted") {
+				t.Errorf("output = %q, want restart confirmation", buf.String())
+			}
+		})
+	}
+}
+
+func TestPrintGuardianVersion(t *testing.T) {
+	current := &guardian.VersionInfo{Version: "v1.2.3", APIVersion: guardian.APIVersion, MinAPIVersion: guardian.MinAPIVersion}
+	tests := []struct {
+		name string
+		info *guardian.VersionInfo
+		err  error
+		want string
+	}{
+		{"compatible", current, nil, "Version: v1.2.3 (API 1-1)\n"},
+		{"predates versioning", &guardian.VersionInfo{}, &guardian.IncompatibleError{}, "predates API versioning"},
+		{"incompatible", current, errors.New("x"), "incompatible with this cloister"},
+		{"unreachable", nil, errors.New("connection refused"), "unable to retrieve: connection refused"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			term.SetOutput(&buf)
+			t.Cleanup(term.Reset)
+
+			printGuardianVersion(tt.info, tt.err)
+			if !strings.Contains(buf.String(), tt.want) {
+				t.Errorf("output = %q, want it to contain %q", buf.String(), tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,90 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+
+	"github.com/spf13/cobra"
+
+	"github.com/xdg/cloister/internal/guardian"
+	"github.com/xdg/cloister/internal/guardian/approval"
+	"github.com/xdg/cloister/internal/term"
+)
+
+var logsCmd = &cobra.Command{
+	Use:   "logs [cloister-name]",
+	Short: "Show hostexec and domain audit events",
+	Long: `Show recent audit events recorded by the guardian: host command requests,
+approvals, denials, and results, and domain approval decisions.
+
+With a cloister name, shows only that cloister's events; otherwise shows
+events for all cloisters. --project and --type narrow the output further.
+--type takes event types (e.g. DENY, DOMAIN_APPROVE) or the categories
+"hostexec" and "domain", and may be repeated or comma-separated.
+
+With --follow, keeps running and prints new events as they happen, which
+is useful for watching an agent from a second terminal. Press Ctrl-C to
+stop.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runLogs,
+}
+
+var (
+	logsFollow  bool
+	logsProject string
+	logsTypes   []string
+	logsTail    int
+)
+
+func init() {
+	logsCmd.Flags().BoolVarP(&logsFollow, "follow", "f", false, "keep printing new events as they happen")
+	logsCmd.Flags().StringVar(&logsProject, "project", "", "show only events for this project")
+	logsCmd.Flags().StringSliceVar(&logsTypes, "type", nil, "show only these event types or categories (hostexec, domain)")
+	logsCmd.Flags().IntVarP(&logsTail, "tail", "n", 20, "number of recent events to show first")
+	rootCmd.AddCommand(logsCmd)
+}
+
+// logsAPI is the subset of approval.Client used by logs.
+type logsAPI interface {
+	Logs(ctx context.Context, q approval.LogQuery, onEntry func(approval.LogEntry)) error
+}
+
+// logsClientFactory creates the client used by logs.
+// It can be overridden for testing.
+var logsClientFactory = func() (logsAPI, error) {
+	return guardian.NewApprovalClient()
+}
+
+func runLogs(_ *cobra.Command, args []string) error {
+	if logsTail < 0 {
+		return fmt.Errorf("--tail must not be negative")
+	}
+	c, err := logsClientFactory()
+	if err != nil {
+		return fmt.Errorf("cannot reach the approval server (is the guardian running?): %w", err)
+	}
+
+	q := approval.LogQuery{
+		Project: logsProject,
+		Types:   logsTypes,
+		Tail:    logsTail,
+		Follow:  logsFollow,
+	}
+	if len(args) > 0 {
+		q.Cloister = args[0]
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	err = c.Logs(ctx, q, func(e approval.LogEntry) {
+		term.Println(e.Line)
+	})
+	// An error after Ctrl-C is just the stream being torn down.
+	if err != nil && ctx.Err() == nil {
+		return fmt.Errorf("failed to read logs: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/xdg/cloister/internal/guardian/approval"
+	"github.com/xdg/cloister/internal/term"
+)
+
+// fakeLogsAPI returns canned entries and records the query.
+type fakeLogsAPI struct {
+	query   approval.LogQuery
+	entries []approval.LogEntry
+	err     error
+}
+
+func (f *fakeLogsAPI) Logs(_ context.Context, q approval.LogQuery, onEntry func(approval.LogEntry)) error {
+	f.query = q
+	for _, e := range f.entries {
+		onEntry(e)
+	}
+	return f.err
+}
+
+// useFakeLogsAPI installs api as the logs client for one test and resets
+// the logs flags afterwards.
+func useFakeLogsAPI(t *testing.T, api *fakeLogsAPI) {
+	t.Helper()
+	old := logsClientFactory
+	logsClientFactory = func() (logsAPI, error) { return api, nil }
+	t.Cleanup(func() {
+		logsClientFactory = old
+		logsFollow, logsProject, logsTypes, logsTail = false, "", nil, 20
+	})
+}
+
+func TestLogsCmd_PrintsLines(t *testing.T) {
+	api := &fakeLogsAPI{entries: []approval.LogEntry{
+		{Line: `2025-01-02T03:04:05Z HOSTEXEC REQUEST project=p cloister=c cmd="make"`},
+		{Line: `2025-01-02T03:04:06Z DOMAIN DOMAIN_REQUEST project=p cloister=c domain="x.io"`},
+	}}
+	useFakeLogsAPI(t, api)
+	var out bytes.Buffer
+	term.SetOutput(&out)
+	t.Cleanup(term.Reset)
+
+	logsFollow, logsProject, logsTypes, logsTail = true, "p", []string{"hostexec", "DOMAIN_DENY"}, 5
+	if err := logsCmd.RunE(logsCmd, []string{"c"}); err != nil {
+		t.Fatalf("logs returned error: %v", err)
+	}
+
+	q := api.query
+	if q.Cloister != "c" || q.Project != "p" || !q.Follow || q.Tail != 5 || !slices.Equal(q.Types, []string{"hostexec", "DOMAIN_DENY"}) {
+		t.Errorf("query = %+v", q)
+	}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 2 || !strings.Contains(lines[0], `cmd="make"`) {
+		t.Errorf("output = %q", out.String())
+	}
+}
+
+func TestLogsCmd_AllCloisters(t *testing.T) {
+	api := &fakeLogsAPI{}
+	useFakeLogsAPI(t, api)
+
+	if err := logsCmd.RunE(logsCmd, nil); err != nil {
+		t.Fatalf("logs returned error: %v", err)
+	}
+	if api.query.Cloister != "" || api.query.Follow || api.query.Tail != 20 {
+		t.Errorf("query = %+v, want all cloisters, tail 20, no follow", api.query)
+	}
+}
+
+func TestLogsCmd_Errors(t *testing.T) {
+	useFakeLogsAPI(t, &fakeLogsAPI{err: errors.New("approval server: unknown event type \"bogus\"")})
+
+	err := logsCmd.RunE(logsCmd, nil)
+	if err == nil || !strings.Contains(err.Error(), "bogus") {
+		t.Errorf("error = %v, want server error", err)
+	}
+
+	logsTail = -1
+	if err := logsCmd.RunE(logsCmd, nil); err == nil {
+		t.Error("expected error for negative --tail")
+	}
+}
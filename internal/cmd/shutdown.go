@@ -19,8 +19,8 @@ var shutdownCmd = &cobra.Command{
 	Short: "Stop all cloisters and the guardian",
 	Long: `Stop all running cloister containers and the guardian service.
 
-Stops each cloister container (revoking its token), then stops the executor
-and guardian. This is equivalent to running "cloister stop" on every cloister
+Stops each cloister container (revoking its token) and prints its session
+report, then stops the executor and guardian. This is equivalent to running "cloister stop" on every cloister
 followed by "cloister guardian stop".`,
 	RunE: runShutdown,
 }
@@ -59,10 +59,12 @@ func runShutdown(_ *cobra.Command, _ []string) error {
 		// Find token for this container (best-effort; guardian may be down)
 		token := guardian.FindTokenForContainer(c.Name)
 
-		if err := cloister.Stop(c.Name, token); err != nil {
+		report, err := cloister.StopWithReport(c.Name, token)
+		if err != nil {
 			term.Warn("failed to stop %s: %v", cloisterName, err)
 			continue
 		}
+		printSessionReport(report)
 		stopped++
 	}
 
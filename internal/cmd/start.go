@@ -13,6 +13,7 @@ import (
 	"github.com/xdg/cloister/internal/config"
 	"github.com/xdg/cloister/internal/container"
 	"github.com/xdg/cloister/internal/docker"
+	"github.com/xdg/cloister/internal/guardian"
 	"github.com/xdg/cloister/internal/project"
 	"github.com/xdg/cloister/internal/term"
 )
@@ -27,7 +28,12 @@ cloister with the project mounted at /work. The guardian proxy is automatically
 started if not already running.
 
 After the cloister starts, an interactive shell is attached. When you exit
-the shell, the cloister remains running. Use 'cloister stop' to terminate it.`,
+the shell, the cloister remains running. Use 'cloister stop' to terminate it.
+
+With --approval-prompts, approval requests from this cloister are announced
+in the terminal title (with a bell) while attached. Press Ctrl-G then 'a' to
+approve or 'd' to deny; for domains, Ctrl-G 's' approves for the session.
+Press Ctrl-G twice to send a literal Ctrl-G to the shell.`,
 	RunE: runStart,
 }
 
@@ -37,10 +43,14 @@ var startAgentFlag string
 // startBranchFlag holds the --branch / -b flag value.
 var startBranchFlag string
 
+// startApprovalPromptsFlag holds the --approval-prompts flag value.
+var startApprovalPromptsFlag bool
+
 func init() {
 	rootCmd.AddCommand(startCmd)
 	startCmd.Flags().StringVar(&startAgentFlag, "agent", "", "AI agent to use (e.g., claude, codex). Overrides config default.")
 	startCmd.Flags().StringVarP(&startBranchFlag, "branch", "b", "", "Create a worktree cloister for the specified branch")
+	startCmd.Flags().BoolVar(&startApprovalPromptsFlag, "approval-prompts", false, "Announce approval requests in the terminal and accept Ctrl-G hotkeys")
 }
 
 func runStart(_ *cobra.Command, _ []string) error {
@@ -87,6 +97,11 @@ func runStart(_ *cobra.Command, _ []string) error {
 		clog.Warn("failed to auto-register project: %v", err)
 	}
 
+	// A running guardian must serve this CLI's API version
+	if err := ensureCompatibleGuardian(); err != nil {
+		return err
+	}
+
 	// If -b was provided, delegate to the worktree flow
 	if startBranchFlag != "" {
 		return runStartWorktree(globalCfg, gitRoot, projectName, startBranchFlag)
@@ -116,7 +131,7 @@ func runStart(_ *cobra.Command, _ []string) error {
 	term.Println()
 
 	// Step 6: Attach interactive shell
-	exitCode, err := cloister.Attach(containerName)
+	exitCode, err := cloister.Attach(containerName, attachOptions()...)
 	if err != nil {
 		return fmt.Errorf("failed to attach to cloister: %w", err)
 	}
@@ -161,7 +176,7 @@ func runStartWorktree(globalCfg *config.GlobalConfig, gitRoot, projectName, bran
 	term.Println()
 
 	// Attach interactive shell
-	exitCode, err := cloister.Attach(containerName)
+	exitCode, err := cloister.Attach(containerName, attachOptions()...)
 	if err != nil {
 		return fmt.Errorf("failed to attach to cloister: %w", err)
 	}
@@ -179,6 +194,20 @@ func runStartWorktree(globalCfg *config.GlobalConfig, gitRoot, projectName, bran
 	return nil
 }
 
+// attachOptions returns the options for attaching to a cloister, enabling
+// the approval side-channel when --approval-prompts is set.
+func attachOptions() []cloister.Option {
+	if !startApprovalPromptsFlag {
+		return nil
+	}
+	client, err := guardian.NewApprovalClient()
+	if err != nil {
+		term.Warn("approval prompts disabled: %v", err)
+		return nil
+	}
+	return []cloister.Option{cloister.WithApprovalPrompts(client)}
+}
+
 // handleStartError maps cloister.Start errors to user-friendly messages.
 func handleStartError(err error, containerName string) error {
 	if errors.Is(err, docker.ErrDockerNotRunning) {
@@ -190,7 +219,7 @@ func handleStartError(err error, containerName string) error {
 		term.Printf("Entering cloister %s. Type 'exit' to leave.\n", cloisterName)
 		term.Println()
 
-		_, exitCode, attachErr := cloister.AttachExisting(containerName)
+		_, exitCode, attachErr := cloister.AttachExisting(containerName, attachOptions()...)
 		if attachErr != nil {
 			return fmt.Errorf("failed to attach to cloister: %w", attachErr)
 		}
@@ -209,6 +238,10 @@ func handleStartError(err error, containerName string) error {
 
 // guardianErrorHint wraps guardian failures with actionable hints.
 func guardianErrorHint(err error) error {
+	var incompatible *guardian.IncompatibleError
+	if errors.As(err, &incompatible) {
+		return incompatibleGuardianError(incompatible)
+	}
 	errStr := err.Error()
 	if !strings.Contains(errStr, "guardian failed to start") {
 		return fmt.Errorf("failed to start cloister: %w", err)
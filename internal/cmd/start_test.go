@@ -286,3 +286,16 @@ func TestStartBranchFlagNaming(t *testing.T) {
 		})
 	}
 }
+
+func TestStartApprovalPromptsFlag(t *testing.T) {
+	flag := startCmd.Flags().Lookup("approval-prompts")
+	if flag == nil {
+		t.Fatal("expected --approval-prompts flag to be registered on startCmd")
+	}
+	if flag.DefValue != "false" {
+		t.Errorf("expected default value false, got %q", flag.DefValue)
+	}
+	if opts := attachOptions(); opts != nil {
+		t.Errorf("attachOptions() = %v, want nil when the flag is unset", opts)
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"github.com/spf13/cobra"
 
 	"github.com/xdg/cloister/internal/cloister"
+	"github.com/xdg/cloister/internal/config"
 	"github.com/xdg/cloister/internal/container"
 	"github.com/xdg/cloister/internal/docker"
 	"github.com/xdg/cloister/internal/guardian"
@@ -19,7 +20,13 @@ var stopCmd = &cobra.Command{
 	Long: `Stop a cloister and clean up its resources.
 
 If no cloister name is provided, stops the cloister for the current project.
-Revokes the cloister's token from the guardian and removes the container.`,
+Revokes the cloister's token from the guardian and removes the container.
+
+Then prints a session report: how long the cloister ran, the domains it
+contacted, approvals granted, hostexec commands and their exit codes,
+denials and timeouts, and git changes in its worktree since it started.
+With log.session_reports enabled, the report is also saved to
+log.per_cloister_dir.`,
 	RunE: runStop,
 }
 
@@ -52,7 +59,7 @@ func runStop(_ *cobra.Command, args []string) error {
 	token := guardian.FindTokenForContainer(containerName)
 
 	// Stop the container (this also revokes the token)
-	err := cloister.Stop(containerName, token)
+	report, err := cloister.StopWithReport(containerName, token)
 	if err != nil {
 		if errors.Is(err, docker.ErrDockerNotRunning) {
 			return dockerNotRunningError()
@@ -68,6 +75,27 @@ func runStop(_ *cobra.Command, args []string) error {
 	if token != "" {
 		term.Println("Token revoked from guardian.")
 	}
+	printSessionReport(report)
 
 	return nil
 }
+
+// printSessionReport prints a stopped cloister's session report, if any, and
+// saves it next to the per-cloister logs if log.session_reports is enabled.
+func printSessionReport(report *cloister.SessionReport) {
+	if report == nil {
+		return
+	}
+	report.Format(term.Stdout())
+
+	cfg, err := config.LoadGlobalConfig()
+	if err != nil || !cfg.Log.SessionReports || cfg.Log.PerCloisterDir == "" {
+		return
+	}
+	path, err := report.Save(cfg.Log.PerCloisterDir)
+	if err != nil {
+		term.Warn("failed to save session report: %v", err)
+		return
+	}
+	term.Printf("Session report saved to %s\n", path)
+}
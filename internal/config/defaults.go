@@ -77,13 +77,20 @@ proxy:
 request:
   listen: ":9998"  # Exposed on cloister-net
 
-  # Default timeout waiting for approval
+  # Default timeout waiting for approval of a hostexec request, or "none"
+  # to wait until decided. Projects, manual_approve patterns, and actions
+  # may override it with approval_timeout.
   timeout: "5m"
 
 # Hostexec server configuration (host-facing)
 hostexec:
   listen: "127.0.0.1:9999"  # Localhost only
 
+  # Limits on approved commands running on the host at once (0 = default).
+  # Extra commands wait in a FIFO queue and report their position.
+  # max_concurrent: 4
+  # max_concurrent_per_cloister: 2
+
   # Allowed command patterns (regex)
   # These bypass the approval UI and execute immediately
   # NOTE: Package installs (npm, pip, cargo, go) run inside the container
@@ -107,6 +114,34 @@ hostexec:
     - pattern: "^gh repo view( .+)?$"
     - pattern: "^gh run (list|view|watch)( .+)?$"
 
+# Notifications when approval requests are queued or time out.
+# Each sink may be filtered by projects, types (hostexec, domain), and
+# events (pending, timeout). The guardian runs in a container, so use
+# host.docker.internal to reach a webhook receiver on the host.
+# notify:
+#   webhooks:
+#     - url: "https://hooks.example.com/cloister"
+#       secret: "change-me"  # Adds an X-Cloister-Signature HMAC header
+#   commands:
+#     - command: ["notify-send", "cloister", "{{summary}}"]
+#       events: ["pending"]
+
+# OpenTelemetry tracing of proxy, approval and hostexec requests, exported
+# by the guardian and the host executor with OTLP over HTTP. Give the
+# collector's address as seen from the host; the guardian container reaches
+# localhost through host.docker.internal.
+# tracing:
+#   endpoint: "http://localhost:4318"
+#   headers:
+#     Authorization: "Bearer change-me"
+
+# Named approvers, each with their own approval UI login URL (shown by
+# "cloister guardian status"). Patterns, actions, and proxy.quorum entries
+# with require_approvals: N stay pending until N different people approve.
+# approvers:
+#   - name: alice
+#   - name: bob
+
 # AI agent configurations
 agents:
   claude:
@@ -146,9 +181,29 @@ log:
   stdout: true
   level: "info"  # debug, info, warn, error
 
-  # Per-cloister log files (in addition to main log)
+  # Audit log format: "text" (key=value lines) or "jsonl" (one versioned
+  # JSON object per line, for log pipelines)
+  # format: text
+
+  # Per-cloister audit logs (in addition to main log), one <cloister>.log
+  # file per cloister in per_cloister_dir
   per_cloister: true
   per_cloister_dir: "` + filepath.Join(stateDir, "logs") + `/"
+
+  # Save the session report printed when a cloister stops to
+  # <cloister>-session-<time>.txt in per_cloister_dir
+  # session_reports: false
+
+  # Rotation of the audit logs and the guardian's debug log. A file is moved
+  # aside to <file>.<timestamp> when it would grow past max_size_mb or is
+  # older than max_age (e.g. "24h"); keep and keep_for (e.g. "720h") limit
+  # how many rotated files are kept and for how long. 0 or "" disables each.
+  rotate:
+    max_size_mb: 100
+    # max_age: "24h"
+    compress: true
+    keep: 10
+    # keep_for: "720h"
 `
 }
 
@@ -177,6 +232,7 @@ func DefaultGlobalConfig() *GlobalConfig {
 		Log: LogConfig{
 			File: filepath.Join(stateDir, "audit.log"), Stdout: true, Level: "info",
 			PerCloister: true, PerCloisterDir: filepath.Join(stateDir, "logs") + "/",
+			Rotate: LogRotateConfig{MaxSizeMB: 100, Compress: true, Keep: 10},
 		},
 	}
 }
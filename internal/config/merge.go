@@ -24,6 +24,7 @@ type EffectiveConfig struct {
 	HostexecListen string
 	AutoApprove    []CommandPattern // Merged
 	ManualApprove  []CommandPattern // Merged
+	Actions        []HostexecAction // Merged
 
 	// Container defaults
 	Image string
@@ -99,6 +100,14 @@ func MergeCommandPatterns(global, project []CommandPattern) []CommandPattern {
 	return mergeSlices(global, project, func(p CommandPattern) string { return p.Pattern })
 }
 
+// MergeHostexecActions combines global and project hostexec actions.
+// Project actions ADD to global (don't replace).
+// Actions are deduplicated by name; a global action shadows a project
+// action with the same name.
+func MergeHostexecActions(global, project []HostexecAction) []HostexecAction {
+	return mergeSlices(global, project, func(a HostexecAction) string { return a.Name })
+}
+
 // ResolveConfig loads and merges global and project configurations into an
 // EffectiveConfig. If projectName is empty, only global config is used.
 // If projectName is provided but the project config doesn't exist, the
@@ -133,6 +142,7 @@ func ResolveConfig(projectName string) (*EffectiveConfig, error) {
 		HostexecListen: global.Hostexec.Listen,
 		AutoApprove:    global.Hostexec.AutoApprove,
 		ManualApprove:  global.Hostexec.ManualApprove,
+		Actions:        global.Hostexec.Actions,
 
 		// Container defaults
 		Image: global.Defaults.Image,
@@ -167,6 +177,15 @@ func ResolveConfig(projectName string) (*EffectiveConfig, error) {
 	// Merge command patterns (global + project)
 	effective.AutoApprove = MergeCommandPatterns(global.Hostexec.AutoApprove, project.Hostexec.AutoApprove)
 	effective.ManualApprove = MergeCommandPatterns(global.Hostexec.ManualApprove, project.Hostexec.ManualApprove)
+	effective.Actions = MergeHostexecActions(global.Hostexec.Actions, project.Hostexec.Actions)
+
+	// Project approval timeouts override global ones
+	if project.Proxy.ApprovalTimeout != "" {
+		effective.ApprovalTimeout = project.Proxy.ApprovalTimeout
+	}
+	if project.Hostexec.ApprovalTimeout != "" {
+		effective.RequestTimeout = project.Hostexec.ApprovalTimeout
+	}
 
 	return effective, nil
 }
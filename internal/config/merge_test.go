@@ -165,6 +165,27 @@ func TestMergeCommandPatterns_Empty(t *testing.T) {
 	}
 }
 
+func TestMergeHostexecActions_Dedup(t *testing.T) {
+	global := []HostexecAction{
+		{Name: "reset-db", Argv: []string{"global-reset"}},
+	}
+	project := []HostexecAction{
+		{Name: "reset-db", Argv: []string{"project-reset"}}, // Shadowed by global
+		{Name: "seed", Argv: []string{"seed"}},
+	}
+
+	result := MergeHostexecActions(global, project)
+	if len(result) != 2 {
+		t.Fatalf("len(result) = %d, want 2", len(result))
+	}
+	if result[0].Name != "reset-db" || result[0].Argv[0] != "global-reset" {
+		t.Errorf("result[0] = %+v, want global reset-db", result[0])
+	}
+	if result[1].Name != "seed" {
+		t.Errorf("result[1].Name = %q, want %q", result[1].Name, "seed")
+	}
+}
+
 func TestMergeDenylists_Empty(t *testing.T) {
 	result := MergeDenylists(nil, nil)
 	if result != nil {
@@ -306,10 +327,12 @@ proxy:
   allow:
     - domain: "custom.myproject.com"
     - domain: "internal.corp.net"
+  approval_timeout: "20s"
 hostexec:
   auto_approve:
     - pattern: "^make test$"
     - pattern: "^npm run build$"
+  approval_timeout: "none"
 `
 	projectPath := filepath.Join(projectsDir, "myproject.yaml")
 	if err := os.WriteFile(projectPath, []byte(projectContent), 0o600); err != nil {
@@ -342,6 +365,9 @@ hostexec:
 	if len(cfg.ProjectRefs) != 1 || cfg.ProjectRefs[0] != "/docs/api-spec" {
 		t.Errorf("cfg.ProjectRefs = %v, want %v", cfg.ProjectRefs, []string{"/docs/api-spec"})
 	}
+	if cfg.ApprovalTimeout != "20s" || cfg.RequestTimeout != "none" {
+		t.Errorf("approval timeouts = %q, %q, want project overrides %q, %q", cfg.ApprovalTimeout, cfg.RequestTimeout, "20s", "none")
+	}
 
 	// Verify merged allowlist contains both global and project entries
 	// Global has defaults like "golang.org", "api.anthropic.com"
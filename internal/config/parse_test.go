@@ -1,6 +1,7 @@
 package config
 
 import (
+	"slices"
 	"strings"
 	"testing"
 )
@@ -152,6 +153,38 @@ hostexec:
 	}
 }
 
+func TestParseGlobalConfig_Notify(t *testing.T) {
+	data := `
+notify:
+  webhooks:
+    - url: "https://hooks.example.com/cloister"
+      secret: "s3cret"
+      projects: ["my-api"]
+      events: ["pending"]
+  commands:
+    - command: ["notify-send", "cloister", "{{summary}}"]
+      types: ["hostexec"]
+`
+	cfg, err := ParseGlobalConfig([]byte(data))
+	if err != nil {
+		t.Fatalf("ParseGlobalConfig() error = %v", err)
+	}
+	if len(cfg.Notify.Webhooks) != 1 || len(cfg.Notify.Commands) != 1 {
+		t.Fatalf("Notify = %+v, want one webhook and one command", cfg.Notify)
+	}
+	w := cfg.Notify.Webhooks[0]
+	if w.URL != "https://hooks.example.com/cloister" || w.Secret != "s3cret" {
+		t.Errorf("webhook = %+v", w)
+	}
+	if !slices.Equal(w.Projects, []string{"my-api"}) || !slices.Equal(w.Events, []string{"pending"}) {
+		t.Errorf("webhook filter = %+v, want inline projects and events", w.NotifyFilter)
+	}
+	c := cfg.Notify.Commands[0]
+	if len(c.Command) != 3 || !slices.Equal(c.Types, []string{"hostexec"}) {
+		t.Errorf("command = %+v", c)
+	}
+}
+
 func TestParseProjectConfig_Valid(t *testing.T) {
 	cfg, err := ParseProjectConfig([]byte(sampleProjectConfig))
 	if err != nil {
@@ -12,6 +12,15 @@ type GlobalConfig struct {
 	Agents       map[string]AgentConfig `yaml:"agents,omitempty"`
 	Defaults     DefaultsConfig         `yaml:"defaults,omitempty"`
 	Log          LogConfig              `yaml:"log,omitempty"`
+	Notify       NotifyConfig           `yaml:"notify,omitempty"`
+	Tracing      TracingConfig          `yaml:"tracing,omitempty"`
+	Approvers    []Approver             `yaml:"approvers,omitempty"`
+}
+
+// Approver is a named person who may approve requests in the approval UI
+// with their own login URL. The guardian's owner can always approve too.
+type Approver struct {
+	Name string `yaml:"name"`
 }
 
 // ProxyConfig contains HTTP CONNECT proxy settings.
@@ -23,6 +32,15 @@ type ProxyConfig struct {
 	ApprovalTimeout        string       `yaml:"approval_timeout,omitempty"`
 	RateLimit              int          `yaml:"rate_limit,omitempty"`
 	MaxRequestBytes        int64        `yaml:"max_request_bytes,omitempty"`
+	Quorum                 []QuorumRule `yaml:"quorum,omitempty"`
+}
+
+// QuorumRule requires RequireApprovals distinct approvers before an unlisted
+// domain matching Domain or Pattern (e.g. "*.example.com") is allowed.
+type QuorumRule struct {
+	Domain           string `yaml:"domain,omitempty"`
+	Pattern          string `yaml:"pattern,omitempty"`
+	RequireApprovals int    `yaml:"require_approvals"`
 }
 
 // AllowEntry represents a single domain or pattern in an allowlist.
@@ -36,8 +54,16 @@ type AllowEntry struct {
 // RequestConfig contains settings for the request server that handles
 // hostexec commands from containers.
 type RequestConfig struct {
-	Listen  string `yaml:"listen,omitempty"`
+	Listen string `yaml:"listen,omitempty"`
+
+	// Timeout is how long a hostexec request waits for approval, e.g.
+	// "15m", or ApprovalTimeoutNone to wait until it is decided.
 	Timeout string `yaml:"timeout,omitempty"`
+
+	// MessagesFile is an absolute path inside the container. If set,
+	// hostexec appends approvers' denial messages to it so agents can read
+	// past feedback. Empty disables the file.
+	MessagesFile string `yaml:"messages_file,omitempty"`
 }
 
 // HostexecConfig contains settings for the hostexec approval server that provides
@@ -46,11 +72,96 @@ type HostexecConfig struct {
 	Listen        string           `yaml:"listen,omitempty"`
 	AutoApprove   []CommandPattern `yaml:"auto_approve,omitempty"`
 	ManualApprove []CommandPattern `yaml:"manual_approve,omitempty"`
+	Actions       []HostexecAction `yaml:"actions,omitempty"`
+	Redact        RedactConfig     `yaml:"redact,omitempty"`
+	Risk          RiskConfig       `yaml:"risk,omitempty"`
+
+	// Concurrency limits for approved commands on the host; 0 selects the
+	// executor's built-in default. Commands over the limit queue FIFO.
+	MaxConcurrent            int `yaml:"max_concurrent,omitempty"`
+	MaxConcurrentPerCloister int `yaml:"max_concurrent_per_cloister,omitempty"`
+}
+
+// RedactConfig controls masking of secrets in hostexec output before it is
+// returned to the cloister. Built-in detectors for common token formats are
+// always applied unless DisableBuiltin is set.
+type RedactConfig struct {
+	DisableBuiltin bool            `yaml:"disable_builtin,omitempty"`
+	Patterns       []RedactPattern `yaml:"patterns,omitempty"`
+}
+
+// RedactPattern is a custom secret detector. If Pattern contains a named
+// group "secret", only that group is masked; otherwise the whole match is.
+type RedactPattern struct {
+	Name    string `yaml:"name"`
+	Pattern string `yaml:"pattern"`
+}
+
+// RiskConfig controls which arguments are highlighted as dangerous on
+// hostexec approval cards. Built-in rules (force pushes, sudo, root mounts,
+// privileged containers, recursive rm) always apply unless DisableBuiltin
+// is set.
+type RiskConfig struct {
+	DisableBuiltin bool       `yaml:"disable_builtin,omitempty"`
+	Rules          []RiskRule `yaml:"rules,omitempty"`
+}
+
+// RiskRule flags each argument matching Token. If Command is set, the rule
+// only applies to command lines it matches.
+type RiskRule struct {
+	Name    string `yaml:"name"`
+	Command string `yaml:"command,omitempty"` // Regex over the canonical command line
+	Token   string `yaml:"token"`             // Regex over a single argument
 }
 
-// CommandPattern represents a regex pattern for matching commands.
+// CommandPattern represents a regex pattern for matching commands, with
+// optional execution limits applied to commands that match it.
 type CommandPattern struct {
-	Pattern string `yaml:"pattern,omitempty"`
+	Pattern        string            `yaml:"pattern,omitempty"`
+	Timeout        string            `yaml:"timeout,omitempty"`          // e.g., "5m"; empty means no timeout
+	MaxOutputBytes int64             `yaml:"max_output_bytes,omitempty"` // Per stream; 0 means unlimited
+	EnvAllow       []string          `yaml:"env_allow,omitempty"`        // Inherited env names to keep (globs)
+	EnvDeny        []string          `yaml:"env_deny,omitempty"`         // Inherited env names to drop (globs)
+	Env            map[string]string `yaml:"env,omitempty"`              // Extra env to inject
+
+	// RequireApprovals is the number of distinct approvers a manual_approve
+	// match needs; 0 or 1 means one.
+	RequireApprovals int `yaml:"require_approvals,omitempty"`
+
+	// ApprovalTimeout overrides request.timeout for a manual_approve match.
+	ApprovalTimeout string `yaml:"approval_timeout,omitempty"`
+}
+
+// HostexecAction defines a named host operation with a fixed command line.
+// Containers invoke it with "hostexec --action <name> [--param key=value ...]"
+// rather than sending a raw command. Argv elements, Workdir, and Env values
+// may reference parameters as {{name}}.
+type HostexecAction struct {
+	Name           string            `yaml:"name"`
+	Description    string            `yaml:"description,omitempty"`
+	Argv           []string          `yaml:"argv"`
+	Workdir        string            `yaml:"workdir,omitempty"` // Relative paths resolve against the cloister's worktree
+	Env            map[string]string `yaml:"env,omitempty"`
+	Params         []ActionParam     `yaml:"params,omitempty"`
+	Approve        string            `yaml:"approve,omitempty"` // "manual" (default) or "auto"
+	Timeout        string            `yaml:"timeout,omitempty"`
+	MaxOutputBytes int64             `yaml:"max_output_bytes,omitempty"`
+
+	// RequireApprovals is the number of distinct approvers a manual action
+	// needs; 0 or 1 means one.
+	RequireApprovals int `yaml:"require_approvals,omitempty"`
+
+	// ApprovalTimeout overrides request.timeout for a manual action.
+	ApprovalTimeout string `yaml:"approval_timeout,omitempty"`
+}
+
+// ActionParam declares a parameter accepted by a HostexecAction.
+type ActionParam struct {
+	Name     string `yaml:"name"`
+	Type     string `yaml:"type,omitempty"`    // "string" (default), "int", or "bool"
+	Pattern  string `yaml:"pattern,omitempty"` // Regex the entire value must match
+	Default  string `yaml:"default,omitempty"`
+	Required bool   `yaml:"required,omitempty"`
 }
 
 // DevcontainerConfig contains settings for devcontainer.json integration.
@@ -95,13 +206,70 @@ const (
 
 // LogConfig contains logging settings.
 type LogConfig struct {
-	File           string `yaml:"file,omitempty"`
-	Stdout         bool   `yaml:"stdout,omitempty"`
-	Level          string `yaml:"level,omitempty"`
-	PerCloister    bool   `yaml:"per_cloister,omitempty"`
-	PerCloisterDir string `yaml:"per_cloister_dir,omitempty"`
+	File           string          `yaml:"file,omitempty"`
+	Stdout         bool            `yaml:"stdout,omitempty"`
+	Level          string          `yaml:"level,omitempty"`
+	Format         string          `yaml:"format,omitempty"` // Audit log format: "text" (default) or "jsonl"
+	PerCloister    bool            `yaml:"per_cloister,omitempty"`
+	PerCloisterDir string          `yaml:"per_cloister_dir,omitempty"`
+	SessionReports bool            `yaml:"session_reports,omitempty"` // Save the report printed when a cloister stops to per_cloister_dir
+	Rotate         LogRotateConfig `yaml:"rotate,omitempty"`
+}
+
+// LogRotateConfig controls rotation of the guardian's audit logs and debug
+// log. Zero values disable each limit.
+type LogRotateConfig struct {
+	MaxSizeMB int    `yaml:"max_size_mb,omitempty"` // Rotate when a file would grow past this size
+	MaxAge    string `yaml:"max_age,omitempty"`     // Rotate when a file is older than this duration
+	Compress  bool   `yaml:"compress,omitempty"`    // gzip rotated files
+	Keep      int    `yaml:"keep,omitempty"`        // Rotated files to keep per log
+	KeepFor   string `yaml:"keep_for,omitempty"`    // Delete rotated files older than this duration
+}
+
+// NotifyConfig contains notification sinks fired when approval requests are
+// queued or time out.
+type NotifyConfig struct {
+	Webhooks []WebhookNotifier `yaml:"webhooks,omitempty"`
+	Commands []CommandNotifier `yaml:"commands,omitempty"`
+}
+
+// TracingConfig enables OpenTelemetry trace export from the guardian and the
+// host executor, using OTLP over HTTP. Tracing is off when Endpoint is empty.
+type TracingConfig struct {
+	// Endpoint is the collector's OTLP/HTTP base URL as seen from the host,
+	// e.g. "http://localhost:4318". Traces are posted to <endpoint>/v1/traces.
+	Endpoint string            `yaml:"endpoint,omitempty"`
+	Headers  map[string]string `yaml:"headers,omitempty"` // Sent with each export, e.g. for authentication
 }
 
+// NotifyFilter restricts which approval requests a notifier fires for.
+// Empty lists match everything.
+type NotifyFilter struct {
+	Projects []string `yaml:"projects,omitempty"`
+	Types    []string `yaml:"types,omitempty"`  // "hostexec" and/or "domain"
+	Events   []string `yaml:"events,omitempty"` // "pending" and/or "timeout"
+}
+
+// WebhookNotifier posts a JSON notification to an HTTP endpoint.
+type WebhookNotifier struct {
+	URL          string `yaml:"url"`
+	Secret       string `yaml:"secret,omitempty"`  // Signs the body with HMAC-SHA256 if set
+	Timeout      string `yaml:"timeout,omitempty"` // e.g., "5s"; default 10s
+	NotifyFilter `yaml:",inline"`
+}
+
+// CommandNotifier runs a command on the host via the executor. Arguments may
+// reference notification fields as {{name}} placeholders.
+type CommandNotifier struct {
+	Command      []string `yaml:"command"`
+	Timeout      string   `yaml:"timeout,omitempty"` // e.g., "5s"; default 10s
+	NotifyFilter `yaml:",inline"`
+}
+
+// ApprovalTimeoutNone as a hostexec approval timeout holds a request until
+// someone approves or denies it.
+const ApprovalTimeoutNone = "none"
+
 // ProjectConfig represents per-project configuration.
 // It is stored at ~/.config/cloister/projects/<project-name>.yaml.
 type ProjectConfig struct {
@@ -117,6 +285,10 @@ type ProjectConfig struct {
 type ProjectProxyConfig struct {
 	Allow []AllowEntry `yaml:"allow,omitempty"`
 	Deny  []AllowEntry `yaml:"deny,omitempty"`
+
+	// ApprovalTimeout overrides proxy.approval_timeout for this project's
+	// domain requests.
+	ApprovalTimeout string `yaml:"approval_timeout,omitempty"`
 }
 
 // ProjectHostexecConfig contains project-specific command patterns that are
@@ -124,4 +296,9 @@ type ProjectProxyConfig struct {
 type ProjectHostexecConfig struct {
 	AutoApprove   []CommandPattern `yaml:"auto_approve,omitempty"`
 	ManualApprove []CommandPattern `yaml:"manual_approve,omitempty"`
+	Actions       []HostexecAction `yaml:"actions,omitempty"`
+
+	// ApprovalTimeout overrides request.timeout for this project's hostexec
+	// requests. Patterns and actions may still set their own.
+	ApprovalTimeout string `yaml:"approval_timeout,omitempty"`
 }
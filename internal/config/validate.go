@@ -2,6 +2,8 @@ package config
 
 import (
 	"fmt"
+	"net/url"
+	"path"
 	"regexp"
 	"strconv"
 	"strings"
@@ -16,6 +18,12 @@ var validLogLevels = map[string]bool{
 	"error": true,
 }
 
+// validLogFormats defines the allowed audit log format values.
+var validLogFormats = map[string]bool{
+	"text":  true,
+	"jsonl": true,
+}
+
 // validAuthMethods defines the allowed auth_method values for agent configs.
 var validAuthMethods = map[string]bool{
 	string(AuthMethodToken):  true,
@@ -30,6 +38,11 @@ var validAuthMethods = map[string]bool{
 //   - RateLimit is non-negative
 //   - MaxRequestBytes is non-negative
 //   - Log.Level is one of: debug, info, warn, error (if non-empty)
+//   - Log.Format is one of: text, jsonl (if non-empty)
+//   - Log.Rotate limits are non-negative and its durations parse
+//   - Notify webhooks and commands are well-formed
+//   - Tracing.Endpoint is an http or https URL (if non-empty)
+//   - Approver names are unique and every require_approvals is reachable
 //
 // Returns nil if the config is valid, or an error with a clear message
 // indicating which field is invalid.
@@ -43,9 +56,24 @@ func ValidateGlobalConfig(cfg *GlobalConfig) error {
 	if err := validateHostexecConfig(&cfg.Hostexec); err != nil {
 		return err
 	}
+	if err := validateNotifyConfig(&cfg.Notify); err != nil {
+		return err
+	}
+	if err := validateApprovers(cfg); err != nil {
+		return err
+	}
+	if err := validateTracingConfig(&cfg.Tracing); err != nil {
+		return err
+	}
 	if cfg.Log.Level != "" && !validLogLevels[cfg.Log.Level] {
 		return fmt.Errorf("log.level: invalid value %q, must be one of: debug, info, warn, error", cfg.Log.Level)
 	}
+	if cfg.Log.Format != "" && !validLogFormats[cfg.Log.Format] {
+		return fmt.Errorf("log.format: invalid value %q, must be one of: text, jsonl", cfg.Log.Format)
+	}
+	if err := validateLogRotateConfig(&cfg.Log.Rotate); err != nil {
+		return err
+	}
 	for name, agentCfg := range cfg.Agents {
 		if err := ValidateAgentConfig(&agentCfg, fmt.Sprintf("agents.%s", name)); err != nil {
 			return err
@@ -54,6 +82,27 @@ func ValidateGlobalConfig(cfg *GlobalConfig) error {
 	return nil
 }
 
+// validateLogRotateConfig validates the log.rotate section of the global config.
+func validateLogRotateConfig(r *LogRotateConfig) error {
+	if r.MaxSizeMB < 0 {
+		return fmt.Errorf("log.rotate.max_size_mb: must not be negative, got %d", r.MaxSizeMB)
+	}
+	if r.Keep < 0 {
+		return fmt.Errorf("log.rotate.keep: must not be negative, got %d", r.Keep)
+	}
+	if r.MaxAge != "" {
+		if err := validateDuration(r.MaxAge, "log.rotate.max_age"); err != nil {
+			return err
+		}
+	}
+	if r.KeepFor != "" {
+		if err := validateDuration(r.KeepFor, "log.rotate.keep_for"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // validateProxyConfig validates the proxy section of the global config.
 func validateProxyConfig(proxy *ProxyConfig) error {
 	if proxy.Listen != "" {
@@ -72,6 +121,59 @@ func validateProxyConfig(proxy *ProxyConfig) error {
 	if proxy.MaxRequestBytes < 0 {
 		return fmt.Errorf("proxy.max_request_bytes: must be non-negative, got %d", proxy.MaxRequestBytes)
 	}
+	for i, q := range proxy.Quorum {
+		field := fmt.Sprintf("proxy.quorum[%d]", i)
+		if (q.Domain == "") == (q.Pattern == "") {
+			return fmt.Errorf("%s: exactly one of domain or pattern is required", field)
+		}
+		if q.Pattern != "" && (!strings.HasPrefix(q.Pattern, "*.") || strings.Contains(q.Pattern[2:], "*")) {
+			return fmt.Errorf("%s.pattern: must have the form *.example.com, got %q", field, q.Pattern)
+		}
+		if q.RequireApprovals < 1 {
+			return fmt.Errorf("%s.require_approvals: must be at least 1, got %d", field, q.RequireApprovals)
+		}
+	}
+	return nil
+}
+
+// validateApprovers checks approver names and that no require_approvals in
+// the global config asks for more approvers than exist. The guardian's owner
+// counts as one approver.
+func validateApprovers(cfg *GlobalConfig) error {
+	seen := make(map[string]bool, len(cfg.Approvers))
+	for i, a := range cfg.Approvers {
+		field := fmt.Sprintf("approvers[%d].name", i)
+		if !actionNameRe.MatchString(a.Name) {
+			return fmt.Errorf("%s: invalid approver name %q", field, a.Name)
+		}
+		if seen[a.Name] {
+			return fmt.Errorf("%s: duplicate approver %q", field, a.Name)
+		}
+		seen[a.Name] = true
+	}
+
+	available := len(cfg.Approvers) + 1
+	check := func(n int, field string) error {
+		if n > available {
+			return fmt.Errorf("%s.require_approvals: %d approvals required but only %d approvers (including the owner) are configured", field, n, available)
+		}
+		return nil
+	}
+	for i, p := range cfg.Hostexec.ManualApprove {
+		if err := check(p.RequireApprovals, fmt.Sprintf("hostexec.manual_approve[%d]", i)); err != nil {
+			return err
+		}
+	}
+	for i, a := range cfg.Hostexec.Actions {
+		if err := check(a.RequireApprovals, fmt.Sprintf("hostexec.actions[%d]", i)); err != nil {
+			return err
+		}
+	}
+	for i, q := range cfg.Proxy.Quorum {
+		if err := check(q.RequireApprovals, fmt.Sprintf("proxy.quorum[%d]", i)); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
@@ -82,10 +184,11 @@ func validateRequestConfig(req *RequestConfig) error {
 			return err
 		}
 	}
-	if req.Timeout != "" {
-		if err := validateDuration(req.Timeout, "request.timeout"); err != nil {
-			return err
-		}
+	if err := validateApprovalTimeout(req.Timeout, "request.timeout", true); err != nil {
+		return err
+	}
+	if req.MessagesFile != "" && !path.IsAbs(req.MessagesFile) {
+		return fmt.Errorf("request.messages_file: must be an absolute container path, got %q", req.MessagesFile)
 	}
 	return nil
 }
@@ -97,23 +200,140 @@ func validateHostexecConfig(hostexec *HostexecConfig) error {
 			return err
 		}
 	}
-	for i, pattern := range hostexec.AutoApprove {
-		if err := validateRegex(pattern.Pattern, fmt.Sprintf("hostexec.auto_approve[%d].pattern", i)); err != nil {
+	if err := validateApprovalPatterns(hostexec.AutoApprove, hostexec.ManualApprove); err != nil {
+		return err
+	}
+	if err := validateHostexecActions(hostexec.Actions); err != nil {
+		return err
+	}
+	if hostexec.MaxConcurrent < 0 {
+		return fmt.Errorf("hostexec.max_concurrent: must be non-negative, got %d", hostexec.MaxConcurrent)
+	}
+	if hostexec.MaxConcurrentPerCloister < 0 {
+		return fmt.Errorf("hostexec.max_concurrent_per_cloister: must be non-negative, got %d", hostexec.MaxConcurrentPerCloister)
+	}
+	if err := validateRedactConfig(&hostexec.Redact); err != nil {
+		return err
+	}
+	return validateRiskConfig(&hostexec.Risk)
+}
+
+// validateRedactConfig validates the hostexec.redact section.
+func validateRedactConfig(r *RedactConfig) error {
+	seen := make(map[string]bool, len(r.Patterns))
+	for i, p := range r.Patterns {
+		field := fmt.Sprintf("hostexec.redact.patterns[%d]", i)
+		if !actionNameRe.MatchString(p.Name) {
+			return fmt.Errorf("%s.name: invalid detector name %q", field, p.Name)
+		}
+		if seen[p.Name] {
+			return fmt.Errorf("%s.name: duplicate detector %q", field, p.Name)
+		}
+		seen[p.Name] = true
+		if p.Pattern == "" {
+			return fmt.Errorf("%s.pattern: must not be empty", field)
+		}
+		if err := validateRegex(p.Pattern, field+".pattern"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateRiskConfig validates the hostexec.risk section.
+func validateRiskConfig(r *RiskConfig) error {
+	seen := make(map[string]bool, len(r.Rules))
+	for i, rule := range r.Rules {
+		field := fmt.Sprintf("hostexec.risk.rules[%d]", i)
+		if !actionNameRe.MatchString(rule.Name) {
+			return fmt.Errorf("%s.name: invalid rule name %q", field, rule.Name)
+		}
+		if seen[rule.Name] {
+			return fmt.Errorf("%s.name: duplicate rule %q", field, rule.Name)
+		}
+		seen[rule.Name] = true
+		if rule.Token == "" {
+			return fmt.Errorf("%s.token: must not be empty", field)
+		}
+		if err := validateRegex(rule.Token, field+".token"); err != nil {
+			return err
+		}
+		if err := validateRegex(rule.Command, field+".command"); err != nil {
 			return err
 		}
 	}
-	for i, pattern := range hostexec.ManualApprove {
-		if err := validateRegex(pattern.Pattern, fmt.Sprintf("hostexec.manual_approve[%d].pattern", i)); err != nil {
+	return nil
+}
+
+// validNotifyTypes and validNotifyEvents define the allowed notify filter values.
+var (
+	validNotifyTypes  = map[string]bool{"hostexec": true, "domain": true}
+	validNotifyEvents = map[string]bool{"pending": true, "timeout": true}
+)
+
+// validateNotifyConfig validates the notify section of the global config.
+func validateNotifyConfig(n *NotifyConfig) error {
+	for i, w := range n.Webhooks {
+		field := fmt.Sprintf("notify.webhooks[%d]", i)
+		u, err := url.Parse(w.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("%s.url: must be an absolute http or https URL, got %q", field, w.URL)
+		}
+		if err := validateNotifyCommon(w.Timeout, &w.NotifyFilter, field); err != nil {
+			return err
+		}
+	}
+	for i, c := range n.Commands {
+		field := fmt.Sprintf("notify.commands[%d]", i)
+		if len(c.Command) == 0 || c.Command[0] == "" {
+			return fmt.Errorf("%s.command: must not be empty", field)
+		}
+		if err := validateNotifyCommon(c.Timeout, &c.NotifyFilter, field); err != nil {
 			return err
 		}
 	}
 	return nil
 }
 
+// validateTracingConfig validates the tracing section of the global config.
+func validateTracingConfig(t *TracingConfig) error {
+	if t.Endpoint == "" {
+		return nil
+	}
+	u, err := url.Parse(t.Endpoint)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("tracing.endpoint: must be an absolute http or https URL, got %q", t.Endpoint)
+	}
+	return nil
+}
+
+// validateNotifyCommon validates the timeout and filter shared by all notifiers.
+func validateNotifyCommon(timeout string, f *NotifyFilter, field string) error {
+	if timeout != "" {
+		if err := validateDuration(timeout, field+".timeout"); err != nil {
+			return err
+		}
+	}
+	for _, t := range f.Types {
+		if !validNotifyTypes[t] {
+			return fmt.Errorf("%s.types: invalid value %q, must be one of: hostexec, domain", field, t)
+		}
+	}
+	for _, e := range f.Events {
+		if !validNotifyEvents[e] {
+			return fmt.Errorf("%s.events: invalid value %q, must be one of: pending, timeout", field, e)
+		}
+	}
+	return nil
+}
+
 // ValidateProjectConfig validates a parsed ProjectConfig, checking that all
 // fields contain valid values. It validates:
 //   - Regex patterns in Hostexec.AutoApprove compile
 //   - Regex patterns in Hostexec.ManualApprove compile
+//   - Per-pattern execution limits are well-formed
+//   - Hostexec actions are well-formed
+//   - Approval timeouts are positive durations ("none" for hostexec only)
 //
 // Note: Remote URL is not validated as required because empty ProjectConfig
 // is valid (defaults will be applied later).
@@ -121,19 +341,216 @@ func validateHostexecConfig(hostexec *HostexecConfig) error {
 // Returns nil if the config is valid, or an error with a clear message
 // indicating which field is invalid.
 func ValidateProjectConfig(cfg *ProjectConfig) error {
-	for i, pattern := range cfg.Hostexec.AutoApprove {
-		if err := validateRegex(pattern.Pattern, fmt.Sprintf("hostexec.auto_approve[%d].pattern", i)); err != nil {
+	if err := validateApprovalPatterns(cfg.Hostexec.AutoApprove, cfg.Hostexec.ManualApprove); err != nil {
+		return err
+	}
+	if err := validateApprovalTimeout(cfg.Hostexec.ApprovalTimeout, "hostexec.approval_timeout", true); err != nil {
+		return err
+	}
+	if err := validateApprovalTimeout(cfg.Proxy.ApprovalTimeout, "proxy.approval_timeout", false); err != nil {
+		return err
+	}
+	return validateHostexecActions(cfg.Hostexec.Actions)
+}
+
+// validateApprovalPatterns validates the auto_approve and manual_approve
+// lists. A quorum only makes sense for manual approval.
+func validateApprovalPatterns(auto, manual []CommandPattern) error {
+	if err := validateCommandPatterns(auto, "hostexec.auto_approve"); err != nil {
+		return err
+	}
+	for i, p := range auto {
+		if p.RequireApprovals != 0 {
+			return fmt.Errorf("hostexec.auto_approve[%d].require_approvals: only valid on manual_approve patterns", i)
+		}
+		if p.ApprovalTimeout != "" {
+			return fmt.Errorf("hostexec.auto_approve[%d].approval_timeout: only valid on manual_approve patterns", i)
+		}
+	}
+	return validateCommandPatterns(manual, "hostexec.manual_approve")
+}
+
+// validateCommandPatterns validates each CommandPattern in a list.
+// The field parameter is the list's path (e.g., "hostexec.auto_approve").
+func validateCommandPatterns(cmds []CommandPattern, field string) error {
+	for i := range cmds {
+		if err := validateCommandPattern(&cmds[i], fmt.Sprintf("%s[%d]", field, i)); err != nil {
 			return err
 		}
 	}
-	for i, pattern := range cfg.Hostexec.ManualApprove {
-		if err := validateRegex(pattern.Pattern, fmt.Sprintf("hostexec.manual_approve[%d].pattern", i)); err != nil {
+	return nil
+}
+
+// validateCommandPattern validates a pattern's regex and its execution limits.
+func validateCommandPattern(p *CommandPattern, field string) error {
+	if err := validateRegex(p.Pattern, field+".pattern"); err != nil {
+		return err
+	}
+	if p.Timeout != "" {
+		if err := validateDuration(p.Timeout, field+".timeout"); err != nil {
+			return err
+		}
+	}
+	if p.MaxOutputBytes < 0 {
+		return fmt.Errorf("%s.max_output_bytes: must be non-negative, got %d", field, p.MaxOutputBytes)
+	}
+	if p.RequireApprovals < 0 {
+		return fmt.Errorf("%s.require_approvals: must be non-negative, got %d", field, p.RequireApprovals)
+	}
+	if err := validateApprovalTimeout(p.ApprovalTimeout, field+".approval_timeout", true); err != nil {
+		return err
+	}
+	if len(p.EnvAllow) > 0 && len(p.EnvDeny) > 0 {
+		return fmt.Errorf("%s: env_allow and env_deny are mutually exclusive", field)
+	}
+	if err := validateEnvGlobs(p.EnvAllow, field+".env_allow"); err != nil {
+		return err
+	}
+	if err := validateEnvGlobs(p.EnvDeny, field+".env_deny"); err != nil {
+		return err
+	}
+	for name := range p.Env {
+		if name == "" || strings.ContainsAny(name, "=\x00") {
+			return fmt.Errorf("%s.env: invalid variable name %q", field, name)
+		}
+	}
+	return nil
+}
+
+// actionNameRe matches valid action, parameter, and redaction detector names.
+var actionNameRe = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]*$`)
+
+// actionParamRefRe matches {{name}} parameter references in action fields.
+var actionParamRefRe = regexp.MustCompile(`\{\{([^{}]*)\}\}`)
+
+// validActionParamTypes defines the allowed action parameter types.
+var validActionParamTypes = map[string]bool{
+	"":       true,
+	"string": true,
+	"int":    true,
+	"bool":   true,
+}
+
+// validateHostexecActions validates the hostexec.actions list.
+func validateHostexecActions(actions []HostexecAction) error {
+	seen := make(map[string]bool, len(actions))
+	for i := range actions {
+		a := &actions[i]
+		field := fmt.Sprintf("hostexec.actions[%d]", i)
+		if seen[a.Name] {
+			return fmt.Errorf("%s.name: duplicate action %q", field, a.Name)
+		}
+		seen[a.Name] = true
+		if err := validateHostexecAction(a, field); err != nil {
 			return err
 		}
 	}
 	return nil
 }
 
+// validateHostexecAction validates a single action definition.
+func validateHostexecAction(a *HostexecAction, field string) error {
+	if !actionNameRe.MatchString(a.Name) {
+		return fmt.Errorf("%s.name: invalid action name %q", field, a.Name)
+	}
+	if len(a.Argv) == 0 || a.Argv[0] == "" {
+		return fmt.Errorf("%s.argv: must contain at least a command", field)
+	}
+	if a.Approve != "" && a.Approve != "auto" && a.Approve != "manual" {
+		return fmt.Errorf("%s.approve: invalid value %q, must be one of: auto, manual", field, a.Approve)
+	}
+	if a.Timeout != "" {
+		if err := validateDuration(a.Timeout, field+".timeout"); err != nil {
+			return err
+		}
+	}
+	if a.MaxOutputBytes < 0 {
+		return fmt.Errorf("%s.max_output_bytes: must be non-negative, got %d", field, a.MaxOutputBytes)
+	}
+	if a.RequireApprovals < 0 {
+		return fmt.Errorf("%s.require_approvals: must be non-negative, got %d", field, a.RequireApprovals)
+	}
+	if a.RequireApprovals > 1 && a.Approve == "auto" {
+		return fmt.Errorf("%s.require_approvals: not valid with approve: auto", field)
+	}
+	if a.ApprovalTimeout != "" && a.Approve == "auto" {
+		return fmt.Errorf("%s.approval_timeout: not valid with approve: auto", field)
+	}
+	if err := validateApprovalTimeout(a.ApprovalTimeout, field+".approval_timeout", true); err != nil {
+		return err
+	}
+	for name := range a.Env {
+		if name == "" || strings.ContainsAny(name, "=\x00") {
+			return fmt.Errorf("%s.env: invalid variable name %q", field, name)
+		}
+	}
+	declared, err := validateActionParams(a.Params, field+".params")
+	if err != nil {
+		return err
+	}
+	return validateActionParamRefs(a, declared, field)
+}
+
+// validateActionParams validates parameter declarations and returns the set
+// of declared parameter names.
+func validateActionParams(params []ActionParam, field string) (map[string]bool, error) {
+	declared := make(map[string]bool, len(params))
+	for i, p := range params {
+		pfield := fmt.Sprintf("%s[%d]", field, i)
+		if !actionNameRe.MatchString(p.Name) {
+			return nil, fmt.Errorf("%s.name: invalid parameter name %q", pfield, p.Name)
+		}
+		if declared[p.Name] {
+			return nil, fmt.Errorf("%s.name: duplicate parameter %q", pfield, p.Name)
+		}
+		declared[p.Name] = true
+		if !validActionParamTypes[p.Type] {
+			return nil, fmt.Errorf("%s.type: invalid value %q, must be one of: string, int, bool", pfield, p.Type)
+		}
+		if err := validateRegex(p.Pattern, pfield+".pattern"); err != nil {
+			return nil, err
+		}
+	}
+	return declared, nil
+}
+
+// validateActionParamRefs checks that every {{name}} reference in an
+// action's argv, workdir, and env values names a declared parameter.
+func validateActionParamRefs(a *HostexecAction, declared map[string]bool, field string) error {
+	check := func(value, where string) error {
+		for _, m := range actionParamRefRe.FindAllStringSubmatch(value, -1) {
+			if !declared[m[1]] {
+				return fmt.Errorf("%s: references undeclared parameter %q", where, m[1])
+			}
+		}
+		return nil
+	}
+	for i, arg := range a.Argv {
+		if err := check(arg, fmt.Sprintf("%s.argv[%d]", field, i)); err != nil {
+			return err
+		}
+	}
+	if err := check(a.Workdir, field+".workdir"); err != nil {
+		return err
+	}
+	for name, value := range a.Env {
+		if err := check(value, fmt.Sprintf("%s.env.%s", field, name)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateEnvGlobs validates that each entry is a well-formed path.Match glob.
+func validateEnvGlobs(globs []string, field string) error {
+	for i, g := range globs {
+		if _, err := path.Match(g, ""); err != nil {
+			return fmt.Errorf("%s[%d]: invalid glob %q: %w", field, i, g, err)
+		}
+	}
+	return nil
+}
+
 // validateListenAddr validates a listen address in the format ":port" or "host:port".
 // Port must be in the range 1-65535.
 func validateListenAddr(addr, field string) error {
@@ -164,6 +581,45 @@ func validateDuration(d, field string) error {
 	return nil
 }
 
+// validateApprovalTimeout validates an optional approval_timeout. allowNone
+// permits ApprovalTimeoutNone, which only hostexec requests support.
+func validateApprovalTimeout(s, field string, allowNone bool) error {
+	if s == "" {
+		return nil
+	}
+	if s == ApprovalTimeoutNone {
+		if !allowNone {
+			return fmt.Errorf("%s: %q is only valid for hostexec requests", field, s)
+		}
+		return nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return fmt.Errorf("%s: invalid duration %q", field, s)
+	}
+	if d <= 0 {
+		return fmt.Errorf("%s: must be positive, got %q", field, s)
+	}
+	return nil
+}
+
+// ParseApprovalTimeout parses a validated approval_timeout. It returns 0 for
+// an empty value, meaning the default applies, and a negative duration for
+// ApprovalTimeoutNone, meaning wait until the request is decided.
+func ParseApprovalTimeout(s string) (time.Duration, error) {
+	switch s {
+	case "":
+		return 0, nil
+	case ApprovalTimeoutNone:
+		return -1, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid approval timeout %q: %w", s, err)
+	}
+	return d, nil
+}
+
 // validateRegex validates that a pattern compiles as a valid regular expression.
 // Empty patterns are considered valid (no-op).
 func validateRegex(pattern, field string) error {
@@ -3,6 +3,7 @@ package config
 import (
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestValidateGlobalConfig_Valid(t *testing.T) {
@@ -159,6 +160,19 @@ func TestValidateGlobalConfig_InvalidDuration(t *testing.T) {
 	}
 }
 
+func TestValidateRequestConfig_MessagesFile(t *testing.T) {
+	cfg := &GlobalConfig{Request: RequestConfig{MessagesFile: "/home/cloister/.cloister/messages.md"}}
+	if err := ValidateGlobalConfig(cfg); err != nil {
+		t.Errorf("ValidateGlobalConfig() error = %v, want nil", err)
+	}
+
+	cfg.Request.MessagesFile = "messages.md"
+	err := ValidateGlobalConfig(cfg)
+	if err == nil || !strings.Contains(err.Error(), "request.messages_file: must be an absolute container path") {
+		t.Errorf("ValidateGlobalConfig() error = %v, want relative path error", err)
+	}
+}
+
 func TestValidateGlobalConfig_InvalidRegex(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -273,6 +287,41 @@ func TestValidateGlobalConfig_InvalidLogLevel(t *testing.T) {
 	}
 }
 
+func TestValidateGlobalConfig_LogFormat(t *testing.T) {
+	for _, format := range []string{"", "text", "jsonl"} {
+		if err := ValidateGlobalConfig(&GlobalConfig{Log: LogConfig{Format: format}}); err != nil {
+			t.Errorf("ValidateGlobalConfig() error = %v for log format %q", err, format)
+		}
+	}
+	err := ValidateGlobalConfig(&GlobalConfig{Log: LogConfig{Format: "json"}})
+	if err == nil || !strings.Contains(err.Error(), "log.format: invalid value") {
+		t.Errorf("ValidateGlobalConfig() error = %v, want invalid log.format", err)
+	}
+}
+
+func TestValidateGlobalConfig_LogRotate(t *testing.T) {
+	valid := LogRotateConfig{MaxSizeMB: 100, MaxAge: "24h", Compress: true, Keep: 10, KeepFor: "720h"}
+	if err := ValidateGlobalConfig(&GlobalConfig{Log: LogConfig{Rotate: valid}}); err != nil {
+		t.Errorf("ValidateGlobalConfig() error = %v for %+v", err, valid)
+	}
+
+	tests := []struct {
+		rotate  LogRotateConfig
+		wantErr string
+	}{
+		{LogRotateConfig{MaxSizeMB: -1}, "log.rotate.max_size_mb"},
+		{LogRotateConfig{Keep: -1}, "log.rotate.keep"},
+		{LogRotateConfig{MaxAge: "1d"}, "log.rotate.max_age: invalid duration"},
+		{LogRotateConfig{KeepFor: "forever"}, "log.rotate.keep_for: invalid duration"},
+	}
+	for _, tt := range tests {
+		err := ValidateGlobalConfig(&GlobalConfig{Log: LogConfig{Rotate: tt.rotate}})
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("ValidateGlobalConfig(%+v) error = %v, want %q", tt.rotate, err, tt.wantErr)
+		}
+	}
+}
+
 func TestValidateGlobalConfig_ValidLogLevels(t *testing.T) {
 	levels := []string{"debug", "info", "warn", "error"}
 
@@ -500,6 +549,177 @@ func TestValidateProjectConfig_ManualApproveInvalidRegex(t *testing.T) {
 	}
 }
 
+func TestValidateCommandPattern_ValidLimits(t *testing.T) {
+	cfg := &ProjectConfig{
+		Hostexec: ProjectHostexecConfig{
+			AutoApprove: []CommandPattern{
+				{
+					Pattern:        "^make test$",
+					Timeout:        "10m",
+					MaxOutputBytes: 1 << 20,
+					EnvAllow:       []string{"PATH", "HOME", "GO*"},
+					Env:            map[string]string{"CI": "1"},
+				},
+			},
+			ManualApprove: []CommandPattern{
+				{Pattern: "^make deploy$", EnvDeny: []string{"AWS_*", "*_TOKEN"}},
+			},
+		},
+	}
+
+	if err := ValidateProjectConfig(cfg); err != nil {
+		t.Errorf("ValidateProjectConfig() error = %v, want nil", err)
+	}
+}
+
+func TestValidateCommandPattern_InvalidLimits(t *testing.T) {
+	tests := []struct {
+		name    string
+		pattern CommandPattern
+		wantErr string
+	}{
+		{
+			name:    "invalid timeout",
+			pattern: CommandPattern{Pattern: "^ls$", Timeout: "forever"},
+			wantErr: "hostexec.auto_approve[0].timeout: invalid duration",
+		},
+		{
+			name:    "negative max output",
+			pattern: CommandPattern{Pattern: "^ls$", MaxOutputBytes: -1},
+			wantErr: "hostexec.auto_approve[0].max_output_bytes: must be non-negative",
+		},
+		{
+			name:    "allow and deny together",
+			pattern: CommandPattern{Pattern: "^ls$", EnvAllow: []string{"PATH"}, EnvDeny: []string{"HOME"}},
+			wantErr: "hostexec.auto_approve[0]: env_allow and env_deny are mutually exclusive",
+		},
+		{
+			name:    "bad allow glob",
+			pattern: CommandPattern{Pattern: "^ls$", EnvAllow: []string{"[PATH"}},
+			wantErr: "hostexec.auto_approve[0].env_allow[0]: invalid glob",
+		},
+		{
+			name:    "bad deny glob",
+			pattern: CommandPattern{Pattern: "^ls$", EnvDeny: []string{"HOME", "AWS_[*"}},
+			wantErr: "hostexec.auto_approve[0].env_deny[1]: invalid glob",
+		},
+		{
+			name:    "env name with equals",
+			pattern: CommandPattern{Pattern: "^ls$", Env: map[string]string{"A=B": "c"}},
+			wantErr: "hostexec.auto_approve[0].env: invalid variable name",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			global := &GlobalConfig{Hostexec: HostexecConfig{AutoApprove: []CommandPattern{tt.pattern}}}
+			err := ValidateGlobalConfig(global)
+			if err == nil {
+				t.Fatal("ValidateGlobalConfig() expected error, got nil")
+			}
+			if !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("error = %q, want to contain %q", err.Error(), tt.wantErr)
+			}
+
+			project := &ProjectConfig{Hostexec: ProjectHostexecConfig{AutoApprove: []CommandPattern{tt.pattern}}}
+			err = ValidateProjectConfig(project)
+			if err == nil {
+				t.Fatal("ValidateProjectConfig() expected error, got nil")
+			}
+			if !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("error = %q, want to contain %q", err.Error(), tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateHostexecActions_Valid(t *testing.T) {
+	cfg := &ProjectConfig{
+		Hostexec: ProjectHostexecConfig{
+			Actions: []HostexecAction{
+				{
+					Name:    "reset-db",
+					Argv:    []string{"./scripts/reset-db.sh", "{{name}}"},
+					Workdir: "{{dir}}",
+					Env:     map[string]string{"DB_NAME": "dev_{{name}}"},
+					Params: []ActionParam{
+						{Name: "name", Pattern: "[a-z_]+", Required: true},
+						{Name: "dir", Default: "."},
+						{Name: "count", Type: "int"},
+					},
+					Approve: "auto",
+					Timeout: "5m",
+				},
+			},
+		},
+	}
+
+	if err := ValidateProjectConfig(cfg); err != nil {
+		t.Errorf("ValidateProjectConfig() error = %v, want nil", err)
+	}
+}
+
+func TestValidateHostexecActions_Invalid(t *testing.T) {
+	valid := func() HostexecAction {
+		return HostexecAction{Name: "ok", Argv: []string{"true"}}
+	}
+	tests := []struct {
+		name    string
+		mutate  func(a *HostexecAction)
+		extra   []HostexecAction
+		wantErr string
+	}{
+		{"empty name", func(a *HostexecAction) { a.Name = "" }, nil, "hostexec.actions[0].name: invalid action name"},
+		{"bad name", func(a *HostexecAction) { a.Name = "has space" }, nil, "invalid action name"},
+		{"empty argv", func(a *HostexecAction) { a.Argv = nil }, nil, "hostexec.actions[0].argv: must contain"},
+		{"bad approve", func(a *HostexecAction) { a.Approve = "yes" }, nil, "hostexec.actions[0].approve: invalid value"},
+		{"bad timeout", func(a *HostexecAction) { a.Timeout = "soon" }, nil, "hostexec.actions[0].timeout: invalid duration"},
+		{"negative output", func(a *HostexecAction) { a.MaxOutputBytes = -5 }, nil, "max_output_bytes: must be non-negative"},
+		{"bad env name", func(a *HostexecAction) { a.Env = map[string]string{"A=B": "x"} }, nil, "env: invalid variable name"},
+		{
+			"bad param type",
+			func(a *HostexecAction) { a.Params = []ActionParam{{Name: "n", Type: "float"}} },
+			nil, "hostexec.actions[0].params[0].type: invalid value",
+		},
+		{
+			"bad param regex",
+			func(a *HostexecAction) { a.Params = []ActionParam{{Name: "n", Pattern: "("}} },
+			nil, "hostexec.actions[0].params[0].pattern: invalid regex",
+		},
+		{
+			"duplicate param",
+			func(a *HostexecAction) { a.Params = []ActionParam{{Name: "n"}, {Name: "n"}} },
+			nil, "params[1].name: duplicate parameter",
+		},
+		{
+			"undeclared ref in argv",
+			func(a *HostexecAction) { a.Argv = []string{"echo", "{{missing}}"} },
+			nil, "hostexec.actions[0].argv[1]: references undeclared parameter \"missing\"",
+		},
+		{
+			"undeclared ref in workdir",
+			func(a *HostexecAction) { a.Workdir = "{{dir}}" },
+			nil, "hostexec.actions[0].workdir: references undeclared parameter",
+		},
+		{"duplicate action", func(_ *HostexecAction) {}, []HostexecAction{{Name: "ok", Argv: []string{"x"}}}, "hostexec.actions[1].name: duplicate action"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := valid()
+			tt.mutate(&a)
+			cfg := &GlobalConfig{Hostexec: HostexecConfig{Actions: append([]HostexecAction{a}, tt.extra...)}}
+			err := ValidateGlobalConfig(cfg)
+			if err == nil {
+				t.Fatal("ValidateGlobalConfig() expected error, got nil")
+			}
+			if !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("error = %q, want to contain %q", err.Error(), tt.wantErr)
+			}
+		})
+	}
+}
+
 func TestValidateAgentConfig_ValidAuthMethods(t *testing.T) {
 	tests := []struct {
 		name string
@@ -702,6 +922,311 @@ func TestValidateGlobalConfig_InvalidAgentConfig(t *testing.T) {
 	}
 }
 
+func TestValidateGlobalConfig_ConcurrencyLimits(t *testing.T) {
+	valid := &GlobalConfig{Hostexec: HostexecConfig{MaxConcurrent: 8, MaxConcurrentPerCloister: 2}}
+	if err := ValidateGlobalConfig(valid); err != nil {
+		t.Errorf("ValidateGlobalConfig() error = %v, want nil", err)
+	}
+
+	tests := []struct {
+		name    string
+		cfg     HostexecConfig
+		wantErr string
+	}{
+		{"negative global", HostexecConfig{MaxConcurrent: -1}, "hostexec.max_concurrent: must be non-negative"},
+		{"negative per cloister", HostexecConfig{MaxConcurrentPerCloister: -2}, "hostexec.max_concurrent_per_cloister: must be non-negative"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateGlobalConfig(&GlobalConfig{Hostexec: tt.cfg})
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("error = %v, want to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateRedactConfig(t *testing.T) {
+	tests := []struct {
+		name     string
+		patterns []RedactPattern
+		wantErr  string
+	}{
+		{"valid", []RedactPattern{{Name: "internal", Pattern: `itk_[a-z0-9]{32}`}}, ""},
+		{"valid named group", []RedactPattern{{Name: "pw", Pattern: `password=(?P<secret>\S+)`}}, ""},
+		{"bad name", []RedactPattern{{Name: "has space", Pattern: "x"}}, "hostexec.redact.patterns[0].name: invalid detector name"},
+		{"empty pattern", []RedactPattern{{Name: "x"}}, "hostexec.redact.patterns[0].pattern: must not be empty"},
+		{"bad regex", []RedactPattern{{Name: "x", Pattern: "("}}, "hostexec.redact.patterns[0].pattern: invalid regex"},
+		{
+			"duplicate",
+			[]RedactPattern{{Name: "x", Pattern: "a"}, {Name: "x", Pattern: "b"}},
+			"hostexec.redact.patterns[1].name: duplicate detector",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &GlobalConfig{Hostexec: HostexecConfig{Redact: RedactConfig{Patterns: tt.patterns}}}
+			err := ValidateGlobalConfig(cfg)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("ValidateGlobalConfig() error = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatal("ValidateGlobalConfig() expected error, got nil")
+			}
+			if !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("error = %q, want to contain %q", err.Error(), tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateRiskConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		rules   []RiskRule
+		wantErr string
+	}{
+		{"valid", []RiskRule{{Name: "prod", Token: `^--env=prod$`}}, ""},
+		{"valid with command", []RiskRule{{Name: "hard_reset", Command: `^git reset\b`, Token: `^--hard$`}}, ""},
+		{"bad name", []RiskRule{{Name: "has space", Token: "x"}}, "hostexec.risk.rules[0].name: invalid rule name"},
+		{"empty token", []RiskRule{{Name: "x"}}, "hostexec.risk.rules[0].token: must not be empty"},
+		{"bad token regex", []RiskRule{{Name: "x", Token: "("}}, "hostexec.risk.rules[0].token: invalid regex"},
+		{"bad command regex", []RiskRule{{Name: "x", Command: "(", Token: "a"}}, "hostexec.risk.rules[0].command: invalid regex"},
+		{
+			"duplicate",
+			[]RiskRule{{Name: "x", Token: "a"}, {Name: "x", Token: "b"}},
+			"hostexec.risk.rules[1].name: duplicate rule",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &GlobalConfig{Hostexec: HostexecConfig{Risk: RiskConfig{Rules: tt.rules}}}
+			err := ValidateGlobalConfig(cfg)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("ValidateGlobalConfig() error = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatal("ValidateGlobalConfig() expected error, got nil")
+			}
+			if !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("error = %q, want to contain %q", err.Error(), tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateGlobalConfig_Quorum(t *testing.T) {
+	two := []Approver{{Name: "alice"}, {Name: "bob"}}
+	tests := []struct {
+		name    string
+		cfg     GlobalConfig
+		wantErr string
+	}{
+		{"valid", GlobalConfig{
+			Approvers: two,
+			Proxy:     ProxyConfig{Quorum: []QuorumRule{{Pattern: "*.prod.example.com", RequireApprovals: 2}}},
+			Hostexec: HostexecConfig{
+				ManualApprove: []CommandPattern{{Pattern: "^git push.*$", RequireApprovals: 3}},
+				Actions:       []HostexecAction{{Name: "deploy", Argv: []string{"make", "deploy"}, RequireApprovals: 2}},
+			},
+		}, ""},
+		{"bad approver name", GlobalConfig{Approvers: []Approver{{Name: "a b"}}}, "approvers[0].name: invalid approver name"},
+		{"duplicate approver", GlobalConfig{Approvers: []Approver{{Name: "a"}, {Name: "a"}}}, "approvers[1].name: duplicate approver"},
+		{"unreachable pattern quorum", GlobalConfig{
+			Hostexec: HostexecConfig{ManualApprove: []CommandPattern{{Pattern: "^x$", RequireApprovals: 2}}},
+		}, "hostexec.manual_approve[0].require_approvals: 2 approvals required but only 1"},
+		{"unreachable domain quorum", GlobalConfig{
+			Approvers: two,
+			Proxy:     ProxyConfig{Quorum: []QuorumRule{{Domain: "example.com", RequireApprovals: 4}}},
+		}, "proxy.quorum[0].require_approvals: 4 approvals required but only 3"},
+		{"auto approve quorum", GlobalConfig{
+			Approvers: two,
+			Hostexec:  HostexecConfig{AutoApprove: []CommandPattern{{Pattern: "^x$", RequireApprovals: 2}}},
+		}, "hostexec.auto_approve[0].require_approvals: only valid on manual_approve"},
+		{"auto action quorum", GlobalConfig{
+			Approvers: two,
+			Hostexec:  HostexecConfig{Actions: []HostexecAction{{Name: "x", Argv: []string{"x"}, Approve: "auto", RequireApprovals: 2}}},
+		}, "hostexec.actions[0].require_approvals: not valid with approve: auto"},
+		{"negative", GlobalConfig{
+			Hostexec: HostexecConfig{ManualApprove: []CommandPattern{{Pattern: "^x$", RequireApprovals: -1}}},
+		}, "hostexec.manual_approve[0].require_approvals: must be non-negative"},
+		{"domain and pattern", GlobalConfig{
+			Proxy: ProxyConfig{Quorum: []QuorumRule{{Domain: "a.com", Pattern: "*.a.com", RequireApprovals: 1}}},
+		}, "proxy.quorum[0]: exactly one of domain or pattern"},
+		{"bad pattern", GlobalConfig{
+			Proxy: ProxyConfig{Quorum: []QuorumRule{{Pattern: "a.*.com", RequireApprovals: 1}}},
+		}, "proxy.quorum[0].pattern: must have the form"},
+		{"zero domain quorum", GlobalConfig{
+			Proxy: ProxyConfig{Quorum: []QuorumRule{{Domain: "a.com"}}},
+		}, "proxy.quorum[0].require_approvals: must be at least 1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateGlobalConfig(&tt.cfg)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("ValidateGlobalConfig() error = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatal("ValidateGlobalConfig() expected error, got nil")
+			}
+			if !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("error = %q, want to contain %q", err.Error(), tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateApprovalTimeouts(t *testing.T) {
+	tests := []struct {
+		name    string
+		global  GlobalConfig
+		project ProjectConfig
+		wantErr string
+	}{
+		{name: "valid", global: GlobalConfig{Request: RequestConfig{Timeout: "none"}, Hostexec: HostexecConfig{
+			ManualApprove: []CommandPattern{{Pattern: "^terraform plan$", ApprovalTimeout: "1h"}, {Pattern: "^make deploy$", ApprovalTimeout: "none"}},
+			Actions:       []HostexecAction{{Name: "plan", Argv: []string{"terraform", "plan"}, ApprovalTimeout: "none"}},
+		}}},
+		{name: "valid project", project: ProjectConfig{
+			Proxy:    ProjectProxyConfig{ApprovalTimeout: "20s"},
+			Hostexec: ProjectHostexecConfig{ApprovalTimeout: "none"},
+		}},
+		{name: "bad duration", global: GlobalConfig{Request: RequestConfig{Timeout: "soon"}},
+			wantErr: "request.timeout: invalid duration"},
+		{name: "zero", global: GlobalConfig{Hostexec: HostexecConfig{ManualApprove: []CommandPattern{{Pattern: "^x$", ApprovalTimeout: "0s"}}}},
+			wantErr: "hostexec.manual_approve[0].approval_timeout: must be positive"},
+		{name: "auto approve pattern", global: GlobalConfig{Hostexec: HostexecConfig{AutoApprove: []CommandPattern{{Pattern: "^x$", ApprovalTimeout: "1m"}}}},
+			wantErr: "hostexec.auto_approve[0].approval_timeout: only valid on manual_approve"},
+		{name: "auto action", global: GlobalConfig{Hostexec: HostexecConfig{Actions: []HostexecAction{{Name: "x", Argv: []string{"x"}, Approve: "auto", ApprovalTimeout: "1m"}}}},
+			wantErr: "hostexec.actions[0].approval_timeout: not valid with approve: auto"},
+		{name: "project proxy none", project: ProjectConfig{Proxy: ProjectProxyConfig{ApprovalTimeout: "none"}},
+			wantErr: "proxy.approval_timeout: \"none\" is only valid for hostexec requests"},
+		{name: "project hostexec bad", project: ProjectConfig{Hostexec: ProjectHostexecConfig{ApprovalTimeout: "-5m"}},
+			wantErr: "hostexec.approval_timeout: must be positive"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateGlobalConfig(&tt.global)
+			if err == nil {
+				err = ValidateProjectConfig(&tt.project)
+			}
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("validate error = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatal("validate expected error, got nil")
+			}
+			if !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("error = %q, want to contain %q", err.Error(), tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestParseApprovalTimeout(t *testing.T) {
+	tests := []struct {
+		in   string
+		want time.Duration
+	}{
+		{"", 0},
+		{"none", -1},
+		{"90s", 90 * time.Second},
+	}
+	for _, tt := range tests {
+		got, err := ParseApprovalTimeout(tt.in)
+		if err != nil || got != tt.want {
+			t.Errorf("ParseApprovalTimeout(%q) = %v, %v; want %v", tt.in, got, err, tt.want)
+		}
+	}
+	if _, err := ParseApprovalTimeout("later"); err == nil {
+		t.Error("ParseApprovalTimeout(\"later\") expected error")
+	}
+}
+
+func TestValidateNotifyConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		notify  NotifyConfig
+		wantErr string
+	}{
+		{"empty", NotifyConfig{}, ""},
+		{
+			"valid",
+			NotifyConfig{
+				Webhooks: []WebhookNotifier{{
+					URL: "https://hooks.example.com/x", Secret: "s", Timeout: "5s",
+					NotifyFilter: NotifyFilter{Projects: []string{"p"}, Types: []string{"domain"}, Events: []string{"timeout"}},
+				}},
+				Commands: []CommandNotifier{{Command: []string{"notify-send", "{{summary}}"}}},
+			},
+			"",
+		},
+		{"relative url", NotifyConfig{Webhooks: []WebhookNotifier{{URL: "/hook"}}}, "notify.webhooks[0].url: must be an absolute http or https URL"},
+		{"bad scheme", NotifyConfig{Webhooks: []WebhookNotifier{{URL: "ftp://x"}}}, "notify.webhooks[0].url"},
+		{"bad timeout", NotifyConfig{Webhooks: []WebhookNotifier{{URL: "http://x", Timeout: "soon"}}}, "notify.webhooks[0].timeout"},
+		{"empty command", NotifyConfig{Commands: []CommandNotifier{{}}}, "notify.commands[0].command: must not be empty"},
+		{
+			"bad type",
+			NotifyConfig{Commands: []CommandNotifier{{Command: []string{"x"}, NotifyFilter: NotifyFilter{Types: []string{"proxy"}}}}},
+			"notify.commands[0].types: invalid value \"proxy\"",
+		},
+		{
+			"bad event",
+			NotifyConfig{Commands: []CommandNotifier{{Command: []string{"x"}, NotifyFilter: NotifyFilter{Events: []string{"approved"}}}}},
+			"notify.commands[0].events: invalid value \"approved\"",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateGlobalConfig(&GlobalConfig{Notify: tt.notify})
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("ValidateGlobalConfig() error = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("error = %v, want to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateTracingConfig(t *testing.T) {
+	for _, tt := range []struct {
+		endpoint string
+		wantErr  bool
+	}{
+		{"", false},
+		{"http://localhost:4318", false},
+		{"https://otel.example.com", false},
+		{"localhost:4318", true},
+		{"grpc://localhost:4317", true},
+	} {
+		err := ValidateGlobalConfig(&GlobalConfig{Tracing: TracingConfig{Endpoint: tt.endpoint}})
+		if (err != nil) != tt.wantErr {
+			t.Errorf("endpoint %q: error = %v, wantErr %v", tt.endpoint, err, tt.wantErr)
+		}
+	}
+}
+
 func TestValidateGlobalConfig_ValidAgentConfig(t *testing.T) {
 	cfg := &GlobalConfig{
 		Agents: map[string]AgentConfig{
@@ -5,11 +5,13 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"strings"
 
 	"github.com/xdg/cloister/internal/docker"
+	"github.com/xdg/cloister/internal/pty"
 )
 
 // ErrContainerExists indicates that a container with the requested name already exists.
@@ -244,19 +246,10 @@ func (m *Manager) List() ([]Info, error) {
 //
 // Returns ErrContainerNotFound if the container does not exist.
 func (m *Manager) Attach(containerName string) (int, error) {
-	// Check if container exists
-	exists, err := m.ContainerExists(containerName)
+	cmd, err := m.attachCommand(containerName)
 	if err != nil {
 		return 0, err
 	}
-	if !exists {
-		return 0, ErrContainerNotFound
-	}
-
-	// Build docker exec command with interactive TTY
-	// -i: Keep STDIN open even if not attached
-	// -t: Allocate a pseudo-TTY
-	cmd := exec.CommandContext(context.Background(), "docker", "exec", "-it", containerName, "/bin/bash")
 
 	// Connect to current process's stdin/stdout/stderr
 	cmd.Stdin = os.Stdin
@@ -264,18 +257,58 @@ func (m *Manager) Attach(containerName string) (int, error) {
 	cmd.Stderr = os.Stderr
 
 	// Run the command and wait for it to complete
-	err = cmd.Run()
+	return shellExitCode(cmd.Run())
+}
+
+// AttachFiltered is like Attach, but relays the shell through a
+// pseudo-terminal so the host can filter terminal input (see pty.Run). The
+// shell's output is written to out, which should be the host terminal.
+// If the relay is unavailable, such as when stdin is not a terminal, it
+// falls back to Attach and neither filter nor out is used.
+func (m *Manager) AttachFiltered(containerName string, filter pty.InputFilter, out io.Writer) (int, error) {
+	cmd, err := m.attachCommand(containerName)
 	if err != nil {
-		// Extract exit code from ExitError
-		var exitErr *exec.ExitError
-		if errors.As(err, &exitErr) {
-			return exitErr.ExitCode(), nil
-		}
-		// Other errors (e.g., docker not found, command failed to start)
-		return 0, fmt.Errorf("run container command: %w", err)
+		return 0, err
 	}
 
-	return 0, nil
+	err = pty.Run(cmd, os.Stdin, out, filter)
+	if errors.Is(err, pty.ErrUnsupported) {
+		return m.Attach(containerName)
+	}
+	return shellExitCode(err)
+}
+
+// attachCommand builds the docker exec command for an interactive shell.
+// Returns ErrContainerNotFound if the container does not exist.
+func (m *Manager) attachCommand(containerName string) (*exec.Cmd, error) {
+	// Check if container exists
+	exists, err := m.ContainerExists(containerName)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, ErrContainerNotFound
+	}
+
+	// Build docker exec command with interactive TTY
+	// -i: Keep STDIN open even if not attached
+	// -t: Allocate a pseudo-TTY
+	return exec.CommandContext(context.Background(), "docker", "exec", "-it", containerName, "/bin/bash"), nil
+}
+
+// shellExitCode converts the result of running an attached shell into its
+// exit code. Only failures to run the shell at all are returned as errors.
+func shellExitCode(err error) (int, error) {
+	if err == nil {
+		return 0, nil
+	}
+	// Extract exit code from ExitError
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitCode(), nil
+	}
+	// Other errors (e.g., docker not found, command failed to start)
+	return 0, fmt.Errorf("run container command: %w", err)
 }
 
 // HasRunningCloister checks if any running cloister container belongs to the given project.
@@ -0,0 +1,120 @@
+package executor
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// AuthSchemeHMACSHA256 authenticates each request with an HMAC-SHA256 over
+// the scheme, timestamp, nonce, and request payload, keyed by the shared
+// secret. The secret itself is never sent.
+const AuthSchemeHMACSHA256 = "hmac-sha256"
+
+// MaxClockSkew bounds how far a request timestamp may differ from the
+// executor's clock. Requests outside the window are rejected, which also
+// bounds how long nonces must be remembered for replay detection.
+const MaxClockSkew = time.Minute
+
+// SupportedAuthSchemes lists the schemes the executor accepts, in order of
+// preference. Servers report these when rejecting an unsupported scheme.
+var SupportedAuthSchemes = []string{AuthSchemeHMACSHA256}
+
+// Authentication errors returned by VerifyAuth.
+var (
+	ErrUnsupportedAuthScheme = errors.New("unsupported auth scheme")
+	ErrStaleRequest          = errors.New("request timestamp outside allowed clock skew")
+	ErrReplayedRequest       = errors.New("request nonce already used")
+	ErrBadSignature          = errors.New("invalid request signature")
+)
+
+// SocketAuth carries per-request authentication for a SocketRequest.
+type SocketAuth struct {
+	Scheme    string `json:"scheme"`
+	Timestamp int64  `json:"timestamp"` // Unix milliseconds
+	Nonce     string `json:"nonce"`     // Random, hex-encoded
+	MAC       string `json:"mac"`       // Hex-encoded HMAC
+}
+
+// SignPayload returns authentication for payload using the shared secret.
+func SignPayload(secret string, payload []byte, now time.Time) (SocketAuth, error) {
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		return SocketAuth{}, fmt.Errorf("generate nonce: %w", err)
+	}
+	auth := SocketAuth{
+		Scheme:    AuthSchemeHMACSHA256,
+		Timestamp: now.UnixMilli(),
+		Nonce:     hex.EncodeToString(nonce),
+	}
+	auth.MAC = hex.EncodeToString(computeMAC(secret, &auth, payload))
+	return auth, nil
+}
+
+// computeMAC computes the HMAC over the auth fields and payload. Fields are
+// newline-separated; none of them can contain a newline except the payload,
+// which comes last.
+func computeMAC(secret string, auth *SocketAuth, payload []byte) []byte {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(auth.Scheme + "\n" + strconv.FormatInt(auth.Timestamp, 10) + "\n" + auth.Nonce + "\n"))
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
+
+// ReplayCache remembers recently seen nonces so a captured request cannot
+// be replayed within the clock-skew window. It is safe for concurrent use.
+type ReplayCache struct {
+	mu     sync.Mutex
+	seen   map[string]time.Time // nonce -> expiry
+	window time.Duration
+}
+
+// NewReplayCache creates a cache that remembers nonces for window.
+func NewReplayCache(window time.Duration) *ReplayCache {
+	return &ReplayCache{seen: make(map[string]time.Time), window: window}
+}
+
+// CheckAndAdd records the nonce and reports whether it was unused.
+// Expired entries are pruned on each call.
+func (c *ReplayCache) CheckAndAdd(nonce string, now time.Time) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for n, expiry := range c.seen {
+		if now.After(expiry) {
+			delete(c.seen, n)
+		}
+	}
+	if _, ok := c.seen[nonce]; ok {
+		return false
+	}
+	c.seen[nonce] = now.Add(c.window)
+	return true
+}
+
+// VerifyAuth checks a request's authentication against the shared secret:
+// the scheme must be supported, the timestamp within MaxClockSkew of now,
+// the MAC valid, and the nonce unused. The nonce is only recorded once the
+// MAC has been verified, so forged requests cannot poison the cache.
+func VerifyAuth(secret string, auth *SocketAuth, payload []byte, now time.Time, cache *ReplayCache) error {
+	if auth.Scheme != AuthSchemeHMACSHA256 {
+		return fmt.Errorf("%w %q (supported: %v)", ErrUnsupportedAuthScheme, auth.Scheme, SupportedAuthSchemes)
+	}
+	skew := now.Sub(time.UnixMilli(auth.Timestamp))
+	if skew > MaxClockSkew || skew < -MaxClockSkew {
+		return ErrStaleRequest
+	}
+	got, err := hex.DecodeString(auth.MAC)
+	if err != nil || !hmac.Equal(got, computeMAC(secret, auth, payload)) {
+		return ErrBadSignature
+	}
+	if auth.Nonce == "" || !cache.CheckAndAdd(auth.Nonce, now) {
+		return ErrReplayedRequest
+	}
+	return nil
+}
@@ -0,0 +1,170 @@
+package executor
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestVerifyAuth(t *testing.T) {
+	now := time.Now()
+	payload := []byte(`{"request":{"command":"echo"}}`)
+
+	tests := []struct {
+		name    string
+		secret  string
+		mutate  func(auth *SocketAuth, payload []byte) []byte
+		wantErr error
+	}{
+		{
+			name:   "valid",
+			secret: "secret",
+		},
+		{
+			name:    "wrong secret",
+			secret:  "other",
+			wantErr: ErrBadSignature,
+		},
+		{
+			name:   "tampered payload",
+			secret: "secret",
+			mutate: func(_ *SocketAuth, _ []byte) []byte {
+				return []byte(`{"request":{"command":"rm"}}`)
+			},
+			wantErr: ErrBadSignature,
+		},
+		{
+			name:   "tampered nonce",
+			secret: "secret",
+			mutate: func(auth *SocketAuth, p []byte) []byte {
+				auth.Nonce = "00" + auth.Nonce[2:]
+				return p
+			},
+			wantErr: ErrBadSignature,
+		},
+		{
+			name:   "malformed mac",
+			secret: "secret",
+			mutate: func(auth *SocketAuth, p []byte) []byte {
+				auth.MAC = "not-hex"
+				return p
+			},
+			wantErr: ErrBadSignature,
+		},
+		{
+			name:   "unsupported scheme",
+			secret: "secret",
+			mutate: func(auth *SocketAuth, p []byte) []byte {
+				auth.Scheme = "plaintext"
+				return p
+			},
+			wantErr: ErrUnsupportedAuthScheme,
+		},
+		{
+			name:   "missing scheme",
+			secret: "secret",
+			mutate: func(auth *SocketAuth, p []byte) []byte {
+				auth.Scheme = ""
+				return p
+			},
+			wantErr: ErrUnsupportedAuthScheme,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			auth, err := SignPayload("secret", payload, now)
+			if err != nil {
+				t.Fatalf("SignPayload() error = %v", err)
+			}
+			p := payload
+			if tc.mutate != nil {
+				p = tc.mutate(&auth, payload)
+			}
+			err = VerifyAuth(tc.secret, &auth, p, now, NewReplayCache(time.Minute))
+			if !errors.Is(err, tc.wantErr) {
+				t.Errorf("VerifyAuth() error = %v, want %v", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestVerifyAuth_ClockSkew(t *testing.T) {
+	now := time.Now()
+	payload := []byte("{}")
+
+	for _, offset := range []time.Duration{-MaxClockSkew - time.Second, MaxClockSkew + time.Second} {
+		auth, err := SignPayload("secret", payload, now.Add(offset))
+		if err != nil {
+			t.Fatalf("SignPayload() error = %v", err)
+		}
+		if err := VerifyAuth("secret", &auth, payload, now, NewReplayCache(time.Minute)); !errors.Is(err, ErrStaleRequest) {
+			t.Errorf("offset %v: VerifyAuth() error = %v, want ErrStaleRequest", offset, err)
+		}
+	}
+
+	auth, err := SignPayload("secret", payload, now.Add(-MaxClockSkew/2))
+	if err != nil {
+		t.Fatalf("SignPayload() error = %v", err)
+	}
+	if err := VerifyAuth("secret", &auth, payload, now, NewReplayCache(time.Minute)); err != nil {
+		t.Errorf("within skew: VerifyAuth() error = %v", err)
+	}
+}
+
+func TestVerifyAuth_Replay(t *testing.T) {
+	now := time.Now()
+	payload := []byte("{}")
+	cache := NewReplayCache(2 * MaxClockSkew)
+
+	auth, err := SignPayload("secret", payload, now)
+	if err != nil {
+		t.Fatalf("SignPayload() error = %v", err)
+	}
+	if err := VerifyAuth("secret", &auth, payload, now, cache); err != nil {
+		t.Fatalf("first VerifyAuth() error = %v", err)
+	}
+	if err := VerifyAuth("secret", &auth, payload, now, cache); !errors.Is(err, ErrReplayedRequest) {
+		t.Errorf("replay VerifyAuth() error = %v, want ErrReplayedRequest", err)
+	}
+}
+
+func TestVerifyAuth_ForgedRequestDoesNotPoisonCache(t *testing.T) {
+	now := time.Now()
+	payload := []byte("{}")
+	cache := NewReplayCache(2 * MaxClockSkew)
+
+	auth, err := SignPayload("secret", payload, now)
+	if err != nil {
+		t.Fatalf("SignPayload() error = %v", err)
+	}
+	forged := auth
+	forged.MAC = auth.MAC[:len(auth.MAC)-2] + "00"
+	if forged.MAC == auth.MAC {
+		forged.MAC = auth.MAC[:len(auth.MAC)-2] + "11"
+	}
+	if err := VerifyAuth("secret", &forged, payload, now, cache); !errors.Is(err, ErrBadSignature) {
+		t.Fatalf("forged VerifyAuth() error = %v, want ErrBadSignature", err)
+	}
+	if err := VerifyAuth("secret", &auth, payload, now, cache); err != nil {
+		t.Errorf("genuine VerifyAuth() after forgery error = %v", err)
+	}
+}
+
+func TestReplayCache_Expiry(t *testing.T) {
+	cache := NewReplayCache(time.Minute)
+	now := time.Now()
+
+	if !cache.CheckAndAdd("n1", now) {
+		t.Fatal("first CheckAndAdd() = false, want true")
+	}
+	if cache.CheckAndAdd("n1", now.Add(30*time.Second)) {
+		t.Error("CheckAndAdd() within window = true, want false")
+	}
+	if !cache.CheckAndAdd("n1", now.Add(2*time.Minute)) {
+		t.Error("CheckAndAdd() after expiry = false, want true")
+	}
+	if len(cache.seen) != 1 {
+		t.Errorf("cache holds %d nonces, want 1 after pruning", len(cache.seen))
+	}
+}
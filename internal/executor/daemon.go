@@ -29,6 +29,9 @@ type DaemonState struct {
 	TCPPort      int    `json:"tcp_port,omitempty"`       // Port for TCP mode
 	TokenAPIPort int    `json:"token_api_port,omitempty"` // Guardian token API port (for test instances)
 	ApprovalPort int    `json:"approval_port,omitempty"`  // Guardian approval server port (for test instances)
+
+	ApprovalSecret  string            `json:"approval_secret,omitempty"`  // Guardian approval UI secret
+	ApproverSecrets map[string]string `json:"approver_secrets,omitempty"` // Login tokens of named approvers
 }
 
 // DaemonStateDir returns the directory for daemon state files.
@@ -9,12 +9,21 @@ type Executor interface {
 }
 
 // ExecuteRequest contains the command execution parameters.
+//
+// EnvAllow and EnvDeny are glob patterns (as in path.Match) over variable
+// names in the executor's inherited environment. If EnvAllow is non-empty,
+// only matching variables are passed through; EnvDeny then removes any
+// matches. Env is applied last and overrides inherited values.
 type ExecuteRequest struct {
-	Command   string            `json:"command"`
-	Args      []string          `json:"args"`
-	Workdir   string            `json:"workdir"`
-	Env       map[string]string `json:"env,omitempty"`
-	TimeoutMs int               `json:"timeout_ms,omitempty"`
+	Cloister       string            `json:"cloister,omitempty"` // Requesting cloister, for per-cloister limits
+	Command        string            `json:"command"`
+	Args           []string          `json:"args"`
+	Workdir        string            `json:"workdir"`
+	Env            map[string]string `json:"env,omitempty"`
+	EnvAllow       []string          `json:"env_allow,omitempty"`
+	EnvDeny        []string          `json:"env_deny,omitempty"`
+	TimeoutMs      int               `json:"timeout_ms,omitempty"`
+	MaxOutputBytes int64             `json:"max_output_bytes,omitempty"` // Per stream; 0 means unlimited
 }
 
 // ExecuteResponse contains the result of command execution.
@@ -24,6 +33,9 @@ type ExecuteResponse struct {
 	Stdout   string `json:"stdout,omitempty"`
 	Stderr   string `json:"stderr,omitempty"`
 	Error    string `json:"error,omitempty"`
+
+	// Truncated is set when stdout or stderr exceeded MaxOutputBytes.
+	Truncated bool `json:"truncated,omitempty"`
 }
 
 // Status constants for ExecuteResponse.Status.
@@ -0,0 +1,171 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+)
+
+// Default concurrency limits used when none are configured.
+const (
+	DefaultMaxConcurrent            = 4
+	DefaultMaxConcurrentPerCloister = 2
+)
+
+// Limiter bounds the number of host commands running at once, both overall
+// and per cloister. Requests over the limit wait in a FIFO queue. A waiter
+// whose cloister is at its own limit does not block waiters from other
+// cloisters behind it.
+type Limiter struct {
+	mu          sync.Mutex
+	global      int
+	perCloister int
+	running     int
+	byCloister  map[string]int
+	waiters     []*waiter // In arrival order
+}
+
+// waiter is a queued Acquire call.
+type waiter struct {
+	cloister string
+	ready    chan struct{} // closed when a slot is granted
+	moved    chan struct{} // signaled (buffered, 1) when queue position changes
+	position int           // 1-based position in the queue
+}
+
+// NewLimiter creates a Limiter. A limit of zero or less selects the default.
+func NewLimiter(global, perCloister int) *Limiter {
+	if global <= 0 {
+		global = DefaultMaxConcurrent
+	}
+	if perCloister <= 0 {
+		perCloister = DefaultMaxConcurrentPerCloister
+	}
+	return &Limiter{
+		global:      global,
+		perCloister: perCloister,
+		byCloister:  make(map[string]int),
+	}
+}
+
+// Acquire blocks until a slot is available for the cloister or ctx is done.
+// While queued, onQueued (if non-nil) is called from the calling goroutine
+// with the current 1-based queue position each time it changes. On success
+// the returned release function must be called exactly once when the
+// command finishes.
+func (l *Limiter) Acquire(ctx context.Context, cloister string, onQueued func(position int)) (func(), error) {
+	l.mu.Lock()
+	if len(l.waiters) == 0 && l.hasSlot(cloister) {
+		l.take(cloister)
+		l.mu.Unlock()
+		return l.releaseFunc(cloister), nil
+	}
+	w := &waiter{cloister: cloister, ready: make(chan struct{}), moved: make(chan struct{}, 1)}
+	l.waiters = append(l.waiters, w)
+	w.position = len(l.waiters)
+	w.moved <- struct{}{} // Report the initial position
+	l.dispatch()
+	l.mu.Unlock()
+
+	reported := 0
+	for {
+		select {
+		case <-w.ready:
+			return l.releaseFunc(cloister), nil
+		case <-w.moved:
+		case <-ctx.Done():
+			l.mu.Lock()
+			select {
+			case <-w.ready:
+				// Granted concurrently with cancellation; give the slot back.
+				l.release(cloister)
+			default:
+				l.waiters = slices.DeleteFunc(l.waiters, func(x *waiter) bool { return x == w })
+				l.dispatch()
+			}
+			l.mu.Unlock()
+			return nil, fmt.Errorf("wait for executor slot: %w", ctx.Err())
+		}
+		if pos := l.positionOf(w); onQueued != nil && pos > 0 && pos != reported {
+			reported = pos
+			onQueued(pos)
+		}
+	}
+}
+
+// Stats returns the number of running and queued commands.
+func (l *Limiter) Stats() (running, queued int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.running, len(l.waiters)
+}
+
+// hasSlot reports whether the cloister may start a command now.
+// Caller must hold l.mu.
+func (l *Limiter) hasSlot(cloister string) bool {
+	return l.running < l.global && l.byCloister[cloister] < l.perCloister
+}
+
+// take records a running command for the cloister. Caller must hold l.mu.
+func (l *Limiter) take(cloister string) {
+	l.running++
+	l.byCloister[cloister]++
+}
+
+// release frees a slot and grants it to waiters. Caller must hold l.mu.
+func (l *Limiter) release(cloister string) {
+	l.running--
+	if l.byCloister[cloister]--; l.byCloister[cloister] <= 0 {
+		delete(l.byCloister, cloister)
+	}
+	l.dispatch()
+}
+
+// releaseFunc returns an idempotent release function for a granted slot.
+func (l *Limiter) releaseFunc(cloister string) func() {
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			l.mu.Lock()
+			defer l.mu.Unlock()
+			l.release(cloister)
+		})
+	}
+}
+
+// dispatch grants slots to eligible waiters in FIFO order and notifies the
+// remaining waiters of their new positions. Caller must hold l.mu.
+func (l *Limiter) dispatch() {
+	remaining := l.waiters[:0]
+	for _, w := range l.waiters {
+		if l.hasSlot(w.cloister) {
+			l.take(w.cloister)
+			close(w.ready)
+			continue
+		}
+		remaining = append(remaining, w)
+		if pos := len(remaining); w.position != pos {
+			w.position = pos
+			select {
+			case w.moved <- struct{}{}:
+			default:
+			}
+		}
+	}
+	clear(l.waiters[len(remaining):])
+	l.waiters = remaining
+}
+
+// positionOf returns the waiter's current queue position, or 0 if it is no
+// longer queued.
+func (l *Limiter) positionOf(w *waiter) int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	select {
+	case <-w.ready:
+		return 0
+	default:
+		return w.position
+	}
+}
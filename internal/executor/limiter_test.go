@@ -0,0 +1,147 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// acquireAsync calls Acquire in a goroutine and returns channels for the
+// release function and reported queue positions.
+func acquireAsync(ctx context.Context, l *Limiter, cloister string) (<-chan func(), <-chan int) {
+	granted := make(chan func(), 1)
+	positions := make(chan int, 16)
+	go func() {
+		release, err := l.Acquire(ctx, cloister, func(pos int) { positions <- pos })
+		if err == nil {
+			granted <- release
+		}
+		close(positions)
+	}()
+	return granted, positions
+}
+
+// waitQueued waits until the limiter reports n queued waiters.
+func waitQueued(t *testing.T, l *Limiter, n int) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if _, queued := l.Stats(); queued == n {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	_, queued := l.Stats()
+	t.Fatalf("queued = %d, want %d", queued, n)
+}
+
+func TestLimiter_Defaults(t *testing.T) {
+	l := NewLimiter(0, -1)
+	if l.global != DefaultMaxConcurrent || l.perCloister != DefaultMaxConcurrentPerCloister {
+		t.Errorf("limits = %d/%d, want defaults %d/%d",
+			l.global, l.perCloister, DefaultMaxConcurrent, DefaultMaxConcurrentPerCloister)
+	}
+}
+
+func TestLimiter_GlobalLimitFIFO(t *testing.T) {
+	l := NewLimiter(1, 10)
+	ctx := context.Background()
+
+	release, err := l.Acquire(ctx, "a", nil)
+	if err != nil {
+		t.Fatalf("Acquire() error = %v", err)
+	}
+
+	firstGranted, firstPos := acquireAsync(ctx, l, "b")
+	waitQueued(t, l, 1)
+	secondGranted, secondPos := acquireAsync(ctx, l, "c")
+	waitQueued(t, l, 2)
+
+	if got := <-firstPos; got != 1 {
+		t.Errorf("first waiter position = %d, want 1", got)
+	}
+	if got := <-secondPos; got != 2 {
+		t.Errorf("second waiter position = %d, want 2", got)
+	}
+
+	release()
+	releaseFirst := <-firstGranted
+	if got := <-secondPos; got != 1 {
+		t.Errorf("second waiter moved to position %d, want 1", got)
+	}
+	select {
+	case <-secondGranted:
+		t.Fatal("second waiter granted before first released")
+	default:
+	}
+
+	releaseFirst()
+	(<-secondGranted)()
+
+	if running, queued := l.Stats(); running != 0 || queued != 0 {
+		t.Errorf("Stats() = %d running, %d queued, want 0, 0", running, queued)
+	}
+}
+
+func TestLimiter_PerCloisterDoesNotBlockOthers(t *testing.T) {
+	l := NewLimiter(4, 1)
+	ctx := context.Background()
+
+	releaseA, err := l.Acquire(ctx, "a", nil)
+	if err != nil {
+		t.Fatalf("Acquire() error = %v", err)
+	}
+
+	aGranted, _ := acquireAsync(ctx, l, "a")
+	waitQueued(t, l, 1)
+
+	// A different cloister is not stuck behind "a"'s queued request.
+	releaseB, err := l.Acquire(ctx, "b", nil)
+	if err != nil {
+		t.Fatalf("Acquire(b) error = %v", err)
+	}
+	releaseB()
+
+	releaseA()
+	(<-aGranted)()
+}
+
+func TestLimiter_CancelWhileQueued(t *testing.T) {
+	l := NewLimiter(1, 1)
+	release, err := l.Acquire(context.Background(), "a", nil)
+	if err != nil {
+		t.Fatalf("Acquire() error = %v", err)
+	}
+	defer release()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errCh := make(chan error, 1)
+	go func() {
+		_, err := l.Acquire(ctx, "a", nil)
+		errCh <- err
+	}()
+	waitQueued(t, l, 1)
+
+	cancel()
+	if err := <-errCh; !errors.Is(err, context.Canceled) {
+		t.Errorf("Acquire() error = %v, want context.Canceled", err)
+	}
+	if _, queued := l.Stats(); queued != 0 {
+		t.Errorf("queued = %d after cancel, want 0", queued)
+	}
+}
+
+func TestLimiter_ReleaseIsIdempotent(t *testing.T) {
+	l := NewLimiter(1, 1)
+	release, err := l.Acquire(context.Background(), "a", nil)
+	if err != nil {
+		t.Fatalf("Acquire() error = %v", err)
+	}
+	release()
+	release()
+
+	if running, _ := l.Stats(); running != 0 {
+		t.Errorf("running = %d, want 0", running)
+	}
+}
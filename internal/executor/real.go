@@ -5,11 +5,20 @@ import (
 	"bytes"
 	"context"
 	"errors"
+	"fmt"
 	"os"
 	"os/exec"
+	"path"
+	"strings"
+	"syscall"
 	"time"
 )
 
+// processGroupWaitDelay bounds how long Execute waits for output pipes to
+// close after the process group has been killed. Without it, a grandchild
+// that inherited stdout could keep Wait blocked indefinitely.
+const processGroupWaitDelay = 2 * time.Second
+
 // RealExecutor executes commands using os/exec.
 type RealExecutor struct{}
 
@@ -19,6 +28,10 @@ func NewRealExecutor() *RealExecutor {
 }
 
 // Execute runs a command and returns the result.
+//
+// The command runs in its own process group so that on timeout or
+// cancellation the whole group (including any children it spawned) is
+// killed, not just the direct child.
 func (e *RealExecutor) Execute(ctx context.Context, req ExecuteRequest) ExecuteResponse {
 	// Apply timeout if specified
 	if req.TimeoutMs > 0 {
@@ -30,75 +43,158 @@ func (e *RealExecutor) Execute(ctx context.Context, req ExecuteRequest) ExecuteR
 	// Create command with context for timeout support
 	// Command must be the executable name; Args are passed directly to exec (no shell)
 	cmd := exec.CommandContext(ctx, req.Command, req.Args...)
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error {
+		return killProcessGroup(cmd.Process)
+	}
+	cmd.WaitDelay = processGroupWaitDelay
 
 	// Set working directory if specified
 	if req.Workdir != "" {
 		cmd.Dir = req.Workdir
 	}
 
-	// Merge environment variables
-	if len(req.Env) > 0 {
-		cmd.Env = os.Environ()
-		for k, v := range req.Env {
-			cmd.Env = append(cmd.Env, k+"="+v)
-		}
+	// Apply environment policy (allow/deny lists plus injected variables)
+	if len(req.Env) > 0 || len(req.EnvAllow) > 0 || len(req.EnvDeny) > 0 {
+		cmd.Env = buildEnv(os.Environ(), req)
 	}
 
-	// Capture stdout and stderr
-	var stdout, stderr bytes.Buffer
-	cmd.Stdout = &stdout
-	cmd.Stderr = &stderr
+	// Capture stdout and stderr, each capped at MaxOutputBytes if set
+	stdout := &cappedBuffer{limit: req.MaxOutputBytes}
+	stderr := &cappedBuffer{limit: req.MaxOutputBytes}
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
 
 	// Run the command
-	err := cmd.Run()
-
-	// Handle different error cases
-	if err != nil {
-		// Check if context was canceled or timed out
-		if ctx.Err() == context.DeadlineExceeded || ctx.Err() == context.Canceled {
-			return ExecuteResponse{
-				Status:   StatusTimeout,
-				ExitCode: -1,
-				Stdout:   stdout.String(),
-				Stderr:   stderr.String(),
-				Error:    "command timed out",
-			}
-		}
+	resp := buildResponse(ctx, req, cmd.Run(), stdout, stderr)
+	resp.Truncated = stdout.truncated || stderr.truncated
+	return resp
+}
 
-		// Check if executable was not found
-		var execErr *exec.Error
-		if errors.As(err, &execErr) {
-			return ExecuteResponse{
-				Status: StatusError,
-				Error:  "executable not found: " + req.Command,
-			}
+// buildResponse maps the result of cmd.Run to an ExecuteResponse.
+func buildResponse(ctx context.Context, req ExecuteRequest, err error, stdout, stderr *cappedBuffer) ExecuteResponse {
+	if err == nil {
+		return ExecuteResponse{
+			Status:   StatusCompleted,
+			ExitCode: 0,
+			Stdout:   stdout.String(),
+			Stderr:   stderr.String(),
 		}
+	}
 
-		// Check for exit error (command ran but returned non-zero)
-		var exitErr *exec.ExitError
-		if errors.As(err, &exitErr) {
-			return ExecuteResponse{
-				Status:   StatusCompleted,
-				ExitCode: exitErr.ExitCode(),
-				Stdout:   stdout.String(),
-				Stderr:   stderr.String(),
-			}
+	// Check if context was canceled or timed out
+	if ctx.Err() == context.DeadlineExceeded || ctx.Err() == context.Canceled {
+		return ExecuteResponse{
+			Status:   StatusTimeout,
+			ExitCode: -1,
+			Stdout:   stdout.String(),
+			Stderr:   stderr.String(),
+			Error:    "command timed out",
 		}
+	}
 
-		// Other errors (e.g., permission denied, etc.)
+	// Check if executable was not found
+	var execErr *exec.Error
+	if errors.As(err, &execErr) {
 		return ExecuteResponse{
 			Status: StatusError,
-			Stdout: stdout.String(),
-			Stderr: stderr.String(),
-			Error:  err.Error(),
+			Error:  "executable not found: " + req.Command,
 		}
 	}
 
-	// Success
+	// Check for exit error (command ran but returned non-zero)
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return ExecuteResponse{
+			Status:   StatusCompleted,
+			ExitCode: exitErr.ExitCode(),
+			Stdout:   stdout.String(),
+			Stderr:   stderr.String(),
+		}
+	}
+
+	// Other errors (e.g., permission denied, etc.)
 	return ExecuteResponse{
-		Status:   StatusCompleted,
-		ExitCode: 0,
-		Stdout:   stdout.String(),
-		Stderr:   stderr.String(),
+		Status: StatusError,
+		Stdout: stdout.String(),
+		Stderr: stderr.String(),
+		Error:  err.Error(),
+	}
+}
+
+// killProcessGroup sends SIGKILL to the process group led by p.
+// The command was started with Setpgid, so its PGID equals its PID.
+func killProcessGroup(p *os.Process) error {
+	if p == nil {
+		return nil
+	}
+	if err := syscall.Kill(-p.Pid, syscall.SIGKILL); err != nil && !errors.Is(err, syscall.ESRCH) {
+		return fmt.Errorf("kill process group %d: %w", p.Pid, err)
+	}
+	return nil
+}
+
+// buildEnv computes the child environment from base (typically os.Environ()).
+// If EnvAllow is non-empty, only variables whose names match one of its glob
+// patterns are kept. Variables matching any EnvDeny pattern are then removed.
+// Finally, Env entries are added, overriding inherited values.
+func buildEnv(base []string, req ExecuteRequest) []string {
+	env := make([]string, 0, len(base)+len(req.Env))
+	for _, kv := range base {
+		name, _, _ := strings.Cut(kv, "=")
+		if _, overridden := req.Env[name]; overridden {
+			continue
+		}
+		if len(req.EnvAllow) > 0 && !matchesAnyName(req.EnvAllow, name) {
+			continue
+		}
+		if matchesAnyName(req.EnvDeny, name) {
+			continue
+		}
+		env = append(env, kv)
+	}
+	for k, v := range req.Env {
+		env = append(env, k+"="+v)
+	}
+	return env
+}
+
+// matchesAnyName reports whether name matches any of the glob patterns.
+// Invalid patterns never match.
+func matchesAnyName(patterns []string, name string) bool {
+	for _, p := range patterns {
+		if ok, err := path.Match(p, name); err == nil && ok {
+			return true
+		}
 	}
+	return false
+}
+
+// cappedBuffer is an io.Writer that keeps at most limit bytes and silently
+// discards the rest, recording that truncation occurred. A limit of zero or
+// less means unlimited. Writes always report success so the child process
+// is not killed by EPIPE when it produces more output than we keep.
+type cappedBuffer struct {
+	buf       bytes.Buffer
+	limit     int64
+	truncated bool
+}
+
+// Write implements io.Writer.
+func (b *cappedBuffer) Write(p []byte) (int, error) {
+	keep := p
+	if b.limit > 0 {
+		remaining := max(b.limit-int64(b.buf.Len()), 0)
+		if int64(len(keep)) > remaining {
+			keep = keep[:remaining]
+			b.truncated = true
+		}
+	}
+	b.buf.Write(keep)
+	return len(p), nil
+}
+
+// String returns the captured output.
+func (b *cappedBuffer) String() string {
+	return b.buf.String()
 }
@@ -4,8 +4,10 @@ import (
 	"context"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 	"testing"
+	"time"
 )
 
 // TestRealExecutorInterface verifies RealExecutor implements Executor.
@@ -205,3 +207,137 @@ func TestRealExecutorPreserveInheritedEnv(t *testing.T) {
 		t.Errorf("Stdout should contain custom env 'custom_value', got: %q", resp.Stdout)
 	}
 }
+
+// TestRealExecutorTimeoutKillsProcessGroup verifies that a timeout kills
+// background children too, so their inherited stdout pipe does not keep
+// Execute blocked until WaitDelay expires.
+func TestRealExecutorTimeoutKillsProcessGroup(t *testing.T) {
+	executor := NewRealExecutor()
+	req := ExecuteRequest{
+		Command:   "sh",
+		Args:      []string{"-c", "sleep 30 & wait"},
+		TimeoutMs: 100,
+	}
+
+	start := time.Now()
+	resp := executor.Execute(context.Background(), req)
+	elapsed := time.Since(start)
+
+	if resp.Status != StatusTimeout {
+		t.Errorf("Status: got %q, want %q", resp.Status, StatusTimeout)
+	}
+	if elapsed >= processGroupWaitDelay {
+		t.Errorf("Execute took %v, want less than %v (process group not killed?)", elapsed, processGroupWaitDelay)
+	}
+}
+
+// TestRealExecutorMaxOutputBytes verifies stdout and stderr are capped and
+// the response is marked truncated.
+func TestRealExecutorMaxOutputBytes(t *testing.T) {
+	executor := NewRealExecutor()
+	req := ExecuteRequest{
+		Command:        "sh",
+		Args:           []string{"-c", "printf 0123456789; printf abcdefghij >&2"},
+		MaxOutputBytes: 4,
+	}
+
+	resp := executor.Execute(context.Background(), req)
+
+	if resp.Status != StatusCompleted {
+		t.Errorf("Status: got %q, want %q", resp.Status, StatusCompleted)
+	}
+	if resp.Stdout != "0123" {
+		t.Errorf("Stdout: got %q, want %q", resp.Stdout, "0123")
+	}
+	if resp.Stderr != "abcd" {
+		t.Errorf("Stderr: got %q, want %q", resp.Stderr, "abcd")
+	}
+	if !resp.Truncated {
+		t.Error("Truncated should be true")
+	}
+}
+
+// TestRealExecutorMaxOutputBytesNotExceeded verifies output under the cap
+// is returned intact and not marked truncated.
+func TestRealExecutorMaxOutputBytesNotExceeded(t *testing.T) {
+	executor := NewRealExecutor()
+	req := ExecuteRequest{
+		Command:        "printf",
+		Args:           []string{"short"},
+		MaxOutputBytes: 1024,
+	}
+
+	resp := executor.Execute(context.Background(), req)
+
+	if resp.Stdout != "short" {
+		t.Errorf("Stdout: got %q, want %q", resp.Stdout, "short")
+	}
+	if resp.Truncated {
+		t.Error("Truncated should be false")
+	}
+}
+
+// TestRealExecutorEnvAllow verifies that only allowlisted variables are inherited.
+func TestRealExecutorEnvAllow(t *testing.T) {
+	t.Setenv("EXECUTOR_TEST_KEEP", "kept")
+	t.Setenv("EXECUTOR_TEST_DROP", "dropped")
+
+	executor := NewRealExecutor()
+	req := ExecuteRequest{
+		Command:  "sh",
+		Args:     []string{"-c", "echo keep=$EXECUTOR_TEST_KEEP drop=$EXECUTOR_TEST_DROP custom=$TEST_CUSTOM"},
+		EnvAllow: []string{"EXECUTOR_TEST_K*"},
+		Env:      map[string]string{"TEST_CUSTOM": "injected"},
+	}
+
+	resp := executor.Execute(context.Background(), req)
+
+	want := "keep=kept drop= custom=injected"
+	if got := strings.TrimSpace(resp.Stdout); got != want {
+		t.Errorf("Stdout: got %q, want %q", got, want)
+	}
+}
+
+// TestRealExecutorEnvDeny verifies that denylisted variables are removed
+// while everything else is inherited.
+func TestRealExecutorEnvDeny(t *testing.T) {
+	t.Setenv("EXECUTOR_TEST_KEEP", "kept")
+	t.Setenv("EXECUTOR_TEST_SECRET", "hunter2")
+
+	executor := NewRealExecutor()
+	req := ExecuteRequest{
+		Command: "sh",
+		Args:    []string{"-c", "echo keep=$EXECUTOR_TEST_KEEP secret=$EXECUTOR_TEST_SECRET"},
+		EnvDeny: []string{"*_SECRET"},
+	}
+
+	resp := executor.Execute(context.Background(), req)
+
+	want := "keep=kept secret="
+	if got := strings.TrimSpace(resp.Stdout); got != want {
+		t.Errorf("Stdout: got %q, want %q", got, want)
+	}
+}
+
+// TestBuildEnvOverridesInherited verifies Env entries replace inherited
+// values rather than appearing twice.
+func TestBuildEnvOverridesInherited(t *testing.T) {
+	base := []string{"FOO=old", "BAR=keep"}
+	env := buildEnv(base, ExecuteRequest{Env: map[string]string{"FOO": "new"}})
+
+	count := 0
+	for _, kv := range env {
+		if strings.HasPrefix(kv, "FOO=") {
+			count++
+			if kv != "FOO=new" {
+				t.Errorf("FOO: got %q, want %q", kv, "FOO=new")
+			}
+		}
+	}
+	if count != 1 {
+		t.Errorf("FOO appears %d times, want 1", count)
+	}
+	if !slices.Contains(env, "BAR=keep") {
+		t.Errorf("BAR=keep missing from %v", env)
+	}
+}
@@ -5,36 +5,109 @@ import (
 	"bufio"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net"
 	"os"
 	"path/filepath"
 	"sync"
+	"time"
 
 	"github.com/xdg/cloister/internal/clog"
+	"github.com/xdg/cloister/internal/tracing"
 )
 
-// SocketRequest is the JSON request sent over the Unix socket.
-// It wraps ExecuteRequest with authentication fields.
+// Socket protocol versions. ProtocolVersion is stamped on every request and
+// response; an executor accepts requests from MinProtocolVersion to
+// ProtocolVersion. Bump ProtocolVersion when the payload or response changes
+// in a way an older peer would misread, and MinProtocolVersion when support
+// for older guardians is dropped.
+const (
+	ProtocolVersion    = 1
+	MinProtocolVersion = 1
+)
+
+// SocketRequest is the JSON request sent over the socket. Payload holds a
+// JSON-encoded SocketPayload; Auth carries an HMAC over the exact Payload
+// bytes, so the shared secret is never transmitted.
 type SocketRequest struct {
-	Secret  string         `json:"secret"`
+	Auth    SocketAuth      `json:"auth"`
+	Payload json.RawMessage `json:"payload"`
+}
+
+// SocketPayload is the authenticated content of a SocketRequest.
+type SocketPayload struct {
+	// Version is the socket protocol version the client speaks. Requests
+	// from clients that predate versioning have none.
+	Version int `json:"version,omitempty"`
+
 	Request ExecuteRequest `json:"request"`
+
+	// Progress asks the server to send progress lines (queued position,
+	// running) before the final response.
+	Progress bool `json:"progress,omitempty"`
+
+	// Ping asks the server to authenticate the request and reply without
+	// running anything, to check that the executor is reachable.
+	Ping bool `json:"ping,omitempty"`
+
+	// Traceparent is the W3C trace context of the guardian's span for this
+	// request, so that the executor's spans join the same trace.
+	Traceparent string `json:"traceparent,omitempty"`
+}
+
+// NewSocketRequest encodes and signs a payload with the shared secret. The
+// payload is stamped with ProtocolVersion unless it already has a version.
+func NewSocketRequest(secret string, payload SocketPayload) (*SocketRequest, error) {
+	if payload.Version == 0 {
+		payload.Version = ProtocolVersion
+	}
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("marshal payload: %w", err)
+	}
+	auth, err := SignPayload(secret, data, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	return &SocketRequest{Auth: auth, Payload: data}, nil
 }
 
 // SocketResponse is the JSON response sent over the Unix socket.
 // It wraps ExecuteResponse with additional error information.
+// When Progress is set, the line is an interim update and the final
+// response follows on a later line.
 type SocketResponse struct {
+	// Version is the executor's ProtocolVersion. Executors that predate
+	// versioning send none.
+	Version int `json:"version,omitempty"`
+
 	Success  bool            `json:"success"`
 	Error    string          `json:"error,omitempty"`
 	Response ExecuteResponse `json:"response,omitzero"`
+	Progress *Progress       `json:"progress,omitempty"`
+}
+
+// Progress is an interim execution state update.
+type Progress struct {
+	State    string `json:"state"`              // ProgressQueued or ProgressRunning
+	Position int    `json:"position,omitempty"` // 1-based queue position when queued
 }
 
+// Progress states.
+const (
+	ProgressQueued  = "queued"
+	ProgressRunning = "running"
+)
+
 // SocketServer listens on a Unix socket or TCP port and executes commands via an Executor.
 type SocketServer struct {
 	socketPath string
 	tcpAddr    string // If set, use TCP instead of Unix socket
 	secret     string
 	executor   Executor
+	limiter    *Limiter     // Optional concurrency limiter
+	replays    *ReplayCache // Nonces seen within the clock-skew window
 
 	listener net.Listener
 	wg       sync.WaitGroup
@@ -61,6 +134,15 @@ func WithTCPAddr(addr string) SocketServerOption {
 	}
 }
 
+// WithLimiter bounds concurrent command execution. Requests over the limit
+// wait in a FIFO queue and are reported as queued to clients that asked for
+// progress.
+func WithLimiter(l *Limiter) SocketServerOption {
+	return func(s *SocketServer) {
+		s.limiter = l
+	}
+}
+
 // NewSocketServer creates a new SocketServer.
 // The secret is used to authenticate requests from the guardian.
 // Token validation is handled by the guardian before forwarding to the executor.
@@ -68,6 +150,7 @@ func NewSocketServer(secret string, executor Executor, opts ...SocketServerOptio
 	s := &SocketServer{
 		secret:   secret,
 		executor: executor,
+		replays:  NewReplayCache(2 * MaxClockSkew),
 		shutdown: make(chan struct{}),
 	}
 	for _, opt := range opts {
@@ -238,15 +321,44 @@ func (s *SocketServer) handleConnection(conn net.Conn) {
 		return
 	}
 
-	// Validate shared secret (guardian-executor authentication)
+	// Verify the request HMAC (guardian-executor authentication)
 	// Token validation is handled by the guardian before forwarding requests
-	if req.Secret != s.secret {
-		s.writeError(conn, "invalid secret")
+	if err := VerifyAuth(s.secret, &req.Auth, req.Payload, time.Now(), s.replays); err != nil {
+		s.writeError(conn, "authentication failed: "+err.Error())
 		return
 	}
 
-	// Execute command
-	ctx := context.Background()
+	var payload SocketPayload
+	if err := json.Unmarshal(req.Payload, &payload); err != nil {
+		s.writeError(conn, "invalid payload: "+err.Error())
+		return
+	}
+	if err := CheckProtocolVersion(payload.Version); err != nil {
+		s.writeError(conn, err.Error())
+		return
+	}
+	if payload.Ping {
+		s.writeResponse(conn, SocketResponse{Success: true})
+		return
+	}
+
+	// Cancel the command if the client goes away. The client sends nothing
+	// after the request line, so any read completing means it disconnected.
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		_, _ = reader.ReadByte()
+		cancel()
+	}()
+
+	if parent, ok := tracing.ParseTraceparent(payload.Traceparent); ok {
+		ctx = tracing.ContextWithRemoteParent(ctx, parent)
+	}
+	ctx, span := tracing.Start(ctx, "executor.execute",
+		tracing.String("cloister.name", payload.Request.Cloister),
+		tracing.String("process.executable.name", filepath.Base(payload.Request.Command)))
+	defer span.End()
+
 	select {
 	case <-s.shutdown:
 		s.writeError(conn, "server shutting down")
@@ -254,7 +366,27 @@ func (s *SocketServer) handleConnection(conn net.Conn) {
 	default:
 	}
 
-	execResp := s.executor.Execute(ctx, req.Request)
+	_, queueSpan := tracing.Start(ctx, "executor.queue")
+	release, err := s.acquireSlot(ctx, conn, &payload)
+	queueSpan.RecordError(err)
+	queueSpan.End()
+	if err != nil {
+		span.RecordError(err)
+		s.writeError(conn, err.Error())
+		return
+	}
+	defer release()
+
+	if payload.Progress {
+		s.writeResponse(conn, SocketResponse{Success: true, Progress: &Progress{State: ProgressRunning}})
+	}
+	runCtx, runSpan := tracing.Start(ctx, "executor.run")
+	execResp := s.executor.Execute(runCtx, payload.Request)
+	runSpan.SetAttributes(tracing.String("cloister.status", execResp.Status), tracing.Int("process.exit.code", execResp.ExitCode))
+	if execResp.Status == StatusError {
+		runSpan.RecordError(errors.New(execResp.Error))
+	}
+	runSpan.End()
 
 	// Write response
 	resp := SocketResponse{
@@ -264,6 +396,48 @@ func (s *SocketServer) handleConnection(conn net.Conn) {
 	s.writeResponse(conn, resp)
 }
 
+// acquireSlot waits for the limiter, if any, to admit the request. Queue
+// position updates are written to the connection when the client asked for
+// progress. Waiting ends early if the client disconnects or the server
+// shuts down.
+func (s *SocketServer) acquireSlot(ctx context.Context, conn net.Conn, req *SocketPayload) (func(), error) {
+	if s.limiter == nil {
+		return func() {}, nil
+	}
+	waitCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		select {
+		case <-s.shutdown:
+			cancel()
+		case <-waitCtx.Done():
+		}
+	}()
+
+	release, err := s.limiter.Acquire(waitCtx, req.Request.Cloister, func(pos int) {
+		if req.Progress {
+			s.writeResponse(conn, SocketResponse{Success: true, Progress: &Progress{State: ProgressQueued, Position: pos}})
+		}
+	})
+	if err != nil {
+		return nil, fmt.Errorf("request cancelled while queued: %w", err)
+	}
+	return release, nil
+}
+
+// CheckProtocolVersion returns an error unless a peer speaking socket
+// protocol version v can talk to this one. Version 0 means the peer predates
+// versioning.
+func CheckProtocolVersion(v int) error {
+	if v >= MinProtocolVersion && v <= ProtocolVersion {
+		return nil
+	}
+	if v == 0 {
+		return fmt.Errorf("unsupported protocol version: peer predates protocol versioning (supported: %d-%d)", MinProtocolVersion, ProtocolVersion)
+	}
+	return fmt.Errorf("unsupported protocol version %d (supported: %d-%d)", v, MinProtocolVersion, ProtocolVersion)
+}
+
 // writeError writes an error response to the connection.
 func (s *SocketServer) writeError(conn net.Conn, errMsg string) {
 	resp := SocketResponse{
@@ -275,6 +449,7 @@ func (s *SocketServer) writeError(conn net.Conn, errMsg string) {
 
 // writeResponse writes a JSON response to the connection.
 func (s *SocketServer) writeResponse(conn net.Conn, resp SocketResponse) {
+	resp.Version = ProtocolVersion
 	data, err := json.Marshal(resp)
 	if err != nil {
 		// Last resort: write a minimal error
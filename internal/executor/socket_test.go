@@ -4,6 +4,7 @@ import (
 	"bufio"
 	"context"
 	"encoding/json"
+	"fmt"
 	"net"
 	"os"
 	"path/filepath"
@@ -27,10 +28,10 @@ func shortTempDir(t *testing.T) string {
 	return dir
 }
 
-// TestSocketRequestJSONRoundTrip verifies SocketRequest serializes correctly.
+// TestSocketRequestJSONRoundTrip verifies SocketRequest serializes correctly
+// and never carries the shared secret.
 func TestSocketRequestJSONRoundTrip(t *testing.T) {
-	req := SocketRequest{
-		Secret: "test-secret-123",
+	req := signedRequest(t, "test-secret-123", SocketPayload{
 		Request: ExecuteRequest{
 			Command:   "echo",
 			Args:      []string{"hello"},
@@ -38,23 +39,33 @@ func TestSocketRequestJSONRoundTrip(t *testing.T) {
 			Env:       map[string]string{"FOO": "bar"},
 			TimeoutMs: 5000,
 		},
-	}
+	})
 
 	data, err := json.Marshal(req)
 	if err != nil {
 		t.Fatalf("Marshal failed: %v", err)
 	}
+	if strings.Contains(string(data), "test-secret-123") {
+		t.Errorf("serialized request contains the secret: %s", data)
+	}
 
 	var got SocketRequest
 	if err := json.Unmarshal(data, &got); err != nil {
 		t.Fatalf("Unmarshal failed: %v", err)
 	}
+	if got.Auth != req.Auth {
+		t.Errorf("Auth: got %+v, want %+v", got.Auth, req.Auth)
+	}
+	if err := VerifyAuth("test-secret-123", &got.Auth, got.Payload, time.Now(), NewReplayCache(time.Minute)); err != nil {
+		t.Errorf("VerifyAuth after round trip: %v", err)
+	}
 
-	if got.Secret != req.Secret {
-		t.Errorf("Secret: got %q, want %q", got.Secret, req.Secret)
+	var payload SocketPayload
+	if err := json.Unmarshal(got.Payload, &payload); err != nil {
+		t.Fatalf("Unmarshal payload failed: %v", err)
 	}
-	if got.Request.Command != req.Request.Command {
-		t.Errorf("Command: got %q, want %q", got.Request.Command, req.Request.Command)
+	if payload.Request.Command != "echo" {
+		t.Errorf("Command: got %q, want %q", payload.Request.Command, "echo")
 	}
 }
 
@@ -220,14 +231,13 @@ func TestSocketServerValidRequest(t *testing.T) {
 	defer func() { _ = conn.Close() }()
 
 	// Send request
-	req := SocketRequest{
-		Secret: "test-secret",
+	req := signedRequest(t, "test-secret", SocketPayload{
 		Request: ExecuteRequest{
 			Command: "echo",
 			Args:    []string{"hello"},
 			Workdir: "/work",
 		},
-	}
+	})
 	sendRequest(t, conn, req)
 
 	// Read response
@@ -277,12 +287,11 @@ func TestSocketServerInvalidSecret(t *testing.T) {
 	defer func() { _ = conn.Close() }()
 
 	// Send request with wrong secret
-	req := SocketRequest{
-		Secret: "wrong-secret",
+	req := signedRequest(t, "wrong-secret", SocketPayload{
 		Request: ExecuteRequest{
 			Command: "echo",
 		},
-	}
+	})
 	sendRequest(t, conn, req)
 
 	// Read response
@@ -291,8 +300,8 @@ func TestSocketServerInvalidSecret(t *testing.T) {
 	if resp.Success {
 		t.Error("Expected failure for invalid secret")
 	}
-	if !strings.Contains(resp.Error, "invalid secret") {
-		t.Errorf("Error should contain 'invalid secret', got: %q", resp.Error)
+	if !strings.Contains(resp.Error, "authentication failed") {
+		t.Errorf("Error should contain 'authentication failed', got: %q", resp.Error)
 	}
 
 	// Verify executor was NOT called
@@ -371,13 +380,12 @@ func TestSocketServerMultipleConnections(t *testing.T) {
 			}
 			defer func() { _ = conn.Close() }()
 
-			req := SocketRequest{
-				Secret: "test-secret",
+			req := signedRequest(t, "test-secret", SocketPayload{
 				Request: ExecuteRequest{
 					Command: "echo",
 					Args:    []string{"hello"},
 				},
-			}
+			})
 
 			data, err := json.Marshal(req)
 			if err != nil {
@@ -453,12 +461,11 @@ func TestSocketServerGracefulShutdown(t *testing.T) {
 
 	// Send request in background
 	go func() {
-		req := SocketRequest{
-			Secret: "test-secret",
+		req := signedRequest(t, "test-secret", SocketPayload{
 			Request: ExecuteRequest{
 				Command: "echo",
 			},
-		}
+		})
 		sendRequest(t, conn, req)
 	}()
 
@@ -498,6 +505,15 @@ func TestSocketServerSocketPath(t *testing.T) {
 
 // Helper functions for tests
 
+func signedRequest(t *testing.T, secret string, payload SocketPayload) SocketRequest {
+	t.Helper()
+	req, err := NewSocketRequest(secret, payload)
+	if err != nil {
+		t.Fatalf("NewSocketRequest failed: %v", err)
+	}
+	return *req
+}
+
 func sendRequest(t *testing.T, conn net.Conn, req SocketRequest) {
 	t.Helper()
 	data, err := json.Marshal(req)
@@ -524,3 +540,229 @@ func readResponse(t *testing.T, conn net.Conn) SocketResponse {
 	}
 	return resp
 }
+
+// blockingExecutor blocks each Execute until release is closed or the
+// context is cancelled, recording whether cancellation was observed.
+type blockingExecutor struct {
+	started   chan string
+	release   chan struct{}
+	cancelled chan string
+}
+
+func newBlockingExecutor() *blockingExecutor {
+	return &blockingExecutor{
+		started:   make(chan string, 8),
+		release:   make(chan struct{}),
+		cancelled: make(chan string, 8),
+	}
+}
+
+func (b *blockingExecutor) Execute(ctx context.Context, req ExecuteRequest) ExecuteResponse {
+	b.started <- req.Command
+	select {
+	case <-b.release:
+		return ExecuteResponse{Status: StatusCompleted}
+	case <-ctx.Done():
+		b.cancelled <- req.Command
+		return ExecuteResponse{Status: StatusError, Error: "cancelled"}
+	}
+}
+
+// dialAndSend connects to the server and sends a request, returning the
+// connection and a reader for consecutive response lines.
+func dialAndSend(t *testing.T, sockPath string, req SocketRequest) (net.Conn, *bufio.Reader) {
+	t.Helper()
+	conn, err := (&net.Dialer{}).DialContext(context.Background(), "unix", sockPath)
+	if err != nil {
+		t.Fatalf("Dial failed: %v", err)
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+	sendRequest(t, conn, req)
+	return conn, bufio.NewReader(conn)
+}
+
+func readLine(t *testing.T, reader *bufio.Reader) SocketResponse {
+	t.Helper()
+	line, err := reader.ReadBytes('\n')
+	if err != nil {
+		t.Fatalf("Read response failed: %v", err)
+	}
+	var resp SocketResponse
+	if err := json.Unmarshal(line, &resp); err != nil {
+		t.Fatalf("Unmarshal response failed: %v", err)
+	}
+	return resp
+}
+
+// TestSocketServerLimiterReportsQueuePosition verifies queued requests get
+// progress lines and run in order once a slot frees up.
+func TestSocketServerLimiterReportsQueuePosition(t *testing.T) {
+	sockPath := filepath.Join(shortTempDir(t), "test.sock")
+	exec := newBlockingExecutor()
+	server := NewSocketServer("s", exec, WithSocketPath(sockPath), WithLimiter(NewLimiter(1, 1)))
+	if err := server.Start(); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	defer func() { _ = server.Stop() }()
+
+	_, first := dialAndSend(t, sockPath, signedRequest(t, "s", SocketPayload{Progress: true, Request: ExecuteRequest{Command: "one", Cloister: "c"}}))
+	if p := readLine(t, first).Progress; p == nil || p.State != ProgressRunning {
+		t.Fatalf("first progress = %+v, want running", p)
+	}
+	<-exec.started
+
+	_, second := dialAndSend(t, sockPath, signedRequest(t, "s", SocketPayload{Progress: true, Request: ExecuteRequest{Command: "two", Cloister: "c"}}))
+	if p := readLine(t, second).Progress; p == nil || p.State != ProgressQueued || p.Position != 1 {
+		t.Fatalf("second progress = %+v, want queued at 1", p)
+	}
+
+	close(exec.release)
+	if resp := readLine(t, first); resp.Progress != nil || resp.Response.Status != StatusCompleted {
+		t.Errorf("first final = %+v, want completed", resp)
+	}
+	if p := readLine(t, second).Progress; p == nil || p.State != ProgressRunning {
+		t.Fatalf("second progress = %+v, want running", p)
+	}
+	if resp := readLine(t, second); resp.Response.Status != StatusCompleted {
+		t.Errorf("second final = %+v, want completed", resp)
+	}
+}
+
+// TestSocketServerCancelsOnDisconnect verifies that a client disconnect
+// cancels the running command's context.
+func TestSocketServerCancelsOnDisconnect(t *testing.T) {
+	sockPath := filepath.Join(shortTempDir(t), "test.sock")
+	exec := newBlockingExecutor()
+	server := NewSocketServer("s", exec, WithSocketPath(sockPath))
+	if err := server.Start(); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	defer func() { _ = server.Stop() }()
+
+	conn, _ := dialAndSend(t, sockPath, signedRequest(t, "s", SocketPayload{Request: ExecuteRequest{Command: "long"}}))
+	<-exec.started
+	_ = conn.Close()
+
+	select {
+	case cmd := <-exec.cancelled:
+		if cmd != "long" {
+			t.Errorf("cancelled %q, want %q", cmd, "long")
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("command was not cancelled after client disconnect")
+	}
+}
+
+// TestSocketServerRejectsReplay verifies a captured request cannot be resent.
+func TestSocketServerRejectsReplay(t *testing.T) {
+	sockPath := filepath.Join(shortTempDir(t), "test.sock")
+	mock := &mockExecutorForSocket{
+		response: ExecuteResponse{Status: StatusCompleted},
+	}
+	server := NewSocketServer("s", mock, WithSocketPath(sockPath))
+	if err := server.Start(); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	defer func() { _ = server.Stop() }()
+
+	req := signedRequest(t, "s", SocketPayload{Request: ExecuteRequest{Command: "echo"}})
+
+	_, first := dialAndSend(t, sockPath, req)
+	if resp := readLine(t, first); !resp.Success {
+		t.Fatalf("first request failed: %q", resp.Error)
+	}
+
+	_, replay := dialAndSend(t, sockPath, req)
+	resp := readLine(t, replay)
+	if resp.Success || !strings.Contains(resp.Error, ErrReplayedRequest.Error()) {
+		t.Errorf("replayed request: got success=%v error=%q, want replay rejection", resp.Success, resp.Error)
+	}
+	if calls := mock.getCalls(); len(calls) != 1 {
+		t.Errorf("executor called %d times, want 1", len(calls))
+	}
+}
+
+// TestSocketServerPing verifies that pings are authenticated and answered
+// without running anything.
+func TestSocketServerPing(t *testing.T) {
+	sockPath := filepath.Join(shortTempDir(t), "test.sock")
+	mock := &mockExecutorForSocket{}
+	server := NewSocketServer("test-secret", mock, WithSocketPath(sockPath))
+	if err := server.Start(); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	defer func() { _ = server.Stop() }()
+
+	for _, tt := range []struct {
+		secret      string
+		wantSuccess bool
+	}{
+		{"test-secret", true},
+		{"wrong-secret", false},
+	} {
+		conn, err := (&net.Dialer{}).DialContext(context.Background(), "unix", sockPath)
+		if err != nil {
+			t.Fatalf("Dial failed: %v", err)
+		}
+		sendRequest(t, conn, signedRequest(t, tt.secret, SocketPayload{Ping: true}))
+		resp := readResponse(t, conn)
+		_ = conn.Close()
+		if resp.Success != tt.wantSuccess {
+			t.Errorf("ping with %s: Success = %v, want %v (%s)", tt.secret, resp.Success, tt.wantSuccess, resp.Error)
+		}
+	}
+	if calls := mock.getCalls(); len(calls) != 0 {
+		t.Errorf("ping ran %d command(s)", len(calls))
+	}
+}
+
+func TestSocketServerProtocolVersion(t *testing.T) {
+	sockPath := filepath.Join(shortTempDir(t), "test.sock")
+	mock := &mockExecutorForSocket{}
+	server := NewSocketServer("test-secret", mock, WithSocketPath(sockPath))
+	if err := server.Start(); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	defer func() { _ = server.Stop() }()
+
+	// A client that predates versioning sends no version.
+	unversioned := []byte(`{"request":{"command":"echo"}}`)
+	auth, err := SignPayload("test-secret", unversioned, time.Now())
+	if err != nil {
+		t.Fatalf("SignPayload failed: %v", err)
+	}
+
+	for _, tt := range []struct {
+		name      string
+		req       SocketRequest
+		wantError string
+	}{
+		{"current", signedRequest(t, "test-secret", SocketPayload{Request: ExecuteRequest{Command: "echo"}}), ""},
+		{"too new", signedRequest(t, "test-secret", SocketPayload{Version: ProtocolVersion + 1, Request: ExecuteRequest{Command: "echo"}}),
+			fmt.Sprintf("unsupported protocol version %d (supported: %d-%d)", ProtocolVersion+1, MinProtocolVersion, ProtocolVersion)},
+		{"unversioned", SocketRequest{Auth: auth, Payload: unversioned},
+			"unsupported protocol version: peer predates protocol versioning"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			conn, err := (&net.Dialer{}).DialContext(context.Background(), "unix", sockPath)
+			if err != nil {
+				t.Fatalf("Dial failed: %v", err)
+			}
+			defer func() { _ = conn.Close() }()
+			sendRequest(t, conn, tt.req)
+			resp := readResponse(t, conn)
+			if resp.Version != ProtocolVersion {
+				t.Errorf("response version = %d, want %d", resp.Version, ProtocolVersion)
+			}
+			if tt.wantError == "" && !resp.Success {
+				t.Errorf("request failed: %s", resp.Error)
+			}
+			if tt.wantError != "" && (resp.Success || !strings.Contains(resp.Error, tt.wantError)) {
+				t.Errorf("response = %+v, want error %q", resp, tt.wantError)
+			}
+		})
+	}
+	if calls := mock.getCalls(); len(calls) != 1 {
+		t.Errorf("ran %d command(s), want 1", len(calls))
+	}
+}
@@ -0,0 +1,71 @@
+package guardian
+
+import (
+	"sync"
+
+	"github.com/xdg/cloister/internal/guardian/actions"
+)
+
+// ProjectActionLoader loads and returns the hostexec action set for a project.
+// It should merge the project config with the global config.
+type ProjectActionLoader func(projectName string) *actions.Set
+
+// ActionCache provides per-project hostexec action lookups with caching.
+type ActionCache struct {
+	mu            sync.RWMutex
+	global        *actions.Set
+	perProject    map[string]*actions.Set
+	projectLoader ProjectActionLoader
+}
+
+// NewActionCache creates a new ActionCache with the given global action set.
+func NewActionCache(global *actions.Set) *ActionCache {
+	return &ActionCache{
+		global:     global,
+		perProject: make(map[string]*actions.Set),
+	}
+}
+
+// SetProjectLoader sets the callback for loading project action sets on-demand.
+func (c *ActionCache) SetProjectLoader(loader ProjectActionLoader) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.projectLoader = loader
+}
+
+// GetProject returns the action set for a specific project.
+// If the project is not cached and a projectLoader is set, it loads and caches the set.
+// If no loader is set or loading returns nil, returns the global action set.
+func (c *ActionCache) GetProject(projectName string) *actions.Set {
+	c.mu.RLock()
+	if set, ok := c.perProject[projectName]; ok {
+		c.mu.RUnlock()
+		return set
+	}
+	loader := c.projectLoader
+	global := c.global
+	c.mu.RUnlock()
+
+	if loader == nil {
+		return global
+	}
+
+	set := loader(projectName)
+	if set == nil {
+		return global
+	}
+
+	c.mu.Lock()
+	c.perProject[projectName] = set
+	c.mu.Unlock()
+
+	return set
+}
+
+// Clear removes all project-specific action sets.
+// The global action set is retained.
+func (c *ActionCache) Clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.perProject = make(map[string]*actions.Set)
+}
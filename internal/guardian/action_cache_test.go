@@ -0,0 +1,57 @@
+package guardian
+
+import (
+	"testing"
+
+	"github.com/xdg/cloister/internal/config"
+	"github.com/xdg/cloister/internal/guardian/actions"
+)
+
+func TestActionCache(t *testing.T) {
+	t.Run("unknown project returns global", func(t *testing.T) {
+		global := actions.NewSet([]config.HostexecAction{{Name: "global", Argv: []string{"true"}}})
+		cache := NewActionCache(global)
+
+		if cache.GetProject("unknown") != global {
+			t.Error("GetProject for unknown should return global")
+		}
+	})
+
+	t.Run("loader caching and clear", func(t *testing.T) {
+		global := actions.NewSet(nil)
+		cache := NewActionCache(global)
+
+		loadCount := 0
+		projectSet := actions.NewSet([]config.HostexecAction{{Name: "project", Argv: []string{"true"}}})
+		cache.SetProjectLoader(func(_ string) *actions.Set {
+			loadCount++
+			return projectSet
+		})
+
+		if got := cache.GetProject("my-project"); got != projectSet {
+			t.Error("GetProject should return set from loader")
+		}
+		if got := cache.GetProject("my-project"); got != projectSet {
+			t.Error("second GetProject should return cached set")
+		}
+		if loadCount != 1 {
+			t.Errorf("loader should have been called once, got %d", loadCount)
+		}
+
+		cache.Clear()
+		cache.GetProject("my-project")
+		if loadCount != 2 {
+			t.Errorf("loader should be called again after Clear, got %d calls", loadCount)
+		}
+	})
+
+	t.Run("loader returning nil falls back to global", func(t *testing.T) {
+		global := actions.NewSet(nil)
+		cache := NewActionCache(global)
+		cache.SetProjectLoader(func(_ string) *actions.Set { return nil })
+
+		if cache.GetProject("p") != global {
+			t.Error("GetProject should return global when loader returns nil")
+		}
+	})
+}
@@ -0,0 +1,285 @@
+// Package actions resolves named hostexec actions into concrete host commands.
+//
+// An action is a fixed recipe declared in config (hostexec.actions) with an
+// argv, optional workdir and env, and typed parameters. Containers invoke an
+// action by name with parameter values; the guardian validates the values
+// and substitutes them into the recipe. Approval policy attaches to the
+// action name rather than to the resulting command line.
+package actions
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/xdg/cloister/internal/clog"
+	"github.com/xdg/cloister/internal/config"
+	"github.com/xdg/cloister/internal/guardian/patterns"
+)
+
+// ErrUnknownAction is returned by Resolve when no action has the given name.
+var ErrUnknownAction = errors.New("unknown action")
+
+// placeholderRe matches {{name}} parameter references.
+var placeholderRe = regexp.MustCompile(`\{\{([^{}]*)\}\}`)
+
+// Param is a parameter name and its resolved value.
+type Param struct {
+	Name  string
+	Value string
+}
+
+// Invocation is a fully resolved action ready for approval and execution.
+type Invocation struct {
+	Name     string          // Action name
+	Params   []Param         // Resolved parameters, in declaration order
+	Argv     []string        // Command line after substitution (Argv[0] is the command)
+	Workdir  string          // Host working directory (empty = executor default)
+	Approval patterns.Action // AutoApprove or ManualApprove
+	Limits   patterns.Limits // Timeout, output cap, and env to inject
+}
+
+// Display returns a human-readable description of the invocation, e.g.
+// `action:reset-db name="foo"`. It is used in audit logs and approval UI.
+func (inv *Invocation) Display() string {
+	var b strings.Builder
+	b.WriteString(PatternName(inv.Name))
+	for _, p := range inv.Params {
+		fmt.Fprintf(&b, " %s=%q", p.Name, p.Value)
+	}
+	return b.String()
+}
+
+// PatternName returns the pseudo-pattern reported for an action, e.g.
+// "action:reset-db". It stands in for MatchResult.Pattern so responses and
+// audit records identify which action authorized a command.
+func PatternName(name string) string {
+	return "action:" + name
+}
+
+// param is a compiled parameter declaration.
+type param struct {
+	name     string
+	typ      string
+	pattern  *regexp.Regexp // Anchored form of source
+	source   string         // Pattern as written in config
+	def      string
+	required bool
+}
+
+// action is a compiled action definition.
+type action struct {
+	def    config.HostexecAction
+	params []param
+	limits patterns.Limits
+}
+
+// Set is a collection of compiled actions keyed by name.
+type Set struct {
+	actions map[string]*action
+}
+
+// NewSet compiles action definitions into a Set.
+// Invalid definitions are logged and skipped, not fatal. When names
+// collide, the first definition wins.
+func NewSet(defs []config.HostexecAction) *Set {
+	s := &Set{actions: make(map[string]*action, len(defs))}
+	for _, def := range defs {
+		if _, exists := s.actions[def.Name]; exists {
+			continue
+		}
+		a, err := compile(def)
+		if err != nil {
+			clog.Warn("invalid hostexec action %q: %v (skipped)", def.Name, err)
+			continue
+		}
+		s.actions[def.Name] = a
+	}
+	return s
+}
+
+// Len returns the number of actions in the set.
+func (s *Set) Len() int {
+	return len(s.actions)
+}
+
+// compile validates and compiles a single action definition.
+func compile(def config.HostexecAction) (*action, error) {
+	if len(def.Argv) == 0 {
+		return nil, errors.New("argv is empty")
+	}
+	a := &action{def: def}
+	for _, p := range def.Params {
+		cp := param{name: p.Name, typ: p.Type, def: p.Default, required: p.Required}
+		if cp.typ == "" {
+			cp.typ = "string"
+		}
+		if p.Pattern != "" {
+			re, err := regexp.Compile("^(?:" + p.Pattern + ")$")
+			if err != nil {
+				return nil, fmt.Errorf("param %s: invalid pattern: %w", p.Name, err)
+			}
+			cp.pattern = re
+			cp.source = p.Pattern
+		}
+		a.params = append(a.params, cp)
+	}
+	if def.Timeout != "" {
+		d, err := time.ParseDuration(def.Timeout)
+		if err != nil {
+			return nil, fmt.Errorf("invalid timeout: %w", err)
+		}
+		a.limits.Timeout = d
+	}
+	a.limits.MaxOutputBytes = def.MaxOutputBytes
+	a.limits.RequireApprovals = def.RequireApprovals
+	d, err := config.ParseApprovalTimeout(def.ApprovalTimeout)
+	if err != nil {
+		return nil, err
+	}
+	a.limits.ApprovalTimeout = d
+	return a, nil
+}
+
+// Resolve validates the supplied parameter values against the named action
+// and returns the resulting invocation. Relative workdirs are resolved
+// against baseDir (normally the cloister's host worktree path); when the
+// action has no workdir, baseDir is used. A workdir that takes parameter
+// values must stay inside baseDir.
+func (s *Set) Resolve(name string, values map[string]string, baseDir string) (*Invocation, error) {
+	a, ok := s.actions[name]
+	if !ok {
+		return nil, fmt.Errorf("%w %q", ErrUnknownAction, name)
+	}
+
+	params, err := a.bindParams(values)
+	if err != nil {
+		return nil, err
+	}
+	lookup := make(map[string]string, len(params))
+	for _, p := range params {
+		lookup[p.Name] = p.Value
+	}
+
+	inv := &Invocation{
+		Name:     name,
+		Params:   params,
+		Argv:     make([]string, len(a.def.Argv)),
+		Approval: patterns.ManualApprove,
+		Limits:   a.limits,
+	}
+	if a.def.Approve == "auto" {
+		inv.Approval = patterns.AutoApprove
+	}
+	for i, arg := range a.def.Argv {
+		inv.Argv[i] = substitute(arg, lookup)
+	}
+	if inv.Workdir, err = resolveWorkdir(a.def.Workdir, lookup, baseDir); err != nil {
+		return nil, err
+	}
+	if len(a.def.Env) > 0 {
+		inv.Limits.Env = make(map[string]string, len(a.def.Env))
+		for k, v := range a.def.Env {
+			inv.Limits.Env[k] = substitute(v, lookup)
+		}
+	}
+	return inv, nil
+}
+
+// bindParams checks values against the declared parameters, applying
+// defaults, and returns the bound parameters in declaration order.
+func (a *action) bindParams(values map[string]string) ([]Param, error) {
+	for name := range values {
+		if !a.hasParam(name) {
+			return nil, fmt.Errorf("unknown parameter %q", name)
+		}
+	}
+	params := make([]Param, 0, len(a.params))
+	for _, p := range a.params {
+		value, given := values[p.name]
+		if !given {
+			if p.required {
+				return nil, fmt.Errorf("missing required parameter %q", p.name)
+			}
+			value = p.def
+		}
+		if !given && value == "" {
+			// Optional parameter with no default: bind empty, skip type checks.
+			params = append(params, Param{Name: p.name})
+			continue
+		}
+		if err := p.check(value); err != nil {
+			return nil, err
+		}
+		params = append(params, Param{Name: p.name, Value: value})
+	}
+	return params, nil
+}
+
+// hasParam reports whether the action declares a parameter with the given name.
+func (a *action) hasParam(name string) bool {
+	for _, p := range a.params {
+		if p.name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// check validates a value against the parameter's type and pattern.
+func (p *param) check(value string) error {
+	switch p.typ {
+	case "int":
+		if _, err := strconv.ParseInt(value, 10, 64); err != nil {
+			return fmt.Errorf("parameter %q: %q is not an integer", p.name, value)
+		}
+	case "bool":
+		if _, err := strconv.ParseBool(value); err != nil {
+			return fmt.Errorf("parameter %q: %q is not a boolean", p.name, value)
+		}
+	}
+	if strings.ContainsRune(value, 0) {
+		return fmt.Errorf("parameter %q: value contains NUL byte", p.name)
+	}
+	if p.pattern != nil && !p.pattern.MatchString(value) {
+		return fmt.Errorf("parameter %q: %q does not match %q", p.name, value, p.source)
+	}
+	return nil
+}
+
+// substitute replaces {{name}} references in s with parameter values.
+// References to unknown names are replaced with the empty string.
+func substitute(s string, values map[string]string) string {
+	return placeholderRe.ReplaceAllStringFunc(s, func(m string) string {
+		return values[m[2:len(m)-2]]
+	})
+}
+
+// resolveWorkdir substitutes parameter values into the workdir template and
+// resolves the result against baseDir. Workdirs fixed in config may point
+// anywhere, but one built from parameter values is rejected unless it is
+// relative and stays inside baseDir, so that a value such as "../.." cannot
+// move an approved action elsewhere on the host.
+func resolveWorkdir(template string, values map[string]string, baseDir string) (string, error) {
+	workdir := substitute(template, values)
+	if workdir == "" {
+		return baseDir, nil
+	}
+	if !placeholderRe.MatchString(template) {
+		if filepath.IsAbs(workdir) || baseDir == "" {
+			return workdir, nil
+		}
+		return filepath.Join(baseDir, workdir), nil
+	}
+	if filepath.IsAbs(workdir) || !filepath.IsLocal(workdir) {
+		return "", fmt.Errorf("workdir %q is outside the worktree", workdir)
+	}
+	if baseDir == "" {
+		return filepath.Clean(workdir), nil
+	}
+	return filepath.Join(baseDir, workdir), nil
+}
@@ -0,0 +1,219 @@
+package actions
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/xdg/cloister/internal/clog"
+	"github.com/xdg/cloister/internal/config"
+	"github.com/xdg/cloister/internal/guardian/patterns"
+)
+
+func resetDBAction() config.HostexecAction {
+	return config.HostexecAction{
+		Name:    "reset-db",
+		Argv:    []string{"./scripts/reset-db.sh", "--name", "{{name}}", "--seed={{seed}}"},
+		Workdir: "db",
+		Env:     map[string]string{"DB_NAME": "dev_{{name}}"},
+		Params: []config.ActionParam{
+			{Name: "name", Pattern: "[a-z][a-z0-9_]*", Required: true},
+			{Name: "seed", Type: "bool", Default: "false"},
+		},
+		Timeout:          "2m",
+		MaxOutputBytes:   4096,
+		RequireApprovals: 2,
+		ApprovalTimeout:  "30m",
+	}
+}
+
+func TestSetResolve_Substitution(t *testing.T) {
+	s := NewSet([]config.HostexecAction{resetDBAction()})
+
+	inv, err := s.Resolve("reset-db", map[string]string{"name": "foo"}, "/home/dev/proj")
+	if err != nil {
+		t.Fatalf("Resolve() error = %v", err)
+	}
+
+	wantArgv := []string{"./scripts/reset-db.sh", "--name", "foo", "--seed=false"}
+	if strings.Join(inv.Argv, "\x00") != strings.Join(wantArgv, "\x00") {
+		t.Errorf("Argv = %q, want %q", inv.Argv, wantArgv)
+	}
+	if inv.Workdir != "/home/dev/proj/db" {
+		t.Errorf("Workdir = %q, want %q", inv.Workdir, "/home/dev/proj/db")
+	}
+	if inv.Limits.Env["DB_NAME"] != "dev_foo" {
+		t.Errorf("Env[DB_NAME] = %q, want %q", inv.Limits.Env["DB_NAME"], "dev_foo")
+	}
+	if inv.Limits.Timeout != 2*time.Minute {
+		t.Errorf("Timeout = %v, want 2m", inv.Limits.Timeout)
+	}
+	if inv.Limits.MaxOutputBytes != 4096 {
+		t.Errorf("MaxOutputBytes = %d, want 4096", inv.Limits.MaxOutputBytes)
+	}
+	if inv.Limits.RequireApprovals != 2 {
+		t.Errorf("RequireApprovals = %d, want 2", inv.Limits.RequireApprovals)
+	}
+	if inv.Limits.ApprovalTimeout != 30*time.Minute {
+		t.Errorf("ApprovalTimeout = %v, want 30m", inv.Limits.ApprovalTimeout)
+	}
+	if inv.Approval != patterns.ManualApprove {
+		t.Errorf("Approval = %v, want ManualApprove", inv.Approval)
+	}
+	if got, want := inv.Display(), `action:reset-db name="foo" seed="false"`; got != want {
+		t.Errorf("Display() = %q, want %q", got, want)
+	}
+}
+
+func TestSetResolve_AutoApprove(t *testing.T) {
+	s := NewSet([]config.HostexecAction{
+		{Name: "api-restart", Argv: []string{"docker", "compose", "restart", "api"}, Approve: "auto"},
+	})
+
+	inv, err := s.Resolve("api-restart", nil, "")
+	if err != nil {
+		t.Fatalf("Resolve() error = %v", err)
+	}
+	if inv.Approval != patterns.AutoApprove {
+		t.Errorf("Approval = %v, want AutoApprove", inv.Approval)
+	}
+	if inv.Workdir != "" {
+		t.Errorf("Workdir = %q, want empty", inv.Workdir)
+	}
+}
+
+func TestSetResolve_WorkdirDefaultsToBaseDir(t *testing.T) {
+	s := NewSet([]config.HostexecAction{{Name: "ls", Argv: []string{"ls"}}})
+
+	inv, err := s.Resolve("ls", nil, "/work/tree")
+	if err != nil {
+		t.Fatalf("Resolve() error = %v", err)
+	}
+	if inv.Workdir != "/work/tree" {
+		t.Errorf("Workdir = %q, want %q", inv.Workdir, "/work/tree")
+	}
+}
+
+func TestSetResolve_WorkdirParamStaysInBaseDir(t *testing.T) {
+	s := NewSet([]config.HostexecAction{{
+		Name:    "build",
+		Argv:    []string{"make"},
+		Workdir: "pkg/{{dir}}",
+		Params:  []config.ActionParam{{Name: "dir", Required: true}},
+	}})
+
+	inv, err := s.Resolve("build", map[string]string{"dir": "api/../web"}, "/work/tree")
+	if err != nil {
+		t.Fatalf("Resolve() error = %v", err)
+	}
+	if inv.Workdir != "/work/tree/pkg/web" {
+		t.Errorf("Workdir = %q, want %q", inv.Workdir, "/work/tree/pkg/web")
+	}
+
+	for _, dir := range []string{"../..", "../../../etc", "x/../../../.."} {
+		if inv, err := s.Resolve("build", map[string]string{"dir": dir}, "/work/tree"); err == nil {
+			t.Errorf("Resolve(dir=%q) = %q, want error", dir, inv.Workdir)
+		}
+	}
+
+	abs := NewSet([]config.HostexecAction{{
+		Name:    "build",
+		Argv:    []string{"make"},
+		Workdir: "{{dir}}",
+		Params:  []config.ActionParam{{Name: "dir", Required: true}},
+	}})
+	if inv, err := abs.Resolve("build", map[string]string{"dir": "/etc"}, "/work/tree"); err == nil {
+		t.Errorf("Resolve(dir=/etc) = %q, want error", inv.Workdir)
+	}
+}
+
+func TestSetResolve_Errors(t *testing.T) {
+	s := NewSet([]config.HostexecAction{
+		resetDBAction(),
+		{
+			Name:   "scale",
+			Argv:   []string{"kubectl", "scale", "--replicas={{n}}"},
+			Params: []config.ActionParam{{Name: "n", Type: "int"}},
+		},
+	})
+
+	tests := []struct {
+		name    string
+		action  string
+		params  map[string]string
+		wantErr string
+	}{
+		{"unknown action", "nope", nil, "unknown action"},
+		{"missing required", "reset-db", nil, `missing required parameter "name"`},
+		{"unknown param", "reset-db", map[string]string{"name": "foo", "extra": "x"}, `unknown parameter "extra"`},
+		{"pattern mismatch", "reset-db", map[string]string{"name": "Foo; rm -rf /"}, "does not match"},
+		{"bad bool", "reset-db", map[string]string{"name": "foo", "seed": "maybe"}, "is not a boolean"},
+		{"bad int", "scale", map[string]string{"n": "three"}, "is not an integer"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := s.Resolve(tt.action, tt.params, "")
+			if err == nil {
+				t.Fatal("Resolve() expected error, got nil")
+			}
+			if !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("error = %q, want to contain %q", err.Error(), tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSetResolve_UnknownActionIsSentinel(t *testing.T) {
+	s := NewSet(nil)
+
+	_, err := s.Resolve("missing", nil, "")
+	if !errors.Is(err, ErrUnknownAction) {
+		t.Errorf("error = %v, want ErrUnknownAction", err)
+	}
+}
+
+func TestSetResolve_OptionalParamWithoutDefault(t *testing.T) {
+	s := NewSet([]config.HostexecAction{{
+		Name:   "scale",
+		Argv:   []string{"scale", "{{n}}"},
+		Params: []config.ActionParam{{Name: "n", Type: "int"}},
+	}})
+
+	inv, err := s.Resolve("scale", nil, "")
+	if err != nil {
+		t.Fatalf("Resolve() error = %v", err)
+	}
+	if inv.Argv[1] != "" {
+		t.Errorf("Argv[1] = %q, want empty", inv.Argv[1])
+	}
+}
+
+func TestNewSet_SkipsInvalid(t *testing.T) {
+	var buf bytes.Buffer
+	old := clog.ReplaceGlobal(clog.TestLogger(&buf))
+	defer clog.ReplaceGlobal(old)
+
+	s := NewSet([]config.HostexecAction{
+		{Name: "empty"},
+		{Name: "badre", Argv: []string{"x"}, Params: []config.ActionParam{{Name: "p", Pattern: "("}}},
+		{Name: "ok", Argv: []string{"true"}},
+		{Name: "ok", Argv: []string{"false"}}, // duplicate: first wins
+	})
+
+	if s.Len() != 1 {
+		t.Errorf("Len() = %d, want 1", s.Len())
+	}
+	inv, err := s.Resolve("ok", nil, "")
+	if err != nil {
+		t.Fatalf("Resolve() error = %v", err)
+	}
+	if inv.Argv[0] != "true" {
+		t.Errorf("Argv[0] = %q, want %q (first definition should win)", inv.Argv[0], "true")
+	}
+	if !strings.Contains(buf.String(), "invalid hostexec action") {
+		t.Errorf("expected warning about invalid action, got %q", buf.String())
+	}
+}
@@ -4,6 +4,7 @@ package guardian
 
 import (
 	"context"
+	"crypto/ed25519"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -29,6 +30,7 @@ type TokenRegistry interface {
 	RegisterWithProject(token, cloisterName, projectName string)
 	RegisterFull(token, cloisterName, projectName, worktreePath string)
 	Revoke(token string) bool
+	Lookup(token string) (token.Info, bool)
 	List() map[string]token.Info
 	Count() int
 }
@@ -51,6 +53,27 @@ type APIServer struct {
 	// the project's policy eagerly.
 	OnTokenRegistered func(projectName string)
 
+	// OnTokenRevoked is called after a token is revoked with the token's
+	// registration, so per-cloister resources can be released.
+	OnTokenRevoked func(info token.Info)
+
+	// Sessions tracks each cloister from token registration to revocation.
+	// If set, the response to a revocation includes the session's report.
+	Sessions SessionTracker
+
+	// Metrics serves GET /metrics in the Prometheus text format. If nil,
+	// the endpoint is not registered.
+	Metrics http.Handler
+
+	// Health serves GET /healthz and GET /readyz. If nil, the endpoints
+	// are not registered.
+	Health *Health
+
+	// AuditPublicKey verifies the signatures sealing the audit log, and is
+	// served by GET /audit/key. If nil, the log is not sealed and the
+	// endpoint is not registered.
+	AuditPublicKey ed25519.PublicKey
+
 	server   *http.Server
 	listener net.Listener
 	mu       sync.Mutex
@@ -87,6 +110,17 @@ func (a *APIServer) Start() error {
 	mux := http.NewServeMux()
 	mux.HandleFunc("/tokens", a.handleTokens)
 	mux.HandleFunc("/tokens/{token}", a.handleRevokeToken)
+	mux.HandleFunc("GET /version", a.handleVersion)
+	if a.Metrics != nil {
+		mux.Handle("GET /metrics", a.Metrics)
+	}
+	if a.Health != nil {
+		mux.HandleFunc("GET /healthz", a.Health.handleHealthz)
+		mux.HandleFunc("GET /readyz", a.Health.handleReadyz)
+	}
+	if a.AuditPublicKey != nil {
+		mux.HandleFunc("GET /audit/key", a.handleAuditKey)
+	}
 
 	a.listener = listener
 	a.server = &http.Server{
@@ -159,6 +193,12 @@ type statusResponse struct {
 	Status string `json:"status"`
 }
 
+// revokeTokenResponse is the response body for DELETE /tokens/{token}.
+type revokeTokenResponse struct {
+	Status string         `json:"status"`
+	Report *SessionReport `json:"report,omitempty"`
+}
+
 // errorResponse is an error response.
 type errorResponse struct {
 	Error string `json:"error"`
@@ -195,6 +235,9 @@ func (a *APIServer) handleRegisterToken(w http.ResponseWriter, r *http.Request)
 	}
 
 	a.Registry.RegisterFull(req.Token, req.Cloister, req.Project, req.Worktree)
+	if a.Sessions != nil {
+		a.Sessions.Start(token.Info{CloisterName: req.Cloister, ProjectName: req.Project, WorktreePath: req.Worktree})
+	}
 
 	if req.Project != "" && a.OnTokenRegistered != nil {
 		a.OnTokenRegistered(req.Project)
@@ -220,17 +263,25 @@ func (a *APIServer) handleRevokeToken(w http.ResponseWriter, r *http.Request) {
 	// (tokens are hex-encoded so this shouldn't be necessary, but be safe)
 	tok = strings.TrimSpace(tok)
 
+	info, _ := a.Registry.Lookup(tok)
 	if !a.Registry.Revoke(tok) {
 		a.writeError(w, http.StatusNotFound, "token not found")
 		return
 	}
+	if a.OnTokenRevoked != nil {
+		a.OnTokenRevoked(info)
+	}
 
 	// Clear session-level policy state for this token to prevent memory leak
 	if a.TokenRevoker != nil {
 		a.TokenRevoker.RevokeToken(tok)
 	}
 
-	a.writeJSON(w, http.StatusOK, statusResponse{Status: "revoked"})
+	resp := revokeTokenResponse{Status: "revoked"}
+	if a.Sessions != nil {
+		resp.Report = a.Sessions.End(info)
+	}
+	a.writeJSON(w, http.StatusOK, resp)
 }
 
 // handleListTokens handles GET /tokens requests.
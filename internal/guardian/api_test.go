@@ -4,8 +4,10 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"io"
 	"maps"
 	"net/http"
+	"strings"
 	"testing"
 	"time"
 
@@ -50,6 +52,11 @@ func (r *mockRegistry) Revoke(tok string) bool {
 	return false
 }
 
+func (r *mockRegistry) Lookup(tok string) (token.Info, bool) {
+	info, ok := r.tokens[tok]
+	return info, ok
+}
+
 func (r *mockRegistry) List() map[string]token.Info {
 	result := make(map[string]token.Info, len(r.tokens))
 	maps.Copy(result, r.tokens)
@@ -395,6 +402,8 @@ func TestAPIServer_RevokeTokenClearsSessionPolicy(t *testing.T) {
 
 	api := NewAPIServer(":0", registry)
 	api.TokenRevoker = revoker
+	var revokedCloister string
+	api.OnTokenRevoked = func(info token.Info) { revokedCloister = info.CloisterName }
 
 	if err := api.Start(); err != nil {
 		t.Fatalf("failed to start API server: %v", err)
@@ -436,6 +445,9 @@ func TestAPIServer_RevokeTokenClearsSessionPolicy(t *testing.T) {
 	if len(revoker.revoked) > 0 && revoker.revoked[0] != "test-token" {
 		t.Errorf("expected revoked token 'test-token', got %q", revoker.revoked[0])
 	}
+	if revokedCloister != "test-cloister" {
+		t.Errorf("OnTokenRevoked cloister = %q, want %q", revokedCloister, "test-cloister")
+	}
 }
 
 func TestAPIServer_RevokeTokenWithNilTokenRevoker(t *testing.T) {
@@ -544,3 +556,34 @@ func TestAPIServer_OnTokenRegistered(t *testing.T) {
 		t.Errorf("expected callback count 0 for no-project registration, got %d", callbackCount)
 	}
 }
+
+func TestAPIServer_Metrics(t *testing.T) {
+	m := NewMetrics()
+	m.WatchTokens(func() int { return 4 })
+
+	api := NewAPIServer(":0", newMockRegistry())
+	api.Metrics = m.Handler()
+	if err := api.Start(); err != nil {
+		t.Fatalf("failed to start API server: %v", err)
+	}
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = api.Stop(ctx)
+	}()
+
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, "http://"+api.ListenAddr()+"/metrics", http.NoBody)
+	if err != nil {
+		t.Fatalf("failed to create request: %v", err)
+	}
+	resp, err := noProxyClient().Do(req)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK || !strings.Contains(string(body), "cloister_active_tokens 4\n") {
+		t.Errorf("GET /metrics = %d:\n%s", resp.StatusCode, body)
+	}
+}
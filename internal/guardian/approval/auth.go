@@ -0,0 +1,298 @@
+package approval
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// Approval UI authentication.
+//
+// The guardian is started with a per-instance secret. A browser logs in once
+// by opening the tokenized URL (/?token=<secret>), which sets an HttpOnly,
+// SameSite=Strict session cookie and redirects to the bare URL. Command-line
+// clients send the secret as a Bearer token instead.
+//
+// State-changing requests (POST) from a browser session must also carry the
+// per-instance CSRF token in the CSRFHeader header, which the index page
+// embeds for its own scripts. All requests must name a loopback Host, which
+// defeats DNS rebinding, and POSTs with an Origin header must be same-origin.
+//
+// Each configured approver has a random credential of their own, generated
+// independently of the secret, that works the same way. Decisions are
+// recorded under the identity of the credential used: the approver's name, or
+// the guardian owner's for the instance secret.
+const (
+	// TokenParam is the query parameter carrying the secret in a login URL.
+	TokenParam = "token" //nolint:gosec // G101: not a credential
+
+	// CSRFHeader carries the CSRF token on POSTs from the browser UI.
+	CSRFHeader = "X-Cloister-CSRF"
+
+	// sessionCookiePrefix is suffixed with a per-instance ID so that several
+	// guardians on one host (distinguished only by port) keep separate
+	// sessions; browsers do not scope cookies by port.
+	sessionCookiePrefix = "cloister_session_"
+)
+
+// principal is one identity that can sign in to the approval server.
+type principal struct {
+	name    string
+	token   string // Login URL token and Bearer credential
+	session string // Session cookie value issued after login
+}
+
+// sessionAuth holds the credentials for one guardian instance.
+type sessionAuth struct {
+	principals []principal // The owner first, then named approvers
+	csrf       string      // Required in CSRFHeader on cookie-authenticated POSTs
+	cookieName string
+}
+
+// newSessionAuth derives fresh session and CSRF tokens for secret, signing
+// in as owner.
+func newSessionAuth(secret, owner string) (*sessionAuth, error) {
+	csrf, err := randomHex(32)
+	if err != nil {
+		return nil, err
+	}
+	id, err := generateID()
+	if err != nil {
+		return nil, err
+	}
+	a := &sessionAuth{
+		csrf:       csrf,
+		cookieName: sessionCookiePrefix + id,
+	}
+	if err := a.addPrincipal(owner, secret); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// addPrincipal registers an identity signing in with token.
+func (a *sessionAuth) addPrincipal(name, token string) error {
+	session, err := randomHex(32)
+	if err != nil {
+		return err
+	}
+	a.principals = append(a.principals, principal{name: name, token: token, session: session})
+	return nil
+}
+
+// byToken returns the principal whose token matches tok.
+func (a *sessionAuth) byToken(tok string) (principal, bool) {
+	for _, p := range a.principals {
+		if secretEqual(tok, p.token) {
+			return p, true
+		}
+	}
+	return principal{}, false
+}
+
+// randomHex returns n random bytes, hex-encoded.
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("generate random token: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// SetSecret enables authentication with the given per-instance secret.
+// Requests made with the secret are attributed to the owner (see SetOwner).
+// Without a secret the server accepts all requests, which is only
+// appropriate for tests.
+func (s *Server) SetSecret(secret string) error {
+	auth, err := newSessionAuth(secret, s.userIdentity)
+	if err != nil {
+		return err
+	}
+	s.auth = auth
+	return nil
+}
+
+// SetOwner sets the identity recorded for decisions made with the instance
+// secret, normally the host user who started the guardian. Call it before
+// SetSecret.
+func (s *Server) SetOwner(name string) {
+	if name != "" {
+		s.userIdentity = name
+	}
+}
+
+// SetApprovers gives each named approver the credential in tokens, keyed by
+// name. Tokens must be distinct from each other and from the secret.
+// SetSecret must be called first.
+func (s *Server) SetApprovers(tokens map[string]string) error {
+	if s.auth == nil {
+		return fmt.Errorf("approvers require a secret")
+	}
+	names := make([]string, 0, len(tokens))
+	for name := range tokens {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		tok := tokens[name]
+		if name == s.userIdentity {
+			return fmt.Errorf("approver %q has the same name as the owner", name)
+		}
+		if tok == "" {
+			return fmt.Errorf("approver %q has no credential", name)
+		}
+		if _, ok := s.auth.byToken(tok); ok {
+			return fmt.Errorf("approver %q reuses another credential", name)
+		}
+		if err := s.auth.addPrincipal(name, tok); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// identityKey is the context key for the authenticated identity.
+type identityKey struct{}
+
+// actor returns the identity that made r, for recording decisions.
+func (s *Server) actor(r *http.Request) string {
+	if name, ok := r.Context().Value(identityKey{}).(string); ok {
+		return name
+	}
+	return s.userIdentity
+}
+
+// csrfToken returns the CSRF token to embed in the UI, or "" if
+// authentication is disabled.
+func (s *Server) csrfToken() string {
+	if s.auth == nil {
+		return ""
+	}
+	return s.auth.csrf
+}
+
+// requireAuth wraps h with host, session, origin, and CSRF checks.
+// It is a no-op when no secret has been set.
+func (s *Server) requireAuth(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		a := s.auth
+		if a == nil {
+			h.ServeHTTP(w, r)
+			return
+		}
+		if !isLoopbackHost(r.Host) {
+			http.Error(w, "invalid host", http.StatusMisdirectedRequest)
+			return
+		}
+		if r.Method == http.MethodGet && r.URL.Query().Has(TokenParam) {
+			a.login(w, r)
+			return
+		}
+
+		p, viaBearer := a.bearer(r)
+		if !viaBearer {
+			var ok bool
+			if p, ok = a.session(r); !ok {
+				http.Error(w, "unauthorized: open the approval URL shown by 'cloister guardian status'", http.StatusUnauthorized)
+				return
+			}
+		}
+
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			if !sameOrigin(r) {
+				http.Error(w, "cross-origin request rejected", http.StatusForbidden)
+				return
+			}
+			// Browsers cannot attach an Authorization header cross-site
+			// without a CORS preflight, which this server never grants.
+			if !viaBearer && !secretEqual(r.Header.Get(CSRFHeader), a.csrf) {
+				http.Error(w, "missing or invalid CSRF token", http.StatusForbidden)
+				return
+			}
+		}
+		h.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), identityKey{}, p.name)))
+	})
+}
+
+// login exchanges a valid ?token= for the principal's session cookie and
+// redirects to the same path without the token, so it does not linger in
+// history.
+func (a *sessionAuth) login(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	p, ok := a.byToken(q.Get(TokenParam))
+	if !ok {
+		http.Error(w, "invalid token", http.StatusUnauthorized)
+		return
+	}
+	http.SetCookie(w, &http.Cookie{
+		Name:     a.cookieName,
+		Value:    p.session,
+		Path:     "/",
+		HttpOnly: true,
+		SameSite: http.SameSiteStrictMode,
+	})
+	q.Del(TokenParam)
+	target := url.URL{Path: r.URL.Path, RawQuery: q.Encode()}
+	http.Redirect(w, r, target.String(), http.StatusSeeOther)
+}
+
+// session returns the principal whose session cookie the request carries.
+func (a *sessionAuth) session(r *http.Request) (principal, bool) {
+	c, err := r.Cookie(a.cookieName)
+	if err != nil {
+		return principal{}, false
+	}
+	for _, p := range a.principals {
+		if secretEqual(c.Value, p.session) {
+			return p, true
+		}
+	}
+	return principal{}, false
+}
+
+// bearer returns the principal whose token the request carries as a Bearer
+// token.
+func (a *sessionAuth) bearer(r *http.Request) (principal, bool) {
+	tok, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok {
+		return principal{}, false
+	}
+	return a.byToken(tok)
+}
+
+// sameOrigin reports whether the request's Origin header, if any, matches
+// its Host. Requests without an Origin (non-browser clients) pass.
+func sameOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host == r.Host
+}
+
+// isLoopbackHost reports whether a Host header names the local machine.
+func isLoopbackHost(hostport string) bool {
+	host := hostport
+	if h, _, err := net.SplitHostPort(hostport); err == nil {
+		host = h
+	}
+	host = strings.Trim(host, "[]")
+	if strings.EqualFold(host, "localhost") {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// secretEqual compares two secrets in constant time.
+func secretEqual(got, want string) bool {
+	return want != "" && subtle.ConstantTimeCompare([]byte(got), []byte(want)) == 1
+}
@@ -0,0 +1,290 @@
+package approval
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/xdg/cloister/internal/audit"
+)
+
+// newAuthTestServer returns a server with authentication enabled and a
+// handler that replies 200 behind requireAuth.
+func newAuthTestServer(t *testing.T) (*Server, http.Handler) {
+	t.Helper()
+	s := NewServer(NewQueue(), nil)
+	if err := s.SetSecret("approval-secret"); err != nil {
+		t.Fatalf("SetSecret() error = %v", err)
+	}
+	ok := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	return s, s.requireAuth(ok)
+}
+
+// loginCookie performs the tokenized-URL login and returns the session cookie.
+func loginCookie(t *testing.T, h http.Handler) *http.Cookie {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, "/?token=approval-secret", http.NoBody)
+	req.Host = "localhost:9999"
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusSeeOther {
+		t.Fatalf("login status = %d, want %d", rec.Code, http.StatusSeeOther)
+	}
+	if loc := rec.Header().Get("Location"); loc != "/" {
+		t.Errorf("login redirect = %q, want %q", loc, "/")
+	}
+	cookies := rec.Result().Cookies()
+	if len(cookies) != 1 {
+		t.Fatalf("login set %d cookies, want 1", len(cookies))
+	}
+	c := cookies[0]
+	if !c.HttpOnly || c.SameSite != http.SameSiteStrictMode {
+		t.Errorf("cookie HttpOnly=%v SameSite=%v, want HttpOnly and Strict", c.HttpOnly, c.SameSite)
+	}
+	return c
+}
+
+func TestRequireAuth(t *testing.T) {
+	s, h := newAuthTestServer(t)
+	cookie := loginCookie(t, h)
+
+	tests := []struct {
+		name   string
+		method string
+		host   string
+		cookie bool
+		bearer string
+		csrf   string
+		origin string
+		want   int
+	}{
+		{name: "no credentials", method: http.MethodGet, want: http.StatusUnauthorized},
+		{name: "session GET", method: http.MethodGet, cookie: true, want: http.StatusOK},
+		{name: "bearer GET", method: http.MethodGet, bearer: "approval-secret", want: http.StatusOK},
+		{name: "wrong bearer", method: http.MethodGet, bearer: "nope", want: http.StatusUnauthorized},
+		{name: "foreign host", method: http.MethodGet, host: "evil.example:9999", cookie: true, want: http.StatusMisdirectedRequest},
+		{name: "ipv6 loopback host", method: http.MethodGet, host: "[::1]:9999", cookie: true, want: http.StatusOK},
+		{name: "session POST without CSRF", method: http.MethodPost, cookie: true, want: http.StatusForbidden},
+		{name: "session POST wrong CSRF", method: http.MethodPost, cookie: true, csrf: "nope", want: http.StatusForbidden},
+		{name: "session POST with CSRF", method: http.MethodPost, cookie: true, csrf: s.csrfToken(), origin: "http://localhost:9999", want: http.StatusOK},
+		{name: "session POST cross-origin", method: http.MethodPost, cookie: true, csrf: s.csrfToken(), origin: "https://evil.example", want: http.StatusForbidden},
+		{name: "bearer POST without CSRF", method: http.MethodPost, bearer: "approval-secret", want: http.StatusOK},
+		{name: "bearer POST cross-origin", method: http.MethodPost, bearer: "approval-secret", origin: "http://evil.example", want: http.StatusForbidden},
+		{name: "POST without credentials", method: http.MethodPost, csrf: s.csrfToken(), want: http.StatusUnauthorized},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(tc.method, "/approve/abc", http.NoBody)
+			req.Host = "localhost:9999"
+			if tc.host != "" {
+				req.Host = tc.host
+			}
+			if tc.cookie {
+				req.AddCookie(cookie)
+			}
+			if tc.bearer != "" {
+				req.Header.Set("Authorization", "Bearer "+tc.bearer)
+			}
+			if tc.csrf != "" {
+				req.Header.Set(CSRFHeader, tc.csrf)
+			}
+			if tc.origin != "" {
+				req.Header.Set("Origin", tc.origin)
+			}
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+			if rec.Code != tc.want {
+				t.Errorf("status = %d, want %d (body %q)", rec.Code, tc.want, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestRequireAuth_LoginWrongToken(t *testing.T) {
+	_, h := newAuthTestServer(t)
+	req := httptest.NewRequest(http.MethodGet, "/?token=wrong", http.NoBody)
+	req.Host = "127.0.0.1:9999"
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusUnauthorized)
+	}
+	if len(rec.Result().Cookies()) != 0 {
+		t.Error("wrong token should not set a session cookie")
+	}
+}
+
+func TestRequireAuth_DisabledWithoutSecret(t *testing.T) {
+	s := NewServer(NewQueue(), nil)
+	h := s.requireAuth(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	req := httptest.NewRequest(http.MethodPost, "/approve/abc", http.NoBody)
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusOK)
+	}
+}
+
+func TestHandleIndex_EmbedsCSRFToken(t *testing.T) {
+	s, _ := newAuthTestServer(t)
+	rec := httptest.NewRecorder()
+	s.handleIndex(rec, httptest.NewRequest(http.MethodGet, "/", http.NoBody))
+
+	want := `<meta name="cloister-csrf" content="` + s.csrfToken() + `">`
+	if !strings.Contains(rec.Body.String(), want) {
+		t.Errorf("index page missing CSRF meta tag %q", want)
+	}
+}
+
+func TestIsLoopbackHost(t *testing.T) {
+	tests := map[string]bool{
+		"localhost":          true,
+		"LOCALHOST:9999":     true,
+		"127.0.0.1:9999":     true,
+		"127.0.0.2":          true,
+		"[::1]:9999":         true,
+		"evil.example:9999":  false,
+		"localhost.evil.com": false,
+		"10.0.0.1:9999":      false,
+		"":                   false,
+	}
+	for host, want := range tests {
+		if got := isLoopbackHost(host); got != want {
+			t.Errorf("isLoopbackHost(%q) = %v, want %v", host, got, want)
+		}
+	}
+}
+
+func TestRequireAuth_ApproverIdentity(t *testing.T) {
+	var auditBuf bytes.Buffer
+	queue := NewQueue()
+	s := NewServer(queue, audit.NewLogger(&auditBuf))
+	s.SetOwner("owner")
+	if err := s.SetSecret("approval-secret"); err != nil {
+		t.Fatalf("SetSecret() error = %v", err)
+	}
+	if err := s.SetApprovers(map[string]string{"alice": "alice-secret"}); err != nil {
+		t.Fatalf("SetApprovers() error = %v", err)
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /approve/{id}", s.handleApprove)
+	h := s.requireAuth(mux)
+
+	respChan := make(chan Response, 1)
+	id, err := queue.Add(&PendingRequest{Project: "p", Cloister: "c", Cmd: "make deploy", Response: respChan, RequireApprovals: 2})
+	if err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+
+	approve := func(bearer string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/approve/"+id, http.NoBody)
+		req.Host = "localhost:9999"
+		req.Header.Set("Authorization", "Bearer "+bearer)
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := approve("alice-secret"); !strings.Contains(rec.Body.String(), `"status":"pending"`) {
+		t.Fatalf("first approval = %d %q, want pending", rec.Code, rec.Body.String())
+	}
+	if rec := approve("alice-secret"); rec.Code != http.StatusConflict {
+		t.Errorf("repeat approval status = %d, want %d", rec.Code, http.StatusConflict)
+	}
+	if rec := approve("mallory-secret"); rec.Code != http.StatusUnauthorized {
+		t.Errorf("unknown approver status = %d, want %d", rec.Code, http.StatusUnauthorized)
+	}
+	if rec := approve("approval-secret"); !strings.Contains(rec.Body.String(), `"status":"approved"`) {
+		t.Fatalf("owner approval = %d %q, want approved", rec.Code, rec.Body.String())
+	}
+
+	if got := (<-respChan).Status; got != "approved" {
+		t.Errorf("response status = %q, want approved", got)
+	}
+	if !strings.Contains(auditBuf.String(), `user="alice,owner"`) {
+		t.Errorf("audit log = %q, want user=\"alice,owner\"", auditBuf.String())
+	}
+}
+
+func TestRequireAuth_DomainQuorumSameScope(t *testing.T) {
+	s := NewServer(NewQueue(), nil)
+	s.DomainQueue = NewDomainQueue()
+	s.SetOwner("owner")
+	if err := s.SetSecret("approval-secret"); err != nil {
+		t.Fatalf("SetSecret() error = %v", err)
+	}
+	if err := s.SetApprovers(map[string]string{"alice": "alice-secret"}); err != nil {
+		t.Fatalf("SetApprovers() error = %v", err)
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /approve-domain/{id}", s.handleApproveDomain)
+	h := s.requireAuth(mux)
+
+	respChan := make(chan DomainResponse, 1)
+	id, err := s.DomainQueue.Add(&DomainRequest{Project: "p", Cloister: "c", Domain: "api.example.com", Token: "tok",
+		Responses: []chan<- DomainResponse{respChan}, RequireApprovals: 2})
+	if err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+
+	approve := func(bearer, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/approve-domain/"+id, strings.NewReader(body))
+		req.Host = "localhost:9999"
+		req.Header.Set("Authorization", "Bearer "+bearer)
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := approve("alice-secret", `{"scope":"once"}`); !strings.Contains(rec.Body.String(), `"status":"pending"`) {
+		t.Fatalf("first approval = %d %q, want pending", rec.Code, rec.Body.String())
+	}
+	if rec := approve("approval-secret", `{"scope":"session","pattern":"*.example.com"}`); rec.Code != http.StatusConflict {
+		t.Errorf("broader approval status = %d, want %d", rec.Code, http.StatusConflict)
+	}
+	if rec := approve("approval-secret", `{"scope":"once"}`); !strings.Contains(rec.Body.String(), `"status":"approved"`) {
+		t.Fatalf("matching approval = %d %q, want approved", rec.Code, rec.Body.String())
+	}
+	if resp := <-respChan; resp.Scope != "once" || resp.Pattern != "" {
+		t.Errorf("response = %+v, want scope once without a pattern", resp)
+	}
+}
+
+func TestSetApprovers_OwnerName(t *testing.T) {
+	s := NewServer(NewQueue(), nil)
+	s.SetOwner("alice")
+	if err := s.SetSecret("approval-secret"); err != nil {
+		t.Fatalf("SetSecret() error = %v", err)
+	}
+	if err := s.SetApprovers(map[string]string{"alice": "alice-secret"}); err == nil {
+		t.Error("SetApprovers() should reject an approver named like the owner")
+	}
+}
+
+func TestSetApprovers_RejectsSharedCredentials(t *testing.T) {
+	tests := map[string]map[string]string{
+		"empty":        {"alice": ""},
+		"owner secret": {"alice": "approval-secret"},
+		"shared":       {"alice": "same", "bob": "same"},
+	}
+	for name, tokens := range tests {
+		t.Run(name, func(t *testing.T) {
+			s := NewServer(NewQueue(), nil)
+			if err := s.SetSecret("approval-secret"); err != nil {
+				t.Fatalf("SetSecret() error = %v", err)
+			}
+			if err := s.SetApprovers(tokens); err == nil {
+				t.Errorf("SetApprovers(%v) should fail", tokens)
+			}
+		})
+	}
+}
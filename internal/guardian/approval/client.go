@@ -0,0 +1,207 @@
+package approval
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// ErrNotPending is returned when a request ID is not in either queue,
+// typically because it was already decided or timed out.
+var ErrNotPending = errors.New("request is not pending")
+
+// Client talks to the approval server from the host, authenticating with
+// the per-instance secret as a Bearer token.
+type Client struct {
+	// BaseURL is the approval server URL (e.g., "http://localhost:9999").
+	BaseURL string
+
+	// Secret is the approval secret. If empty, no Authorization is sent.
+	Secret string
+
+	// HTTPClient is used for regular requests. If nil, a client with a
+	// 10-second timeout is used. Event streams never time out.
+	HTTPClient *http.Client
+}
+
+// NewClient creates a client for the approval server at addr (host:port).
+func NewClient(addr, secret string) *Client {
+	return &Client{
+		BaseURL:    "http://" + addr,
+		Secret:     secret,
+		HTTPClient: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Pending returns the pending hostexec requests.
+func (c *Client) Pending(ctx context.Context) ([]PendingCommand, error) {
+	var resp pendingResponse
+	if err := c.do(ctx, http.MethodGet, "/pending", nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Requests, nil
+}
+
+// PendingDomains returns the pending domain requests.
+func (c *Client) PendingDomains(ctx context.Context) ([]PendingDomain, error) {
+	var resp pendingDomainsResponse
+	if err := c.do(ctx, http.MethodGet, "/pending-domains", nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Requests, nil
+}
+
+// Approve approves a pending hostexec request.
+func (c *Client) Approve(ctx context.Context, id string) error {
+	return c.do(ctx, http.MethodPost, "/approve/"+id, nil, nil)
+}
+
+// Deny denies a pending hostexec request. An empty reason uses the server's
+// default.
+func (c *Client) Deny(ctx context.Context, id, reason string) error {
+	return c.do(ctx, http.MethodPost, "/deny/"+id, denyRequest{Reason: reason}, nil)
+}
+
+// ApproveDomain approves a pending domain request with the given scope
+// ("once", "session", "project", or "global"). A non-empty pattern approves
+// a wildcard such as "*.example.com" instead of the exact domain.
+func (c *Client) ApproveDomain(ctx context.Context, id, scope, pattern string) error {
+	body := approveDomainRequest{Scope: scope, Pattern: pattern}
+	return c.do(ctx, http.MethodPost, "/approve-domain/"+id, body, nil)
+}
+
+// DenyDomain denies a pending domain request with the given scope. If
+// wildcard is set, the denial applies to the domain's wildcard pattern.
+func (c *Client) DenyDomain(ctx context.Context, id, scope string, wildcard bool, reason string) error {
+	body := denyDomainRequest{Scope: scope, Wildcard: wildcard, Reason: reason}
+	return c.do(ctx, http.MethodPost, "/deny-domain/"+id, body, nil)
+}
+
+// Subscribe connects to the SSE stream and calls onEvent for each event
+// until ctx is cancelled or the stream ends. Heartbeats are not delivered.
+func (c *Client) Subscribe(ctx context.Context, onEvent func(Event)) error {
+	return c.stream(ctx, "/events", onEvent)
+}
+
+// stream connects to an SSE endpoint and delivers its events until ctx is
+// cancelled or the stream ends.
+func (c *Client) stream(ctx context.Context, path string, onEvent func(Event)) error {
+	req, err := c.newRequest(ctx, http.MethodGet, path, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "text/event-stream")
+
+	// The stream is long-lived, so it must not share the timeout client.
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to connect to approval server stream: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return responseError(resp)
+	}
+
+	return readEvents(resp.Body, onEvent)
+}
+
+// readEvents parses an SSE stream, delivering each complete event.
+func readEvents(r io.Reader, onEvent func(Event)) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	var ev Event
+	var data []string
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case line == "":
+			ev.Data = strings.Join(data, "\n")
+			if ev.Type != "" && ev.Type != EventHeartbeat {
+				onEvent(ev)
+			}
+			ev, data = Event{}, nil
+		case strings.HasPrefix(line, "event: "):
+			ev.Type = EventType(strings.TrimPrefix(line, "event: "))
+		case strings.HasPrefix(line, "data: "):
+			data = append(data, strings.TrimPrefix(line, "data: "))
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("approval event stream: %w", err)
+	}
+	return nil
+}
+
+// do sends a JSON request and decodes a JSON response into result, if set.
+func (c *Client) do(ctx context.Context, method, path string, body, result any) error {
+	req, err := c.newRequest(ctx, method, path, body)
+	if err != nil {
+		return err
+	}
+
+	client := c.HTTPClient
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to reach approval server: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return ErrNotPending
+	}
+	if resp.StatusCode != http.StatusOK {
+		return responseError(resp)
+	}
+	if result == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(result); err != nil {
+		return fmt.Errorf("failed to decode approval server response: %w", err)
+	}
+	return nil
+}
+
+// newRequest builds an authenticated request with an optional JSON body.
+func (c *Client) newRequest(ctx context.Context, method, path string, body any) (*http.Request, error) {
+	var bodyReader io.Reader = http.NoBody
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal request: %w", err)
+		}
+		bodyReader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.BaseURL+path, bodyReader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("Accept", "application/json")
+	if c.Secret != "" {
+		req.Header.Set("Authorization", "Bearer "+c.Secret)
+	}
+	return req, nil
+}
+
+// responseError builds an error from a non-OK response, preferring the
+// server's JSON error message over the raw body.
+func responseError(resp *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	var errResp errorResponse
+	if json.Unmarshal(body, &errResp) == nil && errResp.Error != "" {
+		return fmt.Errorf("approval server: %s", errResp.Error)
+	}
+	return fmt.Errorf("approval server returned %s: %s", resp.Status, strings.TrimSpace(string(body)))
+}
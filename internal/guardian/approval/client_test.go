@@ -0,0 +1,161 @@
+package approval
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// startClientTestServer starts an authenticated approval server on a random
+// port and returns it with a client for it.
+func startClientTestServer(t *testing.T, secret string) (*Server, *Client) {
+	t.Helper()
+	s := NewServer(NewQueue(), nil)
+	s.SetDomainQueue(NewDomainQueue())
+	s.Addr = "127.0.0.1:0"
+	if err := s.SetSecret("approval-secret"); err != nil {
+		t.Fatalf("SetSecret() error = %v", err)
+	}
+	if err := s.Start(); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	t.Cleanup(func() { _ = s.Stop(context.Background()) })
+	return s, NewClient(s.ListenAddr(), secret)
+}
+
+func TestClient_PendingAndApprove(t *testing.T) {
+	s, c := startClientTestServer(t, "approval-secret")
+	ctx := context.Background()
+
+	respCh := make(chan Response, 1)
+	id, err := s.Queue.Add(&PendingRequest{Cloister: "c1", Project: "p", Cmd: "make test", Timestamp: time.Now(), Response: respCh})
+	if err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+
+	pending, err := c.Pending(ctx)
+	if err != nil {
+		t.Fatalf("Pending() error = %v", err)
+	}
+	if len(pending) != 1 || pending[0].ID != id || pending[0].Cmd != "make test" {
+		t.Fatalf("Pending() = %+v, want one request %s", pending, id)
+	}
+
+	if err := c.Approve(ctx, id); err != nil {
+		t.Fatalf("Approve() error = %v", err)
+	}
+	if resp := <-respCh; resp.Status != "approved" {
+		t.Errorf("response status = %q, want approved", resp.Status)
+	}
+
+	if err := c.Approve(ctx, id); !errors.Is(err, ErrNotPending) {
+		t.Errorf("second Approve() error = %v, want ErrNotPending", err)
+	}
+}
+
+func TestClient_DenyWithReason(t *testing.T) {
+	s, c := startClientTestServer(t, "approval-secret")
+
+	respCh := make(chan Response, 1)
+	id, err := s.Queue.Add(&PendingRequest{Cloister: "c1", Cmd: "rm -rf /", Timestamp: time.Now(), Response: respCh})
+	if err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+
+	if err := c.Deny(context.Background(), id, "not today"); err != nil {
+		t.Fatalf("Deny() error = %v", err)
+	}
+	resp := <-respCh
+	if resp.Status != "denied" || resp.Reason != "not today" {
+		t.Errorf("response = %+v, want denied with reason", resp)
+	}
+}
+
+func TestClient_ApproveDomain(t *testing.T) {
+	s, c := startClientTestServer(t, "approval-secret")
+	ctx := context.Background()
+
+	respCh := make(chan DomainResponse, 1)
+	id, err := s.DomainQueue.Add(&DomainRequest{
+		Cloister: "c1", Project: "p", Domain: "api.example.com", Token: "tok",
+		Timestamp: time.Now(), Responses: []chan<- DomainResponse{respCh},
+	})
+	if err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+
+	domains, err := c.PendingDomains(ctx)
+	if err != nil {
+		t.Fatalf("PendingDomains() error = %v", err)
+	}
+	if len(domains) != 1 || domains[0].Domain != "api.example.com" {
+		t.Fatalf("PendingDomains() = %+v", domains)
+	}
+
+	if err := c.ApproveDomain(ctx, id, "session", ""); err != nil {
+		t.Fatalf("ApproveDomain() error = %v", err)
+	}
+	if resp := <-respCh; resp.Status != "approved" || resp.Scope != "session" {
+		t.Errorf("response = %+v, want approved for session", resp)
+	}
+}
+
+func TestClient_WrongSecret(t *testing.T) {
+	_, c := startClientTestServer(t, "wrong")
+	_, err := c.Pending(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "unauthorized") {
+		t.Errorf("Pending() error = %v, want unauthorized", err)
+	}
+}
+
+func TestClient_Subscribe(t *testing.T) {
+	s, c := startClientTestServer(t, "approval-secret")
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	events := make(chan Event, 4)
+	done := make(chan error, 1)
+	go func() {
+		done <- c.Subscribe(ctx, func(ev Event) { events <- ev })
+	}()
+
+	// Wait for the subscription before adding a request.
+	deadline := time.Now().Add(2 * time.Second)
+	for s.Events.ClientCount() == 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	id, err := s.Queue.Add(&PendingRequest{Cloister: "c1", Cmd: "ls", Timestamp: time.Now()})
+	if err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	select {
+	case ev := <-events:
+		if ev.Type != EventRequestAdded || !strings.Contains(ev.Data, id) {
+			t.Errorf("event = %+v, want request-added for %s", ev, id)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("no event received")
+	}
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Subscribe did not return after cancel")
+	}
+}
+
+func TestReadEvents_MultiLineData(t *testing.T) {
+	stream := "event: heartbeat\ndata: \n\n" +
+		"event: request-added\ndata: <li>\ndata: </li>\n\n"
+	var got []Event
+	if err := readEvents(strings.NewReader(stream), func(ev Event) { got = append(got, ev) }); err != nil {
+		t.Fatalf("readEvents() error = %v", err)
+	}
+	if len(got) != 1 || got[0].Type != EventRequestAdded || got[0].Data != "<li>\n</li>" {
+		t.Errorf("events = %+v, want one request-added with joined data", got)
+	}
+}
@@ -6,6 +6,7 @@ package approval
 
 import (
 	"context"
+	"slices"
 	"sync"
 	"time"
 
@@ -18,6 +19,7 @@ type DomainResponse struct {
 	Status           string `json:"status"`                      // "approved", "denied", or "timeout"
 	Scope            string `json:"scope"`                       // "once", "session", "project", or "global"
 	Reason           string `json:"reason,omitempty"`            // Reason for denial (only for denied)
+	Message          string `json:"message,omitempty"`           // Approver's feedback on a denial, verbatim
 	Pattern          string `json:"pattern,omitempty"`           // Wildcard pattern (e.g., "*.example.com") for approved/denied with wildcard
 	PersistenceError string `json:"persistence_error,omitempty"` // Error message if config persistence failed (domain still approved for session)
 }
@@ -36,6 +38,17 @@ type DomainRequest struct {
 	// The timeout handler uses a non-blocking send, but callers should still use buffered
 	// channels to ensure reliable delivery of approval/denial responses.
 	Responses []chan<- DomainResponse
+
+	// Quorum, as for PendingRequest. Scope and Pattern are the first
+	// approver's choice, which every later approver must repeat.
+	RequireApprovals int
+	Approvals        []string
+	Scope            string
+	Pattern          string
+
+	// Timeout overrides the queue's timeout for this request, as for
+	// PendingRequest.
+	Timeout time.Duration
 }
 
 // DomainQueue manages pending domain approval requests with thread-safe operations.
@@ -47,6 +60,7 @@ type DomainQueue struct {
 	timeout     time.Duration
 	events      *EventHub     // Optional event hub for SSE broadcasts
 	auditLogger *audit.Logger // Optional audit logger for domain events
+	notifier    Notifier      // Optional sink for pending/timeout notifications
 }
 
 // NewDomainQueue creates a new empty domain approval queue with the default timeout.
@@ -91,6 +105,21 @@ func (dq *DomainQueue) SetAuditLogger(logger *audit.Logger) {
 	dq.auditLogger = logger
 }
 
+// SetNotifier sets the notifier told when requests are added or time out.
+// Coalesced duplicates of a pending request do not trigger notifications.
+func (dq *DomainQueue) SetNotifier(n Notifier) {
+	dq.mu.Lock()
+	defer dq.mu.Unlock()
+	dq.notifier = n
+}
+
+// currentNotifier returns the configured notifier, if any.
+func (dq *DomainQueue) currentNotifier() Notifier {
+	dq.mu.RLock()
+	defer dq.mu.RUnlock()
+	return dq.notifier
+}
+
 // Add adds a new pending domain request to the queue and returns its generated ID.
 // The ID is generated using crypto/rand (8 bytes = 16 hex characters).
 // A timeout goroutine is started that will send a timeout response on the
@@ -129,18 +158,25 @@ func (dq *DomainQueue) Add(req *DomainRequest) (string, error) {
 
 	ctx, cancel := context.WithCancel(context.Background())
 
+	timeout := dq.timeout
+	if req.Timeout != 0 {
+		timeout = req.Timeout
+	}
 	req.ID = id
-	req.ExpiresAt = time.Now().Add(dq.timeout)
+	if timeout > 0 {
+		req.ExpiresAt = time.Now().Add(timeout)
+	}
 	dq.requests[id] = req
 	dq.cancels[id] = cancel
 	dq.pending[key] = id
 	events := dq.events           // Capture reference while holding lock
 	auditLogger := dq.auditLogger // Capture reference while holding lock
+	notifier := dq.notifier
 	dq.mu.Unlock()
 
 	// Log domain request event
 	if auditLogger != nil {
-		if err := auditLogger.LogDomainRequest(req.Project, req.Cloister, req.Domain); err != nil {
+		if err := auditLogger.LogDomainRequest(id, req.Project, req.Cloister, req.Domain); err != nil {
 			clog.Warn("failed to log domain request audit event: %v", err)
 		}
 	}
@@ -149,23 +185,29 @@ func (dq *DomainQueue) Add(req *DomainRequest) (string, error) {
 	if events != nil {
 		events.BroadcastDomainRequestAdded(req)
 	}
+	if notifier != nil {
+		notifier.Notify(newDomainNotification(NotifyPending, req))
+	}
 
 	// Start timeout goroutine
-	go dq.handleTimeout(ctx, id)
+	if timeout > 0 {
+		go dq.handleTimeout(ctx, id, timeout)
+	}
 
 	return id, nil
 }
 
 // handleTimeout waits for the timeout duration and sends a timeout response
-// if the context has not been canceled (i.e., request not approved/denied).
+// if the context has not been canceled (i.e., request not approved/denied
+// and its deadline not extended).
 // If an EventHub is configured, a domain-request-removed event is broadcast to SSE clients.
 // The timeout response is broadcast to all response channels in the request's Responses slice.
-func (dq *DomainQueue) handleTimeout(ctx context.Context, id string) {
+func (dq *DomainQueue) handleTimeout(ctx context.Context, id string, timeout time.Duration) {
 	select {
 	case <-ctx.Done():
 		return
-	case <-time.After(dq.timeout):
-		req, events, auditLogger := dq.removeExpired(id)
+	case <-time.After(timeout):
+		req, events, auditLogger := dq.removeExpired(ctx, id)
 		if req == nil {
 			return
 		}
@@ -173,18 +215,22 @@ func (dq *DomainQueue) handleTimeout(ctx context.Context, id string) {
 		if events != nil {
 			events.BroadcastDomainRequestRemoved(id)
 		}
+		if notifier := dq.currentNotifier(); notifier != nil {
+			notifier.Notify(newDomainNotification(NotifyTimeout, req))
+		}
 		broadcastTimeoutResponse(req)
 	}
 }
 
 // removeExpired removes a timed-out request from the queue under lock and returns
-// the request, events hub, and audit logger. Returns nil request if not found.
-func (dq *DomainQueue) removeExpired(id string) (*DomainRequest, *EventHub, *audit.Logger) {
+// the request, events hub, and audit logger. Returns nil request if not found,
+// or if ctx was canceled because the deadline was extended.
+func (dq *DomainQueue) removeExpired(ctx context.Context, id string) (*DomainRequest, *EventHub, *audit.Logger) {
 	dq.mu.Lock()
 	defer dq.mu.Unlock()
 
 	req, exists := dq.requests[id]
-	if !exists {
+	if !exists || ctx.Err() != nil {
 		return nil, nil, nil
 	}
 	delete(dq.requests, id)
@@ -198,7 +244,7 @@ func logTimeoutEvent(auditLogger *audit.Logger, req *DomainRequest) {
 	if auditLogger == nil {
 		return
 	}
-	if err := auditLogger.LogDomainTimeout(req.Project, req.Cloister, req.Domain); err != nil {
+	if err := auditLogger.LogDomainTimeout(req.ID, req.Project, req.Cloister, req.Domain); err != nil {
 		clog.Warn("failed to log domain timeout audit event: %v", err)
 	}
 }
@@ -248,6 +294,89 @@ func (dq *DomainQueue) Remove(id string) {
 	delete(dq.requests, id)
 }
 
+// Take removes a pending domain request from the queue, as for Queue.Take.
+func (dq *DomainQueue) Take(id string) (*DomainRequest, bool) {
+	dq.mu.Lock()
+	defer dq.mu.Unlock()
+
+	req, ok := dq.requests[id]
+	if !ok {
+		return nil, false
+	}
+	if cancel, ok := dq.cancels[id]; ok {
+		cancel()
+		delete(dq.cancels, id)
+	}
+	delete(dq.pending, pendingKey(req.Token, req.Domain))
+	delete(dq.requests, id)
+	return req, true
+}
+
+// Approve records approver's approval of a pending domain request with the
+// given scope and pattern, and returns a copy of the request and its tally.
+// Once the tally is done the request is removed from the queue and its
+// timeout canceled; the caller then sends the response. Errors are as for
+// Queue.Approve, plus ErrScopeMismatch if an earlier approver chose a
+// different scope or pattern.
+func (dq *DomainQueue) Approve(id, approver, scope, pattern string) (DomainRequest, Tally, error) {
+	dq.mu.Lock()
+	defer dq.mu.Unlock()
+
+	req, ok := dq.requests[id]
+	if !ok {
+		return DomainRequest{}, Tally{}, ErrNotPending
+	}
+	if len(req.Approvals) > 0 && (scope != req.Scope || pattern != req.Pattern) {
+		return DomainRequest{}, Tally{}, ErrScopeMismatch
+	}
+	approvals, err := vote(req.Approvals, approver)
+	if err != nil {
+		return DomainRequest{}, Tally{}, err
+	}
+	req.Approvals = approvals
+	req.Scope, req.Pattern = scope, pattern
+	tally := newTally(approvals, req.RequireApprovals)
+	if tally.Done() {
+		if cancel, ok := dq.cancels[id]; ok {
+			cancel()
+			delete(dq.cancels, id)
+		}
+		delete(dq.pending, pendingKey(req.Token, req.Domain))
+		delete(dq.requests, id)
+	}
+	snapshot := *req
+	snapshot.Approvals = tally.Approvals
+	snapshot.Responses = slices.Clone(req.Responses)
+	return snapshot, tally, nil
+}
+
+// Extend moves a pending domain request's deadline back by d, as for
+// Queue.Extend.
+func (dq *DomainQueue) Extend(id string, d time.Duration) (DomainRequest, error) {
+	dq.mu.Lock()
+	defer dq.mu.Unlock()
+
+	req, ok := dq.requests[id]
+	if !ok {
+		return DomainRequest{}, ErrNotPending
+	}
+	if req.ExpiresAt.IsZero() {
+		return DomainRequest{}, ErrNoDeadline
+	}
+	if cancel, ok := dq.cancels[id]; ok {
+		cancel()
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	dq.cancels[id] = cancel
+	req.ExpiresAt = req.ExpiresAt.Add(d)
+	go dq.handleTimeout(ctx, id, time.Until(req.ExpiresAt))
+
+	snapshot := *req
+	snapshot.Approvals = slices.Clone(req.Approvals)
+	snapshot.Responses = nil
+	return snapshot, nil
+}
+
 // List returns a copy of all pending domain requests for the approval UI.
 // The returned slice is safe to iterate without holding locks.
 // The Responses channels are excluded from the returned copies for safety.
@@ -266,6 +395,10 @@ func (dq *DomainQueue) List() []DomainRequest {
 			Token:     req.Token,
 			Timestamp: req.Timestamp,
 			ExpiresAt: req.ExpiresAt,
+
+			RequireApprovals: req.RequireApprovals,
+			Approvals:        slices.Clone(req.Approvals),
+			Timeout:          req.Timeout,
 			// Responses channels intentionally omitted
 		})
 	}
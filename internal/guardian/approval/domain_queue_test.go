@@ -2,6 +2,7 @@ package approval
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"strings"
 	"sync"
@@ -1145,3 +1146,104 @@ func TestDomainResponse_JSONMarshal(t *testing.T) {
 		})
 	}
 }
+
+func TestDomainQueue_ApproveQuorum(t *testing.T) {
+	q := NewDomainQueueWithTimeout(time.Minute)
+	respChan := make(chan DomainResponse, 1)
+	id, err := q.Add(&DomainRequest{
+		Domain:           "api.prod.example.com",
+		Token:            "tok",
+		Timestamp:        time.Now(),
+		Responses:        []chan<- DomainResponse{respChan},
+		RequireApprovals: 2,
+	})
+	if err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+
+	_, tally, err := q.Approve(id, "alice", "once", "")
+	if err != nil {
+		t.Fatalf("Approve(alice) error = %v", err)
+	}
+	if tally.Done() {
+		t.Fatal("one approval should not reach a quorum of two")
+	}
+	if list := q.List(); len(list) != 1 || strings.Join(list[0].Approvals, ",") != "alice" {
+		t.Errorf("List() = %+v, want one request approved by alice", list)
+	}
+
+	for _, c := range []struct{ scope, pattern string }{{"global", ""}, {"once", "*.example.com"}} {
+		if _, _, err := q.Approve(id, "bob", c.scope, c.pattern); !errors.Is(err, ErrScopeMismatch) {
+			t.Errorf("Approve(bob, %q, %q) error = %v, want ErrScopeMismatch", c.scope, c.pattern, err)
+		}
+	}
+
+	req, tally, err := q.Approve(id, "bob", "once", "")
+	if err != nil {
+		t.Fatalf("Approve(bob) error = %v", err)
+	}
+	if !tally.Done() {
+		t.Error("two approvals should reach the quorum")
+	}
+	if len(req.Responses) != 1 {
+		t.Errorf("Responses = %d, want 1 for the caller to answer", len(req.Responses))
+	}
+	if q.Len() != 0 {
+		t.Errorf("queue len = %d, want 0 after quorum", q.Len())
+	}
+}
+
+func TestDomainQueue_TakeBeatsApprove(t *testing.T) {
+	q := NewDomainQueueWithTimeout(time.Minute)
+	id, err := q.Add(&DomainRequest{Domain: "example.com", Token: "tok", RequireApprovals: 2})
+	if err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	if _, _, err := q.Approve(id, "alice", "session", ""); err != nil {
+		t.Fatalf("Approve(alice) error = %v", err)
+	}
+
+	if req, ok := q.Take(id); !ok || req.Domain != "example.com" {
+		t.Fatalf("Take() = %+v, %v; want the request", req, ok)
+	}
+	if _, _, err := q.Approve(id, "bob", "session", ""); !errors.Is(err, ErrNotPending) {
+		t.Errorf("Approve() after Take error = %v, want ErrNotPending", err)
+	}
+	if _, ok := q.Take(id); ok {
+		t.Error("second Take() found the request")
+	}
+	if q.Len() != 0 {
+		t.Errorf("queue len = %d, want 0", q.Len())
+	}
+}
+
+func TestDomainQueue_Extend(t *testing.T) {
+	q := NewDomainQueueWithTimeout(50 * time.Millisecond)
+	respChan := make(chan DomainResponse, 1)
+	id, err := q.Add(&DomainRequest{
+		Domain:    "example.com",
+		Token:     "tok",
+		Responses: []chan<- DomainResponse{respChan},
+		Timeout:   40 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+
+	if _, err := q.Extend(id, 150*time.Millisecond); err != nil {
+		t.Fatalf("Extend() error = %v", err)
+	}
+	time.Sleep(100 * time.Millisecond)
+	if _, ok := q.Get(id); !ok {
+		t.Fatal("extended request timed out at its original deadline")
+	}
+
+	select {
+	case resp := <-respChan:
+		if resp.Status != "timeout" {
+			t.Errorf("status = %q, want timeout", resp.Status)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("extended request never timed out")
+	}
+}
@@ -7,7 +7,6 @@ import (
 	"net"
 	"strings"
 	"sync"
-	"time"
 )
 
 // EventType represents the type of SSE event.
@@ -24,6 +23,13 @@ const (
 	EventDomainRequestAdded EventType = "domain-request-added"
 	// EventDomainRequestRemoved is sent when a domain request is removed (approved/denied/timed out).
 	EventDomainRequestRemoved EventType = "domain-request-removed"
+	// EventRequestUpdated is sent with a re-rendered card when a command or
+	// domain request that needs several approvers gets one of them.
+	EventRequestUpdated EventType = "request-updated"
+	// EventExecutionsUpdated is sent when a host command is queued, starts, or finishes.
+	EventExecutionsUpdated EventType = "executions-updated"
+	// EventLog carries one audit log entry on the GET /logs stream.
+	EventLog EventType = "log"
 )
 
 // Event represents an SSE event to be broadcast to clients.
@@ -122,30 +128,35 @@ func (h *EventHub) ClientCount() int {
 
 // BroadcastRequestAdded broadcasts a request-added event with rendered HTML.
 func (h *EventHub) BroadcastRequestAdded(req templateRequest) {
-	// Render the request template
-	var buf bytes.Buffer
-	if err := templates.ExecuteTemplate(&buf, "request", req); err != nil {
-		// Log error but don't fail - SSE is best-effort
-		return
-	}
-
-	h.Broadcast(Event{
-		Type: EventRequestAdded,
-		Data: buf.String(),
-	})
+	h.broadcastCard(EventRequestAdded, "request", req)
 }
 
 // BroadcastPendingRequestAdded broadcasts a request-added event for a PendingRequest.
 // This is a convenience method that converts PendingRequest to the template format.
 func (h *EventHub) BroadcastPendingRequestAdded(req *PendingRequest) {
-	h.BroadcastRequestAdded(templateRequest{
-		ID:        req.ID,
-		Cloister:  req.Cloister,
-		Project:   req.Project,
-		Agent:     req.Agent,
-		Cmd:       req.Cmd,
-		Timestamp: req.Timestamp.Format(time.RFC3339),
-	})
+	h.BroadcastRequestAdded(newTemplateRequest(req))
+}
+
+// BroadcastPendingRequestUpdated broadcasts a request-updated event with the
+// re-rendered card of a command request.
+func (h *EventHub) BroadcastPendingRequestUpdated(req *PendingRequest) {
+	h.broadcastCard(EventRequestUpdated, "request", newTemplateRequest(req))
+}
+
+// BroadcastDomainRequestUpdated broadcasts a request-updated event with the
+// re-rendered card of a domain request.
+func (h *EventHub) BroadcastDomainRequestUpdated(req *DomainRequest) {
+	h.broadcastCard(EventRequestUpdated, "domain_request", newDomainTemplateRequest(req))
+}
+
+// broadcastCard renders a request card template and broadcasts it.
+func (h *EventHub) broadcastCard(typ EventType, tmpl string, data any) {
+	var buf bytes.Buffer
+	if err := templates.ExecuteTemplate(&buf, tmpl, data); err != nil {
+		// Log error but don't fail - SSE is best-effort
+		return
+	}
+	h.Broadcast(Event{Type: typ, Data: buf.String()})
 }
 
 // BroadcastRequestRemoved broadcasts a request-removed event with the request ID.
@@ -162,23 +173,20 @@ func (h *EventHub) BroadcastRequestRemoved(id string) {
 
 // BroadcastDomainRequestAdded broadcasts a domain-request-added event with rendered HTML.
 func (h *EventHub) BroadcastDomainRequestAdded(req *DomainRequest) {
-	// Render the domain_request template
+	h.broadcastCard(EventDomainRequestAdded, "domain_request", newDomainTemplateRequest(req))
+}
+
+// BroadcastExecutions broadcasts an executions-updated event with the
+// rendered list of queued and running host commands.
+func (h *EventHub) BroadcastExecutions(execs []Execution) {
 	var buf bytes.Buffer
-	templateReq := domainTemplateRequest{
-		ID:        req.ID,
-		Domain:    req.Domain,
-		Cloister:  req.Cloister,
-		Project:   req.Project,
-		Timestamp: req.Timestamp.Format(time.RFC3339),
-		Wildcard:  domainToWildcard(req.Domain),
-	}
-	if err := templates.ExecuteTemplate(&buf, "domain_request", templateReq); err != nil {
+	if err := templates.ExecuteTemplate(&buf, "executions", newTemplateExecutions(execs)); err != nil {
 		// Log error but don't fail - SSE is best-effort
 		return
 	}
 
 	h.Broadcast(Event{
-		Type: EventDomainRequestAdded,
+		Type: EventExecutionsUpdated,
 		Data: buf.String(),
 	})
 }
@@ -196,10 +204,10 @@ func countDomainComponents(domain string) int {
 	return strings.Count(domain, ".") + 1
 }
 
-// domainToWildcard converts a domain like "api.example.com" to a wildcard
+// DomainToWildcard converts a domain like "api.example.com" to a wildcard
 // pattern like "*.example.com". Returns empty string if the domain doesn't
 // have at least three components to prevent overly broad patterns like "*.com".
-func domainToWildcard(domain string) string {
+func DomainToWildcard(domain string) string {
 	// Strip port if present (CONNECT requests include port, e.g. "api.example.com:443")
 	if host, _, err := net.SplitHostPort(domain); err == nil {
 		domain = host
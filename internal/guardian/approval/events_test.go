@@ -482,7 +482,7 @@ func TestEventHub_BroadcastDomainRequestRemoved(t *testing.T) {
 	}
 }
 
-// TestDomainToWildcard_StripsPort verifies that domainToWildcard strips the
+// TestDomainToWildcard_StripsPort verifies that DomainToWildcard strips the
 // port before constructing the wildcard. CONNECT requests include port
 // (e.g. "api.example.com:443"), and the wildcard should be "*.example.com"
 // not "*.example.com:443".
@@ -499,9 +499,9 @@ func TestDomainToWildcard_StripsPort(t *testing.T) {
 	}
 	for _, tc := range tests {
 		t.Run(tc.domain, func(t *testing.T) {
-			got := domainToWildcard(tc.domain)
+			got := DomainToWildcard(tc.domain)
 			if got != tc.expected {
-				t.Errorf("domainToWildcard(%q) = %q, want %q", tc.domain, got, tc.expected)
+				t.Errorf("DomainToWildcard(%q) = %q, want %q", tc.domain, got, tc.expected)
 			}
 		})
 	}
@@ -0,0 +1,117 @@
+package approval
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Execution states shown in the approval UI.
+const (
+	ExecutionSubmitted = "submitted" // Sent to the executor, no status yet
+	ExecutionQueued    = "queued"    // Waiting for a concurrency slot
+	ExecutionRunning   = "running"   // Running on the host
+)
+
+// Execution is an approved host command that has been handed to the
+// executor and has not yet finished.
+type Execution struct {
+	ID        string    `json:"id"`
+	Cloister  string    `json:"cloister"`
+	Project   string    `json:"project"`
+	Cmd       string    `json:"cmd"`
+	State     string    `json:"state"`
+	Position  int       `json:"position,omitempty"` // Queue position when State is queued
+	Submitted time.Time `json:"submitted"`
+}
+
+// ExecutionTracker records queued and running host commands so the approval
+// UI can show what is in flight. It is safe for concurrent use.
+type ExecutionTracker struct {
+	mu     sync.Mutex
+	execs  map[string]*Execution
+	events *EventHub // Optional event hub for SSE broadcasts
+}
+
+// NewExecutionTracker creates an empty tracker.
+func NewExecutionTracker() *ExecutionTracker {
+	return &ExecutionTracker{execs: make(map[string]*Execution)}
+}
+
+// SetEventHub sets the event hub for SSE broadcasts.
+// When set, every change broadcasts the re-rendered execution list.
+func (t *ExecutionTracker) SetEventHub(hub *EventHub) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.events = hub
+}
+
+// Begin records a newly submitted execution and returns its ID.
+func (t *ExecutionTracker) Begin(cloister, project, cmd string) (string, error) {
+	id, err := generateID()
+	if err != nil {
+		return "", err
+	}
+	t.mu.Lock()
+	t.execs[id] = &Execution{
+		ID:        id,
+		Cloister:  cloister,
+		Project:   project,
+		Cmd:       cmd,
+		State:     ExecutionSubmitted,
+		Submitted: time.Now(),
+	}
+	t.mu.Unlock()
+	t.broadcast()
+	return id, nil
+}
+
+// Update sets the state and queue position of an execution.
+// This is a no-op if the ID is not found.
+func (t *ExecutionTracker) Update(id, state string, position int) {
+	t.mu.Lock()
+	e, ok := t.execs[id]
+	if ok {
+		e.State = state
+		e.Position = position
+	}
+	t.mu.Unlock()
+	if ok {
+		t.broadcast()
+	}
+}
+
+// End removes a finished execution. This is a no-op if the ID is not found.
+func (t *ExecutionTracker) End(id string) {
+	t.mu.Lock()
+	_, ok := t.execs[id]
+	delete(t.execs, id)
+	t.mu.Unlock()
+	if ok {
+		t.broadcast()
+	}
+}
+
+// List returns copies of all in-flight executions, oldest first.
+func (t *ExecutionTracker) List() []Execution {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	result := make([]Execution, 0, len(t.execs))
+	for _, e := range t.execs {
+		result = append(result, *e)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Submitted.Before(result[j].Submitted)
+	})
+	return result
+}
+
+// broadcast sends the current execution list to SSE clients.
+func (t *ExecutionTracker) broadcast() {
+	t.mu.Lock()
+	events := t.events
+	t.mu.Unlock()
+	if events != nil {
+		events.BroadcastExecutions(t.List())
+	}
+}
@@ -0,0 +1,119 @@
+package approval
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestExecutionTracker_Lifecycle(t *testing.T) {
+	tracker := NewExecutionTracker()
+
+	first, err := tracker.Begin("c1", "p1", "docker build .")
+	if err != nil {
+		t.Fatalf("Begin() error = %v", err)
+	}
+	second, err := tracker.Begin("c2", "p2", "make test")
+	if err != nil {
+		t.Fatalf("Begin() error = %v", err)
+	}
+
+	tracker.Update(first, ExecutionRunning, 0)
+	tracker.Update(second, ExecutionQueued, 1)
+	tracker.Update("missing", ExecutionRunning, 0) // no-op
+
+	list := tracker.List()
+	if len(list) != 2 {
+		t.Fatalf("List() len = %d, want 2", len(list))
+	}
+	if list[0].ID != first || list[0].State != ExecutionRunning {
+		t.Errorf("list[0] = %+v, want first running", list[0])
+	}
+	if list[1].ID != second || list[1].State != ExecutionQueued || list[1].Position != 1 {
+		t.Errorf("list[1] = %+v, want second queued at 1", list[1])
+	}
+
+	tracker.End(first)
+	tracker.End("missing") // no-op
+	if list := tracker.List(); len(list) != 1 || list[0].ID != second {
+		t.Errorf("List() after End = %+v, want only second", list)
+	}
+}
+
+func TestExecutionTracker_BroadcastsRenderedList(t *testing.T) {
+	hub := NewEventHub()
+	ch := hub.Subscribe()
+	defer hub.Unsubscribe(ch)
+
+	tracker := NewExecutionTracker()
+	tracker.SetEventHub(hub)
+
+	id, err := tracker.Begin("my-cloister", "p", "docker build .")
+	if err != nil {
+		t.Fatalf("Begin() error = %v", err)
+	}
+	<-ch // submitted
+	tracker.Update(id, ExecutionQueued, 3)
+
+	select {
+	case event := <-ch:
+		if event.Type != EventExecutionsUpdated {
+			t.Errorf("expected type %s, got %s", EventExecutionsUpdated, event.Type)
+		}
+		for _, want := range []string{"my-cloister", "docker build .", "queued #3"} {
+			if !strings.Contains(event.Data, want) {
+				t.Errorf("event data missing %q: %s", want, event.Data)
+			}
+		}
+	case <-time.After(100 * time.Millisecond):
+		t.Fatal("timeout waiting for event")
+	}
+
+	tracker.End(id)
+	select {
+	case event := <-ch:
+		if strings.Contains(event.Data, "execution-") {
+			t.Errorf("expected empty list after End, got %q", event.Data)
+		}
+	case <-time.After(100 * time.Millisecond):
+		t.Fatal("timeout waiting for event")
+	}
+}
+
+func TestServer_HandleExecutions(t *testing.T) {
+	server := NewServer(NewQueue(), nil)
+
+	rr := httptest.NewRecorder()
+	server.handleExecutions(rr, httptest.NewRequest(http.MethodGet, "/executions", http.NoBody))
+
+	var resp executionsResponse
+	if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if resp.Executions == nil || len(resp.Executions) != 0 {
+		t.Errorf("expected empty executions array without tracker, got %+v", resp.Executions)
+	}
+
+	tracker := NewExecutionTracker()
+	server.SetExecutions(tracker)
+	id, _ := tracker.Begin("c", "p", "make")
+	tracker.Update(id, ExecutionRunning, 0)
+
+	rr = httptest.NewRecorder()
+	server.handleExecutions(rr, httptest.NewRequest(http.MethodGet, "/executions", http.NoBody))
+	if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if len(resp.Executions) != 1 || resp.Executions[0].State != ExecutionRunning {
+		t.Errorf("executions = %+v, want one running", resp.Executions)
+	}
+
+	rr = httptest.NewRecorder()
+	server.handleIndex(rr, httptest.NewRequest(http.MethodGet, "/", http.NoBody))
+	if !strings.Contains(rr.Body.String(), `id="execution-`+id+`"`) {
+		t.Error("index page should list the running execution")
+	}
+}
@@ -0,0 +1,115 @@
+package approval
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/xdg/cloister/internal/clog"
+)
+
+// extendRequest is the optional request body for POST /extend/{id} and
+// POST /extend-domain/{id}.
+type extendRequest struct {
+	Duration string `json:"duration,omitempty"` // e.g. "10m"; default DefaultExtension
+}
+
+// extendResponse is the response body for POST /extend/{id} and
+// POST /extend-domain/{id}.
+type extendResponse struct {
+	Status    string `json:"status"`
+	ID        string `json:"id"`
+	ExpiresAt string `json:"expires_at"`
+}
+
+// parseExtension reads the extension requested in r's body, writing an error
+// response and returning false if it is invalid.
+func (s *Server) parseExtension(w http.ResponseWriter, r *http.Request) (time.Duration, bool) {
+	var req extendRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		s.writeError(w, http.StatusBadRequest, "invalid request body")
+		return 0, false
+	}
+	if req.Duration == "" {
+		return DefaultExtension, true
+	}
+	d, err := time.ParseDuration(req.Duration)
+	if err != nil || d <= 0 || d > MaxExtension {
+		s.writeError(w, http.StatusBadRequest, fmt.Sprintf("duration must be a positive duration of at most %v", MaxExtension))
+		return 0, false
+	}
+	return d, true
+}
+
+// writeExtendError reports a failed Queue.Extend or DomainQueue.Extend.
+func (s *Server) writeExtendError(w http.ResponseWriter, err error) {
+	if errors.Is(err, ErrNoDeadline) {
+		s.writeError(w, http.StatusConflict, "request waits until decided and has no deadline")
+		return
+	}
+	s.writeError(w, http.StatusNotFound, "request not found")
+}
+
+// handleExtend moves a pending command request's deadline back and
+// broadcasts the updated card.
+func (s *Server) handleExtend(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if id == "" {
+		s.writeError(w, http.StatusBadRequest, "id is required")
+		return
+	}
+	d, ok := s.parseExtension(w, r)
+	if !ok {
+		return
+	}
+
+	req, err := s.Queue.Extend(id, d)
+	if err != nil {
+		s.writeExtendError(w, err)
+		return
+	}
+	clog.Info("approval deadline for %s extended by %v by %s", id, d, s.actor(r))
+	s.Events.BroadcastPendingRequestUpdated(&req)
+
+	if strings.Contains(r.Header.Get("Accept"), "text/html") {
+		s.writeCardHTML(w, "request", newTemplateRequest(&req))
+		return
+	}
+	s.writeJSON(w, http.StatusOK, extendResponse{Status: "extended", ID: id, ExpiresAt: req.ExpiresAt.Format(time.RFC3339)})
+}
+
+// handleExtendDomain moves a pending domain request's deadline back and
+// broadcasts the updated card.
+func (s *Server) handleExtendDomain(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if id == "" {
+		s.writeError(w, http.StatusBadRequest, "id is required")
+		return
+	}
+	if s.DomainQueue == nil {
+		s.writeError(w, http.StatusInternalServerError, "domain queue not initialized")
+		return
+	}
+	d, ok := s.parseExtension(w, r)
+	if !ok {
+		return
+	}
+
+	req, err := s.DomainQueue.Extend(id, d)
+	if err != nil {
+		s.writeExtendError(w, err)
+		return
+	}
+	clog.Info("approval deadline for domain request %s extended by %v by %s", id, d, s.actor(r))
+	s.Events.BroadcastDomainRequestUpdated(&req)
+
+	if strings.Contains(r.Header.Get("Accept"), "text/html") {
+		s.writeCardHTML(w, "domain_request", newDomainTemplateRequest(&req))
+		return
+	}
+	s.writeJSON(w, http.StatusOK, extendResponse{Status: "extended", ID: id, ExpiresAt: req.ExpiresAt.Format(time.RFC3339)})
+}
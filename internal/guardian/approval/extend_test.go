@@ -0,0 +1,116 @@
+package approval
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestServer_HandleExtend(t *testing.T) {
+	queue := NewQueueWithTimeout(time.Minute)
+	id, err := queue.Add(&PendingRequest{Cmd: "terraform plan", Response: make(chan Response, 1)})
+	if err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	orig, _ := queue.Get(id)
+	origExpiry := orig.ExpiresAt
+	server := NewServer(queue, nil)
+	defer queue.Remove(id)
+
+	tests := []struct {
+		name string
+		body string
+		want int
+	}{
+		{"default", "", http.StatusOK},
+		{"explicit", `{"duration":"10m"}`, http.StatusOK},
+		{"too long", `{"duration":"2h"}`, http.StatusBadRequest},
+		{"negative", `{"duration":"-1m"}`, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/extend/"+id, strings.NewReader(tt.body))
+			req.SetPathValue("id", id)
+			rr := httptest.NewRecorder()
+			server.handleExtend(rr, req)
+			if rr.Code != tt.want {
+				t.Errorf("status = %d, want %d (body %q)", rr.Code, tt.want, rr.Body.String())
+			}
+		})
+	}
+
+	req, _ := queue.Get(id)
+	if want := origExpiry.Add(DefaultExtension + 10*time.Minute); !req.ExpiresAt.Equal(want) {
+		t.Errorf("ExpiresAt = %v, want %v", req.ExpiresAt, want)
+	}
+}
+
+func TestServer_HandleExtend_Errors(t *testing.T) {
+	queue := NewQueue()
+	held, err := queue.Add(&PendingRequest{Cmd: "make deploy", Timeout: -1})
+	if err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	defer queue.Remove(held)
+	server := NewServer(queue, nil)
+
+	for id, want := range map[string]int{held: http.StatusConflict, "nonexistent": http.StatusNotFound} {
+		req := httptest.NewRequest(http.MethodPost, "/extend/"+id, http.NoBody)
+		req.SetPathValue("id", id)
+		rr := httptest.NewRecorder()
+		server.handleExtend(rr, req)
+		if rr.Code != want {
+			t.Errorf("extend %s: status = %d, want %d", id, rr.Code, want)
+		}
+	}
+}
+
+func TestServer_HandleExtendDomain(t *testing.T) {
+	dq := NewDomainQueueWithTimeout(time.Minute)
+	id, err := dq.Add(&DomainRequest{Domain: "example.com", Token: "tok", Responses: []chan<- DomainResponse{make(chan DomainResponse, 1)}})
+	if err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	defer dq.Remove(id)
+	server := NewServer(NewQueue(), nil)
+	server.SetDomainQueue(dq)
+
+	req := httptest.NewRequest(http.MethodPost, "/extend-domain/"+id, strings.NewReader(`{"duration":"30s"}`))
+	req.SetPathValue("id", id)
+	rr := httptest.NewRecorder()
+	server.handleExtendDomain(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d (body %q)", rr.Code, http.StatusOK, rr.Body.String())
+	}
+
+	var resp extendResponse
+	if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	pending, _ := dq.Get(id)
+	if resp.Status != "extended" || resp.ExpiresAt != pending.ExpiresAt.Format(time.RFC3339) {
+		t.Errorf("response = %+v, want extended until %v", resp, pending.ExpiresAt)
+	}
+}
+
+func TestTemplates_RequestPartial_Deadline(t *testing.T) {
+	var buf strings.Builder
+	req := newTemplateRequest(&PendingRequest{ID: "abc", Cmd: "ls", ExpiresAt: time.Now().Add(time.Minute)})
+	if err := templates.ExecuteTemplate(&buf, "request", req); err != nil {
+		t.Fatalf("ExecuteTemplate() error = %v", err)
+	}
+	if !strings.Contains(buf.String(), `data-action="/extend/abc"`) {
+		t.Error("card with a deadline should offer an extend button")
+	}
+
+	buf.Reset()
+	if err := templates.ExecuteTemplate(&buf, "request", newTemplateRequest(&PendingRequest{ID: "abc", Cmd: "ls"})); err != nil {
+		t.Fatalf("ExecuteTemplate() error = %v", err)
+	}
+	if strings.Contains(buf.String(), "/extend/") || !strings.Contains(buf.String(), "Waits until decided") {
+		t.Error("held card should say it waits until decided, without an extend button")
+	}
+}
@@ -0,0 +1,113 @@
+package approval
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/xdg/cloister/internal/audit"
+)
+
+// DefaultHistoryLimit is the number of decisions GET /history returns when
+// no limit is given.
+const DefaultHistoryLimit = 100
+
+// historyResponse is the response body for GET /history.
+type historyResponse struct {
+	Decisions []audit.Decision `json:"decisions"`
+}
+
+// templateDecision holds a past decision for template rendering.
+type templateDecision struct {
+	Time     string
+	Type     string
+	Project  string
+	Cloister string
+	Subject  string // Command or domain
+	Outcome  string
+	Label    string // Human-readable outcome
+	Actor    string
+	Scope    string
+	Pattern  string
+	Reason   string
+	Exit     string // Empty until the command completes
+	Duration string
+}
+
+// newTemplateDecisions converts decisions to their template form.
+func newTemplateDecisions(decisions []audit.Decision) []templateDecision {
+	result := make([]templateDecision, len(decisions))
+	for i, d := range decisions {
+		td := templateDecision{
+			Time:     d.Time.Format(time.RFC3339),
+			Type:     d.Type,
+			Project:  d.Project,
+			Cloister: d.Cloister,
+			Subject:  d.Cmd,
+			Outcome:  d.Outcome,
+			Label:    strings.ReplaceAll(d.Outcome, "_", "-"),
+			Actor:    d.Actor,
+			Scope:    d.Scope,
+			Pattern:  d.Pattern,
+			Reason:   d.Reason,
+		}
+		if d.Type == audit.DecisionDomain {
+			td.Subject = d.Domain
+		}
+		if d.ExitCode != nil {
+			td.Exit = strconv.Itoa(*d.ExitCode)
+			td.Duration = (time.Duration(d.DurationMs) * time.Millisecond).String()
+		}
+		result[i] = td
+	}
+	return result
+}
+
+// parseHistoryFilter reads the project, cloister, type, and limit query
+// parameters of GET /history.
+func parseHistoryFilter(r *http.Request) (audit.HistoryFilter, string) {
+	q := r.URL.Query()
+	f := audit.HistoryFilter{
+		Project:  q.Get("project"),
+		Cloister: q.Get("cloister"),
+		Type:     q.Get("type"),
+		Limit:    DefaultHistoryLimit,
+	}
+	if f.Type != "" && f.Type != audit.DecisionHostexec && f.Type != audit.DecisionDomain {
+		return f, "type must be hostexec or domain"
+	}
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			return f, "limit must be a positive integer"
+		}
+		f.Limit = n
+	}
+	return f, ""
+}
+
+// handleHistory returns recent hostexec and domain decisions, newest first,
+// as JSON or as an HTML fragment for the UI's history tab.
+func (s *Server) handleHistory(w http.ResponseWriter, r *http.Request) {
+	filter, problem := parseHistoryFilter(r)
+	if problem != "" {
+		s.writeError(w, http.StatusBadRequest, problem)
+		return
+	}
+
+	decisions := []audit.Decision{}
+	if s.History != nil {
+		decisions = s.History.List(filter)
+	}
+
+	if strings.Contains(r.Header.Get("Accept"), "text/html") {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		if err := templates.ExecuteTemplate(w, "history", newTemplateDecisions(decisions)); err != nil {
+			http.Error(w, "template error", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	s.writeJSON(w, http.StatusOK, historyResponse{Decisions: decisions})
+}
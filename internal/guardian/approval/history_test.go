@@ -0,0 +1,122 @@
+package approval
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/xdg/cloister/internal/audit"
+)
+
+// newHistoryTestServer returns a server whose history holds one hostexec and
+// one domain decision.
+func newHistoryTestServer(t *testing.T) *Server {
+	t.Helper()
+	history := audit.NewHistory(0)
+	logger := audit.NewLogger(nil)
+	logger.AddObserver(history)
+	_ = logger.LogApprove("", "api", "api-main", "make deploy", "alice")
+	_ = logger.LogComplete("", "api", "api-main", "make deploy", 0, 2*time.Second)
+	_ = logger.LogDomainDenyBy("", "web", "web-main", "evil.com", "bob", "suspicious")
+
+	server := NewServer(NewQueue(), logger)
+	server.History = history
+	return server
+}
+
+func TestServer_HandleHistory_JSON(t *testing.T) {
+	server := newHistoryTestServer(t)
+
+	req := httptest.NewRequest(http.MethodGet, "/history", http.NoBody)
+	rr := httptest.NewRecorder()
+	server.handleHistory(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, rr.Code)
+	}
+	var resp historyResponse
+	if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if len(resp.Decisions) != 2 {
+		t.Fatalf("expected 2 decisions, got %d", len(resp.Decisions))
+	}
+	if d := resp.Decisions[0]; d.Domain != "evil.com" || d.Outcome != audit.OutcomeDenied || d.Actor != "bob" {
+		t.Errorf("newest decision = %+v, want domain denial by bob", d)
+	}
+	if d := resp.Decisions[1]; d.ExitCode == nil || *d.ExitCode != 0 || d.DurationMs != 2000 {
+		t.Errorf("hostexec decision = %+v, want exit 0 in 2000ms", d)
+	}
+}
+
+func TestServer_HandleHistory_Filters(t *testing.T) {
+	server := newHistoryTestServer(t)
+
+	tests := []struct {
+		query string
+		want  int
+	}{
+		{"?project=api", 1},
+		{"?cloister=web-main", 1},
+		{"?type=hostexec", 1},
+		{"?type=domain&project=api", 0},
+		{"?limit=1", 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			server.handleHistory(rr, httptest.NewRequest(http.MethodGet, "/history"+tt.query, http.NoBody))
+
+			var resp historyResponse
+			if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if len(resp.Decisions) != tt.want {
+				t.Errorf("got %d decisions, want %d", len(resp.Decisions), tt.want)
+			}
+		})
+	}
+}
+
+func TestServer_HandleHistory_BadQuery(t *testing.T) {
+	server := newHistoryTestServer(t)
+
+	for _, query := range []string{"?type=proxy", "?limit=0", "?limit=many"} {
+		rr := httptest.NewRecorder()
+		server.handleHistory(rr, httptest.NewRequest(http.MethodGet, "/history"+query, http.NoBody))
+		if rr.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected status %d, got %d", query, http.StatusBadRequest, rr.Code)
+		}
+	}
+}
+
+func TestServer_HandleHistory_HTML(t *testing.T) {
+	server := newHistoryTestServer(t)
+
+	req := httptest.NewRequest(http.MethodGet, "/history", http.NoBody)
+	req.Header.Set("Accept", "text/html")
+	rr := httptest.NewRecorder()
+	server.handleHistory(rr, req)
+
+	body := rr.Body.String()
+	for _, want := range []string{"make deploy", "evil.com", "suspicious", "alice", "history-denied", "2s"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("history HTML missing %q:\n%s", want, body)
+		}
+	}
+}
+
+func TestServer_HandleHistory_NilHistory(t *testing.T) {
+	server := NewServer(NewQueue(), nil)
+
+	rr := httptest.NewRecorder()
+	server.handleHistory(rr, httptest.NewRequest(http.MethodGet, "/history", http.NoBody))
+
+	if !bytes.Contains(rr.Body.Bytes(), []byte(`"decisions":[]`)) {
+		t.Errorf("expected empty decisions array, got %s", rr.Body.String())
+	}
+}
@@ -0,0 +1,187 @@
+package approval
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/xdg/cloister/internal/audit"
+)
+
+// LogEntry is one audit log entry as sent on the GET /logs stream.
+type LogEntry struct {
+	Time     time.Time `json:"time"`
+	Category string    `json:"category"` // "hostexec" or "domain"
+	Type     string    `json:"type"`
+	Project  string    `json:"project"`
+	Cloister string    `json:"cloister"`
+	Cmd      string    `json:"cmd,omitempty"`
+	Domain   string    `json:"domain,omitempty"`
+	Line     string    `json:"line"` // The entry as written to the audit log file
+}
+
+// newLogEntry converts an audit event to its wire form.
+func newLogEntry(e *audit.Event) LogEntry {
+	return LogEntry{
+		Time:     e.Timestamp,
+		Category: e.Category(),
+		Type:     string(e.Type),
+		Project:  e.Project,
+		Cloister: e.Cloister,
+		Cmd:      e.Cmd,
+		Domain:   e.Domain,
+		Line:     e.Format(),
+	}
+}
+
+// LogQuery selects entries from GET /logs.
+type LogQuery struct {
+	Cloister string
+	Project  string
+	Types    []string // Event types or categories; see audit.Filter
+	Tail     int      // Number of recent entries to send first
+	Follow   bool     // Keep streaming new entries after the backlog
+}
+
+// values encodes the query as URL parameters.
+func (q LogQuery) values() url.Values {
+	v := url.Values{}
+	if q.Cloister != "" {
+		v.Set("cloister", q.Cloister)
+	}
+	if q.Project != "" {
+		v.Set("project", q.Project)
+	}
+	if len(q.Types) > 0 {
+		v.Set("type", strings.Join(q.Types, ","))
+	}
+	v.Set("tail", strconv.Itoa(q.Tail))
+	v.Set("follow", strconv.FormatBool(q.Follow))
+	return v
+}
+
+// parseLogQuery reads the GET /logs query parameters. Follow defaults to
+// true and tail to zero. Returns a problem description if they are invalid.
+func parseLogQuery(r *http.Request) (LogQuery, string) {
+	q := r.URL.Query()
+	lq := LogQuery{Cloister: q.Get("cloister"), Project: q.Get("project"), Follow: true}
+	if v := q.Get("type"); v != "" {
+		for t := range strings.SplitSeq(v, ",") {
+			t = strings.TrimSpace(t)
+			if !audit.IsKnownType(t) {
+				return lq, fmt.Sprintf("unknown event type %q", t)
+			}
+			lq.Types = append(lq.Types, t)
+		}
+	}
+	if v := q.Get("tail"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return lq, "tail must be a non-negative integer"
+		}
+		lq.Tail = n
+	}
+	if v := q.Get("follow"); v != "" {
+		follow, err := strconv.ParseBool(v)
+		if err != nil {
+			return lq, "follow must be true or false"
+		}
+		lq.Follow = follow
+	}
+	return lq, ""
+}
+
+// handleLogs streams audit log entries as Server-Sent Events, filtered by
+// cloister, project, and event type. Up to tail recent entries are sent
+// first; with follow=false the stream then ends.
+func (s *Server) handleLogs(w http.ResponseWriter, r *http.Request) {
+	lq, problem := parseLogQuery(r)
+	if problem != "" {
+		s.writeError(w, http.StatusBadRequest, problem)
+		return
+	}
+	if s.Logs == nil {
+		s.writeError(w, http.StatusServiceUnavailable, "audit log stream not configured")
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "SSE not supported", http.StatusInternalServerError)
+		return
+	}
+
+	filter := audit.Filter{Cloister: lq.Cloister, Project: lq.Project, Types: lq.Types}
+	recent, ch := s.Logs.Subscribe(filter, lq.Tail)
+	defer s.Logs.Unsubscribe(ch)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+
+	for _, e := range recent {
+		if !writeLogEvent(w, e) {
+			return
+		}
+	}
+	flusher.Flush()
+	if !lq.Follow || ch == nil {
+		return
+	}
+
+	ticker := time.NewTicker(HeartbeatInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-ticker.C:
+			if _, err := fmt.Fprint(w, FormatSSE(Event{Type: EventHeartbeat})); err != nil {
+				return
+			}
+		case e, ok := <-ch:
+			if !ok || !writeLogEvent(w, e) {
+				return
+			}
+		}
+		flusher.Flush()
+	}
+}
+
+// writeLogEvent writes one audit event as an SSE log event. Reports whether
+// the write succeeded.
+func writeLogEvent(w http.ResponseWriter, e *audit.Event) bool {
+	data, err := json.Marshal(newLogEntry(e))
+	if err != nil {
+		return false
+	}
+	_, err = fmt.Fprint(w, FormatSSE(Event{Type: EventLog, Data: string(data)}))
+	return err == nil
+}
+
+// Logs streams audit log entries matching q, calling onEntry for each, until
+// ctx is cancelled or the stream ends. Without q.Follow the stream ends after
+// the backlog.
+func (c *Client) Logs(ctx context.Context, q LogQuery, onEntry func(LogEntry)) error {
+	var decodeErr error
+	err := c.stream(ctx, "/logs?"+q.values().Encode(), func(ev Event) {
+		if ev.Type != EventLog || decodeErr != nil {
+			return
+		}
+		var entry LogEntry
+		if err := json.Unmarshal([]byte(ev.Data), &entry); err != nil {
+			decodeErr = fmt.Errorf("failed to decode log entry: %w", err)
+			return
+		}
+		onEntry(entry)
+	})
+	if err != nil {
+		return err
+	}
+	return decodeErr
+}
@@ -0,0 +1,116 @@
+package approval
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/xdg/cloister/internal/audit"
+)
+
+// newLogsTestClient serves handleLogs for stream and returns a client for it.
+func newLogsTestClient(t *testing.T, stream *audit.Stream) *Client {
+	t.Helper()
+	server := NewServer(NewQueue(), nil)
+	server.Logs = stream
+	ts := httptest.NewServer(http.HandlerFunc(server.handleLogs))
+	t.Cleanup(ts.Close)
+	t.Cleanup(func() {
+		if stream != nil {
+			stream.Close()
+		}
+	})
+	return &Client{BaseURL: ts.URL}
+}
+
+func TestServer_HandleLogs_Backlog(t *testing.T) {
+	stream := audit.NewStream(0)
+	logger := audit.NewLogger(nil)
+	logger.AddObserver(stream)
+	_ = logger.LogRequest("", "api", "api-main", "make test")
+	_ = logger.LogDomainRequest("", "api", "api-main", "x.io")
+	_ = logger.LogRequest("", "web", "web-main", "npm test")
+
+	c := newLogsTestClient(t, stream)
+	var got []LogEntry
+	err := c.Logs(context.Background(), LogQuery{Cloister: "api-main", Tail: 10}, func(e LogEntry) {
+		got = append(got, e)
+	})
+	if err != nil {
+		t.Fatalf("Logs() error = %v", err)
+	}
+
+	if len(got) != 2 {
+		t.Fatalf("got %d entries, want 2: %+v", len(got), got)
+	}
+	if got[0].Type != "REQUEST" || got[0].Cmd != "make test" || got[0].Category != "hostexec" {
+		t.Errorf("first entry = %+v", got[0])
+	}
+	if !strings.Contains(got[1].Line, `DOMAIN DOMAIN_REQUEST project=api cloister=api-main domain="x.io"`) {
+		t.Errorf("second entry line = %q", got[1].Line)
+	}
+}
+
+func TestServer_HandleLogs_Follow(t *testing.T) {
+	stream := audit.NewStream(0)
+	c := newLogsTestClient(t, stream)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	entries := make(chan LogEntry, 4)
+	done := make(chan error, 1)
+	go func() {
+		done <- c.Logs(ctx, LogQuery{Types: []string{"domain"}, Follow: true}, func(e LogEntry) {
+			entries <- e
+		})
+	}()
+
+	// Publish until the subscriber is attached and receives the event.
+	ticker := time.NewTicker(10 * time.Millisecond)
+	defer ticker.Stop()
+	var got LogEntry
+wait:
+	for {
+		select {
+		case got = <-entries:
+			break wait
+		case <-ticker.C:
+			stream.Observe(&audit.Event{Type: audit.EventRequest, Cloister: "c", Cmd: "filtered out"})
+			stream.Observe(&audit.Event{Type: audit.EventDomainApprove, Cloister: "c", Domain: "x.io"})
+		case <-ctx.Done():
+			t.Fatal("timed out waiting for live entry")
+		}
+	}
+	if got.Type != "DOMAIN_APPROVE" || got.Domain != "x.io" {
+		t.Errorf("live entry = %+v", got)
+	}
+
+	cancel()
+	<-done
+}
+
+func TestServer_HandleLogs_BadQuery(t *testing.T) {
+	server := NewServer(NewQueue(), nil)
+	server.Logs = audit.NewStream(0)
+
+	for _, query := range []string{"?type=proxy", "?tail=-1", "?follow=maybe"} {
+		rr := httptest.NewRecorder()
+		server.handleLogs(rr, httptest.NewRequest(http.MethodGet, "/logs"+query, http.NoBody))
+		if rr.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected status %d, got %d", query, http.StatusBadRequest, rr.Code)
+		}
+	}
+}
+
+func TestServer_HandleLogs_NotConfigured(t *testing.T) {
+	server := NewServer(NewQueue(), nil)
+
+	rr := httptest.NewRecorder()
+	server.handleLogs(rr, httptest.NewRequest(http.MethodGet, "/logs", http.NoBody))
+	if rr.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected status %d, got %d", http.StatusServiceUnavailable, rr.Code)
+	}
+}
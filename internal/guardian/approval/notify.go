@@ -0,0 +1,67 @@
+package approval
+
+import "time"
+
+// NotificationEvent identifies the point in a request's life that triggered
+// a notification.
+type NotificationEvent string
+
+const (
+	// NotifyPending is sent when a new request is queued for approval.
+	NotifyPending NotificationEvent = "pending"
+	// NotifyTimeout is sent when a request expires without a decision.
+	NotifyTimeout NotificationEvent = "timeout"
+)
+
+// Request types reported in notifications.
+const (
+	RequestTypeHostexec = "hostexec"
+	RequestTypeDomain   = "domain"
+)
+
+// Notification describes an approval request event for external sinks.
+type Notification struct {
+	Event     NotificationEvent `json:"event"`
+	Type      string            `json:"type"` // RequestTypeHostexec or RequestTypeDomain
+	ID        string            `json:"id"`
+	Cloister  string            `json:"cloister"`
+	Project   string            `json:"project"`
+	Cmd       string            `json:"cmd,omitempty"`
+	Domain    string            `json:"domain,omitempty"`
+	Timestamp time.Time         `json:"timestamp"`
+	ExpiresAt time.Time         `json:"expires_at,omitzero"`
+}
+
+// Notifier receives approval request events. Notify is called from the
+// queue's goroutines and must not block.
+type Notifier interface {
+	Notify(n Notification)
+}
+
+// newCommandNotification builds a notification for a hostexec request.
+func newCommandNotification(event NotificationEvent, req *PendingRequest, expiresAt time.Time) Notification {
+	return Notification{
+		Event:     event,
+		Type:      RequestTypeHostexec,
+		ID:        req.ID,
+		Cloister:  req.Cloister,
+		Project:   req.Project,
+		Cmd:       req.Cmd,
+		Timestamp: time.Now(),
+		ExpiresAt: expiresAt,
+	}
+}
+
+// newDomainNotification builds a notification for a domain request.
+func newDomainNotification(event NotificationEvent, req *DomainRequest) Notification {
+	return Notification{
+		Event:     event,
+		Type:      RequestTypeDomain,
+		ID:        req.ID,
+		Cloister:  req.Cloister,
+		Project:   req.Project,
+		Domain:    req.Domain,
+		Timestamp: time.Now(),
+		ExpiresAt: req.ExpiresAt,
+	}
+}
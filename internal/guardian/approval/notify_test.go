@@ -0,0 +1,106 @@
+package approval
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+// recordingNotifier collects notifications for inspection.
+type recordingNotifier struct {
+	mu   sync.Mutex
+	sent []Notification
+}
+
+func (r *recordingNotifier) Notify(n Notification) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.sent = append(r.sent, n)
+}
+
+// waitFor polls until n notifications have been recorded.
+func (r *recordingNotifier) waitFor(t *testing.T, n int) []Notification {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		r.mu.Lock()
+		if len(r.sent) >= n {
+			sent := append([]Notification(nil), r.sent...)
+			r.mu.Unlock()
+			return sent
+		}
+		r.mu.Unlock()
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("timed out waiting for %d notifications", n)
+	return nil
+}
+
+func TestQueue_NotifiesPendingAndTimeout(t *testing.T) {
+	q := NewQueueWithTimeout(20 * time.Millisecond)
+	rec := &recordingNotifier{}
+	q.SetNotifier(rec)
+
+	id, err := q.Add(&PendingRequest{Cloister: "c1", Project: "p", Cmd: "make test", Response: make(chan Response, 1)})
+	if err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+
+	sent := rec.waitFor(t, 2)
+	pending, timeout := sent[0], sent[1]
+	if pending.Event != NotifyPending || pending.Type != RequestTypeHostexec || pending.ID != id ||
+		pending.Cmd != "make test" || pending.Project != "p" || pending.ExpiresAt.IsZero() {
+		t.Errorf("pending notification = %+v", pending)
+	}
+	if timeout.Event != NotifyTimeout || timeout.ID != id || timeout.Cloister != "c1" {
+		t.Errorf("timeout notification = %+v", timeout)
+	}
+}
+
+func TestQueue_NoTimeoutNotificationWhenDecided(t *testing.T) {
+	q := NewQueueWithTimeout(20 * time.Millisecond)
+	rec := &recordingNotifier{}
+	q.SetNotifier(rec)
+
+	id, err := q.Add(&PendingRequest{Cloister: "c1", Cmd: "ls"})
+	if err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	q.Remove(id)
+	time.Sleep(50 * time.Millisecond)
+
+	if sent := rec.waitFor(t, 1); len(sent) != 1 {
+		t.Errorf("notifications = %+v, want only the pending one", sent)
+	}
+}
+
+func TestDomainQueue_NotifiesOncePerRequest(t *testing.T) {
+	dq := NewDomainQueueWithTimeout(20 * time.Millisecond)
+	rec := &recordingNotifier{}
+	dq.SetNotifier(rec)
+
+	req := func() *DomainRequest {
+		return &DomainRequest{
+			Cloister: "c1", Project: "p", Domain: "api.example.com", Token: "tok",
+			Responses: []chan<- DomainResponse{make(chan DomainResponse, 1)},
+		}
+	}
+	id, err := dq.Add(req())
+	if err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	if _, err := dq.Add(req()); err != nil { // Coalesced duplicate
+		t.Fatalf("second Add() error = %v", err)
+	}
+
+	sent := rec.waitFor(t, 2)
+	if len(sent) != 2 {
+		t.Fatalf("notifications = %+v, want pending and timeout only", sent)
+	}
+	if sent[0].Event != NotifyPending || sent[0].Type != RequestTypeDomain || sent[0].Domain != "api.example.com" {
+		t.Errorf("pending notification = %+v", sent[0])
+	}
+	if sent[1].Event != NotifyTimeout || sent[1].ID != id {
+		t.Errorf("timeout notification = %+v", sent[1])
+	}
+}
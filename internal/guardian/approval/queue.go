@@ -6,14 +6,66 @@ import (
 	"context"
 	"crypto/rand"
 	"encoding/hex"
+	"errors"
 	"fmt"
+	"slices"
 	"sync"
 	"time"
+
+	"github.com/xdg/cloister/internal/audit"
+	"github.com/xdg/cloister/internal/guardian/risk"
 )
 
 // DefaultTimeout is the default timeout for pending requests (5 minutes).
 const DefaultTimeout = 5 * time.Minute
 
+// DefaultExtension is how much Extend adds to a deadline when the approver
+// does not say, and MaxExtension is the most one call may add.
+const (
+	DefaultExtension = 5 * time.Minute
+	MaxExtension     = time.Hour
+)
+
+// MaxDenyMessageLen is the longest feedback message an approver may send
+// back with a denial.
+const MaxDenyMessageLen = 1000
+
+// ErrAlreadyApproved is returned when an approver approves a request they
+// have already approved.
+var ErrAlreadyApproved = errors.New("already approved by this approver")
+
+// ErrScopeMismatch is returned when an approver approves a domain request
+// with a different scope or pattern than the approvers before them.
+var ErrScopeMismatch = errors.New("scope and pattern differ from earlier approvals")
+
+// ErrNoDeadline is returned when extending a request that is held until it
+// is decided.
+var ErrNoDeadline = errors.New("request has no deadline")
+
+// Tally is the approval state of a request that needs a quorum.
+type Tally struct {
+	Approvals []string `json:"approvals,omitempty"` // Approvers so far, in order
+	Required  int      `json:"required"`            // Distinct approvers needed
+}
+
+// Done reports whether the quorum has been reached.
+func (t Tally) Done() bool {
+	return len(t.Approvals) >= t.Required
+}
+
+// newTally returns the tally of a request, treating 0 required as 1.
+func newTally(approvals []string, required int) Tally {
+	return Tally{Approvals: slices.Clone(approvals), Required: max(required, 1)}
+}
+
+// vote adds approver to approvals unless they have already approved.
+func vote(approvals []string, approver string) ([]string, error) {
+	if slices.Contains(approvals, approver) {
+		return approvals, ErrAlreadyApproved
+	}
+	return append(approvals, approver), nil
+}
+
 // Response represents the result of an approval decision.
 // This type is defined locally to avoid import cycles with the request package.
 // It has the same JSON structure as request.CommandResponse.
@@ -21,11 +73,19 @@ type Response struct {
 	Status   string `json:"status"`
 	Pattern  string `json:"pattern,omitempty"`
 	Reason   string `json:"reason,omitempty"`
+	Message  string `json:"message,omitempty"` // Approver's feedback on a denial, verbatim
 	ExitCode int    `json:"exit_code,omitempty"`
 	Stdout   string `json:"stdout,omitempty"`
 	Stderr   string `json:"stderr,omitempty"`
 }
 
+// ActionParam is a named hostexec action parameter and its value,
+// shown to the approver in place of a raw command line.
+type ActionParam struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
 // PendingRequest represents a command execution request awaiting human approval.
 type PendingRequest struct {
 	ID        string
@@ -33,8 +93,28 @@ type PendingRequest struct {
 	Project   string
 	Agent     string
 	Cmd       string
+	Action    string        // Named action, if the request came from "hostexec --action"
+	Params    []ActionParam // Action parameter values, in declaration order
 	Timestamp time.Time
 	Response  chan<- Response // Channel to send result back
+
+	// Context shown to the approver. All fields are optional.
+	Workdir  string           // Host directory the command runs in; empty means the executor's own
+	Pattern  string           // The manual_approve pattern, or "action:<name>", that matched
+	Args     []risk.Arg       // Argv with risky arguments flagged
+	Worktree string           // Host path of the cloister's worktree
+	Recent   []audit.Decision // Recent hostexec decisions for the cloister, newest first
+
+	// Quorum. The request stays pending until RequireApprovals distinct
+	// approvers have approved it; 0 or 1 means one.
+	RequireApprovals int
+	Approvals        []string // Approvers so far, in order
+
+	// Timeout overrides the queue's timeout for this request; a negative
+	// value holds it until it is decided. ExpiresAt is set by Add and
+	// Extend, and is zero for held requests.
+	Timeout   time.Duration
+	ExpiresAt time.Time
 }
 
 // Queue manages pending approval requests with thread-safe operations.
@@ -44,6 +124,7 @@ type Queue struct {
 	cancels  map[string]context.CancelFunc // Cancel functions for timeout goroutines
 	timeout  time.Duration
 	events   *EventHub // Optional event hub for SSE broadcasts
+	notifier Notifier  // Optional sink for pending/timeout notifications
 }
 
 // NewQueue creates a new empty approval queue with the default timeout.
@@ -68,54 +149,86 @@ func (q *Queue) SetEventHub(hub *EventHub) {
 	q.events = hub
 }
 
-// Add adds a new pending request to the queue and returns its generated ID.
-// The ID is generated using crypto/rand (8 bytes = 16 hex characters).
-// A timeout goroutine is started that will send a timeout response on the
-// request's Response channel if the request is not approved/denied in time.
-// If an EventHub is configured, a request-added event is broadcast to SSE clients.
+// SetNotifier sets the notifier told when requests are added or time out.
+func (q *Queue) SetNotifier(n Notifier) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.notifier = n
+}
+
+// Add adds a new pending request to the queue and returns its ID. A request
+// with an ID already set (such as its audit request ID) keeps it; otherwise
+// one is generated using crypto/rand (8 bytes = 16 hex characters).
+// Unless the request is held until decided, a timeout goroutine is started
+// that will send a timeout response on the request's Response channel if
+// the request is not approved/denied in time.
+// If an EventHub is configured, a request-added event is broadcast to SSE clients,
+// and if a Notifier is configured, it is told about the new request.
 func (q *Queue) Add(req *PendingRequest) (string, error) {
-	id, err := generateID()
-	if err != nil {
-		return "", err
+	id := req.ID
+	if id == "" {
+		var err error
+		if id, err = generateID(); err != nil {
+			return "", err
+		}
 	}
 
 	ctx, cancel := context.WithCancel(context.Background())
 
+	timeout := q.timeout
+	if req.Timeout != 0 {
+		timeout = req.Timeout
+	}
+
 	q.mu.Lock()
 	req.ID = id
+	if timeout > 0 {
+		req.ExpiresAt = time.Now().Add(timeout)
+	}
 	q.requests[id] = req
 	q.cancels[id] = cancel
 	events := q.events // Capture reference while holding lock
+	notifier := q.notifier
 	q.mu.Unlock()
 
 	// Broadcast request-added event to SSE clients
 	if events != nil {
 		events.BroadcastPendingRequestAdded(req)
 	}
+	if notifier != nil {
+		notifier.Notify(newCommandNotification(NotifyPending, req, req.ExpiresAt))
+	}
 
 	// Start timeout goroutine
-	go q.handleTimeout(ctx, id, req.Response)
+	if timeout > 0 {
+		go q.handleTimeout(ctx, id, req.Response, timeout)
+	}
 
 	return id, nil
 }
 
 // handleTimeout waits for the timeout duration and sends a timeout response
-// if the context has not been canceled (i.e., request not approved/denied).
-// If an EventHub is configured, a request-removed event is broadcast to SSE clients.
-func (q *Queue) handleTimeout(ctx context.Context, id string, respChan chan<- Response) {
+// if the context has not been canceled (i.e., request not approved/denied
+// and its deadline not extended).
+// If an EventHub is configured, a request-removed event is broadcast to SSE clients,
+// and if a Notifier is configured, it is told about the timeout.
+func (q *Queue) handleTimeout(ctx context.Context, id string, respChan chan<- Response, timeout time.Duration) {
 	select {
 	case <-ctx.Done():
 		// Request was approved/denied before timeout, do nothing
 		return
-	case <-time.After(q.timeout):
-		// Timeout reached, send timeout response
+	case <-time.After(timeout):
+		// Timeout reached, send timeout response. Extend cancels ctx under
+		// the lock, so a canceled ctx here means a newer deadline applies.
 		q.mu.Lock()
-		_, exists := q.requests[id]
+		req, exists := q.requests[id]
+		exists = exists && ctx.Err() == nil
 		if exists {
 			delete(q.requests, id)
 			delete(q.cancels, id)
 		}
 		events := q.events // Capture reference while holding lock
+		notifier := q.notifier
 		q.mu.Unlock()
 
 		// Only send timeout response if the request was still pending
@@ -124,6 +237,9 @@ func (q *Queue) handleTimeout(ctx context.Context, id string, respChan chan<- Re
 			if events != nil {
 				events.BroadcastRequestRemoved(id)
 			}
+			if notifier != nil {
+				notifier.Notify(newCommandNotification(NotifyTimeout, req, time.Time{}))
+			}
 
 			if respChan != nil {
 				respChan <- Response{
@@ -158,6 +274,105 @@ func (q *Queue) Remove(id string) {
 	delete(q.requests, id)
 }
 
+// Take removes a pending request from the queue, cancels its timeout
+// goroutine, and returns it. It is how a request is denied: a request taken
+// cannot also reach its quorum or time out. Returns nil and false if the
+// request is not in the queue.
+func (q *Queue) Take(id string) (*PendingRequest, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	req, ok := q.requests[id]
+	if !ok {
+		return nil, false
+	}
+	if cancel, ok := q.cancels[id]; ok {
+		cancel()
+		delete(q.cancels, id)
+	}
+	delete(q.requests, id)
+	return req, true
+}
+
+// Approve records approver's approval of a pending request and returns a
+// copy of the request and its tally. Once the tally is done the request is removed from
+// the queue and its timeout canceled; the caller then sends the response.
+// Returns ErrNotPending if the request is not in the queue and
+// ErrAlreadyApproved if approver has already approved it.
+func (q *Queue) Approve(id, approver string) (PendingRequest, Tally, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	req, ok := q.requests[id]
+	if !ok {
+		return PendingRequest{}, Tally{}, ErrNotPending
+	}
+	approvals, err := vote(req.Approvals, approver)
+	if err != nil {
+		return PendingRequest{}, Tally{}, err
+	}
+	req.Approvals = approvals
+	tally := newTally(approvals, req.RequireApprovals)
+	if tally.Done() {
+		if cancel, ok := q.cancels[id]; ok {
+			cancel()
+			delete(q.cancels, id)
+		}
+		delete(q.requests, id)
+	}
+	snapshot := *req
+	snapshot.Approvals = tally.Approvals
+	return snapshot, tally, nil
+}
+
+// Extend moves a pending request's deadline back by d and restarts its
+// timeout goroutine, returning a copy of the updated request. Returns
+// ErrNotPending if the request is not in the queue and ErrNoDeadline if it
+// is held until decided.
+func (q *Queue) Extend(id string, d time.Duration) (PendingRequest, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	req, ok := q.requests[id]
+	if !ok {
+		return PendingRequest{}, ErrNotPending
+	}
+	if req.ExpiresAt.IsZero() {
+		return PendingRequest{}, ErrNoDeadline
+	}
+	if cancel, ok := q.cancels[id]; ok {
+		cancel()
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	q.cancels[id] = cancel
+	req.ExpiresAt = req.ExpiresAt.Add(d)
+	go q.handleTimeout(ctx, id, req.Response, time.Until(req.ExpiresAt))
+
+	snapshot := *req
+	snapshot.Approvals = slices.Clone(req.Approvals)
+	return snapshot, nil
+}
+
+// Cancel withdraws a pending request whose requester has gone away. It is
+// like Remove but also broadcasts a request-removed event, and reports
+// whether the request was still pending.
+func (q *Queue) Cancel(id string) bool {
+	q.mu.Lock()
+	_, exists := q.requests[id]
+	if cancel, ok := q.cancels[id]; ok {
+		cancel()
+		delete(q.cancels, id)
+	}
+	delete(q.requests, id)
+	events := q.events
+	q.mu.Unlock()
+
+	if exists && events != nil {
+		events.BroadcastRequestRemoved(id)
+	}
+	return exists
+}
+
 // List returns a copy of all pending requests for the approval UI.
 // The returned slice is safe to iterate without holding locks.
 // The Response channel is excluded from the returned copies for safety.
@@ -174,7 +389,19 @@ func (q *Queue) List() []PendingRequest {
 			Project:   req.Project,
 			Agent:     req.Agent,
 			Cmd:       req.Cmd,
+			Action:    req.Action,
+			Params:    req.Params,
 			Timestamp: req.Timestamp,
+			Workdir:   req.Workdir,
+			Pattern:   req.Pattern,
+			Args:      req.Args,
+			Worktree:  req.Worktree,
+			Recent:    req.Recent,
+
+			RequireApprovals: req.RequireApprovals,
+			Approvals:        slices.Clone(req.Approvals),
+			Timeout:          req.Timeout,
+			ExpiresAt:        req.ExpiresAt,
 			// Response channel intentionally omitted
 		})
 	}
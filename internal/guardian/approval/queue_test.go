@@ -1,6 +1,8 @@
 package approval
 
 import (
+	"errors"
+	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -299,6 +301,39 @@ func TestQueue_GetNotFound(t *testing.T) {
 	}
 }
 
+func TestQueue_CancelBroadcastsRemoval(t *testing.T) {
+	q := NewQueue()
+	hub := NewEventHub()
+	q.SetEventHub(hub)
+
+	id, err := q.Add(&PendingRequest{Cloister: "c", Project: "p", Cmd: "git push", Timestamp: time.Now()})
+	if err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+
+	ch := hub.Subscribe()
+	defer hub.Unsubscribe(ch)
+
+	if !q.Cancel(id) {
+		t.Error("Cancel() = false, want true for pending request")
+	}
+	if q.Len() != 0 {
+		t.Errorf("Len() = %d, want 0", q.Len())
+	}
+	select {
+	case event := <-ch:
+		if event.Type != EventRequestRemoved || !strings.Contains(event.Data, id) {
+			t.Errorf("event = %+v, want request-removed for %s", event, id)
+		}
+	case <-time.After(100 * time.Millisecond):
+		t.Fatal("timeout waiting for request-removed event")
+	}
+
+	if q.Cancel(id) {
+		t.Error("Cancel() = true for already removed request, want false")
+	}
+}
+
 func TestQueue_Remove(t *testing.T) {
 	q := NewQueue()
 
@@ -754,3 +789,153 @@ func TestQueue_NoBroadcastWithoutEventHub(t *testing.T) {
 		t.Errorf("Len() = %d, want 1", q.Len())
 	}
 }
+
+func TestQueue_ApproveQuorum(t *testing.T) {
+	q := NewQueueWithTimeout(time.Minute)
+	id, err := q.Add(&PendingRequest{Cmd: "make deploy", Timestamp: time.Now(), RequireApprovals: 2})
+	if err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+
+	req, tally, err := q.Approve(id, "alice")
+	if err != nil {
+		t.Fatalf("Approve(alice) error = %v", err)
+	}
+	if tally.Done() || tally.Required != 2 {
+		t.Errorf("tally after alice = %+v, want 1 of 2", tally)
+	}
+	if len(req.Approvals) != 1 || req.Approvals[0] != "alice" {
+		t.Errorf("Approvals = %v, want [alice]", req.Approvals)
+	}
+	if _, ok := q.Get(id); !ok {
+		t.Fatal("request should stay pending until the quorum approves")
+	}
+
+	if _, _, err := q.Approve(id, "alice"); !errors.Is(err, ErrAlreadyApproved) {
+		t.Errorf("second Approve(alice) error = %v, want ErrAlreadyApproved", err)
+	}
+
+	_, tally, err = q.Approve(id, "bob")
+	if err != nil {
+		t.Fatalf("Approve(bob) error = %v", err)
+	}
+	if !tally.Done() || strings.Join(tally.Approvals, ",") != "alice,bob" {
+		t.Errorf("tally after bob = %+v, want done by alice,bob", tally)
+	}
+	if q.Len() != 0 {
+		t.Errorf("queue len = %d, want 0 after quorum", q.Len())
+	}
+	if _, _, err := q.Approve(id, "carol"); !errors.Is(err, ErrNotPending) {
+		t.Errorf("Approve() after quorum error = %v, want ErrNotPending", err)
+	}
+}
+
+func TestQueue_ApproveSingle(t *testing.T) {
+	q := NewQueue()
+	id, err := q.Add(&PendingRequest{Cmd: "ls", Timestamp: time.Now()})
+	if err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	_, tally, err := q.Approve(id, "alice")
+	if err != nil {
+		t.Fatalf("Approve() error = %v", err)
+	}
+	if !tally.Done() || tally.Required != 1 {
+		t.Errorf("tally = %+v, want done with 1 required", tally)
+	}
+	if q.Len() != 0 {
+		t.Errorf("queue len = %d, want 0", q.Len())
+	}
+}
+
+func TestQueue_AddKeepsPresetID(t *testing.T) {
+	q := NewQueue()
+	id, err := q.Add(&PendingRequest{ID: "0123456789abcdef", Cmd: "ls", Response: make(chan Response, 1)})
+	if err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	if id != "0123456789abcdef" {
+		t.Errorf("Add() id = %q, want the preset ID", id)
+	}
+	if _, ok := q.Get(id); !ok {
+		t.Error("request not found under its preset ID")
+	}
+	q.Remove(id)
+}
+
+func TestQueue_PerRequestTimeout(t *testing.T) {
+	q := NewQueueWithTimeout(time.Minute)
+	respChan := make(chan Response, 1)
+	if _, err := q.Add(&PendingRequest{Cmd: "ls", Response: respChan, Timeout: 30 * time.Millisecond}); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+
+	select {
+	case resp := <-respChan:
+		if resp.Status != "timeout" {
+			t.Errorf("status = %q, want timeout", resp.Status)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("request did not time out with its own timeout")
+	}
+}
+
+func TestQueue_HeldUntilDecided(t *testing.T) {
+	q := NewQueueWithTimeout(20 * time.Millisecond)
+	respChan := make(chan Response, 1)
+	id, err := q.Add(&PendingRequest{Cmd: "terraform plan", Response: respChan, Timeout: -1})
+	if err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	req, ok := q.Get(id)
+	if !ok {
+		t.Fatal("held request should not time out")
+	}
+	if !req.ExpiresAt.IsZero() {
+		t.Errorf("ExpiresAt = %v, want zero for a held request", req.ExpiresAt)
+	}
+	if _, err := q.Extend(id, time.Minute); !errors.Is(err, ErrNoDeadline) {
+		t.Errorf("Extend() error = %v, want ErrNoDeadline", err)
+	}
+	q.Remove(id)
+}
+
+func TestQueue_Extend(t *testing.T) {
+	q := NewQueueWithTimeout(50 * time.Millisecond)
+	respChan := make(chan Response, 1)
+	id, err := q.Add(&PendingRequest{Cmd: "ls", Response: respChan})
+	if err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	orig, _ := q.Get(id)
+	origExpiry := orig.ExpiresAt
+
+	req, err := q.Extend(id, 150*time.Millisecond)
+	if err != nil {
+		t.Fatalf("Extend() error = %v", err)
+	}
+	if want := origExpiry.Add(150 * time.Millisecond); !req.ExpiresAt.Equal(want) {
+		t.Errorf("ExpiresAt = %v, want %v", req.ExpiresAt, want)
+	}
+
+	// The original deadline passes without a timeout.
+	time.Sleep(100 * time.Millisecond)
+	if _, ok := q.Get(id); !ok {
+		t.Fatal("extended request timed out at its original deadline")
+	}
+
+	select {
+	case resp := <-respChan:
+		if resp.Status != "timeout" {
+			t.Errorf("status = %q, want timeout", resp.Status)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("extended request never timed out")
+	}
+
+	if _, err := q.Extend(id, time.Minute); !errors.Is(err, ErrNotPending) {
+		t.Errorf("Extend() after timeout error = %v, want ErrNotPending", err)
+	}
+}
@@ -11,13 +11,13 @@ import (
 	"html/template"
 	"net"
 	"net/http"
-	"os/user"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/xdg/cloister/internal/audit"
 	"github.com/xdg/cloister/internal/clog"
+	"github.com/xdg/cloister/internal/guardian/risk"
 )
 
 //go:embed templates/*.html
@@ -67,11 +67,24 @@ type Server struct {
 	// AuditLogger logs hostexec events. If nil, no audit logging is performed.
 	AuditLogger *audit.Logger
 
+	// Executions tracks queued and running host commands. If nil, the UI
+	// shows no in-flight commands.
+	Executions *ExecutionTracker
+
+	// History indexes recent decisions for GET /history. If nil, the
+	// history is empty.
+	History *audit.History
+
+	// Logs streams audit events for GET /logs. If nil, the endpoint is
+	// unavailable.
+	Logs *audit.Stream
+
 	server       *http.Server
 	listener     net.Listener
 	mu           sync.Mutex
 	running      bool
-	userIdentity string
+	userIdentity string       // The owner's name; see SetOwner
+	auth         *sessionAuth // Nil disables authentication (tests only)
 }
 
 // NewServer creates a new approval server.
@@ -88,22 +101,13 @@ func NewServer(queue *Queue, auditLogger *audit.Logger) *Server {
 		Queue:        queue,
 		Events:       events,
 		AuditLogger:  auditLogger,
-		userIdentity: getUserIdentity(),
+		userIdentity: DefaultOwner,
 	}
 }
 
-// getUserIdentity returns the current user identity for audit logging.
-// It attempts to get the OS username, falling back to "host-operator" if unavailable.
-// This runs inside the guardian container, so it returns the container user unless
-// we can detect the host user through other means.
-func getUserIdentity() string {
-	currentUser, err := user.Current()
-	if err == nil && currentUser.Username != "" {
-		return currentUser.Username
-	}
-	// Fallback to a descriptive placeholder if we can't determine the user
-	return "host-operator"
-}
+// DefaultOwner is the identity recorded for decisions made with the
+// instance secret when the guardian was not told its owner's name.
+const DefaultOwner = "host-operator"
 
 // SetDomainQueue sets the domain queue and wires its event hub connection.
 func (s *Server) SetDomainQueue(dq *DomainQueue) {
@@ -113,6 +117,14 @@ func (s *Server) SetDomainQueue(dq *DomainQueue) {
 	}
 }
 
+// SetExecutions sets the execution tracker and wires its event hub connection.
+func (s *Server) SetExecutions(t *ExecutionTracker) {
+	s.Executions = t
+	if t != nil && s.Events != nil {
+		t.SetEventHub(s.Events)
+	}
+}
+
 // Start begins accepting connections on the approval server.
 // The server is bound to localhost only for security.
 // Returns an error if the server is already running or fails to start.
@@ -132,16 +144,21 @@ func (s *Server) Start() error {
 	mux := http.NewServeMux()
 	mux.HandleFunc("GET /", s.handleIndex)
 	mux.HandleFunc("GET /pending", s.handlePending)
+	mux.HandleFunc("GET /executions", s.handleExecutions)
+	mux.HandleFunc("GET /history", s.handleHistory)
+	mux.HandleFunc("GET /logs", s.handleLogs)
 	mux.HandleFunc("GET /events", s.handleEvents)
 	mux.HandleFunc("POST /approve/{id}", s.handleApprove)
 	mux.HandleFunc("POST /deny/{id}", s.handleDeny)
+	mux.HandleFunc("POST /extend/{id}", s.handleExtend)
 	mux.HandleFunc("GET /pending-domains", s.handlePendingDomains)
 	mux.HandleFunc("POST /approve-domain/{id}", s.handleApproveDomain)
 	mux.HandleFunc("POST /deny-domain/{id}", s.handleDenyDomain)
+	mux.HandleFunc("POST /extend-domain/{id}", s.handleExtendDomain)
 
 	s.listener = listener
 	s.server = &http.Server{
-		Handler:           mux,
+		Handler:           s.requireAuth(mux),
 		ReadHeaderTimeout: 30 * time.Second,
 	}
 	s.running = true
@@ -166,10 +183,13 @@ func (s *Server) Stop(ctx context.Context) error {
 
 	s.running = false
 
-	// Close the event hub to disconnect all SSE clients
+	// Close the event hub and log stream to disconnect all SSE clients
 	if s.Events != nil {
 		s.Events.Close()
 	}
+	if s.Logs != nil {
+		s.Logs.Close()
+	}
 
 	// Use a background context if nil is provided
 	if ctx == nil {
@@ -199,6 +219,36 @@ func (s *Server) ListenAddr() string {
 type indexData struct {
 	Requests       []templateRequest
 	DomainRequests []domainTemplateRequest
+	Executions     []templateExecution
+	CSRFToken      string
+}
+
+// templateExecution holds in-flight execution data for template rendering.
+type templateExecution struct {
+	ID        string
+	Cloister  string
+	Project   string
+	Cmd       string
+	State     string
+	Position  int
+	Submitted string
+}
+
+// newTemplateExecutions converts executions to their template form.
+func newTemplateExecutions(execs []Execution) []templateExecution {
+	result := make([]templateExecution, len(execs))
+	for i, e := range execs {
+		result[i] = templateExecution{
+			ID:        e.ID,
+			Cloister:  e.Cloister,
+			Project:   e.Project,
+			Cmd:       e.Cmd,
+			State:     e.State,
+			Position:  e.Position,
+			Submitted: e.Submitted.Format(time.RFC3339),
+		}
+	}
+	return result
 }
 
 // templateRequest holds request data for template rendering.
@@ -208,7 +258,72 @@ type templateRequest struct {
 	Project   string
 	Agent     string
 	Cmd       string
+	Action    string
+	Params    []ActionParam
 	Timestamp string
+	Workdir   string
+	Pattern   string
+	Args      []templateArg
+	Risky     bool
+	Worktree  string
+	Recent    []templateDecision
+	Quorum    string // Approval progress for multi-approver requests; empty otherwise
+	Expires   string // Approval deadline; empty if held until decided
+}
+
+// templateArg holds one argument of a command for template rendering.
+type templateArg struct {
+	Value string
+	Risks string // Comma-separated names of the risk rules that flag it
+}
+
+// newTemplateRequest converts a PendingRequest to its template form.
+func newTemplateRequest(req *PendingRequest) templateRequest {
+	tr := templateRequest{
+		ID:        req.ID,
+		Cloister:  req.Cloister,
+		Project:   req.Project,
+		Agent:     req.Agent,
+		Cmd:       req.Cmd,
+		Action:    req.Action,
+		Params:    req.Params,
+		Timestamp: req.Timestamp.Format(time.RFC3339),
+		Workdir:   req.Workdir,
+		Pattern:   req.Pattern,
+		Args:      make([]templateArg, len(req.Args)),
+		Risky:     risk.Risky(req.Args),
+		Worktree:  req.Worktree,
+		Recent:    newTemplateDecisions(req.Recent),
+		Quorum:    quorumLabel(req.Approvals, req.RequireApprovals),
+		Expires:   formatDeadline(req.ExpiresAt),
+	}
+	for i, a := range req.Args {
+		tr.Args[i] = templateArg{Value: a.Value, Risks: strings.Join(a.Risks, ", ")}
+	}
+	return tr
+}
+
+// quorumLabel describes the approval progress of a request that needs more
+// than one approver, e.g. "1 of 2 approvals (alice)". Returns "" for
+// requests that need only one.
+func quorumLabel(approvals []string, required int) string {
+	if required <= 1 {
+		return ""
+	}
+	label := fmt.Sprintf("%d of %d approvals", len(approvals), required)
+	if len(approvals) > 0 {
+		label += " (" + strings.Join(approvals, ", ") + ")"
+	}
+	return label
+}
+
+// formatDeadline formats an approval deadline, or returns "" for a request
+// held until decided.
+func formatDeadline(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.RFC3339)
 }
 
 // domainTemplateRequest holds domain request data for template rendering.
@@ -219,6 +334,30 @@ type domainTemplateRequest struct {
 	Project   string
 	Timestamp string
 	Wildcard  string // Suggested wildcard pattern like "*.example.com" (empty if not applicable)
+	Quorum    string
+	Expires   string
+}
+
+// newDomainTemplateRequest converts a DomainRequest to its template form.
+func newDomainTemplateRequest(req *DomainRequest) domainTemplateRequest {
+	quorum := quorumLabel(req.Approvals, req.RequireApprovals)
+	if quorum != "" && len(req.Approvals) > 0 {
+		// Later approvers must choose the same scope and pattern.
+		quorum += " for scope " + req.Scope
+		if req.Pattern != "" {
+			quorum += " as " + req.Pattern
+		}
+	}
+	return domainTemplateRequest{
+		ID:        req.ID,
+		Domain:    req.Domain,
+		Cloister:  req.Cloister,
+		Project:   req.Project,
+		Timestamp: req.Timestamp.Format(time.RFC3339),
+		Wildcard:  DomainToWildcard(req.Domain),
+		Quorum:    quorum,
+		Expires:   formatDeadline(req.ExpiresAt),
+	}
 }
 
 // resultData holds the data passed to the result.html template.
@@ -244,17 +383,11 @@ func (s *Server) handleIndex(w http.ResponseWriter, _ *http.Request) {
 	pending := s.Queue.List()
 
 	data := indexData{
-		Requests: make([]templateRequest, len(pending)),
+		Requests:  make([]templateRequest, len(pending)),
+		CSRFToken: s.csrfToken(),
 	}
-	for i, req := range pending {
-		data.Requests[i] = templateRequest{
-			ID:        req.ID,
-			Cloister:  req.Cloister,
-			Project:   req.Project,
-			Agent:     req.Agent,
-			Cmd:       req.Cmd,
-			Timestamp: req.Timestamp.Format(time.RFC3339),
-		}
+	for i := range pending {
+		data.Requests[i] = newTemplateRequest(&pending[i])
 	}
 
 	// Add domain requests if DomainQueue is available
@@ -262,36 +395,43 @@ func (s *Server) handleIndex(w http.ResponseWriter, _ *http.Request) {
 		pendingDomains := s.DomainQueue.List()
 		data.DomainRequests = make([]domainTemplateRequest, len(pendingDomains))
 		for i := range pendingDomains {
-			data.DomainRequests[i] = domainTemplateRequest{
-				ID:        pendingDomains[i].ID,
-				Domain:    pendingDomains[i].Domain,
-				Cloister:  pendingDomains[i].Cloister,
-				Project:   pendingDomains[i].Project,
-				Timestamp: pendingDomains[i].Timestamp.Format(time.RFC3339),
-				Wildcard:  domainToWildcard(pendingDomains[i].Domain),
-			}
+			data.DomainRequests[i] = newDomainTemplateRequest(&pendingDomains[i])
 		}
 	}
 
+	if s.Executions != nil {
+		data.Executions = newTemplateExecutions(s.Executions.List())
+	}
+
 	w.Header().Set("Content-Type", "text/html; charset=utf-8")
 	if err := templates.ExecuteTemplate(w, "index.html", data); err != nil {
 		http.Error(w, "failed to render template", http.StatusInternalServerError)
 	}
 }
 
-// pendingRequestJSON represents a pending request in JSON format for the API.
-type pendingRequestJSON struct {
-	ID        string `json:"id"`
-	Cloister  string `json:"cloister"`
-	Project   string `json:"project"`
-	Agent     string `json:"agent"`
-	Cmd       string `json:"cmd"`
-	Timestamp string `json:"timestamp"`
+// PendingCommand is a pending hostexec request as returned by GET /pending.
+type PendingCommand struct {
+	ID        string        `json:"id"`
+	Cloister  string        `json:"cloister"`
+	Project   string        `json:"project"`
+	Agent     string        `json:"agent"`
+	Cmd       string        `json:"cmd"`
+	Action    string        `json:"action,omitempty"`
+	Params    []ActionParam `json:"params,omitempty"`
+	Timestamp string        `json:"timestamp"`
+	Workdir   string        `json:"workdir,omitempty"`
+	Pattern   string        `json:"pattern,omitempty"`
+	Args      []risk.Arg    `json:"args,omitempty"`
+	Worktree  string        `json:"worktree,omitempty"`
+
+	RequireApprovals int      `json:"require_approvals,omitempty"`
+	Approvals        []string `json:"approvals,omitempty"`
+	ExpiresAt        string   `json:"expires_at,omitempty"` // Omitted if held until decided
 }
 
 // pendingResponse is the response body for GET /pending.
 type pendingResponse struct {
-	Requests []pendingRequestJSON `json:"requests"`
+	Requests []PendingCommand `json:"requests"`
 }
 
 // handlePending returns a JSON array of pending requests.
@@ -299,23 +439,47 @@ func (s *Server) handlePending(w http.ResponseWriter, _ *http.Request) {
 	pending := s.Queue.List()
 
 	response := pendingResponse{
-		Requests: make([]pendingRequestJSON, len(pending)),
+		Requests: make([]PendingCommand, len(pending)),
 	}
 
 	for i, req := range pending {
-		response.Requests[i] = pendingRequestJSON{
+		response.Requests[i] = PendingCommand{
 			ID:        req.ID,
 			Cloister:  req.Cloister,
 			Project:   req.Project,
 			Agent:     req.Agent,
 			Cmd:       req.Cmd,
+			Action:    req.Action,
+			Params:    req.Params,
 			Timestamp: req.Timestamp.Format(time.RFC3339),
+			Workdir:   req.Workdir,
+			Pattern:   req.Pattern,
+			Args:      req.Args,
+			Worktree:  req.Worktree,
+
+			RequireApprovals: req.RequireApprovals,
+			Approvals:        req.Approvals,
+			ExpiresAt:        formatDeadline(req.ExpiresAt),
 		}
 	}
 
 	s.writeJSON(w, http.StatusOK, response)
 }
 
+// executionsResponse is the response body for GET /executions.
+type executionsResponse struct {
+	Executions []Execution `json:"executions"`
+}
+
+// handleExecutions returns a JSON array of queued and running host commands.
+func (s *Server) handleExecutions(w http.ResponseWriter, _ *http.Request) {
+	response := executionsResponse{Executions: []Execution{}}
+	if s.Executions != nil {
+		response.Executions = s.Executions.List()
+	}
+	s.writeJSON(w, http.StatusOK, response)
+}
+
 // HeartbeatInterval is the interval between heartbeat events sent to SSE clients.
 // This keeps connections alive through proxies and load balancers that may
 // close idle connections.
@@ -389,13 +553,30 @@ func (s *Server) handleEvents(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-// approveResponse is the response body for POST /approve/{id}.
+// approveResponse is the response body for POST /approve/{id}. Status is
+// "pending" while a multi-approver request still needs more approvals.
 type approveResponse struct {
-	Status string `json:"status"`
-	ID     string `json:"id"`
+	Status    string   `json:"status"`
+	ID        string   `json:"id"`
+	Approvals []string `json:"approvals,omitempty"`
+	Required  int      `json:"required,omitempty"`
 }
 
-// handleApprove approves a pending request by ID.
+// writeApproveError reports a failed Queue.Approve or DomainQueue.Approve.
+func (s *Server) writeApproveError(w http.ResponseWriter, err error) {
+	if errors.Is(err, ErrAlreadyApproved) {
+		s.writeError(w, http.StatusConflict, "you have already approved this request")
+		return
+	}
+	if errors.Is(err, ErrScopeMismatch) {
+		s.writeError(w, http.StatusConflict, "earlier approvers chose a different scope or pattern")
+		return
+	}
+	s.writeError(w, http.StatusNotFound, "request not found")
+}
+
+// handleApprove records an approval of a pending request by ID. Once the
+// request has all the approvals it needs, it is released to run.
 func (s *Server) handleApprove(w http.ResponseWriter, r *http.Request) {
 	id := r.PathValue("id")
 	if id == "" {
@@ -403,28 +584,32 @@ func (s *Server) handleApprove(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	req, ok := s.Queue.Get(id)
-	if !ok {
-		s.writeError(w, http.StatusNotFound, "request not found")
+	req, tally, err := s.Queue.Approve(id, s.actor(r))
+	if err != nil {
+		s.writeApproveError(w, err)
 		return
 	}
 
-	// Capture request info before removing from queue
-	cmd := req.Cmd
-	project := req.Project
-	cloister := req.Cloister
+	if !tally.Done() {
+		s.Events.BroadcastPendingRequestUpdated(&req)
+		if strings.Contains(r.Header.Get("Accept"), "text/html") {
+			s.writeCardHTML(w, "request", newTemplateRequest(&req))
+			return
+		}
+		s.writeJSON(w, http.StatusOK, approveResponse{Status: "pending", ID: id, Approvals: tally.Approvals, Required: tally.Required})
+		return
+	}
 
-	// Log APPROVE event
+	// Log APPROVE event, naming everyone who approved
 	if s.AuditLogger != nil {
-		if err := s.AuditLogger.LogApprove(project, cloister, cmd, s.userIdentity); err != nil {
+		if err := s.AuditLogger.LogApprove(id, req.Project, req.Cloister, req.Cmd, strings.Join(tally.Approvals, ",")); err != nil {
 			clog.Warn("failed to log approve audit event: %v", err)
 		}
 	}
 
-	// Remove from queue FIRST (cancels timeout goroutine to prevent race)
-	s.Queue.Remove(id)
-
-	// Broadcast removal event to SSE clients before unblocking the proxy
+	// Approve has already removed the request from the queue, canceling
+	// its timeout. Broadcast removal to SSE clients before unblocking the
+	// proxy.
 	s.Events.BroadcastRequestRemoved(id)
 
 	// Send approved response on the request's channel.
@@ -437,13 +622,15 @@ func (s *Server) handleApprove(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if strings.Contains(r.Header.Get("Accept"), "text/html") {
-		s.writeResultHTML(w, id, "approved", cmd)
+		s.writeResultHTML(w, id, "approved", req.Cmd)
 		return
 	}
 
 	s.writeJSON(w, http.StatusOK, approveResponse{
-		Status: "approved",
-		ID:     id,
+		Status:    "approved",
+		ID:        id,
+		Approvals: tally.Approvals,
+		Required:  tally.Required,
 	})
 }
 
@@ -466,47 +653,50 @@ func (s *Server) handleDeny(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	req, ok := s.Queue.Get(id)
+	// Parse optional reason from request body
+	var denyReq denyRequest
+	// Decode errors are non-fatal - reason is optional
+	if err := json.NewDecoder(r.Body).Decode(&denyReq); err != nil {
+		clog.Warn("failed to decode deny request body (reason will be empty): %v", err)
+	}
+	if len(denyReq.Reason) > MaxDenyMessageLen {
+		s.writeError(w, http.StatusBadRequest, fmt.Sprintf("reason must be at most %d bytes", MaxDenyMessageLen))
+		return
+	}
+
+	// Take the request off the queue in one step, so that a concurrent
+	// approval cannot also release it and its timeout cannot fire.
+	req, ok := s.Queue.Take(id)
 	if !ok {
 		s.writeError(w, http.StatusNotFound, "request not found")
 		return
 	}
-
-	// Capture request info before removing from queue
 	cmd := req.Cmd
 	project := req.Project
 	cloister := req.Cloister
 
-	// Parse optional reason from request body
-	var denyReq denyRequest
-	// Decode errors are non-fatal - reason is optional
-	if err := json.NewDecoder(r.Body).Decode(&denyReq); err != nil {
-		clog.Warn("failed to decode deny request body (reason will be empty): %v", err)
-	}
-
+	actor := s.actor(r)
 	reason := denyReq.Reason
 	if reason == "" {
-		reason = fmt.Sprintf("Denied by %s", s.userIdentity)
+		reason = fmt.Sprintf("Denied by %s", actor)
 	}
 
 	// Log DENY event
 	if s.AuditLogger != nil {
-		if err := s.AuditLogger.LogDeny(project, cloister, cmd, reason); err != nil {
+		if err := s.AuditLogger.LogDenyBy(id, project, cloister, cmd, actor, reason); err != nil {
 			clog.Warn("failed to log deny audit event: %v", err)
 		}
 	}
 
-	// Remove from queue FIRST (cancels timeout goroutine to prevent race)
-	s.Queue.Remove(id)
-
 	// Broadcast removal event to SSE clients before unblocking the proxy
 	s.Events.BroadcastRequestRemoved(id)
 
 	// Send denied response on the request's channel
 	if req.Response != nil {
 		req.Response <- Response{
-			Status: "denied",
-			Reason: reason,
+			Status:  "denied",
+			Reason:  reason,
+			Message: denyReq.Reason,
 		}
 	}
 
@@ -561,18 +751,31 @@ func (s *Server) writeDomainResultHTML(w http.ResponseWriter, data domainResultD
 	}
 }
 
-// pendingDomainRequestJSON represents a pending domain request in JSON format for the API.
-type pendingDomainRequestJSON struct {
+// writeCardHTML renders a pending request card, for requests still
+// awaiting approvals.
+func (s *Server) writeCardHTML(w http.ResponseWriter, tmpl string, data any) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := templates.ExecuteTemplate(w, tmpl, data); err != nil {
+		http.Error(w, "template error", http.StatusInternalServerError)
+	}
+}
+
+// PendingDomain is a pending domain request as returned by GET /pending-domains.
+type PendingDomain struct {
 	ID        string `json:"id"`
 	Cloister  string `json:"cloister"`
 	Project   string `json:"project"`
 	Domain    string `json:"domain"`
 	Timestamp string `json:"timestamp"`
+
+	RequireApprovals int      `json:"require_approvals,omitempty"`
+	Approvals        []string `json:"approvals,omitempty"`
+	ExpiresAt        string   `json:"expires_at,omitempty"`
 }
 
 // pendingDomainsResponse is the response body for GET /pending-domains.
 type pendingDomainsResponse struct {
-	Requests []pendingDomainRequestJSON `json:"requests"`
+	Requests []PendingDomain `json:"requests"`
 }
 
 // handlePendingDomains returns a JSON array of pending domain requests.
@@ -585,16 +788,20 @@ func (s *Server) handlePendingDomains(w http.ResponseWriter, _ *http.Request) {
 	pending := s.DomainQueue.List()
 
 	response := pendingDomainsResponse{
-		Requests: make([]pendingDomainRequestJSON, len(pending)),
+		Requests: make([]PendingDomain, len(pending)),
 	}
 
 	for i := range pending {
-		response.Requests[i] = pendingDomainRequestJSON{
+		response.Requests[i] = PendingDomain{
 			ID:        pending[i].ID,
 			Cloister:  pending[i].Cloister,
 			Project:   pending[i].Project,
 			Domain:    pending[i].Domain,
 			Timestamp: pending[i].Timestamp.Format(time.RFC3339),
+
+			RequireApprovals: pending[i].RequireApprovals,
+			Approvals:        pending[i].Approvals,
+			ExpiresAt:        formatDeadline(pending[i].ExpiresAt),
 		}
 	}
 
@@ -608,10 +815,14 @@ type approveDomainRequest struct {
 }
 
 // approveDomainResponse is the response body for POST /approve-domain/{id}.
+// Status is "pending" while a multi-approver request still needs more
+// approvals.
 type approveDomainResponse struct {
-	Status string `json:"status"`
-	ID     string `json:"id"`
-	Scope  string `json:"scope"`
+	Status    string   `json:"status"`
+	ID        string   `json:"id"`
+	Scope     string   `json:"scope,omitempty"`
+	Approvals []string `json:"approvals,omitempty"`
+	Required  int      `json:"required,omitempty"`
 }
 
 // domainApprovalState holds the mutable state during domain approval processing.
@@ -624,6 +835,7 @@ type domainApprovalState struct {
 	isPattern        bool
 	persistenceError string
 	requestedScope   string
+	approvals        []string
 }
 
 // handleApproveDomain approves a pending domain request by ID.
@@ -653,12 +865,22 @@ func (s *Server) handleApproveDomain(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	req, ok := s.DomainQueue.Get(id)
-	if !ok {
-		s.writeError(w, http.StatusNotFound, "request not found")
+	req, tally, err := s.DomainQueue.Approve(id, s.actor(r), approveReq.Scope, approveReq.Pattern)
+	if err != nil {
+		s.writeApproveError(w, err)
+		return
+	}
+	if !tally.Done() {
+		s.Events.BroadcastDomainRequestUpdated(&req)
+		if strings.Contains(r.Header.Get("Accept"), "text/html") {
+			s.writeCardHTML(w, "domain_request", newDomainTemplateRequest(&req))
+			return
+		}
+		s.writeJSON(w, http.StatusOK, approveDomainResponse{Status: "pending", ID: id, Approvals: tally.Approvals, Required: tally.Required})
 		return
 	}
 
+	// Every approver chose this scope and pattern.
 	state := &domainApprovalState{
 		scope:          approveReq.Scope,
 		pattern:        approveReq.Pattern,
@@ -667,10 +889,11 @@ func (s *Server) handleApproveDomain(w http.ResponseWriter, r *http.Request) {
 		cloister:       req.Cloister,
 		isPattern:      approveReq.Pattern != "",
 		requestedScope: approveReq.Scope,
+		approvals:      tally.Approvals,
 	}
 
-	s.persistDomainApproval(state, req)
-	s.finalizeDomainApproval(w, r, id, req, state)
+	s.persistDomainApproval(state, &req)
+	s.finalizeDomainApproval(w, r, id, &req, state)
 }
 
 // persistDomainApproval persists the domain approval to config, falling back to session on error.
@@ -703,7 +926,8 @@ func (s *Server) persistDomainApproval(state *domainApprovalState, req *DomainRe
 	}
 }
 
-// finalizeDomainApproval logs, removes from queue, broadcasts, and writes the response.
+// finalizeDomainApproval logs, broadcasts, and writes the response for a
+// request that DomainQueue.Approve has removed from the queue.
 func (s *Server) finalizeDomainApproval(w http.ResponseWriter, r *http.Request, id string, req *DomainRequest, state *domainApprovalState) {
 	persistValue := state.domain
 	if state.pattern != "" && state.persistenceError == "" {
@@ -711,12 +935,11 @@ func (s *Server) finalizeDomainApproval(w http.ResponseWriter, r *http.Request,
 	}
 
 	if s.AuditLogger != nil {
-		if err := s.AuditLogger.LogDomainApprove(state.project, state.cloister, persistValue, state.scope, s.userIdentity); err != nil {
+		if err := s.AuditLogger.LogDomainApprove(id, state.project, state.cloister, persistValue, state.scope, strings.Join(state.approvals, ",")); err != nil {
 			clog.Warn("failed to log domain approve audit event: %v", err)
 		}
 	}
 
-	s.DomainQueue.Remove(id)
 	s.Events.BroadcastDomainRequestRemoved(id)
 
 	resp := DomainResponse{
@@ -747,9 +970,11 @@ func (s *Server) finalizeDomainApproval(w http.ResponseWriter, r *http.Request,
 	}
 
 	s.writeJSON(w, http.StatusOK, approveDomainResponse{
-		Status: "approved",
-		ID:     id,
-		Scope:  state.scope,
+		Status:    "approved",
+		ID:        id,
+		Scope:     state.scope,
+		Approvals: state.approvals,
+		Required:  max(req.RequireApprovals, 1),
 	})
 }
 
@@ -781,17 +1006,6 @@ func (s *Server) handleDenyDomain(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	req, ok := s.DomainQueue.Get(id)
-	if !ok {
-		s.writeError(w, http.StatusNotFound, "request not found")
-		return
-	}
-
-	// Capture request info before removing from queue
-	domain := req.Domain
-	project := req.Project
-	cloister := req.Cloister
-
 	// Parse optional request body (empty body is valid for backward compatibility)
 	var denyReq denyDomainRequest
 	// Decode errors are non-fatal - all fields are optional
@@ -812,27 +1026,40 @@ func (s *Server) handleDenyDomain(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if len(denyReq.Reason) > MaxDenyMessageLen {
+		s.writeError(w, http.StatusBadRequest, fmt.Sprintf("reason must be at most %d bytes", MaxDenyMessageLen))
+		return
+	}
+
+	// Take the request off the queue in one step, as for handleDeny.
+	req, ok := s.DomainQueue.Take(id)
+	if !ok {
+		s.writeError(w, http.StatusNotFound, "request not found")
+		return
+	}
+	domain := req.Domain
+	project := req.Project
+	cloister := req.Cloister
+
 	// Compute wildcard pattern if requested
 	var pattern string
 	if denyReq.Wildcard {
-		pattern = domainToWildcard(domain)
+		pattern = DomainToWildcard(domain)
 	}
 
+	actor := s.actor(r)
 	reason := denyReq.Reason
 	if reason == "" {
-		reason = fmt.Sprintf("Denied by %s", s.userIdentity)
+		reason = fmt.Sprintf("Denied by %s", actor)
 	}
 
 	// Log DOMAIN_DENY event
 	if s.AuditLogger != nil {
-		if err := s.AuditLogger.LogDomainDeny(project, cloister, domain, reason); err != nil {
+		if err := s.AuditLogger.LogDomainDenyBy(id, project, cloister, domain, actor, reason); err != nil {
 			clog.Warn("failed to log domain deny audit event: %v", err)
 		}
 	}
 
-	// Remove from queue FIRST (cancels timeout goroutine to prevent race)
-	s.DomainQueue.Remove(id)
-
 	// Broadcast removal event to SSE clients before unblocking the proxy
 	s.Events.BroadcastDomainRequestRemoved(id)
 
@@ -842,6 +1069,7 @@ func (s *Server) handleDenyDomain(w http.ResponseWriter, r *http.Request) {
 		Scope:   scope,
 		Pattern: pattern,
 		Reason:  reason,
+		Message: denyReq.Reason,
 	})
 
 	if strings.Contains(r.Header.Get("Accept"), "text/html") {
@@ -13,6 +13,7 @@ import (
 	"time"
 
 	"github.com/xdg/cloister/internal/audit"
+	"github.com/xdg/cloister/internal/guardian/risk"
 )
 
 func TestNewServer(t *testing.T) {
@@ -136,6 +137,9 @@ func TestServer_HandlePending_WithRequests(t *testing.T) {
 		Cmd:       "docker compose up -d",
 		Timestamp: time.Date(2024, 1, 15, 14, 32, 5, 0, time.UTC),
 		Response:  respChan,
+		Pattern:   "^docker compose.*$",
+		Args:      []risk.Arg{{Value: "docker"}, {Value: "compose"}, {Value: "up"}, {Value: "-d"}},
+		Worktree:  "/home/u/proj",
 	}
 	id, err := queue.Add(req)
 	if err != nil {
@@ -181,6 +185,9 @@ func TestServer_HandlePending_WithRequests(t *testing.T) {
 	if r.Timestamp != "2024-01-15T14:32:05Z" {
 		t.Errorf("expected timestamp '2024-01-15T14:32:05Z', got %q", r.Timestamp)
 	}
+	if r.Pattern != "^docker compose.*$" || len(r.Args) != 4 || r.Worktree != "/home/u/proj" {
+		t.Errorf("expected pattern, args, and worktree context, got %+v", r)
+	}
 }
 
 func TestServer_HandleApprove_Success(t *testing.T) {
@@ -314,6 +321,9 @@ func TestServer_HandleDeny_Success(t *testing.T) {
 		if !strings.Contains(denyResp.Reason, "Denied by") {
 			t.Errorf("expected default reason to start with 'Denied by', got %q", denyResp.Reason)
 		}
+		if denyResp.Message != "" {
+			t.Errorf("expected no approver message without a reason, got %q", denyResp.Message)
+		}
 	default:
 		t.Error("expected denial response on channel")
 	}
@@ -363,11 +373,50 @@ func TestServer_HandleDeny_WithReason(t *testing.T) {
 		if denyResp.Reason != "Command looks dangerous" {
 			t.Errorf("expected reason 'Command looks dangerous', got %q", denyResp.Reason)
 		}
+		if denyResp.Message != "Command looks dangerous" {
+			t.Errorf("expected message 'Command looks dangerous', got %q", denyResp.Message)
+		}
 	default:
 		t.Error("expected denial response on channel")
 	}
 }
 
+func TestServer_HandleDeny_ReasonTooLong(t *testing.T) {
+	queue := NewQueue()
+	respChan := make(chan Response, 1)
+	id, err := queue.Add(&PendingRequest{
+		Cloister:  "test-cloister",
+		Project:   "test-project",
+		Cmd:       "make deploy",
+		Timestamp: time.Now(),
+		Response:  respChan,
+	})
+	if err != nil {
+		t.Fatalf("failed to add request: %v", err)
+	}
+
+	server := NewServer(queue, nil)
+
+	body, _ := json.Marshal(denyRequest{Reason: strings.Repeat("x", MaxDenyMessageLen+1)})
+	httpReq := httptest.NewRequest(http.MethodPost, "/deny/"+id, bytes.NewReader(body))
+	httpReq.SetPathValue("id", id)
+	rr := httptest.NewRecorder()
+
+	server.handleDeny(rr, httpReq)
+
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("expected status %d, got %d", http.StatusBadRequest, rr.Code)
+	}
+	if queue.Len() != 1 {
+		t.Errorf("expected request to stay queued, got queue length %d", queue.Len())
+	}
+	select {
+	case resp := <-respChan:
+		t.Errorf("expected no response on channel, got %+v", resp)
+	default:
+	}
+}
+
 func TestServer_HandleDeny_NotFound(t *testing.T) {
 	queue := NewQueue()
 	server := NewServer(queue, nil)
@@ -609,6 +658,96 @@ func TestTemplates_RequestPartial(t *testing.T) {
 	}
 }
 
+func TestTemplates_RequestPartial_Action(t *testing.T) {
+	data := templateRequest{
+		ID:        "act123",
+		Cloister:  "my-cloister",
+		Project:   "my-project",
+		Cmd:       `action:reset-db name="foo"`,
+		Action:    "reset-db",
+		Params:    []ActionParam{{Name: "name", Value: "foo"}, {Name: "seed", Value: "true"}},
+		Timestamp: "2024-01-15T15:00:00Z",
+	}
+
+	var buf bytes.Buffer
+	if err := templates.ExecuteTemplate(&buf, "request", data); err != nil {
+		t.Fatalf("failed to execute request template: %v", err)
+	}
+
+	output := buf.String()
+	if !strings.Contains(output, "<strong>reset-db</strong>") {
+		t.Error("expected output to show the action name")
+	}
+	if !strings.Contains(output, "<dt>name</dt><dd>foo</dd>") {
+		t.Error("expected output to list parameter name=foo")
+	}
+	if !strings.Contains(output, "<dt>seed</dt><dd>true</dd>") {
+		t.Error("expected output to list parameter seed=true")
+	}
+	if strings.Contains(output, "request-cmd") {
+		t.Error("expected action card not to render the raw command")
+	}
+}
+
+func TestTemplates_RequestPartial_Context(t *testing.T) {
+	exit := 0
+	req := &PendingRequest{
+		ID:        "ctx123",
+		Cloister:  "api-main",
+		Project:   "api",
+		Cmd:       "git push --force origin main",
+		Timestamp: time.Date(2024, 1, 15, 15, 0, 0, 0, time.UTC),
+		Pattern:   "^git push.*$",
+		Args: []risk.Arg{
+			{Value: "git"}, {Value: "push"}, {Value: "--force", Risks: []string{"force_push"}},
+			{Value: "origin"}, {Value: "main"},
+		},
+		Worktree: "/home/u/api",
+		Recent: []audit.Decision{{
+			Time: time.Date(2024, 1, 15, 14, 0, 0, 0, time.UTC), Type: audit.DecisionHostexec,
+			Cloister: "api-main", Cmd: "git push origin main", Outcome: audit.OutcomeApproved, ExitCode: &exit,
+		}},
+	}
+
+	var buf bytes.Buffer
+	if err := templates.ExecuteTemplate(&buf, "request", newTemplateRequest(req)); err != nil {
+		t.Fatalf("failed to execute request template: %v", err)
+	}
+
+	output := buf.String()
+	for _, want := range []string{
+		`class="request request-risky"`,
+		`<li class="argv-risky" title="force_push">--force</li>`,
+		`<li>origin</li>`,
+		`executor working directory`,
+		`<code>^git push.*$</code>`,
+		`<code>/home/u/api</code>`,
+		`Recent commands from api-main (1)`,
+		`(exit 0) <code>git push origin main</code>`,
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("expected output to contain %q", want)
+		}
+	}
+}
+
+func TestTemplates_RequestPartial_Plain(t *testing.T) {
+	req := &PendingRequest{ID: "x", Cmd: "make", Workdir: "/home/u/api/db", Worktree: "/home/u/api"}
+
+	var buf bytes.Buffer
+	if err := templates.ExecuteTemplate(&buf, "request", newTemplateRequest(req)); err != nil {
+		t.Fatalf("failed to execute request template: %v", err)
+	}
+
+	output := buf.String()
+	if !strings.Contains(output, "<code>/home/u/api/db</code>") {
+		t.Error("expected output to show the workdir")
+	}
+	if strings.Contains(output, "request-risky") || strings.Contains(output, "request-recent") {
+		t.Error("expected no risk highlight or history for a plain request")
+	}
+}
+
 func TestTemplates_ParseFS(t *testing.T) {
 	// Verify that templates can be re-parsed from the embedded filesystem
 	// This tests the embed.FS is valid
@@ -1619,6 +1758,9 @@ func TestServer_HandleDenyDomain_WithReason(t *testing.T) {
 		if denyResp.Reason != "Domain is known malware" {
 			t.Errorf("expected reason 'Domain is known malware', got %q", denyResp.Reason)
 		}
+		if denyResp.Message != "Domain is known malware" {
+			t.Errorf("expected message 'Domain is known malware', got %q", denyResp.Message)
+		}
 	default:
 		t.Error("expected denial response on channel")
 	}
@@ -0,0 +1,93 @@
+package guardian
+
+import (
+	"crypto/ed25519"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/xdg/cloister/internal/audit"
+	"github.com/xdg/cloister/internal/pathutil"
+)
+
+// ContainerAuditKeyDir is where the guardian keeps the key that signs the
+// audit log. It is a Docker volume (see AuditKeyVolume) that only the
+// guardian container mounts: the host user and cloister containers never
+// see the key in their filesystems, and verifying needs only the public key
+// served by GET /audit/key.
+const ContainerAuditKeyDir = "/var/lib/cloister/audit"
+
+// auditKeyFile is the name of the signing key in the audit key directory.
+const auditKeyFile = "signing.key"
+
+// AuditKeyVolume returns the name of the Docker volume holding the audit
+// signing key. Test instances get their own.
+func AuditKeyVolume() string {
+	if id := InstanceID(); id != "" {
+		return "cloister-audit-" + id
+	}
+	return "cloister-audit"
+}
+
+// loadAuditKey returns the audit signing key kept in dir, creating it on
+// first use.
+func loadAuditKey(dir string) (ed25519.PrivateKey, error) {
+	path := filepath.Join(dir, auditKeyFile)
+	data, err := os.ReadFile(path)
+	if err == nil {
+		return parseAuditKey(strings.TrimSpace(string(data)))
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("failed to read audit key: %w", err)
+	}
+
+	key := audit.GenerateChainKey()
+	if err := os.WriteFile(path, []byte(hex.EncodeToString(key.Seed())+"\n"), 0o600); err != nil {
+		return nil, fmt.Errorf("failed to write audit key: %w", err)
+	}
+	return key, nil
+}
+
+// parseAuditKey decodes a hex Ed25519 seed.
+func parseAuditKey(s string) (ed25519.PrivateKey, error) {
+	seed, err := hex.DecodeString(s)
+	if err != nil || len(seed) != ed25519.SeedSize {
+		return nil, fmt.Errorf("audit key must be %d hex-encoded bytes", ed25519.SeedSize)
+	}
+	return ed25519.NewKeyFromSeed(seed), nil
+}
+
+// ParseAuditPublicKey decodes a hex Ed25519 public key, as served by
+// GET /audit/key.
+func ParseAuditPublicKey(s string) (ed25519.PublicKey, error) {
+	key, err := hex.DecodeString(strings.TrimSpace(s))
+	if err != nil || len(key) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("audit public key must be %d hex-encoded bytes", ed25519.PublicKeySize)
+	}
+	return key, nil
+}
+
+// RemoveLegacyAuditKey deletes the HMAC key that earlier versions kept in
+// the host state directory. Any host process could read it and reseal a
+// doctored log, so it must not outlive the switch to signatures.
+func RemoveLegacyAuditKey() error {
+	err := os.Remove(filepath.Join(pathutil.XDGStateHome(), "cloister", "audit.key"))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to remove legacy audit key: %w", err)
+	}
+	return nil
+}
+
+// auditKeyResponse is the response of GET /audit/key.
+type auditKeyResponse struct {
+	PublicKey string `json:"public_key"`
+}
+
+// handleAuditKey serves GET /audit/key.
+func (a *APIServer) handleAuditKey(w http.ResponseWriter, _ *http.Request) {
+	a.writeJSON(w, http.StatusOK, auditKeyResponse{PublicKey: hex.EncodeToString(a.AuditPublicKey)})
+}
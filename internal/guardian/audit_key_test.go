@@ -0,0 +1,92 @@
+package guardian
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLoadAuditKey(t *testing.T) {
+	dir := t.TempDir()
+
+	key, err := loadAuditKey(dir)
+	if err != nil {
+		t.Fatalf("loadAuditKey: %v", err)
+	}
+	if len(key) != ed25519.PrivateKeySize {
+		t.Errorf("key length = %d, want %d", len(key), ed25519.PrivateKeySize)
+	}
+	path := filepath.Join(dir, auditKeyFile)
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("stat key file: %v", err)
+	}
+	if perm := info.Mode().Perm(); perm != 0o600 {
+		t.Errorf("key file mode = %o, want 600", perm)
+	}
+
+	again, err := loadAuditKey(dir)
+	if err != nil || !bytes.Equal(again, key) {
+		t.Errorf("second loadAuditKey = %x, %v; want the existing key", again, err)
+	}
+
+	if err := os.WriteFile(path, []byte("not hex\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loadAuditKey(dir); err == nil {
+		t.Error("loadAuditKey accepted a malformed key")
+	}
+}
+
+func TestRemoveLegacyAuditKey(t *testing.T) {
+	state := t.TempDir()
+	t.Setenv("XDG_STATE_HOME", state)
+	path := filepath.Join(state, "cloister", "audit.key")
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte("00\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := RemoveLegacyAuditKey(); err != nil {
+		t.Fatalf("RemoveLegacyAuditKey: %v", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("legacy key still present: %v", err)
+	}
+	if err := RemoveLegacyAuditKey(); err != nil {
+		t.Errorf("RemoveLegacyAuditKey without a key: %v", err)
+	}
+}
+
+func TestClient_AuditPublicKey(t *testing.T) {
+	key, err := loadAuditKey(t.TempDir())
+	if err != nil {
+		t.Fatalf("loadAuditKey: %v", err)
+	}
+	api := NewAPIServer(":0", newMockRegistry())
+	api.AuditPublicKey = key.Public().(ed25519.PublicKey)
+	if err := api.Start(); err != nil {
+		t.Fatalf("failed to start API server: %v", err)
+	}
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = api.Stop(ctx)
+	}()
+
+	client := NewClient(api.ListenAddr())
+	client.HTTPClient = noProxyClient()
+	got, err := client.AuditPublicKey()
+	if err != nil {
+		t.Fatalf("AuditPublicKey: %v", err)
+	}
+	if !got.Equal(api.AuditPublicKey) {
+		t.Errorf("AuditPublicKey = %x, want %x", got, api.AuditPublicKey)
+	}
+}
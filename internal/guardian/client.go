@@ -5,15 +5,24 @@ package guardian
 import (
 	"bytes"
 	"context"
+	"crypto/ed25519"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"slices"
+	"sync"
 	"time"
 )
 
 // Client provides methods to interact with the guardian API.
+//
+// Before it first registers a token, a Client negotiates with the guardian
+// through GET /version, and refuses to register tokens with a guardian that
+// does not serve its API version: those requests fail with an error
+// wrapping ErrIncompatibleGuardian. Listing and revoking tokens work with
+// any guardian, so that cloisters can still be stopped.
 type Client struct {
 	// BaseURL is the base URL of the guardian API (e.g., "http://localhost:9997").
 	BaseURL string
@@ -21,6 +30,9 @@ type Client struct {
 	// HTTPClient is the HTTP client used for requests.
 	// If nil, a default client with a 10-second timeout is used.
 	HTTPClient *http.Client
+
+	mu         sync.Mutex
+	negotiated *VersionInfo // Set once negotiation reached the guardian
 }
 
 // NewClient creates a new guardian API client.
@@ -35,11 +47,20 @@ func NewClient(guardianAddr string) *Client {
 	}
 }
 
-// doRequest executes an HTTP request and optionally decodes the response.
+// doRequest negotiates with the guardian, if not yet done, then executes an
+// HTTP request with do.
+func (c *Client) doRequest(method, path string, body, result any, acceptedStatuses ...int) error {
+	if _, err := c.Negotiate(); err != nil {
+		return err
+	}
+	return c.do(method, path, body, result, acceptedStatuses...)
+}
+
+// do executes an HTTP request and optionally decodes the response.
 // If body is not nil, it's JSON-encoded and sent as the request body.
 // If result is not nil, the response body is JSON-decoded into it.
 // Returns an error if the response status is not in acceptedStatuses.
-func (c *Client) doRequest(method, path string, body, result any, acceptedStatuses ...int) error {
+func (c *Client) do(method, path string, body, result any, acceptedStatuses ...int) error {
 	// Build request body if provided
 	var bodyReader io.Reader
 	if body != nil {
@@ -75,10 +96,8 @@ func (c *Client) doRequest(method, path string, body, result any, acceptedStatus
 	// Check status
 	if !slices.Contains(acceptedStatuses, resp.StatusCode) {
 		var errResp errorResponse
-		if err := json.NewDecoder(resp.Body).Decode(&errResp); err == nil && errResp.Error != "" {
-			return fmt.Errorf("%s", errResp.Error)
-		}
-		return fmt.Errorf("status %d", resp.StatusCode)
+		_ = json.NewDecoder(resp.Body).Decode(&errResp)
+		return &statusError{code: resp.StatusCode, message: errResp.Error}
 	}
 
 	// Decode response if requested
@@ -91,6 +110,19 @@ func (c *Client) doRequest(method, path string, body, result any, acceptedStatus
 	return nil
 }
 
+// statusError is returned for a response with an unexpected status.
+type statusError struct {
+	code    int
+	message string // From the error response body, if any
+}
+
+func (e *statusError) Error() string {
+	if e.message != "" {
+		return e.message
+	}
+	return fmt.Sprintf("status %d", e.code)
+}
+
 // RegisterToken registers a new token with the guardian.
 // The token will be associated with the given cloister and project names.
 //
@@ -115,20 +147,24 @@ func (c *Client) RegisterTokenFull(token, cloisterName, projectName, worktreePat
 	return nil
 }
 
-// RevokeToken removes a token from the guardian.
-// Returns nil if the token was already revoked or never existed (idempotent).
-func (c *Client) RevokeToken(token string) error {
+// RevokeToken removes a token from the guardian and returns the report of
+// the cloister's session, or nil if the guardian has none.
+// Returns a nil error if the token was already revoked or never existed (idempotent).
+// Like Health, it does not require a compatible guardian.
+func (c *Client) RevokeToken(token string) (*SessionReport, error) {
 	// Accept both OK and NotFound (token already revoked or never existed)
-	if err := c.doRequest(http.MethodDelete, "/tokens/"+token, nil, nil, http.StatusOK, http.StatusNotFound); err != nil {
-		return fmt.Errorf("failed to revoke token: %w", err)
+	var resp revokeTokenResponse
+	if err := c.do(http.MethodDelete, "/tokens/"+token, nil, &resp, http.StatusOK, http.StatusNotFound); err != nil {
+		return nil, fmt.Errorf("failed to revoke token: %w", err)
 	}
-	return nil
+	return resp.Report, nil
 }
 
 // ListTokens returns a map of all registered tokens to their cloister names.
+// Like Health, it does not require a compatible guardian.
 func (c *Client) ListTokens() (map[string]string, error) {
 	var listResp listTokensResponse
-	if err := c.doRequest(http.MethodGet, "/tokens", nil, &listResp, http.StatusOK); err != nil {
+	if err := c.do(http.MethodGet, "/tokens", nil, &listResp, http.StatusOK); err != nil {
 		return nil, fmt.Errorf("failed to list tokens: %w", err)
 	}
 
@@ -139,3 +175,57 @@ func (c *Client) ListTokens() (map[string]string, error) {
 
 	return result, nil
 }
+
+// Health returns the guardian's health report. A guardian reporting itself
+// down still returns its report, with a nil error. Health does not require
+// a compatible guardian.
+func (c *Client) Health() (*HealthReport, error) {
+	var report HealthReport
+	if err := c.do(http.MethodGet, "/healthz", nil, &report, http.StatusOK); err != nil {
+		return nil, fmt.Errorf("failed to get guardian health: %w", err)
+	}
+	return &report, nil
+}
+
+// AuditPublicKey returns the key that verifies the guardian's audit log
+// signatures.
+func (c *Client) AuditPublicKey() (ed25519.PublicKey, error) {
+	var resp auditKeyResponse
+	if err := c.do(http.MethodGet, "/audit/key", nil, &resp, http.StatusOK); err != nil {
+		return nil, fmt.Errorf("failed to get audit public key: %w", err)
+	}
+	return ParseAuditPublicKey(resp.PublicKey)
+}
+
+// Version returns the guardian's version information. A guardian that
+// predates API versioning answers with a VersionInfo whose API versions are
+// 0.
+func (c *Client) Version() (*VersionInfo, error) {
+	var info VersionInfo
+	err := c.do(http.MethodGet, "/version", nil, &info, http.StatusOK)
+	var se *statusError
+	if errors.As(err, &se) && se.code == http.StatusNotFound {
+		return &VersionInfo{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get guardian version: %w", err)
+	}
+	return &info, nil
+}
+
+// Negotiate returns the guardian's version information, and an error
+// wrapping ErrIncompatibleGuardian if the guardian does not serve this
+// client's API version. The result is remembered once the guardian has
+// answered.
+func (c *Client) Negotiate() (*VersionInfo, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.negotiated == nil {
+		info, err := c.Version()
+		if err != nil {
+			return nil, err
+		}
+		c.negotiated = info
+	}
+	return c.negotiated, checkCompatible(c.negotiated)
+}
@@ -83,7 +83,7 @@ func TestClient_RevokeToken(t *testing.T) {
 	client.HTTPClient = noProxyClient()
 
 	// Test successful revocation
-	err := client.RevokeToken("token-to-revoke")
+	_, err := client.RevokeToken("token-to-revoke")
 	if err != nil {
 		t.Fatalf("failed to revoke token: %v", err)
 	}
@@ -94,12 +94,51 @@ func TestClient_RevokeToken(t *testing.T) {
 	}
 
 	// Revoking non-existent token should not error (idempotent)
-	err = client.RevokeToken("non-existent-token")
+	_, err = client.RevokeToken("non-existent-token")
 	if err != nil {
 		t.Errorf("expected no error for non-existent token, got: %v", err)
 	}
 }
 
+func TestClient_RevokeTokenReturnsSessionReport(t *testing.T) {
+	registry := newMockRegistry()
+	api := NewAPIServer(":0", registry)
+	sessions := NewSessions()
+	api.Sessions = sessions
+	if err := api.Start(); err != nil {
+		t.Fatalf("failed to start API server: %v", err)
+	}
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = api.Stop(ctx)
+	}()
+
+	client := NewClient(api.ListenAddr())
+	client.HTTPClient = noProxyClient()
+
+	if err := client.RegisterToken("tok", "my-cloister", "my-project"); err != nil {
+		t.Fatalf("failed to register token: %v", err)
+	}
+	sessions.RecordTraffic("my-cloister", "example.com", 10, 20)
+
+	report, err := client.RevokeToken("tok")
+	if err != nil {
+		t.Fatalf("failed to revoke token: %v", err)
+	}
+	if report == nil || report.Cloister != "my-cloister" || report.Project != "my-project" {
+		t.Fatalf("report = %+v, want one for my-cloister", report)
+	}
+	if len(report.Domains) != 1 || report.Domains[0].BytesReceived != 20 {
+		t.Errorf("report.Domains = %+v", report.Domains)
+	}
+
+	report, err = client.RevokeToken("tok")
+	if err != nil || report != nil {
+		t.Errorf("second RevokeToken = %+v, %v; want nil, nil", report, err)
+	}
+}
+
 func TestClient_ListTokens(t *testing.T) {
 	registry := newMockRegistry()
 	registry.tokens["token-a"] = token.Info{CloisterName: "cloister-a"}
@@ -170,7 +209,7 @@ func TestClient_ConnectionError(t *testing.T) {
 		t.Error("expected connection error")
 	}
 
-	err = client.RevokeToken("test")
+	_, err = client.RevokeToken("test")
 	if err == nil {
 		t.Error("expected connection error")
 	}
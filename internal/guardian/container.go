@@ -4,10 +4,12 @@ package guardian
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
 	"os"
+	"os/user"
 	"strings"
 	"time"
 
@@ -16,6 +18,8 @@ import (
 	"github.com/xdg/cloister/internal/container"
 	"github.com/xdg/cloister/internal/docker"
 	"github.com/xdg/cloister/internal/executor"
+	"github.com/xdg/cloister/internal/guardian/approval"
+	"github.com/xdg/cloister/internal/token"
 )
 
 // DockerOps abstracts Docker operations for testing guardian container management.
@@ -178,8 +182,31 @@ type StartOptions struct {
 	// ApprovalPort is the host port to expose for the guardian approval web UI.
 	// If zero, defaults to 9999 for production or a dynamic port for test instances.
 	ApprovalPort int
+
+	// ApprovalSecret authenticates the approval web UI and CLI clients.
+	// If empty, the guardian generates its own and the UI is only reachable
+	// with the URL from the guardian log.
+	ApprovalSecret string
+
+	// ApprovalOwner is the host user recorded for decisions made with
+	// ApprovalSecret. If empty, approval.DefaultOwner is recorded.
+	ApprovalOwner string
+
+	// ApproverSecrets maps each named approver to their own login token.
+	ApproverSecrets map[string]string
 }
 
+// ApprovalSecretEnvVar is the environment variable for the approval UI secret.
+const ApprovalSecretEnvVar = "CLOISTER_APPROVAL_SECRET" //nolint:gosec // G101: not a credential
+
+// ApprovalOwnerEnvVar names the host user who started the guardian. It is
+// recorded as the approver for decisions made with the approval secret.
+const ApprovalOwnerEnvVar = "CLOISTER_APPROVAL_OWNER"
+
+// ApproverSecretsEnvVar carries the named approvers' login tokens as a JSON
+// object keyed by approver name.
+const ApproverSecretsEnvVar = "CLOISTER_APPROVER_SECRETS" //nolint:gosec // G101: not a credential
+
 // Start starts the guardian container if it is not already running.
 // The container is configured with:
 //   - Connection to cloister-net (internal network) for proxy traffic
@@ -311,6 +338,7 @@ func buildGuardianRunArgs(opts StartOptions, tokenAPIPort, approvalPort int, dir
 		"-v", dirs.TokenDir + ":" + ContainerTokenDir + ":ro",
 		"-v", dirs.ConfigDir + ":" + ContainerConfigDir + ":ro",
 		"-v", dirs.DecisionDir + ":" + ContainerDecisionDir,
+		"-v", AuditKeyVolume() + ":" + ContainerAuditKeyDir,
 	}
 
 	if opts.TCPPort > 0 {
@@ -319,6 +347,17 @@ func buildGuardianRunArgs(opts StartOptions, tokenAPIPort, approvalPort int, dir
 	if opts.SharedSecret != "" {
 		args = append(args, "-e", SharedSecretEnvVar+"="+opts.SharedSecret)
 	}
+	if opts.ApprovalSecret != "" {
+		args = append(args, "-e", ApprovalSecretEnvVar+"="+opts.ApprovalSecret)
+	}
+	if opts.ApprovalOwner != "" {
+		args = append(args, "-e", ApprovalOwnerEnvVar+"="+opts.ApprovalOwner)
+	}
+	if len(opts.ApproverSecrets) > 0 {
+		if data, err := json.Marshal(opts.ApproverSecrets); err == nil {
+			args = append(args, "-e", ApproverSecretsEnvVar+"="+string(data))
+		}
+	}
 
 	args = append(args, container.DefaultImage(), "cloister", "guardian", "run")
 	return args
@@ -363,16 +402,19 @@ func Stop() error {
 }
 
 // EnsureRunning ensures the guardian container and executor daemon are running.
-// If the container is already running, this is a no-op.
+// If the container is already running, it only checks that the guardian
+// serves this CLI's API version.
 // If the container is not running, it starts the executor daemon and container,
-// then waits for API readiness.
+// then waits for API readiness and checks the new guardian's API version.
+// An incompatible guardian is reported with an error wrapping
+// ErrIncompatibleGuardian.
 func EnsureRunning() error {
 	running, err := IsRunning()
 	if err != nil {
 		return err
 	}
 	if running {
-		return nil
+		return checkRunningVersion(APIAddr())
 	}
 
 	tokenAPIPort, approvalPort, err := Ports()
@@ -385,27 +427,77 @@ func EnsureRunning() error {
 		return fmt.Errorf("failed to start executor: %w", err)
 	}
 
-	if err := saveGuardianPorts(execInfo, tokenAPIPort, approvalPort); err != nil {
+	approvalSecret := token.Generate()
+	approverSecrets := generateApproverSecrets()
+	if err := saveGuardianState(tokenAPIPort, approvalPort, approvalSecret, approverSecrets); err != nil {
 		cleanupExecutor(execInfo)
 		return err
 	}
 
+	if err := RemoveLegacyAuditKey(); err != nil {
+		clog.Warn("%v", err)
+	}
+
 	opts := StartOptions{
-		TCPPort:      execInfo.TCPPort,
-		SharedSecret: execInfo.Secret,
-		TokenAPIPort: tokenAPIPort,
-		ApprovalPort: approvalPort,
+		TCPPort:        execInfo.TCPPort,
+		SharedSecret:   execInfo.Secret,
+		TokenAPIPort:   tokenAPIPort,
+		ApprovalPort:   approvalPort,
+		ApprovalSecret: approvalSecret,
+		ApprovalOwner:  hostUser(),
+
+		ApproverSecrets: approverSecrets,
 	}
 	if err := StartWithOptions(opts); err != nil {
 		cleanupExecutor(execInfo)
 		return err
 	}
 
-	return WaitReadyWithPort(tokenAPIPort, 5*time.Second)
+	if err := WaitReadyWithPort(tokenAPIPort, 5*time.Second); err != nil {
+		return err
+	}
+	return checkRunningVersion(fmt.Sprintf("127.0.0.1:%d", tokenAPIPort))
 }
 
-// saveGuardianPorts saves the guardian ports to executor state so clients can discover them.
-func saveGuardianPorts(_ *ExecutorInfo, tokenAPIPort, approvalPort int) error {
+// checkRunningVersion negotiates with the guardian API at addr. Only an
+// incompatible guardian is an error: if the guardian cannot be reached, the
+// requests that follow report it.
+func checkRunningVersion(addr string) error {
+	_, err := NewClient(addr).Negotiate()
+	if err != nil && !errors.Is(err, ErrIncompatibleGuardian) {
+		clog.Debug("guardian version check: %v", err)
+		return nil
+	}
+	return err
+}
+
+// hostUser returns the name of the user starting the guardian, or "" if it
+// cannot be determined.
+func hostUser() string {
+	if u, err := user.Current(); err == nil {
+		return u.Username
+	}
+	return ""
+}
+
+// generateApproverSecrets returns a random login token for each approver in
+// the global config. Each is independent of the approval secret and of the
+// others, so one approver's URL reveals nothing about anyone else's.
+func generateApproverSecrets() map[string]string {
+	cfg, err := config.LoadGlobalConfig()
+	if err != nil || len(cfg.Approvers) == 0 {
+		return nil
+	}
+	secrets := make(map[string]string, len(cfg.Approvers))
+	for _, a := range cfg.Approvers {
+		secrets[a.Name] = token.Generate()
+	}
+	return secrets
+}
+
+// saveGuardianState saves the guardian ports and approval secrets to executor
+// state so clients can discover them.
+func saveGuardianState(tokenAPIPort, approvalPort int, approvalSecret string, approverSecrets map[string]string) error {
 	execState, err := executor.LoadDaemonState()
 	if errors.Is(err, executor.ErrNoState) {
 		return nil
@@ -415,6 +507,8 @@ func saveGuardianPorts(_ *ExecutorInfo, tokenAPIPort, approvalPort int) error {
 	}
 	execState.TokenAPIPort = tokenAPIPort
 	execState.ApprovalPort = approvalPort
+	execState.ApprovalSecret = approvalSecret
+	execState.ApproverSecrets = approverSecrets
 	if err := executor.SaveDaemonState(execState); err != nil {
 		return fmt.Errorf("failed to save guardian ports to executor state: %w", err)
 	}
@@ -433,18 +527,20 @@ func cleanupExecutor(execInfo *ExecutorInfo) {
 	}
 }
 
-// WaitReady polls the guardian API until it responds or timeout is reached.
-// This ensures the API server inside the container has started accepting connections.
+// WaitReady polls the guardian until it reports ready or timeout is reached.
+// This ensures the servers inside the container have started accepting connections.
 // Uses the default API address (dynamic for test instances).
 func WaitReady(timeout time.Duration) error {
 	return WaitReadyWithPort(APIPort(), timeout)
 }
 
-// WaitReadyWithPort polls the guardian API on a specific port until it responds.
+// WaitReadyWithPort polls GET /readyz on a specific API port until the
+// guardian reports ready. A guardian image without /readyz is taken to be
+// ready once its API responds.
 func WaitReadyWithPort(port int, timeout time.Duration) error {
 	client := &http.Client{Timeout: 500 * time.Millisecond}
 	// Use 127.0.0.1 explicitly since Docker port is bound to IPv4 only
-	url := fmt.Sprintf("http://127.0.0.1:%d/tokens", port)
+	url := fmt.Sprintf("http://127.0.0.1:%d/readyz", port)
 
 	deadline := time.Now().Add(timeout)
 	var lastErr error
@@ -458,9 +554,19 @@ func WaitReadyWithPort(port int, timeout time.Duration) error {
 		}
 		resp, err := client.Do(req)
 		if err == nil {
+			var report HealthReport
+			decodeErr := json.NewDecoder(resp.Body).Decode(&report)
 			_ = resp.Body.Close()
-			if resp.StatusCode == http.StatusOK {
+			switch resp.StatusCode {
+			case http.StatusOK, http.StatusNotFound:
 				return nil
+			case http.StatusServiceUnavailable:
+				if decodeErr == nil {
+					err = fmt.Errorf("not ready: %s", strings.Join(report.Unhealthy(), ", "))
+				}
+			}
+			if err == nil {
+				err = fmt.Errorf("status %d", resp.StatusCode)
 			}
 		}
 		lastErr = err
@@ -582,20 +688,31 @@ func RegisterTokenFull(token, cloisterName, projectName, worktreePath string) er
 	return client.RegisterTokenFull(token, cloisterName, projectName, worktreePath)
 }
 
-// RevokeToken revokes a token from the guardian.
+// RevokeToken revokes a token from the guardian and returns the report of
+// the cloister's session, if the guardian has one.
 // Returns nil if the guardian is not running or if the token doesn't exist.
-func RevokeToken(token string) error {
+func RevokeToken(token string) (*SessionReport, error) {
 	client, err := withGuardianClient()
 	if errors.Is(err, ErrGuardianNotRunning) {
 		// Guardian not running, nothing to revoke
-		return nil
+		return nil, nil
 	}
 	if err != nil {
-		return err
+		return nil, err
 	}
 	return client.RevokeToken(token)
 }
 
+// CheckHealth returns the guardian's health report.
+// Returns ErrGuardianNotRunning if the guardian is not running.
+func CheckHealth() (*HealthReport, error) {
+	client, err := withGuardianClient()
+	if err != nil {
+		return nil, err
+	}
+	return client.Health()
+}
+
 // ListTokens returns a map of all registered tokens to their cloister names.
 // Returns an empty map if the guardian is not running.
 func ListTokens() (map[string]string, error) {
@@ -609,3 +726,58 @@ func ListTokens() (map[string]string, error) {
 	}
 	return client.ListTokens()
 }
+
+// ApprovalURL returns the approval UI URL including the login token, built
+// from the port and secret recorded in executor state.
+func ApprovalURL() (string, error) {
+	port, secret, err := approvalEndpoint()
+	if err != nil {
+		return "", err
+	}
+	u := fmt.Sprintf("http://localhost:%d/", port)
+	if secret != "" {
+		u += "?" + approval.TokenParam + "=" + secret
+	}
+	return u, nil
+}
+
+// ApproverURL returns the named approver's approval UI login URL. Approvals
+// made through it are recorded under that approver's name.
+func ApproverURL(name string) (string, error) {
+	state, err := executor.LoadDaemonState()
+	if err != nil {
+		return "", fmt.Errorf("failed to load executor state: %w", err)
+	}
+	secret := state.ApproverSecrets[name]
+	if secret == "" {
+		return "", fmt.Errorf("no login token for approver %q; add them to approvers in the global config and restart the guardian", name)
+	}
+	port := state.ApprovalPort
+	if port == 0 {
+		port = DefaultApprovalPort
+	}
+	return fmt.Sprintf("http://localhost:%d/?%s=%s", port, approval.TokenParam, secret), nil
+}
+
+// NewApprovalClient returns a client for the running guardian's approval
+// server, authenticated with the secret recorded in executor state.
+func NewApprovalClient() (*approval.Client, error) {
+	port, secret, err := approvalEndpoint()
+	if err != nil {
+		return nil, err
+	}
+	return approval.NewClient(fmt.Sprintf("localhost:%d", port), secret), nil
+}
+
+// approvalEndpoint returns the approval server's host port and secret.
+func approvalEndpoint() (port int, secret string, err error) {
+	state, err := executor.LoadDaemonState()
+	if err != nil {
+		return 0, "", fmt.Errorf("failed to load executor state: %w", err)
+	}
+	port = state.ApprovalPort
+	if port == 0 {
+		port = DefaultApprovalPort
+	}
+	return port, state.ApprovalSecret, nil
+}
@@ -170,12 +170,14 @@ func TestGuardian_Lifecycle(t *testing.T) {
 
 		// Build request
 		// Token validation is handled by the guardian before forwarding to executor
-		req := executor.SocketRequest{
-			Secret: state.Secret,
+		req, err := executor.NewSocketRequest(state.Secret, executor.SocketPayload{
 			Request: executor.ExecuteRequest{
 				Command: "echo",
 				Args:    []string{"hello"},
 			},
+		})
+		if err != nil {
+			t.Fatalf("NewSocketRequest() error: %v", err)
 		}
 
 		// Send request
@@ -2,10 +2,12 @@ package guardian
 
 import (
 	"context"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/xdg/cloister/internal/docker"
+	"github.com/xdg/cloister/internal/executor"
 	"github.com/xdg/cloister/internal/token"
 )
 
@@ -94,3 +96,35 @@ func TestFindTokenForContainer_ErrorReturnsEmpty(t *testing.T) {
 		t.Errorf("FindTokenForContainer on error = %q, want empty string", got)
 	}
 }
+
+func TestApproverURL(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	t.Setenv(executor.InstanceIDEnvVar, "")
+	state := &executor.DaemonState{
+		ApprovalPort:    19999,
+		ApprovalSecret:  "owner-secret",
+		ApproverSecrets: map[string]string{"alice": "alice-secret", "bob": "bob-secret"},
+	}
+	if err := executor.SaveDaemonState(state); err != nil {
+		t.Fatalf("SaveDaemonState() error = %v", err)
+	}
+
+	u, err := ApproverURL("alice")
+	if err != nil {
+		t.Fatalf("ApproverURL() error = %v", err)
+	}
+	if u != "http://localhost:19999/?token=alice-secret" {
+		t.Errorf("ApproverURL(alice) = %q", u)
+	}
+	if _, err := ApproverURL("carol"); err == nil || !strings.Contains(err.Error(), "restart the guardian") {
+		t.Errorf("ApproverURL(carol) error = %v, want missing token", err)
+	}
+}
+
+func TestStartArgs_ApproverSecrets(t *testing.T) {
+	opts := StartOptions{ApproverSecrets: map[string]string{"alice": "alice-secret"}}
+	args := strings.Join(buildGuardianRunArgs(opts, 9997, 9999, hostDirs{}), " ")
+	if !strings.Contains(args, ApproverSecretsEnvVar+`={"alice":"alice-secret"}`) {
+		t.Errorf("start args = %q, want approver secrets", args)
+	}
+}
@@ -9,6 +9,7 @@ import (
 
 	"github.com/xdg/cloister/internal/audit"
 	"github.com/xdg/cloister/internal/clog"
+	"github.com/xdg/cloister/internal/config"
 	"github.com/xdg/cloister/internal/guardian/approval"
 )
 
@@ -98,6 +99,44 @@ type DomainApproverImpl struct {
 	queue       *approval.DomainQueue
 	recorder    DecisionRecorder
 	auditLogger *audit.Logger
+	quorum      []quorumRule
+	timeout     func(project string) time.Duration
+}
+
+// quorumRule is a compiled config.QuorumRule.
+type quorumRule struct {
+	domains  *DomainSet
+	required int
+}
+
+// SetQuorum sets the rules for domains that need more than one approver.
+// When several rules match a domain, the largest requirement applies.
+func (d *DomainApproverImpl) SetQuorum(rules []config.QuorumRule) {
+	d.quorum = make([]quorumRule, 0, len(rules))
+	for _, r := range rules {
+		d.quorum = append(d.quorum, quorumRule{
+			domains:  NewDomainSetFromConfig([]config.AllowEntry{{Domain: r.Domain, Pattern: r.Pattern}}),
+			required: r.RequireApprovals,
+		})
+	}
+}
+
+// SetProjectTimeout sets the lookup for a project's domain approval
+// timeout. A zero result uses the queue's timeout.
+func (d *DomainApproverImpl) SetProjectTimeout(fn func(project string) time.Duration) {
+	d.timeout = fn
+}
+
+// requiredApprovals returns the number of approvers domain needs, or 0 if
+// no quorum rule matches it.
+func (d *DomainApproverImpl) requiredApprovals(domain string) int {
+	n := 0
+	for _, r := range d.quorum {
+		if r.domains.Contains(domain) {
+			n = max(n, r.required)
+		}
+	}
+	return n
 }
 
 // NewDomainApprover creates a new DomainApproverImpl.
@@ -140,9 +179,14 @@ func (d *DomainApproverImpl) RequestApproval(project, cloister, domain, token st
 		Token:     token,
 		Timestamp: time.Now(),
 		Responses: []chan<- approval.DomainResponse{respChan},
+
+		RequireApprovals: d.requiredApprovals(domain),
+	}
+	if d.timeout != nil && project != "" {
+		req.Timeout = d.timeout(project)
 	}
 
-	_, err := d.queue.Add(req)
+	id, err := d.queue.Add(req)
 	if err != nil {
 		return DomainApprovalResult{}, fmt.Errorf("failed to add domain request to queue: %w", err)
 	}
@@ -153,8 +197,8 @@ func (d *DomainApproverImpl) RequestApproval(project, cloister, domain, token st
 	case "timeout":
 		return DomainApprovalResult{Approved: false}, nil
 	case "denied":
-		d.handleDenial(project, cloister, domain, token, resp)
-		return DomainApprovalResult{Approved: false}, nil
+		d.handleDenial(id, project, cloister, domain, token, resp)
+		return DomainApprovalResult{Approved: false, Message: resp.Message}, nil
 	case "approved":
 		d.handleApproval(project, domain, token, resp)
 		return DomainApprovalResult{Approved: true, Scope: resp.Scope}, nil
@@ -164,9 +208,9 @@ func (d *DomainApproverImpl) RequestApproval(project, cloister, domain, token st
 }
 
 // handleDenial processes a denied domain response with scope-based persistence.
-func (d *DomainApproverImpl) handleDenial(project, cloister, domain, token string, resp approval.DomainResponse) {
+func (d *DomainApproverImpl) handleDenial(requestID, project, cloister, domain, token string, resp approval.DomainResponse) {
 	if d.auditLogger != nil {
-		if err := d.auditLogger.LogDomainDenyWithScope(project, cloister, domain, resp.Scope, resp.Pattern); err != nil {
+		if err := d.auditLogger.LogDomainDenyWithScope(requestID, project, cloister, domain, resp.Scope, resp.Pattern); err != nil {
 			clog.Warn("failed to log domain deny audit event: %v", err)
 		}
 	}
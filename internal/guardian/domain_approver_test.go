@@ -272,6 +272,32 @@ func TestDomainApproverImpl_RequestApproval_Timeout(t *testing.T) {
 	}
 }
 
+func TestDomainApproverImpl_RequestApproval_ProjectTimeout(t *testing.T) {
+	queue := approval.NewDomainQueueWithTimeout(time.Minute)
+	approver := NewDomainApprover(queue, newMockDecisionRecorder(), nil)
+	approver.SetProjectTimeout(func(project string) time.Duration {
+		if project == "fast" {
+			return 30 * time.Millisecond
+		}
+		return 0
+	})
+
+	done := make(chan DomainApprovalResult, 1)
+	go func() {
+		result, _ := approver.RequestApproval("fast", "fast-main", "example.com", "tok")
+		done <- result
+	}()
+
+	select {
+	case result := <-done:
+		if result.Approved {
+			t.Error("expected timeout, got approval")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("request did not use the project's approval timeout")
+	}
+}
+
 func TestDomainApproverImpl_RequestApproval_Denied(t *testing.T) {
 	queue := approval.NewDomainQueueWithTimeout(5 * time.Second)
 	recorder := newMockDecisionRecorder()
@@ -307,8 +333,9 @@ func TestDomainApproverImpl_RequestApproval_Denied(t *testing.T) {
 
 	// Send denial
 	req.Responses[0] <- approval.DomainResponse{
-		Status: "denied",
-		Reason: "test denial",
+		Status:  "denied",
+		Reason:  "test denial",
+		Message: "test denial",
 	}
 	queue.Remove(requests[0].ID)
 
@@ -321,6 +348,9 @@ func TestDomainApproverImpl_RequestApproval_Denied(t *testing.T) {
 	if result.Approved {
 		t.Errorf("Expected Approved=false for denial, got true")
 	}
+	if result.Message != "test denial" {
+		t.Errorf("Message = %q, want %q", result.Message, "test denial")
+	}
 }
 
 func TestDomainApproverImpl_RequestApproval_SessionScope(t *testing.T) {
@@ -8,9 +8,11 @@ import (
 	"encoding/json"
 	"fmt"
 	"net"
+	"path/filepath"
 
 	"github.com/xdg/cloister/internal/clog"
 	"github.com/xdg/cloister/internal/executor"
+	"github.com/xdg/cloister/internal/tracing"
 )
 
 // DefaultSocketPath is the default path to the hostexec socket inside the guardian container.
@@ -56,22 +58,65 @@ func NewTCPClient(port int, secret string) *Client {
 // Execute sends a command execution request to the host executor and returns the response.
 // It opens a new connection for each request, sends the request as newline-delimited JSON,
 // reads the response, and closes the connection.
-func (c *Client) Execute(req executor.ExecuteRequest) (*executor.ExecuteResponse, error) {
+//
+// If onProgress is non-nil, the executor is asked to report queue position
+// and start of execution, and onProgress is called for each update before
+// the final response arrives. Cancelling ctx closes the connection, which
+// withdraws a queued request or kills a running command on the host.
+//
+// The request carries the trace context of ctx, so the executor's spans
+// join the caller's trace. Spans name only the executable, never its path
+// or arguments, which may hold secrets.
+func (c *Client) Execute(ctx context.Context, req executor.ExecuteRequest, onProgress func(executor.Progress)) (*executor.ExecuteResponse, error) {
+	ctx, span := tracing.Start(ctx, "executor.call", tracing.String("process.executable.name", filepath.Base(req.Command)))
+	defer span.End()
+
+	socketResp, err := c.roundTrip(ctx, executor.SocketPayload{
+		Request:     req,
+		Progress:    onProgress != nil,
+		Traceparent: tracing.Traceparent(ctx),
+	}, onProgress)
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+	span.SetAttributes(tracing.String("cloister.status", socketResp.Response.Status))
+	return &socketResp.Response, nil
+}
+
+// Ping checks that the executor is reachable, accepts the shared secret and
+// speaks a compatible socket protocol version, without running anything.
+func (c *Client) Ping(ctx context.Context) error {
+	resp, err := c.roundTrip(ctx, executor.SocketPayload{Ping: true}, nil)
+	if err != nil {
+		return err
+	}
+	if err := executor.CheckProtocolVersion(resp.Version); err != nil {
+		return fmt.Errorf("incompatible executor: %w", err)
+	}
+	return nil
+}
+
+// roundTrip sends one signed request over a new connection and returns the
+// final response, passing any progress updates to onProgress.
+func (c *Client) roundTrip(ctx context.Context, payload executor.SocketPayload, onProgress func(executor.Progress)) (*executor.SocketResponse, error) {
 	// Connect to the executor
-	conn, err := (&net.Dialer{}).DialContext(context.Background(), c.network, c.address)
+	conn, err := (&net.Dialer{}).DialContext(ctx, c.network, c.address)
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to executor (%s): %w", c.address, err)
 	}
 	defer func() {
-		if err := conn.Close(); err != nil {
+		if err := conn.Close(); err != nil && ctx.Err() == nil {
 			clog.Warn("failed to close executor connection: %v", err)
 		}
 	}()
+	stop := context.AfterFunc(ctx, func() { _ = conn.Close() })
+	defer stop()
 
-	// Build the socket request with authentication
-	socketReq := executor.SocketRequest{
-		Secret:  c.secret,
-		Request: req,
+	// Build the socket request, signed with the shared secret
+	socketReq, err := executor.NewSocketRequest(c.secret, payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign request: %w", err)
 	}
 
 	// Marshal and send request (newline-delimited JSON)
@@ -85,17 +130,9 @@ func (c *Client) Execute(req executor.ExecuteRequest) (*executor.ExecuteResponse
 		return nil, fmt.Errorf("failed to send request: %w", err)
 	}
 
-	// Read response (newline-delimited JSON)
-	reader := bufio.NewReader(conn)
-	respLine, err := reader.ReadBytes('\n')
+	socketResp, err := readFinalResponse(ctx, bufio.NewReader(conn), onProgress)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read response: %w", err)
-	}
-
-	// Parse response
-	var socketResp executor.SocketResponse
-	if err := json.Unmarshal(respLine, &socketResp); err != nil {
-		return nil, fmt.Errorf("failed to parse response: %w", err)
+		return nil, err
 	}
 
 	// Check for socket-level errors (authentication, validation, etc.)
@@ -103,5 +140,30 @@ func (c *Client) Execute(req executor.ExecuteRequest) (*executor.ExecuteResponse
 		return nil, fmt.Errorf("executor error: %s", socketResp.Error)
 	}
 
-	return &socketResp.Response, nil
+	return socketResp, nil
+}
+
+// readFinalResponse reads newline-delimited responses, passing progress
+// updates to onProgress, until the final response arrives.
+func readFinalResponse(ctx context.Context, reader *bufio.Reader, onProgress func(executor.Progress)) (*executor.SocketResponse, error) {
+	for {
+		respLine, err := reader.ReadBytes('\n')
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, fmt.Errorf("execution cancelled: %w", ctx.Err())
+			}
+			return nil, fmt.Errorf("failed to read response: %w", err)
+		}
+
+		var socketResp executor.SocketResponse
+		if err := json.Unmarshal(respLine, &socketResp); err != nil {
+			return nil, fmt.Errorf("failed to parse response: %w", err)
+		}
+		if socketResp.Progress == nil {
+			return &socketResp, nil
+		}
+		if onProgress != nil {
+			onProgress(*socketResp.Progress)
+		}
+	}
 }
@@ -9,8 +9,11 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/xdg/cloister/internal/executor"
+	"github.com/xdg/cloister/internal/testutil"
+	"github.com/xdg/cloister/internal/tracing"
 )
 
 // shortTempDir creates a short temp directory for socket files.
@@ -54,6 +57,22 @@ func newMockServer(t *testing.T) *mockServer {
 	}
 }
 
+// decodeSignedRequest parses a request line, verifies its HMAC against
+// secret, and returns the decoded payload.
+func decodeSignedRequest(line []byte, secret string) (executor.SocketPayload, error) {
+	var req executor.SocketRequest
+	if err := json.Unmarshal(line, &req); err != nil {
+		return executor.SocketPayload{}, err
+	}
+	cache := executor.NewReplayCache(executor.MaxClockSkew)
+	if err := executor.VerifyAuth(secret, &req.Auth, req.Payload, time.Now(), cache); err != nil {
+		return executor.SocketPayload{}, err
+	}
+	var payload executor.SocketPayload
+	err := json.Unmarshal(req.Payload, &payload)
+	return payload, err
+}
+
 // TestClientExecuteSuccess verifies successful command execution.
 func TestClientExecuteSuccess(t *testing.T) {
 	mock := newMockServer(t)
@@ -74,17 +93,14 @@ func TestClientExecuteSuccess(t *testing.T) {
 			return
 		}
 
-		// Verify wire format
-		var req executor.SocketRequest
-		if err := json.Unmarshal(line, &req); err != nil {
-			t.Errorf("Failed to unmarshal request: %v", err)
+		// Verify wire format and signature
+		req, err := decodeSignedRequest(line, "test-secret")
+		if err != nil {
+			t.Errorf("Failed to verify request: %v", err)
 			return
 		}
 
 		// Validate expected fields
-		if req.Secret != "test-secret" {
-			t.Errorf("Secret: got %q, want %q", req.Secret, "test-secret")
-		}
 		if req.Request.Command != "echo" {
 			t.Errorf("Command: got %q, want %q", req.Request.Command, "echo")
 		}
@@ -118,7 +134,7 @@ func TestClientExecuteSuccess(t *testing.T) {
 		Workdir: "/work",
 	}
 
-	resp, err := client.Execute(req)
+	resp, err := client.Execute(context.Background(), req, nil)
 	if err != nil {
 		t.Fatalf("Execute failed: %v", err)
 	}
@@ -145,7 +161,7 @@ func TestClientExecuteConnectionError(t *testing.T) {
 		Command: "echo",
 	}
 
-	resp, err := client.Execute(req)
+	resp, err := client.Execute(context.Background(), req, nil)
 	if err == nil {
 		t.Fatal("Expected error for non-existent socket")
 	}
@@ -183,7 +199,7 @@ func TestClientExecuteInvalidResponse(t *testing.T) {
 		Command: "echo",
 	}
 
-	resp, err := client.Execute(req)
+	resp, err := client.Execute(context.Background(), req, nil)
 	if err == nil {
 		t.Fatal("Expected error for invalid JSON response")
 	}
@@ -227,7 +243,7 @@ func TestClientExecuteSocketError(t *testing.T) {
 		Command: "echo",
 	}
 
-	resp, err := client.Execute(req)
+	resp, err := client.Execute(context.Background(), req, nil)
 	if err == nil {
 		t.Fatal("Expected error for socket-level error response")
 	}
@@ -246,7 +262,7 @@ func TestClientExecuteSocketError(t *testing.T) {
 func TestClientExecuteWithEnvAndTimeout(t *testing.T) {
 	mock := newMockServer(t)
 
-	receivedReq := make(chan executor.SocketRequest, 1)
+	receivedReq := make(chan executor.SocketPayload, 1)
 
 	// Handle connection in background
 	go func() {
@@ -263,8 +279,7 @@ func TestClientExecuteWithEnvAndTimeout(t *testing.T) {
 			return
 		}
 
-		var req executor.SocketRequest
-		if err := json.Unmarshal(line, &req); err == nil {
+		if req, err := decodeSignedRequest(line, "test-secret"); err == nil {
 			receivedReq <- req
 		}
 
@@ -291,7 +306,7 @@ func TestClientExecuteWithEnvAndTimeout(t *testing.T) {
 		TimeoutMs: 5000,
 	}
 
-	_, err := client.Execute(req)
+	_, err := client.Execute(context.Background(), req, nil)
 	if err != nil {
 		t.Fatalf("Execute failed: %v", err)
 	}
@@ -338,7 +353,7 @@ func TestClientExecuteReadError(t *testing.T) {
 		Command: "echo",
 	}
 
-	resp, err := client.Execute(req)
+	resp, err := client.Execute(context.Background(), req, nil)
 	if err == nil {
 		t.Fatal("Expected error when connection closed before response")
 	}
@@ -389,7 +404,7 @@ func TestClientWireFormat(t *testing.T) {
 		Workdir: "/home",
 	}
 
-	_, err := client.Execute(req)
+	_, err := client.Execute(context.Background(), req, nil)
 	if err != nil {
 		t.Fatalf("Execute failed: %v", err)
 	}
@@ -400,20 +415,36 @@ func TestClientWireFormat(t *testing.T) {
 		t.Fatalf("Failed to parse raw request: %v", err)
 	}
 
-	// Verify top-level "secret" field exists
-	if _, ok := parsed["secret"]; !ok {
-		t.Error("Wire format missing 'secret' field at top level")
+	// The secret must never be sent, in any form
+	if strings.Contains(string(rawLine), "my-secret") {
+		t.Errorf("Wire format contains the secret: %s", rawLine)
+	}
+	if _, ok := parsed["secret"]; ok {
+		t.Error("Wire format has a 'secret' field")
 	}
 
-	// Verify top-level "request" field exists
-	if _, ok := parsed["request"]; !ok {
-		t.Error("Wire format missing 'request' field at top level")
+	// Verify top-level "auth" and "payload" fields exist
+	authMap, ok := parsed["auth"].(map[string]any)
+	if !ok {
+		t.Fatal("Wire format 'auth' is not an object")
+	}
+	for _, field := range []string{"scheme", "timestamp", "nonce", "mac"} {
+		if _, ok := authMap[field]; !ok {
+			t.Errorf("Wire format auth missing '%s' field", field)
+		}
+	}
+	if authMap["scheme"] != executor.AuthSchemeHMACSHA256 {
+		t.Errorf("auth scheme: got %v, want %q", authMap["scheme"], executor.AuthSchemeHMACSHA256)
+	}
+	payloadMap, ok := parsed["payload"].(map[string]any)
+	if !ok {
+		t.Fatal("Wire format 'payload' is not an object")
 	}
 
 	// Verify nested structure
-	reqMap, ok := parsed["request"].(map[string]any)
+	reqMap, ok := payloadMap["request"].(map[string]any)
 	if !ok {
-		t.Fatal("Wire format 'request' is not an object")
+		t.Fatal("Wire format 'payload.request' is not an object")
 	}
 
 	expectedFields := []string{"command", "args", "workdir"}
@@ -423,3 +454,194 @@ func TestClientWireFormat(t *testing.T) {
 		}
 	}
 }
+
+// TestClientExecuteProgress verifies progress lines are delivered to the
+// callback and the final response is returned.
+func TestClientExecuteProgress(t *testing.T) {
+	mock := newMockServer(t)
+
+	go func() {
+		conn, err := mock.listener.Accept()
+		if err != nil {
+			return
+		}
+		defer func() { _ = conn.Close() }()
+
+		line, err := bufio.NewReader(conn).ReadBytes('\n')
+		if err != nil {
+			return
+		}
+		if req, err := decodeSignedRequest(line, "test-secret"); err != nil || !req.Progress {
+			t.Errorf("expected progress to be requested, got %s", line)
+		}
+		for _, resp := range []executor.SocketResponse{
+			{Success: true, Progress: &executor.Progress{State: executor.ProgressQueued, Position: 2}},
+			{Success: true, Progress: &executor.Progress{State: executor.ProgressQueued, Position: 1}},
+			{Success: true, Progress: &executor.Progress{State: executor.ProgressRunning}},
+			{Success: true, Response: executor.ExecuteResponse{Status: executor.StatusCompleted, Stdout: "done"}},
+		} {
+			data, _ := json.Marshal(resp)
+			_, _ = conn.Write(append(data, '\n'))
+		}
+	}()
+
+	client := NewClient(mock.sockPath, "test-secret")
+	var got []executor.Progress
+	resp, err := client.Execute(context.Background(), executor.ExecuteRequest{Command: "make"}, func(p executor.Progress) {
+		got = append(got, p)
+	})
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	if resp.Stdout != "done" {
+		t.Errorf("Stdout = %q, want %q", resp.Stdout, "done")
+	}
+	want := []executor.Progress{
+		{State: executor.ProgressQueued, Position: 2},
+		{State: executor.ProgressQueued, Position: 1},
+		{State: executor.ProgressRunning},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("progress = %+v, want %+v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("progress[%d] = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+// TestClientExecuteCancel verifies cancelling the context closes the
+// connection and returns promptly.
+func TestClientExecuteCancel(t *testing.T) {
+	mock := newMockServer(t)
+	received := make(chan struct{})
+	closed := make(chan struct{})
+
+	go func() {
+		conn, err := mock.listener.Accept()
+		if err != nil {
+			return
+		}
+		defer func() { _ = conn.Close() }()
+		reader := bufio.NewReader(conn)
+		_, _ = reader.ReadBytes('\n')
+		close(received)
+		// Never respond; wait for the client to hang up.
+		_, _ = reader.ReadByte()
+		close(closed)
+	}()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errCh := make(chan error, 1)
+	go func() {
+		_, err := NewClient(mock.sockPath, "test-secret").Execute(ctx, executor.ExecuteRequest{Command: "sleep"}, nil)
+		errCh <- err
+	}()
+
+	<-received
+	cancel()
+	err := <-errCh
+	if err == nil || !strings.Contains(err.Error(), "cancel") {
+		t.Errorf("Execute() error = %v, want cancellation error", err)
+	}
+	<-closed
+}
+
+// TestClientPing verifies that Ping sends a signed ping and reports
+// connection failures.
+func TestClientPing(t *testing.T) {
+	server := newMockServer(t)
+	got := make(chan executor.SocketPayload, 1)
+	go func() {
+		conn, err := server.listener.Accept()
+		if err != nil {
+			return
+		}
+		defer func() { _ = conn.Close() }()
+		line, _ := bufio.NewReader(conn).ReadBytes('\n')
+		payload, _ := decodeSignedRequest(line, "test-secret")
+		got <- payload
+		_, _ = conn.Write([]byte(`{"version":1,"success":true}` + "\n"))
+	}()
+
+	if err := NewClient(server.sockPath, "test-secret").Ping(context.Background()); err != nil {
+		t.Fatalf("Ping() returned error: %v", err)
+	}
+	if payload := <-got; !payload.Ping || payload.Version != executor.ProtocolVersion {
+		t.Errorf("payload = %+v, want a ping at version %d", payload, executor.ProtocolVersion)
+	}
+
+	missing := filepath.Join(shortTempDir(t), "missing.sock")
+	if err := NewClient(missing, "test-secret").Ping(context.Background()); err == nil {
+		t.Error("Ping() to a missing socket succeeded")
+	}
+}
+
+// completingExecutor completes every command with exit code 0.
+type completingExecutor struct{}
+
+func (completingExecutor) Execute(context.Context, executor.ExecuteRequest) executor.ExecuteResponse {
+	return executor.ExecuteResponse{Status: executor.StatusCompleted}
+}
+
+func TestClientExecute_PropagatesTrace(t *testing.T) {
+	collector, tracer := testutil.StartCollector(t, "test")
+
+	sockPath := filepath.Join(shortTempDir(t), "exec.sock")
+	server := executor.NewSocketServer("test-secret", completingExecutor{}, executor.WithSocketPath(sockPath))
+	if err := server.Start(); err != nil {
+		t.Fatalf("failed to start executor: %v", err)
+	}
+
+	ctx, root := tracing.Start(context.Background(), "hostexec.request")
+	_, err := NewClient(sockPath, "test-secret").Execute(ctx, executor.ExecuteRequest{Cloister: "api-main", Command: "/usr/bin/make", Args: []string{"TOKEN=s3cret"}}, nil)
+	root.End()
+	// Stopping waits for the connection handler, which ends the executor's spans.
+	_ = server.Stop()
+	if err != nil {
+		t.Fatalf("Execute() returned error: %v", err)
+	}
+
+	call := collector.Span(t, "executor.call", tracer)
+	execute := collector.Span(t, "executor.execute")
+	run := collector.Span(t, "executor.run")
+	if call.ParentSpanID != root.Context().Traceparent()[36:52] {
+		t.Errorf("executor.call parent = %s, want the request span", call.ParentSpanID)
+	}
+	if execute.TraceID != call.TraceID || execute.ParentSpanID != call.SpanID {
+		t.Errorf("executor.execute %+v is not a child of executor.call %+v", execute, call)
+	}
+	if run.ParentSpanID != execute.SpanID || run.Attributes["cloister.status"] != executor.StatusCompleted || run.Attributes["process.exit.code"] != "0" {
+		t.Errorf("executor.run = %+v", run)
+	}
+	if execute.Attributes["cloister.name"] != "api-main" || execute.Attributes["process.executable.name"] != "make" {
+		t.Errorf("executor.execute attributes = %v", execute.Attributes)
+	}
+	for _, span := range []testutil.Span{call, execute, run} {
+		for k, v := range span.Attributes {
+			if strings.Contains(v, "s3cret") || strings.Contains(v, "/usr/bin") {
+				t.Errorf("%s attribute %s = %q exposes the command line", span.Name, k, v)
+			}
+		}
+	}
+}
+
+func TestClientPing_OldExecutor(t *testing.T) {
+	server := newMockServer(t)
+	go func() {
+		conn, err := server.listener.Accept()
+		if err != nil {
+			return
+		}
+		defer func() { _ = conn.Close() }()
+		_, _ = bufio.NewReader(conn).ReadBytes('\n')
+		// An executor that predates protocol versioning.
+		_, _ = conn.Write([]byte(`{"success":true}` + "\n"))
+	}()
+
+	err := NewClient(server.sockPath, "test-secret").Ping(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "incompatible executor: unsupported protocol version: peer predates protocol versioning") {
+		t.Errorf("Ping() = %v, want an incompatible executor error", err)
+	}
+}
@@ -0,0 +1,197 @@
+package guardian
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/xdg/cloister/internal/clog"
+)
+
+// Health states of a guardian component, and of the guardian as a whole.
+const (
+	HealthOK       = "ok"
+	HealthDegraded = "degraded" // Working, but something needs attention
+	HealthDown     = "down"     // Not working
+)
+
+// healthCheckTimeout bounds each component check, such as pinging the
+// executor.
+const healthCheckTimeout = 2 * time.Second
+
+// ComponentHealth is the state of one guardian subsystem.
+type ComponentHealth struct {
+	Name   string `json:"name"`
+	Status string `json:"status"`
+	Detail string `json:"detail,omitempty"`
+
+	// Critical components must not be down for the guardian to be ready.
+	Critical bool `json:"critical,omitempty"`
+}
+
+// HealthReport is the response of GET /healthz and GET /readyz.
+type HealthReport struct {
+	// Status is HealthDown if a critical component is down, HealthDegraded
+	// if any component is not ok, and HealthOK otherwise.
+	Status     string            `json:"status"`
+	Ready      bool              `json:"ready"`
+	Components []ComponentHealth `json:"components"`
+}
+
+// Unhealthy returns the components that are not ok, as "name: status".
+func (r *HealthReport) Unhealthy() []string {
+	var out []string
+	for _, c := range r.Components {
+		if c.Status != HealthOK {
+			out = append(out, c.Name+": "+c.Status)
+		}
+	}
+	return out
+}
+
+// HealthCheck reports a component's status (HealthOK, HealthDegraded or
+// HealthDown) and a short detail for people.
+type HealthCheck func(ctx context.Context) (status, detail string)
+
+// Health collects the state of the guardian's subsystems, served at
+// /healthz and /readyz on the API port. Add and recording reloads on a nil
+// *Health do nothing.
+type Health struct {
+	mu      sync.Mutex
+	checks  []namedCheck
+	reloads map[string]reloadResult // By reload kind
+	now     func() time.Time
+}
+
+// namedCheck is a registered component check.
+type namedCheck struct {
+	name     string
+	critical bool
+	check    HealthCheck
+}
+
+// reloadResult is the outcome of the most recent reload of one kind.
+type reloadResult struct {
+	at  time.Time
+	err error
+}
+
+// NewHealth creates a Health with no components.
+func NewHealth() *Health {
+	return &Health{reloads: make(map[string]reloadResult), now: time.Now}
+}
+
+// Add registers a component check. Components are reported in the order
+// they were added, followed by the outcome of the latest reloads.
+func (h *Health) Add(name string, critical bool, check HealthCheck) {
+	if h == nil {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.checks = append(h.checks, namedCheck{name: name, critical: critical, check: check})
+}
+
+// reload records the outcome of a reload.
+func (h *Health) reload(kind string, err error) {
+	if h == nil {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.reloads[kind] = reloadResult{at: h.now(), err: err}
+}
+
+// checkReloads reports whether the most recent reload of each kind
+// succeeded.
+func (h *Health) checkReloads(context.Context) (status, detail string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if len(h.reloads) == 0 {
+		return HealthOK, "no reloads since start"
+	}
+	kinds := make([]string, 0, len(h.reloads))
+	for kind := range h.reloads {
+		kinds = append(kinds, kind)
+	}
+	slices.Sort(kinds)
+
+	var latest time.Time
+	for _, kind := range kinds {
+		r := h.reloads[kind]
+		if r.err != nil {
+			return HealthDegraded, fmt.Sprintf("%s reload failed at %s: %v", kind, r.at.Format(time.RFC3339), r.err)
+		}
+		if r.at.After(latest) {
+			latest = r.at
+		}
+	}
+	return HealthOK, "last reload at " + latest.Format(time.RFC3339)
+}
+
+// Report runs every component check.
+func (h *Health) Report(ctx context.Context) *HealthReport {
+	h.mu.Lock()
+	checks := append(slices.Clone(h.checks), namedCheck{name: "reload", check: h.checkReloads})
+	h.mu.Unlock()
+
+	report := &HealthReport{Status: HealthOK, Ready: true}
+	for _, c := range checks {
+		checkCtx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+		status, detail := c.check(checkCtx)
+		cancel()
+		report.Components = append(report.Components, ComponentHealth{
+			Name: c.name, Status: status, Detail: detail, Critical: c.critical,
+		})
+		switch {
+		case status == HealthOK:
+		case status == HealthDown && c.critical:
+			report.Status, report.Ready = HealthDown, false
+		case report.Status == HealthOK:
+			report.Status = HealthDegraded
+		}
+	}
+	return report
+}
+
+// handleHealthz serves GET /healthz: the health report, with status 200
+// whenever the guardian can answer at all.
+func (h *Health) handleHealthz(w http.ResponseWriter, r *http.Request) {
+	writeHealthReport(w, h.Report(r.Context()), http.StatusOK)
+}
+
+// handleReadyz serves GET /readyz: the health report, with status 503
+// while a critical component is down.
+func (h *Health) handleReadyz(w http.ResponseWriter, r *http.Request) {
+	report := h.Report(r.Context())
+	status := http.StatusOK
+	if !report.Ready {
+		status = http.StatusServiceUnavailable
+	}
+	writeHealthReport(w, report, status)
+}
+
+// writeHealthReport writes a health report as JSON.
+func writeHealthReport(w http.ResponseWriter, report *HealthReport, status int) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(report); err != nil {
+		clog.Debug("health: failed to write response: %v", err)
+	}
+}
+
+// listeningCheck reports a server as ok while it is listening, and down
+// otherwise.
+func listeningCheck(srv stoppable) HealthCheck {
+	return func(context.Context) (string, string) {
+		if addr := srv.ListenAddr(); addr != "" {
+			return HealthOK, "listening on " + addr
+		}
+		return HealthDown, "not listening"
+	}
+}
@@ -0,0 +1,183 @@
+package guardian
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/xdg/cloister/internal/audit"
+	"github.com/xdg/cloister/internal/guardian/request"
+)
+
+func staticCheck(status, detail string) HealthCheck {
+	return func(context.Context) (string, string) { return status, detail }
+}
+
+func TestHealth_Report(t *testing.T) {
+	tests := []struct {
+		name       string
+		add        func(h *Health)
+		wantStatus string
+		wantReady  bool
+	}{
+		{"all ok", func(h *Health) {
+			h.Add("proxy", true, staticCheck(HealthOK, ""))
+		}, HealthOK, true},
+		{"optional degraded", func(h *Health) {
+			h.Add("proxy", true, staticCheck(HealthOK, ""))
+			h.Add("executor", false, staticCheck(HealthDegraded, "unreachable"))
+		}, HealthDegraded, true},
+		{"optional down", func(h *Health) {
+			h.Add("executor", false, staticCheck(HealthDown, ""))
+		}, HealthDegraded, true},
+		{"critical down", func(h *Health) {
+			h.Add("proxy", true, staticCheck(HealthDown, "not listening"))
+			h.Add("executor", false, staticCheck(HealthDegraded, ""))
+		}, HealthDown, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewHealth()
+			tt.add(h)
+			report := h.Report(context.Background())
+			if report.Status != tt.wantStatus || report.Ready != tt.wantReady {
+				t.Errorf("Report() = %s, ready %v; want %s, ready %v", report.Status, report.Ready, tt.wantStatus, tt.wantReady)
+			}
+			if last := report.Components[len(report.Components)-1]; last.Name != "reload" {
+				t.Errorf("last component = %q, want reload", last.Name)
+			}
+		})
+	}
+}
+
+func TestHealth_Reloads(t *testing.T) {
+	h := NewHealth()
+	now := time.Date(2024, 1, 15, 14, 0, 0, 0, time.UTC)
+	h.now = func() time.Time { return now }
+
+	h.reload(reloadTokens, nil)
+	h.reload(reloadPolicy, errors.New("bad yaml"))
+	report := h.Report(context.Background())
+	reload := report.Components[0]
+	if report.Status != HealthDegraded || reload.Status != HealthDegraded || !strings.Contains(reload.Detail, "policy reload failed at 2024-01-15T14:00:00Z: bad yaml") {
+		t.Errorf("after failed reload: %s, %+v", report.Status, reload)
+	}
+
+	// A later successful reload clears the failure.
+	h.reload(reloadPolicy, nil)
+	if report := h.Report(context.Background()); report.Status != HealthOK {
+		t.Errorf("after successful reload: %+v", report)
+	}
+
+	var nilHealth *Health
+	nilHealth.reload(reloadPolicy, nil)
+	nilHealth.Add("proxy", true, staticCheck(HealthOK, ""))
+}
+
+func TestAPIServer_HealthEndpoints(t *testing.T) {
+	h := NewHealth()
+	var down atomic.Bool
+	h.Add("request", true, func(context.Context) (string, string) {
+		if down.Load() {
+			return HealthDown, "not listening"
+		}
+		return HealthOK, "listening"
+	})
+
+	api := NewAPIServer(":0", newMockRegistry())
+	api.Health = h
+	if err := api.Start(); err != nil {
+		t.Fatalf("failed to start API server: %v", err)
+	}
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = api.Stop(ctx)
+	}()
+
+	client := NewClient(api.ListenAddr())
+	client.HTTPClient = noProxyClient()
+	_, portStr, _ := net.SplitHostPort(api.ListenAddr())
+	port, _ := strconv.Atoi(portStr)
+
+	if err := WaitReadyWithPort(port, time.Second); err != nil {
+		t.Errorf("WaitReadyWithPort() while ready: %v", err)
+	}
+
+	down.Store(true)
+	report, err := client.Health()
+	if err != nil || report.Status != HealthDown || report.Ready {
+		t.Errorf("Health() while down = %+v, %v", report, err)
+	}
+	err = WaitReadyWithPort(port, 300*time.Millisecond)
+	if err == nil || !strings.Contains(err.Error(), "not ready: request: down") {
+		t.Errorf("WaitReadyWithPort() while down = %v", err)
+	}
+}
+
+func TestWaitReadyWithPort_OldGuardian(t *testing.T) {
+	// A guardian image without /readyz answers 404.
+	srv := httptest.NewServer(http.NotFoundHandler())
+	defer srv.Close()
+	u, _ := url.Parse(srv.URL)
+	port, _ := strconv.Atoi(u.Port())
+
+	if err := WaitReadyWithPort(port, time.Second); err != nil {
+		t.Errorf("WaitReadyWithPort() = %v, want ready", err)
+	}
+}
+
+// pingingExecutor is an executor client whose Ping returns err.
+type pingingExecutor struct {
+	fakeExecutor
+	err error
+}
+
+func (p pingingExecutor) Ping(context.Context) error { return p.err }
+
+func TestExecutorHealthCheck(t *testing.T) {
+	tests := []struct {
+		name       string
+		client     request.CommandExecutor
+		wantStatus string
+		wantDetail string
+	}{
+		{"not configured", nil, HealthDegraded, "not configured"},
+		{"reachable", pingingExecutor{}, HealthOK, "reachable"},
+		{"unreachable", pingingExecutor{err: errors.New("connection refused")}, HealthDegraded, "unreachable: connection refused"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, detail := executorHealthCheck(tt.client)(context.Background())
+			if status != tt.wantStatus || !strings.Contains(detail, tt.wantDetail) {
+				t.Errorf("check = %s, %q; want %s, %q", status, detail, tt.wantStatus, tt.wantDetail)
+			}
+		})
+	}
+}
+
+func TestAuditHealthCheck(t *testing.T) {
+	s := &Server{auditLogger: audit.NewLogger(nil)}
+	if status, detail := s.auditHealthCheck(context.Background()); status != HealthOK || detail != "not writing to a file" {
+		t.Errorf("without a file: %s, %q", status, detail)
+	}
+
+	s.auditLogger = audit.NewLogger(failingWriter{})
+	_ = s.auditLogger.LogRequest("", "p", "c", "ls")
+	if status, detail := s.auditHealthCheck(context.Background()); status != HealthDegraded || !strings.Contains(detail, "1 write failure(s), latest: write audit event: disk full") {
+		t.Errorf("after a failed write: %s, %q", status, detail)
+	}
+}
+
+// failingWriter fails every write.
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) { return 0, errors.New("disk full") }
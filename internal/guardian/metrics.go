@@ -0,0 +1,226 @@
+package guardian
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/xdg/cloister/internal/audit"
+	"github.com/xdg/cloister/internal/executor"
+	"github.com/xdg/cloister/internal/guardian/request"
+	"github.com/xdg/cloister/internal/metrics"
+	"github.com/xdg/cloister/internal/token"
+)
+
+// Label value limits. Projects and cloisters come from registered tokens
+// and grow slowly; domains are only labelled once allowed by policy or a
+// person, and are kept to the first maxDomainLabels seen, the rest reported
+// as "other".
+const (
+	maxProjectLabels  = 200
+	maxCloisterLabels = 500
+	maxDomainLabels   = 200
+)
+
+// maxPendingLatency is the most requests awaiting a decision that Metrics
+// remembers the request time of.
+const maxPendingLatency = 10000
+
+// Reload kinds, as reported in metrics.
+const (
+	reloadPolicy = "policy"
+	reloadTokens = "tokens"
+)
+
+// Metrics holds the guardian's Prometheus metrics, served at /metrics on
+// the API port. It implements audit.Observer, to measure how long requests
+// wait for a decision, and request.Metrics. Methods on a nil *Metrics do
+// nothing.
+type Metrics struct {
+	registry *metrics.Registry
+
+	projects  *metrics.Limiter
+	cloisters *metrics.Limiter
+	domains   *metrics.Limiter
+
+	proxyConnections *metrics.CounterVec
+	tunnelBytes      *metrics.CounterVec
+	tunnelDuration   *metrics.HistogramVec
+	queueDepth       *metrics.GaugeVec
+	decisionLatency  *metrics.HistogramVec
+	hostexecRequests *metrics.CounterVec
+	executorDuration *metrics.HistogramVec
+	activeTokens     *metrics.GaugeVec
+	reloads          *metrics.CounterVec
+	reloadErrors     *metrics.CounterVec
+
+	mu        sync.Mutex
+	requested map[string]time.Time // Request times by request ID, until decided
+}
+
+// NewMetrics creates the guardian's metrics.
+func NewMetrics() *Metrics {
+	r := metrics.NewRegistry()
+	return &Metrics{
+		registry:  r,
+		projects:  metrics.NewLimiter(maxProjectLabels),
+		cloisters: metrics.NewLimiter(maxCloisterLabels),
+		domains:   metrics.NewLimiter(maxDomainLabels),
+
+		proxyConnections: r.Counter("cloister_proxy_connections_total",
+			"Proxy requests by policy decision and the tier that made it.",
+			"project", "domain", "decision", "tier"),
+		tunnelBytes: r.Counter("cloister_proxy_tunnel_bytes_total",
+			"Bytes carried by CONNECT tunnels, by direction (sent upstream or received).",
+			"project", "cloister", "direction"),
+		tunnelDuration: r.Histogram("cloister_proxy_tunnel_duration_seconds",
+			"How long CONNECT tunnels stayed open.",
+			[]float64{.1, 1, 10, 60, 300, 900, 3600}, "project", "cloister"),
+		queueDepth: r.Gauge("cloister_approval_queue_depth",
+			"Requests waiting for approval.", "type"),
+		decisionLatency: r.Histogram("cloister_approval_decision_seconds",
+			"Time from a request being queued for approval to its decision or timeout.",
+			metrics.DefaultBuckets, "type", "outcome"),
+		hostexecRequests: r.Counter("cloister_hostexec_requests_total",
+			"Hostexec requests by action (\"command\" for plain commands, \"unknown\" for unconfigured actions) and outcome.",
+			"project", "cloister", "action", "outcome"),
+		executorDuration: r.Histogram("cloister_executor_duration_seconds",
+			"Executor round-trip time for hostexec commands, including queueing and the run.",
+			metrics.DefaultBuckets, "status"),
+		activeTokens: r.Gauge("cloister_active_tokens",
+			"Registered cloister tokens."),
+		reloads: r.Counter("cloister_reloads_total",
+			"Configuration and token reloads on SIGHUP.", "kind"),
+		reloadErrors: r.Counter("cloister_reload_errors_total",
+			"Failed configuration and token reloads.", "kind"),
+
+		requested: make(map[string]time.Time),
+	}
+}
+
+// Handler returns the handler serving the metrics in the Prometheus text
+// format.
+func (m *Metrics) Handler() http.Handler {
+	return m.registry
+}
+
+// WatchQueue reports the length of an approval queue as the queue depth for
+// requests of the given type (audit.DecisionHostexec or audit.DecisionDomain).
+func (m *Metrics) WatchQueue(typ string, length func() int) {
+	if m == nil {
+		return
+	}
+	m.queueDepth.SetFunc(func() float64 { return float64(length()) }, typ)
+}
+
+// WatchTokens reports count as the number of active tokens.
+func (m *Metrics) WatchTokens(count func() int) {
+	if m == nil {
+		return
+	}
+	m.activeTokens.SetFunc(func() float64 { return float64(count()) })
+}
+
+// HostexecRequest counts a hostexec request with its final outcome.
+func (m *Metrics) HostexecRequest(info token.Info, action, outcome string) {
+	if m == nil {
+		return
+	}
+	m.hostexecRequests.Inc(m.projects.Value(info.ProjectName), m.cloisters.Value(info.CloisterName), action, outcome)
+}
+
+// Observe measures the time from each hostexec or domain request to its
+// decision, for requests whose events carry a request ID. Automatic
+// decisions are not measured.
+func (m *Metrics) Observe(e *audit.Event) {
+	if m == nil || e.RequestID == "" {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	switch e.Type {
+	case audit.EventRequest, audit.EventDomainRequest:
+		if len(m.requested) < maxPendingLatency {
+			m.requested[e.RequestID] = e.Timestamp
+		}
+		return
+	case audit.EventAutoApprove:
+		delete(m.requested, e.RequestID)
+		return
+	case audit.EventApprove, audit.EventDeny, audit.EventTimeout,
+		audit.EventDomainApprove, audit.EventDomainDeny, audit.EventDomainTimeout:
+	default:
+		return
+	}
+
+	requested, ok := m.requested[e.RequestID]
+	if !ok {
+		return
+	}
+	delete(m.requested, e.RequestID)
+	if e.User == "" && (e.Type == audit.EventDeny || e.Type == audit.EventDomainDeny) {
+		return // Denied by a pattern or the guardian, not a person
+	}
+	typ := audit.DecisionHostexec
+	if e.Category() == audit.CategoryDomain {
+		typ = audit.DecisionDomain
+	}
+	m.decisionLatency.Observe(e.Timestamp.Sub(requested).Seconds(), typ, e.Outcome())
+}
+
+// proxyConnection counts a proxy request and how access was decided.
+// Denied and rejected domains are reported as "other": a cloister can ask
+// for any number of them, and they must not use up the domain labels.
+func (m *Metrics) proxyConnection(r resolvedRequest, domain string, access accessResult) {
+	if m == nil {
+		return
+	}
+	label := metrics.Other
+	if access.decision == accessAllowed || access.decision == accessApproved {
+		label = m.domains.Value(domain)
+	}
+	m.proxyConnections.Inc(m.projects.Value(r.ProjectName), label, access.decision, access.tier)
+}
+
+// tunnel records a closed CONNECT tunnel.
+func (m *Metrics) tunnel(r resolvedRequest, sent, received int64, d time.Duration) {
+	if m == nil {
+		return
+	}
+	project, cloister := m.projects.Value(r.ProjectName), m.cloisters.Value(r.CloisterName)
+	m.tunnelBytes.Add(float64(sent), project, cloister, "sent")
+	m.tunnelBytes.Add(float64(received), project, cloister, "received")
+	m.tunnelDuration.Observe(d.Seconds(), project, cloister)
+}
+
+// reload counts a reload and whether it failed.
+func (m *Metrics) reload(kind string, err error) {
+	if m == nil {
+		return
+	}
+	m.reloads.Inc(kind)
+	if err != nil {
+		m.reloadErrors.Inc(kind)
+	}
+}
+
+// instrumentedExecutor measures executor round trips.
+type instrumentedExecutor struct {
+	next    request.CommandExecutor
+	metrics *Metrics
+}
+
+// Execute runs req through the wrapped executor client and records how
+// long it took, by the executor's response status or "error".
+func (e *instrumentedExecutor) Execute(ctx context.Context, req executor.ExecuteRequest, onProgress func(executor.Progress)) (*executor.ExecuteResponse, error) {
+	start := time.Now()
+	resp, err := e.next.Execute(ctx, req, onProgress)
+	status := "error"
+	if err == nil {
+		status = resp.Status
+	}
+	e.metrics.executorDuration.Observe(time.Since(start).Seconds(), status)
+	return resp, err //nolint:wrapcheck // Pass the executor client's error through unchanged.
+}
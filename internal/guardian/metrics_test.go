@@ -0,0 +1,175 @@
+package guardian
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/xdg/cloister/internal/audit"
+	"github.com/xdg/cloister/internal/executor"
+	"github.com/xdg/cloister/internal/token"
+)
+
+// metricsText returns the metrics as served at /metrics.
+func metricsText(t *testing.T, m *Metrics) string {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	m.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	return rec.Body.String()
+}
+
+func assertMetrics(t *testing.T, m *Metrics, want ...string) {
+	t.Helper()
+	out := metricsText(t, m)
+	for _, w := range want {
+		if !strings.Contains(out, w+"\n") {
+			t.Errorf("metrics missing %q:\n%s", w, out)
+		}
+	}
+}
+
+func TestMetrics_DecisionLatency(t *testing.T) {
+	m := NewMetrics()
+	start := time.Date(2024, 1, 15, 14, 0, 0, 0, time.UTC)
+	for _, e := range []*audit.Event{
+		{RequestID: "r1", Timestamp: start, Type: audit.EventRequest},
+		{RequestID: "r1", Timestamp: start.Add(2 * time.Second), Type: audit.EventApprove, User: "alice"},
+		{RequestID: "r2", Timestamp: start, Type: audit.EventDomainRequest},
+		{RequestID: "r2", Timestamp: start.Add(time.Minute), Type: audit.EventDomainTimeout},
+		// Denied by a pattern, not a person: not measured.
+		{RequestID: "r3", Timestamp: start, Type: audit.EventRequest},
+		{RequestID: "r3", Timestamp: start, Type: audit.EventDeny},
+		// Automatic decisions are not measured.
+		{RequestID: "r4", Timestamp: start, Type: audit.EventRequest},
+		{RequestID: "r4", Timestamp: start, Type: audit.EventAutoApprove},
+	} {
+		m.Observe(e)
+	}
+
+	assertMetrics(t, m,
+		`cloister_approval_decision_seconds_sum{type="hostexec",outcome="approved"} 2`,
+		`cloister_approval_decision_seconds_count{type="hostexec",outcome="approved"} 1`,
+		`cloister_approval_decision_seconds_count{type="domain",outcome="timeout"} 1`,
+	)
+	if out := metricsText(t, m); strings.Contains(out, `outcome="denied"`) || strings.Contains(out, `outcome="auto_approved"`) {
+		t.Errorf("automatic decisions measured:\n%s", out)
+	}
+	if len(m.requested) != 0 {
+		t.Errorf("%d requests still pending", len(m.requested))
+	}
+}
+
+func TestMetrics_ProxyConnections(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte("hello"))
+	}))
+	defer upstream.Close()
+	upstreamURL, _ := url.Parse(upstream.URL)
+
+	m := NewMetrics()
+	p := NewProxyServer(":0")
+	p.PolicyEngine = newTestProxyPolicyEngine([]string{upstreamURL.Hostname()}, []string{"blocked.example"})
+	p.TokenValidator = newMockTokenValidator("test-token")
+	p.TokenLookup = func(string) (TokenLookupResult, bool) {
+		return TokenLookupResult{ProjectName: "api", CloisterName: "api-main"}, true
+	}
+	p.Metrics = m
+
+	if err := p.Start(); err != nil {
+		t.Fatalf("failed to start proxy server: %v", err)
+	}
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = p.Stop(ctx)
+	}()
+
+	if status, _, err := sendRawHTTPViaProxy(t, p.ListenAddr(), "GET", upstream.URL+"/", "test-token"); err != nil || status != http.StatusOK {
+		t.Fatalf("allowed request = %d, %v; want 200", status, err)
+	}
+	if status, _, err := sendRawHTTPViaProxy(t, p.ListenAddr(), "GET", "http://blocked.example/", "test-token"); err != nil || status != http.StatusForbidden {
+		t.Fatalf("denied request = %d, %v; want 403", status, err)
+	}
+
+	assertMetrics(t, m,
+		`cloister_proxy_connections_total{project="api",domain="`+upstreamURL.Hostname()+`",decision="allowed",tier="global"} 1`,
+		`cloister_proxy_connections_total{project="api",domain="other",decision="denied",tier="global"} 1`,
+	)
+}
+
+func TestMetrics_DeniedDomainsNotLabelled(t *testing.T) {
+	m := NewMetrics()
+	denied := accessResult{decision: accessDenied, tier: "global"}
+	for i := range maxDomainLabels + 1 {
+		m.proxyConnection(resolvedRequest{}, fmt.Sprintf("d%d.example", i), denied)
+	}
+	m.proxyConnection(resolvedRequest{}, "allowed.example", accessResult{decision: accessAllowed, tier: "global"})
+
+	assertMetrics(t, m,
+		`cloister_proxy_connections_total{project="",domain="other",decision="denied",tier="global"} 201`,
+		`cloister_proxy_connections_total{project="",domain="allowed.example",decision="allowed",tier="global"} 1`,
+	)
+}
+
+func TestMetrics_HostexecAndQueues(t *testing.T) {
+	m := NewMetrics()
+	depth := 3
+	m.WatchQueue(audit.DecisionHostexec, func() int { return depth })
+	m.WatchTokens(func() int { return 2 })
+	m.HostexecRequest(token.Info{ProjectName: "api", CloisterName: "api-main"}, "command", "denied")
+	m.reload(reloadPolicy, nil)
+	m.reload(reloadTokens, errors.New("boom"))
+	depth = 5
+
+	assertMetrics(t, m,
+		`cloister_approval_queue_depth{type="hostexec"} 5`,
+		`cloister_active_tokens 2`,
+		`cloister_hostexec_requests_total{project="api",cloister="api-main",action="command",outcome="denied"} 1`,
+		`cloister_reloads_total{kind="policy"} 1`,
+		`cloister_reloads_total{kind="tokens"} 1`,
+		`cloister_reload_errors_total{kind="tokens"} 1`,
+	)
+}
+
+func TestMetrics_NilIsNoop(t *testing.T) {
+	var m *Metrics
+	m.Observe(&audit.Event{RequestID: "r1", Type: audit.EventRequest})
+	m.HostexecRequest(token.Info{}, "command", "denied")
+	m.WatchTokens(func() int { return 1 })
+	m.proxyConnection(resolvedRequest{}, "example.com", accessResult{decision: accessAllowed})
+	m.tunnel(resolvedRequest{}, 1, 1, time.Second)
+	m.reload(reloadPolicy, nil)
+}
+
+func TestInstrumentedExecutor(t *testing.T) {
+	m := NewMetrics()
+	e := &instrumentedExecutor{next: &fakeExecutor{}, metrics: m}
+
+	if _, err := e.Execute(context.Background(), executor.ExecuteRequest{Command: "ok"}, nil); err != nil {
+		t.Fatalf("Execute() returned error: %v", err)
+	}
+	if _, err := e.Execute(context.Background(), executor.ExecuteRequest{Command: "fail"}, nil); err == nil {
+		t.Fatal("Execute() did not pass the error through")
+	}
+
+	assertMetrics(t, m,
+		`cloister_executor_duration_seconds_count{status="`+executor.StatusCompleted+`"} 1`,
+		`cloister_executor_duration_seconds_count{status="error"} 1`,
+	)
+}
+
+// fakeExecutor fails requests for the command "fail" and completes the rest.
+type fakeExecutor struct{}
+
+func (fakeExecutor) Execute(_ context.Context, req executor.ExecuteRequest, _ func(executor.Progress)) (*executor.ExecuteResponse, error) {
+	if req.Command == "fail" {
+		return nil, errors.New("executor unavailable")
+	}
+	return &executor.ExecuteResponse{Status: executor.StatusCompleted}, nil
+}
@@ -0,0 +1,106 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/xdg/cloister/internal/executor"
+	"github.com/xdg/cloister/internal/guardian/approval"
+)
+
+// NotificationEnvVar holds the notification as JSON in a command hook's
+// environment.
+const NotificationEnvVar = "CLOISTER_NOTIFICATION"
+
+// commandMaxOutputBytes caps captured hook output; it is only used in errors.
+const commandMaxOutputBytes = 4096
+
+// placeholderRe matches {{name}} references in command arguments.
+var placeholderRe = regexp.MustCompile(`\{\{([^{}]*)\}\}`)
+
+// Executor runs commands on the host. It is satisfied by the guardian's
+// executor client.
+type Executor interface {
+	Execute(ctx context.Context, req executor.ExecuteRequest, onProgress func(executor.Progress)) (*executor.ExecuteResponse, error)
+}
+
+// Command runs a host command for each notification via the executor.
+//
+// Arguments may contain {{event}}, {{type}}, {{id}}, {{cloister}},
+// {{project}}, {{cmd}}, {{domain}}, and {{summary}} placeholders. The full
+// notification is also passed as JSON in NotificationEnvVar. No shell is
+// involved, so substituted values cannot inject extra arguments.
+type Command struct {
+	Argv    []string
+	Exec    Executor
+	Timeout time.Duration // Passed to the executor; zero means none
+}
+
+// NewCommand creates a command hook sink.
+func NewCommand(argv []string, exec Executor, timeout time.Duration) *Command {
+	return &Command{Argv: argv, Exec: exec, Timeout: timeout}
+}
+
+// Send runs the command. A non-zero exit or executor failure is an error.
+func (c *Command) Send(ctx context.Context, n approval.Notification) error {
+	payload, err := json.Marshal(n)
+	if err != nil {
+		return fmt.Errorf("failed to marshal notification: %w", err)
+	}
+
+	values := placeholderValues(n)
+	args := make([]string, len(c.Argv)-1)
+	for i, a := range c.Argv[1:] {
+		args[i] = placeholderRe.ReplaceAllStringFunc(a, func(m string) string {
+			return values[m[2:len(m)-2]]
+		})
+	}
+
+	resp, err := c.Exec.Execute(ctx, executor.ExecuteRequest{
+		Command:        c.Argv[0],
+		Args:           args,
+		Env:            map[string]string{NotificationEnvVar: string(payload)},
+		TimeoutMs:      int(c.Timeout.Milliseconds()),
+		MaxOutputBytes: commandMaxOutputBytes,
+	}, nil)
+	if err != nil {
+		return fmt.Errorf("failed to run %s: %w", c.Argv[0], err)
+	}
+	if resp.Status != executor.StatusCompleted {
+		return fmt.Errorf("%s: %s %s", c.Argv[0], resp.Status, resp.Error)
+	}
+	if resp.ExitCode != 0 {
+		return fmt.Errorf("%s exited with code %d: %s", c.Argv[0], resp.ExitCode, resp.Stderr)
+	}
+	return nil
+}
+
+// placeholderValues returns the values available to command arguments.
+func placeholderValues(n approval.Notification) map[string]string {
+	return map[string]string{
+		"event":    string(n.Event),
+		"type":     n.Type,
+		"id":       n.ID,
+		"cloister": n.Cloister,
+		"project":  n.Project,
+		"cmd":      n.Cmd,
+		"domain":   n.Domain,
+		"summary":  Summary(n),
+	}
+}
+
+// Summary returns a one-line human-readable description of a notification,
+// suitable for desktop notifications and chat messages.
+func Summary(n approval.Notification) string {
+	what := "run: " + n.Cmd
+	if n.Type == approval.RequestTypeDomain {
+		what = "reach " + n.Domain
+	}
+	if n.Event == approval.NotifyTimeout {
+		return fmt.Sprintf("%s request timed out (%s)", n.Cloister, what)
+	}
+	return fmt.Sprintf("%s wants to %s", n.Cloister, what)
+}
@@ -0,0 +1,99 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/xdg/cloister/internal/executor"
+	"github.com/xdg/cloister/internal/guardian/approval"
+)
+
+// fakeExecutor records the last request and returns a canned response.
+type fakeExecutor struct {
+	req  executor.ExecuteRequest
+	resp executor.ExecuteResponse
+	err  error
+}
+
+func (f *fakeExecutor) Execute(_ context.Context, req executor.ExecuteRequest, _ func(executor.Progress)) (*executor.ExecuteResponse, error) {
+	f.req = req
+	if f.err != nil {
+		return nil, f.err
+	}
+	return &f.resp, nil
+}
+
+func TestCommand_SubstitutesPlaceholders(t *testing.T) {
+	exec := &fakeExecutor{resp: executor.ExecuteResponse{Status: executor.StatusCompleted}}
+	cmd := NewCommand([]string{"notify-send", "cloister {{event}}", "{{summary}}", "{{unknown}}"}, exec, 5*time.Second)
+	n := approval.Notification{
+		Event: approval.NotifyPending, Type: approval.RequestTypeDomain,
+		ID: "d1", Cloister: "api-main", Project: "api", Domain: "example.com",
+	}
+
+	if err := cmd.Send(context.Background(), n); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+
+	if exec.req.Command != "notify-send" {
+		t.Errorf("Command = %q, want notify-send", exec.req.Command)
+	}
+	wantArgs := []string{"cloister pending", "api-main wants to reach example.com", ""}
+	if !slices.Equal(exec.req.Args, wantArgs) {
+		t.Errorf("Args = %q, want %q", exec.req.Args, wantArgs)
+	}
+	if exec.req.TimeoutMs != 5000 {
+		t.Errorf("TimeoutMs = %d, want 5000", exec.req.TimeoutMs)
+	}
+	var decoded approval.Notification
+	if err := json.Unmarshal([]byte(exec.req.Env[NotificationEnvVar]), &decoded); err != nil || decoded.ID != "d1" {
+		t.Errorf("%s = %q, want notification JSON", NotificationEnvVar, exec.req.Env[NotificationEnvVar])
+	}
+}
+
+func TestCommand_Failures(t *testing.T) {
+	n := approval.Notification{Event: approval.NotifyTimeout, Type: approval.RequestTypeHostexec, Cmd: "ls"}
+	tests := []struct {
+		name    string
+		exec    *fakeExecutor
+		wantErr string
+	}{
+		{"executor error", &fakeExecutor{err: errors.New("connection refused")}, "connection refused"},
+		{"nonzero exit", &fakeExecutor{resp: executor.ExecuteResponse{Status: executor.StatusCompleted, ExitCode: 2, Stderr: "boom"}}, "exited with code 2: boom"},
+		{"timeout", &fakeExecutor{resp: executor.ExecuteResponse{Status: executor.StatusTimeout}}, "timeout"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := NewCommand([]string{"hook"}, tt.exec, 0).Send(context.Background(), n)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Send() error = %v, want to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSummary(t *testing.T) {
+	tests := []struct {
+		n    approval.Notification
+		want string
+	}{
+		{
+			approval.Notification{Event: approval.NotifyPending, Type: approval.RequestTypeHostexec, Cloister: "c", Cmd: "make"},
+			"c wants to run: make",
+		},
+		{
+			approval.Notification{Event: approval.NotifyTimeout, Type: approval.RequestTypeDomain, Cloister: "c", Domain: "x.io"},
+			"c request timed out (reach x.io)",
+		},
+	}
+	for _, tt := range tests {
+		if got := Summary(tt.n); got != tt.want {
+			t.Errorf("Summary() = %q, want %q", got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,146 @@
+// Package notify relays approval request notifications to external sinks:
+// HTTP webhooks and host-side commands run through the executor.
+//
+// A Dispatcher implements approval.Notifier. Each notification is matched
+// against every sink's filter and delivered asynchronously, so a slow or
+// unreachable sink never delays the approval queue.
+package notify
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/xdg/cloister/internal/clog"
+	"github.com/xdg/cloister/internal/config"
+	"github.com/xdg/cloister/internal/guardian/approval"
+)
+
+// DefaultTimeout bounds a single delivery when the sink sets no timeout.
+const DefaultTimeout = 10 * time.Second
+
+// Sink delivers a notification to one destination.
+type Sink interface {
+	Send(ctx context.Context, n approval.Notification) error
+}
+
+// Filter restricts which notifications reach a sink. Empty fields match
+// everything.
+type Filter struct {
+	Projects []string
+	Types    []string
+	Events   []string
+}
+
+// Matches reports whether the notification passes the filter.
+func (f Filter) Matches(n approval.Notification) bool {
+	return matchAny(f.Projects, n.Project) &&
+		matchAny(f.Types, n.Type) &&
+		matchAny(f.Events, string(n.Event))
+}
+
+// matchAny reports whether allowed is empty or contains v.
+func matchAny(allowed []string, v string) bool {
+	return len(allowed) == 0 || slices.Contains(allowed, v)
+}
+
+// route is a sink with its filter and delivery settings.
+type route struct {
+	name    string // For log messages
+	sink    Sink
+	filter  Filter
+	timeout time.Duration
+}
+
+// Dispatcher fans notifications out to matching sinks.
+// It is safe for concurrent use.
+type Dispatcher struct {
+	routes []route
+	wg     sync.WaitGroup
+}
+
+// NewDispatcher creates an empty dispatcher. Use Add to register sinks.
+func NewDispatcher() *Dispatcher {
+	return &Dispatcher{}
+}
+
+// New creates a dispatcher for the sinks in cfg. Command sinks run through
+// exec; if exec is nil they are skipped with a warning.
+func New(cfg config.NotifyConfig, exec Executor) (*Dispatcher, error) {
+	d := NewDispatcher()
+	for i, w := range cfg.Webhooks {
+		timeout, err := parseTimeout(w.Timeout)
+		if err != nil {
+			return nil, fmt.Errorf("notify.webhooks[%d]: %w", i, err)
+		}
+		d.Add("webhook "+w.URL, NewWebhook(w.URL, w.Secret), newFilter(w.NotifyFilter), timeout)
+	}
+	for i, c := range cfg.Commands {
+		timeout, err := parseTimeout(c.Timeout)
+		if err != nil {
+			return nil, fmt.Errorf("notify.commands[%d]: %w", i, err)
+		}
+		if exec == nil {
+			clog.Warn("notify.commands[%d]: command execution disabled, skipping %q", i, c.Command[0])
+			continue
+		}
+		d.Add("command "+c.Command[0], NewCommand(c.Command, exec, timeout), newFilter(c.NotifyFilter), timeout)
+	}
+	return d, nil
+}
+
+// Add registers a sink. A zero timeout uses DefaultTimeout.
+func (d *Dispatcher) Add(name string, sink Sink, filter Filter, timeout time.Duration) {
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	d.routes = append(d.routes, route{name: name, sink: sink, filter: filter, timeout: timeout})
+}
+
+// Len returns the number of registered sinks.
+func (d *Dispatcher) Len() int {
+	return len(d.routes)
+}
+
+// Notify delivers n to every matching sink in the background. Delivery
+// failures are logged, not retried.
+func (d *Dispatcher) Notify(n approval.Notification) {
+	for _, r := range d.routes {
+		if !r.filter.Matches(n) {
+			continue
+		}
+		d.wg.Add(1)
+		go func() {
+			defer d.wg.Done()
+			ctx, cancel := context.WithTimeout(context.Background(), r.timeout)
+			defer cancel()
+			if err := r.sink.Send(ctx, n); err != nil {
+				clog.Warn("notification %s for request %s via %s failed: %v", n.Event, n.ID, r.name, err)
+			}
+		}()
+	}
+}
+
+// Wait blocks until all in-flight deliveries finish.
+func (d *Dispatcher) Wait() {
+	d.wg.Wait()
+}
+
+// newFilter converts a config filter.
+func newFilter(f config.NotifyFilter) Filter {
+	return Filter{Projects: f.Projects, Types: f.Types, Events: f.Events}
+}
+
+// parseTimeout parses an optional duration string.
+func parseTimeout(s string) (time.Duration, error) {
+	if s == "" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid timeout %q: %w", s, err)
+	}
+	return d, nil
+}
@@ -0,0 +1,95 @@
+package notify
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/xdg/cloister/internal/config"
+	"github.com/xdg/cloister/internal/guardian/approval"
+)
+
+// fakeSink records delivered notifications.
+type fakeSink struct {
+	mu   sync.Mutex
+	sent []approval.Notification
+}
+
+func (f *fakeSink) Send(_ context.Context, n approval.Notification) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.sent = append(f.sent, n)
+	return nil
+}
+
+func TestFilter_Matches(t *testing.T) {
+	n := approval.Notification{Event: approval.NotifyPending, Type: approval.RequestTypeDomain, Project: "api"}
+	tests := []struct {
+		name   string
+		filter Filter
+		want   bool
+	}{
+		{"empty matches all", Filter{}, true},
+		{"project match", Filter{Projects: []string{"web", "api"}}, true},
+		{"project mismatch", Filter{Projects: []string{"web"}}, false},
+		{"type match", Filter{Types: []string{"domain"}}, true},
+		{"type mismatch", Filter{Types: []string{"hostexec"}}, false},
+		{"event mismatch", Filter{Events: []string{"timeout"}}, false},
+		{"all fields match", Filter{Projects: []string{"api"}, Types: []string{"domain"}, Events: []string{"pending"}}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.filter.Matches(n); got != tt.want {
+				t.Errorf("Matches() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDispatcher_RoutesByFilter(t *testing.T) {
+	all, domainsOnly := &fakeSink{}, &fakeSink{}
+	d := NewDispatcher()
+	d.Add("all", all, Filter{}, 0)
+	d.Add("domains", domainsOnly, Filter{Types: []string{"domain"}}, 0)
+
+	d.Notify(approval.Notification{Event: approval.NotifyPending, Type: approval.RequestTypeHostexec, ID: "h1"})
+	d.Notify(approval.Notification{Event: approval.NotifyPending, Type: approval.RequestTypeDomain, ID: "d1"})
+	d.Wait()
+
+	if len(all.sent) != 2 {
+		t.Errorf("unfiltered sink got %d notifications, want 2", len(all.sent))
+	}
+	if len(domainsOnly.sent) != 1 || domainsOnly.sent[0].ID != "d1" {
+		t.Errorf("domain sink got %+v, want only d1", domainsOnly.sent)
+	}
+}
+
+func TestNew_SkipsCommandsWithoutExecutor(t *testing.T) {
+	cfg := config.NotifyConfig{
+		Webhooks: []config.WebhookNotifier{{URL: "http://localhost:1/hook"}},
+		Commands: []config.CommandNotifier{{Command: []string{"notify-send"}}},
+	}
+
+	d, err := New(cfg, nil)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if d.Len() != 1 {
+		t.Errorf("Len() = %d, want 1 (webhook only)", d.Len())
+	}
+
+	d, err = New(cfg, &fakeExecutor{})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if d.Len() != 2 {
+		t.Errorf("Len() = %d, want 2", d.Len())
+	}
+}
+
+func TestNew_InvalidTimeout(t *testing.T) {
+	cfg := config.NotifyConfig{Webhooks: []config.WebhookNotifier{{URL: "http://x", Timeout: "soon"}}}
+	if _, err := New(cfg, nil); err == nil {
+		t.Error("New() expected error for invalid timeout")
+	}
+}
@@ -0,0 +1,83 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/xdg/cloister/internal/guardian/approval"
+)
+
+// Webhook headers.
+const (
+	// EventHeader carries the notification event ("pending" or "timeout").
+	EventHeader = "X-Cloister-Event"
+	// SignatureHeader carries "sha256=" followed by the hex HMAC-SHA256 of
+	// the request body, keyed with the webhook secret.
+	SignatureHeader = "X-Cloister-Signature"
+)
+
+// Webhook posts notifications as JSON to an HTTP endpoint.
+type Webhook struct {
+	URL    string
+	Secret string // If set, requests carry SignatureHeader
+
+	// Client sends the requests. If nil, http.DefaultClient is used; the
+	// dispatcher's context bounds each delivery.
+	Client *http.Client
+}
+
+// NewWebhook creates a webhook sink.
+func NewWebhook(url, secret string) *Webhook {
+	return &Webhook{URL: url, Secret: secret}
+}
+
+// Send posts the notification. Any non-2xx response is an error.
+func (w *Webhook) Send(ctx context.Context, n approval.Notification) error {
+	body, err := json.Marshal(n)
+	if err != nil {
+		return fmt.Errorf("failed to marshal notification: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.URL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "cloister-guardian")
+	req.Header.Set(EventHeader, string(n.Event))
+	if w.Secret != "" {
+		req.Header.Set(SignatureHeader, Sign(w.Secret, body))
+	}
+
+	client := w.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("webhook request failed: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook returned %s", resp.Status)
+	}
+	return nil
+}
+
+// Sign returns the SignatureHeader value for body: "sha256=" followed by the
+// hex-encoded HMAC-SHA256 of body keyed with secret. Receivers should
+// recompute it and compare with hmac.Equal.
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
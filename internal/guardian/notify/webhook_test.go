@@ -0,0 +1,94 @@
+package notify
+
+import (
+	"context"
+	"crypto/hmac"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/xdg/cloister/internal/guardian/approval"
+)
+
+// capturedRequest is what the stand-in webhook receiver saw.
+type capturedRequest struct {
+	header http.Header
+	body   []byte
+}
+
+// startReceiver starts a local webhook receiver that replies with status.
+func startReceiver(t *testing.T, status int) (*httptest.Server, <-chan capturedRequest) {
+	t.Helper()
+	got := make(chan capturedRequest, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		got <- capturedRequest{header: r.Header.Clone(), body: body}
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, got
+}
+
+func TestWebhook_SendsSignedJSON(t *testing.T) {
+	srv, got := startReceiver(t, http.StatusNoContent)
+	n := approval.Notification{
+		Event: approval.NotifyPending, Type: approval.RequestTypeHostexec,
+		ID: "abc", Cloister: "api-main", Project: "api", Cmd: "make deploy",
+		Timestamp: time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC),
+	}
+
+	if err := NewWebhook(srv.URL, "hook-secret").Send(context.Background(), n); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	req := <-got
+
+	var decoded approval.Notification
+	if err := json.Unmarshal(req.body, &decoded); err != nil {
+		t.Fatalf("body is not JSON: %v", err)
+	}
+	if decoded.ID != "abc" || decoded.Cmd != "make deploy" || decoded.Event != approval.NotifyPending {
+		t.Errorf("decoded body = %+v", decoded)
+	}
+	if ct := req.header.Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Content-Type = %q", ct)
+	}
+	if ev := req.header.Get(EventHeader); ev != "pending" {
+		t.Errorf("%s = %q, want pending", EventHeader, ev)
+	}
+	sig := req.header.Get(SignatureHeader)
+	if !hmac.Equal([]byte(sig), []byte(Sign("hook-secret", req.body))) {
+		t.Errorf("%s = %q does not verify", SignatureHeader, sig)
+	}
+}
+
+func TestWebhook_UnsignedWithoutSecret(t *testing.T) {
+	srv, got := startReceiver(t, http.StatusOK)
+
+	if err := NewWebhook(srv.URL, "").Send(context.Background(), approval.Notification{ID: "x"}); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	if sig := (<-got).header.Get(SignatureHeader); sig != "" {
+		t.Errorf("%s = %q, want none", SignatureHeader, sig)
+	}
+}
+
+func TestWebhook_ErrorStatus(t *testing.T) {
+	srv, _ := startReceiver(t, http.StatusInternalServerError)
+
+	err := NewWebhook(srv.URL, "").Send(context.Background(), approval.Notification{ID: "x"})
+	if err == nil || !strings.Contains(err.Error(), "500") {
+		t.Errorf("Send() error = %v, want 500 status error", err)
+	}
+}
+
+func TestSign(t *testing.T) {
+	// Known-answer value from: printf '{}' | openssl dgst -sha256 -hmac key
+	want := "sha256=a777724d943eb48dc69bca8a4a6d57a04db3f9ec7e1de4e581e860265bdf3032"
+	if got := Sign("key", []byte("{}")); got != want {
+		t.Errorf("Sign() = %q, want %q", got, want)
+	}
+}
@@ -1,6 +1,8 @@
 // Package patterns provides command pattern matching for hostexec auto-approval.
 package patterns
 
+import "time"
+
 // Action represents the result of pattern matching against a command.
 type Action int
 
@@ -27,10 +29,23 @@ func (a Action) String() string {
 	}
 }
 
+// Limits holds per-pattern constraints applied when a command matching the
+// pattern is approved and run on the host. The zero value means no limits.
+type Limits struct {
+	Timeout          time.Duration     // Kill the command after this long (0 = no timeout)
+	MaxOutputBytes   int64             // Cap on each of stdout/stderr (0 = unlimited)
+	EnvAllow         []string          // Inherited variable names to keep (globs; empty = all)
+	EnvDeny          []string          // Inherited variable names to drop (globs)
+	Env              map[string]string // Extra variables to inject
+	RequireApprovals int               // Distinct approvers a manual approval needs (0 = one)
+	ApprovalTimeout  time.Duration     // How long a manual approval waits (0 = default, <0 = until decided)
+}
+
 // MatchResult contains the outcome of matching a command against patterns.
 type MatchResult struct {
 	Action  Action // The action to take (AutoApprove, ManualApprove, Deny)
 	Pattern string // The pattern that matched (empty if Deny)
+	Limits  Limits // Execution limits for the matched pattern
 }
 
 // Matcher defines the interface for command pattern matching.
@@ -10,6 +10,7 @@ import (
 type compiledPattern struct {
 	regex   *regexp.Regexp
 	pattern string
+	limits  Limits
 }
 
 // RegexMatcher implements Matcher using compiled regular expressions.
@@ -23,16 +24,24 @@ type RegexMatcher struct {
 // NewRegexMatcher creates a new RegexMatcher from the given pattern slices.
 // Invalid patterns are logged and skipped, not fatal.
 func NewRegexMatcher(autoApprove, manualApprove []string) *RegexMatcher {
+	return NewRegexMatcherWithLimits(autoApprove, manualApprove, nil, nil)
+}
+
+// NewRegexMatcherWithLimits creates a new RegexMatcher whose match results
+// carry execution limits. Each list has its own limits map, keyed by pattern
+// string, so the same pattern can have different limits in auto_approve and
+// manual_approve; patterns without an entry get zero Limits.
+func NewRegexMatcherWithLimits(autoApprove, manualApprove []string, autoLimits, manualLimits map[string]Limits) *RegexMatcher {
 	m := &RegexMatcher{
-		autoApprove:   compilePatterns(autoApprove, "auto_approve"),
-		manualApprove: compilePatterns(manualApprove, "manual_approve"),
+		autoApprove:   compilePatterns(autoApprove, "auto_approve", autoLimits),
+		manualApprove: compilePatterns(manualApprove, "manual_approve", manualLimits),
 	}
 	return m
 }
 
 // compilePatterns compiles a slice of regex pattern strings.
 // Invalid patterns are logged and skipped.
-func compilePatterns(patterns []string, category string) []compiledPattern {
+func compilePatterns(patterns []string, category string, limits map[string]Limits) []compiledPattern {
 	result := make([]compiledPattern, 0, len(patterns))
 	for _, p := range patterns {
 		re, err := regexp.Compile(p)
@@ -40,7 +49,7 @@ func compilePatterns(patterns []string, category string) []compiledPattern {
 			clog.Warn("invalid %s pattern %q: %v (skipped)", category, p, err)
 			continue
 		}
-		result = append(result, compiledPattern{regex: re, pattern: p})
+		result = append(result, compiledPattern{regex: re, pattern: p, limits: limits[p]})
 	}
 	return result
 }
@@ -55,6 +64,7 @@ func (m *RegexMatcher) Match(cmd string) MatchResult {
 			return MatchResult{
 				Action:  AutoApprove,
 				Pattern: cp.pattern,
+				Limits:  cp.limits,
 			}
 		}
 	}
@@ -65,6 +75,7 @@ func (m *RegexMatcher) Match(cmd string) MatchResult {
 			return MatchResult{
 				Action:  ManualApprove,
 				Pattern: cp.pattern,
+				Limits:  cp.limits,
 			}
 		}
 	}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/xdg/cloister/internal/clog"
 )
@@ -217,3 +218,66 @@ func TestRegexMatcherDockerComposeVariants(t *testing.T) {
 		}
 	}
 }
+
+// TestRegexMatcherWithLimits verifies that match results carry the limits
+// configured for the matched pattern.
+func TestRegexMatcherWithLimits(t *testing.T) {
+	autoLimits := map[string]Limits{
+		"^make test$": {Timeout: 30 * time.Second, MaxOutputBytes: 1024},
+	}
+	manualLimits := map[string]Limits{
+		"^make deploy$": {
+			EnvDeny: []string{"AWS_*"},
+			Env:     map[string]string{"CI": "1"},
+		},
+	}
+	m := NewRegexMatcherWithLimits([]string{"^make test$"}, []string{"^make deploy$"}, autoLimits, manualLimits)
+
+	auto := m.Match("make test")
+	if auto.Action != AutoApprove {
+		t.Fatalf("Action = %v, want AutoApprove", auto.Action)
+	}
+	if auto.Limits.Timeout != 30*time.Second {
+		t.Errorf("Limits.Timeout = %v, want 30s", auto.Limits.Timeout)
+	}
+	if auto.Limits.MaxOutputBytes != 1024 {
+		t.Errorf("Limits.MaxOutputBytes = %d, want 1024", auto.Limits.MaxOutputBytes)
+	}
+
+	manual := m.Match("make deploy")
+	if manual.Action != ManualApprove {
+		t.Fatalf("Action = %v, want ManualApprove", manual.Action)
+	}
+	if len(manual.Limits.EnvDeny) != 1 || manual.Limits.EnvDeny[0] != "AWS_*" {
+		t.Errorf("Limits.EnvDeny = %v, want [AWS_*]", manual.Limits.EnvDeny)
+	}
+	if manual.Limits.Env["CI"] != "1" {
+		t.Errorf("Limits.Env[CI] = %q, want %q", manual.Limits.Env["CI"], "1")
+	}
+}
+
+// TestRegexMatcherWithLimits_PerList verifies that a pattern appearing in
+// both lists takes its limits from the list that matched.
+func TestRegexMatcherWithLimits_PerList(t *testing.T) {
+	m := NewRegexMatcherWithLimits([]string{"^make$"}, []string{"^make$"},
+		nil, map[string]Limits{"^make$": {Timeout: time.Hour}})
+
+	got := m.Match("make")
+	if got.Action != AutoApprove {
+		t.Fatalf("Action = %v, want AutoApprove", got.Action)
+	}
+	if got.Limits.Timeout != 0 {
+		t.Errorf("Limits.Timeout = %v, want 0 (manual_approve limits must not apply)", got.Limits.Timeout)
+	}
+}
+
+// TestRegexMatcherWithoutLimits verifies patterns without configured limits
+// match with zero Limits.
+func TestRegexMatcherWithoutLimits(t *testing.T) {
+	m := NewRegexMatcher([]string{"^ls$"}, nil)
+
+	result := m.Match("ls")
+	if result.Limits.Timeout != 0 || result.Limits.MaxOutputBytes != 0 || result.Limits.Env != nil {
+		t.Errorf("Limits = %+v, want zero value", result.Limits)
+	}
+}
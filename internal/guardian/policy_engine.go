@@ -109,6 +109,12 @@ type PolicyChecker interface {
 	Check(token, project, domain string) Decision
 }
 
+// tierChecker is implemented by policy checkers that can also report which
+// policy tier decided, for metrics. Implemented by *PolicyEngine.
+type tierChecker interface {
+	CheckTier(token, project, domain string) (Decision, string)
+}
+
 // TokenRevoker clears session-level policy state for a revoked token.
 type TokenRevoker interface {
 	RevokeToken(token string)
@@ -220,36 +226,50 @@ func NewPolicyEngine(
 	return pe, nil
 }
 
+// Policy tiers, as reported by CheckTier.
+const (
+	TierGlobal  = "global"
+	TierProject = "project"
+	TierSession = "session"
+)
+
 // Check evaluates domain access across all policy tiers.
 // Evaluation order: deny pass (global -> project -> token), then allow pass
 // (global -> project -> token), then fallback to AskHuman.
 func (pe *PolicyEngine) Check(token, project, domain string) Decision {
+	decision, _ := pe.CheckTier(token, project, domain)
+	return decision
+}
+
+// CheckTier is Check, also returning the tier whose rule decided: TierGlobal,
+// TierProject or TierSession, or "" for AskHuman.
+func (pe *PolicyEngine) CheckTier(token, project, domain string) (Decision, string) {
 	pe.mu.RLock()
 	defer pe.mu.RUnlock()
 
 	// Deny pass: if ANY tier denies, return Deny.
 	if pe.global.IsDenied(domain) {
-		return Deny
+		return Deny, TierGlobal
 	}
 	if p, ok := pe.projects[project]; ok && p.IsDenied(domain) {
-		return Deny
+		return Deny, TierProject
 	}
 	if t, ok := pe.tokens[token]; ok && t.IsDenied(domain) {
-		return Deny
+		return Deny, TierSession
 	}
 
 	// Allow pass: if ANY tier allows, return Allow.
 	if pe.global.IsAllowed(domain) {
-		return Allow
+		return Allow, TierGlobal
 	}
 	if p, ok := pe.projects[project]; ok && p.IsAllowed(domain) {
-		return Allow
+		return Allow, TierProject
 	}
 	if t, ok := pe.tokens[token]; ok && t.IsAllowed(domain) {
-		return Allow
+		return Allow, TierSession
 	}
 
-	return AskHuman
+	return AskHuman, ""
 }
 
 // splitEntries separates a slice of AllowEntry into domain and pattern lists.
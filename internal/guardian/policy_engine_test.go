@@ -276,6 +276,32 @@ func TestPolicyEngine_Check_MissingToken(t *testing.T) {
 	}
 }
 
+func TestPolicyEngine_CheckTier(t *testing.T) {
+	pe := newTestPolicyEngine(
+		ProxyPolicy{Allow: NewDomainSet([]string{"global.com"}, nil)},
+		map[string]*ProxyPolicy{
+			"proj": {Deny: NewDomainSet([]string{"project.com"}, nil)},
+		},
+		map[string]*ProxyPolicy{
+			"tok": {Allow: NewDomainSet([]string{"session.com"}, nil)},
+		},
+	)
+	for _, tt := range []struct {
+		domain   string
+		decision Decision
+		tier     string
+	}{
+		{"global.com", Allow, TierGlobal},
+		{"project.com", Deny, TierProject},
+		{"session.com", Allow, TierSession},
+		{"other.com", AskHuman, ""},
+	} {
+		if decision, tier := pe.CheckTier("tok", "proj", tt.domain); decision != tt.decision || tier != tt.tier {
+			t.Errorf("CheckTier(%q) = %v, %q; want %v, %q", tt.domain, decision, tier, tt.decision, tt.tier)
+		}
+	}
+}
+
 func TestNewPolicyEngine(t *testing.T) {
 	cfg := &config.GlobalConfig{
 		Proxy: config.ProxyConfig{
@@ -18,6 +18,7 @@ import (
 	"time"
 
 	"github.com/xdg/cloister/internal/clog"
+	"github.com/xdg/cloister/internal/tracing"
 )
 
 // DefaultProxyPort is the standard port for HTTP CONNECT proxies.
@@ -42,6 +43,7 @@ type TokenValidator interface {
 type DomainApprovalResult struct {
 	Approved bool
 	Scope    string // "session", "project", or "global"
+	Message  string // Approver's feedback on a denial, if any
 }
 
 // DomainApprover requests human approval for unlisted domains.
@@ -86,6 +88,18 @@ type ProxyServer struct {
 	// If nil, the proxy uses its built-in dialAndTunnel method.
 	TunnelHandler TunnelHandler
 
+	// Metrics records connections, tunnels and reloads. If nil, nothing
+	// is recorded.
+	Metrics *Metrics
+
+	// Health records the outcome of SIGHUP policy reloads. If nil, it is
+	// not recorded.
+	Health *Health
+
+	// Traffic optionally records each allowed connection and the bytes it
+	// carried. Bytes are not counted for connections served by TunnelHandler.
+	Traffic TrafficRecorder
+
 	// OnReload is an optional callback invoked after a successful SIGHUP-triggered
 	// PolicyEngine reload. Use this to clear caches (e.g. PatternCache) that should
 	// be invalidated when config changes. Called only when PolicyEngine is set.
@@ -190,7 +204,10 @@ func (p *ProxyServer) handleSighup() {
 	// PolicyEngine path: reload all policies from disk.
 	if p.PolicyEngine != nil {
 		if pe, ok := p.PolicyEngine.(*PolicyEngine); ok {
-			if err := pe.ReloadAll(); err != nil {
+			err := pe.ReloadAll()
+			p.Metrics.reload(reloadPolicy, err)
+			p.Health.reload(reloadPolicy, err)
+			if err != nil {
 				p.log("SIGHUP PolicyEngine reload failed: %v", err)
 				return
 			}
@@ -283,12 +300,22 @@ func (p *ProxyServer) handlePlainHTTP(w http.ResponseWriter, r *http.Request) {
 	resolved := p.resolveRequest(r)
 	domain := strings.ToLower(stripPort(r.URL.Host))
 
-	if err := p.checkDomainAccess(domain, resolved); err != nil {
+	ctx, span := tracing.Start(r.Context(), "proxy.http", resolved.traceAttrs(domain)...)
+	defer span.End()
+	r = r.WithContext(ctx)
+
+	access, err := p.checkDomainAccess(ctx, domain, resolved)
+	p.Metrics.proxyConnection(resolved, domain, access)
+	span.SetAttributes(access.traceAttrs()...)
+	if err != nil {
+		span.RecordError(err)
 		http.Error(w, err.Error(), http.StatusForbidden)
 		return
 	}
 
-	p.forwardHTTP(w, r)
+	sent, received := p.forwardHTTP(w, r)
+	p.recordTraffic(resolved, domain, sent, received)
+	span.SetAttributes(tracing.Int64("cloister.bytes_sent", sent), tracing.Int64("cloister.bytes_received", received))
 }
 
 // transport returns the HTTP transport for forwarding plain HTTP requests,
@@ -309,8 +336,9 @@ func (p *ProxyServer) transport() *http.Transport {
 
 // forwardHTTP forwards a plain HTTP request to the upstream server and copies
 // the response back to the client. It strips hop-by-hop headers, does not
-// follow redirects, and does not set X-Forwarded-For.
-func (p *ProxyServer) forwardHTTP(w http.ResponseWriter, r *http.Request) {
+// follow redirects, and does not set X-Forwarded-For. It returns the size of
+// the request body sent upstream and of the response body copied back.
+func (p *ProxyServer) forwardHTTP(w http.ResponseWriter, r *http.Request) (sent, received int64) {
 	// Clone the request for the outbound call
 	outReq := r.Clone(r.Context())
 	outReq.RequestURI = "" // Must be empty for http.Client/Transport
@@ -334,7 +362,13 @@ func (p *ProxyServer) forwardHTTP(w http.ResponseWriter, r *http.Request) {
 	outReq.Header.Del("Upgrade")
 
 	// Execute the request using RoundTrip directly to avoid following redirects
+	_, span := tracing.Start(r.Context(), "proxy.forward")
 	resp, err := p.transport().RoundTrip(outReq)
+	if err == nil {
+		span.SetAttributes(tracing.Int("http.response.status_code", resp.StatusCode))
+	}
+	span.RecordError(err)
+	span.End()
 	if err != nil {
 		clog.Warn("forwardHTTP: upstream request to %s failed: %v", outReq.URL.Host, err)
 		if isTimeoutError(err) {
@@ -342,7 +376,7 @@ func (p *ProxyServer) forwardHTTP(w http.ResponseWriter, r *http.Request) {
 		} else {
 			http.Error(w, "Bad Gateway", http.StatusBadGateway)
 		}
-		return
+		return 0, 0
 	}
 	defer resp.Body.Close()
 
@@ -353,9 +387,11 @@ func (p *ProxyServer) forwardHTTP(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 	w.WriteHeader(resp.StatusCode)
-	if _, err := io.Copy(w, resp.Body); err != nil {
+	received, err = io.Copy(w, resp.Body)
+	if err != nil {
 		clog.Debug("proxy: failed to copy response body: %v", err)
 	}
+	return max(outReq.ContentLength, 0), received
 }
 
 // authenticate checks the Proxy-Authorization header and validates the token.
@@ -459,58 +495,119 @@ func (p *ProxyServer) handleConnect(w http.ResponseWriter, r *http.Request) {
 	clog.Debug("handleConnect: host=%s, domain=%s, project=%s, policyEngine=%v",
 		targetHostPort, domain, resolved.ProjectName, p.PolicyEngine != nil)
 
-	if err := p.checkDomainAccess(domain, resolved); err != nil {
+	ctx, span := tracing.Start(r.Context(), "proxy.connect", resolved.traceAttrs(domain)...)
+	defer span.End()
+	r = r.WithContext(ctx)
+
+	access, err := p.checkDomainAccess(ctx, domain, resolved)
+	p.Metrics.proxyConnection(resolved, domain, access)
+	span.SetAttributes(access.traceAttrs()...)
+	if err != nil {
+		span.RecordError(err)
 		http.Error(w, err.Error(), http.StatusForbidden)
 		return
 	}
 
+	start := time.Now()
+	var sent, received int64
 	if p.TunnelHandler != nil {
 		p.TunnelHandler.ServeTunnel(w, r, targetHostPort)
 	} else {
-		p.dialAndTunnel(w, r, targetHostPort)
+		sent, received = p.dialAndTunnel(w, r, targetHostPort)
 	}
+	p.recordTraffic(resolved, domain, sent, received)
+	p.Metrics.tunnel(resolved, sent, received, time.Since(start))
+	span.SetAttributes(tracing.Int64("cloister.bytes_sent", sent), tracing.Int64("cloister.bytes_received", received))
+}
+
+// recordTraffic passes a finished connection to the traffic recorder, if any.
+func (p *ProxyServer) recordTraffic(resolved resolvedRequest, domain string, sent, received int64) {
+	if p.Traffic != nil && resolved.CloisterName != "" {
+		p.Traffic.RecordTraffic(resolved.CloisterName, domain, sent, received)
+	}
+}
+
+// Proxy connection decisions, as reported in metrics.
+const (
+	accessAllowed  = "allowed"  // Allowed by policy
+	accessDenied   = "denied"   // Denied by policy
+	accessApproved = "approved" // Approved by a person
+	accessRejected = "rejected" // Not approved: denied by a person, timed out, or no approver
+)
+
+// tierHuman is the tier reported for requests referred to a person.
+const tierHuman = "human"
+
+// accessResult describes how a domain access decision was made.
+type accessResult struct {
+	decision string // One of the access* constants
+	tier     string // Policy tier, or tierHuman
+}
+
+// traceAttrs returns the span attributes describing the decision.
+func (a accessResult) traceAttrs() []tracing.Attr {
+	return []tracing.Attr{tracing.String("cloister.decision", a.decision), tracing.String("cloister.tier", a.tier)}
 }
 
 // checkDomainAccess evaluates deny/allow rules via PolicyEngine.
 // Returns nil if the domain is allowed, or an error message if denied.
-func (p *ProxyServer) checkDomainAccess(domain string, resolved resolvedRequest) error {
+func (p *ProxyServer) checkDomainAccess(ctx context.Context, domain string, resolved resolvedRequest) (accessResult, error) {
 	if p.PolicyEngine != nil {
-		decision := p.PolicyEngine.Check(resolved.Token, resolved.ProjectName, domain)
+		var decision Decision
+		var tier string
+		_, span := tracing.Start(ctx, "policy.check")
+		if tc, ok := p.PolicyEngine.(tierChecker); ok {
+			decision, tier = tc.CheckTier(resolved.Token, resolved.ProjectName, domain)
+		} else {
+			decision = p.PolicyEngine.Check(resolved.Token, resolved.ProjectName, domain)
+		}
+		span.SetAttributes(tracing.String("cloister.decision", decision.String()), tracing.String("cloister.tier", tier))
+		span.End()
+
 		switch decision {
 		case Allow:
-			return nil
+			return accessResult{accessAllowed, tier}, nil
 		case Deny:
-			return fmt.Errorf("forbidden - domain denied")
+			return accessResult{accessDenied, tier}, fmt.Errorf("forbidden - domain denied")
 		case AskHuman:
-			return p.requestDomainApproval(domain, resolved)
+			return p.requestDomainApproval(ctx, domain, resolved)
 		default:
-			return fmt.Errorf("forbidden - unknown policy decision")
+			return accessResult{accessDenied, tier}, fmt.Errorf("forbidden - unknown policy decision")
 		}
 	}
 
 	// No PolicyEngine configured: reject everything not approved.
-	return p.requestDomainApproval(domain, resolved)
+	return p.requestDomainApproval(ctx, domain, resolved)
 }
 
 // requestDomainApproval queues a domain for human approval or rejects immediately.
-func (p *ProxyServer) requestDomainApproval(domain string, resolved resolvedRequest) error {
+func (p *ProxyServer) requestDomainApproval(ctx context.Context, domain string, resolved resolvedRequest) (accessResult, error) {
+	rejected := accessResult{accessRejected, tierHuman}
 	if p.DomainApprover == nil {
-		return fmt.Errorf("forbidden - domain not allowed")
+		return rejected, fmt.Errorf("forbidden - domain not allowed")
 	}
 	if err := ValidateDomain(domain); err != nil {
-		return fmt.Errorf("forbidden - invalid domain: %w", err)
+		return rejected, fmt.Errorf("forbidden - invalid domain: %w", err)
 	}
+	_, span := tracing.Start(ctx, "domain.approval")
 	result, err := p.DomainApprover.RequestApproval(resolved.ProjectName, resolved.CloisterName, domain, resolved.Token)
+	span.SetAttributes(tracing.Bool("cloister.approved", err == nil && result.Approved))
+	span.RecordError(err)
+	span.End()
 	if err != nil || !result.Approved {
-		return fmt.Errorf("forbidden - domain not approved")
+		if result.Message != "" {
+			return rejected, fmt.Errorf("forbidden - domain not approved\nMessage from approver: %s", result.Message)
+		}
+		return rejected, fmt.Errorf("forbidden - domain not approved")
 	}
-	return nil
+	return accessResult{accessApproved, tierHuman}, nil
 }
 
 // dialAndTunnel establishes a TCP connection to the upstream server, hijacks
 // the client connection, and performs bidirectional copy until either side
-// closes or the idle timeout is reached.
-func (p *ProxyServer) dialAndTunnel(w http.ResponseWriter, r *http.Request, targetHostPort string) {
+// closes or the idle timeout is reached. It returns the number of bytes
+// copied from the client to upstream and from upstream to the client.
+func (p *ProxyServer) dialAndTunnel(w http.ResponseWriter, r *http.Request, targetHostPort string) (sent, received int64) {
 	// Establish connection to upstream server.
 	// We use net.Dial (not TLS) because the client will perform TLS handshake
 	// through the tunnel - this is how HTTP CONNECT proxies work.
@@ -518,17 +615,20 @@ func (p *ProxyServer) dialAndTunnel(w http.ResponseWriter, r *http.Request, targ
 	dialer := &net.Dialer{
 		Timeout: dialTimeout,
 	}
+	_, span := tracing.Start(r.Context(), "proxy.dial")
 	upstreamConn, err := dialer.DialContext(r.Context(), "tcp", targetHostPort)
+	span.RecordError(err)
+	span.End()
 	if err != nil {
 		// Log timeout errors with specific message for debugging
 		if isTimeoutError(err) {
 			p.log("proxy connection timeout to %s after %v: %v", targetHostPort, dialTimeout, err)
 			http.Error(w, fmt.Sprintf("Gateway Timeout - connection to upstream timed out after %v", dialTimeout), http.StatusGatewayTimeout)
-			return
+			return 0, 0
 		}
 		p.log("proxy connection failed to %s: %v", targetHostPort, err)
 		http.Error(w, fmt.Sprintf("Bad Gateway - failed to connect to upstream: %v", err), http.StatusBadGateway)
-		return
+		return 0, 0
 	}
 	defer func() {
 		if err := upstreamConn.Close(); err != nil {
@@ -540,13 +640,13 @@ func (p *ProxyServer) dialAndTunnel(w http.ResponseWriter, r *http.Request, targ
 	hijacker, ok := w.(http.Hijacker)
 	if !ok {
 		http.Error(w, "Internal Server Error - connection hijacking not supported", http.StatusInternalServerError)
-		return
+		return 0, 0
 	}
 
 	clientConn, _, err := hijacker.Hijack()
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Internal Server Error - failed to hijack connection: %v", err), http.StatusInternalServerError)
-		return
+		return 0, 0
 	}
 	defer func() {
 		if err := clientConn.Close(); err != nil {
@@ -559,7 +659,7 @@ func (p *ProxyServer) dialAndTunnel(w http.ResponseWriter, r *http.Request, targ
 	_, err = clientConn.Write([]byte("HTTP/1.1 200 Connection Established\r\n\r\n"))
 	if err != nil {
 		// Client connection failed, nothing more we can do
-		return
+		return 0, 0
 	}
 
 	// Set up bidirectional copy with idle timeout.
@@ -570,7 +670,7 @@ func (p *ProxyServer) dialAndTunnel(w http.ResponseWriter, r *http.Request, targ
 	// Copy from client to upstream
 	go func() {
 		defer wg.Done()
-		copyWithIdleTimeout(upstreamConn, clientConn, idleTimeout)
+		sent = copyWithIdleTimeout(upstreamConn, clientConn, idleTimeout)
 		// When client closes or times out, close upstream write side
 		if tcpConn, ok := upstreamConn.(*net.TCPConn); ok {
 			if err := tcpConn.CloseWrite(); err != nil {
@@ -582,7 +682,7 @@ func (p *ProxyServer) dialAndTunnel(w http.ResponseWriter, r *http.Request, targ
 	// Copy from upstream to client
 	go func() {
 		defer wg.Done()
-		copyWithIdleTimeout(clientConn, upstreamConn, idleTimeout)
+		received = copyWithIdleTimeout(clientConn, upstreamConn, idleTimeout)
 		// When upstream closes or times out, close client write side
 		if tcpConn, ok := clientConn.(*net.TCPConn); ok {
 			if err := tcpConn.CloseWrite(); err != nil {
@@ -592,12 +692,14 @@ func (p *ProxyServer) dialAndTunnel(w http.ResponseWriter, r *http.Request, targ
 	}()
 
 	wg.Wait()
+	return sent, received
 }
 
 // copyWithIdleTimeout copies from src to dst, resetting the deadline on each read.
 // This implements an idle timeout - the connection is closed if no data is transferred
-// for the specified duration.
-func copyWithIdleTimeout(dst, src net.Conn, idleTimeout time.Duration) {
+// for the specified duration. It returns the number of bytes written to dst.
+func copyWithIdleTimeout(dst, src net.Conn, idleTimeout time.Duration) int64 {
+	var written int64
 	buf := make([]byte, 32*1024) // 32KB buffer, same as io.Copy default
 	for {
 		// Set read deadline for idle timeout
@@ -611,14 +713,15 @@ func copyWithIdleTimeout(dst, src net.Conn, idleTimeout time.Duration) {
 			if err := dst.SetWriteDeadline(time.Now().Add(idleTimeout)); err != nil {
 				clog.Warn("failed to set write deadline: %v", err)
 			}
-			_, writeErr := dst.Write(buf[:n])
+			nw, writeErr := dst.Write(buf[:n])
+			written += int64(nw)
 			if writeErr != nil {
-				return
+				return written
 			}
 		}
 		if err != nil {
 			// EOF or timeout or other error - stop copying
-			return
+			return written
 		}
 	}
 }
@@ -630,6 +733,15 @@ type resolvedRequest struct {
 	Token        string
 }
 
+// traceAttrs returns the span attributes identifying a request for domain.
+func (r resolvedRequest) traceAttrs(domain string) []tracing.Attr {
+	return []tracing.Attr{
+		tracing.String("cloister.project", r.ProjectName),
+		tracing.String("cloister.name", r.CloisterName),
+		tracing.String("cloister.domain", domain),
+	}
+}
+
 // resolveRequest determines the project name, cloister name, and token for a request
 // using the TokenLookup function.
 func (p *ProxyServer) resolveRequest(r *http.Request) resolvedRequest {
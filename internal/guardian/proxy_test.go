@@ -19,6 +19,7 @@ import (
 
 	"github.com/xdg/cloister/internal/clog"
 	"github.com/xdg/cloister/internal/config"
+	"github.com/xdg/cloister/internal/testutil"
 	"github.com/xdg/cloister/internal/token"
 )
 
@@ -2120,7 +2121,7 @@ func TestProxyServer_MockPolicyChecker(t *testing.T) {
 // HTTP request with an absolute URI (forward-proxy style). It returns the
 // response status code and body. If token is non-empty, a Proxy-Authorization
 // header with Basic auth (username "cloister") is included.
-func sendRawHTTPViaProxy(t *testing.T, proxyAddr, method, rawURL, tok string) (_ int, _ string, _ error) { //nolint:unparam // test helper returns body for future use
+func sendRawHTTPViaProxy(t *testing.T, proxyAddr, method, rawURL, tok string) (_ int, _ string, _ error) {
 	t.Helper()
 
 	conn, err := (&net.Dialer{Timeout: 5 * time.Second}).DialContext(context.Background(), "tcp", proxyAddr)
@@ -2235,6 +2236,38 @@ func TestProxyServer_PlainHTTP_DeniedDomain(t *testing.T) {
 	}
 }
 
+func TestProxyServer_DomainApproval_DenialMessageInBody(t *testing.T) {
+	// Approver feedback on a denial reaches the agent in the 403 body.
+	p := NewProxyServer(":0")
+	p.PolicyEngine = newTestProxyPolicyEngine(nil, nil) // Empty — all domains go to AskHuman
+	p.DomainApprover = &mockDomainApprover{
+		approveFunc: func(_, _, _, _ string) (DomainApprovalResult, error) {
+			return DomainApprovalResult{Approved: false, Message: "use the internal mirror"}, nil
+		},
+	}
+	p.TokenValidator = newMockTokenValidator("test-token")
+
+	if err := p.Start(); err != nil {
+		t.Fatalf("failed to start proxy server: %v", err)
+	}
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = p.Stop(ctx)
+	}()
+
+	status, body, err := sendRawHTTPViaProxy(t, p.ListenAddr(), "GET", "http://pypi.example.com/", "test-token")
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	if status != http.StatusForbidden {
+		t.Errorf("expected 403 after denial, got %d", status)
+	}
+	if !strings.Contains(body, "Message from approver: use the internal mirror") {
+		t.Errorf("expected approver message in body, got %q", body)
+	}
+}
+
 func TestProxyServer_PlainHTTP_NoAuth(t *testing.T) {
 	// Send a plain HTTP GET without Proxy-Authorization header.
 	// Current code returns 405 for all non-CONNECT; this test asserts
@@ -2584,6 +2617,59 @@ func TestProxyServer_PlainHTTP_ForwardGET(t *testing.T) {
 	}
 }
 
+func TestProxyServer_PlainHTTP_RecordsTraffic(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte("hello"))
+	}))
+	defer upstream.Close()
+	upstreamURL, _ := url.Parse(upstream.URL)
+
+	sessions := NewSessions()
+	sessions.Start(token.Info{CloisterName: "api-main"})
+
+	p := NewProxyServer(":0")
+	p.PolicyEngine = newTestProxyPolicyEngine([]string{upstreamURL.Hostname()}, nil)
+	p.TokenValidator = newMockTokenValidator("test-token")
+	p.TokenLookup = func(string) (TokenLookupResult, bool) {
+		return TokenLookupResult{ProjectName: "api", CloisterName: "api-main"}, true
+	}
+	p.Traffic = sessions
+
+	if err := p.Start(); err != nil {
+		t.Fatalf("failed to start proxy server: %v", err)
+	}
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = p.Stop(ctx)
+	}()
+
+	status, _, err := sendRawHTTPViaProxy(t, p.ListenAddr(), "GET", upstream.URL+"/", "test-token")
+	if err != nil || status != http.StatusOK {
+		t.Fatalf("request = %d, %v; want 200", status, err)
+	}
+
+	// Traffic is recorded once the handler returns, which can be just after
+	// the client has read the response.
+	sessions.mu.Lock()
+	sess := sessions.sessions["api-main"]
+	sessions.mu.Unlock()
+	for deadline := time.Now().Add(2 * time.Second); time.Now().Before(deadline); time.Sleep(5 * time.Millisecond) {
+		sessions.mu.Lock()
+		n := len(sess.domains)
+		sessions.mu.Unlock()
+		if n > 0 {
+			break
+		}
+	}
+
+	report := sessions.End(token.Info{CloisterName: "api-main"})
+	want := DomainTraffic{Domain: upstreamURL.Hostname(), Connections: 1, BytesReceived: 5}
+	if report == nil || len(report.Domains) != 1 || report.Domains[0] != want {
+		t.Errorf("report = %+v, want domains [%+v]", report, want)
+	}
+}
+
 func TestProxyServer_PlainHTTP_ForwardPOST(t *testing.T) {
 	// Start a mock upstream that echoes the request body back.
 	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -3026,3 +3112,61 @@ func TestProxyServer_PlainHTTP_EmptyHost(t *testing.T) {
 		t.Errorf("expected 400 Bad Request for empty host in absolute URI, got %d", status)
 	}
 }
+
+func TestProxyServer_Traces(t *testing.T) {
+	collector, tracer := testutil.StartCollector(t, "test")
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte("hello"))
+	}))
+	defer upstream.Close()
+	upstreamURL, _ := url.Parse(upstream.URL)
+
+	p := NewProxyServer(":0")
+	p.PolicyEngine = newTestProxyPolicyEngine([]string{upstreamURL.Hostname()}, []string{"blocked.example"})
+	p.TokenValidator = newMockTokenValidator("test-token")
+	p.TokenLookup = func(string) (TokenLookupResult, bool) {
+		return TokenLookupResult{ProjectName: "api", CloisterName: "api-main"}, true
+	}
+	if err := p.Start(); err != nil {
+		t.Fatalf("failed to start proxy server: %v", err)
+	}
+
+	if status, _, err := sendRawHTTPViaProxy(t, p.ListenAddr(), "GET", upstream.URL+"/", "test-token"); err != nil || status != http.StatusOK {
+		t.Fatalf("allowed request = %d, %v; want 200", status, err)
+	}
+	if status, _, err := sendRawHTTPViaProxy(t, p.ListenAddr(), "GET", "http://blocked.example/", "test-token"); err != nil || status != http.StatusForbidden {
+		t.Fatalf("denied request = %d, %v; want 403", status, err)
+	}
+	// Stopping waits for the handlers, which end the request spans.
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_ = p.Stop(ctx)
+
+	var allowed, denied testutil.Span
+	for _, s := range collector.Spans(t, tracer) {
+		if s.Name == "proxy.http" && s.Attributes["cloister.domain"] == "blocked.example" {
+			denied = s
+		} else if s.Name == "proxy.http" {
+			allowed = s
+		}
+	}
+	if allowed.Attributes["cloister.project"] != "api" || allowed.Attributes["cloister.name"] != "api-main" ||
+		allowed.Attributes["cloister.decision"] != accessAllowed || allowed.Attributes["cloister.tier"] != "global" ||
+		allowed.Attributes["cloister.bytes_received"] != "5" {
+		t.Errorf("allowed span = %+v", allowed)
+	}
+	if denied.Attributes["cloister.decision"] != accessDenied || denied.Error != "forbidden - domain denied" {
+		t.Errorf("denied span = %+v", denied)
+	}
+
+	for _, s := range collector.Spans(t) {
+		if s.Name == "policy.check" || s.Name == "proxy.forward" {
+			if s.ParentSpanID != allowed.SpanID && s.ParentSpanID != denied.SpanID {
+				t.Errorf("%s span is not a child of a request span: %+v", s.Name, s)
+			}
+		}
+	}
+	if forward := collector.Span(t, "proxy.forward"); forward.Attributes["http.response.status_code"] != "200" {
+		t.Errorf("proxy.forward = %+v", forward)
+	}
+}
@@ -0,0 +1,228 @@
+// Package redact masks secrets in hostexec command output.
+//
+// Approved host commands such as "gh auth status" or "aws sts
+// get-session-token" can print credentials. The guardian passes stdout and
+// stderr through a Redactor before returning them to the cloister so tokens
+// never cross into the container.
+package redact
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/xdg/cloister/internal/clog"
+	"github.com/xdg/cloister/internal/config"
+)
+
+// Lookahead is how many bytes past an output cap the guardian asks the
+// executor for, so that a secret straddling the cap is still matched whole
+// before RedactCapped cuts the output.
+const Lookahead = 4096
+
+// truncatedDetector names masks applied to the end of output cut short.
+const truncatedDetector = "truncated"
+
+// unterminatedKey matches a private key block with no END line.
+var unterminatedKey = regexp.MustCompile(`-----BEGIN [A-Z0-9 ]*PRIVATE KEY-----[\s\S]*\z`)
+
+// maskSuffix matches text ending in a mask, which needs no further masking.
+var maskSuffix = regexp.MustCompile(`\[REDACTED:[^\]]+\]\z`)
+
+// secretGroup is the named capture group that limits masking to part of a
+// match, e.g. the value in `"SecretAccessKey": "..."`.
+const secretGroup = "secret"
+
+// detector is a named secret pattern.
+type detector struct {
+	name  string
+	re    *regexp.Regexp
+	group int // Index of the "secret" group, or 0 to mask the whole match
+}
+
+// builtinPatterns are detectors for common credential formats. Order matters:
+// more specific formats (Anthropic) precede more general ones (OpenAI) so the
+// reported detector name is the most precise one.
+var builtinPatterns = []config.RedactPattern{
+	{Name: "private_key", Pattern: `-----BEGIN [A-Z0-9 ]*PRIVATE KEY-----[\s\S]*?-----END [A-Z0-9 ]*PRIVATE KEY-----`},
+	{Name: "github_token", Pattern: `\b(?:gh[pousr]_[A-Za-z0-9]{36,255}|github_pat_[A-Za-z0-9_]{22,255})\b`},
+	{Name: "aws_access_key", Pattern: `\b(?:AKIA|ASIA|ABIA|ACCA)[A-Z0-9]{16}\b`},
+	{
+		Name: "aws_secret_key",
+		Pattern: `(?i)(?:aws_?secret_?access_?key|SecretAccessKey)["']?\s*[:=]\s*["']?` +
+			`(?P<secret>[A-Za-z0-9/+=]{40})`,
+	},
+	{
+		Name: "aws_session_token",
+		Pattern: `(?i)(?:aws_?session_?token|SessionToken)["']?\s*[:=]\s*["']?` +
+			`(?P<secret>[A-Za-z0-9/+=]{100,})`,
+	},
+	{Name: "anthropic_key", Pattern: `\bsk-ant-[A-Za-z0-9_-]{20,}`},
+	{Name: "openai_key", Pattern: `\bsk-(?:proj-|svcacct-|admin-)?[A-Za-z0-9_-]{20,}`},
+	{Name: "jwt", Pattern: `\beyJ[A-Za-z0-9_-]{8,}\.eyJ[A-Za-z0-9_-]{8,}\.[A-Za-z0-9_-]{8,}`},
+}
+
+// Result summarizes the redactions applied to one or more strings.
+type Result struct {
+	// Counts maps detector name to the number of secrets it masked.
+	Counts map[string]int
+}
+
+// Total returns the total number of masked secrets.
+func (r Result) Total() int {
+	n := 0
+	for _, c := range r.Counts {
+		n += c
+	}
+	return n
+}
+
+// Detectors returns the names of detectors that matched, sorted.
+func (r Result) Detectors() []string {
+	names := make([]string, 0, len(r.Counts))
+	for name := range r.Counts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Redactor masks secrets matched by its detectors.
+type Redactor struct {
+	detectors []detector
+}
+
+// New creates a Redactor from config. Built-in detectors come first unless
+// cfg.DisableBuiltin is set; custom patterns follow. Invalid custom patterns
+// are logged and skipped, not fatal.
+func New(cfg config.RedactConfig) *Redactor {
+	r := &Redactor{}
+	if !cfg.DisableBuiltin {
+		for _, p := range builtinPatterns {
+			r.detectors = append(r.detectors, newDetector(p.Name, regexp.MustCompile(p.Pattern)))
+		}
+	}
+	for _, p := range cfg.Patterns {
+		d, err := compile(p)
+		if err != nil {
+			clog.Warn("invalid redaction pattern %q: %v (skipped)", p.Name, err)
+			continue
+		}
+		r.detectors = append(r.detectors, d)
+	}
+	return r
+}
+
+// compile builds a detector from a pattern definition.
+func compile(p config.RedactPattern) (detector, error) {
+	re, err := regexp.Compile(p.Pattern)
+	if err != nil {
+		return detector{}, fmt.Errorf("invalid regex: %w", err)
+	}
+	return newDetector(p.Name, re), nil
+}
+
+// newDetector wraps a compiled regex, locating its "secret" group if any.
+func newDetector(name string, re *regexp.Regexp) detector {
+	d := detector{name: name, re: re}
+	if i := re.SubexpIndex(secretGroup); i > 0 {
+		d.group = i
+	}
+	return d
+}
+
+// Redact masks secrets in each string in place and returns a summary.
+// A nil Redactor leaves the strings unchanged.
+func (r *Redactor) Redact(texts ...*string) Result {
+	res := Result{Counts: map[string]int{}}
+	if r == nil {
+		return res
+	}
+	for _, t := range texts {
+		for _, d := range r.detectors {
+			*t = d.redact(*t, res.Counts)
+		}
+	}
+	return res
+}
+
+// RedactCapped masks secrets in each string in place, then cuts it to at
+// most limit bytes, at a character boundary; a limit of zero or less means
+// unlimited. Each string must have been
+// captured with a cap of limit+Lookahead. A string that reached that cap may
+// end in a secret cut short, which no detector matches, so its trailing
+// token and any unterminated private key are masked too. Returns a summary
+// and whether any string was cut. A nil Redactor only cuts.
+func (r *Redactor) RedactCapped(limit int64, texts ...*string) (Result, bool) {
+	capped := make([]bool, len(texts))
+	for i, t := range texts {
+		capped[i] = r != nil && limit > 0 && int64(len(*t)) >= limit+Lookahead
+	}
+	res := r.Redact(texts...)
+	cut := false
+	for i, t := range texts {
+		if capped[i] {
+			*t = maskTail(*t, res.Counts)
+		}
+		if limit > 0 && int64(len(*t)) > limit {
+			*t = cutUTF8(*t, int(limit))
+			cut = true
+		}
+	}
+	return res, cut
+}
+
+// cutUTF8 returns s cut to at most n bytes without splitting a multi-byte
+// UTF-8 sequence. Bytes that are not valid UTF-8 are cut at n.
+func cutUTF8(s string, n int) string {
+	for i := n; i > 0 && i > n-utf8.UTFMax; i-- {
+		if utf8.RuneStart(s[i]) {
+			return s[:i]
+		}
+	}
+	return s[:n]
+}
+
+// maskTail masks an unterminated private key block at the end of s, or
+// otherwise its trailing run of non-whitespace characters.
+func maskTail(s string, counts map[string]int) string {
+	mask := "[REDACTED:" + truncatedDetector + "]"
+	if loc := unterminatedKey.FindStringIndex(s); loc != nil {
+		counts[truncatedDetector]++
+		return s[:loc[0]] + mask
+	}
+	start := strings.LastIndexAny(s, " \t\r\n") + 1
+	if start == len(s) || maskSuffix.MatchString(s[start:]) {
+		return s
+	}
+	counts[truncatedDetector]++
+	return s[:start] + mask
+}
+
+// redact replaces matches of d in s with a mask, counting replacements.
+func (d *detector) redact(s string, counts map[string]int) string {
+	matches := d.re.FindAllStringSubmatchIndex(s, -1)
+	if len(matches) == 0 {
+		return s
+	}
+	mask := "[REDACTED:" + d.name + "]"
+	var b strings.Builder
+	last := 0
+	for _, m := range matches {
+		start, end := m[0], m[1]
+		if d.group > 0 {
+			start, end = m[2*d.group], m[2*d.group+1]
+			if start < 0 {
+				continue
+			}
+		}
+		b.WriteString(s[last:start])
+		b.WriteString(mask)
+		last = end
+		counts[d.name]++
+	}
+	b.WriteString(s[last:])
+	return b.String()
+}
@@ -0,0 +1,53 @@
+package request
+
+import (
+	"time"
+
+	"github.com/xdg/cloister/internal/audit"
+	"github.com/xdg/cloister/internal/guardian/approval"
+	"github.com/xdg/cloister/internal/guardian/patterns"
+)
+
+// RecentDecisions is the number of the cloister's past hostexec decisions
+// shown with a pending request.
+const RecentDecisions = 5
+
+// newPendingRequest builds the approval queue entry for a request, with the
+// context an approver needs to judge it: where it runs, what matched it,
+// its argv with risky arguments flagged, and the cloister's recent hostexec
+// history.
+//
+// It deliberately runs nothing on the host: git in the agent-writable
+// worktree would run filter drivers and other helpers from its repo config
+// before anyone approved anything.
+func (s *Server) newPendingRequest(vr *validatedRequest, result patterns.MatchResult, respChan chan<- approval.Response) *approval.PendingRequest {
+	req := &approval.PendingRequest{
+		ID:        vr.id,
+		Cloister:  vr.info.CloisterName,
+		Project:   vr.info.ProjectName,
+		Cmd:       vr.cmd,
+		Timestamp: time.Now(),
+		Response:  respChan,
+		Workdir:   vr.workdir,
+		Pattern:   result.Pattern,
+		Args:      s.Risk.Classify(vr.cmd, vr.args),
+		Worktree:  vr.info.WorktreePath,
+
+		RequireApprovals: result.Limits.RequireApprovals,
+		Timeout:          result.Limits.ApprovalTimeout,
+	}
+	if vr.invocation != nil {
+		req.Action = vr.invocation.Name
+		for _, p := range vr.invocation.Params {
+			req.Params = append(req.Params, approval.ActionParam{Name: p.Name, Value: p.Value})
+		}
+	}
+	if s.History != nil {
+		req.Recent = s.History.List(audit.HistoryFilter{
+			Cloister: vr.info.CloisterName,
+			Type:     audit.DecisionHostexec,
+			Limit:    RecentDecisions,
+		})
+	}
+	return req
+}
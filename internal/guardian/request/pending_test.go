@@ -0,0 +1,64 @@
+package request
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/xdg/cloister/internal/audit"
+	"github.com/xdg/cloister/internal/config"
+	"github.com/xdg/cloister/internal/guardian/patterns"
+	"github.com/xdg/cloister/internal/guardian/risk"
+	"github.com/xdg/cloister/internal/token"
+)
+
+func TestNewPendingRequest_Context(t *testing.T) {
+	mockExec := &mockCommandExecutor{}
+	history := audit.NewHistory(0)
+	logger := audit.NewLogger(nil)
+	logger.AddObserver(history)
+	_ = logger.LogAutoApprove("", "proj", "proj-main", "docker ps", "^docker ps.*$")
+	_ = logger.LogDeny("", "proj", "proj-main", "git push --force", "denied by user")
+	_ = logger.LogAutoApprove("", "other", "other-main", "docker ps", "^docker ps.*$")
+
+	s := &Server{CommandExecutor: mockExec, History: history, Risk: risk.New(config.RiskConfig{})}
+	vr := &validatedRequest{
+		ctx:  context.Background(),
+		args: []string{"git", "push", "--force", "origin", "main"},
+		cmd:  "git push --force origin main",
+		info: token.Info{CloisterName: "proj-main", ProjectName: "proj", WorktreePath: "/home/u/proj"},
+	}
+	result := patterns.MatchResult{Action: patterns.ManualApprove, Pattern: "^git push.*$", Limits: patterns.Limits{RequireApprovals: 2, ApprovalTimeout: time.Hour}}
+	req := s.newPendingRequest(vr, result, nil)
+
+	if req.Pattern != "^git push.*$" || req.Worktree != "/home/u/proj" || req.Workdir != "" {
+		t.Errorf("pattern/worktree/workdir = %q, %q, %q", req.Pattern, req.Worktree, req.Workdir)
+	}
+	if req.RequireApprovals != 2 || req.Timeout != time.Hour {
+		t.Errorf("RequireApprovals, Timeout = %d, %v, want 2, 1h", req.RequireApprovals, req.Timeout)
+	}
+	if len(mockExec.requests) != 0 {
+		t.Errorf("host commands run before approval: %+v", mockExec.requests)
+	}
+	if len(req.Args) != 5 || !slices.Equal(req.Args[2].Risks, []string{"force_push"}) || len(req.Args[3].Risks) != 0 {
+		t.Errorf("Args = %+v, want --force flagged", req.Args)
+	}
+	if len(req.Recent) != 2 || req.Recent[0].Outcome != audit.OutcomeDenied || req.Recent[1].Cmd != "docker ps" {
+		t.Errorf("Recent = %+v, want this cloister's decisions newest first", req.Recent)
+	}
+}
+
+func TestNewPendingRequest_NoHistory(t *testing.T) {
+	s := &Server{}
+	vr := &validatedRequest{
+		ctx:  context.Background(),
+		args: []string{"make"},
+		cmd:  "make",
+		info: token.Info{CloisterName: "c", ProjectName: "p"},
+	}
+	req := s.newPendingRequest(vr, patterns.MatchResult{}, nil)
+	if len(req.Args) != 1 || req.Recent != nil {
+		t.Errorf("Args = %+v, Recent = %+v", req.Args, req.Recent)
+	}
+}
@@ -16,9 +16,13 @@ import (
 	"github.com/xdg/cloister/internal/audit"
 	"github.com/xdg/cloister/internal/clog"
 	"github.com/xdg/cloister/internal/executor"
+	"github.com/xdg/cloister/internal/guardian/actions"
 	"github.com/xdg/cloister/internal/guardian/approval"
 	"github.com/xdg/cloister/internal/guardian/patterns"
+	"github.com/xdg/cloister/internal/guardian/redact"
+	"github.com/xdg/cloister/internal/guardian/risk"
 	"github.com/xdg/cloister/internal/token"
+	"github.com/xdg/cloister/internal/tracing"
 )
 
 // DefaultRequestPort is the port for the request server.
@@ -38,11 +42,35 @@ type PatternMatcher interface {
 // If nil is returned, all commands for that project require manual approval.
 type PatternLookup func(projectName string) PatternMatcher
 
+// ActionResolver resolves named hostexec actions into invocations.
+// Implemented by *actions.Set.
+type ActionResolver interface {
+	// Resolve validates params against the named action and returns the
+	// resulting invocation. Relative workdirs resolve against baseDir.
+	Resolve(name string, params map[string]string, baseDir string) (*actions.Invocation, error)
+}
+
+// ActionLookup returns the ActionResolver for a given project name.
+// The returned resolver should reflect merged global + project actions.
+// If nil is returned, all action requests for that project are denied.
+type ActionLookup func(projectName string) ActionResolver
+
 // CommandExecutor executes approved commands on the host via the executor socket.
 // This interface wraps the executor client for testability.
 type CommandExecutor interface {
 	// Execute sends an execution request to the host executor and returns the response.
-	Execute(req executor.ExecuteRequest) (*executor.ExecuteResponse, error)
+	// Cancelling ctx abandons the request; onProgress, if non-nil, receives
+	// queue position and start-of-execution updates.
+	Execute(ctx context.Context, req executor.ExecuteRequest, onProgress func(executor.Progress)) (*executor.ExecuteResponse, error)
+}
+
+// Metrics counts hostexec requests by outcome. Implemented by
+// *guardian.Metrics.
+type Metrics interface {
+	// HostexecRequest counts a request for a named action, "command" for
+	// a plain command, or "unknown" for an action that is not configured,
+	// with the status it finished with.
+	HostexecRequest(info token.Info, action, outcome string)
 }
 
 // Server handles hostexec command requests from cloister containers.
@@ -59,6 +87,10 @@ type Server struct {
 	// If nil, all commands require manual approval.
 	PatternLookup PatternLookup
 
+	// ActionLookup returns the named actions for a given project.
+	// If nil, action requests are denied.
+	ActionLookup ActionLookup
+
 	// CommandExecutor executes approved commands via the host executor socket.
 	// If nil, commands will return a "not implemented" response.
 	CommandExecutor CommandExecutor
@@ -70,6 +102,25 @@ type Server struct {
 	// AuditLogger logs hostexec events. If nil, no audit logging is performed.
 	AuditLogger *audit.Logger
 
+	// Redactor masks secrets in command output before it is returned.
+	// If nil, output is returned unmodified.
+	Redactor *redact.Redactor
+
+	// Executions tracks queued and running commands for the approval UI.
+	// If nil, executions are not tracked.
+	Executions *approval.ExecutionTracker
+
+	// History supplies the cloister's recent decisions shown with pending
+	// requests. If nil, no history is shown.
+	History *audit.History
+
+	// Risk flags dangerous arguments of pending requests for the approval
+	// UI. If nil, no arguments are flagged.
+	Risk *risk.Rules
+
+	// Metrics counts requests by outcome. If nil, nothing is recorded.
+	Metrics Metrics
+
 	server   *http.Server
 	listener net.Listener
 	mu       sync.Mutex
@@ -165,10 +216,24 @@ func (s *Server) handleRequestRouter(w http.ResponseWriter, r *http.Request) {
 }
 
 // validatedRequest holds a parsed and validated command request.
+// For action requests, args, cmd, and workdir are filled in once the
+// action has been resolved.
 type validatedRequest struct {
-	args []string
-	cmd  string
-	info token.Info
+	id      string          // Audit request ID, also used as the approval queue ID
+	ctx     context.Context // Cancelled when the requesting client disconnects
+	args    []string
+	cmd     string
+	workdir string
+	info    token.Info
+	span    *tracing.Span // Covers the whole request; nil while tracing is off
+
+	stream   http.ResponseWriter // Set if the client asked for progress lines
+	streamed bool                // A progress line has been written
+
+	actionName   string
+	actionParams map[string]string
+	actionKnown  bool // actionName is configured for the project
+	invocation   *actions.Invocation
 }
 
 // parseAndValidateRequest parses the JSON body, validates args, and extracts cloister info.
@@ -180,7 +245,12 @@ func (s *Server) parseAndValidateRequest(w http.ResponseWriter, r *http.Request)
 		return nil
 	}
 
-	if len(req.Args) == 0 {
+	if req.Action != "" && len(req.Args) > 0 {
+		s.writeJSON(w, http.StatusBadRequest, CommandResponse{Status: "error", Reason: "action and args are mutually exclusive"})
+		return nil
+	}
+
+	if req.Action == "" && len(req.Args) == 0 {
 		s.writeJSON(w, http.StatusBadRequest, CommandResponse{Status: "error", Reason: "args is required"})
 		return nil
 	}
@@ -196,7 +266,35 @@ func (s *Server) parseAndValidateRequest(w http.ResponseWriter, r *http.Request)
 		return nil
 	}
 
-	return &validatedRequest{args: req.Args, cmd: canonicalCmd(req.Args), info: info}
+	vr := &validatedRequest{id: audit.NewID(), ctx: r.Context(), info: info}
+	if req.Progress {
+		vr.stream = w
+	}
+	if req.Action != "" {
+		vr.actionName, vr.actionParams = req.Action, req.Params
+		return vr
+	}
+	vr.args, vr.cmd = req.Args, canonicalCmd(req.Args)
+	return vr
+}
+
+// writeProgress streams a progress update to a client that asked for them.
+func (s *Server) writeProgress(vr *validatedRequest, p executor.Progress) {
+	if vr.stream == nil {
+		return
+	}
+	if !vr.streamed {
+		vr.stream.Header().Set("Content-Type", "application/json")
+		vr.stream.WriteHeader(http.StatusOK)
+		vr.streamed = true
+	}
+	if err := json.NewEncoder(vr.stream).Encode(ProgressUpdate{Progress: p}); err != nil {
+		clog.Debug("failed to write progress update: %v", err)
+		return
+	}
+	if err := http.NewResponseController(vr.stream).Flush(); err != nil {
+		clog.Debug("failed to flush progress update: %v", err)
+	}
 }
 
 // logAudit logs an audit event if the logger is configured.
@@ -209,14 +307,22 @@ func (s *Server) logAudit(logFn func() error) {
 	}
 }
 
-// executeAndLog runs a command and logs the completion event.
-func (s *Server) executeAndLog(w http.ResponseWriter, vr *validatedRequest, status, pattern string) {
+// executeAndLog runs a command, logs the completion event, and masks any
+// secrets in its output before responding.
+func (s *Server) executeAndLog(w http.ResponseWriter, vr *validatedRequest, status, pattern string, limits patterns.Limits) {
 	startTime := time.Now()
-	resp := s.executeCommand(vr.args, status, pattern)
+	resp := s.executeCommand(vr, status, pattern, limits)
 	s.logAudit(func() error {
-		return s.AuditLogger.LogComplete(vr.info.ProjectName, vr.info.CloisterName, vr.cmd, resp.ExitCode, time.Since(startTime))
+		return s.AuditLogger.LogComplete(vr.id, vr.info.ProjectName, vr.info.CloisterName, vr.cmd, resp.ExitCode, time.Since(startTime))
 	})
-	s.writeJSON(w, http.StatusOK, resp)
+	res, cut := s.Redactor.RedactCapped(limits.MaxOutputBytes, &resp.Stdout, &resp.Stderr)
+	resp.Truncated = resp.Truncated || cut
+	if res.Total() > 0 {
+		s.logAudit(func() error {
+			return s.AuditLogger.LogRedact(vr.id, vr.info.ProjectName, vr.info.CloisterName, vr.cmd, res.Total(), res.Detectors())
+		})
+	}
+	s.respond(w, vr, http.StatusOK, resp)
 }
 
 // handleRequest processes POST /request from cloister containers.
@@ -227,24 +333,88 @@ func (s *Server) handleRequest(w http.ResponseWriter, r *http.Request) {
 	if vr == nil {
 		return
 	}
+	vr.ctx, vr.span = tracing.Start(vr.ctx, "hostexec.request",
+		tracing.String("cloister.project", vr.info.ProjectName),
+		tracing.String("cloister.name", vr.info.CloisterName),
+		tracing.String("cloister.action", vr.action()))
+	defer vr.span.End()
+
+	if vr.actionName != "" {
+		s.handleAction(w, vr)
+		return
+	}
 
 	s.logAudit(func() error {
-		return s.AuditLogger.LogRequest(vr.info.ProjectName, vr.info.CloisterName, vr.cmd)
+		return s.AuditLogger.LogRequest(vr.id, vr.info.ProjectName, vr.info.CloisterName, vr.cmd)
 	})
 
 	matcher := s.lookupMatcher(vr.info.ProjectName)
 	if matcher == nil {
 		s.logAudit(func() error {
-			return s.AuditLogger.LogDeny(vr.info.ProjectName, vr.info.CloisterName, vr.cmd, "no approval patterns configured")
+			return s.AuditLogger.LogDeny(vr.id, vr.info.ProjectName, vr.info.CloisterName, vr.cmd, "no approval patterns configured")
 		})
-		s.writeJSON(w, http.StatusOK, CommandResponse{Status: "denied", Reason: "no approval patterns configured"})
+		s.respond(w, vr, http.StatusOK, CommandResponse{Status: "denied", Reason: "no approval patterns configured"})
 		return
 	}
 
+	_, span := tracing.Start(vr.ctx, "hostexec.match")
 	result := matcher.Match(vr.cmd)
+	span.SetAttributes(tracing.String("cloister.pattern", result.Pattern))
+	span.End()
 	s.dispatchByAction(w, vr, result)
 }
 
+// handleAction resolves a named action and dispatches it according to the
+// action's approval policy. Unknown actions are denied; invalid parameter
+// values are rejected as bad requests.
+func (s *Server) handleAction(w http.ResponseWriter, vr *validatedRequest) {
+	var resolver ActionResolver
+	if s.ActionLookup != nil {
+		resolver = s.ActionLookup(vr.info.ProjectName)
+	}
+	if resolver == nil {
+		s.denyAction(w, vr, fmt.Sprintf("%s %q", actions.ErrUnknownAction, vr.actionName))
+		return
+	}
+
+	inv, err := resolver.Resolve(vr.actionName, vr.actionParams, vr.info.WorktreePath)
+	if errors.Is(err, actions.ErrUnknownAction) {
+		s.denyAction(w, vr, err.Error())
+		return
+	}
+	vr.actionKnown = true
+	if err != nil {
+		s.respond(w, vr, http.StatusBadRequest, CommandResponse{Status: "error", Reason: "invalid action parameters: " + err.Error()})
+		return
+	}
+
+	vr.invocation = inv
+	vr.args = inv.Argv
+	vr.cmd = inv.Display()
+	vr.workdir = inv.Workdir
+
+	s.logAudit(func() error {
+		return s.AuditLogger.LogRequest(vr.id, vr.info.ProjectName, vr.info.CloisterName, vr.cmd)
+	})
+	s.dispatchByAction(w, vr, patterns.MatchResult{
+		Action:  inv.Approval,
+		Pattern: actions.PatternName(inv.Name),
+		Limits:  inv.Limits,
+	})
+}
+
+// denyAction logs and denies an action request that could not be resolved.
+func (s *Server) denyAction(w http.ResponseWriter, vr *validatedRequest, reason string) {
+	cmd := actions.PatternName(vr.actionName)
+	s.logAudit(func() error {
+		return s.AuditLogger.LogRequest(vr.id, vr.info.ProjectName, vr.info.CloisterName, cmd)
+	})
+	s.logAudit(func() error {
+		return s.AuditLogger.LogDeny(vr.id, vr.info.ProjectName, vr.info.CloisterName, cmd, reason)
+	})
+	s.respond(w, vr, http.StatusOK, CommandResponse{Status: "denied", Reason: reason})
+}
+
 // lookupMatcher returns the pattern matcher for a project, or nil.
 func (s *Server) lookupMatcher(projectName string) PatternMatcher {
 	if s.PatternLookup == nil {
@@ -255,74 +425,129 @@ func (s *Server) lookupMatcher(projectName string) PatternMatcher {
 
 // dispatchByAction handles the command based on the pattern match result.
 func (s *Server) dispatchByAction(w http.ResponseWriter, vr *validatedRequest, result patterns.MatchResult) {
+	vr.span.SetAttributes(tracing.String("cloister.pattern", result.Pattern))
 	switch result.Action {
 	case patterns.AutoApprove:
 		s.logAudit(func() error {
-			return s.AuditLogger.LogAutoApprove(vr.info.ProjectName, vr.info.CloisterName, vr.cmd, result.Pattern)
+			return s.AuditLogger.LogAutoApprove(vr.id, vr.info.ProjectName, vr.info.CloisterName, vr.cmd, result.Pattern)
 		})
-		s.executeAndLog(w, vr, "auto_approved", result.Pattern)
+		s.executeAndLog(w, vr, "auto_approved", result.Pattern, result.Limits)
 
 	case patterns.ManualApprove:
-		s.handleManualApprove(w, vr)
+		s.handleManualApprove(w, vr, result)
 
 	case patterns.Deny:
 		s.logAudit(func() error {
-			return s.AuditLogger.LogDeny(vr.info.ProjectName, vr.info.CloisterName, vr.cmd, "command does not match any approval pattern")
+			return s.AuditLogger.LogDeny(vr.id, vr.info.ProjectName, vr.info.CloisterName, vr.cmd, "command does not match any approval pattern")
 		})
-		s.writeJSON(w, http.StatusOK, CommandResponse{Status: "denied", Reason: "command does not match any approval pattern"})
+		s.respond(w, vr, http.StatusOK, CommandResponse{Status: "denied", Reason: "command does not match any approval pattern"})
 
 	default:
-		s.writeJSON(w, http.StatusInternalServerError, CommandResponse{Status: "error", Reason: "internal error: unknown pattern action"})
+		s.respond(w, vr, http.StatusInternalServerError, CommandResponse{Status: "error", Reason: "internal error: unknown pattern action"})
 	}
 }
 
 // handleManualApprove queues a request for human approval and blocks until resolved.
-func (s *Server) handleManualApprove(w http.ResponseWriter, vr *validatedRequest) {
+// The limits from the matched manual_approve pattern apply if the command is approved.
+func (s *Server) handleManualApprove(w http.ResponseWriter, vr *validatedRequest, result patterns.MatchResult) {
 	if s.Queue == nil {
 		s.logAudit(func() error {
-			return s.AuditLogger.LogDeny(vr.info.ProjectName, vr.info.CloisterName, vr.cmd, "manual approval required but approval queue not configured")
+			return s.AuditLogger.LogDeny(vr.id, vr.info.ProjectName, vr.info.CloisterName, vr.cmd, "manual approval required but approval queue not configured")
 		})
-		s.writeJSON(w, http.StatusOK, CommandResponse{Status: "denied", Reason: "manual approval required but approval queue not configured"})
+		s.respond(w, vr, http.StatusOK, CommandResponse{Status: "denied", Reason: "manual approval required but approval queue not configured"})
 		return
 	}
 
 	respChan := make(chan approval.Response, 1)
-	pendingReq := &approval.PendingRequest{
-		Cloister:  vr.info.CloisterName,
-		Project:   vr.info.ProjectName,
-		Cmd:       vr.cmd,
-		Timestamp: time.Now(),
-		Response:  respChan,
+	id, err := s.Queue.Add(s.newPendingRequest(vr, result, respChan))
+	if err != nil {
+		s.respond(w, vr, http.StatusInternalServerError, CommandResponse{Status: "error", Reason: "failed to queue request for approval"})
+		return
 	}
 
-	if _, err := s.Queue.Add(pendingReq); err != nil {
-		s.writeJSON(w, http.StatusInternalServerError, CommandResponse{Status: "error", Reason: "failed to queue request for approval"})
+	var approvalResp approval.Response
+	_, span := tracing.Start(vr.ctx, "hostexec.approval")
+	defer span.End()
+	select {
+	case approvalResp = <-respChan:
+		span.SetAttributes(tracing.String("cloister.decision", approvalResp.Status))
+		span.End()
+	case <-vr.ctx.Done():
+		// The requester disconnected; withdraw the request from the UI.
+		if s.Queue.Cancel(id) {
+			s.logAudit(func() error {
+				return s.AuditLogger.LogDeny(vr.id, vr.info.ProjectName, vr.info.CloisterName, vr.cmd, "requester disconnected before approval")
+			})
+		}
+		s.recordOutcome(vr, "canceled")
 		return
 	}
 
-	approvalResp := <-respChan
-
 	if approvalResp.Status == "approved" {
-		s.executeAndLog(w, vr, "approved", "")
+		s.executeAndLog(w, vr, "approved", "", result.Limits)
 		return
 	}
 
 	if approvalResp.Status == "timeout" {
 		s.logAudit(func() error {
-			return s.AuditLogger.LogTimeout(vr.info.ProjectName, vr.info.CloisterName, vr.cmd)
+			return s.AuditLogger.LogTimeout(vr.id, vr.info.ProjectName, vr.info.CloisterName, vr.cmd)
 		})
 	}
 
-	s.writeJSON(w, http.StatusOK, CommandResponse{
+	s.respond(w, vr, http.StatusOK, CommandResponse{
 		Status:   approvalResp.Status,
 		Pattern:  approvalResp.Pattern,
 		Reason:   approvalResp.Reason,
+		Message:  approvalResp.Message,
 		ExitCode: approvalResp.ExitCode,
 		Stdout:   approvalResp.Stdout,
 		Stderr:   approvalResp.Stderr,
 	})
 }
 
+// respond records the outcome of a validated request and writes its
+// response.
+func (s *Server) respond(w http.ResponseWriter, vr *validatedRequest, status int, resp CommandResponse) {
+	s.recordOutcome(vr, resp.Status)
+	if vr.streamed {
+		// The status line went out with the first progress update
+		if err := json.NewEncoder(w).Encode(resp); err != nil {
+			clog.Warn("failed to encode JSON response: %v", err)
+		}
+		return
+	}
+	s.writeJSON(w, status, resp)
+}
+
+// recordOutcome records a finished request on its span and counts it in the
+// metrics, if configured.
+func (s *Server) recordOutcome(vr *validatedRequest, outcome string) {
+	vr.span.SetAttributes(tracing.String("cloister.decision", outcome))
+	if s.Metrics == nil {
+		return
+	}
+	s.Metrics.HostexecRequest(vr.info, vr.metricsAction(), outcome)
+}
+
+// metricsAction returns the action label for metrics. Action names come
+// from the container, so only configured ones are used as labels; the rest
+// are counted as "unknown" so that they cannot add series without bound.
+func (vr *validatedRequest) metricsAction() string {
+	if vr.actionName != "" && !vr.actionKnown {
+		return "unknown"
+	}
+	return vr.action()
+}
+
+// action returns the name of the requested action, or "command" for a
+// plain command.
+func (vr *validatedRequest) action() string {
+	if vr.actionName == "" {
+		return "command"
+	}
+	return vr.actionName
+}
+
 // writeJSON writes a JSON response with the given status code.
 func (s *Server) writeJSON(w http.ResponseWriter, status int, v any) {
 	w.Header().Set("Content-Type", "application/json")
@@ -333,10 +558,11 @@ func (s *Server) writeJSON(w http.ResponseWriter, status int, v any) {
 }
 
 // executeCommand runs the command through the executor and returns a CommandResponse.
-// The args parameter is the tokenized argument array (args[0] is the command).
+// The request's args are the tokenized argument array (args[0] is the command).
 // The status parameter is used for the response status (e.g., "approved" or "auto_approved").
 // The pattern parameter is included in the response for auto_approved commands.
-func (s *Server) executeCommand(args []string, status, pattern string) CommandResponse {
+// The limits parameter carries the matched pattern's timeout, output cap, and env policy.
+func (s *Server) executeCommand(vr *validatedRequest, status, pattern string, limits patterns.Limits) CommandResponse {
 	if s.CommandExecutor == nil {
 		return CommandResponse{
 			Status: "error",
@@ -344,6 +570,7 @@ func (s *Server) executeCommand(args []string, status, pattern string) CommandRe
 		}
 	}
 
+	args := vr.args
 	if len(args) == 0 {
 		return CommandResponse{
 			Status: "error",
@@ -355,12 +582,26 @@ func (s *Server) executeCommand(args []string, status, pattern string) CommandRe
 	// args[0] is the command, args[1:] are the arguments
 	// Using pre-tokenized args prevents shell injection
 	execReq := executor.ExecuteRequest{
-		Command: args[0],
-		Args:    args[1:],
-		// Workdir, Env, and TimeoutMs can be extended later
+		Cloister:       vr.info.CloisterName,
+		Command:        args[0],
+		Args:           args[1:],
+		Workdir:        vr.workdir,
+		Env:            limits.Env,
+		EnvAllow:       limits.EnvAllow,
+		EnvDeny:        limits.EnvDeny,
+		TimeoutMs:      int(limits.Timeout.Milliseconds()),
+		MaxOutputBytes: outputCap(limits.MaxOutputBytes),
 	}
 
-	execResp, err := s.CommandExecutor.Execute(execReq)
+	queuePosition := 0
+	onProgress, done := s.trackExecution(vr, func(p executor.Progress) {
+		if p.State == executor.ProgressQueued {
+			queuePosition = max(queuePosition, p.Position)
+		}
+		s.writeProgress(vr, p)
+	})
+	defer done()
+	execResp, err := s.CommandExecutor.Execute(vr.ctx, execReq, onProgress)
 	if err != nil {
 		return CommandResponse{
 			Status: "error",
@@ -369,7 +610,38 @@ func (s *Server) executeCommand(args []string, status, pattern string) CommandRe
 	}
 
 	// Map executor response to command response
-	return mapExecutorResponse(execResp, status, pattern)
+	resp := mapExecutorResponse(execResp, status, pattern)
+	resp.QueuePosition = queuePosition
+	return resp
+}
+
+// outputCap returns the per-stream cap to ask the executor for. Output is
+// redacted before it is cut to limit, so the executor keeps redact.Lookahead
+// bytes more.
+func outputCap(limit int64) int64 {
+	if limit <= 0 {
+		return limit
+	}
+	return limit + redact.Lookahead
+}
+
+// trackExecution registers the command with the execution tracker, if any.
+// It returns a progress callback that updates the tracker and then calls
+// next, and a done function that removes the tracker entry.
+func (s *Server) trackExecution(vr *validatedRequest, next func(executor.Progress)) (onProgress func(executor.Progress), done func()) {
+	if s.Executions == nil {
+		return next, func() {}
+	}
+	id, err := s.Executions.Begin(vr.info.CloisterName, vr.info.ProjectName, vr.cmd)
+	if err != nil {
+		clog.Warn("failed to track execution: %v", err)
+		return next, func() {}
+	}
+	onProgress = func(p executor.Progress) {
+		s.Executions.Update(id, p.State, p.Position)
+		next(p)
+	}
+	return onProgress, func() { s.Executions.End(id) }
 }
 
 // mapExecutorResponse converts an executor.ExecuteResponse to a CommandResponse.
@@ -377,10 +649,11 @@ func (s *Server) executeCommand(args []string, status, pattern string) CommandRe
 // The pattern is included for auto_approved commands.
 func mapExecutorResponse(execResp *executor.ExecuteResponse, status, pattern string) CommandResponse {
 	resp := CommandResponse{
-		Pattern:  pattern,
-		ExitCode: execResp.ExitCode,
-		Stdout:   execResp.Stdout,
-		Stderr:   execResp.Stderr,
+		Pattern:   pattern,
+		ExitCode:  execResp.ExitCode,
+		Stdout:    execResp.Stdout,
+		Stderr:    execResp.Stderr,
+		Truncated: execResp.Truncated,
 	}
 
 	// Map executor status to command response status
@@ -2,6 +2,8 @@
 // between cloister containers and the guardian request server.
 package request
 
+import "github.com/xdg/cloister/internal/executor"
+
 // CommandRequest represents a command execution request from a cloister container.
 type CommandRequest struct {
 	// Cmd is DEPRECATED and ignored. The canonical command string is now
@@ -14,6 +16,24 @@ type CommandRequest struct {
 	// Using a pre-tokenized array prevents shell injection attacks.
 	// The guardian reconstructs the canonical command string from Args.
 	Args []string `json:"args"`
+
+	// Action names a configured hostexec action to run instead of Args.
+	// Exactly one of Action or Args must be set.
+	Action string `json:"action,omitempty"`
+
+	// Params supplies parameter values for Action.
+	Params map[string]string `json:"params,omitempty"`
+
+	// Progress asks for the response to be streamed as newline-delimited
+	// JSON: a ProgressUpdate line whenever the host executor queues or
+	// starts the command, then the CommandResponse.
+	Progress bool `json:"progress,omitempty"`
+}
+
+// ProgressUpdate is an interim line of a streamed response, reporting the
+// command's place in the host executor's queue or that it has started.
+type ProgressUpdate struct {
+	Progress executor.Progress `json:"progress"`
 }
 
 // CommandResponse represents the result of a command execution request.
@@ -30,6 +50,10 @@ type CommandResponse struct {
 	// Only set when Status is "denied", "timeout", or "error".
 	Reason string `json:"reason,omitempty"`
 
+	// Message is the approver's free-text feedback, delivered verbatim.
+	// Only set when a human denied the request and wrote a message.
+	Message string `json:"message,omitempty"`
+
 	// ExitCode is the command's exit code.
 	// Only set when Status is "approved" or "auto_approved".
 	// Not omitempty: exit code 0 must be serialized so hostexec can
@@ -43,4 +67,12 @@ type CommandResponse struct {
 	// Stderr is the command's standard error output.
 	// Only set when Status is "approved" or "auto_approved".
 	Stderr string `json:"stderr,omitempty"`
+
+	// Truncated indicates that Stdout or Stderr was cut off at the
+	// matched pattern's max_output_bytes limit.
+	Truncated bool `json:"truncated,omitempty"`
+
+	// QueuePosition is the command's position when it was first queued by
+	// the host executor's concurrency limit. Zero means it ran immediately.
+	QueuePosition int `json:"queue_position,omitempty"`
 }
@@ -0,0 +1,133 @@
+// Package risk flags dangerous arguments in hostexec commands.
+//
+// A manual_approve pattern like "^git push.*$" admits both routine pushes
+// and force pushes. The approval UI shows each argument of a pending
+// command separately and highlights the ones a rule marks as risky, so the
+// approver's eye lands on "--force" or "-v /:/host" before clicking Approve.
+// Rules only affect presentation; they never approve or deny anything.
+package risk
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/xdg/cloister/internal/clog"
+	"github.com/xdg/cloister/internal/config"
+)
+
+// builtinRules flag arguments that commonly turn a routine host command into
+// a destructive or privilege-escalating one.
+var builtinRules = []config.RiskRule{
+	{Name: "force_push", Command: `^git\s+push\b`, Token: `^(?:-f|--force|--force-with-lease(?:=.*)?|\+.+)$`},
+	{Name: "sudo", Token: `^(?:sudo|doas)$`},
+	{
+		Name:    "root_mount",
+		Command: `^(?:docker|podman)\s`,
+		Token:   `^(?:(?:-v|--volume)=?)?/:|(?:^|,)(?:src|source)=/(?:,|$)`,
+	},
+	{
+		Name:    "privileged",
+		Command: `^(?:docker|podman)\s`,
+		Token:   `^--privileged$|^--(?:pid|network|net|ipc|userns|uts)=host$`,
+	},
+	{Name: "recursive_rm", Command: `^rm\s`, Token: `^-(?:-recursive|-force|[a-zA-Z]*[rRf][a-zA-Z]*)$`},
+}
+
+// rule is a compiled risk rule.
+type rule struct {
+	name    string
+	command *regexp.Regexp // nil matches any command
+	token   *regexp.Regexp
+}
+
+// Arg is one argument of a command and the names of the rules that flag it.
+type Arg struct {
+	Value string   `json:"value"`
+	Risks []string `json:"risks,omitempty"`
+}
+
+// Rules highlights risky arguments.
+type Rules struct {
+	rules []rule
+}
+
+// New creates Rules from config. Built-in rules come first unless
+// cfg.DisableBuiltin is set; custom rules follow. Invalid custom rules are
+// logged and skipped, not fatal.
+func New(cfg config.RiskConfig) *Rules {
+	r := &Rules{}
+	if !cfg.DisableBuiltin {
+		for _, b := range builtinRules {
+			r.rules = append(r.rules, mustCompile(b))
+		}
+	}
+	for _, c := range cfg.Rules {
+		rl, err := compile(c)
+		if err != nil {
+			clog.Warn("invalid risk rule %q: %v (skipped)", c.Name, err)
+			continue
+		}
+		r.rules = append(r.rules, rl)
+	}
+	return r
+}
+
+// compile builds a rule from its definition.
+func compile(c config.RiskRule) (rule, error) {
+	rl := rule{name: c.Name}
+	if c.Command != "" {
+		re, err := regexp.Compile(c.Command)
+		if err != nil {
+			return rule{}, fmt.Errorf("invalid command regex: %w", err)
+		}
+		rl.command = re
+	}
+	re, err := regexp.Compile(c.Token)
+	if err != nil {
+		return rule{}, fmt.Errorf("invalid token regex: %w", err)
+	}
+	rl.token = re
+	return rl, nil
+}
+
+// mustCompile builds a built-in rule, panicking if it is invalid.
+func mustCompile(c config.RiskRule) rule {
+	rl, err := compile(c)
+	if err != nil {
+		panic(fmt.Sprintf("risk: built-in rule %q: %v", c.Name, err))
+	}
+	return rl
+}
+
+// Classify splits argv into Args, flagging each one matched by a rule that
+// applies to cmd, the canonical command line. A nil Rules flags nothing.
+func (r *Rules) Classify(cmd string, argv []string) []Arg {
+	args := make([]Arg, len(argv))
+	for i, v := range argv {
+		args[i].Value = v
+	}
+	if r == nil {
+		return args
+	}
+	for _, rl := range r.rules {
+		if rl.command != nil && !rl.command.MatchString(cmd) {
+			continue
+		}
+		for i := range args {
+			if rl.token.MatchString(args[i].Value) {
+				args[i].Risks = append(args[i].Risks, rl.name)
+			}
+		}
+	}
+	return args
+}
+
+// Risky reports whether any of args is flagged.
+func Risky(args []Arg) bool {
+	for _, a := range args {
+		if len(a.Risks) > 0 {
+			return true
+		}
+	}
+	return false
+}
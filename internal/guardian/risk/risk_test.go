@@ -0,0 +1,107 @@
+package risk
+
+import (
+	"bytes"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/xdg/cloister/internal/clog"
+	"github.com/xdg/cloister/internal/config"
+)
+
+// flagged returns the values of the args flagged by rule name.
+func flagged(args []Arg, name string) []string {
+	var out []string
+	for _, a := range args {
+		if slices.Contains(a.Risks, name) {
+			out = append(out, a.Value)
+		}
+	}
+	return out
+}
+
+func TestClassify_Builtin(t *testing.T) {
+	tests := []struct {
+		name string
+		argv []string
+		rule string
+		want []string
+	}{
+		{"force push", []string{"git", "push", "--force", "origin", "main"}, "force_push", []string{"--force"}},
+		{"force push short", []string{"git", "push", "-f"}, "force_push", []string{"-f"}},
+		{"force refspec", []string{"git", "push", "origin", "+main"}, "force_push", []string{"+main"}},
+		{"lease", []string{"git", "push", "--force-with-lease=main"}, "force_push", []string{"--force-with-lease=main"}},
+		{"plain push", []string{"git", "push", "origin", "main"}, "force_push", nil},
+		{"fetch -f not flagged", []string{"git", "fetch", "-f"}, "force_push", nil},
+		{"sudo", []string{"sudo", "make", "install"}, "sudo", []string{"sudo"}},
+		{"root volume", []string{"docker", "run", "-v", "/:/host", "alpine"}, "root_mount", []string{"/:/host"}},
+		{"root volume joined", []string{"docker", "run", "--volume=/:/host:ro", "alpine"}, "root_mount", []string{"--volume=/:/host:ro"}},
+		{"root mount", []string{"podman", "run", "--mount", "type=bind,source=/,target=/h", "x"}, "root_mount", []string{"type=bind,source=/,target=/h"}},
+		{"project volume", []string{"docker", "run", "-v", "/src:/src", "alpine"}, "root_mount", nil},
+		{"privileged", []string{"docker", "run", "--privileged", "--pid=host", "x"}, "privileged", []string{"--privileged", "--pid=host"}},
+		{"rm -rf", []string{"rm", "-rf", "build"}, "recursive_rm", []string{"-rf"}},
+		{"rm single file", []string{"rm", "out.txt"}, "recursive_rm", nil},
+	}
+
+	r := New(config.RiskConfig{})
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args := r.Classify(strings.Join(tt.argv, " "), tt.argv)
+			if len(args) != len(tt.argv) {
+				t.Fatalf("got %d args, want %d", len(args), len(tt.argv))
+			}
+			if got := flagged(args, tt.rule); !slices.Equal(got, tt.want) {
+				t.Errorf("flagged by %s = %q, want %q", tt.rule, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClassify_Custom(t *testing.T) {
+	r := New(config.RiskConfig{
+		DisableBuiltin: true,
+		Rules: []config.RiskRule{
+			{Name: "prod", Token: `^--env=prod$`},
+			{Name: "hard_reset", Command: `^git reset\b`, Token: `^--hard$`},
+		},
+	})
+
+	args := r.Classify("git reset --hard --env=prod", []string{"git", "reset", "--hard", "--env=prod"})
+	if got := flagged(args, "hard_reset"); !slices.Equal(got, []string{"--hard"}) {
+		t.Errorf("hard_reset flagged %q", got)
+	}
+	if got := flagged(args, "prod"); !slices.Equal(got, []string{"--env=prod"}) {
+		t.Errorf("prod flagged %q", got)
+	}
+
+	args = r.Classify("sudo git push -f", []string{"sudo", "git", "push", "-f"})
+	if Risky(args) {
+		t.Errorf("built-in rules applied despite disable_builtin: %+v", args)
+	}
+}
+
+func TestNew_InvalidCustomRuleSkipped(t *testing.T) {
+	var buf bytes.Buffer
+	old := clog.ReplaceGlobal(clog.TestLogger(&buf))
+	defer clog.ReplaceGlobal(old)
+
+	r := New(config.RiskConfig{
+		DisableBuiltin: true,
+		Rules:          []config.RiskRule{{Name: "bad", Token: "("}, {Name: "ok", Token: "^x$"}},
+	})
+	if !Risky(r.Classify("x", []string{"x"})) {
+		t.Error("valid rule after an invalid one was not applied")
+	}
+	if !strings.Contains(buf.String(), `invalid risk rule "bad"`) {
+		t.Errorf("expected warning, got %q", buf.String())
+	}
+}
+
+func TestClassify_NilRules(t *testing.T) {
+	var r *Rules
+	args := r.Classify("sudo ls", []string{"sudo", "ls"})
+	if len(args) != 2 || args[0].Value != "sudo" || Risky(args) {
+		t.Errorf("nil Rules Classify = %+v", args)
+	}
+}
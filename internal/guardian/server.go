@@ -2,7 +2,11 @@ package guardian
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"io"
+	"net"
+	"net/url"
 	"os"
 	"os/signal"
 	"path/filepath"
@@ -14,12 +18,18 @@ import (
 	"github.com/xdg/cloister/internal/audit"
 	"github.com/xdg/cloister/internal/clog"
 	"github.com/xdg/cloister/internal/config"
+	"github.com/xdg/cloister/internal/guardian/actions"
 	guardianexec "github.com/xdg/cloister/internal/guardian/executor"
+	"github.com/xdg/cloister/internal/guardian/notify"
 	"github.com/xdg/cloister/internal/guardian/patterns"
+	"github.com/xdg/cloister/internal/guardian/redact"
 	"github.com/xdg/cloister/internal/guardian/request"
+	"github.com/xdg/cloister/internal/guardian/risk"
 
 	"github.com/xdg/cloister/internal/guardian/approval"
+	"github.com/xdg/cloister/internal/pathutil"
 	"github.com/xdg/cloister/internal/token"
+	"github.com/xdg/cloister/internal/tracing"
 )
 
 // stoppable is a server that can be started and stopped.
@@ -41,7 +51,16 @@ type Server struct {
 	cfg            *config.GlobalConfig
 	policyEngine   *PolicyEngine
 	patternCache   *PatternCache
+	actionCache    *ActionCache
+	domainTimeouts *TimeoutCache
 	auditLogger    *audit.Logger
+	history        *audit.History
+	logStream      *audit.Stream
+	cloisterLogs   *audit.CloisterLogs
+	sessions       *Sessions
+	metrics        *Metrics
+	health         *Health
+	tracer         *tracing.Tracer // nil unless tracing is configured
 	proxy          stoppable
 	api            stoppable
 	reqServer      stoppable
@@ -62,17 +81,35 @@ func NewServer(registry *token.Registry, cfg *config.GlobalConfig, decisions *co
 	patternLookup := func(projectName string) request.PatternMatcher {
 		return s.patternCache.GetProject(projectName)
 	}
+	s.actionCache = s.setupActionCache()
+	s.domainTimeouts = NewTimeoutCache(projectDomainTimeout)
+	actionLookup := func(projectName string) request.ActionResolver {
+		return s.actionCache.GetProject(projectName)
+	}
 
 	apiAddr := fmt.Sprintf(":%d", DefaultAPIPort)
 	api := NewAPIServer(apiAddr, s.registry)
 
-	s.auditLogger = setupAuditLogger(s.cfg)
+	s.history = audit.NewHistory(audit.DefaultHistorySize)
+	s.logStream = audit.NewStream(audit.DefaultStreamBacklog)
+	s.auditLogger = setupAuditLogger(s.cfg, s.history, s.logStream)
+	s.cloisterLogs = setupCloisterLogs(s.cfg)
+	if s.cloisterLogs != nil {
+		s.auditLogger.AddObserver(s.cloisterLogs)
+	}
+	s.sessions = setupSessions(s.registry)
+	s.auditLogger.AddObserver(s.sessions)
+	s.metrics = NewMetrics()
+	s.auditLogger.AddObserver(s.metrics)
+	s.metrics.WatchTokens(s.registry.Count)
+	s.health = NewHealth()
+	s.tracer = setupTracer(s.cfg)
 
 	requestTokenLookup := func(tok string) (token.Info, bool) {
 		return s.registry.Lookup(tok)
 	}
 
-	approvalQueue := approval.NewQueue()
+	approvalQueue := approval.NewQueueWithTimeout(hostexecApprovalTimeout(s.cfg))
 	dar := s.setupDomainApproval()
 
 	configPersister := &PolicyConfigPersister{Recorder: s.policyEngine}
@@ -80,20 +117,21 @@ func NewServer(registry *token.Registry, cfg *config.GlobalConfig, decisions *co
 	proxy.DomainApprover = dar.Approver
 	proxy.OnReload = func() {
 		s.patternCache.Clear()
+		s.actionCache.Clear()
+		s.domainTimeouts.Clear()
 	}
-	proxy.OnTokenReload = func() {
-		store, err := token.NewStore(ContainerTokenDir)
-		if err != nil {
-			clog.Warn("SIGHUP token reload: failed to open token store: %v", err)
-			return
-		}
-		if err := token.ReconcileWithStore(s.registry, store); err != nil {
-			clog.Warn("SIGHUP token reload: %v", err)
-			return
-		}
-		clog.Info("SIGHUP token registry reconciled with disk")
-	}
+	proxy.OnTokenReload = s.reloadTokens
+	proxy.Traffic = s.sessions
+	proxy.Metrics = s.metrics
+	proxy.Health = s.health
 	api.TokenRevoker = s.policyEngine
+	api.Sessions = s.sessions
+	api.Metrics = s.metrics.Handler()
+	api.Health = s.health
+	api.AuditPublicKey = s.auditLogger.PublicKey()
+	api.OnTokenRevoked = func(info token.Info) {
+		s.cloisterLogs.Close(info.CloisterName)
+	}
 	api.OnTokenRegistered = func(projectName string) {
 		if err := s.policyEngine.EnsureProject(projectName); err != nil {
 			clog.Warn("failed to load project policy on token register: %v", err)
@@ -101,26 +139,93 @@ func NewServer(registry *token.Registry, cfg *config.GlobalConfig, decisions *co
 	}
 
 	execClient := setupExecutorClient()
+	executorCheck := executorHealthCheck(execClient)
+	if execClient != nil {
+		execClient = &instrumentedExecutor{next: execClient, metrics: s.metrics}
+	}
 
 	reqServer := request.NewServer(requestTokenLookup, patternLookup, execClient, s.auditLogger)
 	reqServer.Queue = approvalQueue
+	reqServer.ActionLookup = actionLookup
+	reqServer.Redactor = redact.New(s.cfg.Hostexec.Redact)
+	reqServer.Executions = approval.NewExecutionTracker()
+	reqServer.History = s.history
+	reqServer.Risk = risk.New(s.cfg.Hostexec.Risk)
+	reqServer.Metrics = s.metrics
+
+	s.metrics.WatchQueue(audit.DecisionHostexec, approvalQueue.Len)
+	if dar.DomainQueue != nil {
+		s.metrics.WatchQueue(audit.DecisionDomain, dar.DomainQueue.Len)
+	}
 
-	approvalServer := approval.NewServer(approvalQueue, s.auditLogger)
-	approvalServer.SetDomainQueue(dar.DomainQueue)
+	approvalServer, err := s.setupApprovalServer(approvalQueue, dar.DomainQueue, reqServer.Executions)
+	if err != nil {
+		return nil, err
+	}
 	approvalServer.ConfigPersister = configPersister
 
 	if dar.DomainQueue != nil && s.auditLogger != nil {
 		dar.DomainQueue.SetAuditLogger(s.auditLogger)
 	}
 
+	if notifier := s.setupNotifier(execClient); notifier != nil {
+		approvalQueue.SetNotifier(notifier)
+		if dar.DomainQueue != nil {
+			dar.DomainQueue.SetNotifier(notifier)
+		}
+	}
+
 	s.proxy = proxy
 	s.api = api
 	s.reqServer = reqServer
 	s.approvalServer = approvalServer
 
+	s.health.Add("proxy", true, listeningCheck(proxy))
+	s.health.Add("api", true, listeningCheck(api))
+	s.health.Add("request", true, listeningCheck(reqServer))
+	s.health.Add("approval", true, listeningCheck(approvalServer))
+	s.health.Add("executor", false, executorCheck)
+	s.health.Add("audit", false, s.auditHealthCheck)
+	s.health.Add("events", false, func(context.Context) (string, string) {
+		return HealthOK, fmt.Sprintf("%d approval UI client(s), %d log stream(s)",
+			approvalServer.Events.ClientCount(), s.logStream.SubscriberCount())
+	})
+
 	return s, nil
 }
 
+// reloadTokens reconciles the token registry with the on-disk token store.
+// It is invoked on SIGHUP.
+func (s *Server) reloadTokens() {
+	store, err := token.NewStore(ContainerTokenDir)
+	if err != nil {
+		s.metrics.reload(reloadTokens, err)
+		s.health.reload(reloadTokens, err)
+		clog.Warn("SIGHUP token reload: failed to open token store: %v", err)
+		return
+	}
+	before := s.registry.List()
+	err = token.ReconcileWithStore(s.registry, store)
+	s.metrics.reload(reloadTokens, err)
+	s.health.reload(reloadTokens, err)
+	if err != nil {
+		clog.Warn("SIGHUP token reload: %v", err)
+		return
+	}
+	for tok, info := range before {
+		if _, ok := s.registry.Lookup(tok); !ok {
+			s.cloisterLogs.Close(info.CloisterName)
+			s.sessions.End(info)
+		}
+	}
+	for tok, info := range s.registry.List() {
+		if _, ok := before[tok]; !ok {
+			s.sessions.Start(info)
+		}
+	}
+	clog.Info("SIGHUP token registry reconciled with disk")
+}
+
 // Run starts all servers, blocks on a shutdown signal, then shuts down.
 func (s *Server) Run() error {
 	if err := s.startAllServers(); err != nil {
@@ -206,7 +311,8 @@ func (s *Server) setupProxyServer() *ProxyServer {
 func (s *Server) setupPatternCache() *PatternCache {
 	autoApprovePatterns := extractPatterns(s.cfg.Hostexec.AutoApprove)
 	manualApprovePatterns := extractPatterns(s.cfg.Hostexec.ManualApprove)
-	regexMatcher := patterns.NewRegexMatcher(autoApprovePatterns, manualApprovePatterns)
+	regexMatcher := patterns.NewRegexMatcherWithLimits(autoApprovePatterns, manualApprovePatterns,
+		extractLimits(s.cfg.Hostexec.AutoApprove), extractLimits(s.cfg.Hostexec.ManualApprove))
 	clog.Info("loaded approval patterns: %d auto-approve, %d manual-approve",
 		len(autoApprovePatterns), len(manualApprovePatterns))
 
@@ -218,12 +324,17 @@ func (s *Server) setupPatternCache() *PatternCache {
 			clog.Warn("failed to load project config for patterns %s: %v", projectName, err)
 			return nil
 		}
-		if len(projectCfg.Hostexec.AutoApprove) == 0 && len(projectCfg.Hostexec.ManualApprove) == 0 {
+		if len(projectCfg.Hostexec.AutoApprove) == 0 && len(projectCfg.Hostexec.ManualApprove) == 0 &&
+			projectCfg.Hostexec.ApprovalTimeout == "" {
 			return nil
 		}
 		mergedAuto := config.MergeCommandPatterns(cfg.Hostexec.AutoApprove, projectCfg.Hostexec.AutoApprove)
 		mergedManual := config.MergeCommandPatterns(cfg.Hostexec.ManualApprove, projectCfg.Hostexec.ManualApprove)
-		matcher := patterns.NewRegexMatcher(extractPatterns(mergedAuto), extractPatterns(mergedManual))
+		autoLimits, manualLimits := extractLimits(mergedAuto), extractLimits(mergedManual)
+		applyApprovalTimeout(autoLimits, projectCfg.Hostexec.ApprovalTimeout)
+		applyApprovalTimeout(manualLimits, projectCfg.Hostexec.ApprovalTimeout)
+		matcher := patterns.NewRegexMatcherWithLimits(extractPatterns(mergedAuto), extractPatterns(mergedManual),
+			autoLimits, manualLimits)
 		clog.Info("loaded command patterns for project %s (%d auto-approve, %d manual-approve)",
 			projectName, len(mergedAuto), len(mergedManual))
 		return matcher
@@ -232,11 +343,51 @@ func (s *Server) setupPatternCache() *PatternCache {
 	return cache
 }
 
-// setupAuditLogger creates the audit logger if configured.
-func setupAuditLogger(cfg *config.GlobalConfig) *audit.Logger {
-	if cfg.Log.File == "" {
-		return nil
+// setupActionCache creates the action cache with global actions and a
+// project loader that merges project-level actions.
+func (s *Server) setupActionCache() *ActionCache {
+	globalSet := actions.NewSet(s.cfg.Hostexec.Actions)
+	if globalSet.Len() > 0 {
+		clog.Info("loaded %d hostexec actions", globalSet.Len())
 	}
+
+	cache := NewActionCache(globalSet)
+	cfg := s.cfg
+	cache.SetProjectLoader(func(projectName string) *actions.Set {
+		projectCfg, err := config.LoadProjectConfig(projectName)
+		if err != nil {
+			clog.Warn("failed to load project config for actions %s: %v", projectName, err)
+			return nil
+		}
+		if len(projectCfg.Hostexec.Actions) == 0 && projectCfg.Hostexec.ApprovalTimeout == "" {
+			return nil
+		}
+		merged := config.MergeHostexecActions(cfg.Hostexec.Actions, projectCfg.Hostexec.Actions)
+		merged = withApprovalTimeout(merged, projectCfg.Hostexec.ApprovalTimeout)
+		clog.Info("loaded hostexec actions for project %s (%d actions)", projectName, len(merged))
+		return actions.NewSet(merged)
+	})
+
+	return cache
+}
+
+// setupSessions creates the session tracker, with sessions for the tokens
+// already registered. Sessions of cloisters that outlived a guardian restart
+// are tracked from the restart.
+func setupSessions(registry *token.Registry) *Sessions {
+	sessions := NewSessions()
+	for _, info := range registry.List() {
+		sessions.Start(info)
+	}
+	return sessions
+}
+
+// setupAuditLogger creates the audit logger and attaches the in-memory
+// observers (decision history, live log stream) to it. Events are written to
+// the configured log file, if any, and recent events already in that file are
+// replayed to the observers first. Records are signed with the guardian's
+// audit key when it is available.
+func setupAuditLogger(cfg *config.GlobalConfig, observers ...audit.Observer) *audit.Logger {
 	auditLogPath := cfg.Log.File
 	if strings.HasPrefix(auditLogPath, "~/") {
 		home, err := os.UserHomeDir()
@@ -244,13 +395,125 @@ func setupAuditLogger(cfg *config.GlobalConfig) *audit.Logger {
 			auditLogPath = filepath.Join(home, auditLogPath[2:])
 		}
 	}
-	auditFile, err := clog.OpenLogFile(auditLogPath)
+
+	w := openAuditLog(auditLogPath, LogRotation(cfg), observers)
+	logger := audit.NewLogger(w)
+	logger.SetFormat(auditLogFormat(cfg))
+	if w != nil {
+		logger.SetChain(setupAuditChain(auditLogPath))
+	}
+	for _, o := range observers {
+		logger.AddObserver(o)
+	}
+	return logger
+}
+
+// setupAuditChain returns a chain that continues the audit log at path,
+// signed with the key in ContainerAuditKeyDir, or nil if there is no usable
+// key.
+func setupAuditChain(path string) *audit.Chain {
+	key, err := loadAuditKey(ContainerAuditKeyDir)
+	if err != nil {
+		clog.Warn("audit log records are not sealed: %v", err)
+		return nil
+	}
+	chain := audit.NewChain(key, audit.CheckpointPath(path))
+	if err := chain.Resume(path); err != nil {
+		clog.Warn("failed to resume audit chain, audit log records are not sealed: %v", err)
+		return nil
+	}
+	return chain
+}
+
+// openAuditLog opens the audit log file at auditLogPath for appending after
+// replaying its recent events to observers. The file is rotated according
+// to rotate. Returns nil if no file is configured or it cannot be opened.
+func openAuditLog(auditLogPath string, rotate clog.RotateConfig, observers []audit.Observer) io.Writer {
+	if auditLogPath == "" {
+		return nil
+	}
+	if err := audit.LoadFile(auditLogPath, observers...); err != nil {
+		clog.Warn("failed to load recent audit events from %s: %v", auditLogPath, err)
+	}
+	auditFile, err := clog.OpenRotatingFile(auditLogPath, rotate)
 	if err != nil {
 		clog.Warn("failed to open audit log file %s: %v", auditLogPath, err)
 		return nil
 	}
 	clog.Info("audit logging enabled: %s", auditLogPath)
-	return audit.NewLogger(auditFile)
+	return auditFile
+}
+
+// LogRotation returns the configured rotation for the guardian's log files.
+// Durations are validated when the config is loaded; invalid ones disable
+// their limit.
+func LogRotation(cfg *config.GlobalConfig) clog.RotateConfig {
+	r := cfg.Log.Rotate
+	maxAge, _ := time.ParseDuration(r.MaxAge)
+	keepFor, _ := time.ParseDuration(r.KeepFor)
+	return clog.RotateConfig{
+		MaxSize:  int64(r.MaxSizeMB) << 20,
+		MaxAge:   maxAge,
+		Compress: r.Compress,
+		Keep:     r.Keep,
+		KeepFor:  keepFor,
+	}
+}
+
+// auditLogFormat returns the configured audit log format.
+func auditLogFormat(cfg *config.GlobalConfig) audit.Format {
+	if cfg.Log.Format == "" {
+		return audit.FormatText
+	}
+	return audit.Format(cfg.Log.Format)
+}
+
+// setupTracer installs a tracer exporting to the configured OTLP collector,
+// or returns nil if tracing is not configured.
+func setupTracer(cfg *config.GlobalConfig) *tracing.Tracer {
+	if cfg.Tracing.Endpoint == "" {
+		return nil
+	}
+	endpoint := tracingEndpoint(cfg.Tracing.Endpoint)
+	t := tracing.NewTracer(tracing.Options{
+		Endpoint: endpoint,
+		Service:  "cloister-guardian",
+		Headers:  cfg.Tracing.Headers,
+	})
+	tracing.SetTracer(t)
+	clog.Info("exporting traces to %s", endpoint)
+	return t
+}
+
+// tracingEndpoint returns the collector endpoint as seen from the guardian
+// container: a collector on the host's loopback address is reached through
+// host.docker.internal.
+func tracingEndpoint(endpoint string) string {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return endpoint
+	}
+	switch u.Hostname() {
+	case "localhost", "127.0.0.1", "::1":
+		if port := u.Port(); port != "" {
+			u.Host = net.JoinHostPort(guardianexec.HostDockerInternal, port)
+		} else {
+			u.Host = guardianexec.HostDockerInternal
+		}
+	}
+	return u.String()
+}
+
+// setupCloisterLogs returns the per-cloister audit logs if log.per_cloister
+// is enabled, or nil. Unlike the unified log, these are not replayed into
+// the in-memory observers at startup.
+func setupCloisterLogs(cfg *config.GlobalConfig) *audit.CloisterLogs {
+	if !cfg.Log.PerCloister || cfg.Log.PerCloisterDir == "" {
+		return nil
+	}
+	dir := pathutil.ExpandHome(cfg.Log.PerCloisterDir)
+	clog.Info("per-cloister audit logging enabled: %s", dir)
+	return audit.NewCloisterLogs(dir, auditLogFormat(cfg), LogRotation(cfg))
 }
 
 // setupDomainApproval configures domain approval components if enabled.
@@ -280,6 +543,8 @@ func (s *Server) setupDomainApproval() domainApprovalResult {
 
 	domainQueue := approval.NewDomainQueueWithTimeout(approvalTimeout)
 	domainApprover := NewDomainApprover(domainQueue, s.policyEngine, s.auditLogger)
+	domainApprover.SetQuorum(cfg.Proxy.Quorum)
+	domainApprover.SetProjectTimeout(s.domainTimeouts.GetProject)
 	clog.Info("domain approval enabled (timeout: %v)", approvalTimeout)
 
 	return domainApprovalResult{
@@ -288,6 +553,72 @@ func (s *Server) setupDomainApproval() domainApprovalResult {
 	}
 }
 
+// setupApprovalServer creates the approval web UI server, authenticated with
+// the secret from the environment. Without one the guardian refuses to start,
+// so the UI is never left open. Configured approvers sign in with the tokens
+// the CLI generated for them, also passed in the environment.
+func (s *Server) setupApprovalServer(queue *approval.Queue, dq *approval.DomainQueue, execs *approval.ExecutionTracker) (*approval.Server, error) {
+	srv := approval.NewServer(queue, s.auditLogger)
+	srv.SetDomainQueue(dq)
+	srv.SetExecutions(execs)
+	srv.History = s.history
+	srv.Logs = s.logStream
+
+	srv.SetOwner(os.Getenv(ApprovalOwnerEnvVar))
+	secret := os.Getenv(ApprovalSecretEnvVar)
+	if secret == "" {
+		return nil, fmt.Errorf("%s not set; start the guardian with 'cloister guardian start'", ApprovalSecretEnvVar)
+	}
+	if err := srv.SetSecret(secret); err != nil {
+		return nil, fmt.Errorf("failed to configure approval server auth: %w", err)
+	}
+	tokens, err := approverTokens(s.cfg.Approvers, os.Getenv(ApproverSecretsEnvVar))
+	if err != nil {
+		return nil, err
+	}
+	if err := srv.SetApprovers(tokens); err != nil {
+		return nil, fmt.Errorf("failed to configure approvers: %w", err)
+	}
+	return srv, nil
+}
+
+// approverTokens returns the login token of each configured approver from
+// env, a JSON object keyed by name. Approvers added to the config after the
+// CLI generated the tokens have none and are skipped with a warning.
+func approverTokens(approvers []config.Approver, env string) (map[string]string, error) {
+	secrets := map[string]string{}
+	if env != "" {
+		if err := json.Unmarshal([]byte(env), &secrets); err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", ApproverSecretsEnvVar, err)
+		}
+	}
+	tokens := make(map[string]string, len(approvers))
+	for _, a := range approvers {
+		tok, ok := secrets[a.Name]
+		if !ok {
+			clog.Warn("approver %q has no login token; restart the guardian to create one", a.Name)
+			continue
+		}
+		tokens[a.Name] = tok
+	}
+	return tokens, nil
+}
+
+// setupNotifier creates the approval notification dispatcher from the
+// notify config. Returns nil if no sinks are configured.
+func (s *Server) setupNotifier(execClient request.CommandExecutor) approval.Notifier {
+	d, err := notify.New(s.cfg.Notify, execClient)
+	if err != nil {
+		clog.Warn("approval notifications disabled: %v", err)
+		return nil
+	}
+	if d.Len() == 0 {
+		return nil
+	}
+	clog.Info("approval notifications enabled (%d sinks)", d.Len())
+	return d
+}
+
 // setupExecutorClient creates the executor client if environment is configured.
 func setupExecutorClient() request.CommandExecutor {
 	sharedSecret := os.Getenv(SharedSecretEnvVar)
@@ -310,6 +641,41 @@ func setupExecutorClient() request.CommandExecutor {
 	return guardianexec.NewTCPClient(port, sharedSecret)
 }
 
+// executorPinger is implemented by executor clients that can check the
+// executor is reachable.
+type executorPinger interface {
+	Ping(ctx context.Context) error
+}
+
+// executorHealthCheck returns the health check for the executor client.
+// Without one, hostexec commands cannot run, which is reported as degraded.
+func executorHealthCheck(execClient request.CommandExecutor) HealthCheck {
+	return func(ctx context.Context) (string, string) {
+		if execClient == nil {
+			return HealthDegraded, "not configured; hostexec commands are disabled"
+		}
+		pinger, ok := execClient.(executorPinger)
+		if !ok {
+			return HealthOK, "configured"
+		}
+		if err := pinger.Ping(ctx); err != nil {
+			return HealthDegraded, "unreachable: " + err.Error()
+		}
+		return HealthOK, "reachable"
+	}
+}
+
+// auditHealthCheck reports audit log write failures as degraded.
+func (s *Server) auditHealthCheck(context.Context) (string, string) {
+	if !s.auditLogger.Writing() {
+		return HealthOK, "not writing to a file"
+	}
+	if n, err := s.auditLogger.WriteFailures(); n > 0 {
+		return HealthDegraded, fmt.Sprintf("%d write failure(s), latest: %v", n, err)
+	}
+	return HealthOK, "writing"
+}
+
 // startAllServers starts all guardian servers, cleaning up on failure.
 func (s *Server) startAllServers() error {
 	servers := []struct {
@@ -370,10 +736,122 @@ func (s *Server) shutdownAllServers() error {
 		}
 	}
 
+	if err := s.auditLogger.Checkpoint(); err != nil {
+		clog.Warn("failed to write audit checkpoint: %v", err)
+	}
+	if err := s.auditLogger.Close(); err != nil {
+		clog.Warn("%v", err)
+	}
+	s.cloisterLogs.CloseAll()
+	if s.tracer != nil {
+		if err := s.tracer.Shutdown(ctx); err != nil {
+			clog.Warn("failed to export remaining trace spans: %v", err)
+		}
+	}
 	clog.Debug("guardian servers stopped")
 	return nil
 }
 
+// extractLimits builds a map from pattern string to execution limits for
+// one pattern list. When the same pattern appears more than once in the
+// list, the first occurrence wins, matching the precedence used by
+// config.MergeCommandPatterns.
+func extractLimits(cmds []config.CommandPattern) map[string]patterns.Limits {
+	result := make(map[string]patterns.Limits)
+	for _, p := range cmds {
+		if _, seen := result[p.Pattern]; seen {
+			continue
+		}
+		limits := patterns.Limits{
+			MaxOutputBytes:   p.MaxOutputBytes,
+			EnvAllow:         p.EnvAllow,
+			EnvDeny:          p.EnvDeny,
+			Env:              p.Env,
+			RequireApprovals: p.RequireApprovals,
+		}
+		if p.Timeout != "" {
+			d, err := time.ParseDuration(p.Timeout)
+			if err != nil {
+				clog.Warn("invalid timeout %q for pattern %q: %v (ignored)", p.Timeout, p.Pattern, err)
+			}
+			limits.Timeout = d
+		}
+		d, err := config.ParseApprovalTimeout(p.ApprovalTimeout)
+		if err != nil {
+			clog.Warn("invalid approval_timeout for pattern %q: %v (ignored)", p.Pattern, err)
+		}
+		limits.ApprovalTimeout = d
+		result[p.Pattern] = limits
+	}
+	return result
+}
+
+// applyApprovalTimeout gives every pattern without an approval timeout of
+// its own a project's hostexec.approval_timeout.
+func applyApprovalTimeout(limits map[string]patterns.Limits, timeout string) {
+	d, err := config.ParseApprovalTimeout(timeout)
+	if err != nil {
+		clog.Warn("invalid project approval_timeout: %v (ignored)", err)
+	}
+	if d == 0 {
+		return
+	}
+	for pattern, l := range limits {
+		if l.ApprovalTimeout == 0 {
+			l.ApprovalTimeout = d
+			limits[pattern] = l
+		}
+	}
+}
+
+// withApprovalTimeout returns a copy of defs in which every action without
+// an approval timeout of its own has timeout.
+func withApprovalTimeout(defs []config.HostexecAction, timeout string) []config.HostexecAction {
+	if timeout == "" {
+		return defs
+	}
+	result := make([]config.HostexecAction, len(defs))
+	for i, def := range defs {
+		if def.ApprovalTimeout == "" {
+			def.ApprovalTimeout = timeout
+		}
+		result[i] = def
+	}
+	return result
+}
+
+// hostexecApprovalTimeout returns how long hostexec requests wait for
+// approval by default, from request.timeout. Negative means until decided.
+func hostexecApprovalTimeout(cfg *config.GlobalConfig) time.Duration {
+	d, err := config.ParseApprovalTimeout(cfg.Request.Timeout)
+	if err != nil {
+		clog.Warn("invalid request.timeout, using default %v: %v", approval.DefaultTimeout, err)
+	}
+	if d == 0 {
+		return approval.DefaultTimeout
+	}
+	return d
+}
+
+// projectDomainTimeout returns a project's proxy.approval_timeout, or 0 if
+// it has none.
+func projectDomainTimeout(projectName string) time.Duration {
+	projectCfg, err := config.LoadProjectConfig(projectName)
+	if err != nil {
+		clog.Warn("failed to load project config for approval timeout %s: %v", projectName, err)
+		return 0
+	}
+	if projectCfg.Proxy.ApprovalTimeout == "" {
+		return 0
+	}
+	d, err := time.ParseDuration(projectCfg.Proxy.ApprovalTimeout)
+	if err != nil || d <= 0 {
+		clog.Warn("invalid approval_timeout %q for project %s (ignored)", projectCfg.Proxy.ApprovalTimeout, projectName)
+		return 0
+	}
+	return d
+}
+
 // extractPatterns extracts pattern strings from a slice of CommandPattern.
 func extractPatterns(cmds []config.CommandPattern) []string {
 	result := make([]string, len(cmds))
@@ -1,9 +1,14 @@
 package guardian
 
 import (
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/xdg/cloister/internal/clog"
 	"github.com/xdg/cloister/internal/config"
+	"github.com/xdg/cloister/internal/guardian/approval"
 	"github.com/xdg/cloister/internal/token"
 )
 
@@ -12,6 +17,7 @@ func TestNewServer_DefaultConfig(t *testing.T) {
 	// Disable audit log so setupAuditLogger doesn't try to open a real file.
 	cfg.Log.File = ""
 
+	t.Setenv(ApprovalSecretEnvVar, "test-secret")
 	registry := token.NewRegistry()
 	decisions := &config.Decisions{}
 
@@ -48,10 +54,22 @@ func TestNewServer_DefaultConfig(t *testing.T) {
 	}
 }
 
+func TestNewServer_RequiresApprovalSecret(t *testing.T) {
+	cfg := config.DefaultGlobalConfig()
+	cfg.Log.File = ""
+	t.Setenv(ApprovalSecretEnvVar, "")
+
+	_, err := NewServer(token.NewRegistry(), cfg, &config.Decisions{})
+	if err == nil || !strings.Contains(err.Error(), ApprovalSecretEnvVar) {
+		t.Errorf("NewServer() error = %v, want missing %s", err, ApprovalSecretEnvVar)
+	}
+}
+
 func TestNewServer_PolicyEngineWiredCorrectly(t *testing.T) {
 	cfg := config.DefaultGlobalConfig()
 	cfg.Log.File = ""
 
+	t.Setenv(ApprovalSecretEnvVar, "test-secret")
 	registry := token.NewRegistry()
 	decisions := &config.Decisions{}
 
@@ -74,6 +92,55 @@ func TestNewServer_PolicyEngineWiredCorrectly(t *testing.T) {
 	}
 }
 
+func TestSetupNotifier(t *testing.T) {
+	s := &Server{cfg: &config.GlobalConfig{}}
+	if n := s.setupNotifier(nil); n != nil {
+		t.Errorf("setupNotifier() = %v, want nil without sinks", n)
+	}
+
+	s.cfg.Notify.Webhooks = []config.WebhookNotifier{{URL: "http://host.docker.internal:8080/hook"}}
+	if n := s.setupNotifier(nil); n == nil {
+		t.Error("setupNotifier() = nil, want dispatcher for configured webhook")
+	}
+
+	// Command hooks need the executor; without it they are skipped.
+	s.cfg.Notify = config.NotifyConfig{Commands: []config.CommandNotifier{{Command: []string{"notify-send"}}}}
+	if n := s.setupNotifier(nil); n != nil {
+		t.Errorf("setupNotifier() = %v, want nil when command hooks cannot run", n)
+	}
+}
+
+func TestSetupCloisterLogs(t *testing.T) {
+	cfg := &config.GlobalConfig{Log: config.LogConfig{PerCloisterDir: t.TempDir()}}
+	if c := setupCloisterLogs(cfg); c != nil {
+		t.Error("setupCloisterLogs() returned logs with per_cloister disabled")
+	}
+
+	cfg.Log.PerCloister = true
+	c := setupCloisterLogs(cfg)
+	if c == nil {
+		t.Fatal("setupCloisterLogs() = nil with per_cloister enabled")
+	}
+	if got, want := c.Path("api-main"), filepath.Join(cfg.Log.PerCloisterDir, "api-main.log"); got != want {
+		t.Errorf("Path(api-main) = %q, want %q", got, want)
+	}
+}
+
+func TestLogRotation(t *testing.T) {
+	cfg := &config.GlobalConfig{Log: config.LogConfig{Rotate: config.LogRotateConfig{
+		MaxSizeMB: 5, MaxAge: "24h", Compress: true, Keep: 3, KeepFor: "720h",
+	}}}
+	want := clog.RotateConfig{
+		MaxSize: 5 << 20, MaxAge: 24 * time.Hour, Compress: true, Keep: 3, KeepFor: 720 * time.Hour,
+	}
+	if got := LogRotation(cfg); got != want {
+		t.Errorf("LogRotation() = %+v, want %+v", got, want)
+	}
+	if LogRotation(&config.GlobalConfig{}).Enabled() {
+		t.Error("LogRotation() enabled without log.rotate")
+	}
+}
+
 func TestExtractPatterns(t *testing.T) {
 	tests := []struct {
 		name  string
@@ -122,3 +189,103 @@ func TestExtractPatterns(t *testing.T) {
 		})
 	}
 }
+
+func TestExtractLimits(t *testing.T) {
+	got := extractLimits([]config.CommandPattern{
+		{Pattern: "^make test$", Timeout: "2m", MaxOutputBytes: 4096},
+		{Pattern: "^ls$"},
+		{Pattern: "^make deploy$", EnvDeny: []string{"AWS_*"}, Env: map[string]string{"CI": "1"}, RequireApprovals: 2, ApprovalTimeout: "none"},
+		{Pattern: "^make test$", Timeout: "1h"}, // duplicate: first occurrence wins
+	})
+
+	if l := got["^make test$"]; l.Timeout != 2*time.Minute || l.MaxOutputBytes != 4096 {
+		t.Errorf("limits for ^make test$ = %+v, want Timeout=2m MaxOutputBytes=4096", l)
+	}
+	if l := got["^ls$"]; l.Timeout != 0 || l.MaxOutputBytes != 0 {
+		t.Errorf("limits for ^ls$ = %+v, want zero", l)
+	}
+	l := got["^make deploy$"]
+	if len(l.EnvDeny) != 1 || l.EnvDeny[0] != "AWS_*" {
+		t.Errorf("EnvDeny for ^make deploy$ = %v, want [AWS_*]", l.EnvDeny)
+	}
+	if l.Env["CI"] != "1" {
+		t.Errorf("Env[CI] for ^make deploy$ = %q, want %q", l.Env["CI"], "1")
+	}
+	if l.RequireApprovals != 2 {
+		t.Errorf("RequireApprovals for ^make deploy$ = %d, want 2", l.RequireApprovals)
+	}
+	if l.ApprovalTimeout >= 0 {
+		t.Errorf("ApprovalTimeout for ^make deploy$ = %v, want negative (until decided)", l.ApprovalTimeout)
+	}
+
+	applyApprovalTimeout(got, "30m")
+	if l := got["^ls$"]; l.ApprovalTimeout != 30*time.Minute {
+		t.Errorf("ApprovalTimeout for ^ls$ after project default = %v, want 30m", l.ApprovalTimeout)
+	}
+	if l := got["^make deploy$"]; l.ApprovalTimeout >= 0 {
+		t.Errorf("project default overrode the pattern's own approval timeout: %v", l.ApprovalTimeout)
+	}
+}
+
+func TestWithApprovalTimeout(t *testing.T) {
+	defs := []config.HostexecAction{
+		{Name: "plan", Argv: []string{"terraform", "plan"}},
+		{Name: "apply", Argv: []string{"terraform", "apply"}, ApprovalTimeout: "none"},
+	}
+	got := withApprovalTimeout(defs, "1h")
+	if got[0].ApprovalTimeout != "1h" || got[1].ApprovalTimeout != "none" {
+		t.Errorf("approval timeouts = %q, %q, want %q, %q", got[0].ApprovalTimeout, got[1].ApprovalTimeout, "1h", "none")
+	}
+	if defs[0].ApprovalTimeout != "" {
+		t.Error("withApprovalTimeout modified its input")
+	}
+}
+
+func TestHostexecApprovalTimeout(t *testing.T) {
+	tests := []struct {
+		timeout string
+		want    time.Duration
+	}{
+		{"", approval.DefaultTimeout},
+		{"15m", 15 * time.Minute},
+		{"none", -1},
+	}
+	for _, tt := range tests {
+		cfg := &config.GlobalConfig{Request: config.RequestConfig{Timeout: tt.timeout}}
+		if got := hostexecApprovalTimeout(cfg); got != tt.want {
+			t.Errorf("hostexecApprovalTimeout(%q) = %v, want %v", tt.timeout, got, tt.want)
+		}
+	}
+}
+
+func TestTracingEndpoint(t *testing.T) {
+	tests := []struct {
+		endpoint string
+		want     string
+	}{
+		{"http://localhost:4318", "http://host.docker.internal:4318"},
+		{"http://127.0.0.1:4318/v1/traces", "http://host.docker.internal:4318/v1/traces"},
+		{"http://[::1]:4318", "http://host.docker.internal:4318"},
+		{"https://localhost", "https://host.docker.internal"},
+		{"https://otel.example.com:4318", "https://otel.example.com:4318"},
+	}
+	for _, tt := range tests {
+		if got := tracingEndpoint(tt.endpoint); got != tt.want {
+			t.Errorf("tracingEndpoint(%q) = %q, want %q", tt.endpoint, got, tt.want)
+		}
+	}
+}
+
+func TestApproverTokens(t *testing.T) {
+	approvers := []config.Approver{{Name: "alice"}, {Name: "bob"}}
+	got, err := approverTokens(approvers, `{"alice":"a-token","mallory":"m-token"}`)
+	if err != nil {
+		t.Fatalf("approverTokens() error = %v", err)
+	}
+	if len(got) != 1 || got["alice"] != "a-token" {
+		t.Errorf("approverTokens() = %v, want only alice's token", got)
+	}
+	if _, err := approverTokens(approvers, "not json"); err == nil {
+		t.Error("approverTokens() should reject malformed JSON")
+	}
+}
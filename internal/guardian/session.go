@@ -0,0 +1,151 @@
+package guardian
+
+import (
+	"cmp"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/xdg/cloister/internal/audit"
+	"github.com/xdg/cloister/internal/token"
+)
+
+// sessionHistorySize is the number of decisions kept per session.
+const sessionHistorySize = 5000
+
+// SessionReport summarizes what a cloister did between its token being
+// registered and revoked.
+type SessionReport struct {
+	Cloister  string           `json:"cloister"`
+	Project   string           `json:"project,omitempty"`
+	Started   time.Time        `json:"started"`
+	Ended     time.Time        `json:"ended"`
+	Domains   []DomainTraffic  `json:"domains"`   // Most connections first
+	Decisions []audit.Decision `json:"decisions"` // Oldest first
+}
+
+// Duration returns how long the session lasted.
+func (r *SessionReport) Duration() time.Duration {
+	return r.Ended.Sub(r.Started)
+}
+
+// DomainTraffic is the traffic the proxy carried to one domain. Bytes are
+// counted for tunnels and plain HTTP requests the proxy forwards itself.
+type DomainTraffic struct {
+	Domain        string `json:"domain"`
+	Connections   int    `json:"connections"`
+	BytesSent     int64  `json:"bytes_sent"`
+	BytesReceived int64  `json:"bytes_received"`
+}
+
+// TrafficRecorder accounts for the traffic the proxy carries for each
+// cloister. RecordTraffic is called once per connection, after it closes.
+type TrafficRecorder interface {
+	RecordTraffic(cloister, domain string, sent, received int64)
+}
+
+// SessionTracker tracks cloister sessions for the report returned when a
+// cloister's token is revoked.
+type SessionTracker interface {
+	Start(info token.Info)
+	End(info token.Info) *SessionReport
+}
+
+// Sessions records each cloister's traffic and decisions from the time its
+// token is registered. It implements audit.Observer, TrafficRecorder and
+// SessionTracker.
+type Sessions struct {
+	mu       sync.Mutex
+	sessions map[string]*session // By cloister name
+	now      func() time.Time
+}
+
+// session is the state kept for one cloister.
+type session struct {
+	project string
+	started time.Time
+	domains map[string]*DomainTraffic
+	history *audit.History
+}
+
+// NewSessions creates an empty session tracker.
+func NewSessions() *Sessions {
+	return &Sessions{sessions: make(map[string]*session), now: time.Now}
+}
+
+// Start begins a new session for the cloister, discarding any previous one.
+func (s *Sessions) Start(info token.Info) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sessions[info.CloisterName] = s.newSession(info.ProjectName)
+}
+
+// End ends the cloister's session and returns its report, or nil if no
+// session was started for it.
+func (s *Sessions) End(info token.Info) *SessionReport {
+	s.mu.Lock()
+	sess, ok := s.sessions[info.CloisterName]
+	delete(s.sessions, info.CloisterName)
+	s.mu.Unlock()
+	if !ok {
+		return nil
+	}
+
+	report := &SessionReport{
+		Cloister:  info.CloisterName,
+		Project:   cmp.Or(info.ProjectName, sess.project),
+		Started:   sess.started,
+		Ended:     s.now(),
+		Domains:   make([]DomainTraffic, 0, len(sess.domains)),
+		Decisions: sess.history.List(audit.HistoryFilter{}),
+	}
+	for _, d := range sess.domains {
+		report.Domains = append(report.Domains, *d)
+	}
+	slices.SortFunc(report.Domains, func(a, b DomainTraffic) int {
+		return cmp.Or(cmp.Compare(b.Connections, a.Connections), cmp.Compare(a.Domain, b.Domain))
+	})
+	slices.Reverse(report.Decisions)
+	return report
+}
+
+// Observe records the decision, if any, described by an audit event for a
+// cloister with a session.
+func (s *Sessions) Observe(e *audit.Event) {
+	s.mu.Lock()
+	sess, ok := s.sessions[e.Cloister]
+	s.mu.Unlock()
+	if ok {
+		sess.history.Observe(e)
+	}
+}
+
+// RecordTraffic adds one connection to domain, and the bytes it carried, to
+// the cloister's session.
+func (s *Sessions) RecordTraffic(cloister, domain string, sent, received int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sess, ok := s.sessions[cloister]
+	if !ok {
+		return
+	}
+	d, ok := sess.domains[domain]
+	if !ok {
+		d = &DomainTraffic{Domain: domain}
+		sess.domains[domain] = d
+	}
+	d.Connections++
+	d.BytesSent += sent
+	d.BytesReceived += received
+}
+
+// newSession creates the state for a session starting now.
+func (s *Sessions) newSession(project string) *session {
+	return &session{
+		project: project,
+		started: s.now(),
+		domains: make(map[string]*DomainTraffic),
+		history: audit.NewHistory(sessionHistorySize),
+	}
+}
@@ -0,0 +1,73 @@
+package guardian
+
+import (
+	"testing"
+	"time"
+
+	"github.com/xdg/cloister/internal/audit"
+	"github.com/xdg/cloister/internal/token"
+)
+
+func TestSessions_Report(t *testing.T) {
+	start := time.Date(2024, 1, 15, 14, 0, 0, 0, time.UTC)
+	now := start
+	s := NewSessions()
+	s.now = func() time.Time { return now }
+
+	info := token.Info{CloisterName: "api-main", ProjectName: "api"}
+	s.Start(info)
+	s.RecordTraffic("api-main", "github.com", 100, 2000)
+	s.RecordTraffic("api-main", "github.com", 50, 500)
+	s.RecordTraffic("api-main", "proxy.golang.org", 10, 30)
+	s.RecordTraffic("web-main", "example.com", 1, 1) // No session
+	s.Observe(&audit.Event{Type: audit.EventDomainApprove, Cloister: "api-main", Domain: "pypi.org", Scope: "project", User: "alice"})
+	s.Observe(&audit.Event{Type: audit.EventAutoApprove, Cloister: "api-main", Cmd: "make test"})
+	s.Observe(&audit.Event{Type: audit.EventComplete, Cloister: "api-main", Cmd: "make test", ExitCode: 2})
+	s.Observe(&audit.Event{Type: audit.EventDeny, Cloister: "web-main", Cmd: "rm -rf /"}) // No session
+	now = start.Add(90 * time.Minute)
+
+	r := s.End(info)
+	if r == nil {
+		t.Fatal("End returned nil for a started session")
+	}
+	if r.Cloister != "api-main" || r.Project != "api" || r.Duration() != 90*time.Minute {
+		t.Errorf("report = %+v", r)
+	}
+	want := []DomainTraffic{
+		{Domain: "github.com", Connections: 2, BytesSent: 150, BytesReceived: 2500},
+		{Domain: "proxy.golang.org", Connections: 1, BytesSent: 10, BytesReceived: 30},
+	}
+	if len(r.Domains) != len(want) {
+		t.Fatalf("Domains = %+v, want %+v", r.Domains, want)
+	}
+	for i := range want {
+		if r.Domains[i] != want[i] {
+			t.Errorf("Domains[%d] = %+v, want %+v", i, r.Domains[i], want[i])
+		}
+	}
+	if len(r.Decisions) != 2 {
+		t.Fatalf("Decisions = %+v, want 2", r.Decisions)
+	}
+	if r.Decisions[0].Domain != "pypi.org" || r.Decisions[0].Scope != "project" {
+		t.Errorf("Decisions[0] = %+v, want the pypi.org approval first", r.Decisions[0])
+	}
+	if code := r.Decisions[1].ExitCode; code == nil || *code != 2 {
+		t.Errorf("Decisions[1].ExitCode = %v, want 2", code)
+	}
+
+	if r := s.End(info); r != nil {
+		t.Errorf("second End = %+v, want nil", r)
+	}
+}
+
+func TestSessions_StartDiscardsPrevious(t *testing.T) {
+	s := NewSessions()
+	info := token.Info{CloisterName: "api-main"}
+	s.Start(info)
+	s.RecordTraffic("api-main", "github.com", 1, 1)
+	s.Start(info)
+
+	if r := s.End(info); r == nil || len(r.Domains) != 0 {
+		t.Errorf("End = %+v, want an empty report", r)
+	}
+}
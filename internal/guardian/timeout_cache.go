@@ -0,0 +1,54 @@
+package guardian
+
+import (
+	"sync"
+	"time"
+)
+
+// ProjectTimeoutLoader loads a project's approval timeout, or 0 if it has
+// none.
+type ProjectTimeoutLoader func(projectName string) time.Duration
+
+// TimeoutCache provides per-project approval timeout lookups with caching.
+// Unlike PatternCache and ActionCache it also caches a zero result, so a
+// project with no timeout, or an unreadable config, is loaded once rather
+// than on every request.
+type TimeoutCache struct {
+	mu         sync.RWMutex
+	perProject map[string]time.Duration
+	loader     ProjectTimeoutLoader
+}
+
+// NewTimeoutCache creates a TimeoutCache that loads timeouts with loader.
+func NewTimeoutCache(loader ProjectTimeoutLoader) *TimeoutCache {
+	return &TimeoutCache{
+		perProject: make(map[string]time.Duration),
+		loader:     loader,
+	}
+}
+
+// GetProject returns the approval timeout for a project, loading and
+// caching it on first use.
+func (c *TimeoutCache) GetProject(projectName string) time.Duration {
+	c.mu.RLock()
+	d, ok := c.perProject[projectName]
+	c.mu.RUnlock()
+	if ok {
+		return d
+	}
+
+	d = c.loader(projectName)
+
+	c.mu.Lock()
+	c.perProject[projectName] = d
+	c.mu.Unlock()
+
+	return d
+}
+
+// Clear removes all cached timeouts.
+func (c *TimeoutCache) Clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.perProject = make(map[string]time.Duration)
+}
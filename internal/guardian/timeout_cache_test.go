@@ -0,0 +1,35 @@
+package guardian
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTimeoutCache(t *testing.T) {
+	loads := map[string]int{}
+	cache := NewTimeoutCache(func(projectName string) time.Duration {
+		loads[projectName]++
+		if projectName == "slow" {
+			return 10 * time.Minute
+		}
+		return 0
+	})
+
+	for range 3 {
+		if got := cache.GetProject("slow"); got != 10*time.Minute {
+			t.Errorf("GetProject(slow) = %v, want 10m", got)
+		}
+		if got := cache.GetProject("plain"); got != 0 {
+			t.Errorf("GetProject(plain) = %v, want 0", got)
+		}
+	}
+	if loads["slow"] != 1 || loads["plain"] != 1 {
+		t.Errorf("loads = %v, want each project loaded once", loads)
+	}
+
+	cache.Clear()
+	cache.GetProject("slow")
+	if loads["slow"] != 2 {
+		t.Errorf("loads after Clear = %d, want 2", loads["slow"])
+	}
+}
@@ -0,0 +1,124 @@
+package guardian
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/xdg/cloister/internal/clog"
+	"github.com/xdg/cloister/internal/executor"
+	"github.com/xdg/cloister/internal/version"
+)
+
+// Guardian API versions. A guardian serves clients speaking MinAPIVersion
+// through APIVersion. Bump APIVersion when the CLI starts relying on a new
+// or changed endpoint, and MinAPIVersion when the guardian drops an endpoint
+// or changes one incompatibly.
+const (
+	APIVersion    = 1
+	MinAPIVersion = 1
+)
+
+// Guardian API capabilities, reported by GET /version.
+const (
+	CapabilityTokens         = "tokens"          // POST/GET /tokens, DELETE /tokens/{token}
+	CapabilitySessionReports = "session_reports" // DELETE /tokens/{token} returns a session report
+	CapabilityMetrics        = "metrics"         // GET /metrics
+	CapabilityHealth         = "health"          // GET /healthz and GET /readyz
+	CapabilityAuditKey       = "audit_key"       // GET /audit/key
+)
+
+// VersionInfo is the response of GET /version.
+type VersionInfo struct {
+	// Version is the cloister release the guardian image was built from.
+	Version string `json:"version"`
+
+	// APIVersion and MinAPIVersion bound the API versions the guardian
+	// serves. Both are 0 for a guardian that predates API versioning.
+	APIVersion    int `json:"api_version"`
+	MinAPIVersion int `json:"min_api_version"`
+
+	// ExecutorProtocol is the executor socket protocol version the guardian
+	// speaks to the host executor.
+	ExecutorProtocol int `json:"executor_protocol,omitempty"`
+
+	Capabilities []string `json:"capabilities,omitempty"`
+}
+
+// ErrIncompatibleGuardian is wrapped by errors for a guardian that does not
+// serve this CLI's API version.
+var ErrIncompatibleGuardian = errors.New("incompatible guardian")
+
+// IncompatibleError describes a guardian that does not serve this CLI's
+// API version.
+type IncompatibleError struct {
+	Guardian VersionInfo
+}
+
+func (e *IncompatibleError) Error() string {
+	if e.Guardian.APIVersion == 0 {
+		return fmt.Sprintf("%v: the running guardian predates API versioning; this cloister (%s) needs API version %d",
+			ErrIncompatibleGuardian, version.Version, APIVersion)
+	}
+	return fmt.Sprintf("%v: guardian %s serves API versions %d-%d; this cloister (%s) needs API version %d",
+		ErrIncompatibleGuardian, e.Guardian.Version, e.Guardian.MinAPIVersion, e.Guardian.APIVersion, version.Version, APIVersion)
+}
+
+func (e *IncompatibleError) Unwrap() error { return ErrIncompatibleGuardian }
+
+// GuardianOlder reports whether the guardian is older than the CLI, so that
+// restarting it with the current image may resolve the incompatibility. If
+// not, the CLI is the older one and needs upgrading.
+func (e *IncompatibleError) GuardianOlder() bool {
+	return e.Guardian.APIVersion < APIVersion
+}
+
+// checkCompatible returns an *IncompatibleError unless the guardian serves
+// this CLI's API version.
+func checkCompatible(info *VersionInfo) error {
+	if info.MinAPIVersion <= APIVersion && APIVersion <= info.APIVersion {
+		return nil
+	}
+	return &IncompatibleError{Guardian: *info}
+}
+
+// handleVersion serves GET /version.
+func (a *APIServer) handleVersion(w http.ResponseWriter, _ *http.Request) {
+	caps := []string{CapabilityTokens}
+	if a.Sessions != nil {
+		caps = append(caps, CapabilitySessionReports)
+	}
+	if a.Metrics != nil {
+		caps = append(caps, CapabilityMetrics)
+	}
+	if a.Health != nil {
+		caps = append(caps, CapabilityHealth)
+	}
+	if a.AuditPublicKey != nil {
+		caps = append(caps, CapabilityAuditKey)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(VersionInfo{
+		Version:          version.Version,
+		APIVersion:       APIVersion,
+		MinAPIVersion:    MinAPIVersion,
+		ExecutorProtocol: executor.ProtocolVersion,
+		Capabilities:     caps,
+	}); err != nil {
+		clog.Debug("version: failed to write response: %v", err)
+	}
+}
+
+// CheckVersion returns the running guardian's version information, and an
+// error wrapping ErrIncompatibleGuardian if it does not serve this CLI's
+// API version. Returns ErrGuardianNotRunning if the guardian is not
+// running.
+func CheckVersion() (*VersionInfo, error) {
+	client, err := withGuardianClient()
+	if err != nil {
+		return nil, err
+	}
+	return client.Negotiate()
+}
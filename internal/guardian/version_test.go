@@ -0,0 +1,189 @@
+package guardian
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/xdg/cloister/internal/executor"
+)
+
+func TestAPIServer_Version(t *testing.T) {
+	api := NewAPIServer(":0", newMockRegistry())
+	api.Health = NewHealth()
+	if err := api.Start(); err != nil {
+		t.Fatalf("failed to start API server: %v", err)
+	}
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = api.Stop(ctx)
+	}()
+
+	resp, err := http.Get("http://" + api.ListenAddr() + "/version")
+	if err != nil {
+		t.Fatalf("GET /version: %v", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d, want %d", resp.StatusCode, http.StatusOK)
+	}
+
+	var info VersionInfo
+	if err := json.NewDecoder(resp.Body).Decode(&info); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if info.APIVersion != APIVersion || info.MinAPIVersion != MinAPIVersion {
+		t.Errorf("API versions = %d-%d, want %d-%d", info.MinAPIVersion, info.APIVersion, MinAPIVersion, APIVersion)
+	}
+	if info.ExecutorProtocol != executor.ProtocolVersion {
+		t.Errorf("ExecutorProtocol = %d, want %d", info.ExecutorProtocol, executor.ProtocolVersion)
+	}
+	want := []string{CapabilityTokens, CapabilityHealth}
+	if !slices.Equal(info.Capabilities, want) {
+		t.Errorf("Capabilities = %v, want %v", info.Capabilities, want)
+	}
+}
+
+// versionServer serves GET /version with body, or 404 if body is empty, and
+// counts the requests for it.
+func versionServer(t *testing.T, body string) (*Client, *atomic.Int32) {
+	t.Helper()
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/version":
+			calls.Add(1)
+			if body == "" {
+				http.NotFound(w, r)
+				return
+			}
+			_, _ = w.Write([]byte(body))
+		case "/tokens":
+			w.WriteHeader(http.StatusCreated)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return &Client{BaseURL: server.URL}, &calls
+}
+
+func TestClient_Negotiate(t *testing.T) {
+	tests := []struct {
+		name           string
+		body           string
+		wantErr        bool
+		wantOlder      bool
+		wantAPIVersion int
+	}{
+		{"compatible", `{"version":"v1","api_version":1,"min_api_version":1}`, false, false, 1},
+		{"newer but compatible", `{"version":"v2","api_version":2,"min_api_version":1}`, false, false, 2},
+		{"too new", `{"version":"v3","api_version":3,"min_api_version":2}`, true, false, 3},
+		{"predates versioning", "", true, true, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, calls := versionServer(t, tt.body)
+
+			err := client.RegisterTokenFull("tok", "p-main", "p", "")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("RegisterTokenFull() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr {
+				if err := client.RegisterTokenFull("tok2", "p-main", "p", ""); err != nil {
+					t.Fatalf("second RegisterTokenFull() error = %v", err)
+				}
+				if n := calls.Load(); n != 1 {
+					t.Errorf("GET /version called %d times, want 1", n)
+				}
+				return
+			}
+
+			if !errors.Is(err, ErrIncompatibleGuardian) {
+				t.Errorf("error = %v, want ErrIncompatibleGuardian", err)
+			}
+			var incompatible *IncompatibleError
+			if !errors.As(err, &incompatible) {
+				t.Fatalf("error = %v, want *IncompatibleError", err)
+			}
+			if incompatible.GuardianOlder() != tt.wantOlder {
+				t.Errorf("GuardianOlder() = %v, want %v", incompatible.GuardianOlder(), tt.wantOlder)
+			}
+			if incompatible.Guardian.APIVersion != tt.wantAPIVersion {
+				t.Errorf("Guardian.APIVersion = %d, want %d", incompatible.Guardian.APIVersion, tt.wantAPIVersion)
+			}
+		})
+	}
+}
+
+func TestClient_HealthSkipsNegotiation(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/healthz" {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte(`{"status":"ok","ready":true}`))
+	}))
+	defer server.Close()
+
+	client := &Client{BaseURL: server.URL}
+	if _, err := client.Health(); err != nil {
+		t.Errorf("Health() error = %v", err)
+	}
+}
+
+func TestClient_TokenCleanupSkipsNegotiation(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/tokens":
+			_, _ = w.Write([]byte(`{"tokens":[{"token":"tok","cloister":"p-main"}]}`))
+		case r.Method == http.MethodDelete && r.URL.Path == "/tokens/tok":
+			_, _ = w.Write([]byte(`{"status":"revoked"}`))
+		case r.Method == http.MethodDelete:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"error":"token not found"}`))
+		default:
+			http.NotFound(w, r) // Including /version, as before API versioning
+		}
+	}))
+	defer server.Close()
+
+	client := &Client{BaseURL: server.URL}
+	tokens, err := client.ListTokens()
+	if err != nil || tokens["tok"] != "p-main" {
+		t.Errorf("ListTokens() = %v, %v; want tok for p-main", tokens, err)
+	}
+	if _, err := client.RevokeToken("tok"); err != nil {
+		t.Errorf("RevokeToken() error = %v", err)
+	}
+	if _, err := client.RevokeToken("gone"); err != nil {
+		t.Errorf("RevokeToken() of an unknown token error = %v", err)
+	}
+}
+
+func TestIncompatibleError_Error(t *testing.T) {
+	tests := []struct {
+		name string
+		info VersionInfo
+		want string
+	}{
+		{"predates versioning", VersionInfo{}, "predates API versioning"},
+		{"range", VersionInfo{Version: "v9", APIVersion: 3, MinAPIVersion: 2}, "guardian v9 serves API versions 2-3"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg := (&IncompatibleError{Guardian: tt.info}).Error()
+			if !strings.HasPrefix(msg, "incompatible guardian: ") || !strings.Contains(msg, tt.want) {
+				t.Errorf("Error() = %q, want it to contain %q", msg, tt.want)
+			}
+		})
+	}
+}
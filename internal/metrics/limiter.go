@@ -0,0 +1,36 @@
+package metrics
+
+import "sync"
+
+// Other is the label value that stands in for values beyond a Limiter's
+// limit.
+const Other = "other"
+
+// Limiter bounds the number of distinct values a label takes, so that a
+// label fed from untrusted or unbounded input, such as domain names, cannot
+// create unbounded numbers of series. The first values seen are kept; later
+// new values are reported as Other. It is safe for concurrent use.
+type Limiter struct {
+	mu   sync.Mutex
+	max  int
+	seen map[string]struct{}
+}
+
+// NewLimiter creates a limiter that keeps up to max distinct values.
+func NewLimiter(max int) *Limiter {
+	return &Limiter{max: max, seen: make(map[string]struct{})}
+}
+
+// Value returns v if it is one of the values kept, and Other otherwise.
+func (l *Limiter) Value(v string) string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if _, ok := l.seen[v]; ok {
+		return v
+	}
+	if len(l.seen) >= l.max {
+		return Other
+	}
+	l.seen[v] = struct{}{}
+	return v
+}
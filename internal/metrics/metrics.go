@@ -0,0 +1,272 @@
+// Package metrics implements counters, gauges and histograms with labels,
+// exposed in the Prometheus text format.
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/xdg/cloister/internal/clog"
+)
+
+// ContentType is the media type of the text format written by WriteText.
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// DefaultBuckets are histogram bucket upper bounds, in seconds, suited to
+// request latencies.
+var DefaultBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10, 30, 60, 300}
+
+// Metric types, as named in the text format.
+const (
+	typeCounter   = "counter"
+	typeGauge     = "gauge"
+	typeHistogram = "histogram"
+)
+
+// Registry holds a set of metrics and writes them in the Prometheus text
+// format. It implements http.Handler. It is safe for concurrent use.
+type Registry struct {
+	mu       sync.Mutex
+	families []*family // In registration order
+}
+
+// NewRegistry creates an empty registry.
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// family is a metric and all its labeled series.
+type family struct {
+	name    string
+	help    string
+	typ     string
+	labels  []string
+	buckets []float64 // Histograms only
+
+	mu     sync.Mutex
+	series map[string]*series // Keyed by the joined label values
+}
+
+// series is one combination of label values.
+type series struct {
+	values []string
+
+	value float64        // Counters and gauges
+	fn    func() float64 // Gauges set with SetFunc
+
+	counts []uint64 // Histograms: observations per bucket, not cumulative
+	sum    float64
+	count  uint64
+}
+
+// register adds a metric family. Registering two metrics with the same name
+// is a programming error and panics.
+func (r *Registry) register(name, help, typ string, buckets []float64, labels []string) *family {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if slices.ContainsFunc(r.families, func(f *family) bool { return f.name == name }) {
+		panic("metrics: duplicate metric " + name)
+	}
+	f := &family{name: name, help: help, typ: typ, labels: labels, buckets: buckets, series: make(map[string]*series)}
+	r.families = append(r.families, f)
+	return f
+}
+
+// get returns the series for label values, creating it if needed. The
+// caller must hold f.mu. Passing the wrong number of values is a
+// programming error and panics.
+func (f *family) get(values []string) *series {
+	if len(values) != len(f.labels) {
+		panic(fmt.Sprintf("metrics: %s takes %d label values, got %d", f.name, len(f.labels), len(values)))
+	}
+	key := strings.Join(values, "\xff")
+	s, ok := f.series[key]
+	if !ok {
+		s = &series{values: slices.Clone(values)}
+		if f.typ == typeHistogram {
+			s.counts = make([]uint64, len(f.buckets))
+		}
+		f.series[key] = s
+	}
+	return s
+}
+
+// CounterVec is a counter with labels.
+type CounterVec struct{ f *family }
+
+// Counter registers a counter with the given label names.
+func (r *Registry) Counter(name, help string, labels ...string) *CounterVec {
+	return &CounterVec{r.register(name, help, typeCounter, nil, labels)}
+}
+
+// Inc adds one to the counter with the given label values.
+func (c *CounterVec) Inc(values ...string) {
+	c.Add(1, values...)
+}
+
+// Add adds n, which must not be negative, to the counter with the given
+// label values.
+func (c *CounterVec) Add(n float64, values ...string) {
+	c.f.mu.Lock()
+	defer c.f.mu.Unlock()
+	c.f.get(values).value += n
+}
+
+// GaugeVec is a gauge with labels.
+type GaugeVec struct{ f *family }
+
+// Gauge registers a gauge with the given label names.
+func (r *Registry) Gauge(name, help string, labels ...string) *GaugeVec {
+	return &GaugeVec{r.register(name, help, typeGauge, nil, labels)}
+}
+
+// Set sets the gauge with the given label values.
+func (g *GaugeVec) Set(x float64, values ...string) {
+	g.f.mu.Lock()
+	defer g.f.mu.Unlock()
+	s := g.f.get(values)
+	s.value, s.fn = x, nil
+}
+
+// SetFunc makes the gauge with the given label values report fn's result
+// each time metrics are written.
+func (g *GaugeVec) SetFunc(fn func() float64, values ...string) {
+	g.f.mu.Lock()
+	defer g.f.mu.Unlock()
+	g.f.get(values).fn = fn
+}
+
+// HistogramVec is a histogram with labels.
+type HistogramVec struct{ f *family }
+
+// Histogram registers a histogram with the given bucket upper bounds, which
+// must be sorted, and label names.
+func (r *Registry) Histogram(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	return &HistogramVec{r.register(name, help, typeHistogram, buckets, labels)}
+}
+
+// Observe records x in the histogram with the given label values.
+func (h *HistogramVec) Observe(x float64, values ...string) {
+	h.f.mu.Lock()
+	defer h.f.mu.Unlock()
+	s := h.f.get(values)
+	if i, _ := slices.BinarySearch(h.f.buckets, x); i < len(s.counts) {
+		s.counts[i]++
+	}
+	s.sum += x
+	s.count++
+}
+
+// ServeHTTP writes the registry's metrics in the text format.
+func (r *Registry) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", ContentType)
+	if err := r.WriteText(w); err != nil {
+		clog.Debug("metrics: failed to write response: %v", err)
+	}
+}
+
+// WriteText writes every metric in the Prometheus text format, metrics in
+// registration order and series sorted by label values.
+func (r *Registry) WriteText(w io.Writer) error {
+	r.mu.Lock()
+	families := slices.Clone(r.families)
+	r.mu.Unlock()
+
+	bw := bufio.NewWriter(w)
+	for _, f := range families {
+		f.write(bw)
+	}
+	if err := bw.Flush(); err != nil {
+		return fmt.Errorf("write metrics: %w", err)
+	}
+	return nil
+}
+
+// write writes one metric family.
+func (f *family) write(w *bufio.Writer) {
+	f.mu.Lock()
+	snapshot := make([]series, 0, len(f.series))
+	for _, s := range f.series {
+		c := *s
+		c.counts = slices.Clone(s.counts)
+		snapshot = append(snapshot, c)
+	}
+	f.mu.Unlock()
+	slices.SortFunc(snapshot, func(a, b series) int { return slices.Compare(a.values, b.values) })
+
+	fmt.Fprintf(w, "# HELP %s %s\n", f.name, escapeHelp(f.help))
+	fmt.Fprintf(w, "# TYPE %s %s\n", f.name, f.typ)
+	for i := range snapshot {
+		s := &snapshot[i]
+		labels := f.labelPairs(s.values)
+		switch f.typ {
+		case typeHistogram:
+			var cumulative uint64
+			for j, le := range f.buckets {
+				cumulative += s.counts[j]
+				fmt.Fprintf(w, "%s_bucket%s %d\n", f.name, withLabel(labels, "le", formatFloat(le)), cumulative)
+			}
+			fmt.Fprintf(w, "%s_bucket%s %d\n", f.name, withLabel(labels, "le", "+Inf"), s.count)
+			fmt.Fprintf(w, "%s_sum%s %s\n", f.name, braces(labels), formatFloat(s.sum))
+			fmt.Fprintf(w, "%s_count%s %d\n", f.name, braces(labels), s.count)
+		default:
+			value := s.value
+			if s.fn != nil {
+				value = s.fn()
+			}
+			fmt.Fprintf(w, "%s%s %s\n", f.name, braces(labels), formatFloat(value))
+		}
+	}
+}
+
+// labelPairs formats label names and values as name="value" pairs.
+func (f *family) labelPairs(values []string) []string {
+	pairs := make([]string, len(values))
+	for i, v := range values {
+		pairs[i] = f.labels[i] + `="` + escapeLabel(v) + `"`
+	}
+	return pairs
+}
+
+// withLabel appends one more label pair and wraps the pairs in braces.
+func withLabel(pairs []string, name, value string) string {
+	return braces(append(slices.Clip(pairs), name+`="`+value+`"`))
+}
+
+// braces joins label pairs in braces, or returns "" for no labels.
+func braces(pairs []string) string {
+	if len(pairs) == 0 {
+		return ""
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+// formatFloat formats a sample value.
+func formatFloat(x float64) string {
+	switch {
+	case math.IsInf(x, 1):
+		return "+Inf"
+	case math.IsInf(x, -1):
+		return "-Inf"
+	default:
+		return strconv.FormatFloat(x, 'g', -1, 64)
+	}
+}
+
+var (
+	labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+)
+
+// escapeLabel escapes a label value.
+func escapeLabel(s string) string { return labelEscaper.Replace(s) }
+
+// escapeHelp escapes help text.
+func escapeHelp(s string) string { return helpEscaper.Replace(s) }
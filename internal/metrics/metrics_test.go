@@ -0,0 +1,97 @@
+package metrics
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRegistry_WriteText(t *testing.T) {
+	r := NewRegistry()
+	requests := r.Counter("test_requests_total", "Requests handled.", "project", "outcome")
+	depth := r.Gauge("test_queue_depth", "Queued requests.", "type")
+	tokens := r.Gauge("test_active_tokens", "Registered tokens.")
+	latency := r.Histogram("test_latency_seconds", "Latency.", []float64{0.1, 1}, "type")
+
+	requests.Inc("api", "approved")
+	requests.Add(2, "api", "approved")
+	requests.Inc(`we"b`, "denied")
+	depth.Set(3, "hostexec")
+	n := 0
+	tokens.SetFunc(func() float64 { n++; return float64(n) })
+	latency.Observe(0.05, "domain")
+	latency.Observe(0.5, "domain")
+	latency.Observe(5, "domain")
+
+	var b strings.Builder
+	if err := r.WriteText(&b); err != nil {
+		t.Fatalf("WriteText: %v", err)
+	}
+	want := `# HELP test_requests_total Requests handled.
+# TYPE test_requests_total counter
+test_requests_total{project="api",outcome="approved"} 3
+test_requests_total{project="we\"b",outcome="denied"} 1
+# HELP test_queue_depth Queued requests.
+# TYPE test_queue_depth gauge
+test_queue_depth{type="hostexec"} 3
+# HELP test_active_tokens Registered tokens.
+# TYPE test_active_tokens gauge
+test_active_tokens 1
+# HELP test_latency_seconds Latency.
+# TYPE test_latency_seconds histogram
+test_latency_seconds_bucket{type="domain",le="0.1"} 1
+test_latency_seconds_bucket{type="domain",le="1"} 2
+test_latency_seconds_bucket{type="domain",le="+Inf"} 3
+test_latency_seconds_sum{type="domain"} 5.55
+test_latency_seconds_count{type="domain"} 3
+`
+	if b.String() != want {
+		t.Errorf("WriteText =\n%s\nwant\n%s", b.String(), want)
+	}
+}
+
+func TestRegistry_ServeHTTP(t *testing.T) {
+	r := NewRegistry()
+	r.Counter("test_total", "Test.").Inc()
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	if got := rec.Header().Get("Content-Type"); got != ContentType {
+		t.Errorf("Content-Type = %q, want %q", got, ContentType)
+	}
+	if !strings.Contains(rec.Body.String(), "test_total 1\n") {
+		t.Errorf("body = %q", rec.Body.String())
+	}
+}
+
+func TestRegistry_Panics(t *testing.T) {
+	r := NewRegistry()
+	c := r.Counter("test_total", "Test.", "a")
+	for name, fn := range map[string]func(){
+		"duplicate":         func() { r.Gauge("test_total", "Again.") },
+		"wrong label count": func() { c.Inc("x", "y") },
+	} {
+		t.Run(name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Error("did not panic")
+				}
+			}()
+			fn()
+		})
+	}
+}
+
+func TestLimiter(t *testing.T) {
+	l := NewLimiter(2)
+	for _, tt := range []struct{ in, want string }{
+		{"a.com", "a.com"},
+		{"b.com", "b.com"},
+		{"c.com", Other},
+		{"a.com", "a.com"},
+	} {
+		if got := l.Value(tt.in); got != tt.want {
+			t.Errorf("Value(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
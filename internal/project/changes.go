@@ -0,0 +1,74 @@
+package project
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+)
+
+// Changes describes the commits made in a worktree since an earlier commit.
+// Uncommitted changes are deliberately not included; see ChangesSince.
+type Changes struct {
+	Since        string `json:"since"`         // The commit compared against
+	Commits      int    `json:"commits"`       // Commits made on top of Since
+	FilesChanged int    `json:"files_changed"` // Files that differ between Since and HEAD
+	Insertions   int    `json:"insertions"`
+	Deletions    int    `json:"deletions"`
+}
+
+// shortstatPattern matches the counts in git diff --shortstat output, e.g.
+// "3 files changed, 10 insertions(+), 2 deletions(-)".
+var shortstatPattern = regexp.MustCompile(`(\d+) (file|insertion|deletion)`)
+
+// untrustedRepoEnv keeps git from picking up system or user config, and
+// turns off the fsmonitor hook, when it runs in a repository an agent can
+// write to. The repository's own config is still read, so only commands
+// that never touch the worktree or index may run there: those run filter
+// drivers, textconv, and other helpers the agent could configure.
+var untrustedRepoEnv = []string{
+	"GIT_CONFIG_NOSYSTEM=1",
+	"GIT_CONFIG_GLOBAL=/dev/null",
+	"GIT_TERMINAL_PROMPT=0",
+	"GIT_CONFIG_COUNT=1",
+	"GIT_CONFIG_KEY_0=core.fsmonitor",
+	"GIT_CONFIG_VALUE_0=false",
+}
+
+// HeadCommit returns the full hash of the commit checked out in the git
+// repository at gitRoot.
+func HeadCommit(gitRoot string) (string, error) {
+	return runGitEnv(gitRoot, untrustedRepoEnv, "rev-parse", "HEAD")
+}
+
+// ChangesSince compares HEAD in the git repository at gitRoot with commit,
+// which is usually a HeadCommit result from earlier. The worktree may be
+// written by an agent, so only commits are compared, with plumbing commands
+// that never scan the worktree or run helpers from the repository's config.
+func ChangesSince(gitRoot, commit string) (*Changes, error) {
+	c := &Changes{Since: commit}
+
+	out, err := runGitEnv(gitRoot, untrustedRepoEnv, "rev-list", "--count", commit+"..HEAD")
+	if err != nil {
+		return nil, err
+	}
+	if c.Commits, err = strconv.Atoi(out); err != nil {
+		return nil, fmt.Errorf("unexpected git rev-list output %q: %w", out, err)
+	}
+
+	out, err = runGitEnv(gitRoot, untrustedRepoEnv, "diff-tree", "-r", "--shortstat", commit, "HEAD")
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range shortstatPattern.FindAllStringSubmatch(out, -1) {
+		n, _ := strconv.Atoi(m[1]) //nolint:errcheck // The pattern only matches digits.
+		switch m[2] {
+		case "file":
+			c.FilesChanged = n
+		case "insertion":
+			c.Insertions = n
+		case "deletion":
+			c.Deletions = n
+		}
+	}
+	return c, nil
+}
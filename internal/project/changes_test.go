@@ -0,0 +1,72 @@
+package project
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestChangesSince(t *testing.T) {
+	dir := t.TempDir()
+	git := func(args ...string) {
+		t.Helper()
+		if _, err := runGit(dir, args...); err != nil {
+			if errors.Is(err, ErrGitNotInstalled) {
+				t.Skip("git not installed")
+			}
+			t.Fatalf("git %v failed: %v", args, err)
+		}
+	}
+	write := func(name, content string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	git("init")
+	git("config", "user.email", "test@test.com")
+	git("config", "user.name", "Test User")
+	write("a.txt", "one\ntwo\n")
+	git("add", ".")
+	git("commit", "-m", "initial")
+
+	start, err := HeadCommit(dir)
+	if err != nil {
+		t.Fatalf("HeadCommit failed: %v", err)
+	}
+
+	c, err := ChangesSince(dir, start)
+	if err != nil {
+		t.Fatalf("ChangesSince failed: %v", err)
+	}
+	if *c != (Changes{Since: start}) {
+		t.Errorf("ChangesSince with no changes = %+v", c)
+	}
+
+	write("b.txt", "new\n")
+	git("add", "b.txt")
+	git("commit", "-m", "add b")
+	write("a.txt", "one\nTWO\n")
+	git("commit", "-am", "edit a")
+	write("a.txt", "uncommitted\n")
+	write("untracked.txt", "x\n")
+	// A filter driver in the repo config must not run: the worktree is
+	// never read.
+	marker := filepath.Join(t.TempDir(), "filter-ran")
+	write(".gitattributes", "* filter=evil\n")
+	git("config", "filter.evil.clean", "touch "+marker)
+
+	c, err = ChangesSince(dir, start)
+	if err != nil {
+		t.Fatalf("ChangesSince failed: %v", err)
+	}
+	if _, err := os.Stat(marker); err == nil {
+		t.Error("ChangesSince ran a filter driver from the repo config")
+	}
+	want := Changes{Since: start, Commits: 2, FilesChanged: 2, Insertions: 2, Deletions: 1}
+	if *c != want {
+		t.Errorf("ChangesSince = %+v, want %+v", *c, want)
+	}
+}
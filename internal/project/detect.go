@@ -7,6 +7,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"os"
 	"os/exec"
 	"path/filepath"
 	"regexp"
@@ -41,10 +42,18 @@ func (e *GitError) Unwrap() error {
 // runGit executes a git command in the specified directory and returns stdout.
 // If dir is empty, uses the current working directory.
 func runGit(dir string, args ...string) (string, error) {
+	return runGitEnv(dir, nil, args...)
+}
+
+// runGitEnv is runGit with env added to the command's environment.
+func runGitEnv(dir string, env []string, args ...string) (string, error) {
 	cmd := exec.CommandContext(context.Background(), "git", args...)
 	if dir != "" {
 		cmd.Dir = dir
 	}
+	if env != nil {
+		cmd.Env = append(os.Environ(), env...)
+	}
 
 	var stdout, stderr bytes.Buffer
 	cmd.Stdout = &stdout
@@ -0,0 +1,42 @@
+// Package pty relays an interactive command through a pseudo-terminal so the
+// host can inspect terminal input before the command sees it.
+//
+// Commands like "docker exec -it" insist that their stdin is a TTY, so input
+// cannot simply be piped through a filter. Run gives the command the slave
+// side of a new pseudo-terminal and copies the host terminal to and from the
+// master side, passing input through a caller-supplied filter.
+package pty
+
+import (
+	"errors"
+	"io"
+	"sync"
+)
+
+// ErrUnsupported is returned by Run when pseudo-terminals are not available
+// on this platform or the host input is not a terminal. Callers should fall
+// back to running the command directly on the host terminal.
+var ErrUnsupported = errors.New("pseudo-terminal relay not supported")
+
+// InputFilter wraps the host terminal's input before it reaches the command.
+type InputFilter func(io.Reader) io.Reader
+
+// SyncWriter serializes writes to the host terminal. Passed to Run as its
+// output and shared with other writers, such as terminal title updates, it
+// keeps their escape sequences from landing inside a chunk of relayed output.
+type SyncWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewSyncWriter returns a SyncWriter that writes to w.
+func NewSyncWriter(w io.Writer) *SyncWriter {
+	return &SyncWriter{w: w}
+}
+
+// Write implements io.Writer.
+func (s *SyncWriter) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.w.Write(p) //nolint:wrapcheck // pass writer errors through unchanged
+}
@@ -0,0 +1,51 @@
+package pty
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"syscall"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+// Open allocates a new pseudo-terminal and returns its master and slave ends.
+func Open() (ptm, pts *os.File, err error) {
+	ptm, err = os.OpenFile("/dev/ptmx", os.O_RDWR|syscall.O_NOCTTY|syscall.O_CLOEXEC, 0)
+	if err != nil {
+		return nil, nil, fmt.Errorf("open /dev/ptmx: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			_ = ptm.Close()
+		}
+	}()
+
+	fd := int(ptm.Fd())
+	if err := unix.IoctlSetInt(fd, unix.TIOCPTYGRANT, 0); err != nil {
+		return nil, nil, fmt.Errorf("grant pty: %w", err)
+	}
+	if err := unix.IoctlSetInt(fd, unix.TIOCPTYUNLK, 0); err != nil {
+		return nil, nil, fmt.Errorf("unlock pty: %w", err)
+	}
+
+	// TIOCPTYGNAME fills a caller-supplied 128-byte buffer with the slave
+	// path; x/sys has no typed wrapper for it.
+	var buf [128]byte
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, uintptr(fd), uintptr(unix.TIOCPTYGNAME), uintptr(unsafe.Pointer(&buf[0])))
+	if errno != 0 {
+		return nil, nil, fmt.Errorf("get pty name: %w", errno)
+	}
+	n := bytes.IndexByte(buf[:], 0)
+	if n < 0 {
+		n = len(buf)
+	}
+	name := string(buf[:n])
+
+	pts, err = os.OpenFile(name, os.O_RDWR|syscall.O_NOCTTY|syscall.O_CLOEXEC, 0)
+	if err != nil {
+		return nil, nil, fmt.Errorf("open %s: %w", name, err)
+	}
+	return ptm, pts, nil
+}
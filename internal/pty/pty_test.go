@@ -0,0 +1,93 @@
+//go:build linux || darwin
+
+package pty
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestOpen_RoundTrip(t *testing.T) {
+	ptm, pts, err := Open()
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	defer func() { _ = ptm.Close() }()
+	defer func() { _ = pts.Close() }()
+
+	if _, err := pts.WriteString("hello\n"); err != nil {
+		t.Fatalf("write slave: %v", err)
+	}
+	buf := make([]byte, 64)
+	n, err := ptm.Read(buf)
+	if err != nil {
+		t.Fatalf("read master: %v", err)
+	}
+	if got := string(buf[:n]); !strings.HasPrefix(got, "hello") {
+		t.Errorf("master read %q, want prefix %q", got, "hello")
+	}
+}
+
+func TestRun_NonTerminalStdin(t *testing.T) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("Pipe() error = %v", err)
+	}
+	defer func() { _ = r.Close() }()
+	defer func() { _ = w.Close() }()
+
+	cmd := exec.Command("true")
+	if err := Run(cmd, r, w, nil); !errors.Is(err, ErrUnsupported) {
+		t.Errorf("Run() error = %v, want ErrUnsupported", err)
+	}
+	if cmd.Process != nil {
+		t.Error("Run() started the command despite non-terminal stdin")
+	}
+}
+
+// byteWriter writes one byte at a time, yielding between bytes, so that
+// unsynchronized concurrent writes interleave.
+type byteWriter struct {
+	buf []byte
+}
+
+func (b *byteWriter) Write(p []byte) (int, error) {
+	for _, c := range p {
+		b.buf = append(b.buf, c)
+		runtime.Gosched()
+	}
+	return len(p), nil
+}
+
+func TestSyncWriter_KeepsWritesWhole(t *testing.T) {
+	bw := &byteWriter{}
+	w := NewSyncWriter(bw)
+	chunks := []string{"\x1b]2;title\a", "shell output line\n"}
+
+	var wg sync.WaitGroup
+	for _, chunk := range chunks {
+		wg.Go(func() {
+			for range 100 {
+				_, _ = w.Write([]byte(chunk))
+			}
+		})
+	}
+	wg.Wait()
+
+	rest := string(bw.buf)
+	for rest != "" {
+		switch {
+		case strings.HasPrefix(rest, chunks[0]):
+			rest = rest[len(chunks[0]):]
+		case strings.HasPrefix(rest, chunks[1]):
+			rest = rest[len(chunks[1]):]
+		default:
+			t.Fatalf("writes interleaved at %q", rest[:min(len(rest), 20)])
+		}
+	}
+}
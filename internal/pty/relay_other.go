@@ -0,0 +1,14 @@
+//go:build !linux && !darwin
+
+package pty
+
+import (
+	"io"
+	"os"
+	"os/exec"
+)
+
+// Run is not supported on this platform and always returns ErrUnsupported.
+func Run(_ *exec.Cmd, _ *os.File, _ io.Writer, _ InputFilter) error {
+	return ErrUnsupported
+}
@@ -0,0 +1,90 @@
+//go:build linux || darwin
+
+package pty
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+	xterm "golang.org/x/term"
+)
+
+// Run starts cmd on a new pseudo-terminal and relays the host terminal
+// through it until cmd exits: output from cmd is copied to stdout, and input
+// from stdin is passed through filter (if non-nil) before reaching cmd.
+//
+// The host terminal is put in raw mode for the duration, and window size
+// changes are forwarded. Returns ErrUnsupported without starting cmd if stdin
+// is not a terminal. Otherwise returns the result of cmd.Wait.
+func Run(cmd *exec.Cmd, stdin *os.File, stdout io.Writer, filter InputFilter) error {
+	inFd := int(stdin.Fd())
+	if !xterm.IsTerminal(inFd) {
+		return ErrUnsupported
+	}
+
+	ptm, pts, err := Open()
+	if err != nil {
+		return err
+	}
+	defer func() { _ = ptm.Close() }()
+
+	resize(stdin, ptm)
+	winch := make(chan os.Signal, 1)
+	signal.Notify(winch, syscall.SIGWINCH)
+	defer signal.Stop(winch)
+	go func() {
+		for range winch {
+			resize(stdin, ptm)
+		}
+	}()
+
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = pts, pts, pts
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true, Setctty: true}
+	startErr := cmd.Start()
+	_ = pts.Close()
+	if startErr != nil {
+		return fmt.Errorf("start command: %w", startErr)
+	}
+
+	oldState, err := xterm.MakeRaw(inFd)
+	if err != nil {
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+		return fmt.Errorf("set raw mode: %w", err)
+	}
+	defer func() { _ = xterm.Restore(inFd, oldState) }()
+
+	var in io.Reader = stdin
+	if filter != nil {
+		in = filter(in)
+	}
+	// The input copy blocks on the host terminal and cannot be interrupted;
+	// it ends on the next keystroke after cmd exits, when the write fails.
+	go func() { _, _ = io.Copy(ptm, in) }()
+
+	// Reading the master returns an error (EIO on Linux) once the command
+	// and everything it spawned have closed the slave.
+	outputDone := make(chan struct{})
+	go func() {
+		_, _ = io.Copy(stdout, ptm)
+		close(outputDone)
+	}()
+
+	waitErr := cmd.Wait()
+	<-outputDone
+	return waitErr
+}
+
+// resize copies the window size of the host terminal to the pseudo-terminal.
+func resize(host, ptm *os.File) {
+	ws, err := unix.IoctlGetWinsize(int(host.Fd()), unix.TIOCGWINSZ)
+	if err != nil {
+		return
+	}
+	_ = unix.IoctlSetWinsize(int(ptm.Fd()), unix.TIOCSWINSZ, ws)
+}
@@ -0,0 +1,60 @@
+package sidechannel
+
+import "io"
+
+// Filter wraps the host terminal's input, removing hotkeys before the input
+// reaches the container. It has the signature of pty.InputFilter.
+//
+// HotkeyPrefix is only intercepted while a request is pending, so Ctrl-G
+// reaches the container unchanged the rest of the time.
+func (w *Watcher) Filter(r io.Reader) io.Reader {
+	return &hotkeyReader{r: r, w: w}
+}
+
+// hotkeyReader strips hotkey sequences from an input stream.
+type hotkeyReader struct {
+	r      io.Reader
+	w      *Watcher
+	prefix bool   // HotkeyPrefix seen, awaiting the key
+	buf    []byte // Filtered bytes not yet returned
+}
+
+// Read returns filtered input, blocking until at least one byte survives
+// filtering or the underlying reader fails.
+func (h *hotkeyReader) Read(p []byte) (int, error) {
+	for len(h.buf) == 0 {
+		raw := make([]byte, len(p))
+		n, err := h.r.Read(raw)
+		for _, b := range raw[:n] {
+			h.filter(b)
+		}
+		if err != nil && len(h.buf) == 0 {
+			return 0, err //nolint:wrapcheck // pass reader errors such as io.EOF through unchanged
+		}
+	}
+	n := copy(p, h.buf)
+	h.buf = h.buf[n:]
+	return n, nil
+}
+
+// filter processes one input byte, appending whatever should reach the
+// container to buf.
+func (h *hotkeyReader) filter(b byte) {
+	if h.prefix {
+		h.prefix = false
+		switch {
+		case b == HotkeyPrefix:
+			h.buf = append(h.buf, b) // Doubled prefix sends one Ctrl-G
+		case h.w.handleKey(b):
+			// Consumed as a hotkey
+		default:
+			h.buf = append(h.buf, HotkeyPrefix, b)
+		}
+		return
+	}
+	if b == HotkeyPrefix && h.w.Pending() {
+		h.prefix = true
+		return
+	}
+	h.buf = append(h.buf, b)
+}
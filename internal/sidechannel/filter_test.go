@@ -0,0 +1,94 @@
+package sidechannel
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/xdg/cloister/internal/guardian/approval"
+)
+
+// readFiltered runs input through the watcher's filter and returns the
+// bytes that would reach the container.
+func readFiltered(t *testing.T, w *Watcher, input string) string {
+	t.Helper()
+	got, err := io.ReadAll(w.Filter(strings.NewReader(input)))
+	if err != nil {
+		t.Fatalf("ReadAll() error = %v", err)
+	}
+	return string(got)
+}
+
+func TestFilter_PassesThroughWhenIdle(t *testing.T) {
+	w := New(newFakeAPI(), "c1", &syncBuffer{})
+
+	if got := readFiltered(t, w, "ls\x07a\n"); got != "ls\x07a\n" {
+		t.Errorf("filtered = %q, want input unchanged", got)
+	}
+}
+
+func TestFilter_HotkeyApprovesCommand(t *testing.T) {
+	api := newFakeAPI()
+	api.commands = []approval.PendingCommand{{ID: "r1", Cloister: "c1", Cmd: "make"}}
+	w := New(api, "c1", &syncBuffer{})
+	w.refresh(context.Background())
+
+	if got := readFiltered(t, w, "x\x07ay"); got != "xy" {
+		t.Errorf("filtered = %q, want hotkey removed", got)
+	}
+	if d := waitDecision(t, api); d != "approve r1" {
+		t.Errorf("decision = %q, want approve r1", d)
+	}
+}
+
+func TestFilter_HotkeyDomainSession(t *testing.T) {
+	api := newFakeAPI()
+	api.domains = []approval.PendingDomain{{ID: "d1", Cloister: "c1", Domain: "example.com"}}
+	w := New(api, "c1", &syncBuffer{})
+	w.refresh(context.Background())
+
+	readFiltered(t, w, "\x07s")
+	if d := waitDecision(t, api); d != "approve-domain d1 session" {
+		t.Errorf("decision = %q, want session approval", d)
+	}
+}
+
+func TestFilter_HotkeyDeny(t *testing.T) {
+	api := newFakeAPI()
+	api.commands = []approval.PendingCommand{{ID: "r1", Cloister: "c1", Cmd: "make"}}
+	w := New(api, "c1", &syncBuffer{})
+	w.refresh(context.Background())
+
+	readFiltered(t, w, "\x07d")
+	if d := waitDecision(t, api); d != "deny r1" {
+		t.Errorf("decision = %q, want deny r1", d)
+	}
+}
+
+func TestFilter_PendingNonHotkeys(t *testing.T) {
+	api := newFakeAPI()
+	api.commands = []approval.PendingCommand{{ID: "r1", Cloister: "c1", Cmd: "make"}}
+	w := New(api, "c1", &syncBuffer{})
+	w.refresh(context.Background())
+
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"doubled prefix sends one Ctrl-G", "\x07\x07", "\x07"},
+		{"unknown key passes both bytes", "\x07z", "\x07z"},
+		{"session key is not a command hotkey", "\x07s", "\x07s"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := readFiltered(t, w, tt.input); got != tt.want {
+				t.Errorf("filtered = %q, want %q", got, tt.want)
+			}
+		})
+	}
+	if !w.Pending() {
+		t.Error("request was decided by a non-hotkey")
+	}
+}
@@ -0,0 +1,241 @@
+// Package sidechannel surfaces guardian approval requests for one cloister in
+// the host terminal while the user is attached to it.
+//
+// A Watcher follows the approval server's event stream and, when a request
+// from its cloister is pending, sets the terminal title and rings the bell.
+// The request can then be decided with a hotkey: Ctrl-G followed by a key.
+// Hotkeys are read from a filtered copy of the host terminal's input (see
+// Watcher.Filter), so nothing is written to or read from the container's TTY.
+package sidechannel
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/xdg/cloister/internal/guardian/approval"
+)
+
+// HotkeyPrefix is the byte (Ctrl-G) that starts a hotkey while a request is
+// pending. Typing it twice sends a single Ctrl-G to the container.
+const HotkeyPrefix byte = 0x07
+
+// Hotkeys accepted after HotkeyPrefix.
+const (
+	KeyApprove        = 'a' // Approve (domains: once)
+	KeyApproveSession = 's' // Approve a domain for the session
+	KeyDeny           = 'd' // Deny (domains: once)
+)
+
+// decideTimeout bounds how long a hotkey decision may take.
+const decideTimeout = 10 * time.Second
+
+// API is the subset of approval.Client used by a Watcher.
+type API interface {
+	Pending(ctx context.Context) ([]approval.PendingCommand, error)
+	PendingDomains(ctx context.Context) ([]approval.PendingDomain, error)
+	Approve(ctx context.Context, id string) error
+	Deny(ctx context.Context, id, reason string) error
+	ApproveDomain(ctx context.Context, id, scope, pattern string) error
+	DenyDomain(ctx context.Context, id, scope string, wildcard bool, reason string) error
+	Subscribe(ctx context.Context, onEvent func(approval.Event)) error
+}
+
+// request is the pending request currently offered for a hotkey decision.
+type request struct {
+	id     string
+	domain bool
+	detail string // Command line or domain
+}
+
+// Watcher follows approval requests for a single cloister.
+// It is safe for concurrent use.
+type Watcher struct {
+	api      API
+	cloister string
+	out      io.Writer
+
+	mu      sync.Mutex
+	current *request // Oldest pending request, or nil
+	count   int      // Number of pending requests for the cloister
+}
+
+// New creates a watcher for requests from the named cloister. Notifications
+// are written to out, which should be the host terminal.
+func New(api API, cloisterName string, out io.Writer) *Watcher {
+	return &Watcher{api: api, cloister: cloisterName, out: out}
+}
+
+// Run shows any requests already pending, then follows the approval event
+// stream until ctx is cancelled or the stream fails.
+func (w *Watcher) Run(ctx context.Context) error {
+	w.refresh(ctx)
+	err := w.api.Subscribe(ctx, func(ev approval.Event) {
+		switch ev.Type {
+		case approval.EventRequestAdded, approval.EventRequestRemoved,
+			approval.EventDomainRequestAdded, approval.EventDomainRequestRemoved:
+			w.refresh(ctx)
+		default:
+			// Execution updates and other events don't change what's pending.
+		}
+	})
+	if err != nil {
+		return fmt.Errorf("follow approval events: %w", err)
+	}
+	return nil
+}
+
+// Pending reports whether a request is waiting for a hotkey decision.
+func (w *Watcher) Pending() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.current != nil
+}
+
+// Close clears any notification from the terminal title.
+func (w *Watcher) Close() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.current, w.count = nil, 0
+	w.setTitle("")
+}
+
+// refresh reloads the cloister's pending requests and updates the title,
+// ringing the bell when a new request becomes current.
+func (w *Watcher) refresh(ctx context.Context) {
+	reqs, err := w.list(ctx)
+	if err != nil {
+		return // Best-effort: keep the last known state
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.count = len(reqs)
+	if len(reqs) == 0 {
+		if w.current != nil {
+			w.current = nil
+			w.setTitle("")
+		}
+		return
+	}
+
+	isNew := w.current == nil || w.current.id != reqs[0].id
+	w.current = &reqs[0]
+	w.setTitle(w.prompt())
+	if isNew {
+		_, _ = io.WriteString(w.out, "\a")
+	}
+}
+
+// list returns the cloister's pending requests, commands first, oldest first.
+func (w *Watcher) list(ctx context.Context) ([]request, error) {
+	cmds, err := w.api.Pending(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list pending requests: %w", err)
+	}
+	domains, err := w.api.PendingDomains(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list pending domains: %w", err)
+	}
+
+	var reqs []request
+	for _, r := range cmds {
+		if r.Cloister != w.cloister {
+			continue
+		}
+		// For actions, Cmd names the action and its parameter values
+		reqs = append(reqs, request{id: r.ID, detail: r.Cmd})
+	}
+	for _, r := range domains {
+		if r.Cloister == w.cloister {
+			reqs = append(reqs, request{id: r.ID, domain: true, detail: r.Domain})
+		}
+	}
+	return reqs, nil
+}
+
+// prompt describes the current request and its hotkeys. Caller holds mu.
+func (w *Watcher) prompt() string {
+	keys := "^G a approve, ^G d deny"
+	if w.current.domain {
+		keys = "^G a once, ^G s session, ^G d deny"
+	}
+	more := ""
+	if w.count > 1 {
+		more = fmt.Sprintf(" (+%d more)", w.count-1)
+	}
+	return fmt.Sprintf("approve %s%s? [%s]", w.current.detail, more, keys)
+}
+
+// handleKey acts on the key following HotkeyPrefix. It returns false if the
+// key is not a hotkey for the current request, in which case the caller
+// passes both bytes through.
+func (w *Watcher) handleKey(key byte) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	req := w.current
+	if req == nil {
+		return false
+	}
+	var verb string
+	var decide func(ctx context.Context) error
+	switch {
+	case key == KeyApprove && req.domain:
+		verb, decide = "approved", func(ctx context.Context) error { return w.api.ApproveDomain(ctx, req.id, "once", "") }
+	case key == KeyApprove:
+		verb, decide = "approved", func(ctx context.Context) error { return w.api.Approve(ctx, req.id) }
+	case key == KeyApproveSession && req.domain:
+		verb, decide = "approved for session", func(ctx context.Context) error { return w.api.ApproveDomain(ctx, req.id, "session", "") }
+	case key == KeyDeny && req.domain:
+		verb, decide = "denied", func(ctx context.Context) error { return w.api.DenyDomain(ctx, req.id, "once", false, "") }
+	case key == KeyDeny:
+		verb, decide = "denied", func(ctx context.Context) error { return w.api.Deny(ctx, req.id, "") }
+	default:
+		return false
+	}
+
+	// Clear the request now so further keystrokes pass straight through;
+	// the removal event from the server triggers the next refresh.
+	w.current = nil
+	w.setTitle(req.detail + ": " + verb)
+	go w.decide(req, decide)
+	return true
+}
+
+// decide sends a hotkey decision to the approval server.
+func (w *Watcher) decide(req *request, decide func(ctx context.Context) error) {
+	ctx, cancel := context.WithTimeout(context.Background(), decideTimeout)
+	defer cancel()
+	if err := decide(ctx); err != nil {
+		w.mu.Lock()
+		w.setTitle(fmt.Sprintf("%s: %v", req.detail, err))
+		w.mu.Unlock()
+	}
+	w.refresh(ctx)
+}
+
+// setTitle sets the host terminal title, prefixed with the cloister name.
+// Caller holds mu.
+func (w *Watcher) setTitle(msg string) {
+	title := "cloister: " + w.cloister
+	if msg != "" {
+		title += " - " + msg
+	}
+	_, _ = io.WriteString(w.out, "\x1b]2;"+sanitize(title)+"\a")
+}
+
+// sanitize removes control characters so request details cannot end the
+// title sequence early or inject escape sequences into the host terminal.
+func sanitize(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r < 0x20 || r == 0x7f || (r >= 0x80 && r < 0xa0) {
+			return -1
+		}
+		return r
+	}, s)
+}
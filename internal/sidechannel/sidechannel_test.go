@@ -0,0 +1,206 @@
+package sidechannel
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/xdg/cloister/internal/guardian/approval"
+)
+
+// fakeAPI is an in-memory API that reports decisions on a channel.
+type fakeAPI struct {
+	mu        sync.Mutex
+	commands  []approval.PendingCommand
+	domains   []approval.PendingDomain
+	decisions chan string
+	events    []approval.Event
+}
+
+func newFakeAPI() *fakeAPI {
+	return &fakeAPI{decisions: make(chan string, 8)}
+}
+
+func (f *fakeAPI) Pending(context.Context) ([]approval.PendingCommand, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.commands, nil
+}
+
+func (f *fakeAPI) PendingDomains(context.Context) ([]approval.PendingDomain, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.domains, nil
+}
+
+func (f *fakeAPI) Approve(_ context.Context, id string) error {
+	f.decisions <- "approve " + id
+	return nil
+}
+
+func (f *fakeAPI) Deny(_ context.Context, id, _ string) error {
+	f.decisions <- "deny " + id
+	return nil
+}
+
+func (f *fakeAPI) ApproveDomain(_ context.Context, id, scope, _ string) error {
+	f.decisions <- "approve-domain " + id + " " + scope
+	return nil
+}
+
+func (f *fakeAPI) DenyDomain(_ context.Context, id, scope string, _ bool, _ string) error {
+	f.decisions <- "deny-domain " + id + " " + scope
+	return nil
+}
+
+func (f *fakeAPI) Subscribe(_ context.Context, onEvent func(approval.Event)) error {
+	for _, ev := range f.events {
+		onEvent(ev)
+	}
+	return nil
+}
+
+// syncBuffer is a bytes.Buffer safe for concurrent writes.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+// waitDecision returns the next decision or fails after a timeout.
+func waitDecision(t *testing.T, api *fakeAPI) string {
+	t.Helper()
+	select {
+	case d := <-api.decisions:
+		return d
+	case <-time.After(2 * time.Second):
+		t.Fatal("no decision received")
+		return ""
+	}
+}
+
+func TestWatcher_RefreshFiltersByCloister(t *testing.T) {
+	api := newFakeAPI()
+	api.commands = []approval.PendingCommand{
+		{ID: "other", Cloister: "c2", Cmd: "rm -rf /"},
+		{ID: "mine", Cloister: "c1", Cmd: "make test"},
+	}
+	out := &syncBuffer{}
+	w := New(api, "c1", out)
+
+	w.refresh(context.Background())
+
+	if !w.Pending() {
+		t.Fatal("Pending() = false, want true")
+	}
+	got := out.String()
+	if !strings.Contains(got, "\x1b]2;cloister: c1 - approve make test? [^G a approve, ^G d deny]\a") {
+		t.Errorf("output %q does not set the expected title", got)
+	}
+	if strings.Contains(got, "rm -rf") {
+		t.Errorf("output %q mentions another cloister's request", got)
+	}
+	if strings.Count(got, "\a") != 2 { // Title terminator plus one bell
+		t.Errorf("output %q, want exactly one bell", got)
+	}
+
+	// A refresh with the same request does not ring again.
+	w.refresh(context.Background())
+	if n := strings.Count(out.String(), "\a"); n != 3 {
+		t.Errorf("bell rang again for the same request (%d BELs)", n)
+	}
+}
+
+func TestWatcher_ShowsActionParams(t *testing.T) {
+	api := newFakeAPI()
+	api.commands = []approval.PendingCommand{{
+		ID:       "a1",
+		Cloister: "c1",
+		Cmd:      `action:reset-db name="prod"`,
+		Action:   "reset-db",
+		Params:   []approval.ActionParam{{Name: "name", Value: "prod"}},
+	}}
+	out := &syncBuffer{}
+	w := New(api, "c1", out)
+
+	w.refresh(context.Background())
+
+	if want := `approve action:reset-db name="prod"?`; !strings.Contains(out.String(), want) {
+		t.Errorf("output %q does not contain %q", out.String(), want)
+	}
+}
+
+func TestWatcher_RunClearsWhenDecided(t *testing.T) {
+	api := newFakeAPI()
+	api.domains = []approval.PendingDomain{{ID: "d1", Cloister: "c1", Domain: "api.example.com"}}
+	w := New(api, "c1", &syncBuffer{})
+
+	w.refresh(context.Background())
+	if !w.Pending() {
+		t.Fatal("Pending() = false after domain request added")
+	}
+
+	api.mu.Lock()
+	api.domains = nil
+	api.mu.Unlock()
+	api.events = []approval.Event{{Type: approval.EventDomainRequestRemoved}}
+	if err := w.Run(context.Background()); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if w.Pending() {
+		t.Error("Pending() = true after the request was removed")
+	}
+}
+
+func TestWatcher_PromptCountsOthers(t *testing.T) {
+	api := newFakeAPI()
+	api.domains = []approval.PendingDomain{
+		{ID: "d1", Cloister: "c1", Domain: "a.example.com"},
+		{ID: "d2", Cloister: "c1", Domain: "b.example.com"},
+	}
+	out := &syncBuffer{}
+	w := New(api, "c1", out)
+	w.refresh(context.Background())
+
+	want := "approve a.example.com (+1 more)? [^G a once, ^G s session, ^G d deny]"
+	if !strings.Contains(out.String(), want) {
+		t.Errorf("output %q does not contain %q", out.String(), want)
+	}
+}
+
+func TestWatcher_CloseResetsTitle(t *testing.T) {
+	api := newFakeAPI()
+	api.commands = []approval.PendingCommand{{ID: "a", Cloister: "c1", Cmd: "ls"}}
+	out := &syncBuffer{}
+	w := New(api, "c1", out)
+	w.refresh(context.Background())
+
+	w.Close()
+	if w.Pending() {
+		t.Error("Pending() = true after Close")
+	}
+	if !strings.HasSuffix(out.String(), "\x1b]2;cloister: c1\a") {
+		t.Errorf("output %q does not end with a reset title", out.String())
+	}
+}
+
+func TestSanitize(t *testing.T) {
+	got := sanitize("evil\a\x1b]2;pwned\u009b\x07 ok")
+	if got != "evil]2;pwned ok" {
+		t.Errorf("sanitize() = %q", got)
+	}
+}
@@ -0,0 +1,157 @@
+package testutil
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/xdg/cloister/internal/tracing"
+)
+
+// Span is a span as received by a Collector.
+type Span struct {
+	Service      string
+	TraceID      string
+	SpanID       string
+	ParentSpanID string
+	Name         string
+	Attributes   map[string]string // Values as JSON text, without quotes for strings
+	Error        string            // Status message of a failed span
+}
+
+// Collector is a stand-in for an OTLP/HTTP trace collector.
+type Collector struct {
+	URL string
+
+	mu    sync.Mutex
+	spans []Span
+}
+
+// StartCollector starts a Collector and installs a tracer for service that
+// exports to it. Tracing is turned off when the test ends.
+func StartCollector(t *testing.T, service string) (*Collector, *tracing.Tracer) {
+	t.Helper()
+	c := &Collector{}
+	srv := httptest.NewServer(http.HandlerFunc(c.handle))
+	c.URL = srv.URL
+
+	tracer := tracing.NewTracer(tracing.Options{Endpoint: srv.URL, Service: service, FlushInterval: time.Hour})
+	tracing.SetTracer(tracer)
+	t.Cleanup(func() {
+		tracing.SetTracer(nil)
+		_ = tracer.Shutdown(context.Background())
+		srv.Close()
+	})
+	return c, tracer
+}
+
+// Spans flushes tracers and returns every span received so far.
+func (c *Collector) Spans(t *testing.T, tracers ...*tracing.Tracer) []Span {
+	t.Helper()
+	for _, tr := range tracers {
+		if err := tr.Flush(context.Background()); err != nil {
+			t.Fatalf("flush spans: %v", err)
+		}
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]Span(nil), c.spans...)
+}
+
+// Span returns the first span received named name, failing the test if
+// there is none.
+func (c *Collector) Span(t *testing.T, name string, tracers ...*tracing.Tracer) Span {
+	t.Helper()
+	spans := c.Spans(t, tracers...)
+	for _, s := range spans {
+		if s.Name == name {
+			return s
+		}
+	}
+	names := make([]string, len(spans))
+	for i, s := range spans {
+		names[i] = s.Name
+	}
+	t.Fatalf("no span named %q; received %v", name, names)
+	return Span{}
+}
+
+// otlpValue is an OTLP/JSON AnyValue.
+type otlpValue map[string]json.RawMessage
+
+// text returns the value as JSON text, without quotes for strings.
+func (v otlpValue) text() string {
+	for _, raw := range v {
+		var s string
+		if json.Unmarshal(raw, &s) == nil {
+			return s
+		}
+		return string(raw)
+	}
+	return ""
+}
+
+type otlpAttr struct {
+	Key   string    `json:"key"`
+	Value otlpValue `json:"value"`
+}
+
+// handle receives an OTLP/JSON export request.
+func (c *Collector) handle(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		ResourceSpans []struct {
+			Resource struct {
+				Attributes []otlpAttr `json:"attributes"`
+			} `json:"resource"`
+			ScopeSpans []struct {
+				Spans []struct {
+					TraceID      string     `json:"traceId"`
+					SpanID       string     `json:"spanId"`
+					ParentSpanID string     `json:"parentSpanId"`
+					Name         string     `json:"name"`
+					Attributes   []otlpAttr `json:"attributes"`
+					Status       struct {
+						Message string `json:"message"`
+					} `json:"status"`
+				} `json:"spans"`
+			} `json:"scopeSpans"`
+		} `json:"resourceSpans"`
+	}
+	if r.URL.Path != "/v1/traces" {
+		http.NotFound(w, r)
+		return
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, rs := range req.ResourceSpans {
+		var service string
+		for _, a := range rs.Resource.Attributes {
+			if a.Key == "service.name" {
+				service = a.Value.text()
+			}
+		}
+		for _, ss := range rs.ScopeSpans {
+			for _, s := range ss.Spans {
+				attrs := make(map[string]string, len(s.Attributes))
+				for _, a := range s.Attributes {
+					attrs[a.Key] = a.Value.text()
+				}
+				c.spans = append(c.spans, Span{
+					Service: service, TraceID: s.TraceID, SpanID: s.SpanID, ParentSpanID: s.ParentSpanID,
+					Name: s.Name, Attributes: attrs, Error: s.Status.Message,
+				})
+			}
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write([]byte("{}"))
+}
@@ -0,0 +1,309 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/xdg/cloister/internal/clog"
+)
+
+// Export limits. Spans are sent in batches of up to exportBatchSize, and
+// spans beyond maxQueuedSpans waiting to be sent are dropped, so a missing
+// collector never costs more than a bounded amount of memory.
+const (
+	exportBatchSize      = 512
+	maxQueuedSpans       = 2048
+	defaultFlushInterval = 5 * time.Second
+	exportTimeout        = 10 * time.Second
+)
+
+// Options configures a Tracer.
+type Options struct {
+	// Endpoint is the collector's OTLP/HTTP base URL, such as
+	// "http://localhost:4318". "/v1/traces" is appended unless the URL
+	// already ends with it.
+	Endpoint string
+
+	// Service is reported as the service.name resource attribute.
+	Service string
+
+	// Headers are sent with every export request, for example to
+	// authenticate to a hosted collector.
+	Headers map[string]string
+
+	// Client sends export requests. Defaults to a client with a 10 second
+	// timeout.
+	Client *http.Client
+
+	// FlushInterval is how often queued spans are sent. Defaults to 5
+	// seconds.
+	FlushInterval time.Duration
+}
+
+// Tracer creates spans and exports the ended ones to an OTLP collector in
+// the background.
+type Tracer struct {
+	url     string
+	service string
+	headers map[string]string
+	client  *http.Client
+	now     func() time.Time
+
+	mu      sync.Mutex
+	queue   []*Span
+	dropped int
+
+	// exportMu serializes exports so spans reach the collector in order.
+	exportMu sync.Mutex
+
+	kick     chan struct{}
+	done     chan struct{}
+	stopped  chan struct{}
+	stopOnce sync.Once
+}
+
+// NewTracer creates a Tracer and starts its background exporter. Call
+// Shutdown to send the remaining spans and stop it.
+func NewTracer(opts Options) *Tracer {
+	url := strings.TrimSuffix(opts.Endpoint, "/")
+	if !strings.HasSuffix(url, "/v1/traces") {
+		url += "/v1/traces"
+	}
+	client := opts.Client
+	if client == nil {
+		client = &http.Client{Timeout: exportTimeout}
+	}
+	interval := opts.FlushInterval
+	if interval <= 0 {
+		interval = defaultFlushInterval
+	}
+
+	t := &Tracer{
+		url:     url,
+		service: opts.Service,
+		headers: opts.Headers,
+		client:  client,
+		now:     time.Now,
+		kick:    make(chan struct{}, 1),
+		done:    make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+	go t.run(interval)
+	return t
+}
+
+// finish queues an ended span for export.
+func (t *Tracer) finish(s *Span) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if len(t.queue) >= maxQueuedSpans {
+		t.dropped++
+		return
+	}
+	t.queue = append(t.queue, s)
+	if len(t.queue) >= exportBatchSize {
+		select {
+		case t.kick <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// run exports queued spans every interval, or sooner when a full batch is
+// waiting, until Shutdown.
+func (t *Tracer) run(interval time.Duration) {
+	defer close(t.stopped)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-t.done:
+			return
+		case <-ticker.C:
+		case <-t.kick:
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), exportTimeout)
+		if err := t.Flush(ctx); err != nil {
+			clog.Warn("tracing: %v", err)
+		}
+		cancel()
+	}
+}
+
+// Flush sends all queued spans to the collector.
+func (t *Tracer) Flush(ctx context.Context) error {
+	t.exportMu.Lock()
+	defer t.exportMu.Unlock()
+	for {
+		t.mu.Lock()
+		n := min(len(t.queue), exportBatchSize)
+		batch := t.queue[:n:n]
+		t.queue = t.queue[n:]
+		dropped := t.dropped
+		t.dropped = 0
+		t.mu.Unlock()
+
+		if dropped > 0 {
+			clog.Warn("tracing: dropped %d span(s) while the export queue was full", dropped)
+		}
+		if n == 0 {
+			return nil
+		}
+		if err := t.export(ctx, batch); err != nil {
+			return err
+		}
+	}
+}
+
+// Shutdown stops the background exporter and sends the remaining spans.
+func (t *Tracer) Shutdown(ctx context.Context) error {
+	t.stopOnce.Do(func() { close(t.done) })
+	select {
+	case <-t.stopped:
+	case <-ctx.Done():
+		return ctx.Err() //nolint:wrapcheck // context errors are returned as-is
+	}
+	return t.Flush(ctx)
+}
+
+// export posts one batch of spans.
+func (t *Tracer) export(ctx context.Context, spans []*Span) error {
+	body, err := json.Marshal(t.encode(spans))
+	if err != nil {
+		return fmt.Errorf("encode spans: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("create export request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range t.headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("export %d span(s): %w", len(spans), err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	_, _ = io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("export %d span(s): collector returned %s", len(spans), resp.Status)
+	}
+	return nil
+}
+
+// OTLP/JSON request body, as in the ExportTraceServiceRequest protobuf
+// message. Only the fields cloister sets are declared.
+type (
+	otlpRequest struct {
+		ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+	}
+	otlpResourceSpans struct {
+		Resource   otlpResource     `json:"resource"`
+		ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+	}
+	otlpResource struct {
+		Attributes []otlpAttr `json:"attributes"`
+	}
+	otlpScopeSpans struct {
+		Scope otlpScope  `json:"scope"`
+		Spans []otlpSpan `json:"spans"`
+	}
+	otlpScope struct {
+		Name string `json:"name"`
+	}
+	otlpSpan struct {
+		TraceID           string      `json:"traceId"`
+		SpanID            string      `json:"spanId"`
+		ParentSpanID      string      `json:"parentSpanId,omitempty"`
+		Name              string      `json:"name"`
+		Kind              int         `json:"kind"`
+		StartTimeUnixNano string      `json:"startTimeUnixNano"`
+		EndTimeUnixNano   string      `json:"endTimeUnixNano"`
+		Attributes        []otlpAttr  `json:"attributes,omitempty"`
+		Status            *otlpStatus `json:"status,omitempty"`
+	}
+	otlpAttr struct {
+		Key   string    `json:"key"`
+		Value otlpValue `json:"value"`
+	}
+	otlpValue struct {
+		StringValue *string  `json:"stringValue,omitempty"`
+		IntValue    *string  `json:"intValue,omitempty"` // int64 is a string in OTLP/JSON
+		DoubleValue *float64 `json:"doubleValue,omitempty"`
+		BoolValue   *bool    `json:"boolValue,omitempty"`
+	}
+	otlpStatus struct {
+		Code    int    `json:"code"`
+		Message string `json:"message,omitempty"`
+	}
+)
+
+// OTLP span kind and status codes.
+const (
+	otlpKindInternal = 1
+	otlpStatusError  = 2
+)
+
+// encode converts spans to an OTLP/JSON request body.
+func (t *Tracer) encode(spans []*Span) otlpRequest {
+	out := make([]otlpSpan, 0, len(spans))
+	for _, s := range spans {
+		s.mu.Lock()
+		sp := otlpSpan{
+			TraceID:           hex.EncodeToString(s.sc.TraceID[:]),
+			SpanID:            hex.EncodeToString(s.sc.SpanID[:]),
+			Name:              s.name,
+			Kind:              otlpKindInternal,
+			StartTimeUnixNano: strconv.FormatInt(s.start.UnixNano(), 10),
+			EndTimeUnixNano:   strconv.FormatInt(s.end.UnixNano(), 10),
+		}
+		if s.parent != (SpanID{}) {
+			sp.ParentSpanID = hex.EncodeToString(s.parent[:])
+		}
+		for _, a := range s.attrs {
+			sp.Attributes = append(sp.Attributes, encodeAttr(a))
+		}
+		if s.errMsg != "" {
+			sp.Status = &otlpStatus{Code: otlpStatusError, Message: s.errMsg}
+		}
+		s.mu.Unlock()
+		out = append(out, sp)
+	}
+
+	return otlpRequest{ResourceSpans: []otlpResourceSpans{{
+		Resource:   otlpResource{Attributes: []otlpAttr{encodeAttr(String("service.name", t.service))}},
+		ScopeSpans: []otlpScopeSpans{{Scope: otlpScope{Name: "cloister"}, Spans: out}},
+	}}}
+}
+
+// encodeAttr converts an attribute to OTLP/JSON. Values of other types are
+// sent as strings.
+func encodeAttr(a Attr) otlpAttr {
+	var v otlpValue
+	switch x := a.Value.(type) {
+	case string:
+		v.StringValue = &x
+	case int64:
+		s := strconv.FormatInt(x, 10)
+		v.IntValue = &s
+	case float64:
+		v.DoubleValue = &x
+	case bool:
+		v.BoolValue = &x
+	default:
+		s := fmt.Sprint(x)
+		v.StringValue = &s
+	}
+	return otlpAttr{Key: a.Key, Value: v}
+}
@@ -0,0 +1,192 @@
+package tracing
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// newTestTracer creates a tracer that only exports when flushed, and shuts it
+// down at the end of the test.
+func newTestTracer(t *testing.T, endpoint string) *Tracer {
+	t.Helper()
+	tr := NewTracer(Options{Endpoint: endpoint, Service: "test", FlushInterval: time.Hour})
+	t.Cleanup(func() {
+		tr.stopOnce.Do(func() { close(tr.done) })
+		<-tr.stopped
+	})
+	return tr
+}
+
+// collector is a stand-in for an OTLP/HTTP collector that keeps the
+// requests it receives.
+type collector struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	paths    []string
+	headers  []http.Header
+	requests []otlpRequest
+}
+
+func newCollector(t *testing.T) *collector {
+	t.Helper()
+	c := &collector{}
+	c.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		var req otlpRequest
+		if err := json.Unmarshal(body, &req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		c.mu.Lock()
+		c.paths = append(c.paths, r.URL.Path)
+		c.headers = append(c.headers, r.Header)
+		c.requests = append(c.requests, req)
+		c.mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte("{}"))
+	}))
+	t.Cleanup(c.Close)
+	return c
+}
+
+// spans returns every span received, by name.
+func (c *collector) spans() map[string]otlpSpan {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	out := make(map[string]otlpSpan)
+	for _, req := range c.requests {
+		for _, rs := range req.ResourceSpans {
+			for _, ss := range rs.ScopeSpans {
+				for _, s := range ss.Spans {
+					out[s.Name] = s
+				}
+			}
+		}
+	}
+	return out
+}
+
+// attr returns the value of a span attribute as a string.
+func attr(s otlpSpan, key string) string {
+	for _, a := range s.Attributes {
+		if a.Key != key {
+			continue
+		}
+		switch {
+		case a.Value.StringValue != nil:
+			return *a.Value.StringValue
+		case a.Value.IntValue != nil:
+			return *a.Value.IntValue
+		case a.Value.BoolValue != nil && *a.Value.BoolValue:
+			return "true"
+		case a.Value.BoolValue != nil:
+			return "false"
+		}
+	}
+	return ""
+}
+
+func TestTracer_Export(t *testing.T) {
+	c := newCollector(t)
+	tr := NewTracer(Options{
+		Endpoint:      c.URL,
+		Service:       "cloister-guardian",
+		Headers:       map[string]string{"Authorization": "Bearer secret"},
+		FlushInterval: time.Hour,
+	})
+
+	ctx, root := tr.Start(context.Background(), "proxy.connect", String("cloister.project", "api"))
+	_, child := tr.Start(ctx, "policy.check")
+	child.SetAttributes(String("cloister.decision", "denied"), Int("bytes", 42), Bool("cached", true))
+	child.RecordError(errors.New("blocked"))
+	child.End()
+	root.End()
+	root.End() // Ending twice exports once
+
+	if err := tr.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown() returned error: %v", err)
+	}
+
+	if len(c.requests) != 1 {
+		t.Fatalf("collector received %d requests, want 1", len(c.requests))
+	}
+	if c.paths[0] != "/v1/traces" || c.headers[0].Get("Authorization") != "Bearer secret" || c.headers[0].Get("Content-Type") != "application/json" {
+		t.Errorf("request = %s %v", c.paths[0], c.headers[0])
+	}
+	rs := c.requests[0].ResourceSpans[0]
+	if len(rs.Resource.Attributes) != 1 || *rs.Resource.Attributes[0].Value.StringValue != "cloister-guardian" {
+		t.Errorf("resource = %+v", rs.Resource)
+	}
+	if n := len(rs.ScopeSpans[0].Spans); n != 2 {
+		t.Fatalf("received %d spans, want 2", n)
+	}
+
+	spans := c.spans()
+	gotRoot, gotChild := spans["proxy.connect"], spans["policy.check"]
+	if gotRoot.TraceID != root.sc.Traceparent()[3:35] || gotRoot.ParentSpanID != "" || attr(gotRoot, "cloister.project") != "api" {
+		t.Errorf("root span = %+v", gotRoot)
+	}
+	if gotChild.TraceID != gotRoot.TraceID || gotChild.ParentSpanID != gotRoot.SpanID {
+		t.Errorf("child span %+v is not a child of %+v", gotChild, gotRoot)
+	}
+	if attr(gotChild, "cloister.decision") != "denied" || attr(gotChild, "bytes") != "42" || attr(gotChild, "cached") != "true" {
+		t.Errorf("child attributes = %+v", gotChild.Attributes)
+	}
+	if gotChild.Status == nil || gotChild.Status.Code != otlpStatusError || gotChild.Status.Message != "blocked" {
+		t.Errorf("child status = %+v", gotChild.Status)
+	}
+	if gotRoot.Status != nil {
+		t.Errorf("root status = %+v, want unset", gotRoot.Status)
+	}
+	if gotRoot.StartTimeUnixNano == "" || gotRoot.EndTimeUnixNano < gotRoot.StartTimeUnixNano {
+		t.Errorf("root times = %s..%s", gotRoot.StartTimeUnixNano, gotRoot.EndTimeUnixNano)
+	}
+}
+
+func TestTracer_ExportError(t *testing.T) {
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		http.Error(w, "nope", http.StatusUnauthorized)
+	}))
+	defer collector.Close()
+
+	tr := newTestTracer(t, collector.URL+"/v1/traces")
+	_, span := tr.Start(context.Background(), "work")
+	span.End()
+
+	err := tr.Flush(context.Background())
+	if err == nil || err.Error() != "export 1 span(s): collector returned 401 Unauthorized" {
+		t.Errorf("Flush() = %v", err)
+	}
+}
+
+func TestTracer_QueueLimit(t *testing.T) {
+	c := newCollector(t)
+	tr := newTestTracer(t, c.URL)
+	// Stop the background exporter so that full batches stay queued.
+	tr.stopOnce.Do(func() { close(tr.done) })
+	<-tr.stopped
+	for range maxQueuedSpans + 10 {
+		_, span := tr.Start(context.Background(), "work")
+		span.End()
+	}
+	if tr.dropped != 10 {
+		t.Errorf("dropped = %d, want 10", tr.dropped)
+	}
+
+	if err := tr.Flush(context.Background()); err != nil {
+		t.Fatalf("Flush() returned error: %v", err)
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.requests) != maxQueuedSpans/exportBatchSize {
+		t.Errorf("collector received %d requests, want %d", len(c.requests), maxQueuedSpans/exportBatchSize)
+	}
+}
@@ -0,0 +1,220 @@
+// Package tracing records spans of work across the guardian and the host
+// executor and exports them to an OpenTelemetry collector using OTLP over
+// HTTP. Trace context crosses process boundaries as a W3C traceparent
+// string.
+//
+// Tracing is off until SetTracer installs a Tracer. While it is off, Start
+// returns a nil *Span, and methods on a nil *Span do nothing, so callers
+// need no checks of their own.
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// TraceID identifies a trace.
+type TraceID [16]byte
+
+// SpanID identifies a span within a trace.
+type SpanID [8]byte
+
+// SpanContext is the part of a span that is passed to other processes.
+type SpanContext struct {
+	TraceID TraceID
+	SpanID  SpanID
+}
+
+// IsValid returns whether sc identifies a span.
+func (sc SpanContext) IsValid() bool {
+	return sc.TraceID != TraceID{} && sc.SpanID != SpanID{}
+}
+
+// Traceparent formats sc as a W3C traceparent value, or returns "" if sc is
+// not valid.
+func (sc SpanContext) Traceparent() string {
+	if !sc.IsValid() {
+		return ""
+	}
+	return "00-" + hex.EncodeToString(sc.TraceID[:]) + "-" + hex.EncodeToString(sc.SpanID[:]) + "-01"
+}
+
+// ParseTraceparent parses a W3C traceparent value.
+func ParseTraceparent(s string) (SpanContext, bool) {
+	parts := strings.Split(s, "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || parts[0] == "ff" || len(parts[3]) != 2 {
+		return SpanContext{}, false
+	}
+	if parts[0] == "00" && len(parts) != 4 {
+		return SpanContext{}, false
+	}
+	var sc SpanContext
+	if len(parts[1]) != 2*len(sc.TraceID) || len(parts[2]) != 2*len(sc.SpanID) {
+		return SpanContext{}, false
+	}
+	if _, err := hex.Decode(sc.TraceID[:], []byte(parts[1])); err != nil {
+		return SpanContext{}, false
+	}
+	if _, err := hex.Decode(sc.SpanID[:], []byte(parts[2])); err != nil {
+		return SpanContext{}, false
+	}
+	return sc, sc.IsValid()
+}
+
+// Attr is a span attribute. Value is a string, int64, float64 or bool.
+type Attr struct {
+	Key   string
+	Value any
+}
+
+// String returns a string attribute.
+func String(key, value string) Attr { return Attr{key, value} }
+
+// Int returns an integer attribute.
+func Int(key string, value int) Attr { return Attr{key, int64(value)} }
+
+// Int64 returns an integer attribute.
+func Int64(key string, value int64) Attr { return Attr{key, value} }
+
+// Bool returns a boolean attribute.
+func Bool(key string, value bool) Attr { return Attr{key, value} }
+
+// Span is a timed unit of work. It is safe for concurrent use.
+type Span struct {
+	tracer *Tracer
+	name   string
+	sc     SpanContext
+	parent SpanID
+	start  time.Time
+
+	mu     sync.Mutex
+	end    time.Time
+	attrs  []Attr
+	errMsg string // Set when the span failed
+	ended  bool
+}
+
+// SetAttributes adds attributes to the span, replacing any with the same
+// key.
+func (s *Span) SetAttributes(attrs ...Attr) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, a := range attrs {
+		replaced := false
+		for i := range s.attrs {
+			if s.attrs[i].Key == a.Key {
+				s.attrs[i], replaced = a, true
+				break
+			}
+		}
+		if !replaced {
+			s.attrs = append(s.attrs, a)
+		}
+	}
+}
+
+// RecordError marks the span as failed with err. A nil err does nothing.
+func (s *Span) RecordError(err error) {
+	if s == nil || err == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.errMsg = err.Error()
+}
+
+// End finishes the span and queues it for export. Later calls do nothing.
+func (s *Span) End() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended = true
+	s.end = s.tracer.now()
+	s.mu.Unlock()
+	s.tracer.finish(s)
+}
+
+// Context returns the span's SpanContext, or the zero SpanContext for a nil
+// span.
+func (s *Span) Context() SpanContext {
+	if s == nil {
+		return SpanContext{}
+	}
+	return s.sc
+}
+
+type spanKey struct{}
+
+type remoteKey struct{}
+
+// SpanFromContext returns the span in ctx, or nil.
+func SpanFromContext(ctx context.Context) *Span {
+	s, _ := ctx.Value(spanKey{}).(*Span)
+	return s
+}
+
+// ContextWithRemoteParent returns a context whose next span started is a
+// child of sc, a span in another process. An invalid sc is ignored.
+func ContextWithRemoteParent(ctx context.Context, sc SpanContext) context.Context {
+	if !sc.IsValid() {
+		return ctx
+	}
+	return context.WithValue(ctx, remoteKey{}, sc)
+}
+
+// Traceparent returns the traceparent of the span in ctx, for passing to
+// another process, or "" if there is none.
+func Traceparent(ctx context.Context) string {
+	return SpanFromContext(ctx).Context().Traceparent()
+}
+
+var global atomic.Pointer[Tracer]
+
+// SetTracer installs the tracer used by Start. A nil tracer turns tracing
+// off.
+func SetTracer(t *Tracer) {
+	global.Store(t)
+}
+
+// Start starts a span named name as a child of the span in ctx, or of a
+// remote parent set with ContextWithRemoteParent, or as the root of a new
+// trace. It returns a context holding the span. While tracing is off it
+// returns ctx and a nil span.
+func Start(ctx context.Context, name string, attrs ...Attr) (context.Context, *Span) {
+	t := global.Load()
+	if t == nil {
+		return ctx, nil
+	}
+	return t.Start(ctx, name, attrs...)
+}
+
+// Start starts a span with this tracer. See the package-level Start.
+func (t *Tracer) Start(ctx context.Context, name string, attrs ...Attr) (context.Context, *Span) {
+	s := &Span{tracer: t, name: name, start: t.now()}
+	switch {
+	case SpanFromContext(ctx) != nil:
+		parent := SpanFromContext(ctx).sc
+		s.sc.TraceID, s.parent = parent.TraceID, parent.SpanID
+	case ctx.Value(remoteKey{}) != nil:
+		parent, _ := ctx.Value(remoteKey{}).(SpanContext)
+		s.sc.TraceID, s.parent = parent.TraceID, parent.SpanID
+	default:
+		_, _ = rand.Read(s.sc.TraceID[:])
+	}
+	_, _ = rand.Read(s.sc.SpanID[:])
+	s.SetAttributes(attrs...)
+	return context.WithValue(ctx, spanKey{}, s), s
+}
@@ -0,0 +1,93 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestTraceparent_RoundTrip(t *testing.T) {
+	sc := SpanContext{
+		TraceID: TraceID{0x4b, 0xf9, 0x2f, 0x35, 0x77, 0xb3, 0x4d, 0xa6, 0xa3, 0xce, 0x92, 0x9d, 0x0e, 0x0e, 0x47, 0x36},
+		SpanID:  SpanID{0x00, 0xf0, 0x67, 0xaa, 0x0b, 0xa9, 0x02, 0xb7},
+	}
+	tp := sc.Traceparent()
+	if tp != "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01" {
+		t.Fatalf("Traceparent() = %q", tp)
+	}
+	got, ok := ParseTraceparent(tp)
+	if !ok || got != sc {
+		t.Errorf("ParseTraceparent(%q) = %v, %v", tp, got, ok)
+	}
+	if (SpanContext{}).Traceparent() != "" {
+		t.Error("zero SpanContext has a traceparent")
+	}
+}
+
+func TestParseTraceparent_Invalid(t *testing.T) {
+	for _, s := range []string{
+		"",
+		"garbage",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra",
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e473z-00f067aa0ba902b7-01",
+		"00-4bf92f35-00f067aa0ba902b7-01",
+	} {
+		if sc, ok := ParseTraceparent(s); ok {
+			t.Errorf("ParseTraceparent(%q) = %v, want invalid", s, sc)
+		}
+	}
+
+	// A later version may add fields.
+	if _, ok := ParseTraceparent("01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra"); !ok {
+		t.Error("ParseTraceparent() rejected a future version")
+	}
+}
+
+func TestStart_Disabled(t *testing.T) {
+	SetTracer(nil)
+	ctx := context.Background()
+	gotCtx, span := Start(ctx, "work")
+	if span != nil || gotCtx != ctx {
+		t.Fatalf("Start() while disabled = %v, %v", gotCtx, span)
+	}
+	// Every method is safe on a nil span.
+	span.SetAttributes(String("k", "v"))
+	span.RecordError(errors.New("boom"))
+	span.End()
+	if Traceparent(gotCtx) != "" {
+		t.Error("Traceparent() while disabled is not empty")
+	}
+}
+
+func TestStart_Parents(t *testing.T) {
+	tr := newTestTracer(t, "http://127.0.0.1:0")
+
+	ctx, root := tr.Start(context.Background(), "root")
+	_, child := tr.Start(ctx, "child")
+	if child.sc.TraceID != root.sc.TraceID || child.parent != root.sc.SpanID {
+		t.Errorf("child is not in the root's trace: %+v, root %+v", child.sc, root.sc)
+	}
+	if root.parent != (SpanID{}) || root.sc.SpanID == child.sc.SpanID {
+		t.Errorf("unexpected IDs: root %+v, child %+v", root, child)
+	}
+
+	sc, _ := ParseTraceparent(Traceparent(ctx))
+	_, remote := tr.Start(ContextWithRemoteParent(context.Background(), sc), "remote")
+	if remote.sc.TraceID != root.sc.TraceID || remote.parent != root.sc.SpanID {
+		t.Errorf("remote child is not in the root's trace: %+v", remote)
+	}
+}
+
+func TestSpan_SetAttributesReplaces(t *testing.T) {
+	tr := newTestTracer(t, "http://127.0.0.1:0")
+	_, span := tr.Start(context.Background(), "work", String("decision", "pending"), Int("n", 1))
+	span.SetAttributes(String("decision", "approved"))
+
+	if len(span.attrs) != 2 || span.attrs[0] != String("decision", "approved") {
+		t.Errorf("attrs = %v", span.attrs)
+	}
+}
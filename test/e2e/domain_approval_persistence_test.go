@@ -34,6 +34,17 @@ func approvalPort(t *testing.T) int {
 	return state.ApprovalPort
 }
 
+// setApprovalAuth authenticates a request to the approval server with the
+// approval secret from executor state.
+func setApprovalAuth(t *testing.T, req *http.Request) {
+	t.Helper()
+	state, err := executor.LoadDaemonState()
+	if err != nil {
+		t.Fatalf("Failed to load executor daemon state: %v", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+state.ApprovalSecret)
+}
+
 // pendingDomainJSON represents a single pending domain request from the API.
 type pendingDomainJSON struct {
 	ID        string `json:"id"`
@@ -63,6 +74,7 @@ func waitForPendingDomain(t *testing.T, port int, domain string) string {
 			time.Sleep(100 * time.Millisecond)
 			continue
 		}
+		setApprovalAuth(t, req)
 		resp, err := client.Do(req)
 		if err != nil {
 			time.Sleep(100 * time.Millisecond)
@@ -108,6 +120,7 @@ func approveDomain(t *testing.T, port int, requestID, scope string) {
 		t.Fatalf("Failed to create request: %v", err)
 	}
 	req.Header.Set("Content-Type", "application/json")
+	setApprovalAuth(t, req)
 	resp, err := client.Do(req)
 	if err != nil {
 		t.Fatalf("Failed to approve domain: %v", err)
@@ -130,6 +143,7 @@ func denyDomain(t *testing.T, port int, requestID, scope string, wildcard bool)
 		t.Fatalf("Failed to create request: %v", err)
 	}
 	req.Header.Set("Content-Type", "application/json")
+	setApprovalAuth(t, req)
 	resp, err := client.Do(req)
 	if err != nil {
 		t.Fatalf("Failed to deny domain: %v", err)
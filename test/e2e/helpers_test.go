@@ -78,7 +78,7 @@ func createAuthenticatedTestContainer(t *testing.T, suffix string) testContainer
 		t.Fatalf("Failed to register token: %v", err)
 	}
 	t.Cleanup(func() {
-		_ = guardian.RevokeToken(tok)
+		_, _ = guardian.RevokeToken(tok)
 		_ = store.Remove(containerName)
 		// Clean up project decisions file if it was created
 		_ = os.Remove(config.ProjectDecisionPath(project))
@@ -59,7 +59,7 @@ func TestNetworkIsolation_RequestServerReachable(t *testing.T) {
 		t.Fatalf("Failed to register token: %v", err)
 	}
 	t.Cleanup(func() {
-		_ = guardian.RevokeToken(testToken)
+		_, _ = guardian.RevokeToken(testToken)
 	})
 
 	// Wait for request server port to be ready